@@ -24,6 +24,7 @@ import (
 	"github.com/GTDGit/gtd_api/internal/handler"
 	"github.com/GTDGit/gtd_api/internal/middleware"
 	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/notify"
 	"github.com/GTDGit/gtd_api/internal/repository"
 	"github.com/GTDGit/gtd_api/internal/service"
 	"github.com/GTDGit/gtd_api/internal/sse"
@@ -87,6 +88,11 @@ func main() {
 
 	// 3c. Initialize inquiry cache
 	inquiryCache := cache.NewInquiryCache(redisClient)
+	var inquiryFallbackRepo *repository.InquiryFallbackRepository
+	if cfg.Redis.Degraded() {
+		inquiryFallbackRepo = repository.NewInquiryFallbackRepository(db)
+		inquiryCache.SetFallback(inquiryFallbackRepo)
+	}
 
 	// 4. Initialize Digiflazz clients (DISABLED - soft-deleted)
 	// digiProd := dfg.NewClient(cfg.Digiflazz.Username, cfg.Digiflazz.KeyProduction)
@@ -98,9 +104,14 @@ func main() {
 	productRepo := repository.NewProductRepository(db)
 	skuRepo := repository.NewSKURepository(db)
 	trxRepo := repository.NewTransactionRepository(db)
+	trxRepo.SetTransactionIDSequence(cfg.TransactionIDSeq)
 	cbRepo := repository.NewCallbackRepository(db)
 	bankCodeRepo := repository.NewBankCodeRepository(db)
 	ppobProviderRepo := repository.NewPPOBProviderRepository(db)
+	ppobProviderRepo.SetSelectionStrategy(cfg.PPOBRouting.SelectionStrategy)
+	if seed := cfg.PPOBRouting.TieBreakSeed; seed != nil {
+		ppobProviderRepo.SetTieBreakRotation(repository.NewRoundRobinTieBreak(*seed))
+	}
 	paymentRepo := repository.NewPaymentRepository(db)
 	reconRepo := repository.NewReconciliationRepository(db)
 
@@ -120,6 +131,19 @@ func main() {
 			log.Warn().Err(err).Msg("Alterra client initialization failed - provider will be disabled")
 		}
 	}
+	var alterraSandboxClient *alterra.Client
+	if cfg.Alterra.SandboxClientID != "" && (cfg.Alterra.SandboxPrivateKeyPath != "" || cfg.Alterra.SandboxPrivateKeyPEM != "") {
+		var err error
+		alterraSandboxClient, err = alterra.NewClient(alterra.Config{
+			BaseURL:        cfg.Alterra.SandboxBaseURL,
+			ClientID:       cfg.Alterra.SandboxClientID,
+			PrivateKeyPath: cfg.Alterra.SandboxPrivateKeyPath,
+			PrivateKeyPEM:  cfg.Alterra.SandboxPrivateKeyPEM,
+		})
+		if err != nil {
+			log.Warn().Err(err).Msg("Alterra sandbox client initialization failed - sandbox disabled for Alterra")
+		}
+	}
 
 	var bncClient *bnc.Client
 	if cfg.Disbursement.BNC.ClientID != "" &&
@@ -278,30 +302,80 @@ func main() {
 	// 6. Initialize services
 	authSvc := service.NewAuthService(clientRepo)
 	productSvc := service.NewProductService(productRepo, skuRepo)
+	productSvc.SetPriceRounding(cfg.PriceRounding)
 	callbackSvc := service.NewCallbackService(clientRepo, cbRepo, trxRepo)
 	// syncSvc disabled - Digiflazz sync no longer needed
 	_ = service.NewSyncService // keep import alive
 	trxSvc := service.NewTransactionService(trxRepo, productRepo, skuRepo, cbRepo, digiProd, digiDev, productSvc, callbackSvc, inquiryCache)
+	trxSvc.SetRequestTimeout(cfg.PPOBRouting.RequestTimeout)
+	trxSvc.SetSyncProviderAttempts(cfg.PPOBRouting.SyncProviderAttempts)
+	trxSvc.SetTransactionIDPrefix(cfg.TransactionIDPrefix)
+	trxSvc.SetPriceRounding(cfg.PriceRounding)
+	trxSvc.SetNoDigiflazzInquiryCategories(cfg.PPOBRouting.NoDigiflazzInquiry)
+	trxSvc.SetReinquireCategories(cfg.PPOBRouting.ReinquireCategories)
+	trxSvc.SetExactPaymentSKU(cfg.PPOBRouting.ExactPaymentSKU)
+	trxSvc.SetRequirePositivePrice(cfg.PPOBRouting.RequirePositivePrice)
+	trxSvc.SetReferenceIDMaxLength(cfg.PPOBRouting.ReferenceIDMaxLength)
+	trxSvc.SetSandboxRouting(cfg.PPOBRouting.SandboxRouting)
+	trxSvc.SetInquiryCategoryTimeouts(cfg.PPOBRouting.InquiryCategoryTimeouts)
+	trxSvc.SetInquiryCategoryAttempts(cfg.PPOBRouting.InquiryCategoryAttempts)
+	trxSvc.SetSKUAutoDisable(service.NewSKUAutoDisable(skuRepo, cfg.PPOBRouting.SKUAutoDisableFailures, cfg.PPOBRouting.SKUAutoDisableWindow, cfg.PPOBRouting.SKUAutoDisableCooldown))
+	trxSvc.SetCompletionEstimator(service.NewCompletionEstimator(ppobProviderRepo,
+		cfg.Worker.StatusCheckInterval, cfg.Worker.StatusCheckStaleAfter,
+		map[string]time.Duration{string(models.ProviderKiosbank): cfg.Kiosbank.StatusCheckMinAge}))
+	trxSvc.SetCustomerNoHashSalt(cfg.Privacy.CustomerNoHashSalt)
+	if cfg.Privacy.CustomerNoHashSalt == "" {
+		log.Warn().Msg("CUSTOMER_NO_HASH_SALT is empty; customer number hashing is disabled for all clients")
+	}
 
 	// Wire up callback service to transaction service for immediate retry on webhook
 	callbackSvc.SetTransactionRetrier(trxSvc)
+	callbackSvc.SetSerialNumberCheck(cfg.PPOBRouting.SerialNumberCheck)
+	callbackSvc.SetSerialNumberScope(cfg.PPOBRouting.SerialNumberWindow, cfg.PPOBRouting.SerialNumberIgnoreCategories)
+	callbackSvc.SetCallbackLookupRetry(cfg.Digiflazz.CallbackLookupRetries, cfg.Digiflazz.CallbackLookupInterval)
+	callbackSvc.SetCallbackBatchWait(cfg.Worker.CallbackBatchWait)
+	callbackSvc.SetMaxInFlightCallbacks(cfg.Worker.CallbackMaxInFlight)
+	callbackSvc.SetCallbackLockStore(redisClient)
+
+	// Ops alert routing (Slack/email/webhook); a no-op unless OPS_NOTIFY_ENABLED.
+	opsNotifier, err := buildOpsNotifier(cfg.OpsNotify)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid ops notification config")
+	}
+	callbackSvc.SetAlertNotifier(opsNotifier)
 
 	// Initialize Redis-publishing SSE notifier. Admin now lives in the Gateway
 	// process; the API publishes domain events to Redis and the Gateway fans
 	// them out to admin SSE clients.
 	sseNotifier := sse.NewRedisPublishNotifier(redisClient.Raw())
+	sseNotifier.SetAvailability(redisClient.Available)
 	trxSvc.SetNotifier(sseNotifier)
 	callbackSvc.SetNotifier(sseNotifier)
 
 	// Initialize Provider Router for multi-provider PPOB
 	providerRouter := service.NewProviderRouter(ppobProviderRepo)
+	providerRouter.SetAlertNotifier(opsNotifier)
+	providerRouter.SetAmbiguousAsPending(cfg.PPOBRouting.AmbiguousAsPending)
+	pendingPollProviders := make([]models.ProviderCode, 0, len(cfg.PPOBRouting.PendingPollProviders))
+	for _, code := range cfg.PPOBRouting.PendingPollProviders {
+		pendingPollProviders = append(pendingPollProviders, models.ProviderCode(code))
+	}
+	providerRouter.SetPendingPoll(pendingPollProviders, cfg.PPOBRouting.PendingPollAttempts, cfg.PPOBRouting.PendingPollInterval)
+	var healthBuffer *service.ProviderHealthBuffer
+	if cfg.PPOBRouting.HealthFlushInterval > 0 {
+		healthBuffer = service.NewProviderHealthBuffer(ppobProviderRepo, cfg.PPOBRouting.HealthFlushInterval)
+		providerRouter.SetHealthBuffer(healthBuffer)
+	}
+	if cfg.PPOBRouting.WeightedTies {
+		providerRouter.SetTieBalancer(service.NewTieBalancer(redisClient, ppobProviderRepo))
+	}
 	if kioskbankProdClient != nil {
 		kiosbankAdapter := service.NewKiosbankProviderClient(kioskbankProdClient, kioskbankDevClient, trxRepo, cbRepo, ppobProviderRepo)
 		providerRouter.RegisterProvider(models.ProviderKiosbank, kiosbankAdapter)
 		log.Info().Msg("Kiosbank provider registered")
 	}
 	if alterraClient != nil {
-		alterraAdapter := service.NewAlterraProviderClient(alterraClient, alterraClient) // Same client for prod/dev
+		alterraAdapter := service.NewAlterraProviderClient(alterraClient, alterraSandboxClient)
 		providerRouter.RegisterProvider(models.ProviderAlterra, alterraAdapter)
 		log.Info().Msg("Alterra provider registered")
 	}
@@ -321,6 +395,10 @@ func main() {
 
 	// Update product service with provider-aware version for best price
 	productSvc = service.NewProductServiceWithProviders(productRepo, skuRepo, ppobProviderRepo)
+	productSvc.SetProviderRouter(providerRouter)
+	productSvc.SetPriceRounding(cfg.PriceRounding)
+	productSvc.SetSandboxRouting(cfg.PPOBRouting.SandboxRouting)
+	productSvc.SetReferenceIDMaxLength(cfg.PPOBRouting.ReferenceIDMaxLength)
 
 	// Initialize provider callback service
 	providerCallbackSvc := service.NewProviderCallbackService(ppobProviderRepo, trxRepo, callbackSvc)
@@ -442,6 +520,13 @@ func main() {
 	paymentSvc := service.NewPaymentService(paymentRepo, clientRepo, reconRepo, paymentRouter, paymentCallbackSvc)
 	paymentSvc.SetNotifier(sseNotifier)
 	adminPaymentSvc := service.NewAdminPaymentService(paymentRepo, paymentRouter)
+	adminPPOBSvc := service.NewAdminPPOBService(trxRepo, productRepo, skuRepo, ppobProviderRepo, trxSvc, inquiryCache)
+	adminPPOBSvc.SetSerialNumberIgnoreCategories(cfg.PPOBRouting.SerialNumberIgnoreCategories)
+	priceListGuard := service.PriceListGuard{MaxShrinkPercent: cfg.Worker.SyncMaxShrinkPercent, Alerts: opsNotifier}
+	adminPPOBSvc.SetPriceListGuard(priceListGuard)
+	adminClientSvc := service.NewAdminClientService(clientRepo)
+	adminAuditSvc := service.NewAdminAuditService(repository.NewAdminAuditRepository(db))
+	readOnlySvc := service.NewReadOnlyService(repository.NewSystemFlagRepository(db), cfg.ReadOnly, cfg.ReadOnlyRefresh)
 
 	// Static QRIS merchant wiring (shared DB; gateway owns CRUD, api owns provider
 	// calls + inbound webhooks). Merchant lookup keys on (provider, store_id).
@@ -544,12 +629,16 @@ func main() {
 	xenditWebhookToken := cfg.Payment.Xendit.WebhookToken
 
 	// 7. Initialize handlers
+	healthHandler := handler.NewHealthHandler(digiProd, ppobProviderRepo)
+	healthHandler.SetReadOnlyChecker(readOnlySvc)
+	healthHandler.SetCallbackQueue(callbackSvc)
 	handlers := &Handlers{
-		Health:           handler.NewHealthHandler(digiProd),
+		Health:           healthHandler,
 		Product:          handler.NewProductHandler(productSvc),
 		Balance:          handler.NewBalanceHandler(digiProd),
 		Transaction:      handler.NewTransactionHandler(trxSvc, productSvc),
 		Webhook:          handler.NewWebhookHandler(callbackSvc, cfg.Digiflazz.WebhookSecret),
+		Callback:         handler.NewCallbackHandler(callbackSvc),
 		BankCode:         handler.NewBankCodeHandler(bankCodeRepo),
 		Transfer:         handler.NewPayoutHandler(payoutSvc),
 		BNCConnector:     handler.NewBNCConnectorHandler(bncConnectorSvc),
@@ -557,6 +646,10 @@ func main() {
 		ProviderCallback: handler.NewProviderCallbackHandler(providerCallbackSvc, cfg.Alterra.CallbackPublicKey),
 		Payment:          handler.NewPaymentHandler(paymentSvc),
 		AdminPayment:     handler.NewAdminPaymentHandler(adminPaymentSvc),
+		AdminPPOB:        handler.NewAdminPPOBHandler(adminPPOBSvc),
+		AdminClient:      handler.NewAdminClientHandler(adminClientSvc),
+		AdminAudit:       handler.NewAdminAuditHandler(adminAuditSvc),
+		AdminSystem:      handler.NewAdminSystemHandler(readOnlySvc),
 		PaymentWebhook: handler.NewPaymentWebhookHandler(
 			paymentRepo,
 			paymentSvc,
@@ -587,7 +680,46 @@ func main() {
 	router.Use(gin.Recovery())
 	router.Use(middleware.CORSMiddleware())
 	router.Use(middleware.LoggingMiddleware())
-	setupRoutes(router, handlers, authMw)
+	// Read-only mode rejects writes everywhere except the toggle that lifts
+	// it, the side-effect-free signature helper and the provider webhooks and
+	// connector endpoints: providers report outcomes of work already under
+	// way, and a rejected callback may never be redelivered.
+	router.Use(middleware.ReadOnly(readOnlySvc,
+		"PUT /v1/admin/system/read-only",
+		"POST /v1/ppob/verify-signature",
+		"POST /v1/webhook/digiflazz",
+		"POST /v1/webhook/kiosbank",
+		"POST /v1/webhook/alterra",
+		"POST /v1/webhook/:provider",
+		"POST /bnc/v1.0/access-token/b2b",
+		"POST /bnc/v1.0/transfer/notify",
+		"POST /snap/v1.0/access-token/b2b",
+		"POST /snap/v1.0/transfer-va/notify-payment-intrabank",
+		"POST /v1/webhook/pakailink",
+		"POST /v1/webhook/dana",
+		"POST /v1/webhook/midtrans",
+		"POST /v1/webhook/xendit",
+		"POST /v1/webhook/ovo",
+		"POST /v1/webhook/pakailink-disbursement",
+		"POST /v1/webhook/dana-disbursement",
+		"POST /nobu/v1.0/access-token/b2b",
+		"POST /nobu/v1.0/qr/qr-mpm-notify",
+	))
+	clientLimits := setupRoutes(router, handlers, authMw, middleware.AdminAudit(adminAuditSvc), cfg.RequestTimeouts)
+	adminClientSvc.SetClientDefaults(service.ClientConfigDefaults{
+		TransactionIDPrefix:    cfg.TransactionIDPrefix,
+		PriceRounding:          cfg.PriceRounding,
+		ReferenceIDMaxLength:   cfg.PPOBRouting.ReferenceIDMaxLength,
+		SandboxRouting:         cfg.PPOBRouting.SandboxRouting,
+		CustomerNoRawRetention: cfg.Privacy.CustomerNoRawRetention,
+		RequestTimeouts: map[string]time.Duration{
+			"ppob":    cfg.RequestTimeouts.PPOB,
+			"payout":  cfg.RequestTimeouts.Payout,
+			"payment": cfg.RequestTimeouts.Payment,
+			"qris":    cfg.RequestTimeouts.QRIS,
+		},
+		RateLimits: clientLimits,
+	})
 
 	// 10. Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -596,47 +728,97 @@ func main() {
 	// 11. Start workers
 	// Digiflazz sync worker disabled - no longer syncing from Digiflazz
 	// go worker.NewSyncWorker(syncSvc, cfg.Worker.SyncInterval).Start(ctx)
-	go worker.NewRetryWorker(trxRepo, callbackSvc, cfg.Worker.RetryInterval).Start(ctx)
-	go worker.NewCallbackWorker(callbackSvc, cfg.Worker.CallbackInterval).Start(ctx)
+	retryWorker := worker.NewRetryWorker(trxRepo, callbackSvc, cfg.Worker.RetryInterval)
+	retryWorker.SetPauser(readOnlySvc)
+	// Always on: besides the sync attempt limit and request timeout, a
+	// provider's Retry-After hint defers transactions to next_retry_at.
+	retryWorker.SetProviderContinuer(trxSvc, cfg.Worker.ProviderContinueInterval)
+	go retryWorker.Start(ctx)
+	callbackWorker := worker.NewCallbackWorker(callbackSvc, cfg.Worker.CallbackInterval)
+	callbackWorker.SetPauser(readOnlySvc)
+	go callbackWorker.Start(ctx)
+	if healthBuffer != nil {
+		go healthBuffer.Start(ctx)
+	}
+	providerCallbackWorker := worker.NewProviderCallbackRetryWorker(providerCallbackSvc, cfg.Worker.ProviderCallbackInterval, 50)
+	providerCallbackWorker.SetPauser(readOnlySvc)
+	go providerCallbackWorker.Start(ctx)
+	customerNoMaskWorker := worker.NewCustomerNoMaskWorker(trxRepo, cfg.Privacy.CustomerNoRawRetention, cfg.Privacy.CustomerNoMaskInterval, 500)
+	customerNoMaskWorker.SetPauser(readOnlySvc)
+	go customerNoMaskWorker.Start(ctx)
 	// Digiflazz callback worker disabled
 	// go worker.NewDigiflazzCallbackWorker(cbRepo, trxRepo, trxSvc, callbackSvc, cfg.Worker.DigiflazzCallbackInterval).Start(ctx)
-	go worker.NewStatusCheckWorker(
+	statusCheckWorker := worker.NewStatusCheckWorker(
 		trxRepo, skuRepo, callbackSvc, digiProd, digiDev, providerRouter, trxSvc,
 		cfg.Worker.StatusCheckInterval,
 		cfg.Worker.StatusCheckStaleAfter,
 		cfg.Worker.StatusCheckMaxAge,
 		cfg.Kiosbank.StatusCheckMinAge,
 		cfg.Kiosbank.StatusCheckMaxAge,
-	).Start(ctx)
-	go worker.NewPayoutStatusWorker(
+	)
+	statusCheckWorker.SetPauser(readOnlySvc)
+	if cfg.Worker.StartupReconcile {
+		statusCheckWorker.Reconcile(ctx, cfg.Worker.StartupReconcileTimeout)
+	}
+	go statusCheckWorker.Start(ctx)
+	payoutStatusWorker := worker.NewPayoutStatusWorker(
 		payoutSvc,
 		cfg.Worker.StatusCheckInterval,
 		cfg.Worker.StatusCheckStaleAfter,
 		cfg.Worker.StatusCheckMaxAge,
 		50,
-	).Start(ctx)
+	)
+	payoutStatusWorker.SetPauser(readOnlySvc)
+	go payoutStatusWorker.Start(ctx)
 
 	// Start provider price sync worker
 	providerClients := providerRouter.GetClients()
-	go worker.NewProviderSyncWorker(ppobProviderRepo, providerClients, cfg.Worker.SyncInterval).Start(ctx)
+	providerSyncWorker := worker.NewProviderSyncWorker(ppobProviderRepo, providerClients, cfg.Worker.SyncInterval)
+	providerSyncWorker.SetPriceListGuard(priceListGuard)
+	providerSyncWorker.SetPauser(readOnlySvc)
+	go providerSyncWorker.Start(ctx)
+	balanceWorker := worker.NewProviderBalanceWorker(ppobProviderRepo, providerClients, cfg.Worker.BalanceCheckInterval)
+	balanceWorker.SetHistoryInterval(cfg.Worker.BalanceHistoryInterval)
+	balanceWorker.SetHistoryRetention(cfg.Worker.BalanceHistoryRetention)
+	balanceWorker.SetHistoryLock(redisClient)
+	balanceWorker.SetAlertNotifier(opsNotifier)
+	balanceWorker.SetPauser(readOnlySvc)
+	go balanceWorker.Start(ctx)
+	redisMonitor := worker.NewRedisMonitorWorker(redisClient, cfg.Redis.MonitorInterval, cfg.Redis.Degraded())
+	redisMonitor.SetAlertNotifier(opsNotifier)
+	redisMonitor.SetPauser(readOnlySvc)
+	if inquiryFallbackRepo != nil {
+		redisMonitor.SetInquiryFallback(inquiryFallbackRepo)
+	}
+	go redisMonitor.Start(ctx)
 
 	// Payment module workers
-	go worker.NewPaymentStatusWorker(
+	paymentStatusWorker := worker.NewPaymentStatusWorker(
 		paymentSvc,
 		cfg.Worker.PaymentStatusInterval,
 		cfg.Worker.PaymentStatusStaleAfter,
 		50,
-	).Start(ctx)
-	go worker.NewPaymentExpiryWorker(paymentSvc, cfg.Worker.PaymentExpiryInterval, 100).Start(ctx)
-	go worker.NewPaymentCallbackWorker(paymentCallbackSvc, cfg.Worker.PaymentCallbackInterval, 50).Start(ctx)
+	)
+	paymentStatusWorker.SetPauser(readOnlySvc)
+	go paymentStatusWorker.Start(ctx)
+	paymentExpiryWorker := worker.NewPaymentExpiryWorker(paymentSvc, cfg.Worker.PaymentExpiryInterval, 100)
+	paymentExpiryWorker.SetPauser(readOnlySvc)
+	go paymentExpiryWorker.Start(ctx)
+	paymentCallbackWorker := worker.NewPaymentCallbackWorker(paymentCallbackSvc, cfg.Worker.PaymentCallbackInterval, 50)
+	paymentCallbackWorker.SetPauser(readOnlySvc)
+	go paymentCallbackWorker.Start(ctx)
 
 	// QRIS Nobu Excel batch worker (renders pending registrations at WIB slots).
 	if qrisBatchSvc != nil {
-		go worker.NewQRISBatchWorker(qrisBatchSvc, cfg.QRIS.BatchTimes, cfg.QRIS.BatchTimezone).Start(ctx)
+		qrisBatchWorker := worker.NewQRISBatchWorker(qrisBatchSvc, cfg.QRIS.BatchTimes, cfg.QRIS.BatchTimezone)
+		qrisBatchWorker.SetPauser(readOnlySvc)
+		go qrisBatchWorker.Start(ctx)
 	}
 
 	// QRIS outbound client webhook retry worker (merchant.activated, payment.success).
-	go worker.NewQRISCallbackWorker(qrisCallbackSvc, cfg.QRIS.CallbackInterval, 50).Start(ctx)
+	qrisCallbackWorker := worker.NewQRISCallbackWorker(qrisCallbackSvc, cfg.QRIS.CallbackInterval, 50)
+	qrisCallbackWorker.SetPauser(readOnlySvc)
+	go qrisCallbackWorker.Start(ctx)
 
 	// 12. Start HTTP server
 	srv := &http.Server{
@@ -667,6 +849,10 @@ func main() {
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		log.Error().Err(err).Msg("Server forced to shutdown")
 	}
+	// Persist provider health recorded by requests drained during shutdown.
+	if healthBuffer != nil {
+		healthBuffer.Flush()
+	}
 	log.Info().Msg("Server exited")
 }
 
@@ -677,6 +863,7 @@ type Handlers struct {
 	Balance             *handler.BalanceHandler
 	Transaction         *handler.TransactionHandler
 	Webhook             *handler.WebhookHandler
+	Callback            *handler.CallbackHandler
 	BankCode            *handler.BankCodeHandler
 	Transfer            *handler.PayoutHandler
 	BNCConnector        *handler.BNCConnectorHandler
@@ -684,18 +871,24 @@ type Handlers struct {
 	ProviderCallback    *handler.ProviderCallbackHandler
 	Payment             *handler.PaymentHandler
 	AdminPayment        *handler.AdminPaymentHandler
+	AdminPPOB           *handler.AdminPPOBHandler
+	AdminClient         *handler.AdminClientHandler
+	AdminAudit          *handler.AdminAuditHandler
+	AdminSystem         *handler.AdminSystemHandler
 	PaymentWebhook      *handler.PaymentWebhookHandler
 	DisbursementWebhook *handler.DisbursementWebhookHandler
 	NobuConnector       *handler.NobuConnectorHandler
 	QRIS                *handler.QRISHandler
 }
 
-// setupRoutes registers all routes.
-func setupRoutes(router *gin.Engine, handlers *Handlers, authMiddleware *middleware.AuthMiddleware) {
+// setupRoutes registers all routes and returns the per-client rate limits it
+// installed.
+func setupRoutes(router *gin.Engine, handlers *Handlers, authMiddleware *middleware.AuthMiddleware, adminAudit gin.HandlerFunc, timeouts config.RequestTimeoutConfig) []service.ClientRouteLimit {
 	// Provider webhook endpoints
 	router.POST("/v1/webhook/digiflazz", handlers.Webhook.HandleDigiflazzCallback)
 	router.POST("/v1/webhook/kiosbank", handlers.ProviderCallback.HandleKiosbankCallback)
 	router.POST("/v1/webhook/alterra", handlers.ProviderCallback.HandleAlterraCallback)
+	router.POST("/v1/webhook/:provider", handlers.ProviderCallback.HandleGenericCallback)
 	router.POST("/bnc/v1.0/access-token/b2b", handlers.BNCConnector.CreateAccessToken)
 	router.POST("/bnc/v1.0/transfer/notify", handlers.BNCConnector.HandleTransferNotify)
 	router.POST("/snap/v1.0/access-token/b2b", handlers.BRIConnector.CreateAccessToken)
@@ -720,20 +913,45 @@ func setupRoutes(router *gin.Engine, handlers *Handlers, authMiddleware *middlew
 
 	router.GET("/v1/health", handlers.Health.GetHealth)
 	// API PPOB routes (protected with client API key + ppob scope)
+	// Developer helper; limited per client so it can't be used to brute-force.
+	verifySignatureLimiter := middleware.NewClientRateLimiter(30, time.Minute)
+	// Each resend calls the client's endpoint; the service also spaces resends
+	// of one transaction a minute apart.
+	resendCallbackLimiter := middleware.NewClientRateLimiter(10, time.Minute)
+	routeLimit := func(route string, l *middleware.ClientRateLimiter) service.ClientRouteLimit {
+		n, window := l.Limit()
+		return service.ClientRouteLimit{Route: route, Limit: n, Window: window.String()}
+	}
+	clientLimits := []service.ClientRouteLimit{
+		routeLimit("POST /v1/ppob/verify-signature", verifySignatureLimiter),
+		routeLimit("POST /v1/ppob/transaction/:transactionId/resend-callback", resendCallbackLimiter),
+	}
+
 	ppob := router.Group("/v1/ppob")
-	ppob.Use(authMiddleware.Handle(), middleware.RequireScope(middleware.ScopePPOB))
+	ppob.Use(middleware.Timeout(timeouts.PPOB), authMiddleware.Handle(), middleware.RequireScope(middleware.ScopePPOB))
 	{
 		ppob.GET("/products", handlers.Product.GetProducts)
+		ppob.GET("/products/:skuCode/availability", handlers.Product.GetAvailability)
+		ppob.GET("/products/:skuCode/providers", handlers.Product.GetProviders)
+		ppob.GET("/products/:skuCode/schema", handlers.Product.GetSchema)
+		ppob.GET("/categories", handlers.Product.GetCategories)
+		ppob.GET("/brands", handlers.Product.GetBrands)
 		ppob.GET("/balance", handlers.Balance.GetBalance)
 		ppob.POST("/transaction", handlers.Transaction.CreateTransaction)
 		ppob.GET("/transaction/:transactionId", handlers.Transaction.GetTransaction)
+		ppob.POST("/transaction/:transactionId/resend-callback", resendCallbackLimiter.Handle(), handlers.Callback.ResendCallback)
+		ppob.GET("/stats/by-sku", handlers.Transaction.GetSKUStats)
+		ppob.GET("/statuses", handlers.Transaction.ListStatuses)
+		ppob.GET("/callbacks", handlers.Callback.ListCallbacks)
+		ppob.POST("/callbacks/:id/ack", handlers.Callback.AckCallback)
+		ppob.POST("/verify-signature", verifySignatureLimiter.Handle(), handlers.Callback.VerifySignature)
 	}
 
 	// Bank codes (protected with client API key + disbursement scope)
 	router.GET("/v1/bank-codes", authMiddleware.Handle(), middleware.RequireScope(middleware.ScopeDisbursement), handlers.BankCode.GetBankCodes)
 
 	payout := router.Group("/v1/payout")
-	payout.Use(authMiddleware.Handle(), middleware.RequireScope(middleware.ScopeDisbursement))
+	payout.Use(middleware.Timeout(timeouts.Payout), authMiddleware.Handle(), middleware.RequireScope(middleware.ScopeDisbursement))
 	{
 		payout.POST("/inquiry", handlers.Transfer.CreateInquiry)
 		payout.GET("/methods", handlers.Transfer.ListMethods)
@@ -743,7 +961,7 @@ func setupRoutes(router *gin.Engine, handlers *Handlers, authMiddleware *middlew
 
 	// Payment client API (protected with client API key + payment scope).
 	payment := router.Group("/v1/payment")
-	payment.Use(authMiddleware.Handle(), middleware.RequireScope(middleware.ScopePayment))
+	payment.Use(middleware.Timeout(timeouts.Payment), authMiddleware.Handle(), middleware.RequireScope(middleware.ScopePayment))
 	{
 		payment.GET("/methods", handlers.Payment.ListMethods)
 		payment.POST("/create", handlers.Payment.CreatePayment)
@@ -754,7 +972,7 @@ func setupRoutes(router *gin.Engine, handlers *Handlers, authMiddleware *middlew
 	// Static QRIS client API (protected with client API key + qris scope).
 	// Registration is Excel-batch onboarding to Nobu; merchants activate later.
 	qris := router.Group("/v1/qris")
-	qris.Use(authMiddleware.Handle(), middleware.RequireScope(middleware.ScopeQRIS))
+	qris.Use(middleware.Timeout(timeouts.QRIS), authMiddleware.Handle(), middleware.RequireScope(middleware.ScopeQRIS))
 	{
 		qris.POST("/merchants", handlers.QRIS.CreateMerchant)
 		qris.GET("/merchants", handlers.QRIS.ListMerchants)
@@ -763,10 +981,11 @@ func setupRoutes(router *gin.Engine, handlers *Handlers, authMiddleware *middlew
 	}
 
 	// Admin API (protected with admin JWT). Manages canonical payment methods
-	// and their method-provider mappings.
+	// and their method-provider mappings. Every mutating request is written to
+	// the admin audit log.
 	jwtMw := middleware.NewJWTMiddleware()
 	admin := router.Group("/v1/admin")
-	admin.Use(jwtMw.Handle())
+	admin.Use(middleware.Timeout(timeouts.Admin), jwtMw.Handle(), adminAudit)
 	{
 		// Payment method admin. The first dynamic segment shares the wildcard
 		// name ":method" across routes because gin forbids differently-named
@@ -787,7 +1006,86 @@ func setupRoutes(router *gin.Engine, handlers *Handlers, authMiddleware *middlew
 		admin.GET("/qris/batches", handlers.QRIS.AdminListBatches)
 		admin.GET("/qris/batches/:id/download", handlers.QRIS.AdminDownloadBatch)
 		admin.POST("/qris/batches/:id/sent", handlers.QRIS.AdminMarkBatchSent)
+
+		// PPOB provider/transaction admin.
+		admin.GET("/ppob/providers", handlers.AdminPPOB.ListProviders)
+		admin.GET("/ppob/providers/usage-share", handlers.AdminPPOB.GetProviderUsageShare)
+		admin.GET("/ppob/providers/balance-history", handlers.AdminPPOB.GetProviderBalanceTrend)
+		admin.POST("/ppob/providers/:id/sync", handlers.AdminPPOB.SyncProvider)
+		admin.POST("/ppob/providers/:id/selftest", handlers.AdminPPOB.SelfTestProvider)
+		admin.PUT("/ppob/providers/:id/cut-off", handlers.AdminPPOB.UpdateProviderCutOff)
+		admin.GET("/ppob/providers/:id/skus/reverse", handlers.AdminPPOB.ReverseProviderSKU)
+		admin.GET("/ppob/providers/:id/skus/export", handlers.AdminPPOB.ExportProviderSKUs)
+		admin.GET("/ppob/reports/duplicate-serial-numbers", handlers.AdminPPOB.ListSerialNumberDuplicates)
+		admin.POST("/ppob/transactions/:transactionId/retry-sku", handlers.AdminPPOB.RetryTransactionWithSKU)
+		admin.GET("/ppob/inquiry/:transactionId", handlers.AdminPPOB.InspectInquiryCache)
+		admin.PUT("/ppob/products/:id/customer-no-rules", handlers.AdminPPOB.UpdateCustomerNoRules)
+		admin.PUT("/ppob/products/:id/selection-strategy", handlers.AdminPPOB.UpdateSelectionStrategy)
+		admin.PUT("/ppob/products/:id/duplicate-serial-hold", handlers.AdminPPOB.UpdateDuplicateSerialHold)
+		admin.POST("/ppob/products/:id/simulate", handlers.AdminPPOB.SimulateRouting)
+		admin.GET("/ppob/providers/maintenance-windows", handlers.AdminPPOB.ListMaintenanceWindows)
+		admin.POST("/ppob/providers/maintenance-windows", handlers.AdminPPOB.CreateMaintenanceWindow)
+		admin.PUT("/ppob/providers/maintenance-windows/:id", handlers.AdminPPOB.UpdateMaintenanceWindow)
+		admin.DELETE("/ppob/providers/maintenance-windows/:id", handlers.AdminPPOB.DeleteMaintenanceWindow)
+		admin.GET("/ppob/provider-callbacks/failed", handlers.AdminPPOB.ListFailedProviderCallbacks)
+		admin.GET("/ppob/skus/:id/rc-overrides", handlers.AdminPPOB.ListSKURCOverrides)
+		admin.PUT("/ppob/skus/:id/rc-overrides/:rc", handlers.AdminPPOB.SetSKURCOverride)
+		admin.DELETE("/ppob/skus/:id/rc-overrides/:rc", handlers.AdminPPOB.DeleteSKURCOverride)
+
+		// Dispute refunds recorded against a transaction.
+		admin.POST("/transactions/:transactionId/refund", handlers.AdminPPOB.RefundTransaction)
+		admin.GET("/transactions/:transactionId/refunds", handlers.AdminPPOB.ListTransactionRefunds)
+
+		// Internal investigation notes on a transaction.
+		admin.POST("/transactions/:transactionId/notes", handlers.AdminPPOB.AddTransactionNote)
+		admin.GET("/transactions/:transactionId/notes", handlers.AdminPPOB.ListTransactionNotes)
+		admin.GET("/transactions/:transactionId/provider-response", handlers.AdminPPOB.GetTransactionProviderResponse)
+
+		// Transactions held for review (duplicate serial number on a product with the hold on).
+		admin.POST("/transactions/:transactionId/review", handlers.AdminPPOB.ReviewHeldTransaction)
+
+		// API client list with usage indicators.
+		admin.GET("/clients", handlers.AdminClient.ListClients)
+		admin.GET("/clients/:id/effective-config", handlers.AdminClient.GetEffectiveConfig)
+
+		// Append-only trail of admin actions (secrets redacted).
+		admin.GET("/audit-log", handlers.AdminAudit.ListAuditLog)
+
+		// Global read-only mode: reads keep working, writes get 503 and
+		// workers pause until it is lifted.
+		admin.GET("/system/read-only", handlers.AdminSystem.GetReadOnly)
+		admin.PUT("/system/read-only", handlers.AdminSystem.SetReadOnly)
+	}
+	return clientLimits
+}
+
+// buildOpsNotifier builds the ops alert dispatcher from cfg, or a no-op
+// notifier when ops notifications are disabled.
+func buildOpsNotifier(cfg config.OpsNotifyConfig) (notify.Notifier, error) {
+	if !cfg.Enabled {
+		return notify.Nop{}, nil
+	}
+	routes, err := notify.ParseRoutes(cfg.Routes)
+	if err != nil {
+		return nil, fmt.Errorf("OPS_NOTIFY_ROUTES: %w", err)
+	}
+	channels := make(map[string]notify.Channel)
+	if cfg.SlackWebhookURL != "" {
+		channels[notify.ChannelSlack] = &notify.SlackChannel{URL: cfg.SlackWebhookURL}
+	}
+	if cfg.WebhookURL != "" {
+		channels[notify.ChannelWebhook] = &notify.WebhookChannel{URL: cfg.WebhookURL, Secret: cfg.WebhookSecret}
+	}
+	if cfg.EmailSMTPAddr != "" && cfg.EmailFrom != "" && len(cfg.EmailTo) > 0 {
+		channels[notify.ChannelEmail] = &notify.EmailChannel{
+			Addr:     cfg.EmailSMTPAddr,
+			Username: cfg.EmailUsername,
+			Password: cfg.EmailPassword,
+			From:     cfg.EmailFrom,
+			To:       cfg.EmailTo,
+		}
 	}
+	return notify.NewDispatcher(channels, routes)
 }
 
 func buildKiosbankClients(cfg config.KiosbankConfig) (*kiosbank.Client, *kiosbank.Client) {
@@ -0,0 +1,61 @@
+// Command backfill-provider-code fills transactions.provider_code from
+// ppob_providers for historical rows that only carry provider_id. New and
+// updated transactions write the column themselves; this is a one-off run
+// after migration 000082.
+//
+//	go run ./cmd/backfill-provider-code -batch 5000
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/config"
+	"github.com/GTDGit/gtd_api/internal/database"
+	"github.com/GTDGit/gtd_api/internal/repository"
+)
+
+func main() {
+	batch := flag.Int("batch", 5000, "rows updated per statement")
+	pause := flag.Duration("pause", 100*time.Millisecond, "sleep between batches")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	db, err := database.Connect(&cfg.DB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "database connection failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	trxRepo := repository.NewTransactionRepository(db)
+
+	// Walk ids upwards until a batch comes back empty; a batch that fills no
+	// rows (providers gone) does not end the run.
+	var total int64
+	lastID := 0
+	for {
+		next, n, err := trxRepo.BackfillProviderCode(lastID, *batch)
+		if err != nil {
+			log.Error().Err(err).Int("after_id", lastID).Int64("updated", total).Msg("provider_code backfill failed")
+			os.Exit(1)
+		}
+		if next == 0 {
+			break
+		}
+		lastID = next
+		total += n
+		log.Info().Int64("batch", n).Int("last_id", lastID).Int64("updated", total).Msg("provider_code backfill progress")
+		time.Sleep(*pause)
+	}
+	log.Info().Int64("updated", total).Msg("provider_code backfill done")
+}
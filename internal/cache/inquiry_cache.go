@@ -3,8 +3,12 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
 )
 
 // InquiryData represents cached inquiry data.
@@ -16,12 +20,17 @@ type InquiryData struct {
 	CustomerNo    string          `json:"customerNo"`
 	SKUCode       string          `json:"skuCode"`
 	Amount        int             `json:"amount"`
-	Admin         int             `json:"admin"`
+	Admin         int             `json:"admin"` // as the provider quoted it, sent back on payment
 	CustomerName  string          `json:"customerName,omitempty"`
 	Description   json.RawMessage `json:"description,omitempty"`
 	ExpiredAt     time.Time       `json:"expiredAt"`
 	CachedAt      time.Time       `json:"cachedAt"`
 
+	// QuotedAdmin is Admin after the client's price rounding: what the
+	// client is quoted and charged. 0 on entries cached before admin fees
+	// were rounded; see ClientAdmin.
+	QuotedAdmin int `json:"quotedAdmin,omitempty"`
+
 	// Multi-provider fields: track which provider handled the inquiry
 	// so payment uses the same provider
 	ProviderCode          string          `json:"providerCode,omitempty"`
@@ -38,9 +47,28 @@ type InquiryData struct {
 	FailedReason          string          `json:"failedReason,omitempty"`
 }
 
+// ClientAdmin returns the admin fee quoted to the client.
+func (d *InquiryData) ClientAdmin() int {
+	if d.QuotedAdmin > 0 {
+		return d.QuotedAdmin
+	}
+	return d.Admin
+}
+
+// InquiryStore keeps inquiries outside Redis (the database), for an inquiry
+// cache degraded by a Redis outage. The get methods return nil, nil for an
+// unknown or expired inquiry.
+type InquiryStore interface {
+	SaveInquiry(transactionID string, cacheKey *string, data []byte, expiresAt time.Time) error
+	GetInquiry(transactionID string) ([]byte, error)
+	GetInquiryByCacheKey(cacheKey string) ([]byte, error)
+	DeleteInquiry(transactionID string) error
+}
+
 // InquiryCache provides inquiry caching operations.
 type InquiryCache struct {
-	redis *RedisClient
+	redis    *RedisClient
+	fallback InquiryStore
 }
 
 // NewInquiryCache creates a new InquiryCache.
@@ -50,6 +78,14 @@ func NewInquiryCache(redis *RedisClient) *InquiryCache {
 	}
 }
 
+// SetFallback degrades to store instead of failing while Redis errors: an
+// inquiry Redis cannot take is saved to store, and lookups Redis cannot
+// answer, or finds nothing for, are answered from it. Nil keeps Redis the
+// only store (strict).
+func (c *InquiryCache) SetFallback(store InquiryStore) {
+	c.fallback = store
+}
+
 // calculateTTL calculates TTL from the inquiry expiry, falling back to end of day WIB.
 func (c *InquiryCache) calculateTTL(data *InquiryData) time.Duration {
 	if !data.ExpiredAt.IsZero() {
@@ -106,6 +142,9 @@ func (c *InquiryCache) set(ctx context.Context, data *InquiryData, storeCacheKey
 	// Store primary key (by transactionID)
 	primaryKey := c.keyByTransactionID(data.TransactionID)
 	if err := c.redis.Set(ctx, primaryKey, string(jsonData), ttl); err != nil {
+		if c.fallback != nil {
+			return c.saveFallback(data, jsonData, storeCacheKey, ttl, err)
+		}
 		return fmt.Errorf("failed to set primary key: %w", err)
 	}
 
@@ -116,25 +155,61 @@ func (c *InquiryCache) set(ctx context.Context, data *InquiryData, storeCacheKey
 	// Store secondary key (cache key) - points to transactionID
 	cacheKey := c.keyCacheKey(data.ClientID, data.CustomerNo, data.SKUCode, data.ReferenceID)
 	if err := c.redis.Set(ctx, cacheKey, data.TransactionID, ttl); err != nil {
+		if c.fallback != nil {
+			return c.saveFallback(data, jsonData, storeCacheKey, ttl, err)
+		}
 		return fmt.Errorf("failed to set cache key: %w", err)
 	}
 
 	return nil
 }
 
-// GetByTransactionID retrieves inquiry data by transaction ID.
+// saveFallback saves an inquiry Redis failed to take (redisErr) to the
+// fallback store.
+func (c *InquiryCache) saveFallback(data *InquiryData, jsonData []byte, storeCacheKey bool, ttl time.Duration, redisErr error) error {
+	var cacheKey *string
+	if storeCacheKey {
+		key := c.keyCacheKey(data.ClientID, data.CustomerNo, data.SKUCode, data.ReferenceID)
+		cacheKey = &key
+	}
+	log.Warn().Err(redisErr).Str("transactionId", data.TransactionID).Msg("inquiry cache degraded: saving inquiry to database")
+	if err := c.fallback.SaveInquiry(data.TransactionID, cacheKey, jsonData, time.Now().Add(ttl)); err != nil {
+		return fmt.Errorf("failed to save inquiry fallback: %w", err)
+	}
+	return nil
+}
+
+// GetByTransactionID retrieves inquiry data by transaction ID. A miss is
+// redis.Nil, also when answered by the fallback store.
 func (c *InquiryCache) GetByTransactionID(ctx context.Context, transactionID string) (*InquiryData, error) {
 	key := c.keyByTransactionID(transactionID)
 	jsonData, err := c.redis.Get(ctx, key)
 	if err != nil {
-		return nil, err
+		if c.fallback == nil {
+			return nil, err
+		}
+		return c.fallbackLookup(c.fallback.GetInquiry(transactionID))
+	}
+
+	return decodeInquiry([]byte(jsonData))
+}
+
+// fallbackLookup turns a fallback store answer into a lookup result.
+func (c *InquiryCache) fallbackLookup(raw []byte, err error) (*InquiryData, error) {
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inquiry fallback: %w", err)
+	}
+	if raw == nil {
+		return nil, redis.Nil
 	}
+	return decodeInquiry(raw)
+}
 
+func decodeInquiry(raw []byte) (*InquiryData, error) {
 	var data InquiryData
-	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+	if err := json.Unmarshal(raw, &data); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal inquiry data: %w", err)
 	}
-
 	return &data, nil
 }
 
@@ -146,17 +221,28 @@ func (c *InquiryCache) GetByCacheKey(ctx context.Context, clientID int, customer
 	// Get transactionID from cache key
 	transactionID, err := c.redis.Get(ctx, cacheKey)
 	if err != nil {
-		return nil, err
+		if c.fallback == nil {
+			return nil, err
+		}
+		return c.fallbackLookup(c.fallback.GetInquiryByCacheKey(cacheKey))
 	}
 
 	// Get full data using transactionID
 	return c.GetByTransactionID(ctx, transactionID)
 }
 
-// Delete removes inquiry data from Redis (both primary and cache keys).
+// Delete removes inquiry data from Redis (both primary and cache keys), and
+// from the fallback store when there is one.
 func (c *InquiryCache) Delete(ctx context.Context, data *InquiryData) error {
 	primaryKey := c.keyByTransactionID(data.TransactionID)
 	cacheKey := c.keyCacheKey(data.ClientID, data.CustomerNo, data.SKUCode, data.ReferenceID)
 
-	return c.redis.Delete(ctx, primaryKey, cacheKey)
+	err := c.redis.Delete(ctx, primaryKey, cacheKey)
+	if c.fallback == nil {
+		return err
+	}
+	if ferr := c.fallback.DeleteInquiry(data.TransactionID); ferr != nil {
+		return errors.Join(err, fmt.Errorf("failed to delete inquiry fallback: %w", ferr))
+	}
+	return err
 }
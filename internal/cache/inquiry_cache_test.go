@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type memInquiryStore struct {
+	byID  map[string][]byte
+	byKey map[string][]byte
+}
+
+func newMemInquiryStore() *memInquiryStore {
+	return &memInquiryStore{byID: map[string][]byte{}, byKey: map[string][]byte{}}
+}
+
+func (s *memInquiryStore) SaveInquiry(transactionID string, cacheKey *string, data []byte, _ time.Time) error {
+	s.byID[transactionID] = data
+	if cacheKey != nil {
+		s.byKey[*cacheKey] = data
+	}
+	return nil
+}
+
+func (s *memInquiryStore) GetInquiry(transactionID string) ([]byte, error) {
+	return s.byID[transactionID], nil
+}
+
+func (s *memInquiryStore) GetInquiryByCacheKey(cacheKey string) ([]byte, error) {
+	return s.byKey[cacheKey], nil
+}
+
+func (s *memInquiryStore) DeleteInquiry(transactionID string) error {
+	delete(s.byID, transactionID)
+	return nil
+}
+
+// downRedis is a client whose last availability check failed, so every
+// command fails fast without a server.
+func downRedis() *RedisClient {
+	r := &RedisClient{}
+	r.unavailable.Store(true)
+	return r
+}
+
+func TestInquiryCacheDegradesToFallbackWhileRedisDown(t *testing.T) {
+	ctx := context.Background()
+	store := newMemInquiryStore()
+	c := NewInquiryCache(downRedis())
+	c.SetFallback(store)
+
+	data := &InquiryData{TransactionID: "GRB-1", ReferenceID: "ref-1", ClientID: 7, CustomerNo: "5300", SKUCode: "PLN", Amount: 50000}
+	if err := c.Set(ctx, data); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	got, err := c.GetByTransactionID(ctx, "GRB-1")
+	if err != nil || got.Amount != 50000 {
+		t.Fatalf("GetByTransactionID = %+v, %v", got, err)
+	}
+	got, err = c.GetByCacheKey(ctx, 7, "5300", "PLN", "ref-1")
+	if err != nil || got.TransactionID != "GRB-1" {
+		t.Fatalf("GetByCacheKey = %+v, %v", got, err)
+	}
+	if _, err := c.GetByTransactionID(ctx, "GRB-2"); !errors.Is(err, redis.Nil) {
+		t.Fatalf("miss error = %v, want redis.Nil", err)
+	}
+
+	if err := c.Delete(ctx, data); !errors.Is(err, ErrRedisUnavailable) {
+		t.Fatalf("Delete error = %v, want the Redis error", err)
+	}
+	if _, err := c.GetByTransactionID(ctx, "GRB-1"); !errors.Is(err, redis.Nil) {
+		t.Fatalf("after Delete error = %v, want redis.Nil", err)
+	}
+}
+
+func TestInquiryCacheSetPrimaryOnlySkipsFallbackCacheKey(t *testing.T) {
+	ctx := context.Background()
+	store := newMemInquiryStore()
+	c := NewInquiryCache(downRedis())
+	c.SetFallback(store)
+
+	if err := c.SetPrimaryOnly(ctx, &InquiryData{TransactionID: "GRB-1", ReferenceID: "ref-1", ClientID: 7, CustomerNo: "5300", SKUCode: "PLN"}); err != nil {
+		t.Fatalf("SetPrimaryOnly: %v", err)
+	}
+	if _, err := c.GetByCacheKey(ctx, 7, "5300", "PLN", "ref-1"); !errors.Is(err, redis.Nil) {
+		t.Fatalf("GetByCacheKey error = %v, want redis.Nil", err)
+	}
+}
+
+func TestInquiryCacheStrictFailsWhileRedisDown(t *testing.T) {
+	ctx := context.Background()
+	c := NewInquiryCache(downRedis())
+
+	if err := c.Set(ctx, &InquiryData{TransactionID: "GRB-1"}); !errors.Is(err, ErrRedisUnavailable) {
+		t.Fatalf("Set error = %v, want ErrRedisUnavailable", err)
+	}
+	if _, err := c.GetByTransactionID(ctx, "GRB-1"); !errors.Is(err, ErrRedisUnavailable) {
+		t.Fatalf("GetByTransactionID error = %v, want ErrRedisUnavailable", err)
+	}
+}
@@ -2,7 +2,9 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -10,9 +12,15 @@ import (
 	"github.com/GTDGit/gtd_api/internal/config"
 )
 
+// ErrRedisUnavailable is returned without contacting Redis while the last
+// availability check failed, so callers fail fast instead of waiting out
+// connection timeouts.
+var ErrRedisUnavailable = errors.New("redis unavailable")
+
 // RedisClient wraps the go-redis client with helper methods.
 type RedisClient struct {
-	client *redis.Client
+	client      *redis.Client
+	unavailable atomic.Bool
 }
 
 // NewRedisClient creates a new Redis client from config.
@@ -36,25 +44,91 @@ func NewRedisClient(cfg *config.RedisConfig) (*RedisClient, error) {
 
 // Set stores a key-value pair with TTL.
 func (r *RedisClient) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	if !r.Available() {
+		return ErrRedisUnavailable
+	}
 	return r.client.Set(ctx, key, value, ttl).Err()
 }
 
 // Get retrieves a value by key.
 func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
+	if !r.Available() {
+		return "", ErrRedisUnavailable
+	}
 	return r.client.Get(ctx, key).Result()
 }
 
 // Delete removes a key.
 func (r *RedisClient) Delete(ctx context.Context, keys ...string) error {
+	if !r.Available() {
+		return ErrRedisUnavailable
+	}
 	return r.client.Del(ctx, keys...).Err()
 }
 
 // Exists checks if a key exists.
 func (r *RedisClient) Exists(ctx context.Context, key string) (bool, error) {
+	if !r.Available() {
+		return false, ErrRedisUnavailable
+	}
 	n, err := r.client.Exists(ctx, key).Result()
 	return n > 0, err
 }
 
+// Incr increments a counter and (re)sets its TTL, returning the new value.
+func (r *RedisClient) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	if !r.Available() {
+		return 0, ErrRedisUnavailable
+	}
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// SetNX stores key with TTL only if it does not exist yet and reports
+// whether it was stored.
+func (r *RedisClient) SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	if !r.Available() {
+		return false, ErrRedisUnavailable
+	}
+	return r.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+// deleteIfValueScript deletes a key only while it still holds the given value,
+// so a lock holder never releases a lock that expired and was taken by another.
+var deleteIfValueScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// DeleteIfValue removes key if its current value is value.
+func (r *RedisClient) DeleteIfValue(ctx context.Context, key string, value string) error {
+	if !r.Available() {
+		return ErrRedisUnavailable
+	}
+	return deleteIfValueScript.Run(ctx, r.client, []string{key}, value).Err()
+}
+
+// Available reports whether Redis answered the last availability check.
+func (r *RedisClient) Available() bool {
+	return !r.unavailable.Load()
+}
+
+// CheckAvailability pings Redis, bounded by timeout, and marks it available
+// or unavailable by the result; changed reports a transition either way.
+func (r *RedisClient) CheckAvailability(ctx context.Context, timeout time.Duration) (changed bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err = r.client.Ping(ctx).Err()
+	wasUnavailable := r.unavailable.Swap(err != nil)
+	return wasUnavailable != (err != nil), err
+}
+
 // Close closes the Redis connection.
 func (r *RedisClient) Close() error {
 	return r.client.Close()
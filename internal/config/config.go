@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
+
+	"github.com/GTDGit/gtd_api/internal/models"
 )
 
 // Config holds all application configuration loaded from environment variables.
@@ -20,6 +22,14 @@ type Config struct {
 
 	InternalAPIToken string // shared secret for service-to-service /v1/internal/* routes
 
+	TransactionIDPrefix string // default PPOB transaction ID prefix; clients may override
+	TransactionIDSeq    string // "counter" (atomic per-day counter, default) or "random"
+
+	// PriceRounding is the default rounding of prepaid sell prices, quoted
+	// postpaid admin fees and listed prices; clients may override it
+	// (clients.price_rounding).
+	PriceRounding models.PriceRounding
+
 	DB           DatabaseConfig
 	Redis        RedisConfig
 	Digiflazz    DigiflazzConfig
@@ -32,6 +42,124 @@ type Config struct {
 	Storage      StorageConfig
 	QRIS         QRISConfig
 	FilesPortal  FilesPortalConfig
+	Privacy      PrivacyConfig
+	PPOBRouting  PPOBRoutingConfig
+	OpsNotify    OpsNotifyConfig
+
+	// RequestTimeouts bounds each client/admin route group's handlers.
+	RequestTimeouts RequestTimeoutConfig
+
+	// ReadOnly forces global read-only mode on (writes rejected, workers
+	// paused) regardless of the admin toggle stored in system_flags.
+	ReadOnly        bool
+	ReadOnlyRefresh time.Duration // how often each instance re-reads the admin toggle
+}
+
+// RequestTimeoutConfig holds per route group handler deadlines (see
+// middleware.Timeout). The deadline propagates into the service context; 0
+// disables the middleware for that group. PPOB must stay above
+// PPOB_TRANSACTION_TIMEOUT so the synchronous attempt budget can answer first.
+type RequestTimeoutConfig struct {
+	PPOB    time.Duration
+	Payout  time.Duration
+	Payment time.Duration
+	QRIS    time.Duration
+	Admin   time.Duration
+}
+
+// OpsNotifyConfig routes operational alerts (provider failover, low balance,
+// duplicate serial numbers) to ops channels. Disabled by default; alerts are
+// then only logged.
+type OpsNotifyConfig struct {
+	Enabled bool
+	// Routes maps event types to channels, severity and rate limit:
+	// "type=slack+email:critical:30m;*=webhook" (see notify.ParseRoutes).
+	Routes string
+
+	SlackWebhookURL string
+	WebhookURL      string
+	WebhookSecret   string // signs generic webhook bodies (X-Signature, HMAC-SHA256)
+
+	EmailSMTPAddr string // host:port
+	EmailUsername string
+	EmailPassword string
+	EmailFrom     string
+	EmailTo       []string
+}
+
+// PPOBRoutingConfig controls how providers are ordered and attempted for PPOB
+// execution, and the checks applied to their results.
+type PPOBRoutingConfig struct {
+	SelectionStrategy string        // "price" (default) or "weighted_price"
+	RequestTimeout    time.Duration // synchronous attempt budget per transaction request; 0 disables
+	SerialNumberCheck bool          // flag prepaid successes reusing another transaction's serial number
+	// TieBreakSeed, when set, rotates exactly tied prepaid providers round-robin
+	// starting at this offset; nil keeps the fixed provider-id order.
+	TieBreakSeed *uint64
+	// WeightedTies picks the first of tied providers by weighted round-robin
+	// (ppob_providers.config.routingWeight, default 1) with shared Redis state.
+	WeightedTies bool
+	// NoDigiflazzInquiry lists product categories whose inquiry must not fall
+	// back to Digiflazz when no multi-provider SKU is available.
+	NoDigiflazzInquiry []string
+	// ReinquireCategories lists product categories whose payments re-inquire
+	// with another provider when the inquiry's provider was disabled or
+	// removed; other categories fail with REINQUIRY_REQUIRED.
+	ReinquireCategories []string
+	// SyncProviderAttempts caps the providers a prepaid request tries before
+	// the rest are tried asynchronously by the retry worker; 0 tries all.
+	SyncProviderAttempts int
+	// ExactPaymentSKU requires the payment skuCode to equal the inquiry
+	// skuCode; false only requires the same product.
+	ExactPaymentSKU bool
+	// RequirePositivePrice rejects prepaid requests without a positive sell
+	// price and payments of inquiries without a positive amount.
+	RequirePositivePrice bool
+	// ReferenceIDMaxLength limits the client referenceId length; 0 allows the
+	// full reference_id column width (50).
+	ReferenceIDMaxLength int
+	// AmbiguousAsPending leaves prepaid/payment transactions Processing when a
+	// provider response has no recognizable outcome, instead of failing over.
+	AmbiguousAsPending bool
+	// SerialNumberWindow limits the duplicate serial number check to
+	// transactions created that recently; 0 compares against all of them.
+	SerialNumberWindow time.Duration
+	// SerialNumberIgnoreCategories lists product categories whose serial
+	// numbers repeat by design; the check and the admin report skip them.
+	SerialNumberIgnoreCategories []string
+	// HealthFlushInterval batches provider health writes in memory and flushes
+	// them this often; 0 writes ppob_provider_health on every provider call.
+	HealthFlushInterval time.Duration
+	// SandboxRouting sends sandbox transactions through the provider router
+	// like production, restricted to providers with a sandbox account; false
+	// keeps sandbox on Digiflazz development mode only.
+	SandboxRouting bool
+	// InquiryCategoryTimeouts bounds each provider inquiry of a product
+	// category (e.g. pdam=5s) so slow providers fail over quickly.
+	InquiryCategoryTimeouts map[string]time.Duration
+	// InquiryCategoryAttempts caps the providers an inquiry of a product
+	// category tries (e.g. pdam=2).
+	InquiryCategoryAttempts map[string]int
+	// SKUAutoDisableFailures disables a Digiflazz seller SKU for
+	// SKUAutoDisableCooldown after that many failures in a row within
+	// SKUAutoDisableWindow; 0 turns automatic disabling off.
+	SKUAutoDisableFailures int
+	SKUAutoDisableWindow   time.Duration
+	SKUAutoDisableCooldown time.Duration
+	// PendingPollProviders lists provider codes whose pending prepaid/payment
+	// results are re-checked up to PendingPollAttempts times, PendingPollInterval
+	// apart, before the request returns; empty returns pending right away.
+	PendingPollProviders []string
+	PendingPollAttempts  int
+	PendingPollInterval  time.Duration
+}
+
+// PrivacyConfig drives customer number data minimization for clients that opt
+// in via clients.hash_customer_no.
+type PrivacyConfig struct {
+	CustomerNoHashSalt     string        // secret salt for the customer_no hash; required for opt-in clients
+	CustomerNoRawRetention time.Duration // how long the raw customer_no is kept after creation
+	CustomerNoMaskInterval time.Duration // masking worker tick
 }
 
 // FilesPortalConfig drives the optional upload of QRIS onboarding documents to
@@ -186,19 +314,38 @@ type RedisConfig struct {
 	Port     string
 	Password string
 	DB       int
+
+	// OutageMode is how postpaid inquiries behave while Redis is down:
+	// "degraded" (default) keeps them in the inquiry_fallbacks table so
+	// inquiry and payment keep working; "strict" fails them until Redis is
+	// back.
+	OutageMode string
+	// MonitorInterval is how often Redis availability is checked. While a
+	// check fails, Redis commands fail fast instead of timing out.
+	MonitorInterval time.Duration
 }
 
+// Degraded reports whether inquiries fall back to the database while Redis
+// is down.
+func (c RedisConfig) Degraded() bool { return c.OutageMode != "strict" }
+
 // DigiflazzConfig contains credentials and secrets for Digiflazz integration.
 type DigiflazzConfig struct {
 	Username       string
 	KeyProduction  string
 	KeyDevelopment string
 	WebhookSecret  string
+	// CallbackLookupRetries re-reads a callback's not-yet-inserted transaction
+	// up to this many times, CallbackLookupInterval apart, before the callback
+	// is left to the worker.
+	CallbackLookupRetries  int
+	CallbackLookupInterval time.Duration
 }
 
 // WorkerConfig contains interval configuration for background workers.
 type WorkerConfig struct {
 	SyncInterval              time.Duration
+	SyncMaxShrinkPercent      int // price list shrink (vs the last applied) past which missing SKUs are not disabled; 0 = off
 	RetryInterval             time.Duration
 	CallbackInterval          time.Duration
 	DigiflazzCallbackInterval time.Duration
@@ -209,6 +356,22 @@ type WorkerConfig struct {
 	PaymentStatusStaleAfter   time.Duration
 	PaymentExpiryInterval     time.Duration
 	PaymentCallbackInterval   time.Duration
+	BalanceCheckInterval      time.Duration
+	BalanceHistoryInterval    time.Duration // min gap between balance history samples; 0 disables
+	BalanceHistoryRetention   time.Duration // age after which balance history samples are purged; 0 keeps them
+	ProviderCallbackInterval  time.Duration
+	ProviderContinueInterval  time.Duration
+	// CallbackBatchWait is how long callbacks of batching clients
+	// (clients.callback_batch_size) wait for a full batch.
+	CallbackBatchWait time.Duration
+	// CallbackMaxInFlight caps concurrent client callback deliveries; more
+	// wait in a bounded queue, logged for the retry worker. 0 removes the cap.
+	CallbackMaxInFlight int
+	// StartupReconcile re-checks stale Processing transactions with their
+	// providers once at boot, before the server accepts traffic, bounded by
+	// StartupReconcileTimeout.
+	StartupReconcile        bool
+	StartupReconcileTimeout time.Duration
 }
 
 // KiosbankConfig contains credentials for Kiosbank PPOB provider
@@ -247,6 +410,12 @@ type AlterraConfig struct {
 	PrivateKeyPath    string // Path to RSA private key file
 	PrivateKeyPEM     string // RSA private key PEM content (alternative to path)
 	CallbackPublicKey string // Alterra's public key PEM for verifying callback signatures
+
+	// Sandbox account; without SandboxClientID Alterra serves no sandbox traffic.
+	SandboxBaseURL        string
+	SandboxClientID       string
+	SandboxPrivateKeyPath string
+	SandboxPrivateKeyPEM  string
 }
 
 // BRIConfig contains configuration for BRI SNAP BI and BRIZZI integrations.
@@ -329,6 +498,17 @@ func Load() (*Config, error) {
 	cfg.Env = getEnv("ENV", "development")
 	cfg.JWTSecret = getEnv("JWT_SECRET", "")
 	cfg.InternalAPIToken = getEnv("INTERNAL_API_TOKEN", "")
+	cfg.TransactionIDPrefix = strings.ToUpper(getEnv("TRANSACTION_ID_PREFIX", models.DefaultTransactionIDPrefix))
+	if !models.ValidTransactionIDPrefix(cfg.TransactionIDPrefix) {
+		return nil, fmt.Errorf("invalid TRANSACTION_ID_PREFIX %q: want 2-6 uppercase letters/digits", cfg.TransactionIDPrefix)
+	}
+	if cfg.PriceRounding, err = models.ParsePriceRounding(getEnv("PRICE_ROUNDING", models.RoundExact)); err != nil {
+		return nil, fmt.Errorf("invalid PRICE_ROUNDING: %w", err)
+	}
+	cfg.TransactionIDSeq = strings.ToLower(getEnv("TRANSACTION_ID_SEQUENCE", "counter"))
+	if cfg.TransactionIDSeq != "counter" && cfg.TransactionIDSeq != "random" {
+		return nil, fmt.Errorf("invalid TRANSACTION_ID_SEQUENCE %q: want counter or random", cfg.TransactionIDSeq)
+	}
 
 	// Database
 	cfg.DB = DatabaseConfig{
@@ -347,6 +527,17 @@ func Load() (*Config, error) {
 		Port:     getEnv("REDIS_PORT", "6379"),
 		Password: getEnv("REDIS_PASSWORD", ""),
 		DB:       getEnvInt("REDIS_DB", 0),
+
+		OutageMode: strings.ToLower(getEnv("REDIS_OUTAGE_MODE", "degraded")),
+	}
+	if cfg.Redis.OutageMode != "degraded" && cfg.Redis.OutageMode != "strict" {
+		return nil, fmt.Errorf("invalid REDIS_OUTAGE_MODE %q: want degraded or strict", cfg.Redis.OutageMode)
+	}
+	if cfg.Redis.MonitorInterval, err = parseDurationEnv("REDIS_MONITOR_INTERVAL", "5s"); err != nil {
+		return nil, fmt.Errorf("invalid REDIS_MONITOR_INTERVAL: %w", err)
+	}
+	if cfg.Redis.MonitorInterval == 0 {
+		return nil, fmt.Errorf("invalid REDIS_MONITOR_INTERVAL: must be > 0")
 	}
 
 	// Digiflazz
@@ -355,6 +546,11 @@ func Load() (*Config, error) {
 		KeyProduction:  getEnv("DIGIFLAZZ_KEY_PRODUCTION", ""),
 		KeyDevelopment: getEnv("DIGIFLAZZ_KEY_DEVELOPMENT", ""),
 		WebhookSecret:  getEnv("DIGIFLAZZ_WEBHOOK_SECRET", ""),
+
+		CallbackLookupRetries: getEnvInt("DIGIFLAZZ_CALLBACK_LOOKUP_RETRIES", 3),
+	}
+	if cfg.Digiflazz.CallbackLookupInterval, err = parseDurationEnv("DIGIFLAZZ_CALLBACK_LOOKUP_INTERVAL", "100ms"); err != nil {
+		return nil, fmt.Errorf("invalid DIGIFLAZZ_CALLBACK_LOOKUP_INTERVAL: %w", err)
 	}
 
 	// Kiosbank PPOB Provider
@@ -398,6 +594,11 @@ func Load() (*Config, error) {
 		PrivateKeyPath:    getEnv("ALTERRA_PRIVATE_KEY_PATH", ""),
 		PrivateKeyPEM:     getEnv("ALTERRA_PRIVATE_KEY_PEM", ""),
 		CallbackPublicKey: getEnv("ALTERRA_CALLBACK_PUBLIC_KEY", ""),
+
+		SandboxBaseURL:        getEnv("ALTERRA_SANDBOX_BASE_URL", "https://horven-api-staging.sumpahpalapa.com"),
+		SandboxClientID:       getEnv("ALTERRA_SANDBOX_CLIENT_ID", ""),
+		SandboxPrivateKeyPath: getEnv("ALTERRA_SANDBOX_PRIVATE_KEY_PATH", ""),
+		SandboxPrivateKeyPEM:  getEnv("ALTERRA_SANDBOX_PRIVATE_KEY_PEM", ""),
 	}
 
 	// BRI SNAP BI / BRIZZI
@@ -469,12 +670,17 @@ func Load() (*Config, error) {
 	if cfg.Worker.SyncInterval, err = parseDurationEnv("SYNC_INTERVAL", "15m"); err != nil {
 		return nil, fmt.Errorf("invalid SYNC_INTERVAL: %w", err)
 	}
+	cfg.Worker.SyncMaxShrinkPercent = getEnvInt("PROVIDER_SYNC_MAX_SHRINK_PERCENT", 50)
 	if cfg.Worker.RetryInterval, err = parseDurationEnv("RETRY_INTERVAL", "15m"); err != nil {
 		return nil, fmt.Errorf("invalid RETRY_INTERVAL: %w", err)
 	}
 	if cfg.Worker.CallbackInterval, err = parseDurationEnv("CALLBACK_RETRY_INTERVAL", "1m"); err != nil {
 		return nil, fmt.Errorf("invalid CALLBACK_RETRY_INTERVAL: %w", err)
 	}
+	if cfg.Worker.CallbackBatchWait, err = parseDurationEnv("CALLBACK_BATCH_MAX_WAIT", "5s"); err != nil {
+		return nil, fmt.Errorf("invalid CALLBACK_BATCH_MAX_WAIT: %w", err)
+	}
+	cfg.Worker.CallbackMaxInFlight = getEnvInt("CALLBACK_MAX_IN_FLIGHT", 100)
 	if cfg.Worker.DigiflazzCallbackInterval, err = parseDurationEnv("DIGIFLAZZ_CALLBACK_INTERVAL", "30s"); err != nil {
 		return nil, fmt.Errorf("invalid DIGIFLAZZ_CALLBACK_INTERVAL: %w", err)
 	}
@@ -487,6 +693,13 @@ func Load() (*Config, error) {
 	if cfg.Worker.StatusCheckMaxAge, err = parseDurationEnv("STATUS_CHECK_MAX_AGE", "5m"); err != nil {
 		return nil, fmt.Errorf("invalid STATUS_CHECK_MAX_AGE: %w", err)
 	}
+	cfg.Worker.StartupReconcile = getEnvBool("STARTUP_RECONCILE_ENABLED", false)
+	if cfg.Worker.StartupReconcileTimeout, err = parseDurationEnv("STARTUP_RECONCILE_TIMEOUT", "30s"); err != nil {
+		return nil, fmt.Errorf("invalid STARTUP_RECONCILE_TIMEOUT: %w", err)
+	}
+	if cfg.Worker.StartupReconcile && cfg.Worker.StartupReconcileTimeout == 0 {
+		return nil, fmt.Errorf("invalid STARTUP_RECONCILE_TIMEOUT: must be > 0")
+	}
 	if cfg.Worker.PaymentStatusInterval, err = parseDurationEnv("PAYMENT_STATUS_INTERVAL", "10s"); err != nil {
 		return nil, fmt.Errorf("invalid PAYMENT_STATUS_INTERVAL: %w", err)
 	}
@@ -499,6 +712,21 @@ func Load() (*Config, error) {
 	if cfg.Worker.PaymentCallbackInterval, err = parseDurationEnv("PAYMENT_CALLBACK_INTERVAL", "30s"); err != nil {
 		return nil, fmt.Errorf("invalid PAYMENT_CALLBACK_INTERVAL: %w", err)
 	}
+	if cfg.Worker.BalanceCheckInterval, err = parseDurationEnv("PROVIDER_BALANCE_CHECK_INTERVAL", "5m"); err != nil {
+		return nil, fmt.Errorf("invalid PROVIDER_BALANCE_CHECK_INTERVAL: %w", err)
+	}
+	if cfg.Worker.BalanceHistoryInterval, err = parseDurationEnv("PROVIDER_BALANCE_HISTORY_INTERVAL", "1h"); err != nil {
+		return nil, fmt.Errorf("invalid PROVIDER_BALANCE_HISTORY_INTERVAL: %w", err)
+	}
+	if cfg.Worker.BalanceHistoryRetention, err = parseDurationEnv("PROVIDER_BALANCE_HISTORY_RETENTION", "2160h"); err != nil {
+		return nil, fmt.Errorf("invalid PROVIDER_BALANCE_HISTORY_RETENTION: %w", err)
+	}
+	if cfg.Worker.ProviderCallbackInterval, err = parseDurationEnv("PROVIDER_CALLBACK_RETRY_INTERVAL", "1m"); err != nil {
+		return nil, fmt.Errorf("invalid PROVIDER_CALLBACK_RETRY_INTERVAL: %w", err)
+	}
+	if cfg.Worker.ProviderContinueInterval, err = parseDurationEnv("PPOB_ASYNC_PROVIDER_INTERVAL", "15s"); err != nil {
+		return nil, fmt.Errorf("invalid PPOB_ASYNC_PROVIDER_INTERVAL: %w", err)
+	}
 
 	// Payment providers
 	cfg.Payment = PaymentConfig{
@@ -613,6 +841,100 @@ func Load() (*Config, error) {
 		AccessMode: getEnv("QRIS_DOC_PORTAL_ACCESS_MODE", "once"),
 	}
 
+	cfg.PPOBRouting = PPOBRoutingConfig{
+		SelectionStrategy:  getEnv("PPOB_PROVIDER_SELECTION", "price"),
+		SerialNumberCheck:  getEnvBool("PPOB_SERIAL_NUMBER_CHECK", false),
+		WeightedTies:       getEnvBool("PPOB_PROVIDER_TIE_WEIGHTED", false),
+		NoDigiflazzInquiry: getEnvStringList("PPOB_INQUIRY_NO_DIGIFLAZZ_CATEGORIES", nil),
+		ExactPaymentSKU:    getEnvBool("PPOB_PAYMENT_EXACT_SKU", false),
+
+		SerialNumberIgnoreCategories: getEnvStringList("PPOB_SERIAL_NUMBER_IGNORE_CATEGORIES", nil),
+		ReinquireCategories:          getEnvStringList("PPOB_PAYMENT_REINQUIRE_CATEGORIES", nil),
+		RequirePositivePrice:         getEnvBool("PPOB_REQUIRE_POSITIVE_PRICE", true),
+		AmbiguousAsPending:           getEnvBool("PPOB_AMBIGUOUS_AS_PENDING", true),
+	}
+	if cfg.PPOBRouting.SerialNumberWindow, err = parseDurationEnv("PPOB_SERIAL_NUMBER_WINDOW", "0"); err != nil {
+		return nil, fmt.Errorf("invalid PPOB_SERIAL_NUMBER_WINDOW: %w", err)
+	}
+	if v := strings.TrimSpace(os.Getenv("PPOB_PROVIDER_TIE_SEED")); v != "" {
+		seed, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PPOB_PROVIDER_TIE_SEED: %w", err)
+		}
+		cfg.PPOBRouting.TieBreakSeed = &seed
+	}
+	if cfg.PPOBRouting.RequestTimeout, err = parseDurationEnv("PPOB_TRANSACTION_TIMEOUT", "45s"); err != nil {
+		return nil, fmt.Errorf("invalid PPOB_TRANSACTION_TIMEOUT: %w", err)
+	}
+	if cfg.PPOBRouting.HealthFlushInterval, err = parseDurationEnv("PPOB_HEALTH_FLUSH_INTERVAL", "10s"); err != nil {
+		return nil, fmt.Errorf("invalid PPOB_HEALTH_FLUSH_INTERVAL: %w", err)
+	}
+	cfg.PPOBRouting.SyncProviderAttempts = getEnvInt("PPOB_SYNC_PROVIDER_ATTEMPTS", 0)
+	cfg.PPOBRouting.ReferenceIDMaxLength = getEnvInt("PPOB_REFERENCE_ID_MAX_LENGTH", 50)
+	cfg.PPOBRouting.SandboxRouting = getEnvBool("PPOB_SANDBOX_ROUTING", false)
+	if cfg.PPOBRouting.InquiryCategoryTimeouts, err = parseCategoryDurationsEnv("PPOB_INQUIRY_CATEGORY_TIMEOUTS"); err != nil {
+		return nil, fmt.Errorf("invalid PPOB_INQUIRY_CATEGORY_TIMEOUTS: %w", err)
+	}
+	if cfg.PPOBRouting.InquiryCategoryAttempts, err = parseCategoryIntsEnv("PPOB_INQUIRY_CATEGORY_ATTEMPTS"); err != nil {
+		return nil, fmt.Errorf("invalid PPOB_INQUIRY_CATEGORY_ATTEMPTS: %w", err)
+	}
+	cfg.PPOBRouting.SKUAutoDisableFailures = getEnvInt("PPOB_SKU_AUTO_DISABLE_FAILURES", 0)
+	if cfg.PPOBRouting.SKUAutoDisableWindow, err = parseDurationEnv("PPOB_SKU_AUTO_DISABLE_WINDOW", "10m"); err != nil {
+		return nil, fmt.Errorf("invalid PPOB_SKU_AUTO_DISABLE_WINDOW: %w", err)
+	}
+	if cfg.PPOBRouting.SKUAutoDisableCooldown, err = parseDurationEnv("PPOB_SKU_AUTO_DISABLE_COOLDOWN", "30m"); err != nil {
+		return nil, fmt.Errorf("invalid PPOB_SKU_AUTO_DISABLE_COOLDOWN: %w", err)
+	}
+	cfg.PPOBRouting.PendingPollProviders = getEnvStringList("PPOB_PENDING_POLL_PROVIDERS", nil)
+	cfg.PPOBRouting.PendingPollAttempts = getEnvInt("PPOB_PENDING_POLL_ATTEMPTS", 3)
+	if cfg.PPOBRouting.PendingPollInterval, err = parseDurationEnv("PPOB_PENDING_POLL_INTERVAL", "2s"); err != nil {
+		return nil, fmt.Errorf("invalid PPOB_PENDING_POLL_INTERVAL: %w", err)
+	}
+
+	if cfg.RequestTimeouts.PPOB, err = parseDurationEnv("REQUEST_TIMEOUT_PPOB", "60s"); err != nil {
+		return nil, fmt.Errorf("invalid REQUEST_TIMEOUT_PPOB: %w", err)
+	}
+	if cfg.RequestTimeouts.Payout, err = parseDurationEnv("REQUEST_TIMEOUT_PAYOUT", "60s"); err != nil {
+		return nil, fmt.Errorf("invalid REQUEST_TIMEOUT_PAYOUT: %w", err)
+	}
+	if cfg.RequestTimeouts.Payment, err = parseDurationEnv("REQUEST_TIMEOUT_PAYMENT", "60s"); err != nil {
+		return nil, fmt.Errorf("invalid REQUEST_TIMEOUT_PAYMENT: %w", err)
+	}
+	if cfg.RequestTimeouts.QRIS, err = parseDurationEnv("REQUEST_TIMEOUT_QRIS", "30s"); err != nil {
+		return nil, fmt.Errorf("invalid REQUEST_TIMEOUT_QRIS: %w", err)
+	}
+	if cfg.RequestTimeouts.Admin, err = parseDurationEnv("REQUEST_TIMEOUT_ADMIN", "120s"); err != nil {
+		return nil, fmt.Errorf("invalid REQUEST_TIMEOUT_ADMIN: %w", err)
+	}
+
+	cfg.ReadOnly = getEnvBool("READ_ONLY_MODE", false)
+	if cfg.ReadOnlyRefresh, err = parseDurationEnv("READ_ONLY_REFRESH_INTERVAL", "5s"); err != nil {
+		return nil, fmt.Errorf("invalid READ_ONLY_REFRESH_INTERVAL: %w", err)
+	}
+
+	cfg.OpsNotify = OpsNotifyConfig{
+		Enabled:         getEnvBool("OPS_NOTIFY_ENABLED", false),
+		Routes:          getEnv("OPS_NOTIFY_ROUTES", ""),
+		SlackWebhookURL: getEnv("OPS_NOTIFY_SLACK_WEBHOOK_URL", ""),
+		WebhookURL:      getEnv("OPS_NOTIFY_WEBHOOK_URL", ""),
+		WebhookSecret:   getEnv("OPS_NOTIFY_WEBHOOK_SECRET", ""),
+		EmailSMTPAddr:   getEnv("OPS_NOTIFY_EMAIL_SMTP_ADDR", ""),
+		EmailUsername:   getEnv("OPS_NOTIFY_EMAIL_USERNAME", ""),
+		EmailPassword:   getEnv("OPS_NOTIFY_EMAIL_PASSWORD", ""),
+		EmailFrom:       getEnv("OPS_NOTIFY_EMAIL_FROM", ""),
+		EmailTo:         getEnvStringList("OPS_NOTIFY_EMAIL_TO", nil),
+	}
+
+	cfg.Privacy = PrivacyConfig{
+		CustomerNoHashSalt: getEnv("CUSTOMER_NO_HASH_SALT", ""),
+	}
+	if cfg.Privacy.CustomerNoRawRetention, err = parseDurationEnv("CUSTOMER_NO_RAW_RETENTION", "72h"); err != nil {
+		return nil, fmt.Errorf("invalid CUSTOMER_NO_RAW_RETENTION: %w", err)
+	}
+	if cfg.Privacy.CustomerNoMaskInterval, err = parseDurationEnv("CUSTOMER_NO_MASK_INTERVAL", "1h"); err != nil {
+		return nil, fmt.Errorf("invalid CUSTOMER_NO_MASK_INTERVAL: %w", err)
+	}
+
 	// Basic validation for DB parameters — keeps messages concise and helpful.
 	if cfg.DB.Host == "" || cfg.DB.User == "" || cfg.DB.Name == "" {
 		return nil, errors.New("database configuration incomplete: ensure DB_HOST, DB_USER, and DB_NAME are set")
@@ -723,6 +1045,55 @@ func parseDurationEnv(key, def string) (time.Duration, error) {
 	return d, nil
 }
 
+// getEnvKeyValues parses a comma-separated "key=value" environment variable,
+// e.g. "pdam=5s,pln=20s". Keys are lower-cased.
+func getEnvKeyValues(key string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, part := range getEnvStringList(key, nil) {
+		k, v, ok := strings.Cut(part, "=")
+		k, v = strings.ToLower(strings.TrimSpace(k)), strings.TrimSpace(v)
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("entry %q: want key=value", part)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// parseCategoryDurationsEnv parses "category=duration,..." with positive durations.
+func parseCategoryDurationsEnv(key string) (map[string]time.Duration, error) {
+	kv, err := getEnvKeyValues(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]time.Duration, len(kv))
+	for k, v := range kv {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("entry %q: want a positive duration", k+"="+v)
+		}
+		out[k] = d
+	}
+	return out, nil
+}
+
+// parseCategoryIntsEnv parses "category=n,..." with positive integers.
+func parseCategoryIntsEnv(key string) (map[string]int, error) {
+	kv, err := getEnvKeyValues(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]int, len(kv))
+	for k, v := range kv {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("entry %q: want a positive integer", k+"="+v)
+		}
+		out[k] = n
+	}
+	return out, nil
+}
+
 func defaultKiosbankInsecureSkipVerify(baseURL string) bool {
 	return strings.Contains(strings.ToLower(baseURL), "development.kiosbank.com")
 }
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/service"
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+// AdminAuditHandler exposes the admin audit trail.
+type AdminAuditHandler struct {
+	auditSvc *service.AdminAuditService
+}
+
+func NewAdminAuditHandler(auditSvc *service.AdminAuditService) *AdminAuditHandler {
+	return &AdminAuditHandler{auditSvc: auditSvc}
+}
+
+// ListAuditLog handles GET /v1/admin/audit-log?actor=&action=&target=&start=&end=&page=&limit=
+// — admin actions, newest first. start/end are YYYY-MM-DD (WIB, inclusive).
+func (h *AdminAuditHandler) ListAuditLog(c *gin.Context) {
+	start, end := c.Query("start"), c.Query("end")
+	for _, v := range []string{start, end} {
+		if v == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			utils.Error(c, http.StatusBadRequest, "INVALID_PARAM", "start and end must be YYYY-MM-DD")
+			return
+		}
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	entries, total, err := h.auditSvc.ListAuditLog(c.Query("actor"), c.Query("action"), c.Query("target"), start, end, page, limit)
+	if err != nil {
+		var ve *service.AdminValidationError
+		if errors.As(err, &ve) {
+			utils.Error(c, http.StatusBadRequest, "INVALID_PARAM", ve.Message)
+			return
+		}
+		log.Error().Err(err).Msg("admin audit: list failed")
+		utils.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+	utils.SuccessWithPagination(c, http.StatusOK, "Successfully", entries, page, limit, total)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/service"
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+// AdminClientHandler exposes admin endpoints for API clients.
+type AdminClientHandler struct {
+	adminClientSvc *service.AdminClientService
+}
+
+func NewAdminClientHandler(adminClientSvc *service.AdminClientService) *AdminClientHandler {
+	return &AdminClientHandler{adminClientSvc: adminClientSvc}
+}
+
+// ListClients handles GET /v1/admin/clients?from=&to=&search=&status=&page=&limit=
+// — clients with last production activity and transaction count between the
+// from and to dates (default: the last 30 days).
+func (h *AdminClientHandler) ListClients(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	clients, total, err := h.adminClientSvc.ListClients(c.Query("search"), c.Query("status"), c.Query("from"), c.Query("to"), page, limit)
+	if err != nil {
+		var ve *service.AdminValidationError
+		if errors.As(err, &ve) {
+			utils.Error(c, http.StatusBadRequest, "INVALID_PARAM", ve.Message)
+			return
+		}
+		log.Error().Err(err).Msg("admin clients: list failed")
+		utils.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+	utils.SuccessWithPagination(c, http.StatusOK, "Successfully", clients, page, limit, total)
+}
+
+// GetEffectiveConfig handles GET /v1/admin/clients/:id/effective-config — the
+// settings that apply to the client, global defaults merged with its own
+// overrides, each override marked with its source.
+func (h *AdminClientHandler) GetEffectiveConfig(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.Error(c, http.StatusBadRequest, "INVALID_PARAM", "id must be a positive integer")
+		return
+	}
+	cfg, err := h.adminClientSvc.EffectiveConfig(id)
+	if err != nil {
+		if errors.Is(err, service.ErrAdminNotFound) {
+			utils.Error(c, http.StatusNotFound, "NOT_FOUND", "Client not found")
+			return
+		}
+		log.Error().Err(err).Int("client_id", id).Msg("admin clients: effective config failed")
+		utils.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", cfg)
+}
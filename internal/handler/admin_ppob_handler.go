@@ -0,0 +1,551 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/service"
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+// AdminPPOBHandler exposes admin endpoints for PPOB providers and transactions.
+type AdminPPOBHandler struct {
+	adminPPOBSvc *service.AdminPPOBService
+}
+
+func NewAdminPPOBHandler(adminPPOBSvc *service.AdminPPOBService) *AdminPPOBHandler {
+	return &AdminPPOBHandler{adminPPOBSvc: adminPPOBSvc}
+}
+
+// ListProviders handles GET /v1/admin/ppob/providers — every provider with
+// supportsPrepaid/Postpaid/Balance/StatusCheck capabilities.
+func (h *AdminPPOBHandler) ListProviders(c *gin.Context) {
+	providers, err := h.adminPPOBSvc.ListProviders()
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", providers)
+}
+
+// GetProviderUsageShare handles GET /v1/admin/ppob/providers/usage-share?start=&end=
+// — successful transaction counts and percentage per provider for the period.
+func (h *AdminPPOBHandler) GetProviderUsageShare(c *gin.Context) {
+	start, end, ok := h.dateRange(c)
+	if !ok {
+		return
+	}
+	resp, err := h.adminPPOBSvc.GetProviderUsageShare(start, end)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", resp)
+}
+
+// GetProviderBalanceTrend handles GET /v1/admin/ppob/providers/balance-history?provider=&start=&end=&interval=
+// — the provider's sampled deposit balance per hour or day (min, max, last).
+func (h *AdminPPOBHandler) GetProviderBalanceTrend(c *gin.Context) {
+	start, end, ok := h.dateRange(c)
+	if !ok {
+		return
+	}
+	trend, err := h.adminPPOBSvc.GetProviderBalanceTrend(c.Query("provider"), start, end, c.Query("interval"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", trend)
+}
+
+// ListSerialNumberDuplicates handles GET /v1/admin/ppob/reports/duplicate-serial-numbers?start=&end=&page=&limit=
+// — serial numbers held by more than one successful transaction in the period.
+func (h *AdminPPOBHandler) ListSerialNumberDuplicates(c *gin.Context) {
+	start, end, ok := h.dateRange(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	duplicates, total, err := h.adminPPOBSvc.ListSerialNumberDuplicates(start, end, page, limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.SuccessWithPagination(c, http.StatusOK, "Successfully", duplicates, page, limit, total)
+}
+
+// RetryTransactionSKURequest is the body for a forced-SKU retry.
+type RetryTransactionSKURequest struct {
+	SkuID int `json:"skuId" binding:"required,gt=0"`
+}
+
+// RetryTransactionWithSKU handles POST /v1/admin/ppob/transactions/:transactionId/retry-sku
+// — re-attempts the transaction on the given SKU, bypassing tried-SKU exclusion.
+func (h *AdminPPOBHandler) RetryTransactionWithSKU(c *gin.Context) {
+	var req RetryTransactionSKURequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "MISSING_FIELD", "skuId is required")
+		return
+	}
+	trx, err := h.adminPPOBSvc.RetryTransactionWithSKU(c.Request.Context(), c.Param("transactionId"), req.SkuID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", trx)
+}
+
+// RefundTransaction handles POST /v1/admin/transactions/:transactionId/refund
+// — records a partial refund (amount, reason) against a successful transaction.
+func (h *AdminPPOBHandler) RefundTransaction(c *gin.Context) {
+	var req service.RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "MISSING_FIELD", "amount (> 0) and reason are required")
+		return
+	}
+	refunds, err := h.adminPPOBSvc.RefundTransaction(c.Param("transactionId"), req, c.GetString("email"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusCreated, "Successfully", refunds)
+}
+
+// ListTransactionRefunds handles GET /v1/admin/transactions/:transactionId/refunds
+func (h *AdminPPOBHandler) ListTransactionRefunds(c *gin.Context) {
+	refunds, err := h.adminPPOBSvc.ListTransactionRefunds(c.Param("transactionId"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", refunds)
+}
+
+// AddTransactionNote handles POST /v1/admin/transactions/:transactionId/notes
+// — an internal note by the logged-in admin; never sent to the client.
+func (h *AdminPPOBHandler) AddTransactionNote(c *gin.Context) {
+	var req service.NoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "MISSING_FIELD", "note is required")
+		return
+	}
+	notes, err := h.adminPPOBSvc.AddTransactionNote(c.Param("transactionId"), req, c.GetString("email"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusCreated, "Successfully", notes)
+}
+
+// ListTransactionNotes handles GET /v1/admin/transactions/:transactionId/notes
+func (h *AdminPPOBHandler) ListTransactionNotes(c *gin.Context) {
+	notes, err := h.adminPPOBSvc.ListTransactionNotes(c.Param("transactionId"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", notes)
+}
+
+// ReviewHeldTransaction handles POST /v1/admin/transactions/:transactionId/review {decision, reason}
+// — approves or rejects a transaction held for a duplicate serial number.
+func (h *AdminPPOBHandler) ReviewHeldTransaction(c *gin.Context) {
+	var req service.ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "MISSING_FIELD", "decision is required")
+		return
+	}
+	trx, err := h.adminPPOBSvc.ReviewHeldTransaction(c.Param("transactionId"), req, c.GetString("email"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", trx)
+}
+
+// GetTransactionProviderResponse handles GET /v1/admin/transactions/:transactionId/provider-response
+// — the raw provider responses stored on the transaction, for debugging.
+func (h *AdminPPOBHandler) GetTransactionProviderResponse(c *gin.Context) {
+	resp, err := h.adminPPOBSvc.GetTransactionProviderResponse(c.Param("transactionId"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", resp)
+}
+
+// InspectInquiryCache handles GET /v1/admin/ppob/inquiry/:transactionId
+// — the live inquiry cache entry (provider, amount, expiry) or why it is missing.
+func (h *AdminPPOBHandler) InspectInquiryCache(c *gin.Context) {
+	status, err := h.adminPPOBSvc.InspectInquiryCache(c.Request.Context(), c.Param("transactionId"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", status)
+}
+
+// SyncProvider handles POST /v1/admin/ppob/providers/:id/sync?force= — runs the
+// price sync for one provider now and returns the SKU counts. force=true
+// disables missing SKUs even when the price list shrank past the guard.
+func (h *AdminPPOBHandler) SyncProvider(c *gin.Context) {
+	id, ok := h.intParam(c, "id")
+	if !ok {
+		return
+	}
+	result, err := h.adminPPOBSvc.SyncProvider(c.Request.Context(), id, c.Query("force") == "true")
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", result)
+}
+
+// SelfTestProvider handles POST /v1/admin/ppob/providers/:id/selftest — makes
+// the adapter's cheapest safe call and reports connectivity, authentication
+// and latency. A failed check is still 200; see reachable/authenticated.
+func (h *AdminPPOBHandler) SelfTestProvider(c *gin.Context) {
+	id, ok := h.intParam(c, "id")
+	if !ok {
+		return
+	}
+	result, err := h.adminPPOBSvc.SelfTestProvider(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", result)
+}
+
+// ReverseProviderSKU handles GET /v1/admin/ppob/providers/:id/skus/reverse?code=
+// — lists the products a provider SKU code maps to and flags provider codes
+// mapped to more than one product.
+func (h *AdminPPOBHandler) ReverseProviderSKU(c *gin.Context) {
+	id, ok := h.intParam(c, "id")
+	if !ok {
+		return
+	}
+	result, err := h.adminPPOBSvc.ReverseProviderSKU(id, c.Query("code"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", result)
+}
+
+// ExportProviderSKUs handles GET /v1/admin/ppob/providers/:id/skus/export
+// — streams the provider's SKU mappings as CSV for offline review.
+func (h *AdminPPOBHandler) ExportProviderSKUs(c *gin.Context) {
+	id, ok := h.intParam(c, "id")
+	if !ok {
+		return
+	}
+	started := false
+	err := h.adminPPOBSvc.ExportProviderSKUs(id, func(provider *models.PPOBProvider) io.Writer {
+		started = true
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", "attachment; filename=\""+string(provider.Code)+"-skus.csv\"")
+		c.Status(http.StatusOK)
+		return c.Writer
+	})
+	if err != nil && !started {
+		h.handleError(c, err)
+		return
+	}
+	if err != nil {
+		// Rows are already out; the truncated file is all the client gets.
+		log.Error().Err(err).Int("provider_id", id).Msg("admin ppob: provider sku export failed")
+	}
+}
+
+// UpdateCustomerNoRules handles PUT /v1/admin/ppob/products/:id/customer-no-rules
+// — sets min/max length and an optional regex for the product's customerNo.
+func (h *AdminPPOBHandler) UpdateCustomerNoRules(c *gin.Context) {
+	id, ok := h.intParam(c, "id")
+	if !ok {
+		return
+	}
+	var req service.CustomerNoRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "MISSING_FIELD", "Invalid request body")
+		return
+	}
+	product, err := h.adminPPOBSvc.UpdateCustomerNoRules(id, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", product)
+}
+
+// UpdateSelectionStrategy handles PUT /v1/admin/ppob/products/:id/selection-strategy
+// — overrides the provider failover order for the product (null restores the default).
+func (h *AdminPPOBHandler) UpdateSelectionStrategy(c *gin.Context) {
+	id, ok := h.intParam(c, "id")
+	if !ok {
+		return
+	}
+	var req service.SelectionStrategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "MISSING_FIELD", "Invalid request body")
+		return
+	}
+	product, err := h.adminPPOBSvc.UpdateSelectionStrategy(id, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", product)
+}
+
+// UpdateDuplicateSerialHold handles PUT /v1/admin/ppob/products/:id/duplicate-serial-hold {enabled}
+// — holds successes reusing a serial number of the same product and provider for review.
+func (h *AdminPPOBHandler) UpdateDuplicateSerialHold(c *gin.Context) {
+	id, ok := h.intParam(c, "id")
+	if !ok {
+		return
+	}
+	var req service.DuplicateSerialHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "MISSING_FIELD", "Invalid request body")
+		return
+	}
+	product, err := h.adminPPOBSvc.UpdateDuplicateSerialHold(id, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", product)
+}
+
+// SimulateRouting handles POST /v1/admin/ppob/products/:id/simulate {type, failProviders}
+// — the provider attempt order the router would take, without calling any provider.
+func (h *AdminPPOBHandler) SimulateRouting(c *gin.Context) {
+	id, ok := h.intParam(c, "id")
+	if !ok {
+		return
+	}
+	var req service.SimulateRoutingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "MISSING_FIELD", "Invalid request body")
+		return
+	}
+	sim, err := h.adminPPOBSvc.SimulateRouting(c.Request.Context(), id, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", sim)
+}
+
+// UpdateProviderCutOff handles PUT /v1/admin/ppob/providers/:id/cut-off
+// — the daily WIB window during which routing skips the provider.
+func (h *AdminPPOBHandler) UpdateProviderCutOff(c *gin.Context) {
+	id, ok := h.intParam(c, "id")
+	if !ok {
+		return
+	}
+	var req service.ProviderCutOffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "MISSING_FIELD", "Invalid request body")
+		return
+	}
+	provider, err := h.adminPPOBSvc.UpdateProviderCutOff(id, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", provider)
+}
+
+// ListMaintenanceWindows handles GET /v1/admin/ppob/providers/maintenance-windows?provider=&upcoming=
+func (h *AdminPPOBHandler) ListMaintenanceWindows(c *gin.Context) {
+	upcoming := c.Query("upcoming") == "true"
+	windows, err := h.adminPPOBSvc.ListMaintenanceWindows(c.Query("provider"), upcoming)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", windows)
+}
+
+// CreateMaintenanceWindow handles POST /v1/admin/ppob/providers/maintenance-windows
+func (h *AdminPPOBHandler) CreateMaintenanceWindow(c *gin.Context) {
+	var req service.MaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "MISSING_FIELD", "providerCode, startsAt and endsAt are required")
+		return
+	}
+	w, err := h.adminPPOBSvc.CreateMaintenanceWindow(req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusCreated, "Successfully", w)
+}
+
+// UpdateMaintenanceWindow handles PUT /v1/admin/ppob/providers/maintenance-windows/:id
+func (h *AdminPPOBHandler) UpdateMaintenanceWindow(c *gin.Context) {
+	id, ok := h.intParam(c, "id")
+	if !ok {
+		return
+	}
+	var req service.MaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "MISSING_FIELD", "startsAt and endsAt are required")
+		return
+	}
+	w, err := h.adminPPOBSvc.UpdateMaintenanceWindow(id, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", w)
+}
+
+// DeleteMaintenanceWindow handles DELETE /v1/admin/ppob/providers/maintenance-windows/:id
+func (h *AdminPPOBHandler) DeleteMaintenanceWindow(c *gin.Context) {
+	id, ok := h.intParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.adminPPOBSvc.DeleteMaintenanceWindow(id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", nil)
+}
+
+// ListFailedProviderCallbacks handles GET /v1/admin/ppob/provider-callbacks/failed?exhausted=&page=&limit=
+func (h *AdminPPOBHandler) ListFailedProviderCallbacks(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	callbacks, total, err := h.adminPPOBSvc.ListFailedProviderCallbacks(c.Query("exhausted") == "true", page, limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.SuccessWithPagination(c, http.StatusOK, "Successfully", callbacks, page, limit, total)
+}
+
+// ListSKURCOverrides handles GET /v1/admin/ppob/skus/:id/rc-overrides
+func (h *AdminPPOBHandler) ListSKURCOverrides(c *gin.Context) {
+	id, ok := h.intParam(c, "id")
+	if !ok {
+		return
+	}
+	overrides, err := h.adminPPOBSvc.ListSKURCOverrides(id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", overrides)
+}
+
+// SetSKURCOverride handles PUT /v1/admin/ppob/skus/:id/rc-overrides/:rc
+// — forces outcome fatal or switch_sku for that RC on this SKU.
+func (h *AdminPPOBHandler) SetSKURCOverride(c *gin.Context) {
+	id, ok := h.intParam(c, "id")
+	if !ok {
+		return
+	}
+	var req service.RCOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "MISSING_FIELD", "outcome is required")
+		return
+	}
+	override, err := h.adminPPOBSvc.SetSKURCOverride(id, c.Param("rc"), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", override)
+}
+
+// DeleteSKURCOverride handles DELETE /v1/admin/ppob/skus/:id/rc-overrides/:rc
+func (h *AdminPPOBHandler) DeleteSKURCOverride(c *gin.Context) {
+	id, ok := h.intParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.adminPPOBSvc.DeleteSKURCOverride(id, c.Param("rc")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", nil)
+}
+
+// ---------------------------------------------------------------------------
+// Helpers
+// ---------------------------------------------------------------------------
+
+// dateRange reads optional start/end query params in YYYY-MM-DD format.
+func (h *AdminPPOBHandler) dateRange(c *gin.Context) (string, string, bool) {
+	start, end := c.Query("start"), c.Query("end")
+	for _, v := range []string{start, end} {
+		if v == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			utils.Error(c, http.StatusBadRequest, "INVALID_PARAM", "start and end must be YYYY-MM-DD")
+			return "", "", false
+		}
+	}
+	return start, end, true
+}
+
+func (h *AdminPPOBHandler) intParam(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		utils.Error(c, http.StatusBadRequest, "INVALID_PARAM", name+" must be a positive integer")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *AdminPPOBHandler) handleError(c *gin.Context, err error) {
+	var ve *service.AdminValidationError
+	if errors.As(err, &ve) {
+		utils.Error(c, http.StatusBadRequest, "INVALID_PARAM", ve.Message)
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrAdminNotFound):
+		utils.Error(c, http.StatusNotFound, "NOT_FOUND", "Resource not found")
+		return
+	case errors.Is(err, utils.ErrTransactionNotFound):
+		utils.Error(c, http.StatusNotFound, "TRANSACTION_NOT_FOUND", "Transaction not found")
+		return
+	case errors.Is(err, utils.ErrInvalidSKU):
+		utils.Error(c, http.StatusBadRequest, "INVALID_SKU", "SKU or product not found for this request")
+		return
+	case errors.Is(err, utils.ErrRefundExceedsAmount):
+		utils.Error(c, http.StatusUnprocessableEntity, "REFUND_EXCEEDS_AMOUNT", "Refund total would exceed the transaction amount")
+		return
+	case errors.Is(err, utils.ErrTransactionNotRetryable):
+		utils.Error(c, http.StatusConflict, "TRANSACTION_NOT_RETRYABLE", "Transaction cannot be retried in its current state")
+		return
+	}
+	log.Error().Err(err).Str("path", c.FullPath()).Msg("admin ppob: unhandled error")
+	utils.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+}
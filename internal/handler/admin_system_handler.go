@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/service"
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+// AdminSystemHandler exposes global operational switches.
+type AdminSystemHandler struct {
+	readOnlySvc *service.ReadOnlyService
+}
+
+func NewAdminSystemHandler(readOnlySvc *service.ReadOnlyService) *AdminSystemHandler {
+	return &AdminSystemHandler{readOnlySvc: readOnlySvc}
+}
+
+// GetReadOnly handles GET /v1/admin/system/read-only.
+func (h *AdminSystemHandler) GetReadOnly(c *gin.Context) {
+	state, err := h.readOnlySvc.State()
+	if err != nil {
+		log.Error().Err(err).Msg("admin system: get read-only failed")
+		utils.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", state)
+}
+
+// SetReadOnly handles PUT /v1/admin/system/read-only {enabled, reason}
+// — turns global read-only mode on or off for every instance.
+func (h *AdminSystemHandler) SetReadOnly(c *gin.Context) {
+	var req service.ReadOnlyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "MISSING_FIELD", "enabled is required")
+		return
+	}
+	state, err := h.readOnlySvc.SetReadOnly(req, c.GetString("email"))
+	if err != nil {
+		var ve *service.AdminValidationError
+		if errors.As(err, &ve) {
+			utils.Error(c, http.StatusBadRequest, "INVALID_PARAM", ve.Message)
+			return
+		}
+		log.Error().Err(err).Msg("admin system: set read-only failed")
+		utils.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+	utils.Success(c, http.StatusOK, "Successfully", state)
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/GTDGit/gtd_api/internal/middleware"
+	"github.com/GTDGit/gtd_api/internal/repository"
+	"github.com/GTDGit/gtd_api/internal/service"
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+// CallbackHandler exposes a client's own callback delivery history.
+type CallbackHandler struct {
+	callbackSvc *service.CallbackService
+}
+
+// NewCallbackHandler constructs a CallbackHandler.
+func NewCallbackHandler(callbackSvc *service.CallbackService) *CallbackHandler {
+	return &CallbackHandler{callbackSvc: callbackSvc}
+}
+
+// ListCallbacks handles GET /v1/ppob/callbacks?transactionId=&start=&end=&page=&limit=
+// — recent callback deliveries for the authenticated client's transactions.
+func (h *CallbackHandler) ListCallbacks(c *gin.Context) {
+	client := middleware.GetClient(c)
+	if client == nil {
+		utils.Error(c, http.StatusUnauthorized, "INVALID_TOKEN", "Unauthorized")
+		return
+	}
+
+	start, end := c.Query("start"), c.Query("end")
+	for _, v := range []string{start, end} {
+		if v == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			utils.Error(c, http.StatusBadRequest, "INVALID_PARAM", "start and end must be YYYY-MM-DD")
+			return
+		}
+	}
+
+	page := 1
+	limit := 50
+	if v := c.Query("page"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			page = n
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+	}
+
+	callbacks, total, err := h.callbackSvc.ListClientCallbacks(repository.ClientCallbackFilter{
+		ClientID:      client.ID,
+		TransactionID: c.Query("transactionId"),
+		StartDate:     start,
+		EndDate:       end,
+		Page:          page,
+		Limit:         limit,
+	})
+	if err != nil {
+		utils.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get callbacks")
+		return
+	}
+	if callbacks == nil {
+		callbacks = []repository.ClientCallbackDelivery{}
+	}
+
+	utils.SuccessWithPagination(c, http.StatusOK, "Callbacks retrieved successfully", gin.H{
+		"callbacks": callbacks,
+	}, page, limit, total)
+}
+
+// AckCallback handles POST /v1/ppob/callbacks/:id/ack — the client confirms it
+// received the callback (e.g. its endpoint answers 202), so retries stop.
+func (h *CallbackHandler) AckCallback(c *gin.Context) {
+	client := middleware.GetClient(c)
+	if client == nil {
+		utils.Error(c, http.StatusUnauthorized, "INVALID_TOKEN", "Unauthorized")
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.Error(c, http.StatusBadRequest, "INVALID_PARAM", "id must be a positive integer")
+		return
+	}
+
+	delivery, err := h.callbackSvc.AcknowledgeCallback(client, id)
+	if errors.Is(err, utils.ErrCallbackNotFound) {
+		utils.Error(c, http.StatusNotFound, "CALLBACK_NOT_FOUND", "Callback not found")
+		return
+	}
+	if err != nil {
+		utils.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to acknowledge callback")
+		return
+	}
+	utils.Success(c, http.StatusOK, "Callback acknowledged", delivery)
+}
+
+// VerifySignatureRequest carries a raw callback body and the signature to check.
+type VerifySignatureRequest struct {
+	Payload   string `json:"payload" binding:"required"`   // raw body exactly as received
+	Signature string `json:"signature" binding:"required"` // X-GTD-Signature header value
+}
+
+// VerifySignature handles POST /v1/ppob/verify-signature — recomputes the
+// callback HMAC with the client's secret and signature format and says
+// whether it matches. Only the verdict is returned, never the secret or the
+// expected signature.
+func (h *CallbackHandler) VerifySignature(c *gin.Context) {
+	client := middleware.GetClient(c)
+	if client == nil {
+		utils.Error(c, http.StatusUnauthorized, "INVALID_TOKEN", "Unauthorized")
+		return
+	}
+	var req VerifySignatureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "MISSING_FIELD", "payload and signature are required")
+		return
+	}
+
+	algorithm, encoding := client.CallbackSignatureFormat()
+	utils.Success(c, http.StatusOK, "Signature checked", gin.H{
+		"valid":     h.callbackSvc.VerifySignature(client, []byte(req.Payload), req.Signature),
+		"algorithm": "HMAC-" + strings.ToUpper(algorithm),
+		"encoding":  encoding,
+		"header":    "X-GTD-Signature",
+	})
+}
+
+// ResendCallback handles POST /v1/ppob/transaction/:transactionId/resend-callback
+// — sends the latest callback of the client's transaction again, signed with
+// its current secret (e.g. after fixing its endpoint or rotating the secret).
+func (h *CallbackHandler) ResendCallback(c *gin.Context) {
+	client := middleware.GetClient(c)
+	if client == nil {
+		utils.Error(c, http.StatusUnauthorized, "INVALID_TOKEN", "Unauthorized")
+		return
+	}
+
+	resend, err := h.callbackSvc.ResendCallback(client, c.Param("transactionId"))
+	switch {
+	case errors.Is(err, utils.ErrTransactionNotFound):
+		utils.Error(c, http.StatusNotFound, "TRANSACTION_NOT_FOUND", "Transaction not found")
+	case errors.Is(err, utils.ErrCallbackNotFound):
+		utils.Error(c, http.StatusNotFound, "CALLBACK_NOT_FOUND", "No callback to resend for this transaction")
+	case errors.Is(err, utils.ErrCallbackResendTooSoon):
+		utils.Error(c, http.StatusTooManyRequests, "CALLBACK_RESEND_TOO_SOON", "A callback for this transaction was sent less than a minute ago")
+	case err != nil:
+		utils.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to resend callback")
+	default:
+		utils.Success(c, http.StatusOK, "Callback resent", resend)
+	}
+}
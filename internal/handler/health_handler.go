@@ -5,6 +5,8 @@ import (
 
     "github.com/gin-gonic/gin"
 
+    "github.com/GTDGit/gtd_api/internal/repository"
+    "github.com/GTDGit/gtd_api/internal/service"
     "github.com/GTDGit/gtd_api/internal/utils"
     "github.com/GTDGit/gtd_api/pkg/digiflazz"
 )
@@ -13,12 +15,26 @@ var startTime = time.Now()
 
 // HealthHandler provides health endpoint.
 type HealthHandler struct {
-    digiflazz *digiflazz.Client
+    digiflazz    *digiflazz.Client
+    providerRepo *repository.PPOBProviderRepository
+    readOnly     interface{ ReadOnly() bool }
+    callbacks    interface{ CallbackQueueStats() service.CallbackQueueStats }
 }
 
 // NewHealthHandler creates a new HealthHandler.
-func NewHealthHandler(digiflazz *digiflazz.Client) *HealthHandler {
-    return &HealthHandler{digiflazz: digiflazz}
+func NewHealthHandler(digiflazz *digiflazz.Client, providerRepo *repository.PPOBProviderRepository) *HealthHandler {
+    return &HealthHandler{digiflazz: digiflazz, providerRepo: providerRepo}
+}
+
+// SetReadOnlyChecker reports global read-only mode in the health response.
+func (h *HealthHandler) SetReadOnlyChecker(checker interface{ ReadOnly() bool }) {
+    h.readOnly = checker
+}
+
+// SetCallbackQueue reports the client callback delivery backlog in the
+// health response.
+func (h *HealthHandler) SetCallbackQueue(queue interface{ CallbackQueueStats() service.CallbackQueueStats }) {
+    h.callbacks = queue
 }
 
 // GetHealth responds with service status.
@@ -44,5 +60,27 @@ func (h *HealthHandler) GetHealth(c *gin.Context) {
         }
     }
 
+    if h.providerRepo != nil {
+        if windows, err := h.providerRepo.ListMaintenanceWindows(0, true); err == nil {
+            maintenance := make([]gin.H, 0, len(windows))
+            for _, w := range windows {
+                maintenance = append(maintenance, gin.H{
+                    "provider": w.ProviderCode,
+                    "startsAt": w.StartsAt,
+                    "endsAt":   w.EndsAt,
+                })
+            }
+            data["providerMaintenance"] = maintenance
+        }
+    }
+
+    if h.readOnly != nil {
+        data["readOnly"] = h.readOnly.ReadOnly()
+    }
+
+    if h.callbacks != nil {
+        data["callbackQueue"] = h.callbacks.CallbackQueueStats()
+    }
+
     utils.Success(c, 200, "Service is healthy", data)
 }
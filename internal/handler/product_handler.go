@@ -1,10 +1,12 @@
 package handler
 
 import (
+    "errors"
     "strconv"
 
     "github.com/gin-gonic/gin"
 
+    "github.com/GTDGit/gtd_api/internal/middleware"
     "github.com/GTDGit/gtd_api/internal/service"
     "github.com/GTDGit/gtd_api/internal/utils"
 )
@@ -25,6 +27,7 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
     category := c.Query("category")  // Pulsa, Data, PLN, etc
     brand := c.Query("brand")
     search := c.Query("search")
+    availableNow := c.Query("availableNow") == "true" // only products buyable right now (WIB)
 
     // pagination
     page := 1
@@ -40,7 +43,7 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
         }
     }
 
-    products, total, err := h.productService.GetProducts(productType, category, brand, search, page, limit)
+    products, total, err := h.productService.GetProducts(middleware.GetClient(c), productType, category, brand, search, availableNow, page, limit)
     if err != nil {
         utils.Error(c, 500, "INTERNAL_ERROR", "Failed to get products")
         return
@@ -50,3 +53,77 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
         "products": products,
     }, page, limit, total)
 }
+
+// GetCategories returns the categories of active products with product counts.
+func (h *ProductHandler) GetCategories(c *gin.Context) {
+    categories, err := h.productService.GetCategories(c.Query("type"))
+    if err != nil {
+        utils.Error(c, 500, "INTERNAL_ERROR", "Failed to get categories")
+        return
+    }
+
+    utils.Success(c, 200, "Categories retrieved successfully", gin.H{
+        "categories": categories,
+    })
+}
+
+// GetBrands returns the brands of active products with product counts,
+// optionally within one category.
+func (h *ProductHandler) GetBrands(c *gin.Context) {
+    brands, err := h.productService.GetBrands(c.Query("type"), c.Query("category"))
+    if err != nil {
+        utils.Error(c, 500, "INTERNAL_ERROR", "Failed to get brands")
+        return
+    }
+
+    utils.Success(c, 200, "Brands retrieved successfully", gin.H{
+        "brands": brands,
+    })
+}
+
+// GetAvailability returns the cut-off windows of a product and whether it is available now (WIB).
+func (h *ProductHandler) GetAvailability(c *gin.Context) {
+    availability, err := h.productService.GetProductAvailability(c.Param("skuCode"))
+    if err != nil {
+        if errors.Is(err, utils.ErrInvalidSKU) {
+            utils.Error(c, 404, "INVALID_SKU", "SKU code not found")
+            return
+        }
+        utils.Error(c, 500, "INTERNAL_ERROR", "Failed to get product availability")
+        return
+    }
+
+    utils.Success(c, 200, "Product availability retrieved successfully", availability)
+}
+
+// GetProviders returns the providers that can fulfill a product right now,
+// for the optional provider field of a transaction.
+func (h *ProductHandler) GetProviders(c *gin.Context) {
+    providers, err := h.productService.GetProductProviders(c.Param("skuCode"), middleware.IsSandbox(c))
+    if err != nil {
+        if errors.Is(err, utils.ErrInvalidSKU) {
+            utils.Error(c, 404, "INVALID_SKU", "SKU code not found")
+            return
+        }
+        utils.Error(c, 500, "INTERNAL_ERROR", "Failed to get product providers")
+        return
+    }
+
+    utils.Success(c, 200, "Product providers retrieved successfully", providers)
+}
+
+// GetSchema returns the JSON Schemas of the transaction requests of a product
+// and the fields their responses carry, for building and validating forms.
+func (h *ProductHandler) GetSchema(c *gin.Context) {
+    schema, err := h.productService.GetProductSchema(c.Param("skuCode"))
+    if err != nil {
+        if errors.Is(err, utils.ErrInvalidSKU) {
+            utils.Error(c, 404, "INVALID_SKU", "SKU code not found")
+            return
+        }
+        utils.Error(c, 500, "INTERNAL_ERROR", "Failed to get product schema")
+        return
+    }
+
+    utils.Success(c, 200, "Product schema retrieved successfully", schema)
+}
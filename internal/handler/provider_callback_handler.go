@@ -2,18 +2,24 @@ package handler
 
 import (
 	"crypto"
+	"crypto/hmac"
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 
+	"github.com/GTDGit/gtd_api/internal/models"
 	"github.com/GTDGit/gtd_api/internal/service"
 	"github.com/GTDGit/gtd_api/internal/utils"
 )
@@ -140,22 +146,124 @@ func (h *ProviderCallbackHandler) HandleAlterraCallback(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
-// HandleGenericCallback handles generic provider callback (fallback)
+// HandleGenericCallback handles POST /v1/webhook/:provider. The provider is
+// resolved from ppob_providers and its config decides the payload format and
+// signature scheme, so new providers need no route of their own.
 func (h *ProviderCallbackHandler) HandleGenericCallback(c *gin.Context) {
 	providerCode := c.Param("provider")
 
+	_, cfg, err := h.callbackSvc.ResolveCallbackProvider(providerCode)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownCallbackProvider) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown provider"})
+			return
+		}
+		log.Error().Err(err).Str("provider", providerCode).Msg("Failed to resolve callback provider")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		log.Error().Err(err).Str("provider", providerCode).Msg("Failed to read provider callback body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := verifyCallbackSignature(cfg, body, c.GetHeader(cfg.SignatureHeader)); err != nil {
+		log.Warn().Err(err).Str("provider", providerCode).Msg("Provider callback rejected")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+		return
+	}
+
 	var payload map[string]any
-	if err := c.ShouldBindJSON(&payload); err != nil {
+	if err := json.Unmarshal(body, &payload); err != nil {
 		log.Error().Err(err).Str("provider", providerCode).Msg("Failed to parse provider callback body")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
 
-	log.Info().Str("provider", providerCode).Interface("payload", payload).Msg("Received provider callback")
+	log.Info().Str("provider", providerCode).Str("format", cfg.Format).RawJSON("payload", body).Msg("Received provider callback")
 
-	if err := h.callbackSvc.ProcessGenericCallback(c.Request.Context(), providerCode, payload); err != nil {
+	if err := h.callbackSvc.ProcessGenericCallback(c.Request.Context(), cfg.Format, payload); err != nil {
 		log.Error().Err(err).Str("provider", providerCode).Msg("Failed to process provider callback")
+		if status, msg := genericCallbackErrorStatus(err); status != http.StatusOK {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
 	}
 
 	utils.Success(c, http.StatusOK, "Callback received", nil)
 }
+
+// genericCallbackErrorStatus maps a processing error to the response status.
+// A callback we cannot parse yet is answered 500 so the provider retries
+// once the format is configured; a malformed one 400. Anything else was
+// stored and is retried on our side, so the provider gets 200.
+func genericCallbackErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrUnsupportedCallbackFormat):
+		return http.StatusInternalServerError, "callback format not supported"
+	case errors.Is(err, service.ErrInvalidCallbackPayload):
+		return http.StatusBadRequest, "invalid callback payload"
+	default:
+		return http.StatusOK, ""
+	}
+}
+
+// verifyCallbackSignature checks body against the provider's configured scheme.
+func verifyCallbackSignature(cfg models.ProviderCallbackConfig, body []byte, signature string) error {
+	switch cfg.Signature {
+	case models.CallbackSignatureNone:
+		if !cfg.AllowUnsigned {
+			return errors.New("no signature scheme configured and unsigned callbacks not allowed")
+		}
+		return nil
+	case models.CallbackSignatureRSASHA256:
+		if signature == "" {
+			return errors.New("missing signature")
+		}
+		pub, err := parseRSAPublicKey(cfg.PublicKey)
+		if err != nil {
+			return err
+		}
+		sig, err := base64.StdEncoding.DecodeString(signature)
+		if err != nil {
+			return fmt.Errorf("decode signature: %w", err)
+		}
+		hash := sha256.Sum256(body)
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, hash[:], sig)
+	case models.CallbackSignatureHMACSHA256:
+		if cfg.Secret == "" {
+			return errors.New("hmac secret not configured")
+		}
+		if signature == "" {
+			return errors.New("missing signature")
+		}
+		mac := hmac.New(sha256.New, []byte(cfg.Secret))
+		mac.Write(body)
+		if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(strings.ToLower(signature))) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported signature scheme %q", cfg.Signature)
+	}
+}
+
+// parseRSAPublicKey decodes a PEM-encoded PKIX RSA public key.
+func parseRSAPublicKey(pemKey string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("public key not configured or not PEM")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key: %w", err)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not RSA")
+	}
+	return rsaPub, nil
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/service"
+)
+
+func TestVerifyCallbackSignature(t *testing.T) {
+	t.Parallel()
+
+	provider := models.PPOBProvider{
+		Code:   "newpay",
+		Config: json.RawMessage(`{"callback":{"format":"kiosbank","signature":"hmac-sha256","secret":"s3cret"}}`),
+	}
+	cfg, err := provider.CallbackConfig()
+	if err != nil {
+		t.Fatalf("CallbackConfig: %v", err)
+	}
+	if cfg.Format != "kiosbank" || cfg.SignatureHeader != "X-Signature" {
+		t.Fatalf("unexpected config defaults: %+v", cfg)
+	}
+
+	body := []byte(`{"referenceID":"GRB-20260101-000001"}`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	good := hex.EncodeToString(mac.Sum(nil))
+
+	if err := verifyCallbackSignature(cfg, body, good); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := verifyCallbackSignature(cfg, body, "deadbeef"); err == nil {
+		t.Fatal("wrong signature accepted")
+	}
+	if err := verifyCallbackSignature(cfg, body, ""); err == nil {
+		t.Fatal("missing signature accepted")
+	}
+
+	plain, _ := models.PPOBProvider{Code: models.ProviderKiosbank}.CallbackConfig()
+	if plain.Format != "kiosbank" || plain.Signature != models.CallbackSignatureNone {
+		t.Fatalf("provider without config should default to its own format, unsigned: %+v", plain)
+	}
+	if err := verifyCallbackSignature(plain, body, ""); err == nil {
+		t.Fatal("unsigned callback accepted without opt-in")
+	}
+	optedIn, _ := models.PPOBProvider{
+		Code:   "newpay",
+		Config: json.RawMessage(`{"callback":{"format":"kiosbank","allowUnsigned":true}}`),
+	}.CallbackConfig()
+	if err := verifyCallbackSignature(optedIn, body, ""); err != nil {
+		t.Fatalf("opted-in unsigned callback rejected: %v", err)
+	}
+}
+
+func TestGenericCallbackErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"unsupported format", fmt.Errorf("%w: newpay", service.ErrUnsupportedCallbackFormat), http.StatusInternalServerError},
+		{"malformed payload", fmt.Errorf("%w: no reference ID", service.ErrInvalidCallbackPayload), http.StatusBadRequest},
+		{"stored for retry", errors.New("transaction not found: GRB-1"), http.StatusOK},
+	}
+	for _, tc := range cases {
+		if got, _ := genericCallbackErrorStatus(tc.err); got != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
@@ -1,17 +1,25 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/GTDGit/gtd_api/internal/middleware"
 	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/repository"
 	"github.com/GTDGit/gtd_api/internal/service"
 	"github.com/GTDGit/gtd_api/internal/utils"
 )
 
+// skuStatsMaxDays bounds the date range of GET /v1/ppob/stats/by-sku so the
+// grouped query stays cheap.
+const skuStatsMaxDays = 92
+
 // TransactionHandler handles transaction HTTP endpoints.
 type TransactionHandler struct {
 	trxService     *service.TransactionService
@@ -61,18 +69,22 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 
 	httpCode := transactionCreateHTTPCode(req.Type, trx)
 	message := transactionCreateMessage(req.Type, trx.Status)
-	data := h.formatTransaction(trx)
+	data := h.formatTransaction(c, trx)
 
 	if trx.Status == models.StatusFailed {
 		failure := service.GetCanonicalProviderFailure("")
 		if trx.FailedCode != nil {
 			failure = service.GetCanonicalProviderFailure(*trx.FailedCode)
 		}
+		if client.FlatResponses() {
+			utils.ErrorWithDataFlat(c, httpCode, message, failure.Code, failure.Message, data)
+			return
+		}
 		utils.ErrorWithData(c, httpCode, message, failure.Code, failure.Message, data)
 		return
 	}
 
-	utils.Success(c, httpCode, message, data)
+	h.success(c, client, httpCode, message, data)
 }
 
 // GetTransaction handles GET /v1/transaction/:transactionId
@@ -90,7 +102,83 @@ func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 		return
 	}
 
-	utils.Success(c, 200, "Transaction retrieved", h.formatTransaction(trx))
+	h.success(c, middleware.GetClient(c), 200, "Transaction retrieved", h.formatTransaction(c, trx))
+}
+
+// GetSKUStats handles GET /v1/ppob/stats/by-sku?start=&end=&page=&limit=
+// — the client's prepaid/payment counts and successful amount per SKU, most
+// transactions first. start/end are YYYY-MM-DD (WIB, inclusive); the default
+// is the last 30 days and the range may span at most 92 days.
+func (h *TransactionHandler) GetSKUStats(c *gin.Context) {
+	client := middleware.GetClient(c)
+	if client == nil {
+		utils.Error(c, http.StatusUnauthorized, "INVALID_TOKEN", "Unauthorized")
+		return
+	}
+
+	wib := time.FixedZone("WIB", 7*3600)
+	now := time.Now().In(wib)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, wib)
+	if v := c.Query("end"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, wib)
+		if err != nil {
+			utils.Error(c, http.StatusBadRequest, "INVALID_PARAM", "start and end must be YYYY-MM-DD")
+			return
+		}
+		end = t
+	}
+	start := end.AddDate(0, 0, -29)
+	if v := c.Query("start"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, wib)
+		if err != nil {
+			utils.Error(c, http.StatusBadRequest, "INVALID_PARAM", "start and end must be YYYY-MM-DD")
+			return
+		}
+		start = t
+	}
+	if start.After(end) {
+		utils.Error(c, http.StatusBadRequest, "INVALID_PARAM", "start must not be after end")
+		return
+	}
+	if end.Sub(start) >= skuStatsMaxDays*24*time.Hour {
+		utils.Error(c, http.StatusBadRequest, "INVALID_PARAM", "date range must not exceed 92 days")
+		return
+	}
+
+	page := 1
+	limit := 50
+	if v := c.Query("page"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			page = n
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+	}
+
+	stats, total, err := h.trxService.ListClientSKUStats(repository.ClientSKUStatsFilter{
+		ClientID:  client.ID,
+		IsSandbox: middleware.IsSandbox(c),
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Page:      page,
+		Limit:     limit,
+	})
+	if err != nil {
+		utils.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get SKU stats")
+		return
+	}
+	if stats == nil {
+		stats = []repository.ClientSKUStat{}
+	}
+
+	utils.SuccessWithPagination(c, http.StatusOK, "SKU stats retrieved successfully", gin.H{
+		"start": start.Format("2006-01-02"),
+		"end":   end.Format("2006-01-02"),
+		"skus":  stats,
+	}, page, limit, total)
 }
 
 func (h *TransactionHandler) handleError(c *gin.Context, err error) {
@@ -111,23 +199,68 @@ func (h *TransactionHandler) handleError(c *gin.Context, err error) {
 		utils.Error(c, 400, "SKU_MISMATCH", "SKU code does not match")
 	case utils.ErrCustomerMismatch:
 		utils.Error(c, 400, "CUSTOMER_MISMATCH", "Customer number does not match")
+	case utils.ErrInvalidCustomerNo:
+		utils.Error(c, 400, "INVALID_CUSTOMER_NO", "Customer number is not valid for this product")
+	case utils.ErrInvalidReferenceID:
+		utils.Error(c, 400, "INVALID_REFERENCE_ID", h.trxService.ReferenceIDRule())
 	case utils.ErrInquiryExpired:
 		utils.Error(c, 400, "INQUIRY_EXPIRED", "Inquiry has expired")
 	case utils.ErrInquiryAlreadyPaid:
 		utils.Error(c, 400, "INQUIRY_ALREADY_PAID", "Inquiry has already been paid")
+	case utils.ErrInquiryUnavailable:
+		utils.Error(c, 503, "INQUIRY_UNAVAILABLE", "Inquiry is temporarily unavailable for this product")
+	case utils.ErrPriceUnavailable:
+		utils.Error(c, 503, "PRICE_UNAVAILABLE", "Price is unavailable for this product")
+	case utils.ErrReinquiryRequired:
+		utils.Error(c, 400, "REINQUIRY_REQUIRED", "The inquiry's provider is no longer available; send a new inquiry")
+	case utils.ErrPossibleDuplicate:
+		utils.Error(c, 409, "POSSIBLE_DUPLICATE", "A matching transaction was just created under another referenceId")
 	default:
 		utils.Error(c, 500, "INTERNAL_ERROR", "Internal server error")
 	}
 }
 
-func (h *TransactionHandler) formatTransaction(trx *models.Transaction) interface{} {
+// success writes a transaction response in the client's response format.
+func (h *TransactionHandler) success(c *gin.Context, client *models.Client, code int, message string, data interface{}) {
+	if client != nil && client.FlatResponses() {
+		utils.SuccessFlat(c, code, message, data)
+		return
+	}
+	utils.Success(c, code, message, data)
+}
+
+func (h *TransactionHandler) formatTransaction(c *gin.Context, trx *models.Transaction) interface{} {
 	// Populate skuCode from product
 	if trx.SkuCode == "" && trx.ProductID > 0 {
 		if product, err := h.productService.GetProductByID(trx.ProductID); err == nil && product != nil {
 			trx.SkuCode = product.SkuCode
 		}
 	}
-	return trx
+	trx.EstimatedCompletionAt = h.trxService.EstimateCompletion(trx)
+	return publicTransaction(trx, utils.Locale(c))
+}
+
+// publicTransaction is the copy of trx a client sees. The stored description
+// keeps provider cost fields for admin support; the copy goes without them,
+// and without the serial number while the transaction is held for review as
+// a possible duplicate. failedReason is in locale, as in callbacks.
+func publicTransaction(trx *models.Transaction, locale string) *models.Transaction {
+	out := *trx
+	out.Description = models.NullableRawMessage(service.PublicTransactionDescription(json.RawMessage(trx.Description)))
+	if trx.HeldForReview() {
+		out.SerialNumber = nil
+	}
+	if trx.FailedReason != nil && trx.FailedCode != nil {
+		msg := utils.LocalizeMessage(locale, *trx.FailedCode, *trx.FailedReason)
+		out.FailedReason = &msg
+	}
+	return &out
+}
+
+// ListStatuses handles GET /v1/ppob/statuses — the transaction statuses a
+// client can receive, so integrations can handle every one of them.
+func (h *TransactionHandler) ListStatuses(c *gin.Context) {
+	utils.Success(c, http.StatusOK, "Successfully", models.ClientTransactionStatuses())
 }
 
 func transactionCreateMessage(reqType string, status models.TransactionStatus) string {
@@ -1,10 +1,17 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
 
 	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/service"
+	"github.com/GTDGit/gtd_api/internal/utils"
 )
 
 func TestTransactionCreateMessage(t *testing.T) {
@@ -83,3 +90,194 @@ func TestTransactionCreateHTTPCode(t *testing.T) {
 		})
 	}
 }
+
+func TestGetSKUStatsRejectsBadRanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &TransactionHandler{}
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{name: "no client", query: "", want: http.StatusUnauthorized},
+		{name: "bad date", query: "?start=2026-13-01", want: http.StatusBadRequest},
+		{name: "start after end", query: "?start=2026-04-10&end=2026-04-01", want: http.StatusBadRequest},
+		{name: "range too long", query: "?start=2026-01-01&end=2026-04-03", want: http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		r := gin.New()
+		r.GET("/stats", func(c *gin.Context) {
+			if tc.name != "no client" {
+				c.Set("client", &models.Client{ID: 1})
+			}
+			h.GetSKUStats(c)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stats"+tc.query, nil))
+		if w.Code != tc.want {
+			t.Fatalf("%s: status = %d, want %d (%s)", tc.name, w.Code, tc.want, w.Body.String())
+		}
+		var body map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: invalid JSON body: %v", tc.name, err)
+		}
+	}
+}
+
+func TestListStatusesCoversClientContract(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/statuses", (&TransactionHandler{}).ListStatuses)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/statuses", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", w.Code, w.Body.String())
+	}
+
+	var body struct {
+		Data []models.TransactionStatusInfo `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON body: %v", err)
+	}
+	got := make(map[models.TransactionStatus]bool, len(body.Data))
+	for _, s := range body.Data {
+		if s.Description == "" {
+			t.Errorf("%s has no description", s.Status)
+		}
+		got[s.Status] = s.Final
+	}
+	want := map[models.TransactionStatus]bool{
+		models.StatusProcessing: false,
+		models.StatusPending:    false,
+		models.StatusSuccess:    true,
+		models.StatusFailed:     true,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("statuses = %v, want %v", got, want)
+	}
+	for s, final := range want {
+		if f, ok := got[s]; !ok || f != final {
+			t.Errorf("%s: listed=%v final=%v, want final=%v", s, ok, f, final)
+		}
+		if !s.ClientVisible() {
+			t.Errorf("%s not client visible", s)
+		}
+	}
+	if models.TransactionStatus("PendingReview").ClientVisible() {
+		t.Error("unknown status reported client visible")
+	}
+}
+
+func TestTransactionResponseFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	trx := &models.Transaction{TransactionID: "GRB-1", Status: models.StatusSuccess}
+
+	respond := func(client *models.Client) map[string]json.RawMessage {
+		r := gin.New()
+		r.GET("/trx", func(c *gin.Context) {
+			(&TransactionHandler{}).success(c, client, http.StatusOK, "Transaction retrieved", trx)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/trx", nil))
+		var body map[string]json.RawMessage
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid JSON body: %v", err)
+		}
+		return body
+	}
+
+	envelope := respond(&models.Client{})
+	if _, ok := envelope["data"]; !ok || envelope["transactionId"] != nil {
+		t.Fatalf("default response should nest the transaction under data: %v", envelope)
+	}
+
+	flat := respond(&models.Client{ResponseFormat: models.ResponseFormatFlat})
+	if _, ok := flat["data"]; ok {
+		t.Fatalf("flat response has data: %v", flat)
+	}
+	if string(flat["transactionId"]) != `"GRB-1"` || string(flat["success"]) != "true" || flat["meta"] == nil {
+		t.Fatalf("flat response = %v, want transaction fields beside the envelope fields", flat)
+	}
+}
+
+func TestFormatTransactionWithholdsHeldSerialNumber(t *testing.T) {
+	t.Parallel()
+
+	h := &TransactionHandler{trxService: &service.TransactionService{}}
+	sn := "SN-0001"
+	heldAt := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		holdAt *time.Time
+		wantSN bool
+	}{
+		{name: "released", wantSN: true},
+		{name: "held for review", holdAt: &heldAt, wantSN: false},
+	}
+	for _, tc := range tests {
+		trx := &models.Transaction{TransactionID: "GRB-1", SkuCode: "xld10", Status: models.StatusSuccess, SerialNumber: &sn, ReviewHoldAt: tc.holdAt}
+		raw, err := json.Marshal(h.formatTransaction(&gin.Context{}, trx))
+		if err != nil {
+			t.Fatalf("%s: marshal: %v", tc.name, err)
+		}
+		var body map[string]any
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Fatalf("%s: unmarshal: %v", tc.name, err)
+		}
+		if _, ok := body["serialNumber"]; ok != tc.wantSN {
+			t.Errorf("%s: serialNumber in response = %v, want %v (%s)", tc.name, ok, tc.wantSN, raw)
+		}
+		if trx.SerialNumber == nil {
+			t.Errorf("%s: stored transaction lost its serial number", tc.name)
+		}
+	}
+}
+
+func TestTransactionResponsesLocalizeFailedReason(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &TransactionHandler{trxService: &service.TransactionService{}}
+	code, reason := "PROVIDER_TIMEOUT", "Provider did not respond in time"
+
+	// paths are the two responses that carry a transaction: the POST
+	// failure and the GET (or successful POST) envelope.
+	paths := map[string]func(c *gin.Context, data interface{}){
+		"POST failed": func(c *gin.Context, data interface{}) {
+			utils.ErrorWithData(c, http.StatusGatewayTimeout, "Transaction failed", code, reason, data)
+		},
+		"GET": func(c *gin.Context, data interface{}) {
+			h.success(c, &models.Client{}, http.StatusOK, "Transaction retrieved", data)
+		},
+	}
+	locales := []struct {
+		locale string
+		want   string
+	}{
+		{utils.LocaleEN, reason},
+		{utils.LocaleID, "Provider tidak merespons tepat waktu"},
+	}
+	for name, write := range paths {
+		for _, tc := range locales {
+			r := gin.New()
+			r.GET("/trx", func(c *gin.Context) {
+				utils.SetLocale(c, tc.locale)
+				trx := &models.Transaction{TransactionID: "GRB-1", SkuCode: "xld10", Status: models.StatusFailed, FailedCode: &code, FailedReason: &reason}
+				write(c, h.formatTransaction(c, trx))
+			})
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/trx", nil))
+			var body struct {
+				Data struct {
+					FailedReason string `json:"failedReason"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("%s/%s: invalid JSON body: %v", name, tc.locale, err)
+			}
+			if body.Data.FailedReason != tc.want {
+				t.Errorf("%s/%s: failedReason = %q, want %q", name, tc.locale, body.Data.FailedReason, tc.want)
+			}
+		}
+	}
+}
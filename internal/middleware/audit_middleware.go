@@ -0,0 +1,72 @@
+package middleware
+
+import (
+    "bytes"
+    "context"
+    "io"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/rs/zerolog/log"
+
+    "github.com/GTDGit/gtd_api/internal/service"
+)
+
+// maxAuditBody caps the request body kept for the audit trail; larger bodies
+// are still passed to the handler but recorded without changes.
+const maxAuditBody = 64 << 10
+
+// AdminAudit records every mutating admin request (anything but GET, HEAD and
+// OPTIONS) in the admin audit trail once the handler has answered. Must be
+// chained after JWTMiddleware.Handle so the actor is in context. Secrets in
+// the body are redacted by the service before the row is written.
+func AdminAudit(auditSvc *service.AdminAuditService) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        switch c.Request.Method {
+        case http.MethodGet, http.MethodHead, http.MethodOptions:
+            c.Next()
+            return
+        }
+
+        var body []byte
+        if c.Request.Body != nil {
+            raw, err := io.ReadAll(io.LimitReader(c.Request.Body, maxAuditBody+1))
+            c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(raw), c.Request.Body))
+            if err == nil && len(raw) <= maxAuditBody {
+                body = raw
+            }
+        }
+
+        c.Next()
+
+        params := make([]string, 0, len(c.Params))
+        for _, p := range c.Params {
+            params = append(params, p.Key+"="+p.Value)
+        }
+        route := c.FullPath()
+        if route == "" {
+            route = c.Request.URL.Path
+        }
+
+        ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), 5*time.Second)
+        defer cancel()
+        err := auditSvc.Record(ctx, service.AdminAuditEntry{
+            ActorID:    c.GetInt("user_id"),
+            ActorEmail: c.GetString("email"),
+            Method:     c.Request.Method,
+            Route:      route,
+            Params:     params,
+            Body:       body,
+            StatusCode: c.Writer.Status(),
+            IP:         c.ClientIP(),
+        })
+        if err != nil {
+            log.Error().Err(err).
+                Str("actor", c.GetString("email")).
+                Str("method", c.Request.Method).
+                Str("route", route).
+                Msg("admin audit: record failed")
+        }
+    }
+}
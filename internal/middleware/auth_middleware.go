@@ -27,6 +27,9 @@ func NewAuthMiddleware(authService *service.AuthService) *AuthMiddleware {
 // Handle returns a Gin middleware function that enforces authentication.
 func (m *AuthMiddleware) Handle() gin.HandlerFunc {
     return func(c *gin.Context) {
+        // Errors before the client is known follow Accept-Language only.
+        utils.SetLocale(c, utils.ResolveLocale(c.GetHeader("Accept-Language"), ""))
+
         // 1. Extract Bearer token
         authHeader := c.GetHeader("Authorization")
         if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
@@ -66,6 +69,7 @@ func (m *AuthMiddleware) Handle() gin.HandlerFunc {
         c.Set("client", client)
         c.Set("is_sandbox", isSandbox)
         c.Set("client_id", client.ID)
+        utils.SetLocale(c, utils.ResolveLocale(c.GetHeader("Accept-Language"), client.Locale))
 
         c.Next()
     }
@@ -3,6 +3,10 @@ package middleware
 import (
     "sync"
     "time"
+
+    "github.com/gin-gonic/gin"
+
+    "github.com/GTDGit/gtd_api/internal/utils"
 )
 
 // Rate limiter ONLY for invalid auth attempts
@@ -63,3 +67,57 @@ func (r *InvalidAuthRateLimiter) cleanup() {
         r.mu.Unlock()
     }
 }
+
+// ClientRateLimiter is a fixed-window limiter keyed by authenticated client.
+// Use it for developer tooling endpoints that must not be hammered.
+type ClientRateLimiter struct {
+    mu       sync.Mutex
+    limit    int
+    window   time.Duration
+    attempts map[int]*attemptInfo
+}
+
+// NewClientRateLimiter allows limit requests per client per window.
+func NewClientRateLimiter(limit int, window time.Duration) *ClientRateLimiter {
+    return &ClientRateLimiter{
+        limit:    limit,
+        window:   window,
+        attempts: make(map[int]*attemptInfo),
+    }
+}
+
+// Limit returns the requests allowed per client per window.
+func (r *ClientRateLimiter) Limit() (int, time.Duration) {
+    return r.limit, r.window
+}
+
+// Allow records a request for clientID and reports whether it is within the limit.
+func (r *ClientRateLimiter) Allow(clientID int) bool {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    now := time.Now()
+    info, exists := r.attempts[clientID]
+    if !exists || now.Sub(info.firstAt) > r.window {
+        r.attempts[clientID] = &attemptInfo{count: 1, firstAt: now}
+        return true
+    }
+    if info.count >= r.limit {
+        return false
+    }
+    info.count++
+    return true
+}
+
+// Handle rejects requests over the limit with 429. It must run after the
+// API key auth middleware so client_id is set.
+func (r *ClientRateLimiter) Handle() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if !r.Allow(c.GetInt("client_id")) {
+            utils.Error(c, 429, "RATE_LIMITED", "Too many requests, please try again later")
+            c.Abort()
+            return
+        }
+        c.Next()
+    }
+}
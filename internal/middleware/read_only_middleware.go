@@ -0,0 +1,42 @@
+package middleware
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+
+    "github.com/GTDGit/gtd_api/internal/utils"
+)
+
+// ReadOnlyChecker reports whether global read-only mode is on
+// (service.ReadOnlyService).
+type ReadOnlyChecker interface {
+    ReadOnly() bool
+}
+
+// ReadOnly rejects every write (anything but GET, HEAD and OPTIONS) with 503
+// READ_ONLY_MODE while read-only mode is on, so reads keep working during
+// risky maintenance. allow lists "METHOD /route/pattern" entries that stay
+// open, such as the admin toggle that turns the mode off again. Register it
+// on the engine before any route.
+func ReadOnly(checker ReadOnlyChecker, allow ...string) gin.HandlerFunc {
+    allowed := make(map[string]bool, len(allow))
+    for _, a := range allow {
+        allowed[a] = true
+    }
+
+    return func(c *gin.Context) {
+        switch c.Request.Method {
+        case http.MethodGet, http.MethodHead, http.MethodOptions:
+            c.Next()
+            return
+        }
+        if allowed[c.Request.Method+" "+c.FullPath()] || !checker.ReadOnly() {
+            c.Next()
+            return
+        }
+
+        utils.Error(c, http.StatusServiceUnavailable, "READ_ONLY_MODE", "The API is in read-only mode for maintenance; writes are temporarily disabled")
+        c.Abort()
+    }
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeReadOnly bool
+
+func (f fakeReadOnly) ReadOnly() bool { return bool(f) }
+
+func newReadOnlyRouter(on bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ReadOnly(fakeReadOnly(on), "PUT /v1/admin/system/read-only", "POST /v1/webhook/:provider"))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/v1/ppob/products", ok)
+	r.POST("/v1/ppob/transaction", ok)
+	r.PUT("/v1/admin/system/read-only", ok)
+	r.POST("/v1/webhook/:provider", ok)
+	return r
+}
+
+func TestReadOnly(t *testing.T) {
+	tests := []struct {
+		name   string
+		on     bool
+		method string
+		path   string
+		want   int
+	}{
+		{"write allowed when off", false, http.MethodPost, "/v1/ppob/transaction", http.StatusOK},
+		{"read allowed when on", true, http.MethodGet, "/v1/ppob/products", http.StatusOK},
+		{"write rejected when on", true, http.MethodPost, "/v1/ppob/transaction", http.StatusServiceUnavailable},
+		{"allow-listed write when on", true, http.MethodPut, "/v1/admin/system/read-only", http.StatusOK},
+		{"allow-listed route pattern when on", true, http.MethodPost, "/v1/webhook/kiosbank", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newReadOnlyRouter(tt.on).ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, w.Code)
+			}
+			if tt.want == http.StatusServiceUnavailable {
+				if code := errorCode(t, w); code != "READ_ONLY_MODE" {
+					t.Fatalf("expected READ_ONLY_MODE, got %q", code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+
+    "github.com/GTDGit/gtd_api/internal/utils"
+)
+
+// Timeout bounds a route group's handlers with a context deadline. The
+// deadline rides on c.Request.Context(), so services and outbound calls that
+// take the request context stop once it passes. A handler that has not
+// written by then, or that answers with a 5xx caused by the expired context,
+// is replaced with a clean 504 REQUEST_TIMEOUT. A zero duration disables it.
+func Timeout(d time.Duration) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if d <= 0 {
+            c.Next()
+            return
+        }
+
+        ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+        defer cancel()
+        c.Request = c.Request.WithContext(ctx)
+
+        w := &timeoutWriter{ResponseWriter: c.Writer, ctx: ctx}
+        c.Writer = w
+        c.Next()
+        c.Writer = w.ResponseWriter
+
+        if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+            return
+        }
+        if w.suppressed || !c.Writer.Written() {
+            utils.Error(c, http.StatusGatewayTimeout, "REQUEST_TIMEOUT", "Request exceeded the time limit")
+        }
+    }
+}
+
+// timeoutWriter drops a 5xx body written after the deadline so Timeout can
+// answer with 504 instead of a generic internal error.
+type timeoutWriter struct {
+    gin.ResponseWriter
+    ctx        context.Context
+    suppressed bool
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+    if w.suppress() {
+        return len(b), nil
+    }
+    return w.ResponseWriter.Write(b)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+    if w.suppress() {
+        return len(s), nil
+    }
+    return w.ResponseWriter.WriteString(s)
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+    if w.suppress() {
+        return
+    }
+    w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *timeoutWriter) suppress() bool {
+    if w.suppressed {
+        return true
+    }
+    if w.ResponseWriter.Written() || w.ResponseWriter.Status() < http.StatusInternalServerError {
+        return false
+    }
+    if !errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+        return false
+    }
+    w.suppressed = true
+    return true
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+func newTimeoutRouter(d time.Duration, h gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/x", Timeout(d), h)
+	return r
+}
+
+func serveTimeout(r *gin.Engine) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x", nil))
+	return w
+}
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal: %v body=%s", err, w.Body.String())
+	}
+	errInfo, _ := body["error"].(map[string]any)
+	code, _ := errInfo["code"].(string)
+	return code
+}
+
+func TestTimeout_PassesThroughFastHandler(t *testing.T) {
+	r := newTimeoutRouter(time.Second, func(c *gin.Context) {
+		if _, ok := c.Request.Context().Deadline(); !ok {
+			t.Error("expected deadline on request context")
+		}
+		c.Status(http.StatusOK)
+	})
+
+	if w := serveTimeout(r); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestTimeout_RespondsWhenHandlerWritesNothing(t *testing.T) {
+	r := newTimeoutRouter(10*time.Millisecond, func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+
+	w := serveTimeout(r)
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", w.Code)
+	}
+	if code := errorCode(t, w); code != "REQUEST_TIMEOUT" {
+		t.Fatalf("expected REQUEST_TIMEOUT, got %q", code)
+	}
+}
+
+func TestTimeout_ReplacesInternalErrorAfterDeadline(t *testing.T) {
+	r := newTimeoutRouter(10*time.Millisecond, func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		utils.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", c.Request.Context().Err().Error())
+	})
+
+	w := serveTimeout(r)
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d body=%s", w.Code, w.Body.String())
+	}
+	if code := errorCode(t, w); code != "REQUEST_TIMEOUT" {
+		t.Fatalf("expected REQUEST_TIMEOUT, got %q", code)
+	}
+}
+
+func TestTimeout_KeepsResponseWrittenAfterDeadline(t *testing.T) {
+	r := newTimeoutRouter(10*time.Millisecond, func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		utils.Success(c, http.StatusOK, "Successfully", nil)
+	})
+
+	if w := serveTimeout(r); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestTimeout_ZeroDisables(t *testing.T) {
+	r := newTimeoutRouter(0, func(c *gin.Context) {
+		if _, ok := c.Request.Context().Deadline(); ok {
+			t.Error("expected no deadline when disabled")
+		}
+		c.Status(http.StatusOK)
+	})
+
+	if w := serveTimeout(r); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
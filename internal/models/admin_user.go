@@ -14,3 +14,17 @@ type AdminUser struct {
 	CreatedAt    time.Time  `db:"created_at" json:"createdAt"`
 	UpdatedAt    time.Time  `db:"updated_at" json:"updatedAt"`
 }
+
+// AdminAuditLog is one admin action in the append-only audit trail. Changes
+// is the request body with secret-looking values redacted.
+type AdminAuditLog struct {
+	ID         int64              `db:"id" json:"id"`
+	ActorID    *int               `db:"actor_id" json:"actorId,omitempty"`
+	ActorEmail string             `db:"actor_email" json:"actorEmail"`
+	Action     string             `db:"action" json:"action"`
+	Target     string             `db:"target" json:"target"`
+	Changes    NullableRawMessage `db:"changes" json:"changes,omitempty"`
+	StatusCode int                `db:"status_code" json:"statusCode"`
+	IPAddress  string             `db:"ip_address" json:"ipAddress"`
+	CreatedAt  time.Time          `db:"created_at" json:"createdAt"`
+}
@@ -41,6 +41,10 @@ type CallbackLog struct {
 	CreatedAt     time.Time       `db:"created_at"`
 	NextRetryAt   *time.Time      `db:"next_retry_at"`
 	DeliveredAt   *time.Time      `db:"delivered_at"`
+
+	// AcknowledgedAt is set when the client acknowledged the callback itself;
+	// it stops retries regardless of the HTTP status we saw.
+	AcknowledgedAt *time.Time `db:"acknowledged_at"`
 }
 
 // DigiflazzCallback stores raw callback payload from Digiflazz.
@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Client represents a registered API consumer of the Gerbang gateway.
 // Sensitive keys are omitted from JSON responses for security.
@@ -12,9 +18,152 @@ type Client struct {
 	SandboxKey     string    `db:"sandbox_key" json:"sandboxKey,omitempty"`
 	CallbackURL    string    `db:"callback_url" json:"callbackUrl"`
 	CallbackSecret string    `db:"callback_secret" json:"callbackSecret,omitempty"`
+	CallbackMethod string    `db:"callback_method" json:"callbackMethod"` // POST (default), PUT or PATCH
 	IPWhitelist    []string  `db:"ip_whitelist" json:"ipWhitelist"`
 	Scopes         []string  `db:"scopes" json:"scopes"`
 	IsActive       bool      `db:"is_active" json:"isActive"`
+	HashCustomerNo bool      `db:"hash_customer_no" json:"hashCustomerNo"` // store salted hash, mask raw after retention
 	CreatedAt      time.Time `db:"created_at" json:"createdAt"`
 	UpdatedAt      time.Time `db:"updated_at" json:"updatedAt"`
+
+	// CallbackHeaders are static headers added to every outgoing callback
+	// (e.g. a gateway Authorization bearer). Kept out of JSON like other secrets.
+	CallbackHeaders CallbackHeaders `db:"callback_headers" json:"-"`
+
+	// TransactionIDPrefix overrides the global transaction ID prefix for
+	// white-label partners (nil = use the default).
+	TransactionIDPrefix *string `db:"transaction_id_prefix" json:"transactionIdPrefix,omitempty"`
+
+	// CallbackAttemptInfo adds the provider attempt count and fallback/backup
+	// usage to success callbacks.
+	CallbackAttemptInfo bool `db:"callback_attempt_info" json:"callbackAttemptInfo"`
+
+	// CallbackOrdered delivers a transaction's callbacks one at a time and in
+	// the order they were raised, for clients whose webhook handlers are not
+	// idempotent. Off by default: deliveries for a transaction may overlap.
+	CallbackOrdered bool `db:"callback_ordered" json:"callbackOrdered"`
+
+	// PriceRounding overrides the global rounding of prepaid sell prices and
+	// listed prices, in ParsePriceRounding format (nil = use the default).
+	PriceRounding *string `db:"price_rounding" json:"priceRounding,omitempty"`
+
+	// Locale is the language of client-facing error messages ("en" or "id")
+	// when the request has no supported Accept-Language.
+	Locale string `db:"locale" json:"locale"`
+
+	// CallbackSignatureAlgorithm (sha256 or sha512) and
+	// CallbackSignatureEncoding (hex or base64) select the HMAC of the
+	// X-GTD-Signature callback header; empty means sha256 and hex.
+	CallbackSignatureAlgorithm string `db:"callback_signature_algorithm" json:"callbackSignatureAlgorithm"`
+	CallbackSignatureEncoding  string `db:"callback_signature_encoding" json:"callbackSignatureEncoding"`
+
+	// TransactionPriority is the client's worker priority tier (0-9): the
+	// priority of its transactions and the most a request may ask for.
+	TransactionPriority int `db:"transaction_priority" json:"transactionPriority"`
+
+	// CallbackBatchSize > 0 delivers PPOB callbacks in batches of up to that
+	// many events, as one JSON array with one signature; 0 sends each event
+	// on its own.
+	CallbackBatchSize int `db:"callback_batch_size" json:"callbackBatchSize"`
+
+	// ResponseFormat is the shape of PPOB transaction responses: envelope
+	// (default) or flat. Presentational only; the fields are the same.
+	ResponseFormat string `db:"response_format" json:"responseFormat"`
+
+	// DuplicateWindowSeconds > 0 checks each prepaid and payment request for
+	// a transaction of the same customer, product and price created that
+	// many seconds before under another reference_id; DuplicateAction is
+	// what a match does (warn or block).
+	DuplicateWindowSeconds int    `db:"duplicate_window_seconds" json:"duplicateWindowSeconds"`
+	DuplicateAction        string `db:"duplicate_action" json:"duplicateAction"`
+}
+
+// MaxTransactionPriority is the highest transaction worker priority.
+const MaxTransactionPriority = 9
+
+// Callback signature algorithms and encodings.
+const (
+	CallbackSignatureSHA256 = "sha256"
+	CallbackSignatureSHA512 = "sha512"
+	CallbackSignatureHex    = "hex"
+	CallbackSignatureBase64 = "base64"
+)
+
+// CallbackSignatureFormat returns the client's callback signature algorithm
+// and encoding, falling back to sha256 and hex for empty or unknown values.
+func (c *Client) CallbackSignatureFormat() (algorithm, encoding string) {
+	algorithm, encoding = CallbackSignatureSHA256, CallbackSignatureHex
+	if strings.EqualFold(c.CallbackSignatureAlgorithm, CallbackSignatureSHA512) {
+		algorithm = CallbackSignatureSHA512
+	}
+	if strings.EqualFold(c.CallbackSignatureEncoding, CallbackSignatureBase64) {
+		encoding = CallbackSignatureBase64
+	}
+	return algorithm, encoding
+}
+
+// Transaction response formats.
+const (
+	ResponseFormatEnvelope = "envelope" // transaction under "data"
+	ResponseFormatFlat     = "flat"     // transaction fields at the top level
+)
+
+// FlatResponses reports whether the client takes flat transaction responses.
+func (c *Client) FlatResponses() bool {
+	return strings.EqualFold(c.ResponseFormat, ResponseFormatFlat)
+}
+
+// Likely-duplicate transaction actions.
+const (
+	DuplicateActionWarn  = "warn"  // flag the response with possibleDuplicateOf
+	DuplicateActionBlock = "block" // reject the request
+)
+
+// DuplicateWindow is how far back the client's likely-duplicate check
+// looks, 0 when it is off.
+func (c *Client) DuplicateWindow() time.Duration {
+	if c.DuplicateWindowSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(c.DuplicateWindowSeconds) * time.Second
+}
+
+// BlocksDuplicates reports whether a likely duplicate is rejected rather
+// than flagged.
+func (c *Client) BlocksDuplicates() bool {
+	return strings.EqualFold(c.DuplicateAction, DuplicateActionBlock)
+}
+
+// CallbackHeaders is a JSONB map of static header name -> value.
+type CallbackHeaders map[string]string
+
+// Scan implements sql.Scanner.
+func (h *CallbackHeaders) Scan(value interface{}) error {
+	if value == nil {
+		*h = nil
+		return nil
+	}
+	var raw []byte
+	switch v := value.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("unsupported callback_headers type %T", value)
+	}
+	m := CallbackHeaders{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return err
+	}
+	*h = m
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (h CallbackHeaders) Value() (driver.Value, error) {
+	if h == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(h)
 }
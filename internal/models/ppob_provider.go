@@ -2,6 +2,8 @@ package models
 
 import (
 	"encoding/json"
+	"math"
+	"strings"
 	"time"
 )
 
@@ -26,6 +28,128 @@ type PPOBProvider struct {
 	Config    json.RawMessage `db:"config" json:"config,omitempty"`
 	CreatedAt time.Time       `db:"created_at" json:"-"`
 	UpdatedAt time.Time       `db:"updated_at" json:"updatedAt"`
+
+	// Daily WIB cut-off window (HH:MM:SS) during which routing skips the
+	// provider; 00:00:00-00:00:00 means none, end before start crosses midnight.
+	CutOffStart string `db:"cut_off_start" json:"cutOffStart"`
+	CutOffEnd   string `db:"cut_off_end" json:"cutOffEnd"`
+
+	// LastPriceListSize is the item count of the last price list a sync fully
+	// applied (nil before the first); a much smaller list is not trusted.
+	LastPriceListSize *int `db:"last_price_list_size" json:"lastPriceListSize,omitempty"`
+}
+
+// MinBalance returns the low-float alert threshold from config.minBalance
+// (0 = no alert).
+func (p PPOBProvider) MinBalance() int64 {
+	var cfg struct {
+		MinBalance int64 `json:"minBalance"`
+	}
+	if len(p.Config) == 0 || json.Unmarshal(p.Config, &cfg) != nil {
+		return 0
+	}
+	return cfg.MinBalance
+}
+
+// MaxRoutingWeight caps config.routingWeight.
+const MaxRoutingWeight = 100
+
+// RoutingWeight returns config.routingWeight used to spread volume among
+// tied providers; missing or non-positive values count as 1.
+func (p PPOBProvider) RoutingWeight() int {
+	var cfg struct {
+		RoutingWeight int `json:"routingWeight"`
+	}
+	if len(p.Config) == 0 || json.Unmarshal(p.Config, &cfg) != nil || cfg.RoutingWeight < 1 {
+		return 1
+	}
+	return min(cfg.RoutingWeight, MaxRoutingWeight)
+}
+
+// DerivedSKUConfig is the optional "derivedSku" object of
+// ppob_providers.config. For providers whose SKU codes follow the product's
+// own code, the price sync creates the missing mappings from Template instead
+// of requiring each one to be mapped by hand. Categories and Types, when set,
+// limit which products are derived.
+type DerivedSKUConfig struct {
+	// Template builds the provider SKU code: {skuCode} is the product SKU code,
+	// {skuCodeLower} the same in lower case and {skuSuffix} the part after its
+	// last "-" (ALT-EDU-9022 -> 9022).
+	Template   string   `json:"template"`
+	Categories []string `json:"categories"`
+	Types      []string `json:"types"`
+}
+
+// DerivedSKU returns config.derivedSku, or nil when the provider has no rule
+// (explicit mappings only).
+func (p PPOBProvider) DerivedSKU() *DerivedSKUConfig {
+	var cfg struct {
+		DerivedSKU *DerivedSKUConfig `json:"derivedSku"`
+	}
+	if len(p.Config) == 0 || json.Unmarshal(p.Config, &cfg) != nil {
+		return nil
+	}
+	if cfg.DerivedSKU == nil || strings.TrimSpace(cfg.DerivedSKU.Template) == "" {
+		return nil
+	}
+	return cfg.DerivedSKU
+}
+
+// Code derives the provider SKU code for a product SKU code.
+func (c DerivedSKUConfig) Code(skuCode string) string {
+	suffix := skuCode
+	if i := strings.LastIndex(skuCode, "-"); i >= 0 {
+		suffix = skuCode[i+1:]
+	}
+	return strings.NewReplacer(
+		"{skuCode}", skuCode,
+		"{skuCodeLower}", strings.ToLower(skuCode),
+		"{skuSuffix}", suffix,
+	).Replace(strings.TrimSpace(c.Template))
+}
+
+// Callback signature schemes a provider can declare in its config.
+const (
+	CallbackSignatureNone       = "none"
+	CallbackSignatureRSASHA256  = "rsa-sha256"  // base64 PKCS#1 v1.5 over the raw body
+	CallbackSignatureHMACSHA256 = "hmac-sha256" // hex HMAC of the raw body
+)
+
+// ProviderCallbackConfig is the "callback" object of ppob_providers.config.
+// It lets a provider added via admin receive callbacks on
+// /v1/webhook/:provider without code changes.
+type ProviderCallbackConfig struct {
+	Format          string `json:"format"`          // payload parser: kiosbank or alterra (defaults to the provider code)
+	Signature       string `json:"signature"`       // one of the CallbackSignature* schemes (default none)
+	SignatureHeader string `json:"signatureHeader"` // default X-Signature
+	PublicKey       string `json:"publicKey"`       // PEM, for rsa-sha256
+	Secret          string `json:"secret"`          // for hmac-sha256
+	// AllowUnsigned opts a provider with signature none into unsigned
+	// callbacks; without it they are rejected, as anyone could post them.
+	AllowUnsigned bool `json:"allowUnsigned"`
+}
+
+// CallbackConfig decodes the provider's callback settings and fills defaults.
+func (p PPOBProvider) CallbackConfig() (ProviderCallbackConfig, error) {
+	var wrapper struct {
+		Callback ProviderCallbackConfig `json:"callback"`
+	}
+	if len(p.Config) > 0 && string(p.Config) != "null" {
+		if err := json.Unmarshal(p.Config, &wrapper); err != nil {
+			return ProviderCallbackConfig{}, err
+		}
+	}
+	cfg := wrapper.Callback
+	if cfg.Format == "" {
+		cfg.Format = string(p.Code)
+	}
+	if cfg.Signature == "" {
+		cfg.Signature = CallbackSignatureNone
+	}
+	if cfg.SignatureHeader == "" {
+		cfg.SignatureHeader = "X-Signature"
+	}
+	return cfg, nil
 }
 
 // PPOBProviderSKU maps our products to provider's SKUs
@@ -53,6 +177,14 @@ type PPOBProviderSKU struct {
 	SkuCode      string       `db:"sku_code" json:"skuCode,omitempty"`
 	ProductType  string       `db:"product_type" json:"productType,omitempty"`
 	IsBackup     bool         `db:"is_backup" json:"isBackup,omitempty"`
+
+	// CommissionPercent, when set, replaces Commission with this percentage
+	// of Admin at selection time.
+	CommissionPercent *float64 `db:"commission_percent" json:"commissionPercent,omitempty"`
+
+	// IsDerived marks a mapping created from the provider's config.derivedSku
+	// rule rather than mapped explicitly.
+	IsDerived bool `db:"is_derived" json:"isDerived"`
 }
 
 // EffectiveAdmin returns admin minus commission
@@ -60,6 +192,14 @@ func (s PPOBProviderSKU) EffectiveAdmin() int {
 	return s.Admin - s.Commission
 }
 
+// PercentCommission returns percent% of admin rounded half up to the rupiah.
+// The percentage is first fixed to the column's 4 decimals so float noise
+// from the scanned NUMERIC cannot change the rounding.
+func PercentCommission(admin int, percent float64) int {
+	scaled := int64(math.Round(percent * 10000))
+	return int((int64(admin)*scaled + 500000) / 1000000)
+}
+
 // PPOBProviderHealth tracks provider performance
 type PPOBProviderHealth struct {
 	ID                int        `db:"id" json:"id"`
@@ -72,6 +212,8 @@ type PPOBProviderHealth struct {
 	LastFailureReason *string    `db:"last_failure_reason" json:"lastFailureReason,omitempty"`
 	AvgResponseTimeMs int        `db:"avg_response_time_ms" json:"avgResponseTimeMs"`
 	HealthScore       float64    `db:"health_score" json:"healthScore"`
+	LastBalance       *int64     `db:"last_balance" json:"lastBalance,omitempty"`
+	LastBalanceAt     *time.Time `db:"last_balance_at" json:"lastBalanceAt,omitempty"`
 	Date              time.Time  `db:"date" json:"date"`
 	CreatedAt         time.Time  `db:"created_at" json:"-"`
 	UpdatedAt         time.Time  `db:"updated_at" json:"updatedAt"`
@@ -81,13 +223,38 @@ type PPOBProviderHealth struct {
 	ProviderName string       `db:"provider_name" json:"providerName,omitempty"`
 }
 
+// PPOBProviderMaintenanceWindow is a scheduled period during which a provider
+// is treated as unavailable for routing.
+type PPOBProviderMaintenanceWindow struct {
+	ID         int       `db:"id" json:"id"`
+	ProviderID int       `db:"provider_id" json:"providerId"`
+	StartsAt   time.Time `db:"starts_at" json:"startsAt"`
+	EndsAt     time.Time `db:"ends_at" json:"endsAt"`
+	Reason     *string   `db:"reason" json:"reason,omitempty"`
+	CreatedAt  time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updatedAt"`
+
+	// Joined fields
+	ProviderCode ProviderCode `db:"provider_code" json:"providerCode,omitempty"`
+}
+
+// ProviderBalancePoint summarizes a provider's sampled deposit balance over
+// one period (hour or day) of a balance trend.
+type ProviderBalancePoint struct {
+	Period  time.Time `db:"period" json:"period"`
+	Min     int64     `db:"min_balance" json:"min"`
+	Max     int64     `db:"max_balance" json:"max"`
+	Last    int64     `db:"last_balance" json:"last"`
+	Samples int       `db:"samples" json:"samples"`
+}
+
 // PPOBProviderCallback stores provider callback data
 type PPOBProviderCallback struct {
 	ID            int             `db:"id" json:"id"`
 	ProviderID    int             `db:"provider_id" json:"providerId"`
 	ProviderCode  ProviderCode    `db:"-" json:"providerCode"` // Used internally, not in DB directly
 	ProviderRefID string          `db:"provider_ref_id" json:"providerRefId"`
-	TransactionID int             `db:"transaction_id" json:"transactionId"`
+	TransactionID *int            `db:"transaction_id" json:"transactionId,omitempty"` // nil until a transaction matches
 	Payload       json.RawMessage `db:"payload" json:"payload"`
 	RC            string          `db:"-" json:"rc"` // Extracted RC code
 	Status        *string         `db:"status" json:"status,omitempty"`
@@ -95,6 +262,8 @@ type PPOBProviderCallback struct {
 	IsProcessed   bool            `db:"is_processed" json:"isProcessed"`
 	ProcessedAt   *time.Time      `db:"processed_at" json:"processedAt,omitempty"`
 	ProcessError  *string         `db:"process_error" json:"processError,omitempty"`
+	RetryCount    int             `db:"retry_count" json:"retryCount"`
+	NextRetryAt   *time.Time      `db:"next_retry_at" json:"nextRetryAt,omitempty"`
 	CreatedAt     time.Time       `db:"created_at" json:"createdAt"`
 }
 
@@ -108,6 +277,10 @@ type ProviderOption struct {
 	Admin           int          `db:"admin" json:"admin"`
 	Commission      int          `db:"commission" json:"commission"`
 	IsBackup        bool         `db:"is_backup" json:"isBackup"`
+	Priority        int          `db:"priority" json:"-"`
+
+	CommissionPercent *float64 `db:"commission_percent" json:"commissionPercent,omitempty"`
+	IsDerived         bool     `db:"is_derived" json:"isDerived,omitempty"`
 }
 
 // EffectiveAdmin returns admin minus commission (what customer effectively pays in admin)
@@ -130,3 +303,10 @@ type ProductWithBestPrice struct {
 	Description   string      `db:"description" json:"description,omitempty"`
 	ProviderCount int         `db:"provider_count" json:"providerCount,omitempty"`
 }
+
+// ProductProviderPrice is one non-backup provider's current price for a product.
+type ProductProviderPrice struct {
+	ProductID    int          `db:"product_id"`
+	ProviderCode ProviderCode `db:"provider_code"`
+	Price        int          `db:"price"`
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rounding modes of a PriceRounding.
+const (
+	RoundExact   = "exact"   // prices as computed, to the rupiah
+	RoundUp      = "up"      // up to the next multiple of Unit
+	RoundDown    = "down"    // down to the previous multiple of Unit
+	RoundNearest = "nearest" // to the nearest multiple of Unit, halves up
+)
+
+// PriceRounding rounds client-facing prices to a multiple of Unit. The zero
+// value is exact.
+type PriceRounding struct {
+	Mode string
+	Unit int
+}
+
+// ParsePriceRounding parses "exact" (or empty) or "<up|down|nearest>:<unit>",
+// e.g. "up:100" or "nearest:1000".
+func ParsePriceRounding(s string) (PriceRounding, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" || s == RoundExact {
+		return PriceRounding{}, nil
+	}
+	mode, unit, ok := strings.Cut(s, ":")
+	if !ok {
+		return PriceRounding{}, fmt.Errorf("price rounding %q: want exact or <up|down|nearest>:<unit>", s)
+	}
+	switch mode {
+	case RoundUp, RoundDown, RoundNearest:
+	default:
+		return PriceRounding{}, fmt.Errorf("price rounding %q: unknown mode %q", s, mode)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(unit))
+	if err != nil || n <= 0 {
+		return PriceRounding{}, fmt.Errorf("price rounding %q: unit must be a positive number of rupiah", s)
+	}
+	return PriceRounding{Mode: mode, Unit: n}, nil
+}
+
+// Round applies the rule to price. Exact rules, a unit of 1 and
+// non-positive prices are returned unchanged; a positive price never rounds
+// below one unit.
+func (r PriceRounding) Round(price int) int {
+	if r.Unit <= 1 || price <= 0 {
+		return price
+	}
+	rem := price % r.Unit
+	if rem == 0 {
+		return price
+	}
+	switch r.Mode {
+	case RoundUp:
+		return price - rem + r.Unit
+	case RoundDown:
+		return atLeastUnit(price-rem, r.Unit)
+	case RoundNearest:
+		if rem*2 >= r.Unit {
+			return price - rem + r.Unit
+		}
+		return atLeastUnit(price-rem, r.Unit)
+	}
+	return price
+}
+
+// atLeastUnit keeps rounding a positive price down from reaching zero.
+func atLeastUnit(price, unit int) int {
+	if price < unit {
+		return unit
+	}
+	return price
+}
+
+// String returns the rule in ParsePriceRounding format.
+func (r PriceRounding) String() string {
+	if r.Unit <= 0 || r.Mode == "" || r.Mode == RoundExact {
+		return RoundExact
+	}
+	return r.Mode + ":" + strconv.Itoa(r.Unit)
+}
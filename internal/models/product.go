@@ -28,6 +28,19 @@ type Product struct {
 	CreatedAt   time.Time   `db:"created_at" json:"-"`
 	UpdatedAt   time.Time   `db:"updated_at" json:"updatedAt"`
 
+	// Customer number validation rules (nil = unconstrained).
+	CustomerNoMinLength *int    `db:"customer_no_min_length" json:"customerNoMinLength,omitempty"`
+	CustomerNoMaxLength *int    `db:"customer_no_max_length" json:"customerNoMaxLength,omitempty"`
+	CustomerNoPattern   *string `db:"customer_no_pattern" json:"customerNoPattern,omitempty"`
+
+	// SelectionStrategy overrides the provider order for this product (nil =
+	// price for prepaid, admin - commission for postpaid).
+	SelectionStrategy *string `db:"selection_strategy" json:"selectionStrategy,omitempty"`
+
+	// HoldDuplicateSerial holds a success for admin review when its serial
+	// number is already on a success of this product and provider.
+	HoldDuplicateSerial bool `db:"hold_duplicate_serial" json:"holdDuplicateSerial"`
+
 	ProviderCount int  `db:"provider_count" json:"providerCount"`
 	MinPrice      *int `db:"min_price" json:"minPrice,omitempty"`
 	MinAdmin      *int `db:"min_admin" json:"minAdmin,omitempty"`
@@ -18,4 +18,13 @@ type SKU struct {
     CutOffEnd      string    `db:"cut_off_end" json:"cutOffEnd"`
     CreatedAt      time.Time `db:"created_at" json:"-"`
     UpdatedAt      time.Time `db:"updated_at" json:"updatedAt"`
+
+    // DisabledUntil is set when the SKU failed repeatedly; routing skips it
+    // until then.
+    DisabledUntil *time.Time `db:"disabled_until" json:"disabledUntil,omitempty"`
+}
+
+// AutoDisabled reports whether the SKU is temporarily disabled at now.
+func (s SKU) AutoDisabled(now time.Time) bool {
+    return s.DisabledUntil != nil && now.Before(*s.DisabledUntil)
 }
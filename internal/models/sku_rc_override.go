@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Outcomes a per-SKU RC override can force in place of the global
+// Digiflazz RC classification.
+const (
+	RCOutcomeFatal     = "fatal"      // fail the transaction, no further SKUs
+	RCOutcomeSwitchSKU = "switch_sku" // move on to the next SKU
+)
+
+// SKURCOverride maps one Digiflazz RC to a fixed outcome for a single SKU.
+type SKURCOverride struct {
+	ID        int       `db:"id" json:"id"`
+	SkuID     int       `db:"sku_id" json:"skuId"`
+	RC        string    `db:"rc" json:"rc"`
+	Outcome   string    `db:"outcome" json:"outcome"`
+	Note      *string   `db:"note" json:"note,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+// ValidRCOutcome reports whether outcome is a supported override outcome.
+func ValidRCOutcome(outcome string) bool {
+	return outcome == RCOutcomeFatal || outcome == RCOutcomeSwitchSKU
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
+	"regexp"
 	"time"
 )
 
@@ -22,6 +24,50 @@ const (
 	StatusFailed     TransactionStatus = "Failed"
 )
 
+// TransactionStatusInfo documents a transaction status clients can receive.
+type TransactionStatusInfo struct {
+	Status      TransactionStatus `json:"status"`
+	Final       bool              `json:"final"` // no further change or callback follows
+	Description string            `json:"description"`
+}
+
+// clientTransactionStatuses is the client-facing status contract. A status
+// added above stays internal until it is listed here.
+var clientTransactionStatuses = []TransactionStatusInfo{
+	{StatusProcessing, false, "Accepted and being processed by the provider; the final status arrives by callback or GET /v1/ppob/transaction/:transactionId. estimatedCompletionAt is a best-effort estimate, not a deadline."},
+	{StatusPending, false, "Waiting on the provider or biller; treat like Processing."},
+	{StatusSuccess, true, "Completed. Prepaid transactions carry the serial number, inquiries the bill details."},
+	{StatusFailed, true, "Not completed and not charged; failedCode and failedReason say why."},
+}
+
+// ClientTransactionStatuses returns the statuses clients can receive, in
+// lifecycle order.
+func ClientTransactionStatuses() []TransactionStatusInfo {
+	return append([]TransactionStatusInfo(nil), clientTransactionStatuses...)
+}
+
+// ClientVisible reports whether s is part of the client-facing status contract.
+func (s TransactionStatus) ClientVisible() bool {
+	for _, info := range clientTransactionStatuses {
+		if info.Status == s {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultTransactionIDPrefix is the brand prefix of transaction IDs (GRB-YYYYMMDD-NNNNNN).
+const DefaultTransactionIDPrefix = "GRB"
+
+// transactionIDPrefixPattern keeps prefixes dash-free: ref ID suffix parsing
+// counts dashes in GRB-YYYYMMDD-NNNNNN[-N].
+var transactionIDPrefixPattern = regexp.MustCompile(`^[A-Z0-9]{2,6}$`)
+
+// ValidTransactionIDPrefix reports whether p can be used as a transaction ID prefix.
+func ValidTransactionIDPrefix(p string) bool {
+	return transactionIDPrefixPattern.MatchString(p)
+}
+
 // NullableRawMessage handles NULL values for JSONB columns.
 type NullableRawMessage json.RawMessage
 
@@ -72,6 +118,38 @@ func (n *NullableRawMessage) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// ProviderError is a provider's own error as its adapter parsed it: the
+// status code, a nested or secondary code when the provider has one, and the
+// provider's message. It is kept for support to look up in the provider's
+// documentation; clients only get the canonical failedCode/failedReason.
+type ProviderError struct {
+	Code    string `json:"code,omitempty"`
+	Subcode string `json:"subcode,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Scan implements sql.Scanner for the JSONB column.
+func (e *ProviderError) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*e = ProviderError{}
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("unsupported provider_error type %T", value)
+	}
+	return json.Unmarshal(raw, e)
+}
+
+// Value implements driver.Valuer.
+func (e ProviderError) Value() (driver.Value, error) {
+	return json.Marshal(e)
+}
+
 // Transaction captures the lifecycle information for a customer transaction.
 // Many fields are optional to accommodate different transaction types.
 type Transaction struct {
@@ -85,10 +163,13 @@ type Transaction struct {
 	DigiSkuCode   *string            `db:"-" json:"-"`                 // Digiflazz SKU code used (from JOIN)
 	IsSandbox     bool               `db:"is_sandbox" json:"-"`
 	CustomerNo    string             `db:"customer_no" json:"customerNo"`
+	CustomerHash  *string            `db:"customer_no_hash" json:"-"` // salted hash for opted-in clients
 	CustomerName  *string            `db:"customer_name" json:"customerName,omitempty"`
 	Type          TransactionType    `db:"type" json:"type"`
 	Status        TransactionStatus  `db:"status" json:"status"`
 	SerialNumber  *string            `db:"serial_number" json:"serialNumber,omitempty"`
+	SerialDupOf   *string            `db:"serial_number_duplicate_of" json:"-"` // transaction_id already holding this serial
+	SerialChecked bool               `db:"-" json:"-"`                          // serial number already checked for duplicates
 	Amount        *int               `db:"amount" json:"amount,omitempty"`
 	Admin         int                `db:"admin" json:"admin,omitempty"`
 	Period        *string            `db:"period" json:"period,omitempty"`
@@ -114,10 +195,57 @@ type Transaction struct {
 	// Multi-provider fields
 	ProviderID                *int               `db:"provider_id" json:"-"`
 	ProviderSKUID             *int               `db:"provider_sku_id" json:"-"`
-	ProviderCode              *string            `db:"provider_code" json:"providerCode,omitempty"` // Populated from JOIN with ppob_providers
+	ProviderCode              *string            `db:"provider_code" json:"providerCode,omitempty"` // Denormalized column; reads joining ppob_providers use the live code
 	ProviderRefID             *string            `db:"provider_ref_id" json:"-"`
 	ProviderInitialResponse   NullableRawMessage `db:"provider_initial_response" json:"-"`
 	ProviderResponse          NullableRawMessage `db:"provider_response" json:"-"`
 	ProviderInitialHTTPStatus *int               `db:"provider_initial_http_status" json:"-"`
 	ProviderHTTPStatus        *int               `db:"provider_http_status" json:"-"`
+	// ProviderError is the structured error of the last failed provider
+	// response, for admin support only.
+	ProviderError *ProviderError `db:"provider_error" json:"-"`
+
+	// ReviewHoldAt is set while a success with a duplicate serial number is
+	// held (as Pending) for admin review.
+	ReviewHoldAt *time.Time `db:"review_hold_at" json:"-"`
+
+	// Priority orders the retry, status check and callback worker queues
+	// (0-9, higher first, then oldest first).
+	Priority int `db:"priority" json:"-"`
+
+	// EstimatedCompletionAt is a best-effort estimate of when a Processing
+	// transaction settles, set on client responses only.
+	EstimatedCompletionAt *time.Time `db:"-" json:"estimatedCompletionAt,omitempty"`
+
+	// PossibleDuplicateOf is the transaction_id of a recent transaction of
+	// the same customer, product and price under another referenceId, set
+	// on the create response of a client that checks for duplicates.
+	PossibleDuplicateOf *string `db:"-" json:"possibleDuplicateOf,omitempty"`
+}
+
+// HeldForReview reports whether the transaction waits for an admin review
+// decision; provider updates must not complete it meanwhile.
+func (t *Transaction) HeldForReview() bool {
+	return t.ReviewHoldAt != nil
+}
+
+// TransactionRefund is a (partial) credit recorded against a transaction to
+// resolve a dispute.
+type TransactionRefund struct {
+	ID            int       `db:"id" json:"id"`
+	TransactionID int       `db:"transaction_id" json:"-"`
+	Amount        int       `db:"amount" json:"amount"`
+	Reason        string    `db:"reason" json:"reason"`
+	CreatedBy     *string   `db:"created_by" json:"createdBy,omitempty"`
+	CreatedAt     time.Time `db:"created_at" json:"createdAt"`
+}
+
+// TransactionNote is an internal admin note on a transaction; it is never sent
+// to the client.
+type TransactionNote struct {
+	ID            int       `db:"id" json:"id"`
+	TransactionID int       `db:"transaction_id" json:"-"`
+	Note          string    `db:"note" json:"note"`
+	CreatedBy     *string   `db:"created_by" json:"createdBy,omitempty"`
+	CreatedAt     time.Time `db:"created_at" json:"createdAt"`
 }
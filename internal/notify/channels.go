@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+// Channel names used in route configuration.
+const (
+	ChannelSlack   = "slack"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// Channel delivers an event to one destination.
+type Channel interface {
+	Send(ctx context.Context, e Event) error
+}
+
+// SlackChannel posts the event text to a Slack incoming webhook.
+type SlackChannel struct {
+	URL    string
+	Client *http.Client
+}
+
+// Send implements Channel.
+func (c *SlackChannel) Send(ctx context.Context, e Event) error {
+	body, _ := json.Marshal(map[string]string{"text": e.Text()})
+	return postJSON(ctx, c.Client, c.URL, body, nil)
+}
+
+// WebhookChannel posts the event as JSON. With a Secret the body is signed
+// like client callbacks (X-Signature: HMAC-SHA256 hex).
+type WebhookChannel struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+// Send implements Channel.
+func (c *WebhookChannel) Send(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{}
+	if c.Secret != "" {
+		headers["X-Signature"] = utils.GenerateSignature(body, c.Secret)
+	}
+	return postJSON(ctx, c.Client, c.URL, body, headers)
+}
+
+// EmailChannel sends the event as a plain-text email over SMTP.
+type EmailChannel struct {
+	Addr     string // host:port
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Send implements Channel.
+func (c *EmailChannel) Send(_ context.Context, e Event) error {
+	host, _, err := net.SplitHostPort(c.Addr)
+	if err != nil {
+		return fmt.Errorf("email addr: %w", err)
+	}
+	var auth smtp.Auth
+	if c.Username != "" {
+		auth = smtp.PlainAuth("", c.Username, c.Password, host)
+	}
+	msg := "From: " + c.From + "\r\n" +
+		"To: " + strings.Join(c.To, ", ") + "\r\n" +
+		"Subject: [" + strings.ToUpper(string(e.Severity)) + "] " + string(e.Type) + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n\r\n" +
+		e.Text() + "\r\n"
+	return smtp.SendMail(c.Addr, auth, c.From, c.To, []byte(msg))
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
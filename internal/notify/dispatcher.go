@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// sendTimeout bounds one channel delivery.
+const sendTimeout = 10 * time.Second
+
+// Route says where an event type goes.
+type Route struct {
+	Channels []string
+	// Severity, when set, replaces the severity the event was raised with,
+	// so ops decide which events page and which only inform.
+	Severity Severity
+	// RateLimit drops repeats of the same event type and key within the
+	// window; 0 delivers every event.
+	RateLimit time.Duration
+}
+
+// ParseRoutes parses "type=channel[+channel][:severity][:ratelimit];..."
+// e.g. "provider_low_balance=slack+email:critical:30m;*=webhook". The "*"
+// type catches events without their own route.
+func ParseRoutes(s string) (map[EventType]Route, error) {
+	routes := make(map[EventType]Route)
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("route %q: want type=channels", part)
+		}
+		fields := strings.Split(spec, ":")
+		var route Route
+		for _, ch := range strings.Split(fields[0], "+") {
+			if ch = strings.ToLower(strings.TrimSpace(ch)); ch != "" {
+				route.Channels = append(route.Channels, ch)
+			}
+		}
+		if len(route.Channels) == 0 {
+			return nil, fmt.Errorf("route %q: no channels", part)
+		}
+		if len(fields) > 1 && strings.TrimSpace(fields[1]) != "" {
+			sev, err := ParseSeverity(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("route %q: %w", part, err)
+			}
+			route.Severity = sev
+		}
+		if len(fields) > 2 && strings.TrimSpace(fields[2]) != "" {
+			d, err := time.ParseDuration(strings.TrimSpace(fields[2]))
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("route %q: invalid rate limit %q", part, fields[2])
+			}
+			route.RateLimit = d
+		}
+		if len(fields) > 3 {
+			return nil, fmt.Errorf("route %q: too many fields", part)
+		}
+		routes[EventType(name)] = route
+	}
+	return routes, nil
+}
+
+// Dispatcher is the Notifier that fans events out to channels per route.
+// Deliveries run in the background so callers never wait on a channel.
+type Dispatcher struct {
+	channels map[string]Channel
+	routes   map[EventType]Route
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time // type|key -> last delivery
+	now      func() time.Time
+}
+
+// NewDispatcher checks that every route names a configured channel.
+func NewDispatcher(channels map[string]Channel, routes map[EventType]Route) (*Dispatcher, error) {
+	for t, r := range routes {
+		for _, name := range r.Channels {
+			if channels[name] == nil {
+				return nil, fmt.Errorf("route %s uses unconfigured channel %q", t, name)
+			}
+		}
+	}
+	return &Dispatcher{
+		channels: channels,
+		routes:   routes,
+		lastSent: make(map[string]time.Time),
+		now:      time.Now,
+	}, nil
+}
+
+// Notify implements Notifier.
+func (d *Dispatcher) Notify(ctx context.Context, e Event) {
+	e, channels, ok := d.route(e)
+	if !ok {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	for _, name := range channels {
+		go func(name string, ch Channel) {
+			sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+			defer cancel()
+			if err := ch.Send(sendCtx, e); err != nil {
+				log.Warn().Err(err).Str("channel", name).Str("event", string(e.Type)).Msg("Failed to deliver ops notification")
+			}
+		}(name, d.channels[name])
+	}
+}
+
+// route resolves the event's route, applies the severity override and the
+// rate limit, and returns the channels to deliver to.
+func (d *Dispatcher) route(e Event) (Event, []string, bool) {
+	r, ok := d.routes[e.Type]
+	if !ok {
+		if r, ok = d.routes["*"]; !ok {
+			return e, nil, false
+		}
+	}
+	if r.Severity != "" {
+		e.Severity = r.Severity
+	}
+	if e.Severity == "" {
+		e.Severity = SeverityInfo
+	}
+	if e.Time.IsZero() {
+		e.Time = d.now()
+	}
+
+	if r.RateLimit > 0 {
+		key := string(e.Type) + "|" + e.Key
+		d.mu.Lock()
+		last, seen := d.lastSent[key]
+		if seen && e.Time.Sub(last) < r.RateLimit {
+			d.mu.Unlock()
+			return e, nil, false
+		}
+		d.lastSent[key] = e.Time
+		d.mu.Unlock()
+	}
+	return e, r.Channels, true
+}
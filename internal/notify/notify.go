@@ -0,0 +1,82 @@
+// Package notify routes typed operational events (provider failover, low
+// provider balance, duplicate serial numbers, ...) to ops alert channels such
+// as a Slack webhook, email or a generic webhook.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EventType identifies an operational event.
+type EventType string
+
+// Events emitted by the services and workers.
+const (
+	EventProviderLowBalance       EventType = "provider_low_balance"
+	EventProviderBalanceRecovered EventType = "provider_balance_recovered"
+	EventProviderFailover         EventType = "provider_failover"
+	EventDuplicateSerialNumber    EventType = "duplicate_serial_number"
+	EventProviderPriceListShrunk  EventType = "provider_price_list_shrunk"
+	EventRedisUnavailable         EventType = "redis_unavailable"
+	EventRedisRecovered           EventType = "redis_recovered"
+)
+
+// Severity tells whether an event should page or only inform.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// ParseSeverity parses info, warning or critical.
+func ParseSeverity(s string) (Severity, error) {
+	switch v := Severity(strings.ToLower(strings.TrimSpace(s))); v {
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+		return v, nil
+	}
+	return "", fmt.Errorf("unknown severity %q", s)
+}
+
+// Event is one operational occurrence.
+type Event struct {
+	Type     EventType `json:"type"`
+	Severity Severity  `json:"severity"`
+	// Key scopes rate limiting within the event type, e.g. the provider code,
+	// so one noisy provider does not silence another.
+	Key     string         `json:"key,omitempty"`
+	Message string         `json:"message"`
+	Fields  map[string]any `json:"fields,omitempty"`
+	Time    time.Time      `json:"time"`
+}
+
+// Text renders the event as a single line for chat and email channels.
+func (e Event) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s: %s", strings.ToUpper(string(e.Severity)), e.Type, e.Message)
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, e.Fields[k])
+	}
+	return b.String()
+}
+
+// Notifier is what services call to raise an event.
+type Notifier interface {
+	Notify(ctx context.Context, e Event)
+}
+
+// Nop drops every event.
+type Nop struct{}
+
+// Notify implements Notifier.
+func (Nop) Notify(context.Context, Event) {}
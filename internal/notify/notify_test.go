@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := ParseRoutes("provider_low_balance=slack+email:critical:30m; *=webhook ;provider_failover=slack::5m")
+	if err != nil {
+		t.Fatalf("ParseRoutes: %v", err)
+	}
+	low := routes[EventProviderLowBalance]
+	if len(low.Channels) != 2 || low.Channels[1] != ChannelEmail || low.Severity != SeverityCritical || low.RateLimit != 30*time.Minute {
+		t.Fatalf("low balance route = %+v", low)
+	}
+	if r := routes["*"]; len(r.Channels) != 1 || r.Severity != "" || r.RateLimit != 0 {
+		t.Fatalf("catch-all route = %+v", r)
+	}
+	if r := routes[EventProviderFailover]; r.Severity != "" || r.RateLimit != 5*time.Minute {
+		t.Fatalf("failover route = %+v", r)
+	}
+
+	for _, bad := range []string{"slack", "x=", "x=slack:loud", "x=slack:info:soon", "x=slack:info:1m:extra"} {
+		if _, err := ParseRoutes(bad); err == nil {
+			t.Errorf("ParseRoutes(%q) accepted", bad)
+		}
+	}
+}
+
+func TestNewDispatcherRejectsUnconfiguredChannel(t *testing.T) {
+	routes := map[EventType]Route{EventProviderFailover: {Channels: []string{ChannelEmail}}}
+	if _, err := NewDispatcher(map[string]Channel{}, routes); err == nil {
+		t.Fatal("expected error for route without configured channel")
+	}
+}
+
+func TestDispatcherRouteSeverityAndRateLimit(t *testing.T) {
+	d, err := NewDispatcher(map[string]Channel{ChannelSlack: &SlackChannel{}}, map[EventType]Route{
+		EventProviderLowBalance: {Channels: []string{ChannelSlack}, Severity: SeverityInfo, RateLimit: time.Minute},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	ev := func(key string, at time.Time) Event {
+		return Event{Type: EventProviderLowBalance, Severity: SeverityCritical, Key: key, Time: at}
+	}
+
+	e, channels, ok := d.route(ev("kiosbank", base))
+	if !ok || len(channels) != 1 || e.Severity != SeverityInfo {
+		t.Fatalf("first event: ok=%v channels=%v severity=%s", ok, channels, e.Severity)
+	}
+	if _, _, ok := d.route(ev("kiosbank", base.Add(30*time.Second))); ok {
+		t.Fatal("repeat within the rate limit was delivered")
+	}
+	if _, _, ok := d.route(ev("alterra", base.Add(30*time.Second))); !ok {
+		t.Fatal("other key was rate limited")
+	}
+	if _, _, ok := d.route(ev("kiosbank", base.Add(time.Minute))); !ok {
+		t.Fatal("event after the window was dropped")
+	}
+	if _, _, ok := d.route(Event{Type: EventDuplicateSerialNumber}); ok {
+		t.Fatal("event without a route was delivered")
+	}
+}
+
+func TestWebhookChannelSignsBody(t *testing.T) {
+	var gotBody []byte
+	var gotSig string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSig = r.Header.Get("X-Signature")
+	}))
+	defer srv.Close()
+
+	ch := &WebhookChannel{URL: srv.URL, Secret: "s3cret", Client: srv.Client()}
+	e := Event{Type: EventProviderFailover, Severity: SeverityWarning, Message: "fallback", Fields: map[string]any{"ref_id": "GRB-1"}}
+	if err := ch.Send(context.Background(), e); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if gotSig != utils.GenerateSignature(gotBody, "s3cret") {
+		t.Fatalf("signature %q does not match body", gotSig)
+	}
+	var decoded Event
+	if err := json.Unmarshal(gotBody, &decoded); err != nil || decoded.Type != EventProviderFailover {
+		t.Fatalf("body = %s (%v)", gotBody, err)
+	}
+}
+
+func TestEventText(t *testing.T) {
+	e := Event{Type: EventProviderLowBalance, Severity: SeverityCritical, Message: "low", Fields: map[string]any{"provider": "kiosbank", "balance": 10}}
+	if got, want := e.Text(), "[CRITICAL] provider_low_balance: low balance=10 provider=kiosbank"; got != want {
+		t.Fatalf("Text() = %q, want %q", got, want)
+	}
+}
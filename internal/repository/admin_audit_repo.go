@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+// AdminAuditRepository persists the append-only admin audit trail.
+type AdminAuditRepository struct {
+	db *sqlx.DB
+}
+
+func NewAdminAuditRepository(db *sqlx.DB) *AdminAuditRepository {
+	return &AdminAuditRepository{db: db}
+}
+
+// Create appends one audit entry.
+func (r *AdminAuditRepository) Create(ctx context.Context, e *models.AdminAuditLog) error {
+	const q = `
+		INSERT INTO admin_audit_log (actor_id, actor_email, action, target, changes, status_code, ip_address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+	return r.db.QueryRowxContext(ctx, q, e.ActorID, e.ActorEmail, e.Action, e.Target, e.Changes, e.StatusCode, e.IPAddress).
+		Scan(&e.ID, &e.CreatedAt)
+}
+
+// AdminAuditFilter narrows List. Actor matches the email exactly
+// (case-insensitive); Action and Target match substrings. From/To bound
+// created_at as [From, To) when non-zero.
+type AdminAuditFilter struct {
+	Actor  string
+	Action string
+	Target string
+	From   time.Time
+	To     time.Time
+	Page   int
+	Limit  int
+}
+
+// List pages audit entries, newest first.
+func (r *AdminAuditRepository) List(f AdminAuditFilter) ([]models.AdminAuditLog, int, error) {
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.Limit <= 0 {
+		f.Limit = 20
+	}
+	var from, to *time.Time
+	if !f.From.IsZero() {
+		from = &f.From
+	}
+	if !f.To.IsZero() {
+		to = &f.To
+	}
+	where := `
+		WHERE ($1 = '' OR LOWER(actor_email) = LOWER($1))
+		AND ($2 = '' OR action ILIKE '%' || $2 || '%')
+		AND ($3 = '' OR target ILIKE '%' || $3 || '%')
+		AND ($4::timestamptz IS NULL OR created_at >= $4)
+		AND ($5::timestamptz IS NULL OR created_at < $5)`
+
+	var total int
+	if err := r.db.Get(&total, `SELECT COUNT(1) FROM admin_audit_log`+where, f.Actor, f.Action, f.Target, from, to); err != nil {
+		return nil, 0, err
+	}
+
+	q := `
+		SELECT id, actor_id, actor_email, action, target, changes, status_code, ip_address, created_at
+		FROM admin_audit_log` + where + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $6 OFFSET $7`
+
+	var entries []models.AdminAuditLog
+	if err := r.db.Select(&entries, q, f.Actor, f.Action, f.Target, from, to, f.Limit, (f.Page-1)*f.Limit); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
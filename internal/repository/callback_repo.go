@@ -2,6 +2,8 @@ package repository
 
 import (
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
@@ -72,6 +74,36 @@ func (r *CallbackRepository) GetLogsByTransactionID(transactionID int) ([]models
 	return logs, nil
 }
 
+// TransactionAttemptSummary is a coarse view of how a transaction got its
+// result: provider/SKU attempts logged, the distinct SKUs and provider SKUs
+// they went to, and whether the provider it ended on is the backup one.
+type TransactionAttemptSummary struct {
+	AttemptCount int  `db:"attempt_count"`
+	TargetCount  int  `db:"target_count"`
+	BackupUsed   bool `db:"backup_used"`
+}
+
+// GetAttemptSummary summarizes the transaction_logs of a transaction.
+func (r *CallbackRepository) GetAttemptSummary(transactionID int) (*TransactionAttemptSummary, error) {
+	const q = `
+        SELECT
+            (SELECT COUNT(1) FROM transaction_logs WHERE transaction_id = $1) AS attempt_count,
+            (SELECT COUNT(DISTINCT (COALESCE(sku_id, 0), COALESCE(provider_sku_id, 0)))
+                FROM transaction_logs WHERE transaction_id = $1) AS target_count,
+            COALESCE((
+                SELECT pr.is_backup
+                FROM transactions t
+                JOIN ppob_providers pr ON pr.id = t.provider_id
+                WHERE t.id = $1
+            ), false) AS backup_used`
+
+	var summary TransactionAttemptSummary
+	if err := r.db.Get(&summary, q, transactionID); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
 // CreateCallbackLog inserts a new callback log (to client).
 func (r *CallbackRepository) CreateCallbackLog(log *models.CallbackLog) error {
 	const q = `
@@ -106,8 +138,8 @@ func (r *CallbackRepository) UpdateCallbackLog(log *models.CallbackLog) error {
             attempt = $2,
             http_status = $3,
             response_body = $4,
-            is_delivered = $5,
-            next_retry_at = $6
+            is_delivered = (acknowledged_at IS NOT NULL OR $5),
+            next_retry_at = CASE WHEN acknowledged_at IS NOT NULL THEN NULL ELSE $6::timestamptz END
         WHERE id = $1`
 	stmt, err := r.db.Preparex(q)
 	if err != nil {
@@ -125,16 +157,36 @@ func (r *CallbackRepository) UpdateCallbackLog(log *models.CallbackLog) error {
 	return err
 }
 
-// GetPendingCallbacks returns pending callback logs ready to deliver.
-// Uses SKIP LOCKED to avoid duplicate processing by concurrent workers.
+// ClaimCallbackLog pushes an undelivered, not yet due callback log's
+// next_retry_at lease past now, so the retry worker leaves it to the caller,
+// and reports whether it did. A log already due belongs to the worker.
+func (r *CallbackRepository) ClaimCallbackLog(id int, lease time.Duration) (bool, error) {
+	const q = `
+        UPDATE callback_logs SET next_retry_at = NOW() + $2::interval
+        WHERE id = $1 AND is_delivered = false AND next_retry_at > NOW()`
+	res, err := r.db.Exec(q, id, fmt.Sprintf("%d seconds", int(lease.Seconds())))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// GetPendingCallbacks returns pending callback logs ready to deliver, those
+// of higher priority transactions first. Uses SKIP LOCKED to avoid duplicate
+// processing by concurrent workers.
 func (r *CallbackRepository) GetPendingCallbacks() ([]models.CallbackLog, error) {
 	const q = `
-        SELECT * FROM callback_logs
-        WHERE is_delivered = false
-          AND next_retry_at <= NOW()
-          AND attempt < 5
-        ORDER BY next_retry_at ASC
-        FOR UPDATE SKIP LOCKED`
+        SELECT cl.* FROM callback_logs cl
+        LEFT JOIN transactions t ON t.id = cl.transaction_id
+        WHERE cl.is_delivered = false
+          AND cl.next_retry_at <= NOW()
+          AND cl.attempt < 5
+        ORDER BY COALESCE(t.priority, 0) DESC, cl.next_retry_at ASC
+        FOR UPDATE OF cl SKIP LOCKED`
 	stmt, err := r.db.Preparex(q)
 	if err != nil {
 		return nil, err
@@ -147,6 +199,65 @@ func (r *CallbackRepository) GetPendingCallbacks() ([]models.CallbackLog, error)
 	return logs, nil
 }
 
+// HasEarlierPendingCallback reports whether transaction trxID has an
+// undelivered callback that is still being retried and was logged before
+// beforeID (any, when beforeID <= 0).
+func (r *CallbackRepository) HasEarlierPendingCallback(trxID, beforeID int) (bool, error) {
+	const q = `
+        SELECT EXISTS (
+            SELECT 1 FROM callback_logs
+            WHERE transaction_id = $1
+              AND ($2 <= 0 OR id < $2)
+              AND is_delivered = false
+              AND next_retry_at IS NOT NULL
+              AND attempt < 5
+        )`
+	var exists bool
+	if err := r.db.Get(&exists, q, trxID, beforeID); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// batchableCallbackFilter selects a client's undelivered callbacks that a
+// batch may carry: queued ones not sent yet and retries that are due.
+const batchableCallbackFilter = `
+        WHERE client_id = $1
+          AND is_delivered = false
+          AND attempt < 5
+          AND (attempt = 0 OR next_retry_at <= NOW())`
+
+// GetBatchableCallbacks returns up to limit callbacks of a batching client
+// to deliver together, oldest first.
+func (r *CallbackRepository) GetBatchableCallbacks(clientID, limit int) ([]models.CallbackLog, error) {
+	q := `SELECT * FROM callback_logs` + batchableCallbackFilter + ` ORDER BY id ASC LIMIT $2`
+	var logs []models.CallbackLog
+	if err := r.db.Select(&logs, q, clientID, limit); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// CountBatchableCallbacks counts the callbacks GetBatchableCallbacks would
+// return without a limit.
+func (r *CallbackRepository) CountBatchableCallbacks(clientID int) (int, error) {
+	var n int
+	if err := r.db.Get(&n, `SELECT COUNT(1) FROM callback_logs`+batchableCallbackFilter, clientID); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// GetLatestCallbackLog returns the newest callback log of a transaction.
+func (r *CallbackRepository) GetLatestCallbackLog(trxID int) (*models.CallbackLog, error) {
+	const q = `SELECT * FROM callback_logs WHERE transaction_id = $1 ORDER BY created_at DESC, id DESC LIMIT 1`
+	var cb models.CallbackLog
+	if err := r.db.Get(&cb, q, trxID); err != nil {
+		return nil, err
+	}
+	return &cb, nil
+}
+
 // MarkDelivered marks a callback as delivered.
 func (r *CallbackRepository) MarkDelivered(id int) error {
 	const q = `UPDATE callback_logs SET is_delivered = true WHERE id = $1`
@@ -159,6 +270,113 @@ func (r *CallbackRepository) MarkDelivered(id int) error {
 	return err
 }
 
+// ClientCallbackFilter holds filters for a client's own PPOB callback history.
+type ClientCallbackFilter struct {
+	ClientID      int
+	TransactionID string // public transaction_id
+	StartDate     string // YYYY-MM-DD, inclusive
+	EndDate       string // YYYY-MM-DD, inclusive
+	Page          int
+	Limit         int
+}
+
+// ClientCallbackDelivery is the client-facing view of a callback_logs row.
+type ClientCallbackDelivery struct {
+	ID            int        `db:"id" json:"id"`
+	TransactionID string     `db:"transaction_id" json:"transactionId"`
+	ReferenceID   string     `db:"reference_id" json:"referenceId"`
+	Event         string     `db:"event" json:"event"`
+	HTTPStatus    *int       `db:"http_status" json:"httpStatus,omitempty"`
+	IsDelivered   bool       `db:"is_delivered" json:"delivered"`
+	Attempts      int        `db:"attempt" json:"attempts"`
+	CreatedAt     time.Time  `db:"created_at" json:"createdAt"`
+	DeliveredAt   *time.Time `db:"delivered_at" json:"deliveredAt,omitempty"`
+
+	AcknowledgedAt *time.Time `db:"acknowledged_at" json:"acknowledgedAt,omitempty"`
+}
+
+// ListClientCallbacks returns a page of PPOB callback deliveries for one client, newest first.
+func (r *CallbackRepository) ListClientCallbacks(f ClientCallbackFilter) ([]ClientCallbackDelivery, int, error) {
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.Limit <= 0 || f.Limit > 100 {
+		f.Limit = 50
+	}
+
+	where := ` WHERE cl.client_id = $1`
+	args := []interface{}{f.ClientID}
+	argIdx := 2
+
+	if f.TransactionID != "" {
+		where += fmt.Sprintf(" AND t.transaction_id = $%d", argIdx)
+		args = append(args, f.TransactionID)
+		argIdx++
+	}
+	if f.StartDate != "" {
+		where += fmt.Sprintf(" AND cl.created_at >= $%d::date", argIdx)
+		args = append(args, f.StartDate)
+		argIdx++
+	}
+	if f.EndDate != "" {
+		where += fmt.Sprintf(" AND cl.created_at < ($%d::date + interval '1 day')", argIdx)
+		args = append(args, f.EndDate)
+		argIdx++
+	}
+
+	const from = ` FROM callback_logs cl JOIN transactions t ON t.id = cl.transaction_id AND t.client_id = cl.client_id`
+
+	var total int
+	if err := r.db.Get(&total, `SELECT COUNT(1)`+from+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	q := `SELECT cl.id, t.transaction_id, t.reference_id, cl.event, cl.http_status, cl.is_delivered,
+            cl.attempt, cl.created_at, cl.delivered_at, cl.acknowledged_at` + from + where +
+		fmt.Sprintf(" ORDER BY cl.created_at DESC LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
+	args = append(args, f.Limit, (f.Page-1)*f.Limit)
+
+	var list []ClientCallbackDelivery
+	if err := r.db.Select(&list, q, args...); err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// AcknowledgeClientCallback marks one of the client's PPOB callbacks as
+// delivered and acknowledged, stops its retries and flags the transaction's
+// callback as sent. Acknowledging twice keeps the first timestamp. Returns
+// sql.ErrNoRows when the callback does not exist or belongs to another client.
+func (r *CallbackRepository) AcknowledgeClientCallback(id, clientID int) (*ClientCallbackDelivery, error) {
+	const q = `
+        WITH acked AS (
+            UPDATE callback_logs cl SET
+                is_delivered = true,
+                next_retry_at = NULL,
+                delivered_at = COALESCE(cl.delivered_at, NOW()),
+                acknowledged_at = COALESCE(cl.acknowledged_at, NOW())
+            FROM transactions t
+            WHERE cl.id = $1
+              AND cl.client_id = $2
+              AND t.id = cl.transaction_id
+              AND t.client_id = $2
+            RETURNING cl.id, cl.transaction_id AS trx_id, t.transaction_id, t.reference_id, cl.event,
+                cl.http_status, cl.is_delivered, cl.attempt, cl.created_at, cl.delivered_at, cl.acknowledged_at
+        ), sent AS (
+            UPDATE transactions SET callback_sent = true, callback_sent_at = COALESCE(callback_sent_at, NOW()), updated_at = NOW()
+            WHERE id IN (SELECT trx_id FROM acked) AND callback_sent = false
+        )
+        SELECT id, transaction_id, reference_id, event, http_status, is_delivered,
+            attempt, created_at, delivered_at, acknowledged_at
+        FROM acked`
+
+	var d ClientCallbackDelivery
+	if err := r.db.Get(&d, q, id, clientID); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
 // CreateDigiflazzCallback inserts a digiflazz callback record.
 func (r *CallbackRepository) CreateDigiflazzCallback(cb *models.DigiflazzCallback) error {
 	const q = `
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
@@ -20,7 +21,10 @@ func NewClientRepository(db *sqlx.DB) *ClientRepository {
 }
 
 const clientColumns = `id, client_id, name, api_key, sandbox_key, callback_url, callback_secret,
-    ip_whitelist, scopes, is_active, created_at, updated_at`
+    callback_method, callback_headers, ip_whitelist, scopes, is_active, hash_customer_no,
+    transaction_id_prefix, callback_attempt_info, locale, callback_ordered, price_rounding,
+    callback_signature_algorithm, callback_signature_encoding, transaction_priority, callback_batch_size,
+    response_format, duplicate_window_seconds, duplicate_action, created_at, updated_at`
 
 func scanClient(scanner interface {
 	Scan(dest ...any) error
@@ -33,9 +37,24 @@ func scanClient(scanner interface {
 		&c.SandboxKey,
 		&c.CallbackURL,
 		&c.CallbackSecret,
+		&c.CallbackMethod,
+		&c.CallbackHeaders,
 		pq.Array(&c.IPWhitelist),
 		pq.Array(&c.Scopes),
 		&c.IsActive,
+		&c.HashCustomerNo,
+		&c.TransactionIDPrefix,
+		&c.CallbackAttemptInfo,
+		&c.Locale,
+		&c.CallbackOrdered,
+		&c.PriceRounding,
+		&c.CallbackSignatureAlgorithm,
+		&c.CallbackSignatureEncoding,
+		&c.TransactionPriority,
+		&c.CallbackBatchSize,
+		&c.ResponseFormat,
+		&c.DuplicateWindowSeconds,
+		&c.DuplicateAction,
 		&c.CreatedAt,
 		&c.UpdatedAt,
 	)
@@ -87,8 +106,14 @@ func (r *ClientRepository) GetByID(id int) (*models.Client, error) {
 func (r *ClientRepository) Create(client *models.Client) error {
 	query := `INSERT INTO clients (
         client_id, name, api_key, sandbox_key, callback_url, callback_secret,
-        ip_whitelist, scopes, is_active
-    ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        ip_whitelist, scopes, is_active, hash_customer_no, callback_method, callback_headers,
+        transaction_id_prefix, callback_attempt_info, locale, callback_ordered, price_rounding,
+        callback_signature_algorithm, callback_signature_encoding, transaction_priority, callback_batch_size,
+        response_format, duplicate_window_seconds, duplicate_action
+    ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE(NULLIF($11, ''), 'POST'), $12, $13, $14,
+        COALESCE(NULLIF($15, ''), 'en'), $16, $17,
+        COALESCE(NULLIF($18, ''), 'sha256'), COALESCE(NULLIF($19, ''), 'hex'), $20, $21,
+        COALESCE(NULLIF($22, ''), 'envelope'), $23, COALESCE(NULLIF($24, ''), 'warn'))
               RETURNING id, created_at, updated_at`
 
 	return r.db.QueryRowx(query,
@@ -101,6 +126,21 @@ func (r *ClientRepository) Create(client *models.Client) error {
 		pq.Array(client.IPWhitelist),
 		pq.Array(client.Scopes),
 		client.IsActive,
+		client.HashCustomerNo,
+		client.CallbackMethod,
+		client.CallbackHeaders,
+		client.TransactionIDPrefix,
+		client.CallbackAttemptInfo,
+		client.Locale,
+		client.CallbackOrdered,
+		client.PriceRounding,
+		client.CallbackSignatureAlgorithm,
+		client.CallbackSignatureEncoding,
+		client.TransactionPriority,
+		client.CallbackBatchSize,
+		client.ResponseFormat,
+		client.DuplicateWindowSeconds,
+		client.DuplicateAction,
 	).Scan(&client.ID, &client.CreatedAt, &client.UpdatedAt)
 }
 
@@ -108,8 +148,17 @@ func (r *ClientRepository) Create(client *models.Client) error {
 func (r *ClientRepository) Update(client *models.Client) error {
 	query := `UPDATE clients
               SET client_id = $1, name = $2, callback_url = $3, callback_secret = $4,
-                  ip_whitelist = $5, scopes = $6, is_active = $7, api_key = $8, sandbox_key = $9
-              WHERE id = $10
+                  ip_whitelist = $5, scopes = $6, is_active = $7, api_key = $8, sandbox_key = $9,
+                  hash_customer_no = $10, callback_method = COALESCE(NULLIF($11, ''), 'POST'),
+                  callback_headers = $12, transaction_id_prefix = $13, callback_attempt_info = $14,
+                  locale = COALESCE(NULLIF($15, ''), 'en'), callback_ordered = $16,
+                  price_rounding = $17,
+                  callback_signature_algorithm = COALESCE(NULLIF($18, ''), 'sha256'),
+                  callback_signature_encoding = COALESCE(NULLIF($19, ''), 'hex'),
+                  transaction_priority = $20, callback_batch_size = $21,
+                  response_format = COALESCE(NULLIF($22, ''), 'envelope'),
+                  duplicate_window_seconds = $23, duplicate_action = COALESCE(NULLIF($24, ''), 'warn')
+              WHERE id = $25
               RETURNING updated_at`
 
 	return r.db.QueryRowx(query,
@@ -122,6 +171,21 @@ func (r *ClientRepository) Update(client *models.Client) error {
 		client.IsActive,
 		client.APIKey,
 		client.SandboxKey,
+		client.HashCustomerNo,
+		client.CallbackMethod,
+		client.CallbackHeaders,
+		client.TransactionIDPrefix,
+		client.CallbackAttemptInfo,
+		client.Locale,
+		client.CallbackOrdered,
+		client.PriceRounding,
+		client.CallbackSignatureAlgorithm,
+		client.CallbackSignatureEncoding,
+		client.TransactionPriority,
+		client.CallbackBatchSize,
+		client.ResponseFormat,
+		client.DuplicateWindowSeconds,
+		client.DuplicateAction,
 		client.ID,
 	).Scan(&client.UpdatedAt)
 }
@@ -147,3 +211,74 @@ func (r *ClientRepository) List() ([]*models.Client, error) {
 
 	return clients, rows.Err()
 }
+
+// ClientUsageSummary is the admin list view of a client: identity and status
+// plus production transaction activity: the last transaction and the count in
+// the listed range. Keys and secrets are left out.
+type ClientUsageSummary struct {
+	ID             int        `db:"id" json:"id"`
+	ClientID       string     `db:"client_id" json:"clientId"`
+	Name           string     `db:"name" json:"name"`
+	IsActive       bool       `db:"is_active" json:"isActive"`
+	CreatedAt      time.Time  `db:"created_at" json:"createdAt"`
+	LastActivityAt *time.Time `db:"last_activity_at" json:"lastActivityAt"`
+	Transactions   int        `db:"transactions" json:"transactions"`
+}
+
+// ClientListFilter narrows ListWithUsage. Search matches name or client_id
+// (case-insensitive); IsActive nil means any status. Usage counts production
+// transactions created in [From, To).
+type ClientListFilter struct {
+	Search   string
+	IsActive *bool
+	From     time.Time
+	To       time.Time
+	Page     int
+	Limit    int
+}
+
+// ListWithUsage pages clients (newest first) with their last production
+// transaction time, at any date, and production transaction count in
+// [f.From, f.To). Only the clients of the page are aggregated.
+func (r *ClientRepository) ListWithUsage(f ClientListFilter) ([]ClientUsageSummary, int, error) {
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.Limit <= 0 {
+		f.Limit = 20
+	}
+	where := `
+		WHERE ($1 = '' OR c.name ILIKE '%' || $1 || '%' OR c.client_id ILIKE '%' || $1 || '%')
+		AND ($2::boolean IS NULL OR c.is_active = $2)`
+
+	var total int
+	if err := r.db.Get(&total, `SELECT COUNT(1) FROM clients c`+where, f.Search, f.IsActive); err != nil {
+		return nil, 0, err
+	}
+
+	q := `
+		WITH page AS (
+			SELECT c.id, c.client_id, c.name, c.is_active, c.created_at
+			FROM clients c` + where + `
+			ORDER BY c.created_at DESC
+			LIMIT $3 OFFSET $4
+		)
+		SELECT p.id, p.client_id, p.name, p.is_active, p.created_at,
+			u.last_activity_at, COALESCE(u.transactions, 0) AS transactions
+		FROM page p
+		LEFT JOIN (
+			SELECT client_id, MAX(created_at) AS last_activity_at,
+				COUNT(1) FILTER (WHERE created_at >= $5 AND created_at < $6) AS transactions
+			FROM transactions
+			WHERE is_sandbox = false
+			AND client_id IN (SELECT id FROM page)
+			GROUP BY client_id
+		) u ON u.client_id = p.id
+		ORDER BY p.created_at DESC`
+
+	var clients []ClientUsageSummary
+	if err := r.db.Select(&clients, q, f.Search, f.IsActive, f.Limit, (f.Page-1)*f.Limit, f.From, f.To); err != nil {
+		return nil, 0, err
+	}
+	return clients, total, nil
+}
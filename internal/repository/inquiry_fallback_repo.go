@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// InquiryFallbackRepository stores inquiries in inquiry_fallbacks while
+// Redis is unavailable; it implements cache.InquiryStore.
+type InquiryFallbackRepository struct {
+	db *sqlx.DB
+}
+
+func NewInquiryFallbackRepository(db *sqlx.DB) *InquiryFallbackRepository {
+	return &InquiryFallbackRepository{db: db}
+}
+
+// SaveInquiry upserts an inquiry. Expired rows are left to
+// DeleteExpiredInquiries.
+func (r *InquiryFallbackRepository) SaveInquiry(transactionID string, cacheKey *string, data []byte, expiresAt time.Time) error {
+	const q = `
+		INSERT INTO inquiry_fallbacks (transaction_id, cache_key, data, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (transaction_id) DO UPDATE
+		SET cache_key = EXCLUDED.cache_key, data = EXCLUDED.data, expires_at = EXCLUDED.expires_at`
+	_, err := r.db.Exec(q, transactionID, cacheKey, data, expiresAt)
+	return err
+}
+
+// GetInquiry returns the unexpired inquiry saved under transactionID, or nil.
+func (r *InquiryFallbackRepository) GetInquiry(transactionID string) ([]byte, error) {
+	return r.get(`transaction_id = $1`, transactionID)
+}
+
+// GetInquiryByCacheKey returns the latest unexpired inquiry saved under the
+// duplicate-inquiry cacheKey, or nil.
+func (r *InquiryFallbackRepository) GetInquiryByCacheKey(cacheKey string) ([]byte, error) {
+	return r.get(`cache_key = $1`, cacheKey)
+}
+
+func (r *InquiryFallbackRepository) get(where string, arg any) ([]byte, error) {
+	var data []byte
+	err := r.db.Get(&data, `SELECT data FROM inquiry_fallbacks WHERE `+where+
+		` AND expires_at > NOW() ORDER BY created_at DESC LIMIT 1`, arg)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return data, err
+}
+
+// DeleteInquiry removes the inquiry saved under transactionID, if any.
+func (r *InquiryFallbackRepository) DeleteInquiry(transactionID string) error {
+	_, err := r.db.Exec(`DELETE FROM inquiry_fallbacks WHERE transaction_id = $1`, transactionID)
+	return err
+}
+
+// DeleteExpiredInquiries drops expired inquiries so the table only holds
+// what an outage left behind, returning how many were removed.
+func (r *InquiryFallbackRepository) DeleteExpiredInquiries() (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM inquiry_fallbacks WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -2,21 +2,70 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
+	"sort"
+	"sync/atomic"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
 	"github.com/GTDGit/gtd_api/internal/models"
 )
 
+// Provider selection strategies for prepaid execution order. Products may
+// override the order with any of them (products.selection_strategy).
+const (
+	SelectionStrategyPrice          = "price"           // cheapest first (default)
+	SelectionStrategyWeightedPrice  = "weighted_price"  // price divided by today's success rate
+	SelectionStrategyEffectiveAdmin = "effective_admin" // lowest admin - commission first (postpaid default)
+	SelectionStrategyPriority       = "priority"        // provider priority first, then price
+)
+
+// ValidSelectionStrategy reports whether s can be used as a product override.
+func ValidSelectionStrategy(s string) bool {
+	switch s {
+	case SelectionStrategyPrice, SelectionStrategyWeightedPrice, SelectionStrategyEffectiveAdmin, SelectionStrategyPriority:
+		return true
+	}
+	return false
+}
+
+// minHealthSamples is the number of requests a provider needs today before its
+// success rate is trusted by the weighted strategy.
+const minHealthSamples = 20
+
 // PPOBProviderRepository handles data access for multi-provider PPOB system.
 type PPOBProviderRepository struct {
-	db *sqlx.DB
+	db                *sqlx.DB
+	selectionStrategy string
+	tieBreakNext      func() uint64 // nil = fixed provider-id order among ties
 }
 
 // NewPPOBProviderRepository creates a new PPOBProviderRepository.
 func NewPPOBProviderRepository(db *sqlx.DB) *PPOBProviderRepository {
-	return &PPOBProviderRepository{db: db}
+	return &PPOBProviderRepository{db: db, selectionStrategy: SelectionStrategyPrice}
+}
+
+// SetSelectionStrategy sets how GetProvidersForProduct orders providers.
+// Unknown values fall back to pure price ordering.
+func (r *PPOBProviderRepository) SetSelectionStrategy(strategy string) {
+	r.selectionStrategy = strategy
+}
+
+// SetTieBreakRotation makes GetProvidersForProduct rotate exactly tied
+// providers by the offset returned from next on each call. Pass
+// NewRoundRobinTieBreak(seed) in production or a fixed func in tests; nil keeps
+// the SQL provider-id order.
+func (r *PPOBProviderRepository) SetTieBreakRotation(next func() uint64) {
+	r.tieBreakNext = next
+}
+
+// NewRoundRobinTieBreak returns a goroutine-safe counter starting at seed.
+func NewRoundRobinTieBreak(seed uint64) func() uint64 {
+	var n atomic.Uint64
+	n.Store(seed)
+	return func() uint64 { return n.Add(1) - 1 }
 }
 
 // ============================================
@@ -65,6 +114,20 @@ func (r *PPOBProviderRepository) UpdateProviderStatus(id int, isActive bool) err
 	return err
 }
 
+// UpdateProviderCutOff sets the provider's daily WIB cut-off window
+// ("00:00:00" for both clears it).
+func (r *PPOBProviderRepository) UpdateProviderCutOff(id int, start, end string) error {
+	const q = `UPDATE ppob_providers SET cut_off_start = $2, cut_off_end = $3, updated_at = NOW() WHERE id = $1`
+	res, err := r.db.Exec(q, id, start, end)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 // ============================================
 // Provider SKU CRUD
 // ============================================
@@ -73,8 +136,8 @@ func (r *PPOBProviderRepository) UpdateProviderStatus(id int, isActive bool) err
 func (r *PPOBProviderRepository) CreateProviderSKU(sku *models.PPOBProviderSKU) error {
 	const q = `
 		INSERT INTO ppob_provider_skus
-			(provider_id, product_id, provider_sku_code, provider_product_name, price, admin, commission, is_active, is_available)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			(provider_id, product_id, provider_sku_code, provider_product_name, price, admin, commission, is_active, is_available, commission_percent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, created_at, updated_at`
 
 	return r.db.QueryRowx(q,
@@ -87,6 +150,7 @@ func (r *PPOBProviderRepository) CreateProviderSKU(sku *models.PPOBProviderSKU)
 		sku.Commission,
 		sku.IsActive,
 		sku.IsAvailable,
+		sku.CommissionPercent,
 	).Scan(&sku.ID, &sku.CreatedAt, &sku.UpdatedAt)
 }
 
@@ -101,6 +165,7 @@ func (r *PPOBProviderRepository) UpdateProviderSKU(sku *models.PPOBProviderSKU)
 			commission = $6,
 			is_active = $7,
 			is_available = $8,
+			commission_percent = $9,
 			updated_at = NOW()
 		WHERE id = $1`
 
@@ -113,6 +178,7 @@ func (r *PPOBProviderRepository) UpdateProviderSKU(sku *models.PPOBProviderSKU)
 		sku.Commission,
 		sku.IsActive,
 		sku.IsAvailable,
+		sku.CommissionPercent,
 	)
 	return err
 }
@@ -225,6 +291,105 @@ func (r *PPOBProviderRepository) GetProviderSKUsByProvider(providerID int) ([]mo
 	return skus, nil
 }
 
+// DerivedSKUCollision is a product whose derived provider SKU code another
+// product of the provider already has (or would get from the same rule). No
+// mapping is created for it; it has to be mapped by hand.
+type DerivedSKUCollision struct {
+	SkuCode         string `json:"skuCode"`
+	ProviderSKUCode string `json:"providerSkuCode"`
+}
+
+// derivedSKUProduct is a product CreateDerivedProviderSKUs may map.
+type derivedSKUProduct struct {
+	ID      int    `db:"id"`
+	SkuCode string `db:"sku_code"`
+	Name    string `db:"name"`
+}
+
+// CreateDerivedProviderSKUs maps every active product the provider has no
+// mapping for, within rule's categories and types, to the SKU code rule
+// derives from the product's code. The rows are flagged is_derived and start
+// unavailable with no price until a price sync confirms them. A derived code
+// that clashes with another product's is skipped and reported, since the
+// provider would treat both products as one. Returns the number of mappings
+// created.
+func (r *PPOBProviderRepository) CreateDerivedProviderSKUs(providerID int, rule models.DerivedSKUConfig) (int, []DerivedSKUCollision, error) {
+	const listQ = `
+		SELECT p.id, p.sku_code, p.name
+		FROM products p
+		WHERE p.is_active = true
+		AND (cardinality($2::text[]) = 0 OR p.category = ANY($2))
+		AND (cardinality($3::text[]) = 0 OR p.type::text = ANY($3))
+		AND NOT EXISTS (
+			SELECT 1 FROM ppob_provider_skus ps
+			WHERE ps.provider_id = $1 AND ps.product_id = p.id
+		)
+		ORDER BY p.sku_code`
+	var products []derivedSKUProduct
+	if err := r.db.Select(&products, listQ, providerID, pq.Array(rule.Categories), pq.Array(rule.Types)); err != nil {
+		return 0, nil, err
+	}
+	if len(products) == 0 {
+		return 0, nil, nil
+	}
+
+	var taken []string
+	if err := r.db.Select(&taken, `SELECT DISTINCT provider_sku_code FROM ppob_provider_skus WHERE provider_id = $1`, providerID); err != nil {
+		return 0, nil, err
+	}
+	codes, collisions := deriveSKUCodes(rule, products, taken)
+
+	const insertQ = `
+		INSERT INTO ppob_provider_skus
+			(provider_id, product_id, provider_sku_code, provider_product_name, price, admin, is_active, is_available, is_derived, sync_error)
+		VALUES ($1, $2, $3, $4, 0, 0, true, false, true, 'derived mapping; awaiting price sync')
+		ON CONFLICT DO NOTHING`
+	created := 0
+	for _, p := range products {
+		code, ok := codes[p.ID]
+		if !ok {
+			continue
+		}
+		res, err := r.db.Exec(insertQ, providerID, p.ID, code, p.Name)
+		if err != nil {
+			return created, collisions, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			created++
+		}
+	}
+	return created, collisions, nil
+}
+
+// deriveSKUCodes returns the provider SKU code rule derives for each product,
+// by product ID, leaving out empty codes and codes that are taken or derived
+// for more than one product; the latter are returned as collisions.
+func deriveSKUCodes(rule models.DerivedSKUConfig, products []derivedSKUProduct, taken []string) (map[int]string, []DerivedSKUCollision) {
+	uses := make(map[string]int, len(taken)+len(products))
+	for _, code := range taken {
+		uses[code]++
+	}
+	derived := make([]string, len(products))
+	for i, p := range products {
+		if derived[i] = rule.Code(p.SkuCode); derived[i] != "" {
+			uses[derived[i]]++
+		}
+	}
+
+	codes := make(map[int]string, len(products))
+	var collisions []DerivedSKUCollision
+	for i, p := range products {
+		switch code := derived[i]; {
+		case code == "":
+		case uses[code] > 1:
+			collisions = append(collisions, DerivedSKUCollision{SkuCode: p.SkuCode, ProviderSKUCode: code})
+		default:
+			codes[p.ID] = code
+		}
+	}
+	return codes, collisions
+}
+
 // GetProviderSKUByProviderAndProduct finds SKU mapping by provider and product.
 // Returns nil, nil if no mapping exists.
 func (r *PPOBProviderRepository) GetProviderSKUByProviderAndProduct(providerID, productID int) (*models.PPOBProviderSKU, error) {
@@ -248,6 +413,105 @@ func (r *PPOBProviderRepository) GetProviderSKUByProviderAndProduct(providerID,
 	return &sku, nil
 }
 
+// GetProviderSKUsByCode returns the provider's SKU mappings with
+// provider_sku_code code, of any product. More than one product means the
+// provider code is mapped ambiguously.
+func (r *PPOBProviderRepository) GetProviderSKUsByCode(providerID int, code string) ([]models.PPOBProviderSKU, error) {
+	const q = `
+		SELECT
+			ps.*,
+			pr.code AS provider_code,
+			pr.name AS provider_name,
+			pr.is_backup,
+			p.name AS product_name,
+			p.sku_code,
+			p.type::text AS product_type
+		FROM ppob_provider_skus ps
+		JOIN ppob_providers pr ON ps.provider_id = pr.id
+		JOIN products p ON ps.product_id = p.id
+		WHERE ps.provider_id = $1 AND ps.provider_sku_code = $2
+		ORDER BY p.sku_code`
+
+	var skus []models.PPOBProviderSKU
+	if err := r.db.Select(&skus, q, providerID, code); err != nil {
+		return nil, err
+	}
+	return skus, nil
+}
+
+// ProviderSKUCodeConflict is a provider SKU code mapped to several products.
+type ProviderSKUCodeConflict struct {
+	ProviderSKUCode string         `db:"provider_sku_code" json:"providerSkuCode"`
+	ProductIDs      pq.Int64Array  `db:"product_ids" json:"productIds"`
+	SkuCodes        pq.StringArray `db:"sku_codes" json:"skuCodes"`
+}
+
+// GetProviderSKUCodeConflicts returns the provider's SKU codes that map to
+// more than one product, by code.
+func (r *PPOBProviderRepository) GetProviderSKUCodeConflicts(providerID int) ([]ProviderSKUCodeConflict, error) {
+	const q = `
+		SELECT ps.provider_sku_code,
+			ARRAY_AGG(p.id ORDER BY p.sku_code) AS product_ids,
+			ARRAY_AGG(p.sku_code ORDER BY p.sku_code) AS sku_codes
+		FROM ppob_provider_skus ps
+		JOIN products p ON ps.product_id = p.id
+		WHERE ps.provider_id = $1
+		GROUP BY ps.provider_sku_code
+		HAVING COUNT(DISTINCT ps.product_id) > 1
+		ORDER BY ps.provider_sku_code`
+
+	var conflicts []ProviderSKUCodeConflict
+	if err := r.db.Select(&conflicts, q, providerID); err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
+
+// EachProviderSKU calls fn with each of the provider's SKU mappings, by
+// product sku_code, as they are read, so a large catalog is never held in
+// memory. An error from fn stops the iteration and is returned.
+func (r *PPOBProviderRepository) EachProviderSKU(providerID int, fn func(models.PPOBProviderSKU) error) error {
+	const q = `
+		SELECT ps.*, p.sku_code, p.name AS product_name
+		FROM ppob_provider_skus ps
+		JOIN products p ON ps.product_id = p.id
+		WHERE ps.provider_id = $1
+		ORDER BY p.sku_code, ps.provider_sku_code`
+
+	rows, err := r.db.Queryx(q, providerID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sku models.PPOBProviderSKU
+		if err := rows.StructScan(&sku); err != nil {
+			return err
+		}
+		if err := fn(sku); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// providerSKUListWhere filters provider SKUs (ps) by provider ($1, 0 = all)
+// and product name/sku_code search ($2, empty = all).
+const providerSKUListWhere = `WHERE ($1 = 0 OR ps.provider_id = $1)
+		AND ($2 = '' OR p.name ILIKE '%%' || $2 || '%%' OR p.sku_code ILIKE '%%' || $2 || '%%')`
+
+// CountProviderSKUs returns the total GetAllProviderSKUsPaged reports for the
+// same filter, without selecting any rows.
+func (r *PPOBProviderRepository) CountProviderSKUs(providerID int, search string) (int, error) {
+	countQ := `SELECT COUNT(1) FROM ppob_provider_skus ps
+		JOIN products p ON ps.product_id = p.id ` + providerSKUListWhere
+	var total int
+	if err := r.db.Get(&total, countQ, providerID, search); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
 // GetAllProviderSKUsPaged returns all provider SKUs with pagination.
 func (r *PPOBProviderRepository) GetAllProviderSKUsPaged(providerID int, search string, page, limit int) ([]models.PPOBProviderSKU, int, error) {
 	if page <= 0 {
@@ -258,14 +522,8 @@ func (r *PPOBProviderRepository) GetAllProviderSKUsPaged(providerID int, search
 	}
 	offset := (page - 1) * limit
 
-	baseWhere := `WHERE ($1 = 0 OR ps.provider_id = $1)
-		AND ($2 = '' OR p.name ILIKE '%%' || $2 || '%%' OR p.sku_code ILIKE '%%' || $2 || '%%')`
-
-	// Count
-	countQ := `SELECT COUNT(1) FROM ppob_provider_skus ps
-		JOIN products p ON ps.product_id = p.id ` + baseWhere
-	var total int
-	if err := r.db.Get(&total, countQ, providerID, search); err != nil {
+	total, err := r.CountProviderSKUs(providerID, search)
+	if err != nil {
 		return nil, 0, err
 	}
 
@@ -281,7 +539,7 @@ func (r *PPOBProviderRepository) GetAllProviderSKUsPaged(providerID int, search
 			p.type::text AS product_type
 		FROM ppob_provider_skus ps
 		JOIN ppob_providers pr ON ps.provider_id = pr.id
-		JOIN products p ON ps.product_id = p.id ` + baseWhere + `
+		JOIN products p ON ps.product_id = p.id ` + providerSKUListWhere + `
 		ORDER BY pr.name, p.category, p.brand, p.name
 		LIMIT $3 OFFSET $4`
 
@@ -296,8 +554,33 @@ func (r *PPOBProviderRepository) GetAllProviderSKUsPaged(providerID int, search
 // Provider Selection for Transaction
 // ============================================
 
+// notInMaintenance excludes providers (aliased pr) inside a scheduled maintenance window.
+const notInMaintenance = `
+		AND NOT EXISTS (
+			SELECT 1 FROM ppob_provider_maintenance_windows mw
+			WHERE mw.provider_id = pr.id AND NOW() >= mw.starts_at AND NOW() < mw.ends_at
+		)`
+
+// outsideCutOff excludes providers (aliased pr) inside their daily WIB cut-off
+// window, with the same rules as SKURepository.GetAvailableSKUs.
+const outsideCutOff = `
+		AND (
+			(pr.cut_off_start = '00:00:00' AND pr.cut_off_end = '00:00:00')
+			OR (pr.cut_off_start < pr.cut_off_end
+				AND NOT ((NOW() AT TIME ZONE 'Asia/Jakarta')::time BETWEEN pr.cut_off_start AND pr.cut_off_end))
+			OR (pr.cut_off_start > pr.cut_off_end
+				AND NOT ((NOW() AT TIME ZONE 'Asia/Jakarta')::time >= pr.cut_off_start
+					OR (NOW() AT TIME ZONE 'Asia/Jakarta')::time <= pr.cut_off_end))
+		)`
+
+// tieBreakOrder makes exact ties on (is_backup, price/admin, priority)
+// deterministic: lower provider id first, then lower provider SKU id.
+const tieBreakOrder = `, pr.id ASC, ps.id ASC`
+
 // GetProvidersForProduct returns providers sorted by price for PREPAID transaction execution.
 // Non-backup providers first (sorted by price ASC), then backup providers.
+// With the weighted_price strategy the price is adjusted by today's success rate.
+// A product's selection_strategy, when set, takes precedence over both.
 func (r *PPOBProviderRepository) GetProvidersForProduct(productID int) ([]models.ProviderOption, error) {
 	const q = `
 		SELECT 
@@ -308,24 +591,195 @@ func (r *PPOBProviderRepository) GetProvidersForProduct(productID int) ([]models
 			ps.price,
 			ps.admin,
 			ps.commission,
-			pr.is_backup
+			ps.commission_percent,
+			ps.is_derived,
+			pr.is_backup,
+			pr.priority,
+			COALESCE(p.selection_strategy, '') AS selection_strategy
 		FROM ppob_provider_skus ps
 		JOIN ppob_providers pr ON ps.provider_id = pr.id
+		JOIN products p ON ps.product_id = p.id
 		WHERE ps.product_id = $1
 		AND ps.is_active = true
 		AND ps.is_available = true
 		AND pr.is_active = true
-		AND ps.price > 0
-		ORDER BY pr.is_backup ASC, ps.price ASC, pr.priority ASC`
+		AND ps.price > 0` + notInMaintenance + outsideCutOff + `
+		ORDER BY pr.is_backup ASC, ps.price ASC, pr.priority ASC` + tieBreakOrder
 
-	var options []models.ProviderOption
-	if err := r.db.Select(&options, q, productID); err != nil {
+	var rows []strategyOptionRow
+	if err := r.db.Select(&rows, q, productID); err != nil {
+		return nil, err
+	}
+	options, strategy := splitStrategyRows(rows)
+	applyCommissionPercent(options)
+
+	if strategy == "" && r.selectionStrategy == SelectionStrategyWeightedPrice {
+		strategy = SelectionStrategyWeightedPrice
+	}
+	if r.tieBreakNext != nil {
+		// Rotate on the SQL order: the strategy sorts are stable, so the
+		// rotation only survives among options they still rank equal.
+		rotateTies(options, r.tieBreakNext())
+	}
+	if err := r.orderOptions(options, strategy); err != nil {
 		return nil, err
 	}
 	return options, nil
 }
 
-// GetProvidersForProductPostpaid returns providers sorted by effective admin (admin - commission) for POSTPAID.
+// strategyOptionRow is a provider option read together with its product's
+// selection_strategy, so the override needs no query of its own.
+type strategyOptionRow struct {
+	models.ProviderOption
+	SelectionStrategy string `db:"selection_strategy"`
+}
+
+// splitStrategyRows returns the options and the product's selection strategy
+// ("" when unset or when there are no options).
+func splitStrategyRows(rows []strategyOptionRow) ([]models.ProviderOption, string) {
+	if len(rows) == 0 {
+		return nil, ""
+	}
+	options := make([]models.ProviderOption, len(rows))
+	for i, row := range rows {
+		options[i] = row.ProviderOption
+	}
+	return options, rows[0].SelectionStrategy
+}
+
+// rotateTies rotates each run of exactly tied options (same backup flag, price
+// and priority) left by offset, so tied providers take turns being first. It
+// runs on the SQL order, before any strategy re-sort.
+func rotateTies(options []models.ProviderOption, offset uint64) {
+	for start := 0; start < len(options); {
+		end := start + 1
+		for end < len(options) && sameTie(options[start], options[end]) {
+			end++
+		}
+		if n := end - start; n > 1 {
+			group := options[start:end]
+			k := int(offset % uint64(n))
+			rotated := append(append([]models.ProviderOption{}, group[k:]...), group[:k]...)
+			copy(group, rotated)
+		}
+		start = end
+	}
+}
+
+func sameTie(a, b models.ProviderOption) bool {
+	return a.IsBackup == b.IsBackup && a.Price == b.Price && a.Priority == b.Priority
+}
+
+// applyCommissionPercent replaces Commission with the percentage of Admin for
+// options that carry a commission_percent, reporting whether any did.
+func applyCommissionPercent(options []models.ProviderOption) bool {
+	applied := false
+	for i := range options {
+		if options[i].CommissionPercent == nil {
+			continue
+		}
+		options[i].Commission = models.PercentCommission(options[i].Admin, *options[i].CommissionPercent)
+		applied = true
+	}
+	return applied
+}
+
+// orderOptions re-sorts options by strategy; "" or an unknown value keeps the
+// SQL order. Every strategy keeps non-backup providers first and is stable,
+// so the SQL order breaks ties.
+func (r *PPOBProviderRepository) orderOptions(options []models.ProviderOption, strategy string) error {
+	if len(options) < 2 {
+		return nil
+	}
+	if strategy == SelectionStrategyWeightedPrice {
+		health, err := r.GetAllProviderHealthToday()
+		if err != nil {
+			return err
+		}
+		sortBySuccessWeightedPrice(options, successRates(health))
+		return nil
+	}
+	sortOptions(options, strategy)
+	return nil
+}
+
+// sortOptions applies the price, effective_admin and priority strategies.
+func sortOptions(options []models.ProviderOption, strategy string) {
+	var less func(a, b models.ProviderOption) bool
+	switch strategy {
+	case SelectionStrategyPrice:
+		less = func(a, b models.ProviderOption) bool {
+			if a.Price != b.Price {
+				return a.Price < b.Price
+			}
+			return a.Priority < b.Priority
+		}
+	case SelectionStrategyEffectiveAdmin:
+		less = func(a, b models.ProviderOption) bool {
+			if a.EffectiveAdmin() != b.EffectiveAdmin() {
+				return a.EffectiveAdmin() < b.EffectiveAdmin()
+			}
+			return a.Priority < b.Priority
+		}
+	case SelectionStrategyPriority:
+		less = func(a, b models.ProviderOption) bool {
+			if a.Priority != b.Priority {
+				return a.Priority < b.Priority
+			}
+			return a.Price < b.Price
+		}
+	default:
+		return
+	}
+	sort.SliceStable(options, func(i, j int) bool {
+		if options[i].IsBackup != options[j].IsBackup {
+			return !options[i].IsBackup
+		}
+		return less(options[i], options[j])
+	})
+}
+
+// successRates maps provider ID to today's success rate (0..1) for providers
+// with enough samples; others are left out and treated as fully reliable.
+func successRates(health []models.PPOBProviderHealth) map[int]float64 {
+	rates := make(map[int]float64, len(health))
+	for _, h := range health {
+		if h.TotalRequests < minHealthSamples {
+			continue
+		}
+		rates[h.ProviderID] = float64(h.SuccessCount) / float64(h.TotalRequests)
+	}
+	return rates
+}
+
+// weightedPrice is the expected cost per successful transaction: price / success rate.
+func weightedPrice(price int, rate float64, ok bool) float64 {
+	if !ok {
+		return float64(price)
+	}
+	if rate < 0.01 {
+		rate = 0.01
+	}
+	return float64(price) / rate
+}
+
+// sortBySuccessWeightedPrice reorders options by weighted price, keeping
+// non-backup providers first. The sort is stable so the SQL priority order
+// breaks ties.
+func sortBySuccessWeightedPrice(options []models.ProviderOption, rates map[int]float64) {
+	sort.SliceStable(options, func(i, j int) bool {
+		if options[i].IsBackup != options[j].IsBackup {
+			return !options[i].IsBackup
+		}
+		ri, oki := rates[options[i].ProviderID]
+		rj, okj := rates[options[j].ProviderID]
+		return weightedPrice(options[i].Price, ri, oki) < weightedPrice(options[j].Price, rj, okj)
+	})
+}
+
+// GetProvidersForProductPostpaid returns providers sorted by effective admin (admin - commission) for POSTPAID,
+// unless the product's selection_strategy says otherwise. Percentage commissions are resolved against the
+// current admin before ranking.
 // Lower effective admin = better for postpaid because we earn more commission.
 // Example: A admin=5000, comm=3500 → effective=1500 | B admin=3000, comm=1000 → effective=2000 | A wins
 func (r *PPOBProviderRepository) GetProvidersForProductPostpaid(productID int) ([]models.ProviderOption, error) {
@@ -338,17 +792,31 @@ func (r *PPOBProviderRepository) GetProvidersForProductPostpaid(productID int) (
 			ps.price,
 			ps.admin,
 			ps.commission,
-			pr.is_backup
+			ps.commission_percent,
+			ps.is_derived,
+			pr.is_backup,
+			pr.priority,
+			COALESCE(p.selection_strategy, '') AS selection_strategy
 		FROM ppob_provider_skus ps
 		JOIN ppob_providers pr ON ps.provider_id = pr.id
+		JOIN products p ON ps.product_id = p.id
 		WHERE ps.product_id = $1
 		AND ps.is_active = true
 		AND ps.is_available = true
-		AND pr.is_active = true
-		ORDER BY pr.is_backup ASC, (ps.admin - ps.commission) ASC, pr.priority ASC`
+		AND pr.is_active = true` + notInMaintenance + outsideCutOff + `
+		ORDER BY pr.is_backup ASC, (ps.admin - ps.commission) ASC, pr.priority ASC` + tieBreakOrder
 
-	var options []models.ProviderOption
-	if err := r.db.Select(&options, q, productID); err != nil {
+	var rows []strategyOptionRow
+	if err := r.db.Select(&rows, q, productID); err != nil {
+		return nil, err
+	}
+
+	options, strategy := splitStrategyRows(rows)
+	if applyCommissionPercent(options) && strategy == "" {
+		// The SQL order used the stored commission; re-rank on the computed one.
+		strategy = SelectionStrategyEffectiveAdmin
+	}
+	if err := r.orderOptions(options, strategy); err != nil {
 		return nil, err
 	}
 	return options, nil
@@ -366,18 +834,24 @@ func (r *PPOBProviderRepository) GetProvidersForProductAll(productID int) ([]mod
 			ps.price,
 			ps.admin,
 			ps.commission,
-			pr.is_backup
+			ps.commission_percent,
+			ps.is_derived,
+			pr.is_backup,
+			pr.priority
 		FROM ppob_provider_skus ps
 		JOIN ppob_providers pr ON ps.provider_id = pr.id
 		WHERE ps.product_id = $1
 		AND ps.is_active = true
 		AND pr.is_active = true
-		ORDER BY pr.is_backup ASC, (ps.admin - ps.commission) ASC, pr.priority ASC`
+		ORDER BY pr.is_backup ASC, (ps.admin - ps.commission) ASC, pr.priority ASC` + tieBreakOrder
 
 	var options []models.ProviderOption
 	if err := r.db.Select(&options, q, productID); err != nil {
 		return nil, err
 	}
+	if applyCommissionPercent(options) {
+		sortOptions(options, SelectionStrategyEffectiveAdmin)
+	}
 	return options, nil
 }
 
@@ -392,7 +866,7 @@ func (r *PPOBProviderRepository) GetBestPriceForProduct(productID int) (*int, *i
 		AND ps.is_available = true
 		AND pr.is_active = true
 		AND pr.is_backup = false
-		AND ps.price > 0
+		AND ps.price > 0` + notInMaintenance + outsideCutOff + `
 		ORDER BY ps.price ASC
 		LIMIT 1`
 
@@ -411,7 +885,7 @@ func (r *PPOBProviderRepository) GetBestPriceForProduct(productID int) (*int, *i
 // ============================================
 
 // GetProductsWithBestPrice returns products with their best price from non-backup providers.
-func (r *PPOBProviderRepository) GetProductsWithBestPrice(productType, category, brand, search string, page, limit int) ([]models.ProductWithBestPrice, int, error) {
+func (r *PPOBProviderRepository) GetProductsWithBestPrice(productType, category, brand, search string, availableNow bool, page, limit int) ([]models.ProductWithBestPrice, int, error) {
 	if page <= 0 {
 		page = 1
 	}
@@ -425,6 +899,9 @@ func (r *PPOBProviderRepository) GetProductsWithBestPrice(productType, category,
 		AND ($2 = '' OR p.category = $2)
 		AND ($3 = '' OR p.brand = $3)
 		AND ($4 = '' OR p.name ILIKE '%%' || $4 || '%%')`
+	if availableNow {
+		baseWhere += availableNowFilter
+	}
 
 	// Count
 	countQ := `SELECT COUNT(1) FROM products p ` + baseWhere
@@ -453,7 +930,7 @@ func (r *PPOBProviderRepository) GetProductsWithBestPrice(productType, category,
 				AND ps.is_active = true
 				AND ps.is_available = true
 				AND pr.is_active = true
-				AND pr.is_backup = false
+				AND pr.is_backup = false` + notInMaintenance + outsideCutOff + `
 				AND ps.price > 0
 			) AS best_price,
 			(
@@ -464,7 +941,7 @@ func (r *PPOBProviderRepository) GetProductsWithBestPrice(productType, category,
 				AND ps.is_active = true
 				AND ps.is_available = true
 				AND pr.is_active = true
-				AND pr.is_backup = false
+				AND pr.is_backup = false` + notInMaintenance + outsideCutOff + `
 				AND ps.price > 0
 				ORDER BY ps.price ASC
 				LIMIT 1
@@ -477,7 +954,7 @@ func (r *PPOBProviderRepository) GetProductsWithBestPrice(productType, category,
 				AND ps.is_active = true
 				AND ps.is_available = true
 				AND pr.is_active = true
-				AND pr.is_backup = false
+				AND pr.is_backup = false` + notInMaintenance + outsideCutOff + `
 			) AS provider_count
 		FROM products p ` + baseWhere + `
 		ORDER BY p.category, p.brand, p.name
@@ -490,43 +967,154 @@ func (r *PPOBProviderRepository) GetProductsWithBestPrice(productType, category,
 	return products, total, nil
 }
 
+// GetProviderPricesForProducts returns every priced non-backup provider SKU
+// for the given products, using the same filters as GetProductsWithBestPrice.
+func (r *PPOBProviderRepository) GetProviderPricesForProducts(productIDs []int) ([]models.ProductProviderPrice, error) {
+	if len(productIDs) == 0 {
+		return nil, nil
+	}
+	q := `
+		SELECT ps.product_id, pr.code AS provider_code, ps.price
+		FROM ppob_provider_skus ps
+		JOIN ppob_providers pr ON ps.provider_id = pr.id
+		WHERE ps.product_id = ANY($1)
+		AND ps.is_active = true
+		AND ps.is_available = true
+		AND pr.is_active = true
+		AND pr.is_backup = false` + notInMaintenance + outsideCutOff + `
+		AND ps.price > 0`
+
+	var prices []models.ProductProviderPrice
+	if err := r.db.Select(&prices, q, pq.Array(productIDs)); err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
+
 // ============================================
 // Provider Health
 // ============================================
 
+// ProviderHealthDelta aggregates provider calls not yet written to today's
+// health row.
+type ProviderHealthDelta struct {
+	Requests          int
+	SuccessCount      int
+	FailedCount       int
+	ResponseTimeMsSum int64
+	LastSuccessAt     *time.Time
+	LastFailureAt     *time.Time
+	LastFailureReason string
+}
+
+// Add folds one provider call into the delta.
+func (d *ProviderHealthDelta) Add(success bool, responseTimeMs int, failureReason string, at time.Time) {
+	d.Requests++
+	d.ResponseTimeMsSum += int64(responseTimeMs)
+	if success {
+		d.SuccessCount++
+		d.LastSuccessAt = &at
+		return
+	}
+	d.FailedCount++
+	d.LastFailureAt = &at
+	d.LastFailureReason = failureReason
+}
+
+// UpdateProviderPriceListSize stores the item count of the price list a sync
+// just applied.
+func (r *PPOBProviderRepository) UpdateProviderPriceListSize(providerID, size int) error {
+	_, err := r.db.Exec(`UPDATE ppob_providers SET last_price_list_size = $2 WHERE id = $1`, providerID, size)
+	return err
+}
+
 // RecordProviderRequest records a request to a provider for health tracking.
 func (r *PPOBProviderRepository) RecordProviderRequest(providerID int, success bool, responseTimeMs int, failureReason string) error {
+	var d ProviderHealthDelta
+	d.Add(success, responseTimeMs, failureReason, time.Now())
+	return r.RecordProviderHealth(providerID, d)
+}
+
+// RecordProviderHealth adds an aggregated batch of provider calls to today's
+// health row in one upsert.
+func (r *PPOBProviderRepository) RecordProviderHealth(providerID int, d ProviderHealthDelta) error {
+	if d.Requests <= 0 {
+		return nil
+	}
 	const q = `
 		INSERT INTO ppob_provider_health 
 			(provider_id, total_requests, success_count, failed_count, last_success_at, last_failure_at, last_failure_reason, avg_response_time_ms, date)
-		VALUES ($1, 1, $2, $3, $4, $5, $6, $7, CURRENT_DATE)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8::BIGINT / $2, CURRENT_DATE)
 		ON CONFLICT (provider_id, date) DO UPDATE SET
-			total_requests = ppob_provider_health.total_requests + 1,
-			success_count = ppob_provider_health.success_count + $2,
-			failed_count = ppob_provider_health.failed_count + $3,
-			last_success_at = CASE WHEN $2 = 1 THEN NOW() ELSE ppob_provider_health.last_success_at END,
-			last_failure_at = CASE WHEN $3 = 1 THEN NOW() ELSE ppob_provider_health.last_failure_at END,
-			last_failure_reason = CASE WHEN $3 = 1 THEN $6 ELSE ppob_provider_health.last_failure_reason END,
-			avg_response_time_ms = (ppob_provider_health.avg_response_time_ms * ppob_provider_health.total_requests + $7) / (ppob_provider_health.total_requests + 1),
-			health_score = (ppob_provider_health.success_count + $2)::DECIMAL / (ppob_provider_health.total_requests + 1) * 100,
+			total_requests = ppob_provider_health.total_requests + $2,
+			success_count = ppob_provider_health.success_count + $3,
+			failed_count = ppob_provider_health.failed_count + $4,
+			last_success_at = COALESCE($5, ppob_provider_health.last_success_at),
+			last_failure_at = COALESCE($6, ppob_provider_health.last_failure_at),
+			last_failure_reason = CASE WHEN $4 > 0 THEN $7 ELSE ppob_provider_health.last_failure_reason END,
+			avg_response_time_ms = (ppob_provider_health.avg_response_time_ms::BIGINT * ppob_provider_health.total_requests + $8::BIGINT) / (ppob_provider_health.total_requests + $2),
+			health_score = (ppob_provider_health.success_count + $3)::DECIMAL / (ppob_provider_health.total_requests + $2) * 100,
 			updated_at = NOW()`
 
-	var successCount, failedCount int
-	var lastSuccessAt, lastFailureAt *time.Time
-	now := time.Now()
+	_, err := r.db.Exec(q, providerID, d.Requests, d.SuccessCount, d.FailedCount,
+		d.LastSuccessAt, d.LastFailureAt, d.LastFailureReason, d.ResponseTimeMsSum)
+	return err
+}
 
-	if success {
-		successCount = 1
-		lastSuccessAt = &now
-	} else {
-		failedCount = 1
-		lastFailureAt = &now
-	}
+// RecordProviderBalance stores the provider's latest deposit balance on
+// today's health row.
+func (r *PPOBProviderRepository) RecordProviderBalance(providerID int, balance int64) error {
+	const q = `
+		INSERT INTO ppob_provider_health (provider_id, last_balance, last_balance_at, date)
+		VALUES ($1, $2, NOW(), CURRENT_DATE)
+		ON CONFLICT (provider_id, date) DO UPDATE SET
+			last_balance = EXCLUDED.last_balance,
+			last_balance_at = EXCLUDED.last_balance_at,
+			updated_at = NOW()`
+	_, err := r.db.Exec(q, providerID, balance)
+	return err
+}
 
-	_, err := r.db.Exec(q, providerID, successCount, failedCount, lastSuccessAt, lastFailureAt, failureReason, responseTimeMs)
+// RecordProviderBalanceSample appends a balance sample to the provider's
+// balance history.
+func (r *PPOBProviderRepository) RecordProviderBalanceSample(providerID int, balance int64) error {
+	const q = `INSERT INTO ppob_provider_balance_history (provider_id, balance) VALUES ($1, $2)`
+	_, err := r.db.Exec(q, providerID, balance)
 	return err
 }
 
+// DeleteProviderBalanceSamplesBefore drops balance history samples recorded
+// before cutoff, returning how many were removed.
+func (r *PPOBProviderRepository) DeleteProviderBalanceSamplesBefore(cutoff time.Time) (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM ppob_provider_balance_history WHERE recorded_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetProviderBalanceTrend groups a provider's balance samples in [from, to)
+// by period ("hour" or "day", Asia/Jakarta), oldest first.
+func (r *PPOBProviderRepository) GetProviderBalanceTrend(providerID int, period string, from, to time.Time) ([]models.ProviderBalancePoint, error) {
+	const q = `
+		SELECT
+			DATE_TRUNC($2, recorded_at AT TIME ZONE 'Asia/Jakarta') AT TIME ZONE 'Asia/Jakarta' AS period,
+			MIN(balance) AS min_balance,
+			MAX(balance) AS max_balance,
+			(ARRAY_AGG(balance ORDER BY recorded_at DESC))[1] AS last_balance,
+			COUNT(*) AS samples
+		FROM ppob_provider_balance_history
+		WHERE provider_id = $1 AND recorded_at >= $3 AND recorded_at < $4
+		GROUP BY 1
+		ORDER BY 1`
+
+	var points []models.ProviderBalancePoint
+	if err := r.db.Select(&points, q, providerID, period, from, to); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
 // GetProviderHealth returns health stats for a provider (today).
 func (r *PPOBProviderRepository) GetProviderHealth(providerID int) (*models.PPOBProviderHealth, error) {
 	const q = `
@@ -561,6 +1149,73 @@ func (r *PPOBProviderRepository) GetAllProviderHealthToday() ([]models.PPOBProvi
 	return health, nil
 }
 
+// ============================================
+// Provider Maintenance Windows
+// ============================================
+
+// ListMaintenanceWindows returns maintenance windows, optionally only those not yet ended.
+func (r *PPOBProviderRepository) ListMaintenanceWindows(providerID int, upcomingOnly bool) ([]models.PPOBProviderMaintenanceWindow, error) {
+	const q = `
+		SELECT mw.*, pr.code AS provider_code
+		FROM ppob_provider_maintenance_windows mw
+		JOIN ppob_providers pr ON mw.provider_id = pr.id
+		WHERE ($1 = 0 OR mw.provider_id = $1)
+		AND ($2 = false OR mw.ends_at > NOW())
+		ORDER BY mw.starts_at ASC`
+
+	var windows []models.PPOBProviderMaintenanceWindow
+	if err := r.db.Select(&windows, q, providerID, upcomingOnly); err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+// GetMaintenanceWindow returns a maintenance window by ID.
+func (r *PPOBProviderRepository) GetMaintenanceWindow(id int) (*models.PPOBProviderMaintenanceWindow, error) {
+	const q = `
+		SELECT mw.*, pr.code AS provider_code
+		FROM ppob_provider_maintenance_windows mw
+		JOIN ppob_providers pr ON mw.provider_id = pr.id
+		WHERE mw.id = $1`
+	var w models.PPOBProviderMaintenanceWindow
+	if err := r.db.Get(&w, q, id); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// CreateMaintenanceWindow inserts a maintenance window.
+func (r *PPOBProviderRepository) CreateMaintenanceWindow(w *models.PPOBProviderMaintenanceWindow) error {
+	const q = `
+		INSERT INTO ppob_provider_maintenance_windows (provider_id, starts_at, ends_at, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at`
+	return r.db.QueryRowx(q, w.ProviderID, w.StartsAt, w.EndsAt, w.Reason).
+		Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+}
+
+// UpdateMaintenanceWindow updates the range and reason of a maintenance window.
+func (r *PPOBProviderRepository) UpdateMaintenanceWindow(w *models.PPOBProviderMaintenanceWindow) error {
+	const q = `
+		UPDATE ppob_provider_maintenance_windows
+		SET starts_at = $2, ends_at = $3, reason = $4, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`
+	return r.db.QueryRowx(q, w.ID, w.StartsAt, w.EndsAt, w.Reason).Scan(&w.UpdatedAt)
+}
+
+// DeleteMaintenanceWindow deletes a maintenance window.
+func (r *PPOBProviderRepository) DeleteMaintenanceWindow(id int) error {
+	res, err := r.db.Exec(`DELETE FROM ppob_provider_maintenance_windows WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 // ============================================
 // Provider Callbacks
 // ============================================
@@ -605,6 +1260,93 @@ func (r *PPOBProviderRepository) GetUnprocessedCallbacks(limit int) ([]models.PP
 	return callbacks, nil
 }
 
+// RecordCallbackError stores a processing failure and schedules the next
+// retry; nextRetryAt nil leaves the callback failed for manual follow-up.
+func (r *PPOBProviderRepository) RecordCallbackError(id int, processError string, nextRetryAt *time.Time) error {
+	const q = `
+		UPDATE ppob_provider_callbacks SET
+			process_error = $2,
+			retry_count = retry_count + 1,
+			next_retry_at = $3
+		WHERE id = $1 AND is_processed = false`
+	_, err := r.db.Exec(q, id, processError, nextRetryAt)
+	return err
+}
+
+// ClaimRetryableCallbacks returns up to limit failed callbacks whose next
+// retry is due and pushes their next_retry_at out by lease, so concurrent
+// workers never re-process the same row. A run that dies mid-way leaves the
+// row to be claimed again once the lease expires; RecordCallbackError or
+// marking it processed replaces the lease.
+func (r *PPOBProviderRepository) ClaimRetryableCallbacks(limit int, lease time.Duration) ([]models.PPOBProviderCallback, error) {
+	const q = `
+		UPDATE ppob_provider_callbacks SET next_retry_at = NOW() + $2::interval
+		WHERE id IN (
+			SELECT id FROM ppob_provider_callbacks
+			WHERE is_processed = false
+			AND process_error IS NOT NULL
+			AND next_retry_at <= NOW()
+			ORDER BY next_retry_at ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`
+
+	var callbacks []models.PPOBProviderCallback
+	if err := r.db.Select(&callbacks, q, limit, fmt.Sprintf("%d seconds", int(lease.Seconds()))); err != nil {
+		return nil, err
+	}
+	return callbacks, nil
+}
+
+// FailedProviderCallback is the admin view of a provider callback whose
+// processing failed.
+type FailedProviderCallback struct {
+	ID            int        `db:"id" json:"id"`
+	ProviderCode  string     `db:"provider_code" json:"providerCode"`
+	ProviderRefID string     `db:"provider_ref_id" json:"providerRefId"`
+	TransactionID *string    `db:"transaction_code" json:"transactionId,omitempty"`
+	ProcessError  string     `db:"process_error" json:"processError"`
+	RetryCount    int        `db:"retry_count" json:"retryCount"`
+	NextRetryAt   *time.Time `db:"next_retry_at" json:"nextRetryAt,omitempty"`
+	CreatedAt     time.Time  `db:"created_at" json:"createdAt"`
+}
+
+// ListFailedCallbacks returns unprocessed callbacks with a processing error,
+// newest first. exhaustedOnly keeps those with no retry left.
+func (r *PPOBProviderRepository) ListFailedCallbacks(exhaustedOnly bool, page, limit int) ([]FailedProviderCallback, int, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	where := `
+		WHERE cb.is_processed = false
+		AND cb.process_error IS NOT NULL
+		AND ($1 = false OR cb.next_retry_at IS NULL)`
+
+	var total int
+	if err := r.db.Get(&total, `SELECT COUNT(1) FROM ppob_provider_callbacks cb`+where, exhaustedOnly); err != nil {
+		return nil, 0, err
+	}
+
+	q := `
+		SELECT cb.id, pr.code AS provider_code, cb.provider_ref_id, t.transaction_id AS transaction_code,
+			cb.process_error, cb.retry_count, cb.next_retry_at, cb.created_at
+		FROM ppob_provider_callbacks cb
+		JOIN ppob_providers pr ON cb.provider_id = pr.id
+		LEFT JOIN transactions t ON cb.transaction_id = t.id` + where + `
+		ORDER BY cb.created_at DESC
+		LIMIT $2 OFFSET $3`
+
+	var callbacks []FailedProviderCallback
+	if err := r.db.Select(&callbacks, q, exhaustedOnly, limit, (page-1)*limit); err != nil {
+		return nil, 0, err
+	}
+	return callbacks, total, nil
+}
+
 // MarkCallbackProcessed marks a callback as processed.
 func (r *PPOBProviderRepository) MarkCallbackProcessed(id int, processError string) error {
 	const q = `
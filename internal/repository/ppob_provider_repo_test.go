@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+func TestSortBySuccessWeightedPrice(t *testing.T) {
+	// SQL order (pure price): flaky cheap provider first.
+	options := []models.ProviderOption{
+		{ProviderID: 1, ProviderCode: "kiosbank", Price: 10000},
+		{ProviderID: 2, ProviderCode: "alterra", Price: 10200},
+		{ProviderID: 3, ProviderCode: "digiflazz", Price: 9000, IsBackup: true},
+	}
+	rates := successRates([]models.PPOBProviderHealth{
+		{ProviderID: 1, TotalRequests: 100, SuccessCount: 60},
+		{ProviderID: 2, TotalRequests: 100, SuccessCount: 99},
+	})
+
+	sortBySuccessWeightedPrice(options, rates)
+
+	want := []int{2, 1, 3}
+	for i, id := range want {
+		if options[i].ProviderID != id {
+			t.Fatalf("position %d: got provider %d, want %d (order %+v)", i, options[i].ProviderID, id, options)
+		}
+	}
+}
+
+func TestSuccessRatesIgnoresSmallSamples(t *testing.T) {
+	rates := successRates([]models.PPOBProviderHealth{
+		{ProviderID: 1, TotalRequests: 3, SuccessCount: 0},
+	})
+	if _, ok := rates[1]; ok {
+		t.Fatal("provider below minHealthSamples should not be weighted")
+	}
+}
+
+func TestRotateTiesIsDeterministic(t *testing.T) {
+	base := func() []models.ProviderOption {
+		// SQL order: ties broken by provider id.
+		return []models.ProviderOption{
+			{ProviderID: 1, Price: 10000, Priority: 1},
+			{ProviderID: 2, Price: 10000, Priority: 1},
+			{ProviderID: 3, Price: 10000, Priority: 1},
+			{ProviderID: 4, Price: 11000, Priority: 1},
+		}
+	}
+	ids := func(opts []models.ProviderOption) []int {
+		out := make([]int, len(opts))
+		for i, o := range opts {
+			out[i] = o.ProviderID
+		}
+		return out
+	}
+
+	next := NewRoundRobinTieBreak(1)
+	want := [][]int{{2, 3, 1, 4}, {3, 1, 2, 4}, {1, 2, 3, 4}, {2, 3, 1, 4}}
+	for call, w := range want {
+		opts := base()
+		rotateTies(opts, next())
+		got := ids(opts)
+		for i := range w {
+			if got[i] != w[i] {
+				t.Fatalf("call %d: got %v, want %v", call, got, w)
+			}
+		}
+	}
+}
+
+// Rotating before the strategy sort only reorders options the strategy ranks
+// equal: provider 2 has the lower effective admin and stays first.
+func TestRotateTiesBeforeStrategySort(t *testing.T) {
+	options := []models.ProviderOption{
+		{ProviderID: 1, Price: 10000, Admin: 2500, Priority: 1},
+		{ProviderID: 2, Price: 10000, Admin: 2500, Commission: 500, Priority: 1},
+		{ProviderID: 3, Price: 10000, Admin: 2500, Priority: 1},
+	}
+	rotateTies(options, 2)
+	sortOptions(options, SelectionStrategyEffectiveAdmin)
+
+	want := []int{2, 3, 1}
+	for i, id := range want {
+		if options[i].ProviderID != id {
+			t.Fatalf("position %d: got provider %d, want %d (order %+v)", i, options[i].ProviderID, id, options)
+		}
+	}
+}
+
+func TestSplitStrategyRows(t *testing.T) {
+	options, strategy := splitStrategyRows([]strategyOptionRow{
+		{ProviderOption: models.ProviderOption{ProviderID: 1}, SelectionStrategy: SelectionStrategyPriority},
+		{ProviderOption: models.ProviderOption{ProviderID: 2}, SelectionStrategy: SelectionStrategyPriority},
+	})
+	if strategy != SelectionStrategyPriority || len(options) != 2 || options[1].ProviderID != 2 {
+		t.Fatalf("got %q %+v", strategy, options)
+	}
+	if options, strategy := splitStrategyRows(nil); options != nil || strategy != "" {
+		t.Fatalf("empty rows: got %q %+v", strategy, options)
+	}
+}
+
+func TestSortOptionsByStrategy(t *testing.T) {
+	base := []models.ProviderOption{
+		{ProviderID: 1, Price: 10000, Admin: 2500, Commission: 0, Priority: 3},
+		{ProviderID: 2, Price: 10200, Admin: 2500, Commission: 500, Priority: 1},
+		{ProviderID: 3, Price: 9000, Admin: 1000, Priority: 0, IsBackup: true},
+	}
+	cases := map[string][]int{
+		SelectionStrategyPrice:          {1, 2, 3},
+		SelectionStrategyEffectiveAdmin: {2, 1, 3},
+		SelectionStrategyPriority:       {2, 1, 3},
+		"":                              {1, 2, 3},
+	}
+	for strategy, want := range cases {
+		options := append([]models.ProviderOption(nil), base...)
+		sortOptions(options, strategy)
+		for i, id := range want {
+			if options[i].ProviderID != id {
+				t.Fatalf("%q position %d: got provider %d, want %d", strategy, i, options[i].ProviderID, id)
+			}
+		}
+	}
+}
+
+func TestValidSelectionStrategy(t *testing.T) {
+	if !ValidSelectionStrategy(SelectionStrategyPriority) || ValidSelectionStrategy("fastest") {
+		t.Fatal("unexpected ValidSelectionStrategy result")
+	}
+}
+
+func TestApplyCommissionPercentReordersByEffectiveAdmin(t *testing.T) {
+	pct := func(v float64) *float64 { return &v }
+	// SQL order ranked on the stored commission (kiosbank 2500-100, alterra 2750-0).
+	options := []models.ProviderOption{
+		{ProviderID: 1, Admin: 2500, Commission: 100},
+		{ProviderID: 2, Admin: 2750, Commission: 0, CommissionPercent: pct(12.5)},
+		{ProviderID: 3, Admin: 1000, CommissionPercent: pct(50), IsBackup: true},
+	}
+
+	if !applyCommissionPercent(options) {
+		t.Fatal("expected percentage commission to be applied")
+	}
+	sortOptions(options, SelectionStrategyEffectiveAdmin)
+
+	// alterra: 12.5% of 2750 = 343.75 -> 344, effective 2406 still behind kiosbank's 2400.
+	want := []int{1, 2, 3}
+	for i, id := range want {
+		if options[i].ProviderID != id {
+			t.Fatalf("position %d: got provider %d, want %d (order %+v)", i, options[i].ProviderID, id, options)
+		}
+	}
+	if options[1].Commission != 344 || options[2].Commission != 500 {
+		t.Fatalf("commissions = %d, %d, want 344, 500", options[1].Commission, options[2].Commission)
+	}
+
+	// A slightly higher rate tips alterra below kiosbank.
+	options[1].CommissionPercent = pct(12.75)
+	applyCommissionPercent(options)
+	sortOptions(options, SelectionStrategyEffectiveAdmin)
+	if options[0].ProviderID != 2 || options[0].EffectiveAdmin() != 2399 {
+		t.Fatalf("got %+v first, want alterra with effective admin 2399", options[0])
+	}
+}
+
+func TestPercentCommissionRounding(t *testing.T) {
+	cases := []struct {
+		admin   int
+		percent float64
+		want    int
+	}{
+		{2500, 1.1, 28},  // 27.5 rounds half up
+		{5000, 0.29, 15}, // 14.5; naive float math gives 14.4999...
+		{3000, 0, 0},
+		{2750, 100, 2750},
+	}
+	for _, tc := range cases {
+		if got := models.PercentCommission(tc.admin, tc.percent); got != tc.want {
+			t.Errorf("PercentCommission(%d, %v) = %d, want %d", tc.admin, tc.percent, got, tc.want)
+		}
+	}
+}
+
+// TestCountProviderSKUs needs TEST_DATABASE_URL (see testDB). It reads
+// whatever provider SKUs the database holds.
+func TestCountProviderSKUs(t *testing.T) {
+	r := NewPPOBProviderRepository(testDB(t))
+
+	cases := []struct {
+		name       string
+		providerID int
+		search     string
+	}{
+		{"all", 0, ""},
+		{"one provider", 1, ""},
+		{"search", 0, "pln"},
+		{"no match", 0, "no such product"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, want, err := r.GetAllProviderSKUsPaged(tc.providerID, tc.search, 1, 1)
+			if err != nil {
+				t.Fatalf("GetAllProviderSKUsPaged: %v", err)
+			}
+			got, err := r.CountProviderSKUs(tc.providerID, tc.search)
+			if err != nil {
+				t.Fatalf("CountProviderSKUs: %v", err)
+			}
+			if got != want {
+				t.Fatalf("CountProviderSKUs = %d, paged total = %d", got, want)
+			}
+		})
+	}
+}
+
+func TestDeriveSKUCodesSkipsCollisions(t *testing.T) {
+	rule := models.DerivedSKUConfig{Template: "{skuSuffix}"}
+	products := []derivedSKUProduct{
+		{ID: 1, SkuCode: "ALT-EDU-9022"},
+		{ID: 2, SkuCode: "ALT-EDU-9023"},
+		{ID: 3, SkuCode: "ALT-GAME-9023"},
+		{ID: 4, SkuCode: "ALT-EDU-1000"},
+	}
+	codes, collisions := deriveSKUCodes(rule, products, []string{"1000"})
+
+	if len(codes) != 1 || codes[1] != "9022" {
+		t.Fatalf("codes = %v, want only product 1 -> 9022", codes)
+	}
+	want := []DerivedSKUCollision{
+		{SkuCode: "ALT-EDU-9023", ProviderSKUCode: "9023"},
+		{SkuCode: "ALT-GAME-9023", ProviderSKUCode: "9023"},
+		{SkuCode: "ALT-EDU-1000", ProviderSKUCode: "1000"},
+	}
+	if len(collisions) != len(want) {
+		t.Fatalf("collisions = %+v, want %+v", collisions, want)
+	}
+	for i := range want {
+		if collisions[i] != want[i] {
+			t.Fatalf("collision %d = %+v, want %+v", i, collisions[i], want[i])
+		}
+	}
+}
@@ -43,10 +43,43 @@ func (r *ProductRepository) GetAll(productType, category string) ([]models.Produ
 	return products, nil
 }
 
+// availableNowFilter keeps products (aliased p) that can be transacted right
+// now in WIB: an active SKU that is not auto-disabled and outside its cut-off
+// window (SKURepository.GetAvailableSKUs), or an available provider SKU
+// routing would use (PPOBProviderRepository.GetProvidersForProduct, or
+// GetProvidersForProductPostpaid, which takes a zero price, for postpaid).
+const availableNowFilter = `
+        AND (
+            EXISTS (
+                SELECT 1 FROM skus s
+                WHERE s.product_id = p.id
+                  AND s.is_active = true
+                  AND (s.disabled_until IS NULL OR s.disabled_until <= NOW())
+                  AND (
+                      (s.cut_off_start = '00:00:00' AND s.cut_off_end = '00:00:00')
+                      OR (s.cut_off_start < s.cut_off_end
+                          AND NOT ((NOW() AT TIME ZONE 'Asia/Jakarta')::time BETWEEN s.cut_off_start AND s.cut_off_end))
+                      OR (s.cut_off_start > s.cut_off_end
+                          AND NOT ((NOW() AT TIME ZONE 'Asia/Jakarta')::time >= s.cut_off_start
+                              OR (NOW() AT TIME ZONE 'Asia/Jakarta')::time <= s.cut_off_end))
+                  )
+            )
+            OR EXISTS (
+                SELECT 1 FROM ppob_provider_skus ps
+                JOIN ppob_providers pr ON ps.provider_id = pr.id
+                WHERE ps.product_id = p.id
+                  AND ps.is_active = true
+                  AND ps.is_available = true
+                  AND pr.is_active = true
+                  AND (p.type = 'postpaid' OR ps.price > 0)` + notInMaintenance + outsideCutOff + `
+            )
+        )`
+
 // GetAllPaged returns active products with filters and pagination and also returns total count.
-// Filters: productType (prepaid/postpaid), category, brand (exact), search (ILIKE on name).
+// Filters: productType (prepaid/postpaid), category, brand (exact), search (ILIKE on name),
+// availableNow (only products with an SKU or provider available right now).
 // If a filter is empty it will be ignored. Page begins at 1.
-func (r *ProductRepository) GetAllPaged(productType, category, brand, search string, page, limit int) ([]models.Product, int, error) {
+func (r *ProductRepository) GetAllPaged(productType, category, brand, search string, availableNow bool, page, limit int) ([]models.Product, int, error) {
 	if page <= 0 {
 		page = 1
 	}
@@ -56,22 +89,25 @@ func (r *ProductRepository) GetAllPaged(productType, category, brand, search str
 	offset := (page - 1) * limit
 
 	// Base WHERE clause
-	const baseWhere = `WHERE ($1 = '' OR type::text = $1)
-        AND ($2 = '' OR category = $2)
-        AND ($3 = '' OR brand = $3)
-        AND ($4 = '' OR name ILIKE '%%' || $4 || '%%')
-        AND is_active = true`
+	baseWhere := `WHERE ($1 = '' OR p.type::text = $1)
+        AND ($2 = '' OR p.category = $2)
+        AND ($3 = '' OR p.brand = $3)
+        AND ($4 = '' OR p.name ILIKE '%%' || $4 || '%%')
+        AND p.is_active = true`
+	if availableNow {
+		baseWhere += availableNowFilter
+	}
 
 	// Count total
-	countQuery := `SELECT COUNT(1) FROM products ` + baseWhere
+	countQuery := `SELECT COUNT(1) FROM products p ` + baseWhere
 	var total int
 	if err := r.db.Get(&total, countQuery, productType, category, brand, search); err != nil {
 		return nil, 0, err
 	}
 
 	// Fetch page
-	listQuery := `SELECT * FROM products ` + baseWhere + `
-        ORDER BY category, brand, name LIMIT $5 OFFSET $6`
+	listQuery := `SELECT p.* FROM products p ` + baseWhere + `
+        ORDER BY p.category, p.brand, p.name LIMIT $5 OFFSET $6`
 	var products []models.Product
 	if err := r.db.Select(&products, listQuery, productType, category, brand, search, limit, offset); err != nil {
 		return nil, 0, err
@@ -169,8 +205,9 @@ func (r *ProductRepository) UpdateStatus(id int, isActive bool) error {
 
 // Create creates a new product.
 func (r *ProductRepository) Create(product *models.Product) error {
-	query := `INSERT INTO products (sku_code, name, category, brand, type, variant_id, admin, commission, description, is_active)
-              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	query := `INSERT INTO products (sku_code, name, category, brand, type, variant_id, admin, commission, description, is_active,
+                  customer_no_min_length, customer_no_max_length, customer_no_pattern)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
               RETURNING id, created_at, updated_at`
 
 	return r.db.QueryRowx(query,
@@ -184,6 +221,9 @@ func (r *ProductRepository) Create(product *models.Product) error {
 		product.Commission,
 		product.Description,
 		product.IsActive,
+		product.CustomerNoMinLength,
+		product.CustomerNoMaxLength,
+		product.CustomerNoPattern,
 	).Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt)
 }
 
@@ -191,8 +231,9 @@ func (r *ProductRepository) Create(product *models.Product) error {
 func (r *ProductRepository) Update(product *models.Product) error {
 	query := `UPDATE products
               SET sku_code = $1, name = $2, category = $3, brand = $4,
-                  type = $5, variant_id = $6, admin = $7, commission = $8, description = $9, is_active = $10
-              WHERE id = $11
+                  type = $5, variant_id = $6, admin = $7, commission = $8, description = $9, is_active = $10,
+                  customer_no_min_length = $11, customer_no_max_length = $12, customer_no_pattern = $13
+              WHERE id = $14
               RETURNING updated_at`
 
 	return r.db.QueryRowx(query,
@@ -206,10 +247,54 @@ func (r *ProductRepository) Update(product *models.Product) error {
 		product.Commission,
 		product.Description,
 		product.IsActive,
+		product.CustomerNoMinLength,
+		product.CustomerNoMaxLength,
+		product.CustomerNoPattern,
 		product.ID,
 	).Scan(&product.UpdatedAt)
 }
 
+// UpdateCustomerNoRules sets the customer number validation rules of a product.
+func (r *ProductRepository) UpdateCustomerNoRules(id int, minLength, maxLength *int, pattern *string) error {
+	const q = `UPDATE products
+               SET customer_no_min_length = $2, customer_no_max_length = $3, customer_no_pattern = $4, updated_at = NOW()
+               WHERE id = $1`
+	res, err := r.db.Exec(q, id, minLength, maxLength, pattern)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateSelectionStrategy sets (or clears, with nil) the product's provider ordering override.
+func (r *ProductRepository) UpdateSelectionStrategy(id int, strategy *string) error {
+	const q = `UPDATE products SET selection_strategy = $2, updated_at = NOW() WHERE id = $1`
+	res, err := r.db.Exec(q, id, strategy)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateHoldDuplicateSerial turns the duplicate serial number review hold of a product on or off.
+func (r *ProductRepository) UpdateHoldDuplicateSerial(id int, enabled bool) error {
+	const q = `UPDATE products SET hold_duplicate_serial = $2, updated_at = NOW() WHERE id = $1`
+	res, err := r.db.Exec(q, id, enabled)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 // Delete deletes a product by ID.
 func (r *ProductRepository) Delete(id int) error {
 	query := `DELETE FROM products WHERE id = $1`
@@ -378,3 +463,38 @@ func (r *ProductRepository) GetDistinctBrands(category, productType string) ([]s
 	}
 	return brands, nil
 }
+
+// ProductGroupCount is a category or brand with its number of active products.
+type ProductGroupCount struct {
+	Name         string `db:"name" json:"name"`
+	ProductCount int    `db:"product_count" json:"productCount"`
+}
+
+// CountActiveByCategory returns the categories of active products with their
+// product counts, optionally filtered by type.
+func (r *ProductRepository) CountActiveByCategory(productType string) ([]ProductGroupCount, error) {
+	const q = `SELECT category AS name, COUNT(1) AS product_count FROM products
+        WHERE is_active = true AND category != ''
+          AND ($1 = '' OR type::text = $1)
+        GROUP BY category ORDER BY category`
+	groups := []ProductGroupCount{}
+	if err := r.db.Select(&groups, q, productType); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// CountActiveByBrand returns the brands of active products with their product
+// counts, optionally filtered by type and category.
+func (r *ProductRepository) CountActiveByBrand(productType, category string) ([]ProductGroupCount, error) {
+	const q = `SELECT brand AS name, COUNT(1) AS product_count FROM products
+        WHERE is_active = true AND brand != ''
+          AND ($1 = '' OR type::text = $1)
+          AND ($2 = '' OR category = $2)
+        GROUP BY brand ORDER BY brand`
+	groups := []ProductGroupCount{}
+	if err := r.db.Select(&groups, q, productType, category); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestCountActiveByCategoryAndBrand needs TEST_DATABASE_URL (see testDB). It
+// checks each group count against the product list filtered the same way.
+func TestCountActiveByCategoryAndBrand(t *testing.T) {
+	r := NewProductRepository(testDB(t))
+
+	for _, productType := range []string{"", "prepaid", "postpaid"} {
+		t.Run("type="+productType, func(t *testing.T) {
+			categories, err := r.CountActiveByCategory(productType)
+			if err != nil {
+				t.Fatalf("CountActiveByCategory: %v", err)
+			}
+			for _, c := range categories {
+				_, total, err := r.GetAllPaged(productType, c.Name, "", "", false, 1, 1)
+				if err != nil {
+					t.Fatalf("GetAllPaged: %v", err)
+				}
+				if c.ProductCount != total {
+					t.Errorf("category %q count = %d, listed %d", c.Name, c.ProductCount, total)
+				}
+
+				brands, err := r.CountActiveByBrand(productType, c.Name)
+				if err != nil {
+					t.Fatalf("CountActiveByBrand: %v", err)
+				}
+				for _, b := range brands {
+					_, total, err := r.GetAllPaged(productType, c.Name, b.Name, "", false, 1, 1)
+					if err != nil {
+						t.Fatalf("GetAllPaged: %v", err)
+					}
+					if b.ProductCount != total {
+						t.Errorf("brand %q in %q count = %d, listed %d", b.Name, c.Name, b.ProductCount, total)
+					}
+				}
+			}
+		})
+	}
+
+	none, err := r.CountActiveByBrand("", "no such category")
+	if err != nil || none == nil || len(none) != 0 {
+		t.Fatalf("unknown category = %v, %v; want an empty list", none, err)
+	}
+}
+
+// TestGetAllPagedAvailableNow needs TEST_DATABASE_URL (see testDB). A product
+// is available now exactly when it has an available SKU or a provider to
+// route to, the checks availableNowFilter mirrors. It adds a postpaid
+// product whose only route is a zero-priced provider SKU, as postpaid
+// provider SKUs carry just an admin fee.
+func TestGetAllPagedAvailableNow(t *testing.T) {
+	db := testDB(t)
+	r := NewProductRepository(db)
+	skus := NewSKURepository(db)
+	providers := NewPPOBProviderRepository(db)
+
+	code := fmt.Sprintf("T%d", time.Now().UnixNano()%1e9)
+	var providerID, productID int
+	if err := db.QueryRow(`INSERT INTO ppob_providers (code, name) VALUES ($1, $1) RETURNING id`, code).Scan(&providerID); err != nil {
+		t.Fatalf("insert provider: %v", err)
+	}
+	t.Cleanup(func() { _, _ = db.Exec(`DELETE FROM ppob_providers WHERE id = $1`, providerID) })
+	if err := db.QueryRow(`
+		INSERT INTO products (sku_code, name, category, brand, type)
+		VALUES ($1, $1, 'Test', 'Test', 'postpaid') RETURNING id`, code).Scan(&productID); err != nil {
+		t.Fatalf("insert product: %v", err)
+	}
+	t.Cleanup(func() { _, _ = db.Exec(`DELETE FROM products WHERE id = $1`, productID) })
+	if _, err := db.Exec(`
+		INSERT INTO ppob_provider_skus (provider_id, product_id, provider_sku_code, price, admin)
+		VALUES ($1, $2, $3, 0, 2500)`, providerID, productID, code); err != nil {
+		t.Fatalf("insert provider sku: %v", err)
+	}
+
+	for _, productType := range []string{"prepaid", "postpaid"} {
+		t.Run(productType, func(t *testing.T) {
+			all, _, err := r.GetAllPaged(productType, "", "", "", false, 1, 10000)
+			if err != nil {
+				t.Fatalf("GetAllPaged: %v", err)
+			}
+			available, total, err := r.GetAllPaged(productType, "", "", "", true, 1, 10000)
+			if err != nil {
+				t.Fatalf("GetAllPaged available now: %v", err)
+			}
+			if total != len(available) {
+				t.Fatalf("total = %d, listed %d", total, len(available))
+			}
+			got := make(map[int]bool, len(available))
+			for _, p := range available {
+				got[p.ID] = true
+			}
+			if productType == "postpaid" && !got[productID] {
+				t.Errorf("postpaid product with a zero-priced provider SKU is not available now")
+			}
+
+			routesFor := providers.GetProvidersForProduct
+			if productType == "postpaid" {
+				routesFor = providers.GetProvidersForProductPostpaid
+			}
+			now := time.Now().In(time.FixedZone("WIB", 7*3600)).Format("15:04:05")
+			for _, p := range all {
+				ready, err := skus.GetAvailableSKUs(p.ID, now)
+				if err != nil {
+					t.Fatalf("GetAvailableSKUs: %v", err)
+				}
+				routes, err := routesFor(p.ID)
+				if err != nil {
+					t.Fatalf("list provider routes: %v", err)
+				}
+				if want := len(ready) > 0 || len(routes) > 0; got[p.ID] != want {
+					t.Errorf("product %s available now = %v, want %v", p.SkuCode, got[p.ID], want)
+				}
+			}
+		})
+	}
+}
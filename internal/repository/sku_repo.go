@@ -2,8 +2,10 @@ package repository
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
 	"github.com/GTDGit/gtd_api/internal/models"
 )
@@ -41,6 +43,7 @@ func (r *SKURepository) GetAvailableSKUs(productID int, currentTime string) ([]m
         SELECT * FROM skus
         WHERE product_id = $1
           AND is_active = true
+          AND (disabled_until IS NULL OR disabled_until <= NOW())
           AND (
               (cut_off_start = '00:00:00' AND cut_off_end = '00:00:00')
               OR
@@ -152,6 +155,13 @@ func (r *SKURepository) UpdateStatus(id int, isActive bool) error {
 	return err
 }
 
+// DisableUntil takes a SKU out of GetAvailableSKUs until the given time.
+func (r *SKURepository) DisableUntil(id int, until time.Time) error {
+	const q = `UPDATE skus SET disabled_until = $2, updated_at = NOW() WHERE id = $1`
+	_, err := r.db.Exec(q, id, until)
+	return err
+}
+
 // GetMainSKUPrice returns price of priority=1 active SKU for a product.
 func (r *SKURepository) GetMainSKUPrice(productID int) (int, error) {
 	const q = `SELECT price FROM skus WHERE product_id = $1 AND priority = 1 AND is_active = true LIMIT 1`
@@ -221,3 +231,50 @@ func (r *SKURepository) Delete(id int) error {
 	_, err := r.db.Exec(query, id)
 	return err
 }
+
+// ListRCOverrides returns the RC overrides configured for a SKU.
+func (r *SKURepository) ListRCOverrides(skuID int) ([]models.SKURCOverride, error) {
+	const q = `SELECT * FROM sku_rc_overrides WHERE sku_id = $1 ORDER BY rc ASC`
+	var overrides []models.SKURCOverride
+	if err := r.db.Select(&overrides, q, skuID); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// ListRCOverridesForSKUs returns the RC overrides configured for any of
+// skuIDs, in one query.
+func (r *SKURepository) ListRCOverridesForSKUs(skuIDs []int) ([]models.SKURCOverride, error) {
+	const q = `SELECT * FROM sku_rc_overrides WHERE sku_id = ANY($1)`
+	var overrides []models.SKURCOverride
+	if err := r.db.Select(&overrides, q, pq.Array(skuIDs)); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// UpsertRCOverride creates or replaces the override for (sku_id, rc).
+func (r *SKURepository) UpsertRCOverride(o *models.SKURCOverride) error {
+	const q = `
+		INSERT INTO sku_rc_overrides (sku_id, rc, outcome, note)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (sku_id, rc) DO UPDATE SET
+			outcome = EXCLUDED.outcome,
+			note = EXCLUDED.note,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at`
+	return r.db.QueryRowx(q, o.SkuID, o.RC, o.Outcome, o.Note).
+		Scan(&o.ID, &o.CreatedAt, &o.UpdatedAt)
+}
+
+// DeleteRCOverride removes the override for (skuID, rc).
+func (r *SKURepository) DeleteRCOverride(skuID int, rc string) error {
+	res, err := r.db.Exec(`DELETE FROM sku_rc_overrides WHERE sku_id = $1 AND rc = $2`, skuID, rc)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SystemFlagReadOnly is the system_flags row behind global read-only mode.
+const SystemFlagReadOnly = "read_only"
+
+// SystemFlag is one global operational switch.
+type SystemFlag struct {
+	Name      string    `db:"name" json:"name"`
+	Enabled   bool      `db:"enabled" json:"enabled"`
+	Reason    string    `db:"reason" json:"reason"`
+	UpdatedBy *string   `db:"updated_by" json:"updatedBy,omitempty"`
+	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+// SystemFlagRepository reads and writes system_flags.
+type SystemFlagRepository struct {
+	db *sqlx.DB
+}
+
+func NewSystemFlagRepository(db *sqlx.DB) *SystemFlagRepository {
+	return &SystemFlagRepository{db: db}
+}
+
+// Get returns the named flag; a missing row reads as disabled.
+func (r *SystemFlagRepository) Get(name string) (*SystemFlag, error) {
+	var f SystemFlag
+	err := r.db.Get(&f, `SELECT name, enabled, reason, updated_by, updated_at FROM system_flags WHERE name = $1`, name)
+	if err == sql.ErrNoRows {
+		return &SystemFlag{Name: name}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Set stores the named flag and returns it.
+func (r *SystemFlagRepository) Set(name string, enabled bool, reason, updatedBy string) (*SystemFlag, error) {
+	const q = `
+		INSERT INTO system_flags (name, enabled, reason, updated_by, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NOW())
+		ON CONFLICT (name) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			reason = EXCLUDED.reason,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+		RETURNING name, enabled, reason, updated_by, updated_at`
+	var f SystemFlag
+	if err := r.db.Get(&f, q, name, enabled, reason, updatedBy); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
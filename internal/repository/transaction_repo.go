@@ -9,20 +9,36 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
 	"github.com/GTDGit/gtd_api/internal/models"
 )
 
+// Transaction ID sequence modes for GenerateTransactionID.
+const (
+	TransactionIDSequenceCounter = "counter" // atomic per-prefix, per-day counter (default)
+	TransactionIDSequenceRandom  = "random"  // random 6 digits checked against existing IDs
+)
+
 // TransactionRepository handles data access for transactions.
 type TransactionRepository struct {
-	db *sqlx.DB
+	db         *sqlx.DB
+	idSequence string
 }
 
 // NewTransactionRepository creates a new TransactionRepository.
 func NewTransactionRepository(db *sqlx.DB) *TransactionRepository {
-	return &TransactionRepository{db: db}
+	return &TransactionRepository{db: db, idSequence: TransactionIDSequenceCounter}
+}
+
+// SetTransactionIDSequence selects how GenerateTransactionID numbers IDs.
+// Unknown values fall back to the counter.
+func (r *TransactionRepository) SetTransactionIDSequence(mode string) {
+	r.idSequence = mode
 }
 
+// transactionSelectWithProvider reads provider_code from the live join; it
+// comes after t.* so it wins over the denormalized t.provider_code column.
 const transactionSelectWithProvider = `
 	SELECT t.*, pp.code AS provider_code
 	FROM transactions t
@@ -46,7 +62,8 @@ func (r *TransactionRepository) Create(trx *models.Transaction) error {
             inquiry_id, digi_ref_id, buy_price, sell_price,
             provider_id, provider_sku_id, provider_ref_id, provider_initial_response,
             provider_response, provider_initial_http_status, provider_http_status,
-            created_at, processed_at
+            created_at, processed_at, customer_no_hash, provider_code, priority,
+            provider_error
         ) VALUES (
             $1,$2,$3,$4,$5,$6,
             $7,$8,$9,$10,$11,$12,$13,
@@ -54,7 +71,8 @@ func (r *TransactionRepository) Create(trx *models.Transaction) error {
             $20,$21,$22,$23,
             $24,$25,$26,$27,
             $28,$29,$30,
-            NOW(),$31
+            NOW(),$31,$32,(SELECT code FROM ppob_providers WHERE id = $24),$33,
+            $34
         ) RETURNING id, created_at, updated_at`
 
 	return r.db.QueryRow(q,
@@ -63,7 +81,8 @@ func (r *TransactionRepository) Create(trx *models.Transaction) error {
 		trx.Period, nullableJSON(trx.Description), trx.FailedReason, trx.RetryCount, trx.NextRetryAt, trx.ExpiredAt,
 		trx.InquiryID, trx.DigiRefID, trx.BuyPrice, trx.SellPrice,
 		trx.ProviderID, trx.ProviderSKUID, trx.ProviderRefID, nullableJSON(trx.ProviderInitialResponse),
-		nullableJSON(trx.ProviderResponse), trx.ProviderInitialHTTPStatus, trx.ProviderHTTPStatus, trx.ProcessedAt,
+		nullableJSON(trx.ProviderResponse), trx.ProviderInitialHTTPStatus, trx.ProviderHTTPStatus, trx.ProcessedAt, trx.CustomerHash,
+		trx.Priority, trx.ProviderError,
 	).Scan(&trx.ID, &trx.CreatedAt, &trx.UpdatedAt)
 }
 
@@ -97,6 +116,8 @@ func (r *TransactionRepository) Update(trx *models.Transaction) error {
             provider_response = $25,
             provider_initial_http_status = $26,
             provider_http_status = $27,
+            provider_error = $28,
+            provider_code = (SELECT code FROM ppob_providers WHERE id = $21),
             updated_at = NOW()
         WHERE transaction_id = $1`
 
@@ -134,6 +155,7 @@ func (r *TransactionRepository) Update(trx *models.Transaction) error {
 		nullableJSON(trx.ProviderResponse),
 		trx.ProviderInitialHTTPStatus,
 		trx.ProviderHTTPStatus,
+		trx.ProviderError,
 	)
 	return err
 }
@@ -210,14 +232,14 @@ func (r *TransactionRepository) GetByDigiRefID(digiRefID string) (*models.Transa
 	return &t, nil
 }
 
-// GetAllPendingTransactions returns all transactions in Pending status.
-// With the new logic, transactions should not stay in Pending state,
+// GetAllPendingTransactions returns all transactions in Pending status,
+// except those held for review. With the new logic, transactions should not stay in Pending state,
 // so this is used for cleanup purposes.
 func (r *TransactionRepository) GetAllPendingTransactions() ([]models.Transaction, error) {
 	const q = `
         SELECT * FROM transactions
-        WHERE status = 'Pending'
-        ORDER BY created_at ASC
+        WHERE status = 'Pending' AND review_hold_at IS NULL
+        ORDER BY priority DESC, created_at ASC
         FOR UPDATE SKIP LOCKED`
 
 	stmt, err := r.db.Preparex(q)
@@ -232,9 +254,36 @@ func (r *TransactionRepository) GetAllPendingTransactions() ([]models.Transactio
 	return list, nil
 }
 
+// GetStaleProcessingTransactionsAfter pages through the Processing
+// transactions GetStaleProcessingTransactions would return, by id: up to
+// limit of them with an id above afterID. Unlike the worker query it never
+// returns a row twice, so rows a provider keeps pending do not hide the rest.
+func (r *TransactionRepository) GetStaleProcessingTransactionsAfter(staleAfter time.Duration, afterID, limit int) ([]models.Transaction, error) {
+	const q = `
+        SELECT t.*, pp.code AS provider_code
+        FROM transactions t
+        LEFT JOIN ppob_providers pp ON t.provider_id = pp.id
+        WHERE t.status = 'Processing'
+          AND t.created_at < NOW() - $1::interval
+          AND (
+            (t.type = 'prepaid' AND t.digi_ref_id IS NOT NULL)
+            OR (t.provider_id IS NOT NULL AND t.provider_ref_id IS NOT NULL)
+          )
+          AND t.id > $2
+        ORDER BY t.id
+        LIMIT $3`
+
+	var list []models.Transaction
+	if err := r.db.Select(&list, q, fmt.Sprintf("%d seconds", int(staleAfter.Seconds())), afterID, limit); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
 // GetStaleProcessingTransactions returns Processing transactions older than the given duration.
 // Finds both legacy Digiflazz transactions and multi-provider transactions.
-// Used to re-check status by calling the appropriate provider.
+// Used to re-check status by calling the appropriate provider, highest
+// priority first, then oldest first.
 func (r *TransactionRepository) GetStaleProcessingTransactions(staleAfter time.Duration) ([]models.Transaction, error) {
 	const q = `
         SELECT t.*, pp.code AS provider_code
@@ -246,7 +295,7 @@ func (r *TransactionRepository) GetStaleProcessingTransactions(staleAfter time.D
             (t.type = 'prepaid' AND t.digi_ref_id IS NOT NULL)
             OR (t.provider_id IS NOT NULL AND t.provider_ref_id IS NOT NULL)
           )
-        ORDER BY t.created_at ASC
+        ORDER BY t.priority DESC, t.created_at ASC
         LIMIT 50
         FOR UPDATE OF t SKIP LOCKED`
 
@@ -266,6 +315,80 @@ func (r *TransactionRepository) GetStaleProcessingTransactions(staleAfter time.D
 	return list, nil
 }
 
+// GetDueProviderContinuations returns Processing prepaid transactions whose
+// remaining providers are due to be tried asynchronously (next_retry_at set
+// when the synchronous provider attempt limit was reached), highest priority
+// first.
+func (r *TransactionRepository) GetDueProviderContinuations(limit int) ([]models.Transaction, error) {
+	const q = `
+        SELECT t.*, pp.code AS provider_code
+        FROM transactions t
+        LEFT JOIN ppob_providers pp ON t.provider_id = pp.id
+        WHERE t.status = 'Processing'
+          AND t.type = 'prepaid'
+          AND t.next_retry_at <= NOW()
+        ORDER BY t.priority DESC, t.next_retry_at ASC
+        LIMIT $1`
+
+	var list []models.Transaction
+	if err := r.db.Select(&list, q, limit); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ClaimProviderContinuation pushes next_retry_at out by lease so only one
+// worker continues the transaction; a crashed run is picked up again once the
+// lease expires. It reports false when another worker claimed it first.
+func (r *TransactionRepository) ClaimProviderContinuation(id int, lease time.Duration) (bool, error) {
+	const q = `
+        UPDATE transactions
+        SET next_retry_at = NOW() + $2::interval, updated_at = NOW()
+        WHERE id = $1 AND status = 'Processing' AND next_retry_at <= NOW()`
+
+	res, err := r.db.Exec(q, id, fmt.Sprintf("%d seconds", int(lease.Seconds())))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// BackfillProviderCode copies ppob_providers.code onto the next batchSize
+// transactions after afterID that have provider_id but no provider_code yet.
+// It returns the last id of the batch, 0 once no such rows are left, and how
+// many rows were filled. Rows whose provider no longer exists are skipped, so
+// a batch can fill fewer rows than it holds, even none.
+func (r *TransactionRepository) BackfillProviderCode(afterID, batchSize int) (int, int64, error) {
+	const q = `
+        WITH batch AS (
+            SELECT id FROM transactions
+            WHERE id > $1 AND provider_id IS NOT NULL AND provider_code IS NULL
+            ORDER BY id
+            LIMIT $2
+        ), filled AS (
+            UPDATE transactions t
+            SET provider_code = pp.code
+            FROM batch b, ppob_providers pp
+            WHERE t.id = b.id AND pp.id = t.provider_id
+            RETURNING t.id
+        )
+        SELECT COALESCE((SELECT MAX(id) FROM batch), 0) AS last_id,
+               (SELECT COUNT(1) FROM filled) AS filled`
+
+	var res struct {
+		LastID int   `db:"last_id"`
+		Filled int64 `db:"filled"`
+	}
+	if err := r.db.Get(&res, q, afterID, batchSize); err != nil {
+		return 0, 0, err
+	}
+	return res.LastID, res.Filled, nil
+}
+
 // ExistsReferenceID checks if a client has already used a reference_id.
 func (r *TransactionRepository) ExistsReferenceID(clientID int, referenceID string) (bool, error) {
 	const q = `SELECT EXISTS(SELECT 1 FROM transactions WHERE client_id = $1 AND reference_id = $2)`
@@ -281,8 +404,83 @@ func (r *TransactionRepository) ExistsReferenceID(clientID int, referenceID stri
 	return exists, nil
 }
 
+// FindLikelyDuplicate returns the transaction_id of the latest transaction
+// of trx's client, type, product, customer number (its hash when trx has
+// one) and sell price created since then under another reference_id and not
+// failed, or "" when none.
+func (r *TransactionRepository) FindLikelyDuplicate(trx *models.Transaction, since time.Time) (string, error) {
+	// Customer numbers of hashing clients are masked once final, so their
+	// transactions are matched on the hash.
+	customerCol, customer := "customer_no", trx.CustomerNo
+	if trx.CustomerHash != nil && *trx.CustomerHash != "" {
+		customerCol, customer = "customer_no_hash", *trx.CustomerHash
+	}
+	q := `
+		SELECT transaction_id FROM transactions
+		WHERE client_id = $1 AND ` + customerCol + ` = $2 AND created_at >= $3
+		  AND type = $4 AND product_id = $5 AND is_sandbox = $6
+		  AND sell_price IS NOT DISTINCT FROM $7
+		  AND reference_id <> $8 AND status <> $9
+		ORDER BY created_at DESC LIMIT 1`
+	var transactionID string
+	err := r.db.Get(&transactionID, q, trx.ClientID, customer, since,
+		trx.Type, trx.ProductID, trx.IsSandbox, trx.SellPrice, trx.ReferenceID, models.StatusFailed)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return transactionID, err
+}
+
 // GenerateTransactionID returns an ID like GRB-YYYYMMDD-NNNNNN using Asia/Jakarta date.
-func (r *TransactionRepository) GenerateTransactionID() (string, error) {
+// prefix replaces GRB; empty falls back to models.DefaultTransactionIDPrefix.
+func (r *TransactionRepository) GenerateTransactionID(prefix string) (string, error) {
+	if prefix == "" {
+		prefix = models.DefaultTransactionIDPrefix
+	}
+	if r.idSequence == TransactionIDSequenceRandom {
+		return r.randomTransactionID(prefix)
+	}
+	return r.counterTransactionID(prefix)
+}
+
+// counterTransactionID takes the next value of the prefix's counter for today
+// in one atomic statement, so concurrent callers always get distinct numbers.
+// IDs already taken (random ones issued before the switch) are skipped.
+func (r *TransactionRepository) counterTransactionID(prefix string) (string, error) {
+	const nextQ = `
+        INSERT INTO transaction_id_counters (prefix, day, last_value)
+        VALUES ($1, TO_CHAR(NOW() AT TIME ZONE 'Asia/Jakarta', 'YYYYMMDD'), 1)
+        ON CONFLICT (prefix, day) DO UPDATE
+            SET last_value = transaction_id_counters.last_value + 1, updated_at = NOW()
+        RETURNING day, last_value`
+	const existsQ = `SELECT EXISTS(SELECT 1 FROM transactions WHERE transaction_id = $1)`
+
+	for attempt := 0; attempt < 10; attempt++ {
+		var next struct {
+			Day   string `db:"day"`
+			Value int    `db:"last_value"`
+		}
+		if err := r.db.Get(&next, nextQ, prefix); err != nil {
+			return "", err
+		}
+
+		candidate := formatTransactionID(prefix, next.Day, next.Value)
+		var exists bool
+		if err := r.db.Get(&exists, existsQ, candidate); err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+
+	return "", errors.New("failed to generate unique transaction id")
+}
+
+// randomTransactionID picks random numbers until one is unused. Two callers
+// can still pick the same number concurrently; the unique constraint on
+// transaction_id is the last line of defence.
+func (r *TransactionRepository) randomTransactionID(prefix string) (string, error) {
 	// Get date string in Asia/Jakarta from DB to avoid TZ mismatches.
 	const dateQ = `SELECT TO_CHAR(NOW() AT TIME ZONE 'Asia/Jakarta', 'YYYYMMDD')`
 	var ymd string
@@ -297,7 +495,7 @@ func (r *TransactionRepository) GenerateTransactionID() (string, error) {
 			return "", err
 		}
 
-		candidate := fmt.Sprintf("GRB-%s-%06d", ymd, n.Int64())
+		candidate := formatTransactionID(prefix, ymd, int(n.Int64()))
 		var exists bool
 		if err := r.db.Get(&exists, existsQ, candidate); err != nil {
 			return "", err
@@ -310,6 +508,12 @@ func (r *TransactionRepository) GenerateTransactionID() (string, error) {
 	return "", errors.New("failed to generate unique transaction id")
 }
 
+// formatTransactionID renders PREFIX-YYYYMMDD-NNNNNN; sequences past 999999
+// simply grow a digit.
+func formatTransactionID(prefix, ymd string, seq int) string {
+	return fmt.Sprintf("%s-%s-%06d", prefix, ymd, seq)
+}
+
 // GetInquiryForPayment returns the inquiry transaction eligible for linking to a payment.
 // Validations: type='inquiry', status='Success', expired_at > NOW(), and not already used by a payment.
 func (r *TransactionRepository) GetInquiryForPayment(transactionID string) (*models.Transaction, error) {
@@ -364,6 +568,9 @@ type AdminTransactionFilter struct {
 	IsSandbox     *bool
 	Page          int
 	Limit         int
+	// CountOnly returns just the totals, without selecting any rows, for
+	// count badges that poll frequently.
+	CountOnly bool
 }
 
 // AdminTransactionResult contains paginated transaction results.
@@ -459,6 +666,14 @@ func (r *TransactionRepository) GetAllAdmin(filter *AdminTransactionFilter) (*Ad
 	}
 	offset := (filter.Page - 1) * filter.Limit
 	totalPages := (total + filter.Limit - 1) / filter.Limit
+	if filter.CountOnly {
+		return &AdminTransactionResult{
+			TotalItems: total,
+			TotalPages: totalPages,
+			Page:       filter.Page,
+			Limit:      filter.Limit,
+		}, nil
+	}
 
 	// Select with pagination - include product sku_code, digi_sku_code, and provider info
 	selectQ := fmt.Sprintf(`
@@ -466,11 +681,11 @@ func (r *TransactionRepository) GetAllAdmin(filter *AdminTransactionFilter) (*Ad
 			t.id, t.transaction_id, t.reference_id, t.client_id, t.product_id, t.sku_id,
 			t.is_sandbox, t.customer_no, t.customer_name, t.type, t.status,
 			t.serial_number, t.amount, t.admin, t.period, t.description,
-			t.failed_reason, t.failed_code, t.retry_count, t.max_retry,
+			t.failed_reason, t.failed_code, t.provider_error, t.retry_count, t.max_retry,
 			t.next_retry_at, t.expired_at, t.inquiry_id, t.digi_ref_id,
 			t.buy_price, t.sell_price,
 			t.provider_id, t.provider_ref_id,
-			pp.code AS provider_code,
+			COALESCE(pp.code, t.provider_code) AS provider_code,
 			t.callback_sent, t.callback_sent_at, t.created_at, t.processed_at, t.updated_at,
 			p.sku_code AS product_sku_code,
 			s.digi_sku_code AS digi_sku_code
@@ -524,6 +739,7 @@ type transactionWithJoins struct {
 	Description    []byte                   `db:"description"`
 	FailedReason   *string                  `db:"failed_reason"`
 	FailedCode     *string                  `db:"failed_code"`
+	ProviderError  *models.ProviderError    `db:"provider_error"`
 	RetryCount     int                      `db:"retry_count"`
 	MaxRetry       int                      `db:"max_retry"`
 	NextRetryAt    *time.Time               `db:"next_retry_at"`
@@ -566,6 +782,7 @@ func (t *transactionWithJoins) toTransaction() models.Transaction {
 		Description:   t.Description,
 		FailedReason:  t.FailedReason,
 		FailedCode:    t.FailedCode,
+		ProviderError: t.ProviderError,
 		RetryCount:    t.RetryCount,
 		MaxRetry:      t.MaxRetry,
 		NextRetryAt:   t.NextRetryAt,
@@ -594,6 +811,8 @@ type AdminTransactionStats struct {
 	PendingTransactions    int   `db:"pending_transactions" json:"pendingTransactions"`
 	ProcessingTransactions int   `db:"processing_transactions" json:"processingTransactions"`
 	TotalAmount            int64 `db:"total_amount" json:"totalAmount"`
+	RefundedAmount         int64 `db:"refunded_amount" json:"refundedAmount"`
+	NetAmount              int64 `db:"net_amount" json:"netAmount"` // totalAmount - refundedAmount
 	// ByType breakdown
 	PrepaidCount int `db:"prepaid_count" json:"prepaidCount"`
 	InquiryCount int `db:"inquiry_count" json:"inquiryCount"`
@@ -618,10 +837,16 @@ func (r *TransactionRepository) GetAdminStats(clientID *int, startDate, endDate
             COUNT(*) FILTER (WHERE status = 'Pending') as pending_transactions,
             COUNT(*) FILTER (WHERE status = 'Processing') as processing_transactions,
             COALESCE(SUM(amount) FILTER (WHERE status = 'Success'), 0) as total_amount,
+            COALESCE(SUM(rf.refunded), 0) as refunded_amount,
+            COALESCE(SUM(amount) FILTER (WHERE status = 'Success'), 0) - COALESCE(SUM(rf.refunded), 0) as net_amount,
             COUNT(*) FILTER (WHERE type = 'prepaid') as prepaid_count,
             COUNT(*) FILTER (WHERE type = 'inquiry') as inquiry_count,
             COUNT(*) FILTER (WHERE type = 'payment') as payment_count
           FROM transactions
+          LEFT JOIN (
+              SELECT transaction_id, SUM(amount) AS refunded
+              FROM transaction_refunds GROUP BY transaction_id
+          ) rf ON rf.transaction_id = transactions.id
           WHERE 1=1`
 
 	args := []interface{}{}
@@ -691,32 +916,27 @@ func (r *TransactionRepository) GetDailyTrend(clientID *int, startDate, endDate
 
 // GetByIDAdmin returns a transaction by ID for admin (no client filtering).
 func (r *TransactionRepository) GetByIDAdmin(id int) (*models.Transaction, error) {
-	const q = `SELECT t.*, p.sku_code as "sku_code" 
-               FROM transactions t
-               JOIN products p ON t.product_id = p.id
-               WHERE t.id = $1 LIMIT 1`
-	var trx models.Transaction
-	if err := r.db.Get(&trx, q, id); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, sql.ErrNoRows
-		}
-		return nil, err
-	}
-	return &trx, nil
+	return r.getAdmin("t.id = $1", id)
 }
 
 // GetByTransactionIDAdmin returns a transaction by transaction_id for admin (no client filtering).
 func (r *TransactionRepository) GetByTransactionIDAdmin(transactionID string) (*models.Transaction, error) {
-	const q = `
+	return r.getAdmin("t.transaction_id = $1", transactionID)
+}
+
+// getAdmin loads one transaction with its product SKU, Digiflazz SKU and
+// provider code joined in.
+func (r *TransactionRepository) getAdmin(where string, arg any) (*models.Transaction, error) {
+	q := `
 		SELECT
 			t.id, t.transaction_id, t.reference_id, t.client_id, t.product_id, t.sku_id,
 			t.is_sandbox, t.customer_no, t.customer_name, t.type, t.status,
 			t.serial_number, t.amount, t.admin, t.period, t.description,
-			t.failed_reason, t.failed_code, t.retry_count, t.max_retry,
+			t.failed_reason, t.failed_code, t.provider_error, t.retry_count, t.max_retry,
 			t.next_retry_at, t.expired_at, t.inquiry_id, t.digi_ref_id,
 			t.buy_price, t.sell_price,
 			t.provider_id, t.provider_ref_id,
-			pp.code AS provider_code,
+			COALESCE(pp.code, t.provider_code) AS provider_code,
 			t.callback_sent, t.callback_sent_at, t.created_at, t.processed_at, t.updated_at,
 			p.sku_code AS product_sku_code,
 			s.digi_sku_code AS digi_sku_code
@@ -724,10 +944,10 @@ func (r *TransactionRepository) GetByTransactionIDAdmin(transactionID string) (*
 		JOIN products p ON t.product_id = p.id
 		LEFT JOIN skus s ON t.sku_id = s.id
 		LEFT JOIN ppob_providers pp ON t.provider_id = pp.id
-		WHERE t.transaction_id = $1 LIMIT 1`
+		WHERE ` + where + ` LIMIT 1`
 
 	var trx transactionWithJoins
-	if err := r.db.Get(&trx, q, transactionID); err != nil {
+	if err := r.db.Get(&trx, q, arg); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, sql.ErrNoRows
 		}
@@ -743,3 +963,341 @@ func (r *TransactionRepository) MarkCallbackSent(id int) error {
 	_, err := r.db.Exec(q, id)
 	return err
 }
+
+// ProviderUsageCount is the number of successful transactions fulfilled by a provider.
+type ProviderUsageCount struct {
+	ProviderCode string `db:"provider_code" json:"providerCode"`
+	Count        int    `db:"count" json:"count"`
+}
+
+// GetProviderUsageCounts groups successful transactions by fulfilling provider.
+// Legacy rows without provider_id were fulfilled through Digiflazz SKUs.
+func (r *TransactionRepository) GetProviderUsageCounts(startDate, endDate *string) ([]ProviderUsageCount, error) {
+	q := `SELECT
+            COALESCE(pp.code, CASE WHEN t.sku_id IS NOT NULL THEN 'digiflazz' ELSE 'unknown' END) as provider_code,
+            COUNT(*) as count
+          FROM transactions t
+          LEFT JOIN ppob_providers pp ON t.provider_id = pp.id
+          WHERE t.status = 'Success' AND t.type IN ('prepaid', 'payment')`
+
+	args := []interface{}{}
+	argIdx := 1
+
+	if startDate != nil && *startDate != "" {
+		q += fmt.Sprintf(" AND t.created_at >= $%d::date", argIdx)
+		args = append(args, *startDate)
+		argIdx++
+	}
+	if endDate != nil && *endDate != "" {
+		q += fmt.Sprintf(" AND t.created_at < ($%d::date + interval '1 day')", argIdx)
+		args = append(args, *endDate)
+		argIdx++
+	}
+
+	q += " GROUP BY 1 ORDER BY count DESC"
+
+	var counts []ProviderUsageCount
+	if err := r.db.Select(&counts, q, args...); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// ClientSKUStatsFilter filters a client's per-SKU transaction counts.
+type ClientSKUStatsFilter struct {
+	ClientID  int
+	IsSandbox bool
+	StartDate string // YYYY-MM-DD, inclusive
+	EndDate   string // YYYY-MM-DD, inclusive
+	Page      int
+	Limit     int
+}
+
+// ClientSKUStat is one product's prepaid/payment volume for a client.
+// TotalAmount sums the sell price of its successful transactions.
+type ClientSKUStat struct {
+	SkuCode      string `db:"sku_code" json:"skuCode"`
+	ProductName  string `db:"product_name" json:"productName"`
+	Total        int    `db:"total" json:"total"`
+	SuccessCount int    `db:"success_count" json:"successCount"`
+	FailedCount  int    `db:"failed_count" json:"failedCount"`
+	TotalAmount  int64  `db:"total_amount" json:"totalAmount"`
+}
+
+// ListClientSKUStats groups a client's prepaid and payment transactions by
+// product, most transactions first. The int is the number of products.
+func (r *TransactionRepository) ListClientSKUStats(f ClientSKUStatsFilter) ([]ClientSKUStat, int, error) {
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.Limit <= 0 || f.Limit > 100 {
+		f.Limit = 50
+	}
+
+	where := ` WHERE t.client_id = $1 AND t.is_sandbox = $2 AND t.type IN ('prepaid', 'payment')`
+	args := []interface{}{f.ClientID, f.IsSandbox}
+	argIdx := 3
+
+	if f.StartDate != "" {
+		where += fmt.Sprintf(" AND t.created_at >= $%d::date", argIdx)
+		args = append(args, f.StartDate)
+		argIdx++
+	}
+	if f.EndDate != "" {
+		where += fmt.Sprintf(" AND t.created_at < ($%d::date + interval '1 day')", argIdx)
+		args = append(args, f.EndDate)
+		argIdx++
+	}
+
+	var total int
+	if err := r.db.Get(&total, `SELECT COUNT(DISTINCT t.product_id) FROM transactions t`+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	q := `SELECT
+            p.sku_code, p.name AS product_name,
+            COUNT(*) AS total,
+            COUNT(*) FILTER (WHERE t.status = 'Success') AS success_count,
+            COUNT(*) FILTER (WHERE t.status = 'Failed') AS failed_count,
+            COALESCE(SUM(t.sell_price) FILTER (WHERE t.status = 'Success'), 0) AS total_amount
+          FROM transactions t
+          JOIN products p ON p.id = t.product_id` + where +
+		fmt.Sprintf(" GROUP BY p.id, p.sku_code, p.name ORDER BY total DESC, p.sku_code LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
+	args = append(args, f.Limit, (f.Page-1)*f.Limit)
+
+	var stats []ClientSKUStat
+	if err := r.db.Select(&stats, q, args...); err != nil {
+		return nil, 0, err
+	}
+	return stats, total, nil
+}
+
+// MaskHashedCustomerNos masks the raw customer_no of final transactions that
+// belong to clients with hash_customer_no enabled, once older than retention.
+// Only the last four characters are kept for support lookups.
+func (r *TransactionRepository) MaskHashedCustomerNos(retention time.Duration, limit int) (int64, error) {
+	const q = `
+        UPDATE transactions SET
+            customer_no = REPEAT('*', GREATEST(LENGTH(customer_no) - 4, 0)) || RIGHT(customer_no, 4),
+            updated_at = NOW()
+        WHERE id IN (
+            SELECT t.id FROM transactions t
+            JOIN clients c ON c.id = t.client_id
+            WHERE c.hash_customer_no = true
+              AND t.customer_no_hash IS NOT NULL
+              AND t.status IN ('Success', 'Failed')
+              AND t.created_at < NOW() - ($1 * interval '1 second')
+              AND LENGTH(t.customer_no) > 4
+              AND LEFT(t.customer_no, 1) <> '*'
+            ORDER BY t.id
+            LIMIT $2
+        )`
+	res, err := r.db.Exec(q, int64(retention.Seconds()), limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// FindSuccessBySerialNumber returns the transaction_id of another successful
+// transaction carrying the same serial number, or "" when there is none.
+// Only production transactions created within window (0 = any age) and
+// outside ignoreCategories (lower-cased product categories) are considered.
+func (r *TransactionRepository) FindSuccessBySerialNumber(serialNumber string, excludeID int, window time.Duration, ignoreCategories []string) (string, error) {
+	const q = `
+        SELECT t.transaction_id FROM transactions t
+        JOIN products p ON p.id = t.product_id
+        WHERE t.serial_number = $1 AND t.status = 'Success' AND t.id <> $2
+          AND t.is_sandbox = false
+          AND ($3 = 0 OR t.created_at >= NOW() - ($3 * interval '1 second'))
+          AND NOT (LOWER(p.category) = ANY(COALESCE($4::text[], '{}')))
+        ORDER BY t.id LIMIT 1`
+	var trxID string
+	if err := r.db.Get(&trxID, q, serialNumber, excludeID, int64(window.Seconds()), pq.Array(ignoreCategories)); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return trxID, nil
+}
+
+// SerialNumberDuplicateFilter holds filters for the duplicate serial number report.
+type SerialNumberDuplicateFilter struct {
+	StartDate        string   // YYYY-MM-DD, inclusive
+	EndDate          string   // YYYY-MM-DD, inclusive
+	IgnoreCategories []string // lower-cased product categories whose serial numbers repeat by design
+	Page             int
+	Limit            int
+}
+
+// SerialNumberDuplicate is one serial number shared by several successful transactions.
+type SerialNumberDuplicate struct {
+	SerialNumber   string         `db:"serial_number" json:"serialNumber"`
+	Count          int            `db:"count" json:"count"`
+	TransactionIDs pq.StringArray `db:"transaction_ids" json:"transactionIds"`
+	ProviderCodes  pq.StringArray `db:"provider_codes" json:"providerCodes"`
+	FirstAt        time.Time      `db:"first_at" json:"firstAt"`
+	LastAt         time.Time      `db:"last_at" json:"lastAt"`
+}
+
+// ListSerialNumberDuplicates groups successful production transactions
+// created in the date range by serial number and returns those held by more than one,
+// most recent first.
+func (r *TransactionRepository) ListSerialNumberDuplicates(f SerialNumberDuplicateFilter) ([]SerialNumberDuplicate, int, error) {
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.Limit <= 0 || f.Limit > 100 {
+		f.Limit = 50
+	}
+
+	where := ` WHERE t.status = 'Success' AND t.is_sandbox = false
+          AND t.serial_number IS NOT NULL AND t.serial_number <> ''
+          AND NOT (LOWER(p.category) = ANY(COALESCE($1::text[], '{}')))`
+	args := []interface{}{pq.Array(f.IgnoreCategories)}
+	argIdx := 2
+
+	if f.StartDate != "" {
+		where += fmt.Sprintf(" AND t.created_at >= $%d::date", argIdx)
+		args = append(args, f.StartDate)
+		argIdx++
+	}
+	if f.EndDate != "" {
+		where += fmt.Sprintf(" AND t.created_at < ($%d::date + interval '1 day')", argIdx)
+		args = append(args, f.EndDate)
+		argIdx++
+	}
+
+	grouped := `
+        SELECT t.serial_number,
+            COUNT(*) AS count,
+            ARRAY_AGG(t.transaction_id ORDER BY t.id) AS transaction_ids,
+            ARRAY_AGG(DISTINCT COALESCE(t.provider_code, 'unknown')) AS provider_codes,
+            MIN(t.created_at) AS first_at,
+            MAX(t.created_at) AS last_at
+        FROM transactions t
+        JOIN products p ON p.id = t.product_id` + where + `
+        GROUP BY t.serial_number
+        HAVING COUNT(*) > 1`
+
+	var total int
+	if err := r.db.Get(&total, `SELECT COUNT(*) FROM (`+grouped+`) d`, args...); err != nil {
+		return nil, 0, err
+	}
+
+	q := grouped + fmt.Sprintf(" ORDER BY last_at DESC LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
+	args = append(args, f.Limit, (f.Page-1)*f.Limit)
+
+	var list []SerialNumberDuplicate
+	if err := r.db.Select(&list, q, args...); err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// FlagDuplicateSerialNumber records which transaction already holds the serial number of id.
+func (r *TransactionRepository) FlagDuplicateSerialNumber(id int, duplicateOf string) error {
+	_, err := r.db.Exec(`UPDATE transactions SET serial_number_duplicate_of = $2 WHERE id = $1`, id, duplicateOf)
+	return err
+}
+
+// HoldDuplicateSerialForReview holds transaction id for review when product
+// productID has hold_duplicate_serial and duplicateOf is a transaction of the
+// same product and provider. It reports whether the transaction was held;
+// its status is left to the caller, which saves it as Pending.
+func (r *TransactionRepository) HoldDuplicateSerialForReview(id, productID int, providerID *int, duplicateOf string) (bool, error) {
+	const q = `
+        UPDATE transactions t SET review_hold_at = NOW(), updated_at = NOW()
+        FROM products p
+        WHERE t.id = $1
+          AND p.id = $2 AND p.hold_duplicate_serial
+          AND EXISTS (
+            SELECT 1 FROM transactions o
+            WHERE o.transaction_id = $4 AND o.product_id = $2
+              AND o.provider_id IS NOT DISTINCT FROM $3
+          )`
+	res, err := r.db.Exec(q, id, productID, providerID, duplicateOf)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ReleaseReviewHold clears the review hold of transaction id so exactly one
+// decision goes through; sql.ErrNoRows when it is not held.
+func (r *TransactionRepository) ReleaseReviewHold(id int) error {
+	res, err := r.db.Exec(`UPDATE transactions SET review_hold_at = NULL WHERE id = $1 AND review_hold_at IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListRefunds returns the refunds recorded against a transaction, oldest first.
+func (r *TransactionRepository) ListRefunds(trxID int) ([]models.TransactionRefund, error) {
+	const q = `SELECT * FROM transaction_refunds WHERE transaction_id = $1 ORDER BY id`
+	refunds := []models.TransactionRefund{}
+	if err := r.db.Select(&refunds, q, trxID); err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+// ListNotes returns the internal notes of a transaction, oldest first.
+func (r *TransactionRepository) ListNotes(trxID int) ([]models.TransactionNote, error) {
+	const q = `SELECT * FROM transaction_notes WHERE transaction_id = $1 ORDER BY id`
+	notes := []models.TransactionNote{}
+	if err := r.db.Select(&notes, q, trxID); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// CreateNote records an internal note on a transaction.
+func (r *TransactionRepository) CreateNote(note *models.TransactionNote) error {
+	const q = `
+        INSERT INTO transaction_notes (transaction_id, note, created_by)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at`
+	return r.db.QueryRowx(q, note.TransactionID, note.Note, note.CreatedBy).
+		Scan(&note.ID, &note.CreatedAt)
+}
+
+// CreateRefund records a refund unless the transaction's refund total would
+// exceed maxTotal, in which case it returns false without inserting. The parent
+// transaction row is locked so concurrent refunds cannot overshoot together.
+func (r *TransactionRepository) CreateRefund(refund *models.TransactionRefund, maxTotal int) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after commit
+
+	var locked int
+	if err := tx.Get(&locked, `SELECT id FROM transactions WHERE id = $1 FOR UPDATE`, refund.TransactionID); err != nil {
+		return false, err
+	}
+
+	var refunded int
+	if err := tx.Get(&refunded, `SELECT COALESCE(SUM(amount), 0) FROM transaction_refunds WHERE transaction_id = $1`, refund.TransactionID); err != nil {
+		return false, err
+	}
+	if refunded+refund.Amount > maxTotal {
+		return false, nil
+	}
+
+	const q = `
+        INSERT INTO transaction_refunds (transaction_id, amount, reason, created_by)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at`
+	if err := tx.QueryRowx(q, refund.TransactionID, refund.Amount, refund.Reason, refund.CreatedBy).
+		Scan(&refund.ID, &refund.CreatedAt); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
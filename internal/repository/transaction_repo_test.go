@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestFormatTransactionID(t *testing.T) {
+	cases := []struct {
+		prefix, ymd string
+		seq         int
+		want        string
+	}{
+		{"GRB", "20260101", 1, "GRB-20260101-000001"},
+		{"ACME", "20261231", 999999, "ACME-20261231-999999"},
+		{"GRB", "20260101", 1000000, "GRB-20260101-1000000"},
+	}
+	for _, tc := range cases {
+		if got := formatTransactionID(tc.prefix, tc.ymd, tc.seq); got != tc.want {
+			t.Errorf("formatTransactionID(%q, %q, %d) = %q, want %q", tc.prefix, tc.ymd, tc.seq, got, tc.want)
+		}
+	}
+}
+
+// testDB connects to the migrated PostgreSQL database in TEST_DATABASE_URL,
+// skipping the test without one.
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := sqlx.Connect("postgres", url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// TestGenerateTransactionIDConcurrent needs TEST_DATABASE_URL (see testDB).
+func TestGenerateTransactionIDConcurrent(t *testing.T) {
+	db := testDB(t)
+
+	// A prefix of its own keeps the counter apart from real transaction IDs.
+	prefix := fmt.Sprintf("T%05d", time.Now().UnixNano()%100000)
+	t.Cleanup(func() {
+		_, _ = db.Exec(`DELETE FROM transaction_id_counters WHERE prefix = $1`, prefix)
+	})
+	r := NewTransactionRepository(db)
+
+	const workers, perWorker = 20, 10
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]bool)
+		wg   sync.WaitGroup
+	)
+	errs := make(chan error, workers*perWorker)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				id, err := r.GenerateTransactionID(prefix)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				mu.Lock()
+				if seen[id] {
+					errs <- fmt.Errorf("duplicate transaction id %s", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if len(seen) != workers*perWorker {
+		t.Fatalf("got %d distinct ids, want %d", len(seen), workers*perWorker)
+	}
+}
+
+// TestGetAllAdminCountOnly needs TEST_DATABASE_URL (see testDB). It reads
+// whatever transactions the database holds.
+func TestGetAllAdminCountOnly(t *testing.T) {
+	r := NewTransactionRepository(testDB(t))
+	status, trxType, sandbox := "Success", "prepaid", true
+
+	cases := []struct {
+		name   string
+		filter AdminTransactionFilter
+	}{
+		{"no filter", AdminTransactionFilter{}},
+		{"status", AdminTransactionFilter{Status: &status}},
+		{"type and sandbox", AdminTransactionFilter{Type: &trxType, IsSandbox: &sandbox}},
+		{"small pages", AdminTransactionFilter{Limit: 1}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			full := tc.filter
+			want, err := r.GetAllAdmin(&full)
+			if err != nil {
+				t.Fatalf("GetAllAdmin: %v", err)
+			}
+			counted := tc.filter
+			counted.CountOnly = true
+			got, err := r.GetAllAdmin(&counted)
+			if err != nil {
+				t.Fatalf("GetAllAdmin count only: %v", err)
+			}
+			if got.Transactions != nil {
+				t.Fatalf("count only selected %d rows", len(got.Transactions))
+			}
+			if got.TotalItems != want.TotalItems || got.TotalPages != want.TotalPages ||
+				got.Page != want.Page || got.Limit != want.Limit {
+				t.Fatalf("count only = %+v, want the totals of %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRedactAuditBody(t *testing.T) {
+	body := []byte(`{
+		"name": "Kiosbank",
+		"apiKey": "k-123",
+		"client_secret": "s-456",
+		"config": {"callbackToken": "t-789", "baseUrl": "https://x", "pin": "1234"},
+		"webhooks": [{"url": "https://y", "signature_key": "sig"}],
+		"keyword": "pulsa",
+		"shipping": "kept"
+	}`)
+
+	var got map[string]any
+	if err := json.Unmarshal(RedactAuditBody(body), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	for _, k := range []string{"apiKey", "client_secret"} {
+		if got[k] != auditRedacted {
+			t.Errorf("%s = %v, want redacted", k, got[k])
+		}
+	}
+	cfg := got["config"].(map[string]any)
+	if cfg["callbackToken"] != auditRedacted || cfg["pin"] != auditRedacted {
+		t.Errorf("nested secrets not redacted: %v", cfg)
+	}
+	if cfg["baseUrl"] != "https://x" {
+		t.Errorf("baseUrl = %v, want kept", cfg["baseUrl"])
+	}
+	hook := got["webhooks"].([]any)[0].(map[string]any)
+	if hook["signature_key"] != auditRedacted || hook["url"] != "https://y" {
+		t.Errorf("array element = %v", hook)
+	}
+	if got["name"] != "Kiosbank" || got["keyword"] != "pulsa" || got["shipping"] != "kept" {
+		t.Errorf("harmless fields changed: %v", got)
+	}
+}
+
+func TestRedactAuditBody_DropsNonJSON(t *testing.T) {
+	for _, body := range []string{"", "  ", "password=hunter2", "{broken"} {
+		if got := RedactAuditBody([]byte(body)); got != nil {
+			t.Errorf("RedactAuditBody(%q) = %s, want nil", body, got)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+// Where a ConfigValue comes from.
+const (
+	ConfigSourceClient  = "client"  // the client's own override
+	ConfigSourceDefault = "default" // the global setting
+)
+
+// ClientConfigDefaults are the global settings client overrides resolve
+// against, as wired in main.
+type ClientConfigDefaults struct {
+	TransactionIDPrefix    string
+	PriceRounding          models.PriceRounding
+	ReferenceIDMaxLength   int // 0 = reference_id column width
+	SandboxRouting         bool
+	CustomerNoRawRetention time.Duration
+	RequestTimeouts        map[string]time.Duration // route group -> handler deadline, 0 = none
+	RateLimits             []ClientRouteLimit
+}
+
+// ClientRouteLimit is a per-client rate limit of one route.
+type ClientRouteLimit struct {
+	Route  string `json:"route"`
+	Limit  int    `json:"limit"`
+	Window string `json:"window"`
+}
+
+// ConfigValue is one resolved setting and where it comes from.
+type ConfigValue struct {
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// ClientCallbackConfig is a client's callback delivery setup; secrets and
+// header values are reported as present, never returned.
+type ClientCallbackConfig struct {
+	URL         string   `json:"url"`
+	Method      string   `json:"method"`
+	HeaderNames []string `json:"headerNames"`
+	SecretSet   bool     `json:"secretSet"`
+	// SignatureAlgorithm and SignatureEncoding are the X-GTD-Signature format.
+	SignatureAlgorithm string `json:"signatureAlgorithm"`
+	SignatureEncoding  string `json:"signatureEncoding"`
+	AttemptInfo        bool   `json:"attemptInfo"`
+	Ordered            bool   `json:"ordered"`
+	BatchSize          int    `json:"batchSize"` // 0 = one event per request
+}
+
+// ClientDuplicateDetection is a client's likely-duplicate transaction check.
+type ClientDuplicateDetection struct {
+	Enabled bool   `json:"enabled"`
+	Window  string `json:"window"`
+	Action  string `json:"action"` // warn or block
+}
+
+// ClientEffectiveConfig is what actually applies to a client's requests.
+type ClientEffectiveConfig struct {
+	ID          int      `json:"id"`
+	ClientID    string   `json:"clientId"`
+	Name        string   `json:"name"`
+	IsActive    bool     `json:"isActive"`
+	Scopes      []string `json:"scopes"`
+	IPWhitelist []string `json:"ipWhitelist"` // empty allows any IP
+
+	SandboxEnabled bool `json:"sandboxEnabled"` // has a sandbox key
+	SandboxRouting bool `json:"sandboxRouting"` // sandbox uses the provider router, not only Digiflazz dev
+
+	Callback             ClientCallbackConfig `json:"callback"`
+	TransactionIDPrefix  ConfigValue          `json:"transactionIdPrefix"`
+	PriceRounding        ConfigValue          `json:"priceRounding"`
+	Locale               ConfigValue          `json:"locale"`         // used when Accept-Language has no supported locale
+	ResponseFormat       ConfigValue          `json:"responseFormat"` // PPOB transaction response shape
+	ReferenceIDMaxLength int                  `json:"referenceIdMaxLength"`
+
+	HashCustomerNo         bool   `json:"hashCustomerNo"`
+	CustomerNoRawRetention string `json:"customerNoRawRetention,omitempty"` // only for hashing clients
+
+	TransactionPriority int                      `json:"transactionPriority"` // worker priority tier, 0-9
+	DuplicateDetection  ClientDuplicateDetection `json:"duplicateDetection"`
+
+	RequestTimeouts map[string]string  `json:"requestTimeouts"`
+	RateLimits      []ClientRouteLimit `json:"rateLimits"`
+}
+
+// SetClientDefaults sets the global settings EffectiveConfig resolves
+// client overrides against.
+func (s *AdminClientService) SetClientDefaults(d ClientConfigDefaults) {
+	s.defaults = d
+}
+
+// EffectiveConfig returns the settings that apply to client id: its own
+// overrides merged over the global defaults.
+func (s *AdminClientService) EffectiveConfig(id int) (*ClientEffectiveConfig, error) {
+	client, err := s.clientRepo.GetByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAdminNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get client: %w", err)
+	}
+	return effectiveClientConfig(client, s.defaults), nil
+}
+
+func effectiveClientConfig(client *models.Client, d ClientConfigDefaults) *ClientEffectiveConfig {
+	cfg := &ClientEffectiveConfig{
+		ID:             client.ID,
+		ClientID:       client.ClientID,
+		Name:           client.Name,
+		IsActive:       client.IsActive,
+		Scopes:         nonNilStrings(client.Scopes),
+		IPWhitelist:    nonNilStrings(client.IPWhitelist),
+		SandboxEnabled: client.SandboxKey != "",
+		SandboxRouting: d.SandboxRouting,
+		Callback: ClientCallbackConfig{
+			URL:         client.CallbackURL,
+			Method:      client.CallbackMethod,
+			HeaderNames: make([]string, 0, len(client.CallbackHeaders)),
+			SecretSet:   client.CallbackSecret != "",
+			AttemptInfo: client.CallbackAttemptInfo,
+			Ordered:     client.CallbackOrdered,
+			BatchSize:   callbackBatchSize(client),
+		},
+		ReferenceIDMaxLength: d.ReferenceIDMaxLength,
+		HashCustomerNo:       client.HashCustomerNo,
+		TransactionPriority:  client.TransactionPriority,
+		RequestTimeouts:      make(map[string]string, len(d.RequestTimeouts)),
+		RateLimits:           d.RateLimits,
+	}
+	cfg.Callback.SignatureAlgorithm, cfg.Callback.SignatureEncoding = client.CallbackSignatureFormat()
+	if cfg.Callback.Method == "" {
+		cfg.Callback.Method = "POST"
+	}
+	for name := range client.CallbackHeaders {
+		cfg.Callback.HeaderNames = append(cfg.Callback.HeaderNames, name)
+	}
+	sort.Strings(cfg.Callback.HeaderNames)
+	cfg.DuplicateDetection = ClientDuplicateDetection{
+		Enabled: client.DuplicateWindow() > 0,
+		Window:  client.DuplicateWindow().String(),
+		Action:  models.DuplicateActionWarn,
+	}
+	if client.BlocksDuplicates() {
+		cfg.DuplicateDetection.Action = models.DuplicateActionBlock
+	}
+	if cfg.ReferenceIDMaxLength <= 0 || cfg.ReferenceIDMaxLength > maxReferenceIDLength {
+		cfg.ReferenceIDMaxLength = maxReferenceIDLength
+	}
+	if client.HashCustomerNo {
+		cfg.CustomerNoRawRetention = d.CustomerNoRawRetention.String()
+	}
+	for group, timeout := range d.RequestTimeouts {
+		cfg.RequestTimeouts[group] = timeout.String()
+	}
+	if cfg.RateLimits == nil {
+		cfg.RateLimits = []ClientRouteLimit{}
+	}
+
+	cfg.TransactionIDPrefix = ConfigValue{Value: transactionIDPrefixFor(client, d.TransactionIDPrefix), Source: ConfigSourceDefault}
+	if client.TransactionIDPrefix != nil && models.ValidTransactionIDPrefix(*client.TransactionIDPrefix) {
+		cfg.TransactionIDPrefix.Source = ConfigSourceClient
+	}
+
+	cfg.PriceRounding = ConfigValue{Value: d.PriceRounding.String(), Source: ConfigSourceDefault}
+	if client.PriceRounding != nil {
+		if r, err := models.ParsePriceRounding(*client.PriceRounding); err == nil {
+			cfg.PriceRounding = ConfigValue{Value: r.String(), Source: ConfigSourceClient}
+		}
+	}
+
+	// clients.locale defaults to en in the table, so en reads as the default.
+	cfg.Locale = ConfigValue{Value: utils.LocaleEN, Source: ConfigSourceDefault}
+	if locale := utils.NormalizeLocale(client.Locale); locale != "" && locale != utils.LocaleEN {
+		cfg.Locale = ConfigValue{Value: locale, Source: ConfigSourceClient}
+	}
+
+	cfg.ResponseFormat = ConfigValue{Value: models.ResponseFormatEnvelope, Source: ConfigSourceDefault}
+	if client.FlatResponses() {
+		cfg.ResponseFormat = ConfigValue{Value: models.ResponseFormatFlat, Source: ConfigSourceClient}
+	}
+	return cfg
+}
+
+func nonNilStrings(v []string) []string {
+	if v == nil {
+		return []string{}
+	}
+	return v
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/repository"
+)
+
+// AdminClientService backs the admin client list and effective config.
+type AdminClientService struct {
+	clientRepo *repository.ClientRepository
+	defaults   ClientConfigDefaults
+}
+
+func NewAdminClientService(clientRepo *repository.ClientRepository) *AdminClientService {
+	return &AdminClientService{clientRepo: clientRepo}
+}
+
+// maxClientUsageRange bounds the usage range of the client list.
+const maxClientUsageRange = 366 * 24 * time.Hour
+
+// defaultClientUsageDays is the usage range of the client list when from is
+// left out: that many days up to and including to.
+const defaultClientUsageDays = 30
+
+// ListClients pages clients with usage indicators over the from..to dates
+// (YYYY-MM-DD, WIB, both inclusive). to defaults to today and from to 30 days
+// up to to. status is "", "active" or "inactive".
+func (s *AdminClientService) ListClients(search, status, from, to string, page, limit int) ([]repository.ClientUsageSummary, int, error) {
+	isActive, err := clientStatusFilter(status)
+	if err != nil {
+		return nil, 0, err
+	}
+	start, end, err := clientUsageRange(from, to, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+	clients, total, err := s.clientRepo.ListWithUsage(repository.ClientListFilter{
+		Search:   strings.TrimSpace(search),
+		IsActive: isActive,
+		From:     start,
+		To:       end,
+		Page:     page,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list clients: %w", err)
+	}
+	return clients, total, nil
+}
+
+// clientUsageRange parses the from..to dates into the half-open range
+// [from 00:00, the day after to 00:00) in WIB. A missing to is the day of now,
+// a missing from defaultClientUsageDays days up to to.
+func clientUsageRange(from, to string, now time.Time) (time.Time, time.Time, error) {
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	wib := time.FixedZone("WIB", 7*3600)
+	if to == "" {
+		to = now.In(wib).Format("2006-01-02")
+	}
+	last, err := time.ParseInLocation("2006-01-02", to, wib)
+	if err != nil {
+		return time.Time{}, time.Time{}, &AdminValidationError{Message: "to must be a YYYY-MM-DD date"}
+	}
+	end := last.AddDate(0, 0, 1)
+	start := end.AddDate(0, 0, -defaultClientUsageDays)
+	if from != "" {
+		if start, err = time.ParseInLocation("2006-01-02", from, wib); err != nil {
+			return time.Time{}, time.Time{}, &AdminValidationError{Message: "from must be a YYYY-MM-DD date"}
+		}
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, &AdminValidationError{Message: "to must not be before from"}
+	}
+	if end.Sub(start) > maxClientUsageRange {
+		return time.Time{}, time.Time{}, &AdminValidationError{Message: "from..to may span at most 366 days"}
+	}
+	return start, end, nil
+}
+
+func clientStatusFilter(status string) (*bool, error) {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "":
+		return nil, nil
+	case "active":
+		v := true
+		return &v, nil
+	case "inactive":
+		v := false
+		return &v, nil
+	}
+	return nil, &AdminValidationError{Message: "status must be active or inactive"}
+}
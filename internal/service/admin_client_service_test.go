@@ -0,0 +1,132 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+func TestClientStatusFilter(t *testing.T) {
+	t.Parallel()
+
+	yes, no := true, false
+	cases := []struct {
+		in      string
+		want    *bool
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"active", &yes, false},
+		{" Inactive ", &no, false},
+		{"suspended", nil, true},
+	}
+	for _, tc := range cases {
+		got, err := clientStatusFilter(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("clientStatusFilter(%q) err = %v, wantErr %v", tc.in, err, tc.wantErr)
+		}
+		if (got == nil) != (tc.want == nil) || (got != nil && *got != *tc.want) {
+			t.Errorf("clientStatusFilter(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestEffectiveClientConfigSources(t *testing.T) {
+	t.Parallel()
+
+	d := ClientConfigDefaults{
+		TransactionIDPrefix: "GRB",
+		PriceRounding:       models.PriceRounding{Mode: models.RoundUp, Unit: 100},
+	}
+
+	plain := &models.Client{ClientID: "c1", Locale: "en"}
+	got := effectiveClientConfig(plain, d)
+	if got.TransactionIDPrefix != (ConfigValue{Value: "GRB", Source: ConfigSourceDefault}) {
+		t.Errorf("prefix = %+v, want default GRB", got.TransactionIDPrefix)
+	}
+	if got.PriceRounding != (ConfigValue{Value: "up:100", Source: ConfigSourceDefault}) {
+		t.Errorf("rounding = %+v, want default up:100", got.PriceRounding)
+	}
+	if got.Locale.Source != ConfigSourceDefault || got.Callback.Method != "POST" {
+		t.Errorf("locale = %+v, method = %q, want default en and POST", got.Locale, got.Callback.Method)
+	}
+
+	prefix, rounding := "ACM", "nearest:1000"
+	custom := &models.Client{
+		ClientID:            "c2",
+		Locale:              "id",
+		TransactionIDPrefix: &prefix,
+		PriceRounding:       &rounding,
+		CallbackSecret:      "s3cret",
+		CallbackHeaders:     models.CallbackHeaders{"X-Token": "abc", "Authorization": "Bearer x"},
+	}
+	got = effectiveClientConfig(custom, d)
+	if got.TransactionIDPrefix != (ConfigValue{Value: "ACM", Source: ConfigSourceClient}) {
+		t.Errorf("prefix = %+v, want client ACM", got.TransactionIDPrefix)
+	}
+	if got.PriceRounding != (ConfigValue{Value: "nearest:1000", Source: ConfigSourceClient}) {
+		t.Errorf("rounding = %+v, want client nearest:1000", got.PriceRounding)
+	}
+	if got.Locale != (ConfigValue{Value: "id", Source: ConfigSourceClient}) {
+		t.Errorf("locale = %+v, want client id", got.Locale)
+	}
+	if !got.Callback.SecretSet || !reflect.DeepEqual(got.Callback.HeaderNames, []string{"Authorization", "X-Token"}) {
+		t.Errorf("callback = %+v, want secret set and sorted header names", got.Callback)
+	}
+}
+
+func TestEffectiveClientConfigDuplicateDetection(t *testing.T) {
+	t.Parallel()
+
+	off := effectiveClientConfig(&models.Client{DuplicateAction: models.DuplicateActionBlock}, ClientConfigDefaults{})
+	if off.DuplicateDetection.Enabled {
+		t.Errorf("duplicate detection = %+v, want off without a window", off.DuplicateDetection)
+	}
+
+	warn := effectiveClientConfig(&models.Client{DuplicateWindowSeconds: 120}, ClientConfigDefaults{})
+	if want := (ClientDuplicateDetection{Enabled: true, Window: "2m0s", Action: "warn"}); warn.DuplicateDetection != want {
+		t.Errorf("duplicate detection = %+v, want %+v", warn.DuplicateDetection, want)
+	}
+
+	block := effectiveClientConfig(&models.Client{DuplicateWindowSeconds: 30, DuplicateAction: "BLOCK"}, ClientConfigDefaults{})
+	if block.DuplicateDetection.Action != models.DuplicateActionBlock {
+		t.Errorf("duplicate action = %q, want block", block.DuplicateDetection.Action)
+	}
+}
+
+func TestClientUsageRange(t *testing.T) {
+	t.Parallel()
+
+	// 2026-03-10 02:00 WIB
+	now := time.Date(2026, 3, 9, 19, 0, 0, 0, time.UTC)
+	cases := []struct {
+		from, to  string
+		wantStart string
+		wantDays  int
+		wantErr   bool
+	}{
+		{"2026-01-01", "2026-01-31", "2026-01-01", 31, false},
+		{"2026-01-05", "2026-01-05", "2026-01-05", 1, false},
+		{"", "", "2026-02-09", 30, false},
+		{"", "2026-01-31", "2026-01-02", 30, false},
+		{"2026-03-01", "", "2026-03-01", 10, false},
+		{"2026-02-01", "2026-01-31", "", 0, true},
+		{"01/01/2026", "2026-01-31", "", 0, true},
+		{"2026-01-01", "31/01/2026", "", 0, true},
+		{"2025-01-01", "2026-01-31", "", 0, true},
+	}
+	for _, tc := range cases {
+		start, end, err := clientUsageRange(tc.from, tc.to, now)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("clientUsageRange(%q, %q) err = %v, wantErr %v", tc.from, tc.to, err, tc.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if got := start.Format("2006-01-02"); got != tc.wantStart || int(end.Sub(start).Hours()/24) != tc.wantDays {
+			t.Errorf("clientUsageRange(%q, %q) = %v..%v, want %d days from %s", tc.from, tc.to, start, end, tc.wantDays, tc.wantStart)
+		}
+	}
+}
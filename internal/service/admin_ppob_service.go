@@ -0,0 +1,1024 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/cache"
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/repository"
+	"github.com/GTDGit/gtd_api/internal/utils"
+	"github.com/GTDGit/gtd_api/pkg/digiflazz"
+)
+
+// AdminPPOBService provides read/ops operations over PPOB providers and
+// transactions for the admin API.
+type AdminPPOBService struct {
+	trxRepo      *repository.TransactionRepository
+	productRepo  *repository.ProductRepository
+	skuRepo      *repository.SKURepository
+	providerRepo *repository.PPOBProviderRepository
+	trxSvc       *TransactionService
+	inquiryCache *cache.InquiryCache
+	// serialIgnore lists lower-cased categories left out of the duplicate
+	// serial number report.
+	serialIgnore []string
+	// priceGuard is applied to on-demand provider syncs.
+	priceGuard PriceListGuard
+	skuCodes   providerSKUCodeStore // providerRepo; a fake in tests
+}
+
+// providerSKUCodeStore is the part of the provider repository the reverse
+// SKU code lookup uses.
+type providerSKUCodeStore interface {
+	GetProviderByID(id int) (*models.PPOBProvider, error)
+	GetProviderSKUsByCode(providerID int, code string) ([]models.PPOBProviderSKU, error)
+	GetProviderSKUCodeConflicts(providerID int) ([]repository.ProviderSKUCodeConflict, error)
+}
+
+// AdminValidationError carries a client-facing message for rejected admin input.
+type AdminValidationError struct {
+	Message string
+}
+
+func (e *AdminValidationError) Error() string { return e.Message }
+
+// ErrAdminNotFound is returned when an admin-managed record does not exist.
+var ErrAdminNotFound = errors.New("NOT_FOUND")
+
+// NewAdminPPOBService constructs an AdminPPOBService.
+func NewAdminPPOBService(
+	trxRepo *repository.TransactionRepository,
+	productRepo *repository.ProductRepository,
+	skuRepo *repository.SKURepository,
+	providerRepo *repository.PPOBProviderRepository,
+	trxSvc *TransactionService,
+	inquiryCache *cache.InquiryCache,
+) *AdminPPOBService {
+	return &AdminPPOBService{
+		trxRepo:      trxRepo,
+		productRepo:  productRepo,
+		skuRepo:      skuRepo,
+		providerRepo: providerRepo,
+		trxSvc:       trxSvc,
+		inquiryCache: inquiryCache,
+		skuCodes:     providerRepo,
+	}
+}
+
+// SetSerialNumberIgnoreCategories sets the product categories whose serial
+// numbers repeat by design and are left out of the duplicate report.
+func (s *AdminPPOBService) SetSerialNumberIgnoreCategories(categories []string) {
+	s.serialIgnore = lowerCategories(categories)
+}
+
+// SetPriceListGuard applies the price list shrink guard to on-demand syncs.
+func (s *AdminPPOBService) SetPriceListGuard(guard PriceListGuard) {
+	s.priceGuard = guard
+}
+
+// ListSerialNumberDuplicates reports serial numbers shared by more than one
+// successful transaction created within [start, end] (dates, inclusive, both
+// optional) — possible provider double issues or fraud.
+func (s *AdminPPOBService) ListSerialNumberDuplicates(start, end string, page, limit int) ([]repository.SerialNumberDuplicate, int, error) {
+	list, total, err := s.trxRepo.ListSerialNumberDuplicates(repository.SerialNumberDuplicateFilter{
+		StartDate:        start,
+		EndDate:          end,
+		IgnoreCategories: s.serialIgnore,
+		Page:             page,
+		Limit:            limit,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list serial number duplicates: %w", err)
+	}
+	if list == nil {
+		list = []repository.SerialNumberDuplicate{}
+	}
+	return list, total, nil
+}
+
+// ProviderUsageShare is one provider's share of successful transactions.
+type ProviderUsageShare struct {
+	ProviderCode string  `json:"providerCode"`
+	IsBackup     bool    `json:"isBackup"`
+	Count        int     `json:"count"`
+	Percentage   float64 `json:"percentage"`
+}
+
+// ProviderUsageShareResponse is the payload for the usage-share endpoint.
+type ProviderUsageShareResponse struct {
+	Start     string               `json:"start,omitempty"`
+	End       string               `json:"end,omitempty"`
+	Total     int                  `json:"total"`
+	Providers []ProviderUsageShare `json:"providers"`
+}
+
+// GetProviderUsageShare returns what share of successful transactions each
+// provider fulfilled within [start, end] (dates, inclusive, both optional).
+func (s *AdminPPOBService) GetProviderUsageShare(start, end string) (*ProviderUsageShareResponse, error) {
+	counts, err := s.trxRepo.GetProviderUsageCounts(&start, &end)
+	if err != nil {
+		return nil, fmt.Errorf("get provider usage counts: %w", err)
+	}
+
+	providers, err := s.providerRepo.GetAllProviders(false)
+	if err != nil {
+		return nil, fmt.Errorf("get providers: %w", err)
+	}
+
+	resp := providerUsageShare(counts, providers)
+	resp.Start, resp.End = start, end
+	return resp, nil
+}
+
+// providerUsageShare turns per-provider success counts into shares of their
+// total, flagging backup providers. Digiflazz, which legacy rows count under,
+// is a backup unless its provider row says otherwise.
+func providerUsageShare(counts []repository.ProviderUsageCount, providers []models.PPOBProvider) *ProviderUsageShareResponse {
+	backups := map[string]bool{string(models.ProviderDigiflazz): true}
+	for _, p := range providers {
+		backups[string(p.Code)] = p.IsBackup
+	}
+
+	total := 0
+	for _, c := range counts {
+		total += c.Count
+	}
+
+	resp := &ProviderUsageShareResponse{
+		Total:     total,
+		Providers: make([]ProviderUsageShare, 0, len(counts)),
+	}
+	for _, c := range counts {
+		resp.Providers = append(resp.Providers, ProviderUsageShare{
+			ProviderCode: c.ProviderCode,
+			IsBackup:     backups[c.ProviderCode],
+			Count:        c.Count,
+			Percentage:   percentage(c.Count, total),
+		})
+	}
+	return resp
+}
+
+// ProviderWithCapabilities is a provider row plus what its registered client
+// can do. Registered is false when the provider has no client configured in
+// this deployment; its capabilities are then all false.
+type ProviderWithCapabilities struct {
+	models.PPOBProvider
+	Registered   bool                 `json:"registered"`
+	Capabilities ProviderCapabilities `json:"capabilities"`
+	// NoInquiryCategories lists categories whose inquiry never falls back to
+	// this provider (Digiflazz only, PPOB_INQUIRY_NO_DIGIFLAZZ_CATEGORIES).
+	NoInquiryCategories []string `json:"noInquiryCategories,omitempty"`
+}
+
+// ListProviders returns every PPOB provider with its capabilities.
+func (s *AdminPPOBService) ListProviders() ([]ProviderWithCapabilities, error) {
+	providers, err := s.providerRepo.GetAllProviders(false)
+	if err != nil {
+		return nil, fmt.Errorf("get providers: %w", err)
+	}
+
+	var clients map[models.ProviderCode]PPOBProviderClient
+	if s.trxSvc != nil && s.trxSvc.providerRouter != nil {
+		clients = s.trxSvc.providerRouter.GetClients()
+	}
+
+	out := make([]ProviderWithCapabilities, 0, len(providers))
+	for _, p := range providers {
+		item := ProviderWithCapabilities{PPOBProvider: p}
+		if client, ok := clients[p.Code]; ok {
+			item.Registered = true
+			item.Capabilities = ClientCapabilities(client)
+		}
+		if p.Code == models.ProviderDigiflazz && s.trxSvc != nil {
+			item.NoInquiryCategories = s.trxSvc.noDigiflazzInquiryCategories()
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// SyncProvider refreshes the prices of a single provider right away instead of
+// waiting for the next ProviderSyncWorker tick. force applies a price list the
+// shrink guard would not trust.
+func (s *AdminPPOBService) SyncProvider(ctx context.Context, providerID int, force bool) (*ProviderSyncResult, error) {
+	provider, err := s.providerRepo.GetProviderByID(providerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAdminNotFound
+		}
+		return nil, fmt.Errorf("get provider: %w", err)
+	}
+
+	var client PPOBProviderClient
+	if s.trxSvc != nil && s.trxSvc.providerRouter != nil {
+		client = s.trxSvc.providerRouter.GetClients()[provider.Code]
+	}
+	if client == nil {
+		return nil, &AdminValidationError{Message: "provider has no registered client"}
+	}
+
+	guard := s.priceGuard
+	guard.Accept = force
+	result, err := SyncProviderPrices(ctx, s.providerRepo, *provider, client, guard)
+	if err != nil {
+		return nil, fmt.Errorf("sync provider prices: %w", err)
+	}
+	return result, nil
+}
+
+// RetryTransactionWithSKU re-attempts a transaction on a specific SKU of its
+// product, even if that SKU was already tried.
+func (s *AdminPPOBService) RetryTransactionWithSKU(ctx context.Context, transactionID string, skuID int) (*models.Transaction, error) {
+	trx, err := s.trxRepo.GetByTransactionID(transactionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrTransactionNotFound
+		}
+		return nil, fmt.Errorf("get transaction: %w", err)
+	}
+	return s.trxSvc.RetryWithSKU(ctx, trx, skuID)
+}
+
+// RefundRequest records a (partial) dispute refund against a transaction.
+type RefundRequest struct {
+	Amount int    `json:"amount" binding:"required,gt=0"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+// TransactionRefunds is a transaction's refund ledger.
+type TransactionRefunds struct {
+	TransactionID  string                     `json:"transactionId"`
+	Amount         int                        `json:"amount"`
+	RefundedAmount int                        `json:"refundedAmount"`
+	Refunds        []models.TransactionRefund `json:"refunds"`
+}
+
+// RefundTransaction records a refund of a successful transaction. The total
+// refunded may not exceed the transaction amount (or sell price when the
+// provider did not report an amount).
+func (s *AdminPPOBService) RefundTransaction(transactionID string, req RefundRequest, createdBy string) (*TransactionRefunds, error) {
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, &AdminValidationError{Message: "reason is required"}
+	}
+	trx, err := s.trxRepo.GetByTransactionID(transactionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrTransactionNotFound
+		}
+		return nil, fmt.Errorf("get transaction: %w", err)
+	}
+	if trx.Status != models.StatusSuccess {
+		return nil, &AdminValidationError{Message: "only successful transactions can be refunded"}
+	}
+	amount := refundableAmount(trx)
+	if amount <= 0 {
+		return nil, &AdminValidationError{Message: "transaction has no amount to refund"}
+	}
+
+	refund := &models.TransactionRefund{
+		TransactionID: trx.ID,
+		Amount:        req.Amount,
+		Reason:        strings.TrimSpace(req.Reason),
+	}
+	if createdBy != "" {
+		refund.CreatedBy = &createdBy
+	}
+	ok, err := s.trxRepo.CreateRefund(refund, amount)
+	if err != nil {
+		return nil, fmt.Errorf("create refund: %w", err)
+	}
+	if !ok {
+		return nil, utils.ErrRefundExceedsAmount
+	}
+	return s.refunds(trx, amount)
+}
+
+// ListTransactionRefunds returns the refund ledger of a transaction.
+func (s *AdminPPOBService) ListTransactionRefunds(transactionID string) (*TransactionRefunds, error) {
+	trx, err := s.trxRepo.GetByTransactionID(transactionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrTransactionNotFound
+		}
+		return nil, fmt.Errorf("get transaction: %w", err)
+	}
+	return s.refunds(trx, refundableAmount(trx))
+}
+
+// Review decisions on a transaction held for a duplicate serial number.
+const (
+	ReviewApprove = "approve"
+	ReviewReject  = "reject"
+)
+
+// ReviewRequest decides a transaction held for review. Reason is kept as an
+// internal note.
+type ReviewRequest struct {
+	Decision string `json:"decision" binding:"required"`
+	Reason   string `json:"reason"`
+}
+
+// reviewRejectedReason is the failedReason clients see on a rejected hold.
+const reviewRejectedReason = "Transaction rejected after review"
+
+// ReviewHeldTransaction completes (approve) or fails (reject) a transaction
+// held for review and sends the matching callback.
+func (s *AdminPPOBService) ReviewHeldTransaction(transactionID string, req ReviewRequest, reviewedBy string) (*models.Transaction, error) {
+	if req.Decision != ReviewApprove && req.Decision != ReviewReject {
+		return nil, &AdminValidationError{Message: "decision must be approve or reject"}
+	}
+	if len([]rune(req.Reason)) > maxNoteLength {
+		return nil, &AdminValidationError{Message: fmt.Sprintf("reason must be at most %d characters", maxNoteLength)}
+	}
+	trx, err := s.transactionByID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if !trx.HeldForReview() {
+		return nil, &AdminValidationError{Message: "transaction is not held for review"}
+	}
+	if err := s.trxRepo.ReleaseReviewHold(trx.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &AdminValidationError{Message: "transaction is not held for review"}
+		}
+		return nil, fmt.Errorf("release review hold: %w", err)
+	}
+
+	trx.ReviewHoldAt = nil
+	event, text := "transaction.success", "Review approved"
+	if req.Decision == ReviewApprove {
+		trx.Status = models.StatusSuccess
+	} else {
+		reason := reviewRejectedReason
+		trx.Status = models.StatusFailed
+		trx.FailedReason = &reason
+		trx.FailedCode = nil
+		event, text = "transaction.failed", "Review rejected"
+	}
+	if err := s.trxRepo.Update(trx); err != nil {
+		return nil, fmt.Errorf("update transaction: %w", err)
+	}
+
+	if reason := strings.TrimSpace(req.Reason); reason != "" {
+		text += ": " + reason
+	}
+	note := &models.TransactionNote{TransactionID: trx.ID, Note: text}
+	if reviewedBy != "" {
+		note.CreatedBy = &reviewedBy
+	}
+	if err := s.trxRepo.CreateNote(note); err != nil {
+		log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("failed to record review note")
+	}
+	log.Warn().Str("transaction_id", trx.TransactionID).Str("decision", req.Decision).
+		Str("actor", reviewedBy).Msg("Held transaction reviewed")
+
+	if s.trxSvc != nil {
+		if s.trxSvc.notifier != nil {
+			s.trxSvc.notifier.NotifyTransactionStatusChanged(trx)
+		}
+		// The serial number is already flagged, so the callback is not held again.
+		s.trxSvc.callbackSvc.QueueCallback(trx, event)
+	}
+	return trx, nil
+}
+
+// maxNoteLength caps a transaction note (characters).
+const maxNoteLength = 5000
+
+// NoteRequest is an internal note to attach to a transaction.
+type NoteRequest struct {
+	Note string `json:"note" binding:"required"`
+}
+
+// TransactionNotes is a transaction's internal note thread.
+type TransactionNotes struct {
+	TransactionID string                   `json:"transactionId"`
+	Notes         []models.TransactionNote `json:"notes"`
+}
+
+// AddTransactionNote attaches an internal note, authored by createdBy, to a
+// transaction and returns the transaction's notes.
+func (s *AdminPPOBService) AddTransactionNote(transactionID string, req NoteRequest, createdBy string) (*TransactionNotes, error) {
+	text := strings.TrimSpace(req.Note)
+	if text == "" {
+		return nil, &AdminValidationError{Message: "note is required"}
+	}
+	if len([]rune(text)) > maxNoteLength {
+		return nil, &AdminValidationError{Message: fmt.Sprintf("note must be at most %d characters", maxNoteLength)}
+	}
+	trx, err := s.transactionByID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	note := &models.TransactionNote{TransactionID: trx.ID, Note: text}
+	if createdBy != "" {
+		note.CreatedBy = &createdBy
+	}
+	if err := s.trxRepo.CreateNote(note); err != nil {
+		return nil, fmt.Errorf("create note: %w", err)
+	}
+	return s.notes(trx)
+}
+
+// ListTransactionNotes returns the internal notes of a transaction.
+func (s *AdminPPOBService) ListTransactionNotes(transactionID string) (*TransactionNotes, error) {
+	trx, err := s.transactionByID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.notes(trx)
+}
+
+// TransactionProviderResponse is the raw provider exchange stored on a
+// transaction: the response to the initial request and the latest one
+// (status check or callback), as received.
+type TransactionProviderResponse struct {
+	TransactionID     string                    `json:"transactionId"`
+	ProviderCode      *string                   `json:"providerCode,omitempty"`
+	ProviderRefID     *string                   `json:"providerRefId,omitempty"`
+	InitialHTTPStatus *int                      `json:"initialHttpStatus,omitempty"`
+	InitialResponse   models.NullableRawMessage `json:"initialResponse"`
+	HTTPStatus        *int                      `json:"httpStatus,omitempty"`
+	Response          models.NullableRawMessage `json:"response"`
+	// Error is the provider's own error code, subcode and message of the
+	// failure, as stored; failedReason stays the public one.
+	Error *models.ProviderError `json:"error,omitempty"`
+}
+
+// GetTransactionProviderResponse returns the raw provider responses stored on a transaction.
+func (s *AdminPPOBService) GetTransactionProviderResponse(transactionID string) (*TransactionProviderResponse, error) {
+	trx, err := s.transactionByID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	return &TransactionProviderResponse{
+		TransactionID:     trx.TransactionID,
+		ProviderCode:      trx.ProviderCode,
+		ProviderRefID:     trx.ProviderRefID,
+		InitialHTTPStatus: trx.ProviderInitialHTTPStatus,
+		InitialResponse:   trx.ProviderInitialResponse,
+		HTTPStatus:        trx.ProviderHTTPStatus,
+		Response:          trx.ProviderResponse,
+		Error:             trx.ProviderError,
+	}, nil
+}
+
+func (s *AdminPPOBService) notes(trx *models.Transaction) (*TransactionNotes, error) {
+	list, err := s.trxRepo.ListNotes(trx.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list notes: %w", err)
+	}
+	return &TransactionNotes{TransactionID: trx.TransactionID, Notes: list}, nil
+}
+
+func (s *AdminPPOBService) transactionByID(transactionID string) (*models.Transaction, error) {
+	trx, err := s.trxRepo.GetByTransactionID(transactionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrTransactionNotFound
+		}
+		return nil, fmt.Errorf("get transaction: %w", err)
+	}
+	return trx, nil
+}
+
+func (s *AdminPPOBService) refunds(trx *models.Transaction, amount int) (*TransactionRefunds, error) {
+	list, err := s.trxRepo.ListRefunds(trx.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list refunds: %w", err)
+	}
+	resp := &TransactionRefunds{TransactionID: trx.TransactionID, Amount: amount, Refunds: list}
+	for _, r := range list {
+		resp.RefundedAmount += r.Amount
+	}
+	return resp, nil
+}
+
+// refundableAmount is the cap for refunds on trx.
+func refundableAmount(trx *models.Transaction) int {
+	if trx.Amount != nil && *trx.Amount > 0 {
+		return *trx.Amount
+	}
+	if trx.SellPrice != nil {
+		return *trx.SellPrice
+	}
+	return 0
+}
+
+// Inquiry cache states reported by InspectInquiryCache.
+const (
+	InquiryCacheCached  = "cached"  // entry present and not past its expiry
+	InquiryCacheExpired = "expired" // entry gone (or stale) and the inquiry has expired
+	InquiryCacheAbsent  = "absent"  // no entry and no expired inquiry on record
+)
+
+// InquiryCacheStatus describes the live inquiry cache entry of a transaction.
+type InquiryCacheStatus struct {
+	TransactionID string             `json:"transactionId"`
+	State         string             `json:"state"`
+	ExpiredAt     *time.Time         `json:"expiredAt,omitempty"`
+	TTLSeconds    int                `json:"ttlSeconds"`
+	Inquiry       *cache.InquiryData `json:"inquiry,omitempty"`
+}
+
+// InspectInquiryCache reads the cached inquiry of a transaction, falling back
+// to the stored transaction to tell an expired inquiry from a missing one.
+func (s *AdminPPOBService) InspectInquiryCache(ctx context.Context, transactionID string) (*InquiryCacheStatus, error) {
+	status := &InquiryCacheStatus{TransactionID: transactionID, State: InquiryCacheAbsent}
+
+	data, err := s.inquiryCache.GetByTransactionID(ctx, transactionID)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("get inquiry cache: %w", err)
+	}
+	if data != nil {
+		status.Inquiry = data
+		status.State = InquiryCacheCached
+		if !data.ExpiredAt.IsZero() {
+			expiredAt := data.ExpiredAt
+			status.ExpiredAt = &expiredAt
+			if ttl := time.Until(expiredAt); ttl > 0 {
+				status.TTLSeconds = int(ttl.Seconds())
+			} else {
+				status.State = InquiryCacheExpired
+			}
+		}
+		return status, nil
+	}
+
+	trx, err := s.trxRepo.GetByTransactionID(transactionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return status, nil
+		}
+		return nil, fmt.Errorf("get transaction: %w", err)
+	}
+	if trx.ExpiredAt != nil {
+		status.ExpiredAt = trx.ExpiredAt
+		if !trx.ExpiredAt.After(time.Now()) {
+			status.State = InquiryCacheExpired
+		}
+	}
+	return status, nil
+}
+
+// CustomerNoRulesRequest updates a product's customer number validation rules.
+// Omitted (null) fields clear the corresponding rule.
+type CustomerNoRulesRequest struct {
+	MinLength *int    `json:"minLength"`
+	MaxLength *int    `json:"maxLength"`
+	Pattern   *string `json:"pattern"`
+}
+
+// UpdateCustomerNoRules validates and stores the customer number rules of a product.
+func (s *AdminPPOBService) UpdateCustomerNoRules(productID int, req CustomerNoRulesRequest) (*models.Product, error) {
+	if req.MinLength != nil && *req.MinLength < 0 {
+		return nil, &AdminValidationError{Message: "minLength must be >= 0"}
+	}
+	if req.MaxLength != nil && *req.MaxLength < 0 {
+		return nil, &AdminValidationError{Message: "maxLength must be >= 0"}
+	}
+	if req.MinLength != nil && req.MaxLength != nil && *req.MaxLength > 0 && *req.MinLength > *req.MaxLength {
+		return nil, &AdminValidationError{Message: "minLength must not exceed maxLength"}
+	}
+	if req.Pattern != nil && *req.Pattern == "" {
+		req.Pattern = nil
+	}
+	if req.Pattern != nil {
+		if _, err := regexp.Compile(*req.Pattern); err != nil {
+			return nil, &AdminValidationError{Message: "pattern is not a valid regular expression"}
+		}
+	}
+
+	if err := s.productRepo.UpdateCustomerNoRules(productID, req.MinLength, req.MaxLength, req.Pattern); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrInvalidSKU
+		}
+		return nil, fmt.Errorf("update customer no rules: %w", err)
+	}
+	return s.productRepo.GetByID(productID)
+}
+
+// SelectionStrategyRequest sets a product's provider ordering override.
+// A null or empty strategy restores the type default.
+type SelectionStrategyRequest struct {
+	Strategy *string `json:"strategy"`
+}
+
+// UpdateSelectionStrategy validates and stores the provider selection strategy of a product.
+func (s *AdminPPOBService) UpdateSelectionStrategy(productID int, req SelectionStrategyRequest) (*models.Product, error) {
+	if req.Strategy != nil && *req.Strategy == "" {
+		req.Strategy = nil
+	}
+	if req.Strategy != nil && !repository.ValidSelectionStrategy(*req.Strategy) {
+		return nil, &AdminValidationError{Message: "strategy must be one of price, weighted_price, effective_admin, priority"}
+	}
+
+	if err := s.productRepo.UpdateSelectionStrategy(productID, req.Strategy); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrInvalidSKU
+		}
+		return nil, fmt.Errorf("update selection strategy: %w", err)
+	}
+	return s.productRepo.GetByID(productID)
+}
+
+// DuplicateSerialHoldRequest turns a product's duplicate serial number review
+// hold on or off.
+type DuplicateSerialHoldRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// UpdateDuplicateSerialHold stores whether successes of a product with an
+// already used serial number are held for review.
+func (s *AdminPPOBService) UpdateDuplicateSerialHold(productID int, req DuplicateSerialHoldRequest) (*models.Product, error) {
+	if req.Enabled == nil {
+		return nil, &AdminValidationError{Message: "enabled is required"}
+	}
+	if err := s.productRepo.UpdateHoldDuplicateSerial(productID, *req.Enabled); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrInvalidSKU
+		}
+		return nil, fmt.Errorf("update duplicate serial hold: %w", err)
+	}
+	return s.productRepo.GetByID(productID)
+}
+
+// SimulateRoutingRequest asks which providers the router would try for a
+// product. FailProviders are treated as failing, to show the failover order.
+type SimulateRoutingRequest struct {
+	Type          string   `json:"type"`
+	FailProviders []string `json:"failProviders"`
+}
+
+// SimulateRouting returns the provider attempt order for a product without
+// executing anything.
+func (s *AdminPPOBService) SimulateRouting(ctx context.Context, productID int, req SimulateRoutingRequest) (*RoutingSimulation, error) {
+	t := ProviderTransactionType(strings.ToLower(strings.TrimSpace(req.Type)))
+	switch t {
+	case ProviderTrxPrepaid, ProviderTrxInquiry, ProviderTrxPayment:
+	default:
+		return nil, &AdminValidationError{Message: "type must be one of prepaid, inquiry, payment"}
+	}
+	if s.trxSvc == nil || s.trxSvc.providerRouter == nil {
+		return nil, &AdminValidationError{Message: "multi-provider routing is not configured"}
+	}
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrInvalidSKU
+		}
+		return nil, fmt.Errorf("get product: %w", err)
+	}
+
+	fail := make(map[models.ProviderCode]bool, len(req.FailProviders))
+	for _, code := range req.FailProviders {
+		if code = strings.ToLower(strings.TrimSpace(code)); code != "" {
+			fail[models.ProviderCode(code)] = true
+		}
+	}
+	return s.trxSvc.providerRouter.Simulate(ctx, productID, t, fail)
+}
+
+// MaintenanceWindowRequest creates or updates a provider maintenance window.
+type MaintenanceWindowRequest struct {
+	ProviderCode string    `json:"providerCode"`
+	StartsAt     time.Time `json:"startsAt" binding:"required"`
+	EndsAt       time.Time `json:"endsAt" binding:"required"`
+	Reason       *string   `json:"reason"`
+}
+
+// ProviderBalanceTrend is a provider's sampled deposit balance over time.
+type ProviderBalanceTrend struct {
+	ProviderCode string                        `json:"providerCode"`
+	Interval     string                        `json:"interval"`
+	Start        string                        `json:"start"`
+	End          string                        `json:"end"`
+	Change       int64                         `json:"change"` // last sample minus first sample
+	Points       []models.ProviderBalancePoint `json:"points"`
+}
+
+// GetProviderBalanceTrend returns the provider's balance history between start
+// and end (YYYY-MM-DD, inclusive, WIB; default the last 7 days) grouped by
+// interval ("hour" or "day", default "day").
+func (s *AdminPPOBService) GetProviderBalanceTrend(providerCode, start, end, interval string) (*ProviderBalanceTrend, error) {
+	if interval == "" {
+		interval = "day"
+	}
+	if interval != "hour" && interval != "day" {
+		return nil, &AdminValidationError{Message: "interval must be hour or day"}
+	}
+	provider, err := s.providerByCode(providerCode)
+	if err != nil {
+		return nil, err
+	}
+
+	wib := time.FixedZone("WIB", 7*3600)
+	if end == "" {
+		end = time.Now().In(wib).Format("2006-01-02")
+	}
+	endDay, _ := time.ParseInLocation("2006-01-02", end, wib)
+	if start == "" {
+		start = endDay.AddDate(0, 0, -6).Format("2006-01-02")
+	}
+	startDay, _ := time.ParseInLocation("2006-01-02", start, wib)
+	if endDay.Before(startDay) {
+		return nil, &AdminValidationError{Message: "end must not be before start"}
+	}
+
+	points, err := s.providerRepo.GetProviderBalanceTrend(provider.ID, interval, startDay, endDay.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("get provider balance trend: %w", err)
+	}
+	if points == nil {
+		points = []models.ProviderBalancePoint{}
+	}
+
+	trend := &ProviderBalanceTrend{
+		ProviderCode: string(provider.Code),
+		Interval:     interval,
+		Start:        start,
+		End:          end,
+		Points:       points,
+	}
+	if len(points) > 1 {
+		trend.Change = points[len(points)-1].Last - points[0].Last
+	}
+	return trend, nil
+}
+
+// ProviderCutOffRequest sets a provider's daily WIB cut-off window. Times are
+// HH:MM or HH:MM:SS; end before start crosses midnight, both empty clears it.
+type ProviderCutOffRequest struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// UpdateProviderCutOff stores when, every day, routing should skip a provider.
+func (s *AdminPPOBService) UpdateProviderCutOff(providerID int, req ProviderCutOffRequest) (*models.PPOBProvider, error) {
+	start, end, err := normalizeCutOff(req.Start, req.End)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.providerRepo.UpdateProviderCutOff(providerID, start, end); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAdminNotFound
+		}
+		return nil, fmt.Errorf("update provider cut-off: %w", err)
+	}
+	return s.providerRepo.GetProviderByID(providerID)
+}
+
+// normalizeCutOff validates a cut-off window and returns it as HH:MM:SS.
+func normalizeCutOff(start, end string) (string, string, error) {
+	start, end = strings.TrimSpace(start), strings.TrimSpace(end)
+	if start == "" && end == "" {
+		return "00:00:00", "00:00:00", nil
+	}
+	parse := func(v string) (string, bool) {
+		for _, layout := range []string{"15:04:05", "15:04"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t.Format("15:04:05"), true
+			}
+		}
+		return "", false
+	}
+	from, okStart := parse(start)
+	to, okEnd := parse(end)
+	if !okStart || !okEnd {
+		return "", "", &AdminValidationError{Message: "start and end must be HH:MM or HH:MM:SS"}
+	}
+	if from == to {
+		return "", "", &AdminValidationError{Message: "start and end must differ"}
+	}
+	return from, to, nil
+}
+
+// ProviderSKUReverseLookup is the products a provider SKU code maps to.
+type ProviderSKUReverseLookup struct {
+	ProviderID       int                                  `json:"providerId"`
+	ProviderCode     models.ProviderCode                  `json:"providerCode"`
+	ProviderSKUCode  string                               `json:"providerSkuCode"`
+	Mappings         []models.PPOBProviderSKU             `json:"mappings"`
+	MultipleProducts bool                                 `json:"multipleProducts"` // code maps to more than one product: misconfigured
+	Conflicts        []repository.ProviderSKUCodeConflict `json:"conflicts"`        // every such code of the provider
+}
+
+// ReverseProviderSKU finds the products providerID's SKU code maps to, and
+// lists all of the provider's codes mapped to more than one product, which
+// would fulfil a different product than the one ordered.
+func (s *AdminPPOBService) ReverseProviderSKU(providerID int, code string) (*ProviderSKUReverseLookup, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, &AdminValidationError{Message: "code is required"}
+	}
+	provider, err := s.skuCodes.GetProviderByID(providerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAdminNotFound
+		}
+		return nil, fmt.Errorf("get provider: %w", err)
+	}
+	mappings, err := s.skuCodes.GetProviderSKUsByCode(providerID, code)
+	if err != nil {
+		return nil, fmt.Errorf("get provider skus by code: %w", err)
+	}
+	conflicts, err := s.skuCodes.GetProviderSKUCodeConflicts(providerID)
+	if err != nil {
+		return nil, fmt.Errorf("get provider sku code conflicts: %w", err)
+	}
+
+	lookup := &ProviderSKUReverseLookup{
+		ProviderID:      provider.ID,
+		ProviderCode:    provider.Code,
+		ProviderSKUCode: code,
+		Mappings:        mappings,
+		Conflicts:       conflicts,
+	}
+	if lookup.Mappings == nil {
+		lookup.Mappings = []models.PPOBProviderSKU{}
+	}
+	if lookup.Conflicts == nil {
+		lookup.Conflicts = []repository.ProviderSKUCodeConflict{}
+	}
+	products := make(map[int]bool, len(mappings))
+	for _, m := range mappings {
+		products[m.ProductID] = true
+	}
+	lookup.MultipleProducts = len(products) > 1
+	return lookup, nil
+}
+
+// ListMaintenanceWindows lists maintenance windows, optionally for one provider
+// and only those not yet ended.
+func (s *AdminPPOBService) ListMaintenanceWindows(providerCode string, upcomingOnly bool) ([]models.PPOBProviderMaintenanceWindow, error) {
+	providerID := 0
+	if providerCode != "" {
+		provider, err := s.providerByCode(providerCode)
+		if err != nil {
+			return nil, err
+		}
+		providerID = provider.ID
+	}
+	windows, err := s.providerRepo.ListMaintenanceWindows(providerID, upcomingOnly)
+	if err != nil {
+		return nil, fmt.Errorf("list maintenance windows: %w", err)
+	}
+	return windows, nil
+}
+
+// CreateMaintenanceWindow schedules a maintenance window for a provider.
+func (s *AdminPPOBService) CreateMaintenanceWindow(req MaintenanceWindowRequest) (*models.PPOBProviderMaintenanceWindow, error) {
+	if !req.EndsAt.After(req.StartsAt) {
+		return nil, &AdminValidationError{Message: "endsAt must be after startsAt"}
+	}
+	provider, err := s.providerByCode(req.ProviderCode)
+	if err != nil {
+		return nil, err
+	}
+	w := &models.PPOBProviderMaintenanceWindow{
+		ProviderID:   provider.ID,
+		StartsAt:     req.StartsAt,
+		EndsAt:       req.EndsAt,
+		Reason:       req.Reason,
+		ProviderCode: provider.Code,
+	}
+	if err := s.providerRepo.CreateMaintenanceWindow(w); err != nil {
+		return nil, fmt.Errorf("create maintenance window: %w", err)
+	}
+	return w, nil
+}
+
+// UpdateMaintenanceWindow changes the range or reason of a maintenance window.
+func (s *AdminPPOBService) UpdateMaintenanceWindow(id int, req MaintenanceWindowRequest) (*models.PPOBProviderMaintenanceWindow, error) {
+	if !req.EndsAt.After(req.StartsAt) {
+		return nil, &AdminValidationError{Message: "endsAt must be after startsAt"}
+	}
+	w, err := s.providerRepo.GetMaintenanceWindow(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAdminNotFound
+		}
+		return nil, fmt.Errorf("get maintenance window: %w", err)
+	}
+	w.StartsAt, w.EndsAt, w.Reason = req.StartsAt, req.EndsAt, req.Reason
+	if err := s.providerRepo.UpdateMaintenanceWindow(w); err != nil {
+		return nil, fmt.Errorf("update maintenance window: %w", err)
+	}
+	return w, nil
+}
+
+// DeleteMaintenanceWindow removes a maintenance window.
+func (s *AdminPPOBService) DeleteMaintenanceWindow(id int) error {
+	if err := s.providerRepo.DeleteMaintenanceWindow(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAdminNotFound
+		}
+		return fmt.Errorf("delete maintenance window: %w", err)
+	}
+	return nil
+}
+
+// ListFailedProviderCallbacks pages provider callbacks whose processing
+// failed; exhaustedOnly keeps those the retry worker has given up on.
+func (s *AdminPPOBService) ListFailedProviderCallbacks(exhaustedOnly bool, page, limit int) ([]repository.FailedProviderCallback, int, error) {
+	callbacks, total, err := s.providerRepo.ListFailedCallbacks(exhaustedOnly, page, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list failed provider callbacks: %w", err)
+	}
+	return callbacks, total, nil
+}
+
+// RCOverrideRequest sets the outcome forced for one RC on a SKU.
+type RCOverrideRequest struct {
+	Outcome string  `json:"outcome" binding:"required"`
+	Note    *string `json:"note"`
+}
+
+// ListSKURCOverrides lists the RC overrides of a SKU.
+func (s *AdminPPOBService) ListSKURCOverrides(skuID int) ([]models.SKURCOverride, error) {
+	if _, err := s.skuByID(skuID); err != nil {
+		return nil, err
+	}
+	overrides, err := s.skuRepo.ListRCOverrides(skuID)
+	if err != nil {
+		return nil, fmt.Errorf("list sku rc overrides: %w", err)
+	}
+	return overrides, nil
+}
+
+// SetSKURCOverride creates or replaces the override of rc on a SKU.
+func (s *AdminPPOBService) SetSKURCOverride(skuID int, rc string, req RCOverrideRequest) (*models.SKURCOverride, error) {
+	rc = strings.TrimSpace(rc)
+	if rc == "" || len(rc) > 10 {
+		return nil, &AdminValidationError{Message: "rc must be 1-10 characters"}
+	}
+	if !models.ValidRCOutcome(req.Outcome) {
+		return nil, &AdminValidationError{Message: "outcome must be fatal or switch_sku"}
+	}
+	if digiflazz.IsSuccess(rc) || digiflazz.IsPending(rc) {
+		return nil, &AdminValidationError{Message: "success and pending RCs cannot be overridden"}
+	}
+	if _, err := s.skuByID(skuID); err != nil {
+		return nil, err
+	}
+	o := &models.SKURCOverride{SkuID: skuID, RC: rc, Outcome: req.Outcome, Note: req.Note}
+	if err := s.skuRepo.UpsertRCOverride(o); err != nil {
+		return nil, fmt.Errorf("upsert sku rc override: %w", err)
+	}
+	return o, nil
+}
+
+// DeleteSKURCOverride removes the override of rc on a SKU.
+func (s *AdminPPOBService) DeleteSKURCOverride(skuID int, rc string) error {
+	if err := s.skuRepo.DeleteRCOverride(skuID, rc); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAdminNotFound
+		}
+		return fmt.Errorf("delete sku rc override: %w", err)
+	}
+	return nil
+}
+
+func (s *AdminPPOBService) skuByID(id int) (*models.SKU, error) {
+	sku, err := s.skuRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAdminNotFound
+		}
+		return nil, fmt.Errorf("get sku: %w", err)
+	}
+	return sku, nil
+}
+
+func (s *AdminPPOBService) providerByCode(code string) (*models.PPOBProvider, error) {
+	if code == "" {
+		return nil, &AdminValidationError{Message: "providerCode is required"}
+	}
+	provider, err := s.providerRepo.GetProviderByCode(models.ProviderCode(code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &AdminValidationError{Message: "unknown providerCode " + code}
+		}
+		return nil, fmt.Errorf("get provider: %w", err)
+	}
+	return provider, nil
+}
+
+// percentage returns part/total as a percentage rounded to two decimals.
+func percentage(part, total int) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return math.Round(float64(part)*10000/float64(total)) / 100
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/repository"
+)
+
+func TestPercentage(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		part, total int
+		want        float64
+	}{
+		{0, 0, 0},
+		{5, 0, 0},
+		{1, 3, 33.33},
+		{2, 3, 66.67},
+		{10, 10, 100},
+	}
+	for _, tc := range cases {
+		if got := percentage(tc.part, tc.total); got != tc.want {
+			t.Fatalf("percentage(%d, %d) = %v, want %v", tc.part, tc.total, got, tc.want)
+		}
+	}
+}
+
+func TestProviderUsageShare(t *testing.T) {
+	t.Parallel()
+
+	counts := []repository.ProviderUsageCount{
+		{ProviderCode: "kiosbank", Count: 6},
+		{ProviderCode: "digiflazz", Count: 3},
+		{ProviderCode: "unknown", Count: 1},
+	}
+	providers := []models.PPOBProvider{
+		{Code: models.ProviderKiosbank},
+		{Code: models.ProviderAlterra, IsBackup: true},
+	}
+	resp := providerUsageShare(counts, providers)
+	if resp.Total != 10 {
+		t.Fatalf("total = %d, want 10", resp.Total)
+	}
+	want := []ProviderUsageShare{
+		{ProviderCode: "kiosbank", Count: 6, Percentage: 60},
+		{ProviderCode: "digiflazz", IsBackup: true, Count: 3, Percentage: 30},
+		{ProviderCode: "unknown", Count: 1, Percentage: 10},
+	}
+	if len(resp.Providers) != len(want) {
+		t.Fatalf("providers = %+v, want %+v", resp.Providers, want)
+	}
+	for i, w := range want {
+		if resp.Providers[i] != w {
+			t.Errorf("provider %d = %+v, want %+v", i, resp.Providers[i], w)
+		}
+	}
+
+	if empty := providerUsageShare(nil, nil); empty.Total != 0 || empty.Providers == nil {
+		t.Fatalf("no counts = %+v, want zero total and an empty list", empty)
+	}
+}
+
+func TestRefundableAmount(t *testing.T) {
+	t.Parallel()
+
+	amount, sell := 50000, 51000
+	if got := refundableAmount(&models.Transaction{Amount: &amount, SellPrice: &sell}); got != amount {
+		t.Fatalf("got %d, want amount %d", got, amount)
+	}
+	if got := refundableAmount(&models.Transaction{SellPrice: &sell}); got != sell {
+		t.Fatalf("got %d, want sell price %d", got, sell)
+	}
+	if got := refundableAmount(&models.Transaction{}); got != 0 {
+		t.Fatalf("got %d, want 0", got)
+	}
+}
+
+func TestSetSKURCOverrideValidation(t *testing.T) {
+	t.Parallel()
+
+	svc := &AdminPPOBService{}
+	cases := []struct {
+		name string
+		rc   string
+		req  RCOverrideRequest
+	}{
+		{"empty rc", " ", RCOverrideRequest{Outcome: models.RCOutcomeFatal}},
+		{"unknown outcome", "40", RCOverrideRequest{Outcome: "retry"}},
+		{"success rc", "00", RCOverrideRequest{Outcome: models.RCOutcomeFatal}},
+		{"pending rc", "03", RCOverrideRequest{Outcome: models.RCOutcomeSwitchSKU}},
+	}
+	for _, tc := range cases {
+		_, err := svc.SetSKURCOverride(1, tc.rc, tc.req)
+		if _, ok := err.(*AdminValidationError); !ok {
+			t.Fatalf("%s: want validation error, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestAddTransactionNoteValidation(t *testing.T) {
+	t.Parallel()
+
+	svc := &AdminPPOBService{}
+	for _, note := range []string{"", "   ", strings.Repeat("x", maxNoteLength+1)} {
+		_, err := svc.AddTransactionNote("GRB-20260101-000001", NoteRequest{Note: note}, "ops@example.com")
+		var ve *AdminValidationError
+		if !errors.As(err, &ve) {
+			t.Fatalf("note of length %d: got %v, want validation error", len(note), err)
+		}
+	}
+}
+
+func TestReviewHeldTransactionValidation(t *testing.T) {
+	t.Parallel()
+
+	svc := &AdminPPOBService{}
+	cases := []ReviewRequest{
+		{Decision: ""},
+		{Decision: "approved"},
+		{Decision: ReviewReject, Reason: strings.Repeat("x", maxNoteLength+1)},
+	}
+	for _, req := range cases {
+		_, err := svc.ReviewHeldTransaction("GRB-20260101-000001", req, "ops@example.com")
+		var ve *AdminValidationError
+		if !errors.As(err, &ve) {
+			t.Fatalf("decision %q: got %v, want validation error", req.Decision, err)
+		}
+	}
+}
+
+func TestNormalizeCutOff(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		start, end         string
+		wantStart, wantEnd string
+		wantErr            bool
+	}{
+		{"", "", "00:00:00", "00:00:00", false},
+		{"23:00", "05:30", "23:00:00", "05:30:00", false},
+		{"01:00:00", "04:00:00", "01:00:00", "04:00:00", false},
+		{"23:00", "", "", "", true},
+		{"25:00", "05:00", "", "", true},
+		{"08:00", "08:00:00", "", "", true},
+	}
+	for _, tc := range cases {
+		start, end, err := normalizeCutOff(tc.start, tc.end)
+		var ve *AdminValidationError
+		if tc.wantErr != errors.As(err, &ve) {
+			t.Fatalf("normalizeCutOff(%q, %q) err = %v, wantErr %v", tc.start, tc.end, err, tc.wantErr)
+		}
+		if start != tc.wantStart || end != tc.wantEnd {
+			t.Fatalf("normalizeCutOff(%q, %q) = %q, %q, want %q, %q", tc.start, tc.end, start, end, tc.wantStart, tc.wantEnd)
+		}
+	}
+}
+
+// fakeSKUCodes is a providerSKUCodeStore holding one provider's mappings.
+type fakeSKUCodes struct {
+	provider  *models.PPOBProvider
+	mappings  []models.PPOBProviderSKU
+	conflicts []repository.ProviderSKUCodeConflict
+	err       error
+}
+
+func (f *fakeSKUCodes) GetProviderByID(id int) (*models.PPOBProvider, error) {
+	if f.provider == nil || f.provider.ID != id {
+		return nil, sql.ErrNoRows
+	}
+	return f.provider, nil
+}
+
+func (f *fakeSKUCodes) GetProviderSKUsByCode(providerID int, code string) ([]models.PPOBProviderSKU, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var out []models.PPOBProviderSKU
+	for _, m := range f.mappings {
+		if m.ProviderID == providerID && m.ProviderSKUCode == code {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSKUCodes) GetProviderSKUCodeConflicts(int) ([]repository.ProviderSKUCodeConflict, error) {
+	return f.conflicts, f.err
+}
+
+func TestReverseProviderSKU(t *testing.T) {
+	t.Parallel()
+
+	provider := &models.PPOBProvider{ID: 2, Code: models.ProviderKiosbank}
+	mappings := []models.PPOBProviderSKU{
+		{ID: 1, ProviderID: 2, ProductID: 10, ProviderSKUCode: "PLN20", SkuCode: "PLN20"},
+		{ID: 2, ProviderID: 2, ProductID: 11, ProviderSKUCode: "TSEL10", SkuCode: "TSEL10"},
+		{ID: 3, ProviderID: 2, ProductID: 12, ProviderSKUCode: "TSEL10", SkuCode: "TSEL10-PROMO"},
+	}
+	conflicts := []repository.ProviderSKUCodeConflict{
+		{ProviderSKUCode: "TSEL10", ProductIDs: []int64{11, 12}, SkuCodes: []string{"TSEL10", "TSEL10-PROMO"}},
+	}
+	store := &fakeSKUCodes{provider: provider, mappings: mappings, conflicts: conflicts}
+
+	cases := []struct {
+		name         string
+		store        *fakeSKUCodes
+		providerID   int
+		code         string
+		wantMappings int
+		wantMultiple bool
+		wantErr      func(error) bool
+	}{
+		{"one product", store, 2, "PLN20", 1, false, nil},
+		{"several products", store, 2, " TSEL10 ", 2, true, nil},
+		{"unmapped code", store, 2, "XL5", 0, false, nil},
+		{"blank code", store, 2, " ", 0, false, func(err error) bool {
+			var ve *AdminValidationError
+			return errors.As(err, &ve)
+		}},
+		{"unknown provider", store, 3, "PLN20", 0, false, func(err error) bool { return errors.Is(err, ErrAdminNotFound) }},
+		{"store failure", &fakeSKUCodes{provider: provider, err: errors.New("db down")}, 2, "PLN20", 0, false,
+			func(err error) bool { return err != nil && !errors.Is(err, ErrAdminNotFound) }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &AdminPPOBService{skuCodes: tc.store}
+			got, err := svc.ReverseProviderSKU(tc.providerID, tc.code)
+			if tc.wantErr != nil {
+				if !tc.wantErr(err) {
+					t.Fatalf("err = %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if got.ProviderCode != models.ProviderKiosbank || got.ProviderSKUCode != strings.TrimSpace(tc.code) {
+				t.Fatalf("lookup = %s/%q", got.ProviderCode, got.ProviderSKUCode)
+			}
+			if len(got.Mappings) != tc.wantMappings || got.Mappings == nil {
+				t.Fatalf("mappings = %#v, want %d", got.Mappings, tc.wantMappings)
+			}
+			if got.MultipleProducts != tc.wantMultiple {
+				t.Fatalf("multipleProducts = %v, want %v", got.MultipleProducts, tc.wantMultiple)
+			}
+			if len(got.Conflicts) != 1 || got.Conflicts[0].ProviderSKUCode != "TSEL10" {
+				t.Fatalf("conflicts = %+v, want the provider's TSEL10 conflict", got.Conflicts)
+			}
+		})
+	}
+
+	empty, err := (&AdminPPOBService{skuCodes: &fakeSKUCodes{provider: provider}}).ReverseProviderSKU(2, "PLN20")
+	if err != nil || empty.Mappings == nil || empty.Conflicts == nil {
+		t.Fatalf("no mappings: %+v, %v; want empty, non-nil lists", empty, err)
+	}
+}
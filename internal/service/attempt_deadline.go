@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errAttemptDeadline signals that the synchronous attempt budget of a request
+// ran out; the transaction stays Processing and is completed asynchronously.
+var errAttemptDeadline = errors.New("transaction attempt deadline exceeded")
+
+// errAttemptLimit signals that the synchronous provider attempt limit was hit
+// with providers left; those are tried asynchronously by the retry worker.
+var errAttemptLimit = errors.New("synchronous provider attempt limit reached")
+
+type attemptDeadlineKey struct{}
+
+type attemptLimitKey struct{}
+
+// withAttemptDeadline attaches a soft deadline for starting new provider
+// attempts. Unlike context.WithTimeout it never cancels an in-flight provider
+// call, which could otherwise leave a charged-but-unrecorded top-up.
+func withAttemptDeadline(ctx context.Context, timeout time.Duration) context.Context {
+	if timeout <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, attemptDeadlineKey{}, time.Now().Add(timeout))
+}
+
+// attemptDeadlineExceeded reports whether the attempt deadline attached to ctx has passed.
+func attemptDeadlineExceeded(ctx context.Context) bool {
+	deadline, ok := ctx.Value(attemptDeadlineKey{}).(time.Time)
+	return ok && !time.Now().Before(deadline)
+}
+
+// withAttemptLimit caps how many providers the router tries before handing the
+// rest to async continuation. n <= 0 leaves it unlimited.
+func withAttemptLimit(ctx context.Context, n int) context.Context {
+	if n <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, attemptLimitKey{}, n)
+}
+
+// attemptLimitReached reports whether attempts already made use up the limit
+// attached to ctx.
+func attemptLimitReached(ctx context.Context, attempts int) bool {
+	n, ok := ctx.Value(attemptLimitKey{}).(int)
+	return ok && attempts >= n
+}
+
+// waitBeforeRetry sleeps for d unless ctx is canceled (ctx.Err()) or the wait
+// would end past the attempt deadline (errAttemptDeadline, returned at once).
+func waitBeforeRetry(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Value(attemptDeadlineKey{}).(time.Time); ok && time.Now().Add(d).After(deadline) {
+		return errAttemptDeadline
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
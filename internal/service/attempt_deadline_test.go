@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+func TestAttemptDeadline(t *testing.T) {
+	if attemptDeadlineExceeded(context.Background()) {
+		t.Fatal("no deadline attached, should not be exceeded")
+	}
+	if ctx := withAttemptDeadline(context.Background(), 0); attemptDeadlineExceeded(ctx) {
+		t.Fatal("zero timeout disables the deadline")
+	}
+
+	ctx := withAttemptDeadline(context.Background(), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if !attemptDeadlineExceeded(ctx) {
+		t.Fatal("deadline should be exceeded")
+	}
+	if ctx.Err() != nil {
+		t.Fatal("attempt deadline must not cancel the context")
+	}
+}
+
+func TestWaitBeforeRetryAbandonsPastDeadline(t *testing.T) {
+	ctx := withAttemptDeadline(context.Background(), time.Second)
+	start := time.Now()
+	if err := waitBeforeRetry(ctx, time.Minute); !errors.Is(err, errAttemptDeadline) {
+		t.Fatalf("got %v, want errAttemptDeadline", err)
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Fatal("wait that cannot finish before the deadline should return immediately")
+	}
+	if err := waitBeforeRetry(ctx, time.Millisecond); err != nil {
+		t.Fatalf("short wait within deadline: %v", err)
+	}
+}
+
+func TestAttemptLimit(t *testing.T) {
+	if attemptLimitReached(context.Background(), 10) {
+		t.Fatal("no limit attached, should never be reached")
+	}
+	if ctx := withAttemptLimit(context.Background(), 0); attemptLimitReached(ctx, 10) {
+		t.Fatal("zero limit tries every provider")
+	}
+
+	ctx := withAttemptLimit(context.Background(), 2)
+	if attemptLimitReached(ctx, 1) {
+		t.Fatal("one attempt of two should not reach the limit")
+	}
+	if !attemptLimitReached(ctx, 2) {
+		t.Fatal("two attempts of two should reach the limit")
+	}
+}
+
+// A deadline stop clears the failed attempt's ref and leaves the rest to
+// continueRemainingSKUs, which skips used-up SKUs and sends fresh ref_ids.
+func TestRemainingSKUsAfterDeadline(t *testing.T) {
+	s := &TransactionService{}
+	skus := []models.SKU{{ID: 1}, {ID: 2}, {ID: 3}}
+	id := func(n int) *int { return &n }
+	rc := func(v string) *string { return &v }
+
+	cases := []struct {
+		name       string
+		logs       []models.TransactionLog
+		wantIDs    []int
+		wantSuffix int
+	}{
+		{"nothing tried", nil, []int{1, 2, 3}, 0},
+		{"first SKU failed", []models.TransactionLog{
+			{SkuID: id(1), DigiRefID: "GRB-20260101-000001", RC: rc("44")},
+		}, []int{2, 3}, 1},
+		{"network errors use up the SKU", []models.TransactionLog{
+			{SkuID: id(1), DigiRefID: "GRB-20260101-000001", RC: rc("44")},
+			{SkuID: id(2), DigiRefID: "GRB-20260101-000001-1"},
+			{SkuID: id(2), DigiRefID: "GRB-20260101-000001-1"},
+		}, []int{3}, 2},
+		{"rate limited SKU is retried", []models.TransactionLog{
+			{SkuID: id(1), DigiRefID: "GRB-20260101-000001-3", RC: rc("85")},
+		}, []int{1, 2, 3}, 4},
+	}
+	for _, tc := range cases {
+		got, suffix := s.remainingSKUs(skus, tc.logs)
+		var ids []int
+		for _, sku := range got {
+			ids = append(ids, sku.ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(tc.wantIDs) || suffix != tc.wantSuffix {
+			t.Errorf("%s: remaining = %v, suffix %d; want %v, suffix %d", tc.name, ids, suffix, tc.wantIDs, tc.wantSuffix)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/repository"
+)
+
+const (
+	// callbackBatchEvent is the X-GTD-Event of a batch; each element of the
+	// JSON array body carries its own event.
+	callbackBatchEvent         = "transaction.batch"
+	callbackBatchLockKeyPrefix = "ppob:callback_batch_lock:client:"
+	maxCallbackBatchSize       = 100
+	maxCallbackFlushBatches    = 10 // per flush; the callback worker sends any rest
+	defaultCallbackBatchWait   = 5 * time.Second
+)
+
+// callbackLogStore is the part of the callback repository that batching,
+// queued and retried delivery attempts and client acknowledgments use.
+type callbackLogStore interface {
+	CreateCallbackLog(log *models.CallbackLog) error
+	UpdateCallbackLog(log *models.CallbackLog) error
+	GetBatchableCallbacks(clientID, limit int) ([]models.CallbackLog, error)
+	CountBatchableCallbacks(clientID int) (int, error)
+	AcknowledgeClientCallback(id, clientID int) (*repository.ClientCallbackDelivery, error)
+	ClaimCallbackLog(id int, lease time.Duration) (bool, error)
+}
+
+// callbackBatchSize is the client's callback batch size, 0 when it takes
+// each event on its own.
+func callbackBatchSize(client *models.Client) int {
+	switch n := client.CallbackBatchSize; {
+	case n <= 0:
+		return 0
+	case n > maxCallbackBatchSize:
+		return maxCallbackBatchSize
+	default:
+		return n
+	}
+}
+
+// SetCallbackBatchWait sets how long a batching client's callback waits for
+// its batch to fill before the batch is sent anyway.
+func (s *CallbackService) SetCallbackBatchWait(d time.Duration) {
+	if d > 0 {
+		s.batchWait = d
+	}
+}
+
+// batchCallback logs a batching client's callback unsent and sends the
+// client's batch once it is full, or batchWait later. The log is due at that
+// time too, so the callback worker sends it should this instance stop first.
+func (s *CallbackService) batchCallback(trx *models.Transaction, client *models.Client, event string, payload []byte) {
+	if !s.logBatchedCallback(trx, client, event, payload) {
+		return
+	}
+	n, err := s.logs.CountBatchableCallbacks(client.ID)
+	if err != nil {
+		log.Error().Err(err).Int("client_id", client.ID).Msg("failed to count batched callbacks")
+	}
+	if err == nil && n >= callbackBatchSize(client) {
+		s.flushCallbacks(client)
+		return
+	}
+	s.scheduleCallbackFlush(client.ID)
+}
+
+// logBatchedCallback logs a batching client's callback unsent, due batchWait
+// from now, and reports whether it did.
+func (s *CallbackService) logBatchedCallback(trx *models.Transaction, client *models.Client, event string, payload []byte) bool {
+	due := time.Now().Add(s.batchWait)
+	logEntry := &models.CallbackLog{
+		TransactionID: &trx.ID,
+		ClientID:      client.ID,
+		Event:         event,
+		Payload:       json.RawMessage(payload),
+		NextRetryAt:   &due,
+	}
+	if err := s.logs.CreateCallbackLog(logEntry); err != nil {
+		log.Error().Err(err).Msg("failed to create callback log")
+		return false
+	}
+	return true
+}
+
+// scheduleCallbackFlush flushes the client's batch batchWait from now unless
+// a flush is already scheduled.
+func (s *CallbackService) scheduleCallbackFlush(clientID int) {
+	s.batchMu.Lock()
+	defer s.batchMu.Unlock()
+	if _, ok := s.batchTimers[clientID]; ok {
+		return
+	}
+	s.batchTimers[clientID] = time.AfterFunc(s.batchWait, func() {
+		s.batchMu.Lock()
+		delete(s.batchTimers, clientID)
+		s.batchMu.Unlock()
+		s.FlushCallbacks(clientID)
+	})
+}
+
+// FlushCallbacks sends a batching client's queued and due callbacks in
+// batches of its batch size. Batches of one client are sent one at a time.
+func (s *CallbackService) FlushCallbacks(clientID int) {
+	client, err := s.clientRepo.GetByID(clientID)
+	if err != nil || client == nil || client.CallbackURL == "" {
+		return
+	}
+	s.flushCallbacks(client)
+}
+
+func (s *CallbackService) flushCallbacks(client *models.Client) {
+	clientID := client.ID
+	size := callbackBatchSize(client)
+	if size == 0 {
+		return // batching turned off; the callback worker sends them one by one
+	}
+	for i := 0; i < maxCallbackFlushBatches; i++ {
+		release := s.orderLock.acquireKey(callbackBatchLockKeyPrefix+strconv.Itoa(clientID), callbackLockWait)
+		if release == nil {
+			return // still busy; the callback worker picks the rest up when due
+		}
+		n, err := s.deliverCallbackBatch(client, size)
+		release()
+		if err != nil {
+			log.Error().Err(err).Int("client_id", clientID).Msg("failed to deliver callback batch")
+			return
+		}
+		if n < size {
+			return
+		}
+	}
+}
+
+// deliverCallbackBatch sends up to size of the client's batchable callbacks
+// as one signed JSON array and records the attempt on each of them, so every
+// event keeps its own retry schedule. It returns how many were sent.
+func (s *CallbackService) deliverCallbackBatch(client *models.Client, size int) (int, error) {
+	batch, err := s.logs.GetBatchableCallbacks(client.ID, size)
+	if err != nil || len(batch) == 0 {
+		return 0, err
+	}
+	statusCode, respBody, delivered, err := s.postCallback(client, callbackBatchBody(batch), callbackBatchEvent)
+	if err != nil {
+		return 0, err
+	}
+	for i := range batch {
+		s.recordCallbackAttempt(&batch[i], statusCode, respBody, delivered)
+	}
+	log.Info().Int("client_id", client.ID).Int("events", len(batch)).Bool("delivered", delivered).Msg("Callback batch sent")
+	return len(batch), nil
+}
+
+// callbackBatchBody is the JSON array of the batch's payloads, in order.
+func callbackBatchBody(batch []models.CallbackLog) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, cb := range batch {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(cb.Payload)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes()
+}
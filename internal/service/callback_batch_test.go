@@ -0,0 +1,299 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/repository"
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+func TestCallbackBatchBody(t *testing.T) {
+	batch := []models.CallbackLog{
+		{Payload: json.RawMessage(`{"event":"transaction.success","data":{"transactionId":"GRB-1"}}`)},
+		{Payload: json.RawMessage(`{"event":"transaction.failed","data":{"transactionId":"GRB-2"}}`)},
+	}
+	body := callbackBatchBody(batch)
+
+	var events []struct {
+		Event string `json:"event"`
+	}
+	if err := json.Unmarshal(body, &events); err != nil {
+		t.Fatalf("batch body is not a JSON array: %v (%s)", err, body)
+	}
+	if len(events) != 2 || events[0].Event != "transaction.success" || events[1].Event != "transaction.failed" {
+		t.Fatalf("events = %+v, want both in order", events)
+	}
+
+	client := &models.Client{CallbackSecret: "secret"}
+	req, err := newCallbackRequest(client, body, callbackBatchEvent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(&CallbackService{}).VerifySignature(client, body, req.Header.Get("X-GTD-Signature")) {
+		t.Fatal("batch signature should verify over the array body")
+	}
+	if got := req.Header.Get("X-GTD-Event"); got != "transaction.batch" {
+		t.Fatalf("X-GTD-Event = %q, want transaction.batch", got)
+	}
+}
+
+func TestCallbackBatchSize(t *testing.T) {
+	for in, want := range map[int]int{-1: 0, 0: 0, 25: 25, 500: maxCallbackBatchSize} {
+		if got := callbackBatchSize(&models.Client{CallbackBatchSize: in}); got != want {
+			t.Errorf("callbackBatchSize(%d) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+// memCallbackLogs is a callbackLogStore with the repository's batchable
+// filter and acknowledgment rules.
+type memCallbackLogs struct {
+	mu   sync.Mutex
+	logs []models.CallbackLog
+}
+
+func (m *memCallbackLogs) CreateCallbackLog(cb *models.CallbackLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cb.ID = len(m.logs) + 1
+	m.logs = append(m.logs, *cb)
+	return nil
+}
+
+func (m *memCallbackLogs) UpdateCallbackLog(cb *models.CallbackLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	updated := *cb
+	if acked := m.logs[cb.ID-1].AcknowledgedAt; acked != nil {
+		updated.AcknowledgedAt, updated.IsDelivered, updated.NextRetryAt = acked, true, nil
+	}
+	m.logs[cb.ID-1] = updated
+	return nil
+}
+
+func (m *memCallbackLogs) AcknowledgeClientCallback(id, clientID int) (*repository.ClientCallbackDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id < 1 || id > len(m.logs) || m.logs[id-1].ClientID != clientID {
+		return nil, sql.ErrNoRows
+	}
+	cb := &m.logs[id-1]
+	if cb.AcknowledgedAt == nil {
+		now := time.Now()
+		cb.AcknowledgedAt = &now
+	}
+	cb.IsDelivered, cb.NextRetryAt = true, nil
+	return &repository.ClientCallbackDelivery{ID: cb.ID, Event: cb.Event, IsDelivered: true, Attempts: cb.Attempt, AcknowledgedAt: cb.AcknowledgedAt}, nil
+}
+
+func (m *memCallbackLogs) ClaimCallbackLog(id int, lease time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cb := &m.logs[id-1]
+	if cb.IsDelivered || cb.NextRetryAt == nil || !cb.NextRetryAt.After(time.Now()) {
+		return false, nil
+	}
+	until := time.Now().Add(lease)
+	cb.NextRetryAt = &until
+	return true, nil
+}
+
+func (m *memCallbackLogs) GetBatchableCallbacks(clientID, limit int) ([]models.CallbackLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.CallbackLog
+	for _, cb := range m.logs {
+		if len(out) < limit && m.batchable(cb, clientID) {
+			out = append(out, cb)
+		}
+	}
+	return out, nil
+}
+
+func (m *memCallbackLogs) CountBatchableCallbacks(clientID int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, cb := range m.logs {
+		if m.batchable(cb, clientID) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memCallbackLogs) batchable(cb models.CallbackLog, clientID int) bool {
+	return cb.ClientID == clientID && !cb.IsDelivered && cb.Attempt < 5 &&
+		(cb.Attempt == 0 || (cb.NextRetryAt != nil && !cb.NextRetryAt.After(time.Now())))
+}
+
+// batchReceiver records the number of events in each batch it receives and
+// answers with status.
+type batchReceiver struct {
+	mu      sync.Mutex
+	status  int
+	batches []int
+}
+
+func (b *batchReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var events []json.RawMessage
+	_ = json.Unmarshal(body, &events)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.batches = append(b.batches, len(events))
+	w.WriteHeader(b.status)
+}
+
+func newBatchingCallbackService(t *testing.T, status int) (*CallbackService, *memCallbackLogs, *batchReceiver, *models.Client) {
+	recv := &batchReceiver{status: status}
+	srv := httptest.NewServer(recv)
+	t.Cleanup(srv.Close)
+	logs := &memCallbackLogs{}
+	s := &CallbackService{
+		logs:        logs,
+		httpClient:  srv.Client(),
+		orderLock:   newCallbackOrderLock(newMemoryCallbackLocks()),
+		batchWait:   time.Hour,
+		batchTimers: make(map[int]*time.Timer),
+	}
+	t.Cleanup(func() {
+		for _, timer := range s.batchTimers {
+			timer.Stop()
+		}
+	})
+	client := &models.Client{ID: 7, CallbackURL: srv.URL, CallbackSecret: "secret", CallbackBatchSize: 2}
+	return s, logs, recv, client
+}
+
+func TestBatchCallbackFlushesAtBatchSize(t *testing.T) {
+	s, logs, recv, client := newBatchingCallbackService(t, http.StatusOK)
+
+	s.batchCallback(&models.Transaction{ID: 1}, client, "transaction.success", []byte(`{"event":"transaction.success"}`))
+	if len(recv.batches) != 0 {
+		t.Fatalf("batches = %v, want none before the batch is full", recv.batches)
+	}
+	s.batchCallback(&models.Transaction{ID: 2}, client, "transaction.failed", []byte(`{"event":"transaction.failed"}`))
+	if len(recv.batches) != 1 || recv.batches[0] != 2 {
+		t.Fatalf("batches = %v, want one batch of 2", recv.batches)
+	}
+	for _, cb := range logs.logs {
+		if !cb.IsDelivered || cb.Attempt != 1 || cb.NextRetryAt != nil {
+			t.Fatalf("callback log %d = %+v, want delivered after one attempt", cb.ID, cb)
+		}
+	}
+}
+
+func TestFlushCallbacksSendsPartialBatches(t *testing.T) {
+	s, logs, recv, client := newBatchingCallbackService(t, http.StatusOK)
+	for i := 1; i <= 3; i++ {
+		_ = logs.CreateCallbackLog(&models.CallbackLog{ClientID: client.ID, Event: "transaction.success", Payload: json.RawMessage(`{}`)})
+	}
+
+	s.flushCallbacks(client)
+	if len(recv.batches) != 2 || recv.batches[0] != 2 || recv.batches[1] != 1 {
+		t.Fatalf("batches = %v, want 2 then the remaining 1", recv.batches)
+	}
+	if n, _ := logs.CountBatchableCallbacks(client.ID); n != 0 {
+		t.Fatalf("%d callbacks left to batch, want 0", n)
+	}
+
+	// Batching off: nothing is sent, the callback worker sends them one by one.
+	_ = logs.CreateCallbackLog(&models.CallbackLog{ClientID: client.ID, Payload: json.RawMessage(`{}`)})
+	s.flushCallbacks(&models.Client{ID: client.ID, CallbackURL: client.CallbackURL})
+	if len(recv.batches) != 2 {
+		t.Fatalf("batches = %v, want no batch with batching off", recv.batches)
+	}
+}
+
+func TestFailedCallbackBatchIsRetried(t *testing.T) {
+	s, logs, recv, client := newBatchingCallbackService(t, http.StatusInternalServerError)
+	for i := 1; i <= 2; i++ {
+		_ = logs.CreateCallbackLog(&models.CallbackLog{ClientID: client.ID, Payload: json.RawMessage(`{}`)})
+	}
+
+	s.flushCallbacks(client)
+	for _, cb := range logs.logs {
+		if cb.IsDelivered || cb.Attempt != 1 || cb.NextRetryAt == nil {
+			t.Fatalf("callback log %d = %+v, want a retry scheduled", cb.ID, cb)
+		}
+	}
+	// Not due yet: the next flush leaves them alone.
+	s.flushCallbacks(client)
+	if len(recv.batches) != 1 {
+		t.Fatalf("batches = %v, want no resend before the retry is due", recv.batches)
+	}
+
+	past := time.Now().Add(-time.Second)
+	for i := range logs.logs {
+		logs.logs[i].NextRetryAt = &past
+	}
+	recv.status = http.StatusOK
+	s.flushCallbacks(client)
+	if len(recv.batches) != 2 || recv.batches[1] != 2 {
+		t.Fatalf("batches = %v, want the failed batch resent whole", recv.batches)
+	}
+	for _, cb := range logs.logs {
+		if !cb.IsDelivered || cb.Attempt != 2 {
+			t.Fatalf("callback log %d = %+v, want delivered on the second attempt", cb.ID, cb)
+		}
+	}
+}
+
+func TestAcknowledgeCallbackStopsRetries(t *testing.T) {
+	s, logs, recv, client := newBatchingCallbackService(t, http.StatusOK)
+	past := time.Now().Add(-time.Second)
+	_ = logs.CreateCallbackLog(&models.CallbackLog{ClientID: client.ID, Attempt: 1, NextRetryAt: &past, Payload: json.RawMessage(`{}`)})
+	_ = logs.CreateCallbackLog(&models.CallbackLog{ClientID: 99, Attempt: 1, NextRetryAt: &past, Payload: json.RawMessage(`{}`)})
+
+	cases := []struct {
+		name    string
+		id      int
+		wantErr error
+	}{
+		{"own callback", 1, nil},
+		{"acknowledged again", 1, nil},
+		{"another client's callback", 2, utils.ErrCallbackNotFound},
+		{"unknown callback", 3, utils.ErrCallbackNotFound},
+	}
+	var first *time.Time
+	for _, tc := range cases {
+		delivery, err := s.AcknowledgeCallback(client, tc.id)
+		if !errors.Is(err, tc.wantErr) {
+			t.Fatalf("%s: err = %v, want %v", tc.name, err, tc.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if !delivery.IsDelivered || delivery.AcknowledgedAt == nil {
+			t.Fatalf("%s: delivery = %+v, want delivered and acknowledged", tc.name, delivery)
+		}
+		if first == nil {
+			first = delivery.AcknowledgedAt
+		} else if !delivery.AcknowledgedAt.Equal(*first) {
+			t.Fatalf("%s: acknowledgedAt moved from %v to %v", tc.name, first, delivery.AcknowledgedAt)
+		}
+	}
+
+	// A delivery attempt racing the acknowledgment cannot reopen it.
+	failed := logs.logs[0]
+	failed.IsDelivered, failed.NextRetryAt = false, &past
+	_ = logs.UpdateCallbackLog(&failed)
+
+	s.flushCallbacks(client)
+	if len(recv.batches) != 0 {
+		t.Fatalf("batches = %v, want the acknowledged callback left alone", recv.batches)
+	}
+	if logs.logs[1].IsDelivered {
+		t.Fatal("another client's callback must stay undelivered")
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	callbackLockKeyPrefix = "ppob:callback_lock:trx:"
+	// callbackLockTTL outlives one delivery (20s HTTP timeout) so a crashed
+	// holder cannot block a transaction's callbacks for longer than this.
+	callbackLockTTL  = 45 * time.Second
+	callbackLockWait = 25 * time.Second // how long a new event waits before queueing to the worker
+	callbackLockPoll = 100 * time.Millisecond
+)
+
+// CallbackLockStore is the shared lock behind ordered callback delivery
+// (cache.RedisClient).
+type CallbackLockStore interface {
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	DeleteIfValue(ctx context.Context, key, value string) error
+}
+
+// callbackOrderLock serializes callback delivery per transaction for clients
+// that opted into ordered callbacks. Holders are identified by a random
+// token, so releasing after the TTL expired never drops another holder's lock.
+type callbackOrderLock struct {
+	store CallbackLockStore
+	ttl   time.Duration
+	poll  time.Duration
+}
+
+func newCallbackOrderLock(store CallbackLockStore) *callbackOrderLock {
+	return &callbackOrderLock{store: store, ttl: callbackLockTTL, poll: callbackLockPoll}
+}
+
+// acquire takes the lock of transaction trxID, polling for up to wait (0
+// tries once). It returns a release func, or nil when the lock is held
+// elsewhere. A store error counts as not acquired so ordering is kept.
+func (l *callbackOrderLock) acquire(trxID int, wait time.Duration) func() {
+	return l.acquireKey(callbackLockKeyPrefix+strconv.Itoa(trxID), wait)
+}
+
+// acquireKey is acquire for any lock key.
+func (l *callbackOrderLock) acquireKey(key string, wait time.Duration) func() {
+	token := generateRequestID()
+	deadline := time.Now().Add(wait)
+	for {
+		ok, err := l.store.SetNX(context.Background(), key, token, l.ttl)
+		if err != nil {
+			log.Warn().Err(err).Str("lock", key).Msg("callback lock unavailable")
+		}
+		if err == nil && ok {
+			return func() {
+				if err := l.store.DeleteIfValue(context.Background(), key, token); err != nil {
+					log.Warn().Err(err).Str("lock", key).Msg("callback lock release failed")
+				}
+			}
+		}
+		if !time.Now().Before(deadline) {
+			return nil
+		}
+		time.Sleep(l.poll)
+	}
+}
+
+// memoryCallbackLocks is the single-instance CallbackLockStore used until a
+// shared store is set.
+type memoryCallbackLocks struct {
+	mu    sync.Mutex
+	locks map[string]memoryCallbackLock
+}
+
+type memoryCallbackLock struct {
+	value   string
+	expires time.Time
+}
+
+func newMemoryCallbackLocks() *memoryCallbackLocks {
+	return &memoryCallbackLocks{locks: make(map[string]memoryCallbackLock)}
+}
+
+func (m *memoryCallbackLocks) SetNX(_ context.Context, key, value string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cur, ok := m.locks[key]; ok && time.Now().Before(cur.expires) {
+		return false, nil
+	}
+	m.locks[key] = memoryCallbackLock{value: value, expires: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (m *memoryCallbackLocks) DeleteIfValue(_ context.Context, key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cur, ok := m.locks[key]; ok && cur.value == value {
+		delete(m.locks, key)
+	}
+	return nil
+}
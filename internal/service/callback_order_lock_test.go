@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCallbackOrderLockSerializesPerTransaction(t *testing.T) {
+	l := newCallbackOrderLock(newMemoryCallbackLocks())
+	l.poll = time.Millisecond
+
+	release := l.acquire(1, 0)
+	if release == nil {
+		t.Fatal("first acquire failed")
+	}
+	if l.acquire(1, 0) != nil {
+		t.Fatal("second acquire of a held lock succeeded")
+	}
+	other := l.acquire(2, 0)
+	if other == nil {
+		t.Fatal("lock of another transaction is blocked")
+	}
+	other()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		release()
+	}()
+	next := l.acquire(1, time.Second)
+	if next == nil {
+		t.Fatal("waiting acquire did not get the released lock")
+	}
+	next()
+}
+
+func TestCallbackOrderLockExpiredHolderCannotRelease(t *testing.T) {
+	l := newCallbackOrderLock(newMemoryCallbackLocks())
+	l.ttl = 5 * time.Millisecond
+
+	stale := l.acquire(1, 0)
+	if stale == nil {
+		t.Fatal("first acquire failed")
+	}
+	time.Sleep(10 * time.Millisecond)
+	l.ttl = time.Minute
+	current := l.acquire(1, 0)
+	if current == nil {
+		t.Fatal("expired lock was not taken over")
+	}
+	stale()
+	if l.acquire(1, 0) != nil {
+		t.Fatal("stale holder released the current holder's lock")
+	}
+	current()
+}
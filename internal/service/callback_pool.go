@@ -0,0 +1,220 @@
+package service
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+// defaultMaxInFlightCallbacks caps concurrent client callback deliveries
+// started by QueueCallback.
+const defaultMaxInFlightCallbacks = 100
+
+// callbackQueueFactor bounds the wait queue at this many times the in-flight
+// cap; past it deliveries go straight to the retry worker.
+const callbackQueueFactor = 10
+
+// callbackQueueGrace is how long a waiting delivery stays the pool's before
+// the retry worker may deliver its callback log. A queued job claims the log
+// before sending and leaves it to the worker once it is due.
+const callbackQueueGrace = 2 * time.Minute
+
+// CallbackQueueStats is a snapshot of QueueCallback's delivery pool.
+type CallbackQueueStats struct {
+	MaxInFlight int    `json:"maxInFlight"` // 0 = unbounded
+	InFlight    int    `json:"inFlight"`
+	Queued      int    `json:"queued"`      // waiting for a free slot
+	PeakQueued  int    `json:"peakQueued"`  // since start
+	TotalQueued uint64 `json:"totalQueued"` // deliveries that had to wait, since start
+}
+
+// callbackPool runs at most max jobs at a time; up to callbackQueueFactor
+// times as many wait in FIFO order, and submit refuses the rest.
+type callbackPool struct {
+	mu      sync.Mutex
+	max     int
+	running int
+	queue   []func()
+	peak    int
+	total   uint64
+}
+
+func newCallbackPool(max int) *callbackPool {
+	return &callbackPool{max: max}
+}
+
+// tryRun runs job now when a slot is free and reports whether it did. A nil
+// pool runs every job at once.
+func (p *callbackPool) tryRun(job func()) bool {
+	if p == nil {
+		go job()
+		return true
+	}
+	p.mu.Lock()
+	if p.max <= 0 || p.running < p.max {
+		p.running++
+		p.mu.Unlock()
+		go p.run(job)
+		return true
+	}
+	p.mu.Unlock()
+	return false
+}
+
+// submit runs job now when a slot is free, else queues it; false means the
+// queue is full and job was not taken.
+func (p *callbackPool) submit(job func()) bool {
+	if p.tryRun(job) {
+		return true
+	}
+	p.mu.Lock()
+	if p.max <= 0 || p.running < p.max {
+		// A slot freed up since tryRun.
+		p.running++
+		p.mu.Unlock()
+		go p.run(job)
+		return true
+	}
+	if len(p.queue) >= p.max*callbackQueueFactor {
+		p.mu.Unlock()
+		return false
+	}
+	p.queue = append(p.queue, job)
+	p.total++
+	depth := len(p.queue)
+	if depth > p.peak {
+		p.peak = depth
+	}
+	p.mu.Unlock()
+	if depth == 1 || depth%1000 == 0 {
+		log.Warn().Int("queued", depth).Int("max_in_flight", p.max).Msg("Client callbacks queued: delivery pool is full")
+	}
+	return true
+}
+
+// run runs job, then queued jobs, until the queue is empty.
+func (p *callbackPool) run(job func()) {
+	for job != nil {
+		job()
+		p.mu.Lock()
+		if len(p.queue) == 0 {
+			p.running--
+			job = nil
+		} else {
+			job = p.queue[0]
+			p.queue[0] = nil
+			p.queue = p.queue[1:]
+		}
+		p.mu.Unlock()
+	}
+}
+
+func (p *callbackPool) stats() CallbackQueueStats {
+	if p == nil {
+		return CallbackQueueStats{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return CallbackQueueStats{
+		MaxInFlight: p.max,
+		InFlight:    p.running,
+		Queued:      len(p.queue),
+		PeakQueued:  p.peak,
+		TotalQueued: p.total,
+	}
+}
+
+// SetMaxInFlightCallbacks caps how many callbacks QueueCallback delivers at
+// once; the rest wait their turn (see QueueCallback). n <= 0 removes the cap.
+func (s *CallbackService) SetMaxInFlightCallbacks(n int) {
+	if s.pool == nil {
+		s.pool = newCallbackPool(n)
+		return
+	}
+	s.pool.mu.Lock()
+	s.pool.max = n
+	s.pool.mu.Unlock()
+}
+
+// QueueCallback sends trx's callback in the background (see SendCallback),
+// within the SetMaxInFlightCallbacks cap. A callback that has to wait for a
+// slot is logged first, so a restart or a full queue leaves it to the retry
+// worker instead of losing it.
+func (s *CallbackService) QueueCallback(trx *models.Transaction, event string) {
+	if s.pool.tryRun(func() { s.SendCallback(trx, event) }) {
+		return
+	}
+	s.queueWaitingCallback(trx, event)
+}
+
+// queueWaitingCallback logs trx's callback due callbackQueueGrace from now
+// and queues its delivery; when the queue is full it is made due now for the
+// retry worker. Only the log row is written on the caller's goroutine: a
+// batching client's batch is left to its flush timer, and delivery to the
+// pool or the worker.
+func (s *CallbackService) queueWaitingCallback(trx *models.Transaction, event string) {
+	client, payload, err := s.callbackPayload(trx, event)
+	if err != nil || client == nil {
+		if err != nil {
+			log.Error().Err(err).Str("transactionId", trx.TransactionID).Msg("failed to prepare queued callback")
+		}
+		return
+	}
+	s.enqueueWaitingCallback(trx, client, event, payload)
+}
+
+func (s *CallbackService) enqueueWaitingCallback(trx *models.Transaction, client *models.Client, event string, payload []byte) {
+	switch {
+	case callbackBatchSize(client) > 0:
+		if s.logBatchedCallback(trx, client, event, payload) {
+			s.scheduleCallbackFlush(client.ID)
+		}
+		return
+	case client.CallbackOrdered:
+		s.queueCallback(trx, client, event, payload)
+		return
+	}
+
+	due := time.Now().Add(callbackQueueGrace)
+	cb := &models.CallbackLog{
+		TransactionID: &trx.ID,
+		ClientID:      client.ID,
+		Event:         event,
+		Payload:       json.RawMessage(payload),
+		NextRetryAt:   &due,
+	}
+	if err := s.logs.CreateCallbackLog(cb); err != nil {
+		log.Error().Err(err).Str("transactionId", trx.TransactionID).Msg("failed to create callback log")
+		return
+	}
+	queued := s.pool.submit(func() {
+		// Claim the log before sending: once it is due the retry worker may
+		// have it, and only one of us may deliver.
+		claimed, err := s.logs.ClaimCallbackLog(cb.ID, callbackQueueGrace)
+		if err != nil {
+			log.Error().Err(err).Int("callback_id", cb.ID).Msg("failed to claim queued callback")
+			return
+		}
+		if !claimed {
+			return // due by now: the retry worker delivers it
+		}
+		s.retryCallback(client, cb)
+	})
+	if !queued {
+		now := time.Now()
+		cb.NextRetryAt = &now
+		if err := s.logs.UpdateCallbackLog(cb); err != nil {
+			log.Error().Err(err).Msg("failed to update callback log")
+		}
+		log.Warn().Str("transactionId", trx.TransactionID).Msg("Callback queue full: left to the retry worker")
+	}
+}
+
+// CallbackQueueStats reports the callback delivery pool's load.
+func (s *CallbackService) CallbackQueueStats() CallbackQueueStats {
+	return s.pool.stats()
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+func TestCallbackPoolCapsInFlight(t *testing.T) {
+	p := newCallbackPool(2)
+	release := make(chan struct{})
+	var started sync.WaitGroup
+	started.Add(2)
+	for i := 0; i < 2; i++ {
+		p.submit(func() { started.Done(); <-release })
+	}
+	started.Wait()
+
+	var mu sync.Mutex
+	var order []int
+	var done sync.WaitGroup
+	done.Add(3)
+	for i := 0; i < 3; i++ {
+		i := i
+		p.submit(func() {
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			done.Done()
+		})
+	}
+	if st := p.stats(); st.InFlight != 2 || st.Queued != 3 || st.TotalQueued != 3 {
+		t.Fatalf("stats = %+v, want 2 in flight and 3 queued", st)
+	}
+
+	close(release)
+	done.Wait()
+	if st := p.stats(); st.Queued != 0 || st.PeakQueued != 3 {
+		t.Fatalf("stats after drain = %+v", st)
+	}
+	if len(order) != 3 {
+		t.Fatalf("queued jobs ran %v, want all 3", order)
+	}
+}
+
+func TestCallbackPoolUnbounded(t *testing.T) {
+	p := newCallbackPool(0)
+	var done sync.WaitGroup
+	done.Add(5)
+	for i := 0; i < 5; i++ {
+		p.submit(done.Done)
+	}
+	done.Wait()
+	if st := p.stats(); st.TotalQueued != 0 {
+		t.Fatalf("unbounded pool queued jobs: %+v", st)
+	}
+}
+
+func TestCallbackPoolBoundsQueue(t *testing.T) {
+	p := newCallbackPool(1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	if !p.tryRun(func() { close(started); <-release }) {
+		t.Fatal("tryRun refused a free slot")
+	}
+	<-started
+	if p.tryRun(func() {}) {
+		t.Fatal("tryRun ran past the cap")
+	}
+
+	var done sync.WaitGroup
+	for i := 0; i < callbackQueueFactor; i++ {
+		done.Add(1)
+		if !p.submit(done.Done) {
+			t.Fatalf("submit %d refused below the queue bound", i)
+		}
+	}
+	if p.submit(func() {}) {
+		t.Fatal("submit queued past the bound")
+	}
+	close(release)
+	done.Wait()
+}
+
+func TestQueuedBatchCallbackLeavesFlushToTimer(t *testing.T) {
+	s, logs, recv, client := newBatchingCallbackService(t, http.StatusOK)
+
+	// A full batch is not sent on the caller's goroutine.
+	for id := 1; id <= client.CallbackBatchSize; id++ {
+		s.enqueueWaitingCallback(&models.Transaction{ID: id}, client, "transaction.success", []byte(`{}`))
+	}
+	if len(recv.batches) != 0 {
+		t.Fatalf("batches = %v, want none sent inline", recv.batches)
+	}
+	if n, _ := logs.CountBatchableCallbacks(client.ID); n != client.CallbackBatchSize {
+		t.Fatalf("%d callbacks logged to batch, want %d", n, client.CallbackBatchSize)
+	}
+	s.batchMu.Lock()
+	timer := s.batchTimers[client.ID]
+	s.batchMu.Unlock()
+	if timer == nil {
+		t.Fatal("no flush scheduled for the batch")
+	}
+}
+
+func TestQueuedCallbackClaimsLogBeforeSending(t *testing.T) {
+	tests := []struct {
+		name     string
+		dueNow   bool // the log became due, so the retry worker owns it
+		wantSent int
+	}{
+		{name: "still the pool's", wantSent: 1},
+		{name: "due for the worker", dueNow: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s, logs, recv, client := newBatchingCallbackService(t, http.StatusOK)
+			client.CallbackBatchSize = 0
+			s.pool = newCallbackPool(1)
+
+			// Hold the only slot so the delivery waits in the queue.
+			release := make(chan struct{})
+			s.pool.submit(func() { <-release })
+			s.enqueueWaitingCallback(&models.Transaction{ID: 1}, client, "transaction.success", []byte(`{}`))
+			if tt.dueNow {
+				past := time.Now().Add(-time.Second)
+				logs.mu.Lock()
+				logs.logs[0].NextRetryAt = &past
+				logs.mu.Unlock()
+			}
+			done := make(chan struct{})
+			s.pool.submit(func() { close(done) })
+			close(release)
+			<-done
+
+			recv.mu.Lock()
+			sent := len(recv.batches)
+			recv.mu.Unlock()
+			if sent != tt.wantSent {
+				t.Fatalf("deliveries = %d, want %d", sent, tt.wantSent)
+			}
+			if delivered := logs.logs[0].IsDelivered; delivered != (tt.wantSent == 1) {
+				t.Fatalf("log delivered = %v", delivered)
+			}
+		})
+	}
+}
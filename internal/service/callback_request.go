@@ -0,0 +1,138 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+const (
+	maxCallbackHeaders        = 10
+	maxCallbackHeaderNameLen  = 64
+	maxCallbackHeaderValueLen = 1024
+)
+
+// reservedCallbackHeaders cannot be overridden by client-configured headers:
+// they carry the signature/metadata or are managed by net/http.
+var reservedCallbackHeaders = map[string]bool{
+	"Content-Type":      true,
+	"Content-Length":    true,
+	"Host":              true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+	"X-Gtd-Signature":   true,
+	"X-Gtd-Event":       true,
+	"X-Gtd-Timestamp":   true,
+	"X-Gtd-Request-Id":  true,
+}
+
+// callbackMethod returns the client's configured callback method, defaulting to POST.
+func callbackMethod(client *models.Client) string {
+	switch m := strings.ToUpper(strings.TrimSpace(client.CallbackMethod)); m {
+	case http.MethodPut, http.MethodPatch:
+		return m
+	default:
+		return http.MethodPost
+	}
+}
+
+// ValidateCallbackHeaders checks client-configured static callback headers.
+func ValidateCallbackHeaders(headers map[string]string) error {
+	if len(headers) > maxCallbackHeaders {
+		return fmt.Errorf("at most %d callback headers are allowed", maxCallbackHeaders)
+	}
+	for name, value := range headers {
+		if name == "" || len(name) > maxCallbackHeaderNameLen || !isHeaderToken(name) {
+			return fmt.Errorf("invalid callback header name %q", name)
+		}
+		if reservedCallbackHeaders[http.CanonicalHeaderKey(name)] {
+			return fmt.Errorf("callback header %q is reserved", name)
+		}
+		if len(value) > maxCallbackHeaderValueLen || strings.ContainsAny(value, "\r\n\x00") {
+			return fmt.Errorf("invalid value for callback header %q", name)
+		}
+	}
+	return nil
+}
+
+// isHeaderToken reports whether s is a valid RFC 7230 header field name.
+func isHeaderToken(s string) bool {
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newCallbackRequest builds a signed callback request to the client's URL
+// using its configured method and static headers. Invalid configured headers
+// are skipped (and logged) rather than blocking delivery.
+func newCallbackRequest(client *models.Client, payload []byte, event string) (*http.Request, error) {
+	req, err := http.NewRequest(callbackMethod(client), client.CallbackURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(client.CallbackHeaders) > 0 {
+		if err := ValidateCallbackHeaders(client.CallbackHeaders); err != nil {
+			log.Warn().Err(err).Int("client_id", client.ID).Msg("ignoring invalid callback headers")
+		} else {
+			for name, value := range client.CallbackHeaders {
+				req.Header.Set(name, value)
+			}
+		}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GTD-Signature", callbackSignature(client, payload))
+	req.Header.Set("X-GTD-Event", event)
+	req.Header.Set("X-GTD-Timestamp", time.Now().Format(time.RFC3339))
+	req.Header.Set("X-GTD-Request-Id", generateRequestID())
+	return req, nil
+}
+
+// callbackSignature returns the X-GTD-Signature value of payload in the
+// client's format: "sha256=<hex>" by default, "sha512=<hex>", or
+// "sha256-base64=<base64>" / "sha512-base64=<base64>".
+func callbackSignature(client *models.Client, payload []byte) string {
+	algorithm, encoding := client.CallbackSignatureFormat()
+	return callbackSignaturePrefix(algorithm, encoding) + signCallbackPayload(payload, client.CallbackSecret, algorithm, encoding)
+}
+
+// callbackSignaturePrefix is the X-GTD-Signature prefix naming the format.
+func callbackSignaturePrefix(algorithm, encoding string) string {
+	if encoding == models.CallbackSignatureBase64 {
+		return algorithm + "-base64="
+	}
+	return algorithm + "="
+}
+
+// signCallbackPayload computes the HMAC of payload under secret with the
+// given algorithm and encoding (see models.Client.CallbackSignatureFormat).
+func signCallbackPayload(payload []byte, secret, algorithm, encoding string) string {
+	h := sha256.New
+	if algorithm == models.CallbackSignatureSHA512 {
+		h = sha512.New
+	}
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(payload)
+	if encoding == models.CallbackSignatureBase64 {
+		return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+func TestValidateCallbackHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		wantErr bool
+	}{
+		{"empty", nil, false},
+		{"bearer and routing header", map[string]string{"Authorization": "Bearer abc", "X-Route": "ppob"}, false},
+		{"reserved signature header", map[string]string{"x-gtd-signature": "forged"}, true},
+		{"reserved content type", map[string]string{"Content-Type": "text/plain"}, true},
+		{"invalid name", map[string]string{"Bad Header": "v"}, true},
+		{"header injection in value", map[string]string{"X-Route": "a\r\nX-Evil: 1"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCallbackHeaders(tt.headers); (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateCallbackHeaders(%v) error = %v, wantErr %v", tt.headers, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewCallbackRequestAppliesClientConfig(t *testing.T) {
+	client := &models.Client{
+		CallbackURL:     "https://client.example/webhook",
+		CallbackSecret:  "secret",
+		CallbackMethod:  "put",
+		CallbackHeaders: models.CallbackHeaders{"Authorization": "Bearer abc"},
+	}
+	req, err := newCallbackRequest(client, []byte(`{}`), "transaction.success")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != http.MethodPut {
+		t.Fatalf("method = %s, want PUT", req.Method)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer abc" {
+		t.Fatalf("Authorization = %q", got)
+	}
+	if req.Header.Get("X-GTD-Signature") == "" {
+		t.Fatal("signature header missing")
+	}
+
+	client.CallbackMethod = ""
+	client.CallbackHeaders = models.CallbackHeaders{"X-GTD-Event": "forged"}
+	req, err = newCallbackRequest(client, []byte(`{}`), "transaction.failed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != http.MethodPost {
+		t.Fatalf("default method = %s, want POST", req.Method)
+	}
+	if got := req.Header.Get("X-GTD-Event"); got != "transaction.failed" {
+		t.Fatalf("X-GTD-Event = %q, reserved header must not be overridden", got)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	svc := &CallbackService{}
+	client := &models.Client{CallbackSecret: "secret"}
+	payload := []byte(`{"event":"transaction.success"}`)
+	sig := signCallbackPayload(payload, client.CallbackSecret, models.CallbackSignatureSHA256, models.CallbackSignatureHex)
+
+	if !svc.VerifySignature(client, payload, "sha256="+sig) {
+		t.Fatal("header form should verify")
+	}
+	if !svc.VerifySignature(client, payload, sig) {
+		t.Fatal("bare hex should verify")
+	}
+	if svc.VerifySignature(client, []byte(`{"event":"transaction.failed"}`), "sha256="+sig) {
+		t.Fatal("tampered payload must not verify")
+	}
+}
+
+func TestCallbackSignatureFormats(t *testing.T) {
+	svc := &CallbackService{}
+	payload := []byte(`{"event":"transaction.success"}`)
+	tests := []struct {
+		algorithm, encoding string
+		prefix              string
+		newHash             func() hash.Hash
+	}{
+		{"", "", "sha256=", sha256.New},
+		{"sha512", "hex", "sha512=", sha512.New},
+		{"sha256", "base64", "sha256-base64=", sha256.New},
+		{"SHA512", "base64", "sha512-base64=", sha512.New},
+	}
+	for _, tc := range tests {
+		client := &models.Client{CallbackSecret: "secret", CallbackSignatureAlgorithm: tc.algorithm, CallbackSignatureEncoding: tc.encoding}
+		mac := hmac.New(tc.newHash, []byte("secret"))
+		mac.Write(payload)
+		sum := hex.EncodeToString(mac.Sum(nil))
+		if tc.encoding == "base64" {
+			sum = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		}
+
+		req, err := newCallbackRequest(client, payload, "transaction.success")
+		if err != nil {
+			t.Fatalf("%s/%s: %v", tc.algorithm, tc.encoding, err)
+		}
+		if got := req.Header.Get("X-GTD-Signature"); got != tc.prefix+sum {
+			t.Errorf("%s/%s: X-GTD-Signature = %q, want %q", tc.algorithm, tc.encoding, got, tc.prefix+sum)
+		}
+		if !svc.VerifySignature(client, payload, tc.prefix+sum) || !svc.VerifySignature(client, payload, sum) {
+			t.Errorf("%s/%s: own signature should verify with and without prefix", tc.algorithm, tc.encoding)
+		}
+	}
+}
+
+func TestBuildCallbackPayloadAttemptInfo(t *testing.T) {
+	trx := &models.Transaction{TransactionID: "GRB-20260101-000001", Status: models.StatusSuccess}
+
+	var plain struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(buildCallbackPayload(trx, "transaction.success", utils.LocaleEN, nil), &plain); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := plain.Data["attemptInfo"]; ok {
+		t.Fatal("attemptInfo must be omitted for clients that did not opt in")
+	}
+
+	var withInfo struct {
+		Data struct {
+			AttemptInfo callbackAttemptInfo `json:"attemptInfo"`
+		} `json:"data"`
+	}
+	info := &callbackAttemptInfo{AttemptCount: 3, FallbackUsed: true, BackupUsed: true}
+	if err := json.Unmarshal(buildCallbackPayload(trx, "transaction.success", utils.LocaleEN, info), &withInfo); err != nil {
+		t.Fatal(err)
+	}
+	if withInfo.Data.AttemptInfo != *info {
+		t.Fatalf("attemptInfo = %+v, want %+v", withInfo.Data.AttemptInfo, *info)
+	}
+}
+
+func TestBuildCallbackPayloadDropsProviderCostFields(t *testing.T) {
+	trx := &models.Transaction{
+		TransactionID: "GRB-20260101-000001",
+		Status:        models.StatusSuccess,
+		Description:   models.NullableRawMessage(`{"idpel":"530000000001","periode":"202601","komisi":1500,"sisaSaldo":9800000,"tagihan":{"admin":2500,"hargaBeli":150000}}`),
+	}
+
+	var got struct {
+		Data struct {
+			Description map[string]any `json:"description"`
+			Details     map[string]any `json:"details"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(buildCallbackPayload(trx, "transaction.success", utils.LocaleEN, nil), &got); err != nil {
+		t.Fatal(err)
+	}
+	fields := got.Data.Description
+	if fields["periode"] != "202601" {
+		t.Errorf("description = %v, want periode kept", fields)
+	}
+	if _, ok := fields["komisi"]; ok {
+		t.Errorf("description carries komisi: %v", fields)
+	}
+	if _, ok := fields["sisaSaldo"]; ok {
+		t.Errorf("description carries sisaSaldo: %v", fields)
+	}
+	if tagihan, _ := fields["tagihan"].(map[string]any); tagihan == nil || tagihan["hargaBeli"] != nil {
+		t.Errorf("description tagihan = %v, want admin only", fields["tagihan"])
+	}
+	if got.Data.Details != nil {
+		t.Errorf("details = %v, want the description only", got.Data.Details)
+	}
+	if !strings.Contains(string(trx.Description), "sisaSaldo") {
+		t.Fatal("the stored description must keep cost fields")
+	}
+}
+
+func TestBuildCallbackPayloadLocalizesFailedReason(t *testing.T) {
+	code, reason := "INVALID_CUSTOMER", "Invalid customer number or account"
+	custom := "Provider said no"
+	cases := []struct {
+		locale string
+		code   *string
+		reason *string
+		want   string
+	}{
+		{utils.LocaleID, &code, &reason, "Nomor pelanggan atau akun tidak valid"},
+		{utils.LocaleEN, &code, &reason, reason},
+		{utils.LocaleID, nil, &custom, custom},
+	}
+	for _, tc := range cases {
+		trx := &models.Transaction{TransactionID: "GRB-1", Status: models.StatusFailed, FailedCode: tc.code, FailedReason: tc.reason}
+		var got struct {
+			Data struct {
+				FailedReason string `json:"failedReason"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(buildCallbackPayload(trx, "transaction.failed", tc.locale, nil), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Data.FailedReason != tc.want {
+			t.Errorf("locale %s: failedReason = %q, want %q", tc.locale, got.Data.FailedReason, tc.want)
+		}
+	}
+}
@@ -1,35 +1,68 @@
 package service
 
 import (
-	"bytes"
 	"context"
 	"crypto/hmac"
 	"crypto/rand"
-	"crypto/sha256"
+	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/notify"
 	"github.com/GTDGit/gtd_api/internal/repository"
 	"github.com/GTDGit/gtd_api/internal/sse"
+	"github.com/GTDGit/gtd_api/internal/utils"
 	"github.com/GTDGit/gtd_api/pkg/digiflazz"
 )
 
+// callbackResendCooldown is the least time between a transaction's last
+// callback and a resend the client asks for.
+const callbackResendCooldown = time.Minute
+
 // CallbackService handles outgoing callbacks to client systems and processing
 // of incoming Digiflazz callbacks.
 type CallbackService struct {
 	clientRepo   *repository.ClientRepository
 	callbackRepo *repository.CallbackRepository
+	logs         callbackLogStore // callbackRepo; a fake in tests
+	attempts     attemptSummaryReader
 	trxRepo      *repository.TransactionRepository
 	httpClient   *http.Client
 	// trxRetrier is set after initialization to avoid circular dependency
 	trxRetrier TransactionRetrier
 	notifier   sse.TransactionNotifier
+	// checkSerialNumbers flags successful prepaid transactions whose serial
+	// number is already recorded on another successful transaction.
+	checkSerialNumbers bool
+	serialWindow       time.Duration // 0 compares against all successful transactions
+	serialIgnore       []string      // lower-cased categories whose serial numbers repeat by design
+	// alerts receives ops events (duplicate serial numbers); nil only logs.
+	alerts notify.Notifier
+	// lookupRetries/lookupInterval re-read a Digiflazz callback's transaction
+	// that is not found yet (callback beat the insert) before leaving it to
+	// the worker; 0 retries defers at once.
+	lookupRetries  int
+	lookupInterval time.Duration
+	// orderLock serializes deliveries per transaction for clients with
+	// CallbackOrdered; in-process until SetCallbackLockStore shares it.
+	orderLock *callbackOrderLock
+	// batchWait is how long a batching client's callbacks wait for a full
+	// batch; batchTimers holds the pending flush per client.
+	batchWait   time.Duration
+	batchMu     sync.Mutex
+	batchTimers map[int]*time.Timer
+
+	// pool bounds concurrent QueueCallback deliveries.
+	pool *callbackPool
 }
 
 // TransactionRetrier interface for retry functionality (avoids circular dependency)
@@ -47,10 +80,16 @@ func NewCallbackService(clientRepo *repository.ClientRepository, callbackRepo *r
 	return &CallbackService{
 		clientRepo:   clientRepo,
 		callbackRepo: callbackRepo,
+		logs:         callbackRepo,
+		attempts:     callbackRepo,
 		trxRepo:      trxRepo,
 		httpClient: &http.Client{
 			Timeout: 20 * time.Second,
 		},
+		orderLock:   newCallbackOrderLock(newMemoryCallbackLocks()),
+		batchWait:   defaultCallbackBatchWait,
+		batchTimers: make(map[int]*time.Timer),
+		pool:        newCallbackPool(defaultMaxInFlightCallbacks),
 	}
 }
 
@@ -64,51 +103,79 @@ func (s *CallbackService) SetNotifier(notifier sse.TransactionNotifier) {
 	s.notifier = notifier
 }
 
-// SendCallback sends an HTTP POST webhook to the client's callback URL and logs the attempt.
+// SetAlertNotifier routes ops alerts raised by the service to notifier.
+func (s *CallbackService) SetAlertNotifier(notifier notify.Notifier) {
+	s.alerts = notifier
+}
+
+// SetSerialNumberCheck enables the duplicate serial number check on success.
+func (s *CallbackService) SetSerialNumberCheck(enabled bool) {
+	s.checkSerialNumbers = enabled
+}
+
+// SetSerialNumberScope narrows the duplicate serial number check to
+// transactions created within window (0 = any age) and outside the given
+// product categories.
+func (s *CallbackService) SetSerialNumberScope(window time.Duration, ignoreCategories []string) {
+	s.serialWindow = window
+	s.serialIgnore = lowerCategories(ignoreCategories)
+}
+
+// SetCallbackLookupRetry makes an incoming Digiflazz callback retry its
+// transaction lookup up to retries times, interval apart, before deferring to
+// the callback worker.
+func (s *CallbackService) SetCallbackLookupRetry(retries int, interval time.Duration) {
+	s.lookupRetries = retries
+	s.lookupInterval = interval
+}
+
+// SetCallbackLockStore shares the per-transaction lock of ordered callback
+// delivery across instances (Redis); without it only this instance's
+// deliveries are serialized.
+func (s *CallbackService) SetCallbackLockStore(store CallbackLockStore) {
+	if store != nil {
+		s.orderLock = newCallbackOrderLock(store)
+	}
+}
+
+// SendCallback sends a webhook (POST unless the client configured another
+// method) to the client's callback URL and logs the attempt.
 // It schedules retries when delivery is not successful.
 func (s *CallbackService) SendCallback(trx *models.Transaction, event string) error {
-	if trx == nil {
-		return nil
-	}
-	client, err := s.clientRepo.GetByID(trx.ClientID)
-	if err != nil || client == nil || client.CallbackURL == "" {
+	client, payload, err := s.callbackPayload(trx, event)
+	if err != nil || client == nil {
 		return err
 	}
 
-	payload := buildCallbackPayload(trx, event)
-	signature := generateSignature(payload, client.CallbackSecret)
+	if callbackBatchSize(client) > 0 {
+		s.batchCallback(trx, client, event, payload)
+		return nil
+	}
+	if client.CallbackOrdered {
+		// Wait for an in-flight delivery of this transaction, then go behind
+		// any older callback still being retried.
+		release := s.orderLock.acquire(trx.ID, callbackLockWait)
+		if release == nil {
+			s.queueCallback(trx, client, event, payload)
+			return nil
+		}
+		defer release()
+		if earlier, err := s.callbackRepo.HasEarlierPendingCallback(trx.ID, 0); err != nil || earlier {
+			if err != nil {
+				log.Error().Err(err).Str("transactionId", trx.TransactionID).Msg("failed to check pending callbacks")
+			}
+			s.queueCallback(trx, client, event, payload)
+			return nil
+		}
+	}
 
-	req, err := http.NewRequest(http.MethodPost, client.CallbackURL, bytes.NewReader(payload))
+	statusCode, respBody, delivered, err := s.postCallback(client, payload, event)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to create callback request")
 		return err
 	}
-	reqID := generateRequestID()
-	timestamp := time.Now().Format(time.RFC3339)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-GTD-Signature", "sha256="+signature)
-	req.Header.Set("X-GTD-Event", event)
-	req.Header.Set("X-GTD-Timestamp", timestamp)
-	req.Header.Set("X-GTD-Request-Id", reqID)
-
-	resp, err := s.httpClient.Do(req)
-
-	// read response body (best effort)
-	var statusCode *int
-	var respBody *string
-	if resp != nil {
-		defer resp.Body.Close()
-		sc := resp.StatusCode
-		statusCode = &sc
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		bodyStr := string(bodyBytes)
-		if bodyStr != "" {
-			respBody = &bodyStr
-		}
-	}
 
 	// Log attempt
-	delivered := err == nil && resp != nil && resp.StatusCode == http.StatusOK
 	logEntry := &models.CallbackLog{
 		TransactionID: &trx.ID,
 		ClientID:      client.ID,
@@ -143,6 +210,247 @@ func (s *CallbackService) SendCallback(trx *models.Transaction, event string) er
 	return nil
 }
 
+// callbackPayload returns trx's client and event payload, or a nil client
+// when no callback is due: no callback URL, or a success held for review
+// whose callback follows the admin decision.
+func (s *CallbackService) callbackPayload(trx *models.Transaction, event string) (*models.Client, []byte, error) {
+	if trx == nil {
+		return nil, nil, nil
+	}
+	if event == "transaction.success" && s.checkDuplicateSerialNumber(trx) {
+		if s.trxRepo != nil {
+			if err := s.trxRepo.Update(trx); err != nil {
+				log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("failed to save transaction held for review")
+			}
+		}
+		if s.notifier != nil {
+			s.notifier.NotifyTransactionStatusChanged(trx)
+		}
+		return nil, nil, nil
+	}
+	client, err := s.clientRepo.GetByID(trx.ClientID)
+	if err != nil || client == nil || client.CallbackURL == "" {
+		return nil, nil, err
+	}
+	return client, buildCallbackPayload(trx, event, utils.ResolveLocale("", client.Locale), s.attemptInfo(client, trx, event)), nil
+}
+
+// queueCallback logs an ordered client's callback without sending it, due
+// now, so the retry worker delivers it once the earlier ones are done.
+func (s *CallbackService) queueCallback(trx *models.Transaction, client *models.Client, event string, payload []byte) {
+	now := time.Now()
+	logEntry := &models.CallbackLog{
+		TransactionID: &trx.ID,
+		ClientID:      client.ID,
+		Event:         event,
+		Payload:       json.RawMessage(payload),
+		NextRetryAt:   &now,
+	}
+	if err := s.callbackRepo.CreateCallbackLog(logEntry); err != nil {
+		log.Error().Err(err).Msg("failed to create callback log")
+		return
+	}
+	log.Info().Str("transactionId", trx.TransactionID).Str("event", event).Msg("Ordered callback queued behind an earlier delivery")
+}
+
+// checkDuplicateSerialNumber flags (without failing) a successful prepaid
+// transaction whose serial number another successful transaction already has.
+// Every success path dispatches transaction.success, so that is the hook;
+// paths that check before saving the success skip it, and a transaction
+// checked or flagged before is not checked again. When the product holds
+// duplicate serial numbers and the other success is of the same product and
+// provider, trx is held for admin review with status Pending, which the
+// caller saves, and true is returned.
+func (s *CallbackService) checkDuplicateSerialNumber(trx *models.Transaction) bool {
+	if !s.checkSerialNumbers || trx.Type != models.TrxTypePrepaid || trx.SerialDupOf != nil || trx.SerialChecked ||
+		trx.SerialNumber == nil || strings.TrimSpace(*trx.SerialNumber) == "" {
+		return false
+	}
+	trx.SerialChecked = true
+	other, err := s.trxRepo.FindSuccessBySerialNumber(*trx.SerialNumber, trx.ID, s.serialWindow, s.serialIgnore)
+	if err != nil {
+		log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("serial number check failed")
+		return false
+	}
+	if other == "" {
+		return false
+	}
+	if err := s.trxRepo.FlagDuplicateSerialNumber(trx.ID, other); err != nil {
+		log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("failed to flag duplicate serial number")
+	}
+	trx.SerialDupOf = &other
+	held, err := s.trxRepo.HoldDuplicateSerialForReview(trx.ID, trx.ProductID, trx.ProviderID, other)
+	if err != nil {
+		log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("failed to hold duplicate serial number for review")
+	}
+	if held {
+		now := time.Now()
+		trx.Status = models.StatusPending
+		trx.ReviewHoldAt = &now
+	}
+	log.Error().
+		Str("alert", "duplicate_serial_number").
+		Str("transaction_id", trx.TransactionID).
+		Str("duplicate_of", other).
+		Str("provider_code", derefString(trx.ProviderCode)).
+		Str("serial_number", *trx.SerialNumber).
+		Bool("held_for_review", held).
+		Msg("ALERT: provider returned a serial number already used by another successful transaction")
+	if s.alerts != nil {
+		s.alerts.Notify(context.Background(), notify.Event{
+			Type:     notify.EventDuplicateSerialNumber,
+			Severity: notify.SeverityWarning,
+			Key:      derefString(trx.ProviderCode),
+			Message:  "provider returned a serial number already used by another successful transaction",
+			Fields: map[string]any{
+				"transaction_id":  trx.TransactionID,
+				"duplicate_of":    other,
+				"provider_code":   derefString(trx.ProviderCode),
+				"serial_number":   *trx.SerialNumber,
+				"held_for_review": held,
+			},
+		})
+	}
+	return held
+}
+
+// VerifySignature reports whether signature, the X-GTD-Signature value with
+// or without its format prefix, is the callback signature of payload under
+// the client's callback secret and signature format.
+func (s *CallbackService) VerifySignature(client *models.Client, payload []byte, signature string) bool {
+	algorithm, encoding := client.CallbackSignatureFormat()
+	got := strings.TrimPrefix(strings.TrimSpace(signature), callbackSignaturePrefix(algorithm, encoding))
+	if encoding == models.CallbackSignatureHex {
+		got = strings.ToLower(got)
+	}
+	want := signCallbackPayload(payload, client.CallbackSecret, algorithm, encoding)
+	return hmac.Equal([]byte(got), []byte(want))
+}
+
+// ListClientCallbacks returns the client's own PPOB callback delivery history.
+func (s *CallbackService) ListClientCallbacks(filter repository.ClientCallbackFilter) ([]repository.ClientCallbackDelivery, int, error) {
+	return s.callbackRepo.ListClientCallbacks(filter)
+}
+
+// AcknowledgeCallback records the client's acknowledgment of one of its PPOB
+// callbacks; it is then treated as delivered and no longer retried.
+func (s *CallbackService) AcknowledgeCallback(client *models.Client, callbackID int) (*repository.ClientCallbackDelivery, error) {
+	delivery, err := s.logs.AcknowledgeClientCallback(callbackID, client.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, utils.ErrCallbackNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Int("callback_id", callbackID).Int("client_id", client.ID).
+		Str("transaction_id", delivery.TransactionID).Msg("Callback acknowledged by client")
+	return delivery, nil
+}
+
+// postCallback signs payload with the client's current secret and sends it
+// once. Only a request that cannot be built is returned as an error; a failed
+// delivery is reported through delivered.
+func (s *CallbackService) postCallback(client *models.Client, payload []byte, event string) (statusCode *int, respBody *string, delivered bool, err error) {
+	req, err := newCallbackRequest(client, payload, event)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if resp == nil {
+		return nil, nil, false, nil
+	}
+	defer resp.Body.Close()
+
+	// read response body (best effort)
+	sc := resp.StatusCode
+	statusCode = &sc
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	if bodyStr := string(bodyBytes); bodyStr != "" {
+		respBody = &bodyStr
+	}
+	return statusCode, respBody, err == nil && resp.StatusCode == http.StatusOK, nil
+}
+
+// CallbackResend is the outcome of a client-requested callback resend.
+type CallbackResend struct {
+	TransactionID string `json:"transactionId"`
+	Event         string `json:"event"`
+	Delivered     bool   `json:"delivered"`
+	HTTPStatus    *int   `json:"httpStatus,omitempty"`
+}
+
+// ResendCallback sends the latest callback of one of the client's
+// transactions again, signed with the client's current secret, and logs it
+// as a new delivery. It is not retried further: the caller sees the result.
+// A transaction's callback is resent at most once per callbackResendCooldown.
+func (s *CallbackService) ResendCallback(client *models.Client, transactionID string) (*CallbackResend, error) {
+	trx, err := s.trxRepo.GetByTransactionID(transactionID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && trx.ClientID != client.ID) {
+		return nil, utils.ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if client.CallbackURL == "" {
+		return nil, utils.ErrCallbackNotFound
+	}
+	last, err := s.callbackRepo.GetLatestCallbackLog(trx.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, utils.ErrCallbackNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(last.CreatedAt) < callbackResendCooldown {
+		return nil, utils.ErrCallbackResendTooSoon
+	}
+
+	if client.CallbackOrdered {
+		// Never overtake a delivery of this transaction that is in flight.
+		release := s.orderLock.acquire(trx.ID, 0)
+		if release == nil {
+			return nil, utils.ErrCallbackResendTooSoon
+		}
+		defer release()
+	}
+
+	// A batching client expects every callback as a JSON array.
+	body, event := []byte(last.Payload), last.Event
+	if callbackBatchSize(client) > 0 {
+		body, event = callbackBatchBody([]models.CallbackLog{*last}), callbackBatchEvent
+	}
+	statusCode, respBody, delivered, err := s.postCallback(client, body, event)
+	if err != nil {
+		return nil, err
+	}
+	logEntry := &models.CallbackLog{
+		TransactionID: &trx.ID,
+		ClientID:      client.ID,
+		Event:         last.Event,
+		Payload:       last.Payload,
+		Attempt:       1,
+		HTTPStatus:    statusCode,
+		ResponseBody:  respBody,
+		IsDelivered:   delivered,
+	}
+	if err := s.callbackRepo.CreateCallbackLog(logEntry); err != nil {
+		log.Error().Err(err).Msg("failed to create callback log")
+	}
+	if delivered {
+		s.trxRepo.MarkCallbackSent(trx.ID)
+	}
+	log.Info().Int("client_id", client.ID).Str("transactionId", trx.TransactionID).
+		Str("event", last.Event).Bool("delivered", delivered).Msg("Callback resent on client request")
+
+	return &CallbackResend{
+		TransactionID: trx.TransactionID,
+		Event:         last.Event,
+		Delivered:     delivered,
+		HTTPStatus:    statusCode,
+	}, nil
+}
+
 // getNextRetryTime returns next retry time based on attempt number.
 // Retry intervals: 30s, 1m, 5m, 30m, 2h
 func (s *CallbackService) getNextRetryTime(attempt int) time.Time {
@@ -159,72 +467,90 @@ func (s *CallbackService) getNextRetryTime(attempt int) time.Time {
 	return time.Now().Add(intervals[attempt])
 }
 
-// RetryPendingCallbacks retries undelivered callbacks.
+// RetryPendingCallbacks retries undelivered callbacks. Those of batching
+// clients are sent in batches.
 func (s *CallbackService) RetryPendingCallbacks() error {
 	callbacks, err := s.callbackRepo.GetPendingCallbacks()
 	if err != nil {
 		return err
 	}
+	var batching []int
+	batched := make(map[int]bool)
 	for i := range callbacks {
 		cb := &callbacks[i]
+		if batched[cb.ClientID] {
+			continue
+		}
 		client, err := s.clientRepo.GetByID(cb.ClientID)
 		if err != nil || client == nil || client.CallbackURL == "" {
 			continue
 		}
-		req, err := http.NewRequest(http.MethodPost, client.CallbackURL, bytes.NewReader(cb.Payload))
-		if err != nil {
+		if callbackBatchSize(client) > 0 {
+			batched[cb.ClientID] = true
+			batching = append(batching, cb.ClientID)
 			continue
 		}
-		// Recompute signature (payload unchanged)
-		sig := generateSignature([]byte(cb.Payload), client.CallbackSecret)
-		reqID := generateRequestID()
-		timestamp := time.Now().Format(time.RFC3339)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-GTD-Signature", "sha256="+sig)
-		req.Header.Set("X-GTD-Event", cb.Event)
-		req.Header.Set("X-GTD-Timestamp", timestamp)
-		req.Header.Set("X-GTD-Request-Id", reqID)
-
-		resp, err := s.httpClient.Do(req)
-		var statusCode *int
-		var respBody *string
-		if resp != nil {
-			defer resp.Body.Close()
-			sc := resp.StatusCode
-			statusCode = &sc
-			b, _ := io.ReadAll(resp.Body)
-			bs := string(b)
-			if bs != "" {
-				respBody = &bs
-			}
+		if !client.CallbackOrdered || cb.TransactionID == nil {
+			s.retryCallback(client, cb)
+			continue
+		}
+		// Ordered: skip (until the next run) while another delivery of the
+		// transaction is in flight or an older callback is still pending.
+		release := s.orderLock.acquire(*cb.TransactionID, 0)
+		if release == nil {
+			continue
+		}
+		earlier, err := s.callbackRepo.HasEarlierPendingCallback(*cb.TransactionID, cb.ID)
+		if err != nil {
+			log.Error().Err(err).Int("callback_id", cb.ID).Msg("failed to check pending callbacks")
 		}
+		if err == nil && !earlier {
+			s.retryCallback(client, cb)
+		}
+		release()
+	}
+	for _, clientID := range batching {
+		s.FlushCallbacks(clientID)
+	}
+	return nil
+}
 
-		cb.Attempt++
-		cb.HTTPStatus = statusCode
-		cb.ResponseBody = respBody
-		delivered := err == nil && resp != nil && resp.StatusCode == http.StatusOK
-		cb.IsDelivered = delivered
-		if !delivered {
-			next := s.getNextRetryTime(cb.Attempt)
-			if next.IsZero() {
-				// No more retries
-				cb.NextRetryAt = nil
-			} else {
-				cb.NextRetryAt = &next
-			}
-		} else {
+// retryCallback re-sends one pending callback log and records the attempt.
+func (s *CallbackService) retryCallback(client *models.Client, cb *models.CallbackLog) {
+	// Signature is recomputed over the unchanged payload
+	statusCode, respBody, delivered, err := s.postCallback(client, []byte(cb.Payload), cb.Event)
+	if err != nil {
+		return
+	}
+	s.recordCallbackAttempt(cb, statusCode, respBody, delivered)
+}
+
+// recordCallbackAttempt records one more delivery attempt of cb and schedules
+// its next retry when it was not delivered.
+func (s *CallbackService) recordCallbackAttempt(cb *models.CallbackLog, statusCode *int, respBody *string, delivered bool) {
+	cb.Attempt++
+	cb.HTTPStatus = statusCode
+	cb.ResponseBody = respBody
+	cb.IsDelivered = delivered
+	if !delivered {
+		next := s.getNextRetryTime(cb.Attempt)
+		if next.IsZero() {
+			// No more retries
 			cb.NextRetryAt = nil
-			// Update transaction callback_sent status
-			if s.trxRepo != nil && cb.TransactionID != nil {
-				s.trxRepo.MarkCallbackSent(*cb.TransactionID)
-			}
+		} else {
+			cb.NextRetryAt = &next
 		}
-
-		if err := s.callbackRepo.UpdateCallbackLog(cb); err != nil {
-			log.Error().Err(err).Msg("failed to update callback log")
+	} else {
+		cb.NextRetryAt = nil
+		// Update transaction callback_sent status
+		if s.trxRepo != nil && cb.TransactionID != nil {
+			s.trxRepo.MarkCallbackSent(*cb.TransactionID)
 		}
 	}
-	return nil
+
+	if err := s.logs.UpdateCallbackLog(cb); err != nil {
+		log.Error().Err(err).Msg("failed to update callback log")
+	}
 }
 
 // ProcessDigiflazzCallback processes Digiflazz callback immediately.
@@ -268,32 +594,53 @@ func (s *CallbackService) ProcessDigiflazzCallback(payload *digiflazz.CallbackPa
 	return nil
 }
 
-// processCallbackImmediate handles the callback processing logic immediately
-func (s *CallbackService) processCallbackImmediate(cb *models.DigiflazzCallback, payload *digiflazz.CallbackPayload) {
-	// Find transaction by digi_ref_id
-	trx, err := s.trxRepo.GetByDigiRefID(payload.RefID)
+// findCallbackTransaction finds the transaction of a Digiflazz callback by
+// digi_ref_id, falling back to the base ref_id (without suffix).
+func (s *CallbackService) findCallbackTransaction(refID string) (*models.Transaction, error) {
+	trx, err := s.trxRepo.GetByDigiRefID(refID)
 	if err != nil {
-		// Try base ref_id (without suffix)
-		baseRefID := extractBaseRefID(payload.RefID)
-		if baseRefID != payload.RefID {
+		baseRefID := extractBaseRefID(refID)
+		if baseRefID != refID {
 			trx, err = s.trxRepo.GetByDigiRefID(baseRefID)
 			if err != nil {
 				trx, err = s.trxRepo.GetByTransactionID(baseRefID)
 			}
 		} else {
-			trx, err = s.trxRepo.GetByTransactionID(payload.RefID)
+			trx, err = s.trxRepo.GetByTransactionID(refID)
 		}
 	}
+	return trx, err
+}
+
+// retryLookup calls lookup once, then up to retries more times interval
+// apart while it finds nothing.
+func retryLookup(retries int, interval time.Duration, lookup func() (*models.Transaction, error)) (*models.Transaction, error) {
+	trx, err := lookup()
+	for i := 0; i < retries && (err != nil || trx == nil); i++ {
+		time.Sleep(interval)
+		trx, err = lookup()
+	}
+	return trx, err
+}
+
+// processCallbackImmediate handles the callback processing logic immediately
+func (s *CallbackService) processCallbackImmediate(cb *models.DigiflazzCallback, payload *digiflazz.CallbackPayload) {
+	// Find transaction by digi_ref_id. A callback may beat the insert of its
+	// transaction, so look again briefly before leaving it to the worker.
+	trx, err := retryLookup(s.lookupRetries, s.lookupInterval, func() (*models.Transaction, error) {
+		return s.findCallbackTransaction(payload.RefID)
+	})
 
 	if err != nil || trx == nil {
 		log.Warn().
 			Str("digi_ref_id", payload.RefID).
+			Int("lookup_retries", s.lookupRetries).
 			Msg("Transaction not found for Digiflazz callback, will retry via worker")
 		return // Worker will retry later
 	}
 
-	// Skip if transaction is already in final state
-	if trx.Status == models.StatusSuccess || trx.Status == models.StatusFailed {
+	// Skip if transaction is already in final state (or held for review)
+	if trx.Status == models.StatusSuccess || trx.Status == models.StatusFailed || trx.HeldForReview() {
 		log.Debug().
 			Str("transaction_id", trx.TransactionID).
 			Str("status", string(trx.Status)).
@@ -326,7 +673,7 @@ func (s *CallbackService) processCallbackImmediate(cb *models.DigiflazzCallback,
 			s.notifier.NotifyTransactionStatusChanged(trx)
 		}
 
-		go s.SendCallback(trx, "transaction.success")
+		s.QueueCallback(trx, "transaction.success")
 		log.Info().Str("transaction_id", trx.TransactionID).Msg("Transaction updated to Success from Digiflazz callback")
 
 	case digiflazz.IsFatal(rc):
@@ -344,7 +691,7 @@ func (s *CallbackService) processCallbackImmediate(cb *models.DigiflazzCallback,
 			s.notifier.NotifyTransactionStatusChanged(trx)
 		}
 
-		go s.SendCallback(trx, "transaction.failed")
+		s.QueueCallback(trx, "transaction.failed")
 		log.Info().Str("transaction_id", trx.TransactionID).Str("rc", rc).Msg("Transaction updated to Failed (fatal RC)")
 
 	case digiflazz.IsRetryable(rc):
@@ -403,7 +750,7 @@ func (s *CallbackService) processCallbackImmediate(cb *models.DigiflazzCallback,
 			s.notifier.NotifyTransactionStatusChanged(trx)
 		}
 
-		go s.SendCallback(trx, "transaction.failed")
+		s.QueueCallback(trx, "transaction.failed")
 	}
 
 	// Mark callback as processed
@@ -436,8 +783,41 @@ func extractBaseRefID(refID string) string {
 	return refID
 }
 
-// buildCallbackPayload constructs the JSON payload sent to clients.
-func buildCallbackPayload(trx *models.Transaction, event string) []byte {
+// attemptSummaryReader summarizes the provider attempts of a transaction
+// (repository.CallbackRepository).
+type attemptSummaryReader interface {
+	GetAttemptSummary(transactionID int) (*repository.TransactionAttemptSummary, error)
+}
+
+// callbackAttemptInfo is the opt-in reliability hint on success callbacks.
+type callbackAttemptInfo struct {
+	AttemptCount int  `json:"attemptCount"`
+	FallbackUsed bool `json:"fallbackUsed"` // succeeded on another SKU or provider SKU than the first tried
+	BackupUsed   bool `json:"backupUsed"`   // final provider is the backup provider
+}
+
+// attemptInfo returns the attempt summary for clients that opted in to it on
+// success callbacks, nil otherwise or when it cannot be loaded.
+func (s *CallbackService) attemptInfo(client *models.Client, trx *models.Transaction, event string) *callbackAttemptInfo {
+	if !client.CallbackAttemptInfo || event != "transaction.success" {
+		return nil
+	}
+	summary, err := s.attempts.GetAttemptSummary(trx.ID)
+	if err != nil {
+		log.Warn().Err(err).Str("transaction_id", trx.TransactionID).Msg("failed to load attempt summary for callback")
+		return nil
+	}
+	return &callbackAttemptInfo{
+		AttemptCount: summary.AttemptCount,
+		FallbackUsed: summary.TargetCount > 1,
+		BackupUsed:   summary.BackupUsed,
+	}
+}
+
+// buildCallbackPayload constructs the JSON payload sent to clients, with
+// failedReason in locale like API error messages. attempts is included only
+// when non-nil.
+func buildCallbackPayload(trx *models.Transaction, event, locale string, attempts *callbackAttemptInfo) []byte {
 	type dataPayload struct {
 		TransactionID string      `json:"transactionId"`
 		ReferenceID   string      `json:"referenceId,omitempty"`
@@ -451,6 +831,7 @@ func buildCallbackPayload(trx *models.Transaction, event string) []byte {
 		Admin         int         `json:"admin,omitempty"`
 		Period        *string     `json:"period,omitempty"`
 		Description   interface{} `json:"description,omitempty"`
+		AttemptInfo   interface{} `json:"attemptInfo,omitempty"`
 		FailedReason  *string     `json:"failedReason,omitempty"`
 		FailedCode    *string     `json:"failedCode,omitempty"`
 		CreatedAt     time.Time   `json:"createdAt"`
@@ -462,8 +843,17 @@ func buildCallbackPayload(trx *models.Transaction, event string) []byte {
 		Timestamp string      `json:"timestamp"`
 	}
 	var desc any
-	if len(trx.Description) > 0 {
-		_ = json.Unmarshal(trx.Description, &desc)
+	if d := PublicTransactionDescription(json.RawMessage(trx.Description)); len(d) > 0 {
+		desc = d
+	}
+	var attemptInfo any
+	if attempts != nil {
+		attemptInfo = attempts
+	}
+	failedReason := trx.FailedReason
+	if failedReason != nil && trx.FailedCode != nil {
+		msg := utils.LocalizeMessage(locale, *trx.FailedCode, *failedReason)
+		failedReason = &msg
 	}
 	p := payload{
 		Event: event,
@@ -480,7 +870,8 @@ func buildCallbackPayload(trx *models.Transaction, event string) []byte {
 			Admin:         trx.Admin,
 			Period:        trx.Period,
 			Description:   desc,
-			FailedReason:  trx.FailedReason,
+			AttemptInfo:   attemptInfo,
+			FailedReason:  failedReason,
 			FailedCode:    trx.FailedCode,
 			CreatedAt:     trx.CreatedAt,
 			ProcessedAt:   trx.ProcessedAt,
@@ -491,13 +882,6 @@ func buildCallbackPayload(trx *models.Transaction, event string) []byte {
 	return b
 }
 
-// generateSignature creates HMAC-SHA256 signature of payload using secret.
-func generateSignature(payload []byte, secret string) string {
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write(payload)
-	return hex.EncodeToString(mac.Sum(nil))
-}
-
 // generateRequestID creates a unique request ID for callback tracking.
 func generateRequestID() string {
 	b := make([]byte, 8)
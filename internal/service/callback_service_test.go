@@ -0,0 +1,137 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/repository"
+)
+
+func TestRetryLookupFindsLateTransaction(t *testing.T) {
+	calls := 0
+	trx, err := retryLookup(3, time.Millisecond, func() (*models.Transaction, error) {
+		calls++
+		if calls < 3 {
+			return nil, errors.New("not found")
+		}
+		return &models.Transaction{TransactionID: "GRB-20260101-000001"}, nil
+	})
+	if err != nil || trx == nil || calls != 3 {
+		t.Fatalf("trx = %v, err = %v after %d calls; want found on the 3rd", trx, err, calls)
+	}
+}
+
+func TestRetryLookupGivesUp(t *testing.T) {
+	for _, retries := range []int{0, 2} {
+		calls := 0
+		trx, err := retryLookup(retries, time.Millisecond, func() (*models.Transaction, error) {
+			calls++
+			return nil, errors.New("not found")
+		})
+		if err == nil || trx != nil || calls != retries+1 {
+			t.Errorf("retries=%d: trx = %v, err = %v, calls = %d; want not found after %d calls", retries, trx, err, calls, retries+1)
+		}
+	}
+}
+
+func TestPostCallbackSignsWithCurrentSecret(t *testing.T) {
+	payload := []byte(`{"event":"transaction.success"}`)
+	var gotSig string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get("X-GTD-Signature")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	s := &CallbackService{httpClient: srv.Client()}
+	client := &models.Client{CallbackURL: srv.URL, CallbackSecret: "rotated"}
+	status, body, delivered, err := s.postCallback(client, payload, "transaction.success")
+	if err != nil || !delivered || status == nil || *status != http.StatusOK || body == nil || *body != "ok" {
+		t.Fatalf("status = %v, body = %v, delivered = %v, err = %v", status, body, delivered, err)
+	}
+	if !s.VerifySignature(client, payload, gotSig) {
+		t.Fatalf("signature %q does not verify with the current secret", gotSig)
+	}
+
+	srv.Close()
+	if _, _, delivered, err := s.postCallback(client, payload, "transaction.success"); err != nil || delivered {
+		t.Fatalf("unreachable endpoint: delivered = %v, err = %v; want undelivered without error", delivered, err)
+	}
+}
+
+func TestCheckDuplicateSerialNumberRunsOnce(t *testing.T) {
+	// No repository: a second lookup would panic.
+	s := &CallbackService{}
+	s.SetSerialNumberCheck(true)
+	sn := "SN-1"
+	dup := "GRB-20260101-000001"
+
+	cases := []struct {
+		name string
+		trx  *models.Transaction
+	}{
+		{"checked before saving", &models.Transaction{Type: models.TrxTypePrepaid, SerialNumber: &sn, SerialChecked: true}},
+		{"already flagged", &models.Transaction{Type: models.TrxTypePrepaid, SerialNumber: &sn, SerialDupOf: &dup}},
+		{"postpaid", &models.Transaction{Type: models.TrxTypePayment, SerialNumber: &sn}},
+		{"no serial number", &models.Transaction{Type: models.TrxTypePrepaid}},
+	}
+	for _, tc := range cases {
+		if s.checkDuplicateSerialNumber(tc.trx) {
+			t.Errorf("%s: transaction unexpectedly held", tc.name)
+		}
+	}
+}
+
+type fakeAttemptSummaries struct {
+	summary repository.TransactionAttemptSummary
+	err     error
+	calls   int
+}
+
+func (f *fakeAttemptSummaries) GetAttemptSummary(int) (*repository.TransactionAttemptSummary, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &f.summary, nil
+}
+
+func TestCallbackAttemptInfo(t *testing.T) {
+	optedIn := &models.Client{CallbackAttemptInfo: true}
+	cases := []struct {
+		name    string
+		client  *models.Client
+		event   string
+		summary repository.TransactionAttemptSummary
+		err     error
+		want    *callbackAttemptInfo
+	}{
+		{"first attempt", optedIn, "transaction.success", repository.TransactionAttemptSummary{AttemptCount: 1, TargetCount: 1}, nil,
+			&callbackAttemptInfo{AttemptCount: 1}},
+		{"retries on the same SKU", optedIn, "transaction.success", repository.TransactionAttemptSummary{AttemptCount: 3, TargetCount: 1}, nil,
+			&callbackAttemptInfo{AttemptCount: 3}},
+		{"after fallback to backup", optedIn, "transaction.success", repository.TransactionAttemptSummary{AttemptCount: 3, TargetCount: 2, BackupUsed: true}, nil,
+			&callbackAttemptInfo{AttemptCount: 3, FallbackUsed: true, BackupUsed: true}},
+		{"not opted in", &models.Client{}, "transaction.success", repository.TransactionAttemptSummary{AttemptCount: 2}, nil, nil},
+		{"failure event", optedIn, "transaction.failed", repository.TransactionAttemptSummary{AttemptCount: 2}, nil, nil},
+		{"summary unavailable", optedIn, "transaction.success", repository.TransactionAttemptSummary{}, errors.New("db down"), nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := &fakeAttemptSummaries{summary: tc.summary, err: tc.err}
+			s := &CallbackService{attempts: attempts}
+			got := s.attemptInfo(tc.client, &models.Transaction{ID: 1}, tc.event)
+			if (got == nil) != (tc.want == nil) || (got != nil && *got != *tc.want) {
+				t.Fatalf("attemptInfo = %+v, want %+v", got, tc.want)
+			}
+			if tc.want == nil && tc.err == nil && attempts.calls != 0 {
+				t.Fatal("attempt summary loaded for a callback that does not carry it")
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+// completionHealthRefresh bounds how long a provider's average response time
+// is reused before today's health row is read again.
+const completionHealthRefresh = time.Minute
+
+// ProviderHealthReader returns today's health row of a provider, nil when
+// there is none yet (repository.PPOBProviderRepository).
+type ProviderHealthReader interface {
+	GetProviderHealth(providerID int) (*models.PPOBProviderHealth, error)
+}
+
+// CompletionEstimator gives a Processing transaction a best-effort
+// estimatedCompletionAt: when the status check worker next asks the provider
+// (after the stale delay, then up to one tick), or when a deferred provider
+// continuation is next due, plus the provider's average response time today.
+// A provider callback usually settles it sooner.
+type CompletionEstimator struct {
+	health     ProviderHealthReader
+	interval   time.Duration
+	staleAfter time.Duration
+	minAges    map[string]time.Duration // provider code -> earliest status check age
+	now        func() time.Time
+
+	mu  sync.Mutex
+	avg map[int]cachedResponseTime
+}
+
+type cachedResponseTime struct {
+	d        time.Duration
+	loadedAt time.Time
+}
+
+// NewCompletionEstimator mirrors the status check worker schedule: interval
+// between runs, staleAfter before a transaction is checked and minAges for
+// providers checked later than that (e.g. Kiosbank).
+func NewCompletionEstimator(health ProviderHealthReader, interval, staleAfter time.Duration, minAges map[string]time.Duration) *CompletionEstimator {
+	return &CompletionEstimator{
+		health:     health,
+		interval:   interval,
+		staleAfter: staleAfter,
+		minAges:    minAges,
+		now:        time.Now,
+		avg:        make(map[int]cachedResponseTime),
+	}
+}
+
+// Estimate returns the estimated completion time of trx, or nil when it is
+// not Processing (final, or held for review with no schedule to go by).
+func (e *CompletionEstimator) Estimate(trx *models.Transaction) *time.Time {
+	if e == nil || trx == nil || trx.Status != models.StatusProcessing {
+		return nil
+	}
+	wait := e.staleAfter
+	if minAge := e.minAges[derefString(trx.ProviderCode)]; minAge > wait {
+		wait = minAge
+	}
+	now := e.now()
+	checkAt := trx.CreatedAt.Add(wait)
+	if trx.NextRetryAt != nil {
+		// Remaining providers are tried again once next_retry_at <= NOW().
+		checkAt = *trx.NextRetryAt
+	}
+	if checkAt.Before(now) {
+		checkAt = now
+	}
+	eta := checkAt.Add(e.interval)
+	if trx.ProviderID != nil {
+		eta = eta.Add(e.responseTime(*trx.ProviderID, now))
+	}
+	eta = eta.Truncate(time.Second)
+	return &eta
+}
+
+// responseTime is the provider's average response time today; 0 when unknown.
+func (e *CompletionEstimator) responseTime(providerID int, now time.Time) time.Duration {
+	e.mu.Lock()
+	cached, ok := e.avg[providerID]
+	e.mu.Unlock()
+	if ok && now.Sub(cached.loadedAt) < completionHealthRefresh {
+		return cached.d
+	}
+
+	var d time.Duration
+	h, err := e.health.GetProviderHealth(providerID)
+	if err != nil {
+		log.Warn().Err(err).Int("provider_id", providerID).Msg("completion estimate: provider health unavailable")
+	} else if h != nil {
+		d = time.Duration(h.AvgResponseTimeMs) * time.Millisecond
+	}
+	e.mu.Lock()
+	e.avg[providerID] = cachedResponseTime{d: d, loadedAt: now}
+	e.mu.Unlock()
+	return d
+}
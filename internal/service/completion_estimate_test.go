@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+type fakeHealthReader struct {
+	avgMs map[int]int
+	calls int
+}
+
+func (f *fakeHealthReader) GetProviderHealth(providerID int) (*models.PPOBProviderHealth, error) {
+	f.calls++
+	ms, ok := f.avgMs[providerID]
+	if !ok {
+		return nil, nil
+	}
+	return &models.PPOBProviderHealth{ProviderID: providerID, AvgResponseTimeMs: ms}, nil
+}
+
+func TestCompletionEstimatorEstimate(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	health := &fakeHealthReader{avgMs: map[int]int{1: 2000}}
+	e := NewCompletionEstimator(health, 10*time.Second, 10*time.Second,
+		map[string]time.Duration{string(models.ProviderKiosbank): time.Minute})
+	e.now = func() time.Time { return now }
+
+	providerID := 1
+	kiosbank := string(models.ProviderKiosbank)
+	retryAt := now.Add(45 * time.Second)
+	overdue := now.Add(-5 * time.Second)
+	cases := []struct {
+		name string
+		trx  models.Transaction
+		want time.Duration // from now; -1 = no estimate
+	}{
+		{"fresh", models.Transaction{Status: models.StatusProcessing, CreatedAt: now, ProviderID: &providerID}, 22 * time.Second},
+		{"already due", models.Transaction{Status: models.StatusProcessing, CreatedAt: now.Add(-time.Minute)}, 10 * time.Second},
+		{"provider min age", models.Transaction{Status: models.StatusProcessing, CreatedAt: now, ProviderCode: &kiosbank}, 70 * time.Second},
+		{"continuation scheduled", models.Transaction{Status: models.StatusProcessing, CreatedAt: now.Add(-time.Minute), NextRetryAt: &retryAt}, 55 * time.Second},
+		{"continuation due", models.Transaction{Status: models.StatusProcessing, CreatedAt: now, NextRetryAt: &overdue}, 10 * time.Second},
+		{"success", models.Transaction{Status: models.StatusSuccess, CreatedAt: now}, -1},
+		{"held pending", models.Transaction{Status: models.StatusPending, CreatedAt: now}, -1},
+	}
+	for _, tc := range cases {
+		got := e.Estimate(&tc.trx)
+		if tc.want < 0 {
+			if got != nil {
+				t.Errorf("%s: got %v, want no estimate", tc.name, got)
+			}
+			continue
+		}
+		if got == nil || !got.Equal(now.Add(tc.want)) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, now.Add(tc.want))
+		}
+	}
+
+	e.Estimate(&models.Transaction{Status: models.StatusProcessing, CreatedAt: now, ProviderID: &providerID})
+	if health.calls != 1 {
+		t.Errorf("provider health read %d times, want 1 (cached)", health.calls)
+	}
+}
+
+func TestCompletionEstimatorNil(t *testing.T) {
+	var e *CompletionEstimator
+	if got := e.Estimate(&models.Transaction{Status: models.StatusProcessing}); got != nil {
+		t.Fatalf("nil estimator: got %v", got)
+	}
+}
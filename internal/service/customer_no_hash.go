@@ -0,0 +1,27 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+// hashCustomerNo returns the salted (HMAC-SHA256) hex digest of customerNo.
+func hashCustomerNo(salt, customerNo string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(strings.TrimSpace(customerNo)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// customerNoHashFor returns the hash to persist for client's transaction, or
+// nil when the client has not opted in or no salt is configured.
+func (s *TransactionService) customerNoHashFor(client *models.Client, customerNo string) *string {
+	if client == nil || !client.HashCustomerNo || s.customerNoSalt == "" {
+		return nil
+	}
+	h := hashCustomerNo(s.customerNoSalt, customerNo)
+	return &h
+}
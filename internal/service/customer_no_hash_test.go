@@ -0,0 +1,29 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+func TestCustomerNoHashFor(t *testing.T) {
+	t.Parallel()
+
+	svc := &TransactionService{customerNoSalt: "pepper"}
+	optedIn := &models.Client{HashCustomerNo: true}
+
+	h1 := svc.customerNoHashFor(optedIn, "081234567890")
+	h2 := svc.customerNoHashFor(optedIn, " 081234567890 ")
+	if h1 == nil || h2 == nil || *h1 != *h2 || len(*h1) != 64 {
+		t.Fatalf("expected stable 64-char hash, got %v / %v", h1, h2)
+	}
+	if *h1 == hashCustomerNo("other", "081234567890") {
+		t.Fatal("hash must depend on the salt")
+	}
+	if got := svc.customerNoHashFor(&models.Client{}, "081234567890"); got != nil {
+		t.Fatalf("client not opted in, got %v", *got)
+	}
+	if got := (&TransactionService{}).customerNoHashFor(optedIn, "081234567890"); got != nil {
+		t.Fatalf("no salt configured, got %v", *got)
+	}
+}
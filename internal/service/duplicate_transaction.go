@@ -0,0 +1,53 @@
+package service
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+// checkLikelyDuplicate looks for a transaction like trx the client created
+// within its duplicate window under another referenceId: a client-side
+// double submit with a fresh referenceId. A match flags trx with
+// PossibleDuplicateOf, or fails with ErrPossibleDuplicate when the client
+// blocks duplicates. It is best effort: a lookup error lets trx through, and
+// two requests racing each other can both pass.
+func (s *TransactionService) checkLikelyDuplicate(client *models.Client, trx *models.Transaction) error {
+	return checkLikelyDuplicate(s.trxRepo, client, trx)
+}
+
+// DuplicateFinder looks up a likely duplicate of a transaction
+// (repository.TransactionRepository).
+type DuplicateFinder interface {
+	FindLikelyDuplicate(trx *models.Transaction, since time.Time) (string, error)
+}
+
+func checkLikelyDuplicate(finder DuplicateFinder, client *models.Client, trx *models.Transaction) error {
+	window := client.DuplicateWindow()
+	if window <= 0 {
+		return nil
+	}
+	dupOf, err := finder.FindLikelyDuplicate(trx, time.Now().Add(-window))
+	if err != nil {
+		log.Error().Err(err).Int("client_id", client.ID).Msg("FindLikelyDuplicate failed")
+		return nil
+	}
+	if dupOf == "" {
+		return nil
+	}
+
+	log.Warn().
+		Int("client_id", client.ID).
+		Str("reference_id", trx.ReferenceID).
+		Str("duplicate_of", dupOf).
+		Bool("blocked", client.BlocksDuplicates()).
+		Msg("Likely duplicate transaction")
+	if client.BlocksDuplicates() {
+		return utils.ErrPossibleDuplicate
+	}
+	trx.PossibleDuplicateOf = &dupOf
+	return nil
+}
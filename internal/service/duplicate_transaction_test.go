@@ -0,0 +1,55 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+type fakeDuplicateFinder struct {
+	dupOf string
+	since time.Time
+	calls int
+}
+
+func (f *fakeDuplicateFinder) FindLikelyDuplicate(trx *models.Transaction, since time.Time) (string, error) {
+	f.calls++
+	f.since = since
+	return f.dupOf, nil
+}
+
+func TestCheckLikelyDuplicate(t *testing.T) {
+	cases := []struct {
+		name     string
+		client   models.Client
+		dupOf    string
+		wantErr  error
+		wantFlag bool
+	}{
+		{"warn flags the transaction", models.Client{DuplicateWindowSeconds: 60, DuplicateAction: models.DuplicateActionWarn}, "GRB-1", nil, true},
+		{"block rejects it", models.Client{DuplicateWindowSeconds: 60, DuplicateAction: models.DuplicateActionBlock}, "GRB-1", utils.ErrPossibleDuplicate, false},
+		{"no match passes", models.Client{DuplicateWindowSeconds: 60, DuplicateAction: models.DuplicateActionBlock}, "", nil, false},
+	}
+	for _, tc := range cases {
+		finder := &fakeDuplicateFinder{dupOf: tc.dupOf}
+		trx := &models.Transaction{ReferenceID: "ref-2"}
+		err := checkLikelyDuplicate(finder, &tc.client, trx)
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.wantErr)
+		}
+		if flagged := trx.PossibleDuplicateOf != nil && *trx.PossibleDuplicateOf == tc.dupOf; flagged != tc.wantFlag {
+			t.Errorf("%s: PossibleDuplicateOf = %v, want flagged %v", tc.name, trx.PossibleDuplicateOf, tc.wantFlag)
+		}
+		if d := time.Since(finder.since); d < 59*time.Second || d > 61*time.Second {
+			t.Errorf("%s: looked back %v, want the 60s window", tc.name, d)
+		}
+	}
+
+	finder := &fakeDuplicateFinder{dupOf: "GRB-1"}
+	if err := checkLikelyDuplicate(finder, &models.Client{DuplicateAction: models.DuplicateActionBlock}, &models.Transaction{}); err != nil || finder.calls != 0 {
+		t.Fatalf("window off: err = %v after %d lookups, want no lookup", err, finder.calls)
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+// inquiryCategoryPolicy bounds the multi-provider inquiry loop for one
+// product category. Zero fields leave the default behavior.
+type inquiryCategoryPolicy struct {
+	timeout  time.Duration // per-provider inquiry timeout
+	attempts int           // providers tried before giving up
+}
+
+// SetInquiryCategoryTimeouts sets a per-provider inquiry timeout for the
+// given product categories, so slow providers of latency-sensitive
+// categories (e.g. PDAM) fail over quickly. Inquiry does not charge, so
+// cancelling it is safe.
+func (s *TransactionService) SetInquiryCategoryTimeouts(timeouts map[string]time.Duration) {
+	for category, d := range timeouts {
+		if d <= 0 {
+			continue
+		}
+		p := s.inquiryPolicyFor(category)
+		p.timeout = d
+		s.setInquiryPolicy(category, p)
+	}
+}
+
+// SetInquiryCategoryAttempts caps how many providers an inquiry of the given
+// product categories tries.
+func (s *TransactionService) SetInquiryCategoryAttempts(attempts map[string]int) {
+	for category, n := range attempts {
+		if n <= 0 {
+			continue
+		}
+		p := s.inquiryPolicyFor(category)
+		p.attempts = n
+		s.setInquiryPolicy(category, p)
+	}
+}
+
+func (s *TransactionService) setInquiryPolicy(category string, p inquiryCategoryPolicy) {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if category == "" {
+		return
+	}
+	if s.inquiryPolicy == nil {
+		s.inquiryPolicy = make(map[string]inquiryCategoryPolicy)
+	}
+	s.inquiryPolicy[category] = p
+}
+
+func (s *TransactionService) inquiryPolicyFor(category string) inquiryCategoryPolicy {
+	return s.inquiryPolicy[strings.ToLower(strings.TrimSpace(category))]
+}
+
+// inquiryPolicyForProduct returns the inquiry policy of product's category.
+func (s *TransactionService) inquiryPolicyForProduct(product *models.Product) inquiryCategoryPolicy {
+	if product == nil {
+		return inquiryCategoryPolicy{}
+	}
+	return s.inquiryPolicyFor(product.Category)
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+type slowInquiryClient struct {
+	PPOBProviderClient
+	delay time.Duration
+}
+
+func (c slowInquiryClient) Inquiry(ctx context.Context, _ *ProviderRequest) (*ProviderResponse, error) {
+	select {
+	case <-time.After(c.delay):
+		return &ProviderResponse{Success: true}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestInquiryCategoryPolicy(t *testing.T) {
+	s := &TransactionService{}
+	s.SetInquiryCategoryTimeouts(map[string]time.Duration{"PDAM": 5 * time.Second, "pln": 0})
+	s.SetInquiryCategoryAttempts(map[string]int{"pdam": 2, "bpjs": 1})
+
+	if got := s.inquiryPolicyForProduct(&models.Product{Category: "Pdam"}); got.timeout != 5*time.Second || got.attempts != 2 {
+		t.Fatalf("pdam policy = %+v, want 5s/2", got)
+	}
+	if got := s.inquiryPolicyForProduct(&models.Product{Category: "BPJS"}); got.timeout != 0 || got.attempts != 1 {
+		t.Fatalf("bpjs policy = %+v, want no timeout/1", got)
+	}
+	if got := s.inquiryPolicyForProduct(&models.Product{Category: "PLN"}); got != (inquiryCategoryPolicy{}) {
+		t.Fatalf("pln policy = %+v, want default", got)
+	}
+}
+
+func TestInquireProviderTimeout(t *testing.T) {
+	s := &TransactionService{}
+	slow := slowInquiryClient{delay: time.Second}
+
+	_, err := s.inquireProvider(context.Background(), slow, &ProviderRequest{}, 10*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "timed out after 10ms") {
+		t.Fatalf("err = %v, want inquiry timeout", err)
+	}
+
+	fast := slowInquiryClient{delay: time.Millisecond}
+	if resp, err := s.inquireProvider(context.Background(), fast, &ProviderRequest{}, time.Second); err != nil || !resp.Success {
+		t.Fatalf("fast inquiry = %+v, %v", resp, err)
+	}
+	if resp, err := s.inquireProvider(context.Background(), fast, &ProviderRequest{}, 0); err != nil || !resp.Success {
+		t.Fatalf("unbounded inquiry = %+v, %v", resp, err)
+	}
+}
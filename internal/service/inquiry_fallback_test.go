@@ -0,0 +1,42 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+func TestDigiflazzInquiryBlocked(t *testing.T) {
+	t.Parallel()
+
+	svc := &TransactionService{}
+	if svc.digiflazzInquiryBlocked(&models.Product{Category: "PLN"}) {
+		t.Fatal("fallback must stay enabled by default")
+	}
+
+	svc.SetNoDigiflazzInquiryCategories([]string{" pln ", "BPJS"})
+	if !svc.digiflazzInquiryBlocked(&models.Product{Category: "PLN"}) {
+		t.Fatal("PLN should be blocked (case-insensitive)")
+	}
+	if !svc.digiflazzInquiryBlocked(&models.Product{Category: "bpjs"}) {
+		t.Fatal("BPJS should be blocked")
+	}
+	if svc.digiflazzInquiryBlocked(&models.Product{Category: "PDAM"}) {
+		t.Fatal("PDAM is not configured and must keep the fallback")
+	}
+}
+
+func TestLowerCategories(t *testing.T) {
+	t.Parallel()
+
+	got := lowerCategories([]string{" Voucher Game ", "", "  ", "PLN"})
+	want := []string{"voucher game", "pln"}
+	if len(got) != len(want) {
+		t.Fatalf("lowerCategories() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("lowerCategories() = %q, want %q", got, want)
+		}
+	}
+}
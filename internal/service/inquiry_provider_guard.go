@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/cache"
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+// SetReinquireCategories makes payments of the given product categories
+// re-inquire with another provider when the inquiry's provider can no longer
+// take them, instead of failing with REINQUIRY_REQUIRED.
+func (s *TransactionService) SetReinquireCategories(categories []string) {
+	s.reinquire = make(map[string]bool, len(categories))
+	for _, c := range lowerCategories(categories) {
+		s.reinquire[c] = true
+	}
+}
+
+// inquiryProviderUsable reports whether the provider that answered an
+// inquiry can still take its payment: its adapter is registered and neither
+// the provider nor its SKU mapping was disabled or removed since. A failed
+// lookup counts as usable so it does not block payments.
+func (s *TransactionService) inquiryProviderUsable(inquiry *cache.InquiryData) bool {
+	if s.providerRouter.GetAdapter(inquiry.ProviderCode) == nil {
+		return false
+	}
+	options, err := s.providerRouter.GetProviderOptionsAll(inquiry.ProductID)
+	if err != nil {
+		log.Warn().Err(err).Str("inquiry_trx_id", inquiry.TransactionID).Msg("Could not check the inquiry provider, paying with it")
+		return true
+	}
+	for _, opt := range options {
+		if opt.ProviderID == inquiry.ProviderID && opt.ProviderSKUID == inquiry.ProviderSKUID {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceStaleInquiry handles a payment whose inquiry provider is no longer
+// usable. The stale inquiry is dropped so it can be neither paid nor served
+// from the cache again. In re-inquire categories the bill is inquired again
+// with the other providers and the fresh inquiry is returned to be paid,
+// provided it succeeds for no more than the quoted amount; otherwise the
+// client gets ErrReinquiryRequired, and a fresh quote is already cached for
+// its next inquiry.
+func (s *TransactionService) replaceStaleInquiry(
+	ctx context.Context,
+	req *CreateTransactionRequest,
+	client *models.Client,
+	product *models.Product,
+	stale *cache.InquiryData,
+	isSandbox bool,
+) (*cache.InquiryData, error) {
+	log.Warn().
+		Str("provider", stale.ProviderCode).
+		Str("inquiry_trx_id", stale.TransactionID).
+		Str("category", product.Category).
+		Msg("Inquiry provider no longer usable for payment")
+	if err := s.inquiryCache.Delete(ctx, stale); err != nil {
+		log.Warn().Err(err).Str("inquiry_trx_id", stale.TransactionID).Msg("failed to delete stale inquiry cache")
+	}
+	if !s.reinquire[strings.ToLower(product.Category)] {
+		return nil, utils.ErrReinquiryRequired
+	}
+
+	options, err := s.providerRouter.GetProviderOptionsPostpaid(product.ID)
+	if err != nil {
+		return nil, utils.ErrReinquiryRequired
+	}
+	others := make([]models.ProviderOption, 0, len(options))
+	for _, opt := range options {
+		if string(opt.ProviderCode) != stale.ProviderCode {
+			others = append(others, opt)
+		}
+	}
+	if len(others) == 0 {
+		return nil, utils.ErrReinquiryRequired
+	}
+
+	trxID, err := s.trxRepo.GenerateTransactionID(s.transactionIDPrefix(client))
+	if err != nil {
+		return nil, err
+	}
+	wib := time.FixedZone("WIB", 7*3600)
+	nowWIB := time.Now().In(wib)
+	eod := time.Date(nowWIB.Year(), nowWIB.Month(), nowWIB.Day(), 23, 59, 59, 0, wib)
+
+	inquiryReq := *req
+	inquiryReq.Type = "inquiry"
+	inquiryReq.Provider = ""
+	inquiryReq.TransactionID = ""
+	trx, err := s.executeInquiryWithProviders(ctx, &inquiryReq, client, product, trxID, others, eod, isSandbox)
+	if err != nil || trx.Status != models.StatusSuccess {
+		return nil, utils.ErrReinquiryRequired
+	}
+	fresh, err := s.inquiryCache.GetByTransactionID(ctx, trx.TransactionID)
+	if err != nil {
+		return nil, utils.ErrReinquiryRequired
+	}
+	if err := s.acceptReinquiry(stale, fresh, isSandbox); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("stale_provider", stale.ProviderCode).
+		Str("provider", fresh.ProviderCode).
+		Str("stale_inquiry_trx_id", stale.TransactionID).
+		Str("inquiry_trx_id", fresh.TransactionID).
+		Msg("Payment re-inquired with another provider")
+	return fresh, nil
+}
+
+// acceptReinquiry reports whether the fresh inquiry may be paid in place of
+// the stale one: it must cost no more than the quote and, under the price
+// guard, have a positive amount like any inquiry that is paid.
+func (s *TransactionService) acceptReinquiry(stale, fresh *cache.InquiryData, isSandbox bool) error {
+	if fresh.Amount > stale.Amount {
+		log.Warn().
+			Str("inquiry_trx_id", fresh.TransactionID).
+			Int("quoted", stale.Amount).
+			Int("amount", fresh.Amount).
+			Msg("Re-inquiry costs more than the quote, client must re-inquire")
+		return utils.ErrReinquiryRequired
+	}
+	if err := s.checkPrice(&fresh.Amount, isSandbox); err != nil {
+		log.Warn().Str("inquiry_trx_id", fresh.TransactionID).Msg("Payment rejected: re-inquiry has no positive amount")
+		return err
+	}
+	return nil
+}
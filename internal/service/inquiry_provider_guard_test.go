@@ -0,0 +1,51 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/cache"
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+func TestInquiryProviderUnusableWithoutAdapter(t *testing.T) {
+	s := &TransactionService{providerRouter: &ProviderRouter{}}
+	inquiry := &cache.InquiryData{TransactionID: "GRB-1", ProviderCode: "kiosbank", ProviderID: 1, ProviderSKUID: 2}
+	if s.inquiryProviderUsable(inquiry) {
+		t.Fatal("an inquiry whose provider has no registered adapter should not be payable with it")
+	}
+}
+
+func TestSetReinquireCategories(t *testing.T) {
+	s := &TransactionService{}
+	s.SetReinquireCategories([]string{" PLN ", "", "bpjs"})
+	if !s.reinquire["pln"] || !s.reinquire["bpjs"] || len(s.reinquire) != 2 {
+		t.Fatalf("reinquire = %v, want pln and bpjs", s.reinquire)
+	}
+}
+
+func TestAcceptReinquiry(t *testing.T) {
+	stale := &cache.InquiryData{TransactionID: "GRB-1", Amount: 152500}
+	cases := []struct {
+		name         string
+		requirePrice bool
+		sandbox      bool
+		amount       int
+		want         error
+	}{
+		{"same amount is paid", true, false, 152500, nil},
+		{"lower amount is paid", true, false, 150000, nil},
+		{"higher amount needs a new inquiry", true, false, 155000, utils.ErrReinquiryRequired},
+		{"zero amount under the price guard", true, false, 0, utils.ErrPriceUnavailable},
+		{"zero amount without the guard", false, false, 0, nil},
+		{"zero amount in sandbox", true, true, 0, nil},
+	}
+	for _, tc := range cases {
+		s := &TransactionService{}
+		s.SetRequirePositivePrice(tc.requirePrice)
+		fresh := &cache.InquiryData{TransactionID: "GRB-2", Amount: tc.amount}
+		if err := s.acceptReinquiry(stale, fresh, tc.sandbox); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package service
+
+import "testing"
+
+func TestPaymentSKUMismatch(t *testing.T) {
+	t.Parallel()
+
+	svc := &TransactionService{}
+	if svc.paymentSKUMismatch("PLNPOST2", "PLNPOST") {
+		t.Fatal("product-level matching is the default; a different SKU must pass here")
+	}
+
+	svc.SetExactPaymentSKU(true)
+	if !svc.paymentSKUMismatch("PLNPOST2", "PLNPOST") {
+		t.Fatal("exact matching must reject a different SKU")
+	}
+	if svc.paymentSKUMismatch("PLNPOST", "PLNPOST") {
+		t.Fatal("exact matching must accept the quoted SKU")
+	}
+}
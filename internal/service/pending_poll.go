@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+// pendingPoll re-checks pending results of some providers within the request,
+// so ones that settle in a few seconds answer synchronously.
+type pendingPoll struct {
+	providers map[models.ProviderCode]bool
+	attempts  int
+	interval  time.Duration
+}
+
+// SetPendingPoll makes a pending prepaid or payment result of one of
+// providers trigger up to attempts status checks, interval apart, before
+// Execute returns. A settled success is returned as such; a settled failure
+// fails over like any other failed attempt. Still pending after the last
+// check, the transaction is left to callbacks and the status check worker.
+// No providers or attempts <= 0 turns it off.
+func (r *ProviderRouter) SetPendingPoll(providers []models.ProviderCode, attempts int, interval time.Duration) {
+	if len(providers) == 0 || attempts <= 0 {
+		r.pendingPoll = nil
+		return
+	}
+	p := &pendingPoll{providers: make(map[models.ProviderCode]bool, len(providers)), attempts: attempts, interval: interval}
+	for _, code := range providers {
+		p.providers[code] = true
+	}
+	r.pendingPoll = p
+}
+
+// settlePending polls the status of a pending resp from client when enabled
+// for its provider, returning the first settled response, or resp when it
+// stays pending. Inquiries are never polled.
+func (r *ProviderRouter) settlePending(ctx context.Context, client PPOBProviderClient, req *ProviderRequest, resp *ProviderResponse) *ProviderResponse {
+	p := r.pendingPoll
+	if p == nil || resp == nil || !resp.Pending || req.Type == ProviderTrxInquiry || !p.providers[client.Code()] {
+		return resp
+	}
+	refID := resp.ProviderRefID
+	if refID == "" {
+		return resp
+	}
+	ctx = WithSandbox(ctx, req.IsSandbox)
+
+	for i := 0; i < p.attempts; i++ {
+		if !sleepContext(ctx, p.interval) || attemptDeadlineExceeded(ctx) {
+			break
+		}
+		status, err := client.CheckStatus(ctx, refID)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("provider", string(client.Code())).
+				Str("ref_id", req.RefID).
+				Msg("Pending poll status check failed")
+			continue
+		}
+		if status == nil || status.Pending || r.AmbiguousAsPending(status) {
+			continue
+		}
+		if status.ProviderRefID == "" {
+			status.ProviderRefID = refID
+		}
+		log.Info().
+			Str("provider", string(client.Code())).
+			Str("ref_id", req.RefID).
+			Bool("success", status.Success).
+			Int("checks", i+1).
+			Msg("Pending transaction settled by status poll")
+		return status
+	}
+	return resp
+}
+
+// sleepContext waits for d, reporting false when ctx ends first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
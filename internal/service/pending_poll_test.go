@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+// pendingThenSettledClient answers status checks from statuses in order.
+type pendingThenSettledClient struct {
+	PPOBProviderClient
+	statuses []*ProviderResponse
+	checks   int
+	refIDs   []string
+}
+
+func (c *pendingThenSettledClient) Code() models.ProviderCode { return models.ProviderKiosbank }
+func (c *pendingThenSettledClient) CheckStatus(_ context.Context, refID string) (*ProviderResponse, error) {
+	c.refIDs = append(c.refIDs, refID)
+	status := c.statuses[c.checks]
+	c.checks++
+	return status, nil
+}
+
+func TestSettlePendingResolvesOnSecondCheck(t *testing.T) {
+	client := &pendingThenSettledClient{statuses: []*ProviderResponse{
+		{Pending: true, Status: "Pending"},
+		{Success: true, Status: "Success", SerialNumber: "SN123"},
+	}}
+	r := &ProviderRouter{}
+	r.SetPendingPoll([]models.ProviderCode{models.ProviderKiosbank}, 3, 0)
+
+	pending := &ProviderResponse{Pending: true, ProviderRefID: "KB-1"}
+	got := r.settlePending(context.Background(), client, &ProviderRequest{Type: ProviderTrxPrepaid}, pending)
+	if !got.Success || got.SerialNumber != "SN123" || got.ProviderRefID != "KB-1" {
+		t.Fatalf("settled = %+v, want success with SN123 and ref KB-1", got)
+	}
+	if client.checks != 2 || client.refIDs[0] != "KB-1" {
+		t.Fatalf("checks = %d with refs %v, want 2 for KB-1", client.checks, client.refIDs)
+	}
+}
+
+func TestSettlePendingBounded(t *testing.T) {
+	client := &pendingThenSettledClient{statuses: []*ProviderResponse{
+		{Pending: true}, {Pending: true}, {Success: true},
+	}}
+	r := &ProviderRouter{}
+	r.SetPendingPoll([]models.ProviderCode{models.ProviderKiosbank}, 2, 0)
+
+	pending := &ProviderResponse{Pending: true, ProviderRefID: "KB-2"}
+	if got := r.settlePending(context.Background(), client, &ProviderRequest{Type: ProviderTrxPayment}, pending); got != pending {
+		t.Fatalf("settled = %+v, want the original pending response", got)
+	}
+	if client.checks != 2 {
+		t.Fatalf("checks = %d, want 2", client.checks)
+	}
+}
+
+func TestSettlePendingOffByDefault(t *testing.T) {
+	client := &pendingThenSettledClient{statuses: []*ProviderResponse{{Success: true}}}
+	pending := &ProviderResponse{Pending: true, ProviderRefID: "KB-3"}
+
+	r := &ProviderRouter{}
+	if got := r.settlePending(context.Background(), client, &ProviderRequest{Type: ProviderTrxPrepaid}, pending); got != pending || client.checks != 0 {
+		t.Fatalf("default router polled: %+v after %d checks", got, client.checks)
+	}
+
+	r.SetPendingPoll([]models.ProviderCode{models.ProviderAlterra}, 3, 0)
+	if got := r.settlePending(context.Background(), client, &ProviderRequest{Type: ProviderTrxPrepaid}, pending); got != pending || client.checks != 0 {
+		t.Fatalf("unlisted provider polled: %+v after %d checks", got, client.checks)
+	}
+}
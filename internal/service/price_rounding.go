@@ -0,0 +1,21 @@
+package service
+
+import (
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+// priceRoundingFor returns the client's own rounding rule when configured and
+// valid, otherwise def. An invalid client rule is logged and ignored.
+func priceRoundingFor(client *models.Client, def models.PriceRounding) models.PriceRounding {
+	if client == nil || client.PriceRounding == nil {
+		return def
+	}
+	r, err := models.ParsePriceRounding(*client.PriceRounding)
+	if err != nil {
+		log.Warn().Err(err).Int("client_id", client.ID).Msg("ignoring invalid clients.price_rounding")
+		return def
+	}
+	return r
+}
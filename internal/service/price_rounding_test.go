@@ -0,0 +1,104 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/cache"
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+func TestPriceRoundingBoundaries(t *testing.T) {
+	cases := []struct {
+		rule  string
+		price int
+		want  int
+	}{
+		{"exact", 10123, 10123},
+		{"up:100", 10100, 10100},
+		{"up:100", 10101, 10200},
+		{"up:100", 10199, 10200},
+		{"down:100", 10199, 10100},
+		{"down:100", 10100, 10100},
+		{"nearest:100", 10149, 10100},
+		{"nearest:100", 10150, 10200},
+		{"nearest:1000", 12499, 12000},
+		{"nearest:1000", 12500, 13000},
+		{"up:1000", 999, 1000},
+		{"down:1000", 999, 1000},
+		{"nearest:1000", 400, 1000},
+		{"up:1", 10123, 10123},
+		{"up:100", 0, 0},
+	}
+	for _, tc := range cases {
+		r, err := models.ParsePriceRounding(tc.rule)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.rule, err)
+		}
+		if got := r.Round(tc.price); got != tc.want {
+			t.Errorf("%s.Round(%d) = %d, want %d", tc.rule, tc.price, got, tc.want)
+		}
+	}
+}
+
+func TestParsePriceRoundingRejectsInvalid(t *testing.T) {
+	for _, rule := range []string{"up", "up:0", "up:-100", "ceil:100", "nearest:abc"} {
+		if _, err := models.ParsePriceRounding(rule); err == nil {
+			t.Errorf("ParsePriceRounding(%q) succeeded, want error", rule)
+		}
+	}
+	if r, err := models.ParsePriceRounding(" Up:500 "); err != nil || r.String() != "up:500" {
+		t.Errorf("ParsePriceRounding(\" Up:500 \") = %v, %v", r, err)
+	}
+}
+
+func TestPriceRoundingForClientOverride(t *testing.T) {
+	def := models.PriceRounding{Mode: models.RoundUp, Unit: 100}
+	own, bad := "nearest:1000", "sometimes"
+	cases := []struct {
+		client *models.Client
+		want   string
+	}{
+		{nil, "up:100"},
+		{&models.Client{}, "up:100"},
+		{&models.Client{PriceRounding: &own}, "nearest:1000"},
+		{&models.Client{PriceRounding: &bad}, "up:100"},
+	}
+	for _, tc := range cases {
+		if got := priceRoundingFor(tc.client, def).String(); got != tc.want {
+			t.Errorf("priceRoundingFor = %s, want %s", got, tc.want)
+		}
+	}
+}
+
+func TestPriceRoundingAppliesToAdmin(t *testing.T) {
+	def := models.PriceRounding{Mode: models.RoundUp, Unit: 100}
+	products := []ProductResponse{{Price: 150250, Admin: 2450}}
+	roundProductPrices(products, def)
+	if products[0].Price != 150300 || products[0].Admin != 2500 {
+		t.Fatalf("listed price/admin = %d/%d, want 150300/2500", products[0].Price, products[0].Admin)
+	}
+
+	s := &TransactionService{priceRounding: def}
+	own := "nearest:1000"
+	if got := s.quotedAdmin(&models.Client{}, 2450); got != 2500 {
+		t.Errorf("quotedAdmin = %d, want 2500", got)
+	}
+	if got := s.quotedAdmin(&models.Client{PriceRounding: &own}, 2450); got != 2000 {
+		t.Errorf("quotedAdmin with client rule = %d, want 2000", got)
+	}
+
+	// The client is quoted and charged the rounded admin; an inquiry cached
+	// before admin rounding keeps the provider's.
+	inquiry := &cache.InquiryData{Admin: 2450, QuotedAdmin: 2500}
+	if trx := s.cachedInquiryToTransaction(inquiry, 1, 1); trx.Admin != 2500 {
+		t.Errorf("inquiry admin = %d, want 2500", trx.Admin)
+	}
+	payment := &models.Transaction{}
+	applyInquiryReceipt(payment, inquiry)
+	if payment.Admin != 2500 {
+		t.Errorf("payment admin = %d, want 2500", payment.Admin)
+	}
+	if got := (&cache.InquiryData{Admin: 2450}).ClientAdmin(); got != 2450 {
+		t.Errorf("ClientAdmin without a quote = %d, want 2450", got)
+	}
+}
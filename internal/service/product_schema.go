@@ -0,0 +1,143 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+// ProductSchemaResponse describes what transacting a product takes, for
+// clients that build forms and validate input before submitting.
+type ProductSchemaResponse struct {
+	SkuCode  string `json:"skuCode"`
+	Category string `json:"category"`
+	Brand    string `json:"brand"`
+	Type     string `json:"type"`
+	// InquiryRequired is true for postpaid products: a payment must carry
+	// the transactionId of an inquiry for the same customerNo.
+	InquiryRequired bool `json:"inquiryRequired"`
+	// Requests holds a JSON Schema of the POST /v1/ppob/transaction body per
+	// request type: prepaid, or inquiry and payment.
+	Requests map[string]map[string]any `json:"requests"`
+	// ResponseFields lists the transaction fields a response to each request
+	// type can carry; fields without a value are omitted.
+	ResponseFields map[string][]string `json:"responseFields"`
+}
+
+// Response fields by request type; transactionResponseFields are common to
+// all. Together they cover every JSON field of models.Transaction.
+var (
+	transactionResponseFields = []string{
+		"transactionId", "referenceId", "skuCode", "customerNo", "type", "status",
+		"providerCode", "description", "failedCode", "failedReason",
+		"createdAt", "processedAt",
+	}
+	prepaidResponseFields = []string{
+		"price", "serialNumber", "retryCount", "nextRetryAt", "estimatedCompletionAt", "possibleDuplicateOf",
+	}
+	inquiryResponseFields = []string{"customerName", "amount", "admin", "price", "period", "expiredAt"}
+	paymentResponseFields = []string{
+		"customerName", "amount", "admin", "price", "period", "serialNumber",
+		"retryCount", "nextRetryAt", "estimatedCompletionAt", "possibleDuplicateOf",
+	}
+)
+
+// GetProductSchema returns the request schemas and response fields of a
+// product, with customerNo constrained by the product's customer number
+// rules, the same ones a transaction is validated against.
+func (s *ProductService) GetProductSchema(skuCode string) (*ProductSchemaResponse, error) {
+	product, err := s.productRepo.GetBySKUCode(skuCode)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrInvalidSKU
+		}
+		return nil, err
+	}
+	return productSchema(product, s.refIDMaxLen), nil
+}
+
+func productSchema(product *models.Product, refIDMaxLen int) *ProductSchemaResponse {
+	resp := &ProductSchemaResponse{
+		SkuCode:  product.SkuCode,
+		Category: product.Category,
+		Brand:    product.Brand,
+		Type:     string(product.Type),
+	}
+	if product.Type == models.ProductTypePostpaid {
+		resp.InquiryRequired = true
+		resp.Requests = map[string]map[string]any{
+			"inquiry": transactionRequestSchema(product, refIDMaxLen, "inquiry"),
+			"payment": transactionRequestSchema(product, refIDMaxLen, "payment"),
+		}
+		resp.ResponseFields = map[string][]string{
+			"inquiry": append(append([]string(nil), transactionResponseFields...), inquiryResponseFields...),
+			"payment": append(append([]string(nil), transactionResponseFields...), paymentResponseFields...),
+		}
+		return resp
+	}
+	resp.Requests = map[string]map[string]any{
+		"prepaid": transactionRequestSchema(product, refIDMaxLen, "prepaid"),
+	}
+	resp.ResponseFields = map[string][]string{
+		"prepaid": append(append([]string(nil), transactionResponseFields...), prepaidResponseFields...),
+	}
+	return resp
+}
+
+// transactionRequestSchema is the JSON Schema of a CreateTransactionRequest
+// of trxType for product, mirroring the checks the transaction service runs.
+func transactionRequestSchema(product *models.Product, refIDMaxLen int, trxType string) map[string]any {
+	if refIDMaxLen <= 0 || refIDMaxLen > maxReferenceIDLength {
+		refIDMaxLen = maxReferenceIDLength
+	}
+	properties := map[string]any{
+		"referenceId": map[string]any{
+			"type":      "string",
+			"minLength": 1,
+			"maxLength": refIDMaxLen,
+			"pattern":   referenceIDPattern.String(),
+		},
+		"skuCode":    map[string]any{"const": product.SkuCode},
+		"customerNo": customerNoSchema(product),
+		"type":       map[string]any{"const": trxType},
+		"provider":   map[string]any{"type": "string"},
+		"data":       map[string]any{"type": "object"},
+	}
+	required := []string{"referenceId", "skuCode", "customerNo", "type"}
+
+	if trxType == "inquiry" {
+		properties["forceFresh"] = map[string]any{"type": "boolean"}
+	} else {
+		properties["priority"] = map[string]any{"type": "integer", "minimum": 0, "maximum": 9}
+	}
+	if trxType == "payment" {
+		properties["transactionId"] = map[string]any{"type": "string", "minLength": 1}
+		required = append(required, "transactionId")
+	}
+
+	return map[string]any{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// customerNoSchema mirrors validateCustomerNo: lengths when set and a
+// pattern when set (Go RE2 syntax, which JSON Schema's ECMA-262 patterns
+// accept for the usual anchors, classes and counts).
+func customerNoSchema(product *models.Product) map[string]any {
+	schema := map[string]any{"type": "string", "minLength": 1}
+	if product.CustomerNoMinLength != nil && *product.CustomerNoMinLength > 0 {
+		schema["minLength"] = *product.CustomerNoMinLength
+	}
+	if product.CustomerNoMaxLength != nil && *product.CustomerNoMaxLength > 0 {
+		schema["maxLength"] = *product.CustomerNoMaxLength
+	}
+	if product.CustomerNoPattern != nil && *product.CustomerNoPattern != "" {
+		schema["pattern"] = *product.CustomerNoPattern
+	}
+	return schema
+}
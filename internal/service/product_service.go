@@ -1,10 +1,13 @@
 package service
 
 import (
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/GTDGit/gtd_api/internal/models"
 	"github.com/GTDGit/gtd_api/internal/repository"
+	"github.com/GTDGit/gtd_api/internal/utils"
 )
 
 // ProductService provides product-related business logic.
@@ -12,6 +15,17 @@ type ProductService struct {
 	productRepo  *repository.ProductRepository
 	skuRepo      *repository.SKURepository
 	providerRepo *repository.PPOBProviderRepository
+	router       *ProviderRouter // optional; enables EffectiveBestPrice
+
+	// priceRounding is the default rounding of listed prices;
+	// clients may override it (clients.price_rounding).
+	priceRounding models.PriceRounding
+	// sandboxRouting mirrors TransactionService.SetSandboxRouting: without it
+	// sandbox requests never reach the provider router.
+	sandboxRouting bool
+	// refIDMaxLen mirrors TransactionService.SetReferenceIDMaxLength for
+	// product schemas.
+	refIDMaxLen int
 }
 
 // NewProductService constructs a ProductService.
@@ -24,6 +38,29 @@ func NewProductServiceWithProviders(productRepo *repository.ProductRepository, s
 	return &ProductService{productRepo: productRepo, skuRepo: skuRepo, providerRepo: providerRepo}
 }
 
+// SetProviderRouter enables health-aware pricing in the product list.
+func (s *ProductService) SetProviderRouter(router *ProviderRouter) {
+	s.router = router
+}
+
+// SetPriceRounding sets the default rounding of listed prices and admin fees,
+// matching what the transaction service charges.
+func (s *ProductService) SetPriceRounding(r models.PriceRounding) {
+	s.priceRounding = r
+}
+
+// SetSandboxRouting tells the provider list that sandbox transactions go
+// through the provider router (see TransactionService.SetSandboxRouting).
+func (s *ProductService) SetSandboxRouting(enabled bool) {
+	s.sandboxRouting = enabled
+}
+
+// SetReferenceIDMaxLength sets the referenceId length limit product schemas
+// describe (see TransactionService.SetReferenceIDMaxLength).
+func (s *ProductService) SetReferenceIDMaxLength(n int) {
+	s.refIDMaxLen = n
+}
+
 // ProductResponse is the outward-facing payload for product listing.
 type ProductResponse struct {
 	SkuCode       string    `json:"skuCode"`
@@ -38,24 +75,60 @@ type ProductResponse struct {
 	Description   string    `json:"description"`
 	ProviderCount int       `json:"providerCount,omitempty"`
 	UpdatedAt     time.Time `json:"updatedAt"`
+
+	// EffectiveBestPrice is the best price among providers that are registered
+	// and healthy right now; absent when none of them is reachable.
+	EffectiveBestPrice *int `json:"effectiveBestPrice,omitempty"`
 }
 
-// GetProducts returns products with filters and pagination.
+// GetProducts returns products with filters and pagination, priced with the
+// client's rounding rule. availableNow leaves out products with no SKU or
+// provider available at the current WIB time (see GetProductAvailability).
 // If multi-provider is enabled, returns best price from all providers.
 // Otherwise falls back to the main SKU price (priority=1).
-func (s *ProductService) GetProducts(productType, category, brand, search string, page, limit int) ([]ProductResponse, int, error) {
-	// Use multi-provider pricing if available
+func (s *ProductService) GetProducts(client *models.Client, productType, category, brand, search string, availableNow bool, page, limit int) ([]ProductResponse, int, error) {
+	var products []ProductResponse
+	var total int
+	var err error
 	if s.providerRepo != nil {
-		return s.getProductsWithBestPrice(productType, category, brand, search, page, limit)
+		// Use multi-provider pricing if available
+		products, total, err = s.getProductsWithBestPrice(productType, category, brand, search, availableNow, page, limit)
+	} else {
+		// Fallback to legacy SKU-based pricing
+		products, total, err = s.getProductsLegacy(productType, category, brand, search, availableNow, page, limit)
 	}
+	if err != nil {
+		return nil, 0, err
+	}
+	roundProductPrices(products, priceRoundingFor(client, s.priceRounding))
+	return products, total, nil
+}
 
-	// Fallback to legacy SKU-based pricing
-	return s.getProductsLegacy(productType, category, brand, search, page, limit)
+// roundProductPrices applies r to the listed price, effective best price and
+// admin fee, as CreateTransaction does to the sell price and quoted admin.
+func roundProductPrices(products []ProductResponse, r models.PriceRounding) {
+	if r.Unit <= 1 {
+		return
+	}
+	for i := range products {
+		p := &products[i]
+		p.Price = r.Round(p.Price)
+		p.Admin = r.Round(p.Admin)
+		if p.EffectiveBestPrice != nil {
+			ep := r.Round(*p.EffectiveBestPrice)
+			p.EffectiveBestPrice = &ep
+		}
+	}
 }
 
 // getProductsWithBestPrice returns products with best price from multi-provider system
-func (s *ProductService) getProductsWithBestPrice(productType, category, brand, search string, page, limit int) ([]ProductResponse, int, error) {
-	products, total, err := s.providerRepo.GetProductsWithBestPrice(productType, category, brand, search, page, limit)
+func (s *ProductService) getProductsWithBestPrice(productType, category, brand, search string, availableNow bool, page, limit int) ([]ProductResponse, int, error) {
+	products, total, err := s.providerRepo.GetProductsWithBestPrice(productType, category, brand, search, availableNow, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	effective, err := s.effectiveBestPrices(products)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -83,13 +156,48 @@ func (s *ProductService) getProductsWithBestPrice(productType, category, brand,
 			Description:   p.Description,
 			ProviderCount: p.ProviderCount,
 		})
+		if ep, ok := effective[p.ID]; ok {
+			result[len(result)-1].EffectiveBestPrice = &ep
+		}
 	}
 	return result, total, nil
 }
 
+// effectiveBestPrices returns product ID -> lowest price over reachable
+// providers. Empty when no router is configured.
+func (s *ProductService) effectiveBestPrices(products []models.ProductWithBestPrice) (map[int]int, error) {
+	if s.router == nil || len(products) == 0 {
+		return nil, nil
+	}
+	ids := make([]int, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	prices, err := s.providerRepo.GetProviderPricesForProducts(ids)
+	if err != nil {
+		return nil, err
+	}
+	return lowestReachablePrices(prices, s.router.IsReachable), nil
+}
+
+// lowestReachablePrices keeps, per product, the minimum price among providers
+// for which reachable returns true.
+func lowestReachablePrices(prices []models.ProductProviderPrice, reachable func(models.ProviderCode) bool) map[int]int {
+	best := make(map[int]int)
+	for _, p := range prices {
+		if !reachable(p.ProviderCode) {
+			continue
+		}
+		if cur, ok := best[p.ProductID]; !ok || p.Price < cur {
+			best[p.ProductID] = p.Price
+		}
+	}
+	return best
+}
+
 // getProductsLegacy returns products with main SKU price (legacy method)
-func (s *ProductService) getProductsLegacy(productType, category, brand, search string, page, limit int) ([]ProductResponse, int, error) {
-	products, total, err := s.productRepo.GetAllPaged(productType, category, brand, search, page, limit)
+func (s *ProductService) getProductsLegacy(productType, category, brand, search string, availableNow bool, page, limit int) ([]ProductResponse, int, error) {
+	products, total, err := s.productRepo.GetAllPaged(productType, category, brand, search, availableNow, page, limit)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -114,6 +222,18 @@ func (s *ProductService) getProductsLegacy(productType, category, brand, search
 	return result, total, nil
 }
 
+// GetCategories returns the categories of active products with product
+// counts, for client-side navigation.
+func (s *ProductService) GetCategories(productType string) ([]repository.ProductGroupCount, error) {
+	return s.productRepo.CountActiveByCategory(productType)
+}
+
+// GetBrands returns the brands of active products with product counts,
+// optionally within one category.
+func (s *ProductService) GetBrands(productType, category string) ([]repository.ProductGroupCount, error) {
+	return s.productRepo.CountActiveByBrand(productType, category)
+}
+
 // GetAvailableSKUs returns SKUs that are not in cutoff at the current WIB time.
 func (s *ProductService) GetAvailableSKUs(productID int) ([]models.SKU, error) {
 	wib := time.FixedZone("WIB", 7*3600) // UTC+7
@@ -121,6 +241,159 @@ func (s *ProductService) GetAvailableSKUs(productID int) ([]models.SKU, error) {
 	return s.skuRepo.GetAvailableSKUs(productID, currentTime)
 }
 
+// AvailabilityWindow is a daily WIB cut-off ("gangguan") window during which an SKU is unavailable.
+type AvailabilityWindow struct {
+	Start string `json:"start"` // HH:MM:SS WIB
+	End   string `json:"end"`   // HH:MM:SS WIB; may be before Start when the window crosses midnight
+}
+
+// ProductAvailabilityResponse describes when a product can be transacted.
+type ProductAvailabilityResponse struct {
+	SkuCode        string               `json:"skuCode"`
+	Timezone       string               `json:"timezone"`
+	CurrentTime    string               `json:"currentTime"`
+	IsAvailable    bool                 `json:"isAvailable"`
+	CutOffWindows  []AvailabilityWindow `json:"cutOffWindows"`
+	AlwaysOpenSKUs int                  `json:"alwaysOpenSkus"`
+}
+
+// GetProductAvailability returns the cut-off windows of a product's active SKUs
+// and whether it is available right now in WIB, using the same rules as GetAvailableSKUs.
+func (s *ProductService) GetProductAvailability(skuCode string) (*ProductAvailabilityResponse, error) {
+	product, err := s.productRepo.GetBySKUCode(skuCode)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrInvalidSKU
+		}
+		return nil, err
+	}
+	skus, err := s.skuRepo.GetByProductID(product.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	wib := time.FixedZone("WIB", 7*3600) // UTC+7
+	now := time.Now().In(wib).Format("15:04:05")
+	resp := &ProductAvailabilityResponse{
+		SkuCode:       product.SkuCode,
+		Timezone:      "WIB",
+		CurrentTime:   now,
+		CutOffWindows: make([]AvailabilityWindow, 0),
+	}
+
+	seen := make(map[AvailabilityWindow]bool)
+	nowTime := time.Now()
+	for _, sku := range skus {
+		if !sku.IsActive || sku.AutoDisabled(nowTime) {
+			continue
+		}
+		if !inCutOff(sku.CutOffStart, sku.CutOffEnd, now) {
+			resp.IsAvailable = true
+		}
+		w := AvailabilityWindow{Start: sku.CutOffStart, End: sku.CutOffEnd}
+		if !hasCutOff(w.Start, w.End) {
+			resp.AlwaysOpenSKUs++
+			continue
+		}
+		if !seen[w] {
+			seen[w] = true
+			resp.CutOffWindows = append(resp.CutOffWindows, w)
+		}
+	}
+
+	// Multi-provider SKUs carry no cut-off windows; an available one keeps the product open.
+	if !resp.IsAvailable && s.providerRepo != nil {
+		if options, err := s.providerRepo.GetProvidersForProduct(product.ID); err == nil && len(options) > 0 {
+			resp.IsAvailable = true
+		}
+	}
+	resp.IsAvailable = resp.IsAvailable && product.IsActive
+	return resp, nil
+}
+
+// ProductProvidersResponse lists the providers a client may request through
+// the transaction provider field for a product.
+type ProductProvidersResponse struct {
+	SkuCode   string                `json:"skuCode"`
+	Type      string                `json:"type"`
+	Providers []models.ProviderCode `json:"providers"` // in routing order
+}
+
+// GetProductProviders returns the providers that can fulfill a product right
+// now: with an available SKU for it, registered and healthy, and for sandbox
+// requests with a sandbox account. Empty when the product is inactive or only
+// served through the legacy Digiflazz flow, where the provider field has no
+// effect. Prices are not exposed.
+func (s *ProductService) GetProductProviders(skuCode string, sandbox bool) (*ProductProvidersResponse, error) {
+	product, err := s.productRepo.GetBySKUCode(skuCode)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.ErrInvalidSKU
+		}
+		return nil, err
+	}
+	resp := &ProductProvidersResponse{
+		SkuCode:   product.SkuCode,
+		Type:      string(product.Type),
+		Providers: make([]models.ProviderCode, 0),
+	}
+	if s.router == nil || !product.IsActive || (sandbox && !s.sandboxRouting) {
+		return resp, nil
+	}
+
+	var options []models.ProviderOption
+	if product.Type == models.ProductTypePostpaid {
+		options, err = s.router.GetProviderOptionsPostpaid(product.ID)
+	} else {
+		options, err = s.router.GetProviderOptions(product.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp.Providers = s.reachableProviders(options, sandbox)
+	return resp, nil
+}
+
+// reachableProviders returns the providers of options, in routing order and
+// each once, that are registered and healthy and, for sandbox, have a
+// sandbox account.
+func (s *ProductService) reachableProviders(options []models.ProviderOption, sandbox bool) []models.ProviderCode {
+	providers := make([]models.ProviderCode, 0, len(options))
+	seen := make(map[models.ProviderCode]bool, len(options))
+	for _, opt := range options {
+		if seen[opt.ProviderCode] || !s.router.IsReachable(opt.ProviderCode) {
+			continue
+		}
+		if sandbox && !clientHasSandbox(s.router.GetAdapter(string(opt.ProviderCode))) {
+			continue
+		}
+		seen[opt.ProviderCode] = true
+		providers = append(providers, opt.ProviderCode)
+	}
+	return providers
+}
+
+// hasCutOff reports whether an SKU has a cut-off window (00:00:00-00:00:00 means none).
+func hasCutOff(start, end string) bool {
+	return !(start == "00:00:00" && end == "00:00:00")
+}
+
+// inCutOff mirrors the SKU cut-off filter of SKURepository.GetAvailableSKUs.
+// Times are "HH:MM:SS", so lexical comparison matches time order.
+func inCutOff(start, end, now string) bool {
+	switch {
+	case !hasCutOff(start, end):
+		return false
+	case start < end:
+		return now >= start && now <= end
+	case start > end:
+		return now >= start || now <= end
+	default:
+		// start == end (non-zero) is excluded by the SQL filter as well.
+		return true
+	}
+}
+
 // GetProductBySkuCode returns a product by sku code.
 func (s *ProductService) GetProductBySkuCode(skuCode string) (*models.Product, error) {
 	return s.productRepo.GetBySKUCode(skuCode)
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+func TestInCutOff(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		now        string
+		want       bool
+	}{
+		{"no cutoff", "00:00:00", "00:00:00", "12:00:00", false},
+		{"inside same-day window", "23:00:00", "23:59:59", "23:30:00", true},
+		{"outside same-day window", "01:00:00", "02:00:00", "03:00:00", false},
+		{"inside window crossing midnight, before midnight", "23:45:00", "00:15:00", "23:50:00", true},
+		{"inside window crossing midnight, after midnight", "23:45:00", "00:15:00", "00:10:00", true},
+		{"outside window crossing midnight", "23:45:00", "00:15:00", "12:00:00", false},
+		{"window edge is inclusive", "01:00:00", "02:00:00", "02:00:00", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inCutOff(tt.start, tt.end, tt.now); got != tt.want {
+				t.Fatalf("inCutOff(%q, %q, %q) = %v, want %v", tt.start, tt.end, tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLowestReachablePrices(t *testing.T) {
+	prices := []models.ProductProviderPrice{
+		{ProductID: 1, ProviderCode: models.ProviderKiosbank, Price: 9800},
+		{ProductID: 1, ProviderCode: models.ProviderAlterra, Price: 10100},
+		{ProductID: 1, ProviderCode: models.ProviderBRI, Price: 10050},
+		{ProductID: 2, ProviderCode: models.ProviderKiosbank, Price: 5000},
+	}
+	down := map[models.ProviderCode]bool{models.ProviderKiosbank: true}
+	got := lowestReachablePrices(prices, func(c models.ProviderCode) bool { return !down[c] })
+
+	if got[1] != 10050 {
+		t.Fatalf("product 1: want 10050 from the cheapest healthy provider, got %d", got[1])
+	}
+	if _, ok := got[2]; ok {
+		t.Fatalf("product 2 has no reachable provider, got %d", got[2])
+	}
+}
+
+// stubProviderClient is a registered provider with fixed health and
+// sandbox account.
+type stubProviderClient struct {
+	PPOBProviderClient
+	healthy, sandbox bool
+}
+
+func (c *stubProviderClient) IsHealthy() bool  { return c.healthy }
+func (c *stubProviderClient) HasSandbox() bool { return c.sandbox }
+
+func TestReachableProviders(t *testing.T) {
+	s := &ProductService{router: &ProviderRouter{providers: map[models.ProviderCode]PPOBProviderClient{
+		models.ProviderKiosbank: &stubProviderClient{healthy: true, sandbox: true},
+		models.ProviderAlterra:  &stubProviderClient{healthy: true},
+		models.ProviderBRI:      &stubProviderClient{healthy: false, sandbox: true},
+	}}}
+	options := []models.ProviderOption{
+		{ProviderCode: models.ProviderAlterra, ProviderSKUID: 1},
+		{ProviderCode: models.ProviderBRI, ProviderSKUID: 2},
+		{ProviderCode: models.ProviderKiosbank, ProviderSKUID: 3},
+		{ProviderCode: models.ProviderAlterra, ProviderSKUID: 4},
+		{ProviderCode: models.ProviderDigiflazz, ProviderSKUID: 5},
+	}
+	cases := []struct {
+		name    string
+		sandbox bool
+		want    []models.ProviderCode
+	}{
+		{"production keeps routing order, healthy and registered only", false,
+			[]models.ProviderCode{models.ProviderAlterra, models.ProviderKiosbank}},
+		{"sandbox needs a sandbox account", true,
+			[]models.ProviderCode{models.ProviderKiosbank}},
+	}
+	for _, tc := range cases {
+		if got := s.reachableProviders(options, tc.sandbox); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if got := s.reachableProviders(nil, false); got == nil || len(got) != 0 {
+		t.Errorf("no options: got %#v, want an empty list", got)
+	}
+}
+
+func TestProductSchema(t *testing.T) {
+	minLen, maxLen, pattern := 11, 12, `^\d+$`
+	postpaid := &models.Product{
+		SkuCode: "PLNPOST", Category: "PLN", Type: models.ProductTypePostpaid,
+		CustomerNoMinLength: &minLen, CustomerNoMaxLength: &maxLen, CustomerNoPattern: &pattern,
+	}
+	schema := productSchema(postpaid, 30)
+	if !schema.InquiryRequired || len(schema.Requests) != 2 {
+		t.Fatalf("postpaid schema = %+v, want inquiry and payment requests", schema)
+	}
+	payment := schema.Requests["payment"]
+	props := payment["properties"].(map[string]any)
+	customerNo := props["customerNo"].(map[string]any)
+	if customerNo["minLength"] != 11 || customerNo["maxLength"] != 12 || customerNo["pattern"] != pattern {
+		t.Fatalf("customerNo schema = %v, want the product rules", customerNo)
+	}
+	if props["referenceId"].(map[string]any)["maxLength"] != 30 {
+		t.Fatalf("referenceId schema = %v, want maxLength 30", props["referenceId"])
+	}
+	required := payment["required"].([]string)
+	if required[len(required)-1] != "transactionId" {
+		t.Fatalf("payment required = %v, want transactionId", required)
+	}
+	if _, ok := schema.Requests["inquiry"]["properties"].(map[string]any)["transactionId"]; ok {
+		t.Fatalf("inquiry schema has transactionId")
+	}
+
+	prepaid := productSchema(&models.Product{SkuCode: "TSEL10", Type: models.ProductTypePrepaid}, 0)
+	if prepaid.InquiryRequired || prepaid.Requests["prepaid"] == nil {
+		t.Fatalf("prepaid schema = %+v, want one prepaid request", prepaid)
+	}
+	props = prepaid.Requests["prepaid"]["properties"].(map[string]any)
+	if props["referenceId"].(map[string]any)["maxLength"] != maxReferenceIDLength {
+		t.Fatalf("referenceId schema = %v, want the column width", props["referenceId"])
+	}
+	if _, ok := props["customerNo"].(map[string]any)["pattern"]; ok {
+		t.Fatalf("unconstrained customerNo has a pattern")
+	}
+}
+
+func TestProductSchemaResponseFieldsMatchTransaction(t *testing.T) {
+	t.Parallel()
+
+	want := map[string]bool{}
+	trxType := reflect.TypeOf(models.Transaction{})
+	for i := 0; i < trxType.NumField(); i++ {
+		name, _, _ := strings.Cut(trxType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			want[name] = true
+		}
+	}
+
+	got := map[string]bool{}
+	for _, fields := range [][]string{
+		productSchema(&models.Product{Type: models.ProductTypePrepaid}, 0).ResponseFields["prepaid"],
+		productSchema(&models.Product{Type: models.ProductTypePostpaid}, 0).ResponseFields["inquiry"],
+		productSchema(&models.Product{Type: models.ProductTypePostpaid}, 0).ResponseFields["payment"],
+	} {
+		seen := map[string]bool{}
+		for _, f := range fields {
+			if seen[f] {
+				t.Errorf("response field %q listed twice in %v", f, fields)
+			}
+			if !want[f] {
+				t.Errorf("response field %q is not a transaction JSON field", f)
+			}
+			seen[f], got[f] = true, true
+		}
+	}
+	for f := range want {
+		if !got[f] {
+			t.Errorf("transaction JSON field %q missing from every response field list", f)
+		}
+	}
+}
@@ -35,17 +35,45 @@ func (c *AlterraProviderClient) Code() models.ProviderCode {
 	return models.ProviderAlterra
 }
 
-// getClient returns the appropriate client based on sandbox mode
-func (c *AlterraProviderClient) getClient(isSandbox bool) *alterra.Client {
-	if isSandbox && c.devClient != nil {
-		return c.devClient
+// Balance returns the production deposit balance.
+func (c *AlterraProviderClient) Balance(ctx context.Context) (int64, error) {
+	client, err := c.getClient(false)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.GetBalance(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("alterra balance: %w", err)
+	}
+	return int64(resp.Balance), nil
+}
+
+// HasSandbox reports whether a separate sandbox (staging) client is configured.
+func (c *AlterraProviderClient) HasSandbox() bool {
+	return c.devClient != nil && c.devClient != c.prodClient
+}
+
+// getClient returns the appropriate client based on sandbox mode. Sandbox
+// requests never fall back to the production client.
+func (c *AlterraProviderClient) getClient(isSandbox bool) (*alterra.Client, error) {
+	if !isSandbox {
+		if c.prodClient == nil {
+			return nil, fmt.Errorf("alterra client not configured")
+		}
+		return c.prodClient, nil
+	}
+	if !c.HasSandbox() {
+		return nil, fmt.Errorf("alterra sandbox client not configured")
 	}
-	return c.prodClient
+	return c.devClient, nil
 }
 
 // Topup processes a prepaid transaction via Purchase
 func (c *AlterraProviderClient) Topup(ctx context.Context, req *ProviderRequest) (*ProviderResponse, error) {
-	client := c.getClient(req.IsSandbox)
+	client, err := c.getClient(req.IsSandbox)
+	if err != nil {
+		return nil, err
+	}
 	startTime := time.Now()
 
 	// Parse product ID from SKU code
@@ -74,7 +102,10 @@ func (c *AlterraProviderClient) Topup(ctx context.Context, req *ProviderRequest)
 
 // Inquiry checks a postpaid bill
 func (c *AlterraProviderClient) Inquiry(ctx context.Context, req *ProviderRequest) (*ProviderResponse, error) {
-	client := c.getClient(req.IsSandbox)
+	client, err := c.getClient(req.IsSandbox)
+	if err != nil {
+		return nil, err
+	}
 	startTime := time.Now()
 
 	// Parse product ID from SKU code
@@ -103,7 +134,10 @@ func (c *AlterraProviderClient) Inquiry(ctx context.Context, req *ProviderReques
 
 // Payment pays a postpaid bill
 func (c *AlterraProviderClient) Payment(ctx context.Context, req *ProviderRequest) (*ProviderResponse, error) {
-	client := c.getClient(req.IsSandbox)
+	client, err := c.getClient(req.IsSandbox)
+	if err != nil {
+		return nil, err
+	}
 	startTime := time.Now()
 
 	// Parse product ID from SKU code
@@ -131,7 +165,10 @@ func (c *AlterraProviderClient) Payment(ctx context.Context, req *ProviderReques
 
 // CheckStatus checks transaction status using Alterra's transaction ID
 func (c *AlterraProviderClient) CheckStatus(ctx context.Context, refID string) (*ProviderResponse, error) {
-	client := c.getClient(false)
+	client, err := c.getClient(isSandboxContext(ctx))
+	if err != nil {
+		return nil, err
+	}
 	startTime := time.Now()
 
 	// refID is Alterra's transaction ID (numeric string)
@@ -172,7 +209,10 @@ func (c *AlterraProviderClient) CheckStatus(ctx context.Context, refID string) (
 
 // GetPriceList fetches current prices
 func (c *AlterraProviderClient) GetPriceList(ctx context.Context, category string) ([]ProviderProduct, error) {
-	client := c.getClient(false)
+	client, err := c.getClient(false)
+	if err != nil {
+		return nil, err
+	}
 
 	products, err := client.GetAllProducts(ctx)
 	if err != nil {
@@ -304,6 +344,8 @@ func (c *AlterraProviderClient) convertResponse(resp *alterra.TransactionRespons
 		RawResponse:   rawResp,
 		NeedsRetry:    alterra.NeedsNewRefID(resp.ResponseCode),
 		ResponseTime:  responseTime,
+		RetryAfter:    resp.RetryAfter,
+		Error:         alterraProviderError(resp, rc),
 	}
 }
 
@@ -326,6 +368,27 @@ func alterraResponseCode(resp *alterra.TransactionResponse) string {
 	return ""
 }
 
+// alterraProviderError keeps the code of Alterra's nested error object
+// alongside response_code, and its own message rather than the RC table's.
+func alterraProviderError(resp *alterra.TransactionResponse, rc string) *models.ProviderError {
+	if alterra.IsSuccess(resp.ResponseCode) || alterra.IsPending(resp.ResponseCode) {
+		return nil
+	}
+	e := &models.ProviderError{Code: rc, Message: resp.Message}
+	if resp.Error != nil {
+		if resp.Error.Code != rc {
+			e.Subcode = resp.Error.Code
+		}
+		if resp.Error.Message != "" {
+			e.Message = resp.Error.Message
+		}
+	}
+	if e.Subcode == "" && resp.Code != rc {
+		e.Subcode = resp.Code
+	}
+	return e
+}
+
 func alterraResponseMessage(resp *alterra.TransactionResponse, rc string) string {
 	if resp == nil {
 		return ""
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/GTDGit/gtd_api/internal/models"
 	"github.com/GTDGit/gtd_api/pkg/alterra"
 )
 
@@ -79,6 +80,44 @@ func TestAlterraResponseMessageFallsBackToTopLevelMessage(t *testing.T) {
 	}
 }
 
+func TestAlterraProviderErrorKeepsNestedCode(t *testing.T) {
+	t.Parallel()
+
+	resp := &alterra.TransactionResponse{
+		ResponseCode: "99",
+		Error: &alterra.ErrorDetail{
+			Code:    "406",
+			Message: "Invalid parameter",
+		},
+	}
+
+	want := &models.ProviderError{Code: "99", Subcode: "406", Message: "Invalid parameter"}
+	if got := alterraProviderError(resp, alterraResponseCode(resp)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("alterraProviderError() = %+v, want %+v", got, want)
+	}
+
+	trx := &models.Transaction{}
+	ApplyCanonicalFailureToTransaction(trx, string(models.ProviderAlterra), ProviderFailurePhaseInitialPayment, &ProviderResponse{RC: "99", Error: want})
+	if trx.ProviderError != want {
+		t.Fatalf("trx.ProviderError = %+v, want %+v", trx.ProviderError, want)
+	}
+
+	// A later failure without a structured error clears the earlier one.
+	ApplyCanonicalFailureToTransaction(trx, string(models.ProviderAlterra), ProviderFailurePhaseInitialPayment, &ProviderResponse{RC: "99"})
+	if trx.ProviderError != nil {
+		t.Fatalf("trx.ProviderError = %+v after a failure without one, want nil", trx.ProviderError)
+	}
+}
+
+func TestAlterraProviderErrorNilOnSuccess(t *testing.T) {
+	t.Parallel()
+
+	resp := &alterra.TransactionResponse{ResponseCode: "00"}
+	if got := alterraProviderError(resp, alterraResponseCode(resp)); got != nil {
+		t.Fatalf("alterraProviderError() = %+v, want nil", got)
+	}
+}
+
 func TestSanitizeAlterraExtraStripsInternalPricingFields(t *testing.T) {
 	t.Parallel()
 
@@ -35,6 +35,21 @@ func (c *BRIProviderClient) Code() models.ProviderCode {
 	return models.ProviderBRI
 }
 
+// Capabilities reports BRIZZI top-up only: Inquiry validates the card and
+// Payment is the same top-up, so there is no postpaid bill flow.
+func (c *BRIProviderClient) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{SupportsPrepaid: true, SupportsStatusCheck: true}
+}
+
+// SelfTest fetches a BRIZZI OAuth token; GetPriceList is served from the
+// configured denominations and never reaches BRI.
+func (c *BRIProviderClient) SelfTest(ctx context.Context) (string, error) {
+	if c.client == nil {
+		return "token", fmt.Errorf("bri client not configured")
+	}
+	return "token", c.client.VerifyBRIZZICredentials(ctx)
+}
+
 func (c *BRIProviderClient) Topup(ctx context.Context, req *ProviderRequest) (*ProviderResponse, error) {
 	if c.client == nil {
 		return nil, fmt.Errorf("bri client not configured")
@@ -68,6 +83,7 @@ func (c *BRIProviderClient) Topup(ctx context.Context, req *ProviderRequest) (*P
 		Description:   rawResp,
 		RawResponse:   rawResp,
 		ResponseTime:  responseTime,
+		Error:         newProviderError(resp.ResponseCode != "00" && !isBRIZZIPending(resp.ResponseCode), resp.ResponseCode, resp.ErrorCode, resp.ResponseDescription),
 	}, nil
 }
 
@@ -97,6 +113,7 @@ func (c *BRIProviderClient) Inquiry(ctx context.Context, req *ProviderRequest) (
 		Description:  rawResp,
 		RawResponse:  rawResp,
 		ResponseTime: responseTime,
+		Error:        newProviderError(resp.ResponseCode != "00", resp.ResponseCode, "", resp.ResponseDescription),
 	}, nil
 }
 
@@ -137,6 +154,7 @@ func (c *BRIProviderClient) CheckStatus(ctx context.Context, refID string) (*Pro
 		Description:   rawResp,
 		RawResponse:   rawResp,
 		ResponseTime:  responseTime,
+		Error:         newProviderError(resp.ResponseCode != "00" && !isBRIZZIPending(resp.ResponseCode), resp.ResponseCode, "", resp.ResponseDescription),
 	}, nil
 }
 
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+func TestNextProviderCallbackRetry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	if next := nextProviderCallbackRetry(0, now); next == nil || !next.Equal(now.Add(time.Minute)) {
+		t.Fatalf("first retry: got %v", next)
+	}
+	last := len(providerCallbackRetryIntervals) - 1
+	if next := nextProviderCallbackRetry(last, now); next == nil || !next.Equal(now.Add(providerCallbackRetryIntervals[last])) {
+		t.Fatalf("last retry: got %v", next)
+	}
+	if next := nextProviderCallbackRetry(last+1, now); next != nil {
+		t.Fatalf("budget exhausted, want nil, got %v", next)
+	}
+}
+
+func TestFailCallbackWithoutStoredRow(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("db down")
+	err := (&ProviderCallbackService{}).failCallback(nil, cause)
+	var recorded recordedCallbackError
+	if errors.As(err, &recorded) || !errors.Is(err, cause) {
+		t.Fatalf("unsaved callback must return the cause unmarked, got %#v", err)
+	}
+}
+
+// fakeProviderCallbacks is a providerCallbackStore over in-memory rows; every
+// scheduled retry counts as due.
+type fakeProviderCallbacks struct {
+	providers []models.PPOBProvider
+	rows      []*models.PPOBProviderCallback
+}
+
+func (f *fakeProviderCallbacks) GetProviderByCode(code models.ProviderCode) (*models.PPOBProvider, error) {
+	for i := range f.providers {
+		if f.providers[i].Code == code {
+			return &f.providers[i], nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeProviderCallbacks) GetProviderByID(id int) (*models.PPOBProvider, error) {
+	for i := range f.providers {
+		if f.providers[i].ID == id {
+			return &f.providers[i], nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeProviderCallbacks) CreateProviderCallback(cb *models.PPOBProviderCallback) error {
+	cb.ID = len(f.rows) + 1
+	row := *cb
+	f.rows = append(f.rows, &row)
+	return nil
+}
+
+func (f *fakeProviderCallbacks) row(id int) *models.PPOBProviderCallback {
+	for _, r := range f.rows {
+		if r.ID == id {
+			return r
+		}
+	}
+	return nil
+}
+
+func (f *fakeProviderCallbacks) UpdateProviderCallbackProcessed(id int, processed bool) error {
+	f.row(id).IsProcessed = processed
+	return nil
+}
+
+func (f *fakeProviderCallbacks) MarkCallbackProcessed(id int, processError string) error {
+	r := f.row(id)
+	r.IsProcessed, r.ProcessError = true, &processError
+	return nil
+}
+
+func (f *fakeProviderCallbacks) RecordCallbackError(id int, processError string, next *time.Time) error {
+	if r := f.row(id); !r.IsProcessed {
+		r.ProcessError, r.NextRetryAt = &processError, next
+		r.RetryCount++
+	}
+	return nil
+}
+
+func (f *fakeProviderCallbacks) ClaimRetryableCallbacks(limit int, _ time.Duration) ([]models.PPOBProviderCallback, error) {
+	var due []models.PPOBProviderCallback
+	for _, r := range f.rows {
+		if !r.IsProcessed && r.ProcessError != nil && r.NextRetryAt != nil && len(due) < limit {
+			due = append(due, *r)
+		}
+	}
+	return due, nil
+}
+
+// fakeCallbackTransactions finds transactions by provider ref or transaction ID.
+type fakeCallbackTransactions struct {
+	trxs    []*models.Transaction
+	updates int
+}
+
+func (f *fakeCallbackTransactions) GetByProviderRefID(ref string) (*models.Transaction, error) {
+	for _, t := range f.trxs {
+		if t.ProviderRefID != nil && *t.ProviderRefID == ref {
+			return t, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeCallbackTransactions) GetByTransactionID(id string) (*models.Transaction, error) {
+	for _, t := range f.trxs {
+		if t.TransactionID == id {
+			return t, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeCallbackTransactions) Update(*models.Transaction) error {
+	f.updates++
+	return nil
+}
+
+type countingRetrier struct{ calls int }
+
+func (r *countingRetrier) RetryWithNextProvider(_ context.Context, trx *models.Transaction, _, _ string) (*models.Transaction, bool, error) {
+	r.calls++
+	return trx, true, nil
+}
+
+var callbackTestProviders = []models.PPOBProvider{
+	{ID: 1, Code: models.ProviderKiosbank},
+	{ID: 2, Code: models.ProviderAlterra},
+}
+
+func TestRetryFailedCallbacksChecksAttempt(t *testing.T) {
+	t.Parallel()
+
+	ref := func(s string) *string { return &s }
+	id := func(n int) *int { return &n }
+	cases := []struct {
+		name        string
+		trx         *models.Transaction
+		wantRetries int
+	}{
+		{"failed over to another provider", &models.Transaction{ProviderID: id(2), ProviderRefID: ref("ALT-9")}, 0},
+		{"current attempt", &models.Transaction{ProviderID: id(1), ProviderRefID: ref("GRB-1")}, 1},
+		{"current attempt without a ref yet", &models.Transaction{ProviderID: id(1)}, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.trx.ID, tc.trx.TransactionID = 7, "GRB-1"
+			tc.trx.Type, tc.trx.Status = models.TrxTypePrepaid, models.StatusProcessing
+			payload, _ := json.Marshal(map[string]any{"referenceID": "GRB-1", "rc": "17"})
+			due := time.Now().Add(-time.Minute)
+			store := &fakeProviderCallbacks{providers: callbackTestProviders, rows: []*models.PPOBProviderCallback{{
+				ID: 1, ProviderID: 1, ProviderRefID: "GRB-1", TransactionID: id(7), Payload: payload,
+				ProcessError: ref("db down"), RetryCount: 1, NextRetryAt: &due,
+			}}}
+			retrier := &countingRetrier{}
+			s := &ProviderCallbackService{providerRepo: store, trxRepo: &fakeCallbackTransactions{trxs: []*models.Transaction{tc.trx}}, retrier: retrier}
+
+			if _, err := s.RetryFailedCallbacks(context.Background(), 10); err != nil {
+				t.Fatalf("RetryFailedCallbacks: %v", err)
+			}
+			if retrier.calls != tc.wantRetries {
+				t.Fatalf("provider failovers = %d, want %d", retrier.calls, tc.wantRetries)
+			}
+			if row := store.row(1); !row.IsProcessed {
+				t.Fatalf("callback left unprocessed: %+v", row)
+			}
+		})
+	}
+}
+
+func TestCallbackWithoutTransactionIsRetried(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeProviderCallbacks{providers: callbackTestProviders}
+	trxs := &fakeCallbackTransactions{}
+	s := &ProviderCallbackService{providerRepo: store, trxRepo: trxs}
+	payload := map[string]any{"referenceID": "GRB-2", "rc": "05"}
+
+	if err := s.ProcessKiosbankCallback(context.Background(), payload); err == nil {
+		t.Fatal("callback for an unknown transaction succeeded")
+	}
+	if len(store.rows) != 1 {
+		t.Fatalf("stored %d callbacks, want 1", len(store.rows))
+	}
+	row := store.rows[0]
+	if row.ProviderID != 1 || row.TransactionID != nil || row.RetryCount != 1 || row.NextRetryAt == nil {
+		t.Fatalf("stored callback = %+v, want a scheduled retry for kiosbank", row)
+	}
+
+	// Still no transaction: the retry counts once more.
+	if n, err := s.RetryFailedCallbacks(context.Background(), 10); err != nil || n != 0 {
+		t.Fatalf("retry = %d, %v", n, err)
+	}
+	if row.RetryCount != 2 || row.IsProcessed {
+		t.Fatalf("after a missed retry: %+v", row)
+	}
+
+	trx := &models.Transaction{ID: 8, TransactionID: "GRB-2", Type: models.TrxTypePrepaid, Status: models.StatusProcessing}
+	trxs.trxs = append(trxs.trxs, trx)
+	if n, err := s.RetryFailedCallbacks(context.Background(), 10); err != nil || n != 1 {
+		t.Fatalf("retry = %d, %v; want the callback applied", n, err)
+	}
+	if !row.IsProcessed || trx.ProviderRefID == nil || *trx.ProviderRefID != "GRB-2" || trxs.updates != 1 {
+		t.Fatalf("callback %+v, transaction ref %v after %d updates", row, trx.ProviderRefID, trxs.updates)
+	}
+}
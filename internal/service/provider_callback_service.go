@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -32,10 +34,30 @@ func extractProviderResponseCode(raw models.NullableRawMessage) string {
 	return rc
 }
 
+// providerCallbackStore keeps the provider callback audit rows
+// (repository.PPOBProviderRepository).
+type providerCallbackStore interface {
+	GetProviderByCode(code models.ProviderCode) (*models.PPOBProvider, error)
+	GetProviderByID(id int) (*models.PPOBProvider, error)
+	CreateProviderCallback(cb *models.PPOBProviderCallback) error
+	UpdateProviderCallbackProcessed(id int, processed bool) error
+	MarkCallbackProcessed(id int, processError string) error
+	RecordCallbackError(id int, processError string, nextRetryAt *time.Time) error
+	ClaimRetryableCallbacks(limit int, lease time.Duration) ([]models.PPOBProviderCallback, error)
+}
+
+// callbackTransactionStore is the transaction access callback processing
+// needs (repository.TransactionRepository).
+type callbackTransactionStore interface {
+	GetByProviderRefID(providerRefID string) (*models.Transaction, error)
+	GetByTransactionID(transactionID string) (*models.Transaction, error)
+	Update(trx *models.Transaction) error
+}
+
 // ProviderCallbackService handles callbacks from PPOB providers
 type ProviderCallbackService struct {
-	providerRepo *repository.PPOBProviderRepository
-	trxRepo      *repository.TransactionRepository
+	providerRepo providerCallbackStore    // *repository.PPOBProviderRepository; a fake in tests
+	trxRepo      callbackTransactionStore // *repository.TransactionRepository; a fake in tests
 	callbackSvc  *CallbackService
 	notifier     sse.TransactionNotifier
 	retrier      ProviderFallbackRetrier
@@ -66,6 +88,12 @@ func (s *ProviderCallbackService) SetRetrier(retrier ProviderFallbackRetrier) {
 
 // ProcessKiosbankCallback processes a callback from Kiosbank
 func (s *ProviderCallbackService) ProcessKiosbankCallback(ctx context.Context, payload map[string]any) error {
+	return s.processKiosbankCallback(ctx, payload, nil)
+}
+
+// processKiosbankCallback applies a Kiosbank callback; stored is the existing
+// audit row when re-processing, nil for a fresh callback.
+func (s *ProviderCallbackService) processKiosbankCallback(ctx context.Context, payload map[string]any, stored *models.PPOBProviderCallback) error {
 	// Log the callback for audit
 	rawPayload, _ := json.Marshal(payload)
 
@@ -78,7 +106,7 @@ func (s *ProviderCallbackService) ProcessKiosbankCallback(ctx context.Context, p
 		refID, _ = payload["ref_id"].(string)
 	}
 	if refID == "" {
-		return fmt.Errorf("no reference ID in Kiosbank callback")
+		return fmt.Errorf("%w: no reference ID in Kiosbank callback", ErrInvalidCallbackPayload)
 	}
 
 	// Extract RC
@@ -87,15 +115,15 @@ func (s *ProviderCallbackService) ProcessKiosbankCallback(ctx context.Context, p
 		rc, _ = payload["RC"].(string)
 	}
 
-	// Find transaction by provider ref ID
-	trx, err := s.trxRepo.GetByProviderRefID(refID)
+	// Find transaction by provider ref ID, or by transaction ID (ref_id might
+	// be our transaction_id)
+	trx, err := s.findCallbackTransaction(refID)
 	if err != nil {
-		// Try finding by transaction ID (ref_id might be our transaction_id)
-		trx, err = s.trxRepo.GetByTransactionID(refID)
-		if err != nil {
-			log.Warn().Str("ref_id", refID).Msg("Transaction not found for Kiosbank callback")
-			return fmt.Errorf("transaction not found: %s", refID)
-		}
+		log.Warn().Str("ref_id", refID).Msg("Transaction not found for Kiosbank callback")
+		return s.failUnmatchedCallback(models.ProviderKiosbank, refID, rawPayload, stored, err)
+	}
+	if stored != nil && !callbackMatchesAttempt(stored, trx) {
+		return s.supersedeCallback(stored, trx)
 	}
 
 	// Determine provider ID from transaction or lookup
@@ -132,17 +160,20 @@ func (s *ProviderCallbackService) ProcessKiosbankCallback(ctx context.Context, p
 		status = &s
 	}
 
-	// Store callback to audit log
-	callback := &models.PPOBProviderCallback{
-		ProviderID:    providerID,
-		ProviderRefID: refID,
-		TransactionID: trx.ID,
-		Payload:       rawPayload,
-		Status:        status,
-		Message:       msg,
-		IsProcessed:   false,
+	// Store callback to audit log (re-processing reuses the stored row)
+	callback := stored
+	if callback == nil {
+		callback = &models.PPOBProviderCallback{
+			ProviderID:    providerID,
+			ProviderRefID: refID,
+			TransactionID: &trx.ID,
+			Payload:       rawPayload,
+			Status:        status,
+			Message:       msg,
+			IsProcessed:   false,
+		}
+		_ = s.providerRepo.CreateProviderCallback(callback)
 	}
-	_ = s.providerRepo.CreateProviderCallback(callback)
 
 	trx.ProviderResponse = models.NullableRawMessage(rawPayload)
 	httpStatus := http.StatusOK
@@ -151,8 +182,8 @@ func (s *ProviderCallbackService) ProcessKiosbankCallback(ctx context.Context, p
 		trx.ProviderRefID = &refID
 	}
 
-	// Check if transaction is already in terminal state
-	if trx.Status == models.StatusSuccess || trx.Status == models.StatusFailed {
+	// Check if transaction is already in terminal state (or held for review)
+	if trx.Status == models.StatusSuccess || trx.Status == models.StatusFailed || trx.HeldForReview() {
 		if err := s.trxRepo.Update(trx); err != nil {
 			log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("Failed to refresh terminal Kiosbank trace from callback")
 		}
@@ -170,6 +201,7 @@ func (s *ProviderCallbackService) ProcessKiosbankCallback(ctx context.Context, p
 		trx.Status = models.StatusSuccess
 		trx.FailedCode = nil
 		trx.FailedReason = nil
+		trx.ProviderError = nil
 		// Extract serial number from data sub-object (product-specific keys)
 		if data, ok := payload["data"].(map[string]any); ok {
 			sn := extractKiosbankSN(data)
@@ -180,15 +212,21 @@ func (s *ProviderCallbackService) ProcessKiosbankCallback(ctx context.Context, p
 			if bp := extractKiosbankBuyPrice(data); bp > 0 {
 				trx.BuyPrice = &bp
 			}
+			if raw, err := json.Marshal(data); err == nil {
+				if desc := SanitizePublicProviderDescription(raw); len(desc) > 0 {
+					trx.Description = models.NullableRawMessage(desc)
+				}
+			}
 		}
 		trx.ProcessedAt = &now
 		if err := s.trxRepo.Update(trx); err != nil {
 			log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("CRITICAL: failed to update transaction in DB from callback")
+			return s.failCallback(callback, err)
 		}
 		if s.notifier != nil {
 			s.notifier.NotifyTransactionStatusChanged(trx)
 		}
-		go s.callbackSvc.SendCallback(trx, "transaction.success")
+		s.callbackSvc.QueueCallback(trx, "transaction.success")
 	case kiosbank.ResponseClassFailed:
 		failedMessage := kiosbank.GetRCDescription(rc)
 		if desc, ok := payload["description"].(string); ok && desc != "" {
@@ -200,7 +238,7 @@ func (s *ProviderCallbackService) ProcessKiosbankCallback(ctx context.Context, p
 		if s.retrier != nil && trx.Type == models.TrxTypePrepaid {
 			result, handled, err := s.retrier.RetryWithNextProvider(ctx, trx, rc, failedMessage)
 			if err != nil {
-				return err
+				return s.failCallback(callback, err)
 			}
 			if handled {
 				_ = result
@@ -216,15 +254,17 @@ func (s *ProviderCallbackService) ProcessKiosbankCallback(ctx context.Context, p
 			Message:     failedMessage,
 			HTTPStatus:  http.StatusOK,
 			RawResponse: rawPayload,
+			Error:       newProviderError(true, rc, "", failedMessage),
 		})
 		trx.ProcessedAt = &now
 		if err := s.trxRepo.Update(trx); err != nil {
 			log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("CRITICAL: failed to update transaction in DB from callback")
+			return s.failCallback(callback, err)
 		}
 		if s.notifier != nil {
 			s.notifier.NotifyTransactionStatusChanged(trx)
 		}
-		go s.callbackSvc.SendCallback(trx, "transaction.failed")
+		s.callbackSvc.QueueCallback(trx, "transaction.failed")
 	default:
 		if err := s.trxRepo.Update(trx); err != nil {
 			log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("Failed to refresh pending Kiosbank trace from callback")
@@ -240,6 +280,12 @@ func (s *ProviderCallbackService) ProcessKiosbankCallback(ctx context.Context, p
 
 // ProcessAlterraCallback processes a callback from Alterra
 func (s *ProviderCallbackService) ProcessAlterraCallback(ctx context.Context, payload map[string]any) error {
+	return s.processAlterraCallback(ctx, payload, nil)
+}
+
+// processAlterraCallback applies an Alterra callback; stored is the existing
+// audit row when re-processing, nil for a fresh callback.
+func (s *ProviderCallbackService) processAlterraCallback(ctx context.Context, payload map[string]any, stored *models.PPOBProviderCallback) error {
 	// Log the callback for audit
 	rawPayload, _ := json.Marshal(payload)
 
@@ -248,7 +294,7 @@ func (s *ProviderCallbackService) ProcessAlterraCallback(ctx context.Context, pa
 	if orderID == "" {
 		orderID, _ = payload["orderID"].(string)
 		if orderID == "" {
-			return fmt.Errorf("no order ID in Alterra callback")
+			return fmt.Errorf("%w: no order ID in Alterra callback", ErrInvalidCallbackPayload)
 		}
 	}
 
@@ -259,13 +305,13 @@ func (s *ProviderCallbackService) ProcessAlterraCallback(ctx context.Context, pa
 	}
 
 	// Find transaction
-	trx, err := s.trxRepo.GetByProviderRefID(orderID)
+	trx, err := s.findCallbackTransaction(orderID)
 	if err != nil {
-		trx, err = s.trxRepo.GetByTransactionID(orderID)
-		if err != nil {
-			log.Warn().Str("order_id", orderID).Msg("Transaction not found for Alterra callback")
-			return fmt.Errorf("transaction not found: %s", orderID)
-		}
+		log.Warn().Str("order_id", orderID).Msg("Transaction not found for Alterra callback")
+		return s.failUnmatchedCallback(models.ProviderAlterra, orderID, rawPayload, stored, err)
+	}
+	if stored != nil && !callbackMatchesAttempt(stored, trx) {
+		return s.supersedeCallback(stored, trx)
 	}
 
 	// Determine provider ID from transaction or lookup
@@ -305,20 +351,23 @@ func (s *ProviderCallbackService) ProcessAlterraCallback(ctx context.Context, pa
 		trx.ProviderHTTPStatus = &httpStatus
 	}
 
-	// Store callback
-	callback := &models.PPOBProviderCallback{
-		ProviderID:    providerID,
-		ProviderRefID: orderID,
-		TransactionID: trx.ID,
-		Payload:       rawPayload,
-		Status:        status,
-		Message:       msg,
-		IsProcessed:   false,
+	// Store callback (re-processing reuses the stored row)
+	callback := stored
+	if callback == nil {
+		callback = &models.PPOBProviderCallback{
+			ProviderID:    providerID,
+			ProviderRefID: orderID,
+			TransactionID: &trx.ID,
+			Payload:       rawPayload,
+			Status:        status,
+			Message:       msg,
+			IsProcessed:   false,
+		}
+		_ = s.providerRepo.CreateProviderCallback(callback)
 	}
-	_ = s.providerRepo.CreateProviderCallback(callback)
 
-	// Check terminal state
-	if trx.Status == models.StatusSuccess || trx.Status == models.StatusFailed {
+	// Check terminal state (or held for review)
+	if trx.Status == models.StatusSuccess || trx.Status == models.StatusFailed || trx.HeldForReview() {
 		if shouldRefreshTrace {
 			if err := s.trxRepo.Update(trx); err != nil {
 				log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("Failed to refresh terminal Alterra trace from callback")
@@ -337,6 +386,7 @@ func (s *ProviderCallbackService) ProcessAlterraCallback(ctx context.Context, pa
 		trx.Status = models.StatusSuccess
 		trx.FailedCode = nil
 		trx.FailedReason = nil
+		trx.ProviderError = nil
 		if sn, ok := payload["serial_number"].(string); ok && sn != "" {
 			trx.SerialNumber = &sn
 		}
@@ -348,17 +398,18 @@ func (s *ProviderCallbackService) ProcessAlterraCallback(ctx context.Context, pa
 		trx.ProcessedAt = &now
 		if err := s.trxRepo.Update(trx); err != nil {
 			log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("CRITICAL: failed to update transaction in DB from callback")
+			return s.failCallback(callback, err)
 		}
 		if s.notifier != nil {
 			s.notifier.NotifyTransactionStatusChanged(trx)
 		}
-		go s.callbackSvc.SendCallback(trx, "transaction.success")
+		s.callbackSvc.QueueCallback(trx, "transaction.success")
 	} else if alterra.IsFatal(rc) {
 		failedMessage := alterraFailureMessageFromPayload(payload, rc)
 		if s.retrier != nil && trx.Type == models.TrxTypePrepaid {
 			result, handled, err := s.retrier.RetryWithNextProvider(ctx, trx, rc, failedMessage)
 			if err != nil {
-				return err
+				return s.failCallback(callback, err)
 			}
 			if handled {
 				_ = result
@@ -374,15 +425,17 @@ func (s *ProviderCallbackService) ProcessAlterraCallback(ctx context.Context, pa
 			Message:     failedMessage,
 			HTTPStatus:  http.StatusOK,
 			RawResponse: rawPayload,
+			Error:       newProviderError(true, rc, alterraErrorCodeFromPayload(payload), failedMessage),
 		})
 		trx.ProcessedAt = &now
 		if err := s.trxRepo.Update(trx); err != nil {
 			log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("CRITICAL: failed to update transaction in DB from callback")
+			return s.failCallback(callback, err)
 		}
 		if s.notifier != nil {
 			s.notifier.NotifyTransactionStatusChanged(trx)
 		}
-		go s.callbackSvc.SendCallback(trx, "transaction.failed")
+		s.callbackSvc.QueueCallback(trx, "transaction.failed")
 	}
 	// If pending, wait for next callback
 
@@ -429,6 +482,17 @@ func alterraFailureMessageFromPayload(payload map[string]any, rc string) string
 	return alterra.GetRCDescription(rc)
 }
 
+// alterraErrorCodeFromPayload returns the code of an Alterra callback's
+// nested error object, if any.
+func alterraErrorCodeFromPayload(payload map[string]any) string {
+	if errMap, ok := payload["error"].(map[string]any); ok {
+		if code, ok := errMap["code"].(string); ok {
+			return strings.TrimSpace(code)
+		}
+	}
+	return ""
+}
+
 // extractKiosbankBuyPrice extracts buy price from Kiosbank callback data.
 func extractKiosbankBuyPrice(data map[string]any) int {
 	// Try tagihan (postpaid)
@@ -463,15 +527,200 @@ func parseCallbackAmount(s string) int {
 	return v
 }
 
-// ProcessGenericCallback processes a generic provider callback
-func (s *ProviderCallbackService) ProcessGenericCallback(ctx context.Context, providerCode string, payload map[string]any) error {
-	switch models.ProviderCode(providerCode) {
+// ErrUnknownCallbackProvider is returned when a callback arrives for a
+// provider code that is not in ppob_providers.
+var ErrUnknownCallbackProvider = errors.New("unknown callback provider")
+
+// ErrUnsupportedCallbackFormat is returned for a provider whose configured
+// callback format has no parser.
+var ErrUnsupportedCallbackFormat = errors.New("unsupported callback format")
+
+// ErrInvalidCallbackPayload is returned for a callback missing the fields
+// needed to match it to a transaction.
+var ErrInvalidCallbackPayload = errors.New("invalid callback payload")
+
+// ResolveCallbackProvider looks up the provider behind /v1/webhook/:provider
+// and returns its callback settings.
+func (s *ProviderCallbackService) ResolveCallbackProvider(providerCode string) (*models.PPOBProvider, models.ProviderCallbackConfig, error) {
+	provider, err := s.providerRepo.GetProviderByCode(models.ProviderCode(providerCode))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ProviderCallbackConfig{}, ErrUnknownCallbackProvider
+		}
+		return nil, models.ProviderCallbackConfig{}, fmt.Errorf("get provider %s: %w", providerCode, err)
+	}
+	cfg, err := provider.CallbackConfig()
+	if err != nil {
+		return nil, models.ProviderCallbackConfig{}, fmt.Errorf("provider %s callback config: %w", providerCode, err)
+	}
+	return provider, cfg, nil
+}
+
+// ProcessGenericCallback dispatches a callback by payload format; format is
+// the provider's configured callback format (see ResolveCallbackProvider).
+func (s *ProviderCallbackService) ProcessGenericCallback(ctx context.Context, format string, payload map[string]any) error {
+	return s.processByFormat(ctx, format, payload, nil)
+}
+
+func (s *ProviderCallbackService) processByFormat(ctx context.Context, format string, payload map[string]any, stored *models.PPOBProviderCallback) error {
+	switch models.ProviderCode(format) {
 	case models.ProviderKiosbank:
-		return s.ProcessKiosbankCallback(ctx, payload)
+		return s.processKiosbankCallback(ctx, payload, stored)
 	case models.ProviderAlterra:
-		return s.ProcessAlterraCallback(ctx, payload)
+		return s.processAlterraCallback(ctx, payload, stored)
 	default:
-		log.Warn().Str("provider", providerCode).Msg("Unknown provider code in callback")
-		return fmt.Errorf("unknown provider: %s", providerCode)
+		log.Warn().Str("format", format).Msg("Unsupported provider callback format")
+		return fmt.Errorf("%w: %s", ErrUnsupportedCallbackFormat, format)
+	}
+}
+
+// providerCallbackRetryIntervals is the backoff between re-processing
+// attempts of a failed provider callback; after the last one it is left for
+// the admin failed-callbacks view.
+var providerCallbackRetryIntervals = []time.Duration{
+	1 * time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	1 * time.Hour,
+	4 * time.Hour,
+}
+
+// nextProviderCallbackRetry returns when to retry after retryCount failed
+// attempts, or nil when the budget is exhausted.
+func nextProviderCallbackRetry(retryCount int, now time.Time) *time.Time {
+	if retryCount >= len(providerCallbackRetryIntervals) {
+		return nil
+	}
+	next := now.Add(providerCallbackRetryIntervals[retryCount])
+	return &next
+}
+
+// findCallbackTransaction returns the transaction a callback reference
+// belongs to: by provider ref ID, or by our transaction ID.
+func (s *ProviderCallbackService) findCallbackTransaction(refID string) (*models.Transaction, error) {
+	trx, err := s.trxRepo.GetByProviderRefID(refID)
+	if err == nil {
+		return trx, nil
+	}
+	if trx, err = s.trxRepo.GetByTransactionID(refID); err != nil {
+		return nil, fmt.Errorf("transaction not found: %s", refID)
+	}
+	return trx, nil
+}
+
+// failUnmatchedCallback stores a callback no transaction matches yet, when
+// it is not stored already, and schedules a retry: the callback can arrive
+// before the provider ref is saved.
+func (s *ProviderCallbackService) failUnmatchedCallback(code models.ProviderCode, refID string, rawPayload []byte, stored *models.PPOBProviderCallback, err error) error {
+	if stored == nil {
+		provider, lookupErr := s.providerRepo.GetProviderByCode(code)
+		if lookupErr != nil {
+			return err
+		}
+		stored = &models.PPOBProviderCallback{ProviderID: provider.ID, ProviderRefID: refID, Payload: rawPayload}
+		if createErr := s.providerRepo.CreateProviderCallback(stored); createErr != nil {
+			log.Error().Err(createErr).Str("ref_id", refID).Msg("Failed to store unmatched provider callback")
+			return err
+		}
+	}
+	return s.failCallback(stored, err)
+}
+
+// callbackMatchesAttempt reports whether a stored callback is from trx's
+// current provider attempt. After a failover the transaction is at another
+// provider or another ref, and an old failure must not fail it over again.
+func callbackMatchesAttempt(cb *models.PPOBProviderCallback, trx *models.Transaction) bool {
+	if trx.ProviderID != nil && *trx.ProviderID != cb.ProviderID {
+		return false
+	}
+	if trx.ProviderRefID != nil && *trx.ProviderRefID != "" &&
+		cb.ProviderRefID != *trx.ProviderRefID && cb.ProviderRefID != trx.TransactionID {
+		return false
+	}
+	return true
+}
+
+// supersedeCallback closes a stored callback from an earlier attempt without
+// applying it.
+func (s *ProviderCallbackService) supersedeCallback(cb *models.PPOBProviderCallback, trx *models.Transaction) error {
+	log.Warn().Int("callback_id", cb.ID).Str("transaction_id", trx.TransactionID).
+		Msg("Stored provider callback is from an earlier attempt, not applying it")
+	cb.IsProcessed = true
+	if err := s.providerRepo.MarkCallbackProcessed(cb.ID, "superseded: transaction moved to another provider attempt"); err != nil {
+		return fmt.Errorf("mark superseded callback %d: %w", cb.ID, err)
+	}
+	return nil
+}
+
+// recordedCallbackError marks an error already stored by failCallback, so the
+// retry loop does not count the same attempt twice.
+type recordedCallbackError struct{ error }
+
+func (e recordedCallbackError) Unwrap() error { return e.error }
+
+// failCallback records err on the stored callback and schedules a retry.
+func (s *ProviderCallbackService) failCallback(callback *models.PPOBProviderCallback, err error) error {
+	if callback == nil || callback.ID == 0 {
+		return err
+	}
+	next := nextProviderCallbackRetry(callback.RetryCount, time.Now())
+	if recErr := s.providerRepo.RecordCallbackError(callback.ID, err.Error(), next); recErr != nil {
+		log.Error().Err(recErr).Int("callback_id", callback.ID).Msg("Failed to record provider callback error")
+	}
+	if next == nil {
+		log.Error().Err(err).Int("callback_id", callback.ID).Int("retries", callback.RetryCount).
+			Msg("Provider callback failed permanently, manual follow-up required")
+	}
+	return recordedCallbackError{err}
+}
+
+// providerCallbackRetryLease is how long a claimed callback retry is kept
+// from other workers; it outlasts a provider failover.
+const providerCallbackRetryLease = 5 * time.Minute
+
+// RetryFailedCallbacks re-processes stored callbacks whose retry is due and
+// returns how many succeeded.
+func (s *ProviderCallbackService) RetryFailedCallbacks(ctx context.Context, limit int) (int, error) {
+	callbacks, err := s.providerRepo.ClaimRetryableCallbacks(limit, providerCallbackRetryLease)
+	if err != nil {
+		return 0, fmt.Errorf("get retryable callbacks: %w", err)
+	}
+
+	formats := make(map[int]string)
+	succeeded := 0
+	for i := range callbacks {
+		cb := &callbacks[i]
+		format, ok := formats[cb.ProviderID]
+		if !ok {
+			provider, err := s.providerRepo.GetProviderByID(cb.ProviderID)
+			if err != nil {
+				log.Error().Err(err).Int("provider_id", cb.ProviderID).Msg("Failed to load provider for callback retry")
+				continue
+			}
+			cfg, err := provider.CallbackConfig()
+			if err != nil {
+				_ = s.failCallback(cb, fmt.Errorf("provider callback config: %w", err))
+				continue
+			}
+			format = cfg.Format
+			formats[cb.ProviderID] = format
+		}
+
+		var payload map[string]any
+		if err := json.Unmarshal(cb.Payload, &payload); err != nil {
+			_ = s.failCallback(cb, fmt.Errorf("decode stored payload: %w", err))
+			continue
+		}
+		if err := s.processByFormat(ctx, format, payload, cb); err != nil {
+			// Failures not recorded on the row yet still count.
+			var recorded recordedCallbackError
+			if !errors.As(err, &recorded) {
+				_ = s.failCallback(cb, err)
+			}
+			log.Warn().Err(err).Int("callback_id", cb.ID).Int("retry", cb.RetryCount+1).Msg("Provider callback retry failed")
+			continue
+		}
+		succeeded++
 	}
+	return succeeded, nil
 }
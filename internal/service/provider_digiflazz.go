@@ -33,6 +33,11 @@ func (c *DigiflazzProviderClient) Code() models.ProviderCode {
 	return models.ProviderDigiflazz
 }
 
+// HasSandbox reports whether the development-key client is configured.
+func (c *DigiflazzProviderClient) HasSandbox() bool {
+	return c.devClient != nil && c.devClient != c.prodClient
+}
+
 // getClient returns the appropriate client based on sandbox mode
 func (c *DigiflazzProviderClient) getClient(isSandbox bool) *digiflazz.Client {
 	if isSandbox {
@@ -92,6 +97,11 @@ func (c *DigiflazzProviderClient) Payment(ctx context.Context, req *ProviderRequ
 	return c.convertResponse(resp, responseTime), nil
 }
 
+// Capabilities reports that Digiflazz has no status check or balance API here.
+func (c *DigiflazzProviderClient) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{SupportsPrepaid: true, SupportsPostpaid: true}
+}
+
 // CheckStatus checks transaction status
 func (c *DigiflazzProviderClient) CheckStatus(ctx context.Context, refID string) (*ProviderResponse, error) {
 	// Digiflazz doesn't have a direct check status API
@@ -191,5 +201,7 @@ func (c *DigiflazzProviderClient) convertResponse(resp *digiflazz.TransactionRes
 		RawResponse:   rawResp,
 		NeedsRetry:    digiflazz.NeedsNewRefID(resp.RC),
 		ResponseTime:  responseTime,
+		RetryAfter:    resp.RetryAfter,
+		Error:         newProviderError(!digiflazz.IsSuccess(resp.RC) && !digiflazz.IsPending(resp.RC), resp.RC, "", resp.Message),
 	}
 }
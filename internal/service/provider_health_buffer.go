@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/repository"
+)
+
+// ProviderHealthStore persists aggregated provider health
+// (repository.PPOBProviderRepository).
+type ProviderHealthStore interface {
+	RecordProviderHealth(providerID int, d repository.ProviderHealthDelta) error
+}
+
+// ProviderHealthBuffer aggregates per-call provider health in memory and
+// writes one upsert per provider each flush, instead of one per call on the
+// provider's hot daily row. Routing reads health at most one interval late.
+type ProviderHealthBuffer struct {
+	store    ProviderHealthStore
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[int]*repository.ProviderHealthDelta
+}
+
+// NewProviderHealthBuffer constructs a ProviderHealthBuffer flushing every
+// interval once started.
+func NewProviderHealthBuffer(store ProviderHealthStore, interval time.Duration) *ProviderHealthBuffer {
+	return &ProviderHealthBuffer{
+		store:    store,
+		interval: interval,
+		pending:  make(map[int]*repository.ProviderHealthDelta),
+	}
+}
+
+// Record adds one provider call to the pending batch.
+func (b *ProviderHealthBuffer) Record(providerID int, success bool, responseTimeMs int, failureReason string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	d := b.pending[providerID]
+	if d == nil {
+		d = &repository.ProviderHealthDelta{}
+		b.pending[providerID] = d
+	}
+	d.Add(success, responseTimeMs, failureReason, time.Now())
+}
+
+// Start flushes every interval until ctx is cancelled. Call Flush after the
+// HTTP server has drained to persist calls recorded during shutdown.
+func (b *ProviderHealthBuffer) Start(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	log.Info().Dur("interval", b.interval).Msg("Provider health buffer started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.Flush()
+			log.Info().Msg("Provider health buffer stopped")
+			return
+		case <-ticker.C:
+			b.Flush()
+		}
+	}
+}
+
+// Flush writes the pending batch. A provider whose write fails is merged back
+// so its counts go out with the next flush.
+func (b *ProviderHealthBuffer) Flush() {
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = make(map[int]*repository.ProviderHealthDelta)
+	b.mu.Unlock()
+
+	for providerID, d := range batch {
+		if err := b.store.RecordProviderHealth(providerID, *d); err != nil {
+			log.Warn().Err(err).Int("provider_id", providerID).Int("requests", d.Requests).Msg("Provider health flush failed, retrying next flush")
+			b.requeue(providerID, d)
+		}
+	}
+}
+
+func (b *ProviderHealthBuffer) requeue(providerID int, d *repository.ProviderHealthDelta) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	cur := b.pending[providerID]
+	if cur == nil {
+		b.pending[providerID] = d
+		return
+	}
+	// cur holds calls newer than d, so its last-seen fields win.
+	cur.Requests += d.Requests
+	cur.SuccessCount += d.SuccessCount
+	cur.FailedCount += d.FailedCount
+	cur.ResponseTimeMsSum += d.ResponseTimeMsSum
+	if cur.LastSuccessAt == nil {
+		cur.LastSuccessAt = d.LastSuccessAt
+	}
+	if cur.LastFailureAt == nil {
+		cur.LastFailureAt = d.LastFailureAt
+		cur.LastFailureReason = d.LastFailureReason
+	}
+}
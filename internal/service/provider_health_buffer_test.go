@@ -0,0 +1,83 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/repository"
+)
+
+type fakeHealthStore struct {
+	fail    bool
+	written map[int]repository.ProviderHealthDelta
+}
+
+func (s *fakeHealthStore) RecordProviderHealth(providerID int, d repository.ProviderHealthDelta) error {
+	if s.fail {
+		return errors.New("db down")
+	}
+	if s.written == nil {
+		s.written = make(map[int]repository.ProviderHealthDelta)
+	}
+	s.written[providerID] = d
+	return nil
+}
+
+func TestProviderHealthBuffer_AggregatesPerProvider(t *testing.T) {
+	store := &fakeHealthStore{}
+	b := NewProviderHealthBuffer(store, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.Record(1, true, 100, "")
+		}()
+	}
+	wg.Wait()
+	b.Record(1, false, 300, "timeout")
+	b.Record(2, true, 40, "")
+	b.Flush()
+
+	d := store.written[1]
+	if d.Requests != 51 || d.SuccessCount != 50 || d.FailedCount != 1 {
+		t.Fatalf("provider 1 counts = %+v", d)
+	}
+	if d.ResponseTimeMsSum != 50*100+300 {
+		t.Fatalf("provider 1 response time sum = %d", d.ResponseTimeMsSum)
+	}
+	if d.LastFailureAt == nil || d.LastFailureReason != "timeout" || d.LastSuccessAt == nil {
+		t.Fatalf("provider 1 last-seen fields = %+v", d)
+	}
+	if got := store.written[2]; got.Requests != 1 || got.FailedCount != 0 || got.LastFailureAt != nil {
+		t.Fatalf("provider 2 = %+v", got)
+	}
+
+	store.written = nil
+	b.Flush()
+	if len(store.written) != 0 {
+		t.Fatalf("second flush wrote %v, want nothing", store.written)
+	}
+}
+
+func TestProviderHealthBuffer_RequeuesFailedFlush(t *testing.T) {
+	store := &fakeHealthStore{fail: true}
+	b := NewProviderHealthBuffer(store, 0)
+
+	b.Record(1, false, 200, "rc 99")
+	b.Flush()
+
+	store.fail = false
+	b.Record(1, true, 100, "")
+	b.Flush()
+
+	d := store.written[1]
+	if d.Requests != 2 || d.SuccessCount != 1 || d.FailedCount != 1 || d.ResponseTimeMsSum != 300 {
+		t.Fatalf("requeued counts = %+v", d)
+	}
+	if d.LastFailureReason != "rc 99" || d.LastSuccessAt == nil {
+		t.Fatalf("requeued last-seen fields = %+v", d)
+	}
+}
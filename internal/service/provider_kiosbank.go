@@ -45,6 +45,27 @@ func (c *KiosbankProviderClient) Code() models.ProviderCode {
 	return models.ProviderKiosbank
 }
 
+// HasSandbox reports whether a separate development client is configured.
+func (c *KiosbankProviderClient) HasSandbox() bool {
+	return c.devClient != nil && c.devClient != c.prodClient
+}
+
+// ChecksStatusByRefID reports that CheckStatus finds the transaction by the
+// reference ID Kiosbank was sent, which is our RefID.
+func (c *KiosbankProviderClient) ChecksStatusByRefID() bool {
+	return true
+}
+
+// SelfTest signs on again with the production account, replacing its
+// session, so the merchant credentials are checked now.
+func (c *KiosbankProviderClient) SelfTest(ctx context.Context) (string, error) {
+	client := c.getClient(false)
+	if client == nil {
+		return "sign_on", fmt.Errorf("kiosbank client not configured")
+	}
+	return "sign_on", client.RefreshSession(ctx)
+}
+
 // getClient returns the appropriate client based on sandbox mode
 func (c *KiosbankProviderClient) getClient(isSandbox bool) *kiosbank.Client {
 	if isSandbox {
@@ -282,6 +303,7 @@ func (c *KiosbankProviderClient) convertInquiryResponse(resp *kiosbank.InquiryRe
 		RawResponse:    rawResp,
 		NeedsRetry:     kiosbank.NeedsNewRefID(resp.RC),
 		ResponseTime:   responseTime,
+		Error:          newProviderError(class != kiosbank.ResponseClassSuccess && class != kiosbank.ResponseClassPending, resp.RC, "", resp.Description),
 	}
 }
 
@@ -310,6 +332,7 @@ func (c *KiosbankProviderClient) convertPaymentResponse(resp *kiosbank.PaymentRe
 		RawResponse:    rawResp,
 		NeedsRetry:     kiosbank.NeedsNewRefID(resp.RC),
 		ResponseTime:   responseTime,
+		Error:          newProviderError(class != kiosbank.ResponseClassSuccess && class != kiosbank.ResponseClassPending, resp.RC, "", resp.Description),
 	}
 }
 
@@ -341,6 +364,7 @@ func (c *KiosbankProviderClient) convertSinglePaymentResponse(resp *kiosbank.Sin
 		RawResponse:    rawResp,
 		NeedsRetry:     kiosbank.NeedsNewRefID(resp.RC),
 		ResponseTime:   responseTime,
+		Error:          newProviderError(class != kiosbank.ResponseClassSuccess && class != kiosbank.ResponseClassPending, resp.RC, "", resp.Description),
 	}
 }
 
@@ -369,6 +393,7 @@ func (c *KiosbankProviderClient) convertAsyncPaymentResponse(resp *kiosbank.Paym
 		RawResponse:    rawResp,
 		NeedsRetry:     kiosbank.NeedsNewRefID(resp.RC),
 		ResponseTime:   responseTime,
+		Error:          newProviderError(class != kiosbank.ResponseClassSuccess && class != kiosbank.ResponseClassPending, resp.RC, "", resp.Description),
 	}
 }
 
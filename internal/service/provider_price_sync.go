@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/notify"
+	"github.com/GTDGit/gtd_api/internal/repository"
+)
+
+// ProviderSyncResult summarises one provider price sync.
+type ProviderSyncResult struct {
+	ProviderCode string `json:"providerCode"`
+	Total        int    `json:"total"`
+	Updated      int    `json:"updated"`
+	Unavailable  int    `json:"unavailable"`
+	Preserved    int    `json:"preserved"`
+	Derived      int    `json:"derived"` // mappings created from config.derivedSku
+	Errors       int    `json:"errors"`
+	DurationMs   int64  `json:"durationMs"`
+	// Error is set when the provider price list could not be fetched; every
+	// SKU is then counted in Errors and marked with the sync error.
+	Error string `json:"error,omitempty"`
+	// DisableSkipped is set when the price list shrank past the guard; the
+	// Kept SKUs missing from it keep their availability.
+	DisableSkipped bool `json:"disableSkipped,omitempty"`
+	Kept           int  `json:"kept,omitempty"`
+	// DerivedCollisions are products config.derivedSku was not applied to
+	// because their derived code belongs to another product.
+	DerivedCollisions []repository.DerivedSKUCollision `json:"derivedCollisions,omitempty"`
+}
+
+// PriceListGuard keeps a price sync from disabling the SKUs missing from a
+// price list that shrank by more than MaxShrinkPercent since the last applied
+// sync; that is far more often a truncated response than a real delisting.
+type PriceListGuard struct {
+	MaxShrinkPercent int             // 0 = off
+	Alerts           notify.Notifier // nil only logs
+	// Accept applies a shrunk list anyway (admin override after checking it).
+	Accept bool
+}
+
+// shrunk reports whether size is more than MaxShrinkPercent below prev.
+func (g PriceListGuard) shrunk(prev *int, size int) bool {
+	if g.Accept || g.MaxShrinkPercent <= 0 || prev == nil || *prev <= 0 {
+		return false
+	}
+	return (*prev-size)*100 > *prev*g.MaxShrinkPercent
+}
+
+func (g PriceListGuard) alert(provider models.PPOBProvider, prev, size int) {
+	log.Error().
+		Str("alert", "provider_price_list_shrunk").
+		Str("provider", string(provider.Code)).
+		Int("previous_size", prev).
+		Int("size", size).
+		Int("max_shrink_percent", g.MaxShrinkPercent).
+		Msg("ALERT: provider price list shrank suspiciously, missing SKUs not disabled")
+	if g.Alerts == nil {
+		return
+	}
+	g.Alerts.Notify(context.Background(), notify.Event{
+		Type:     notify.EventProviderPriceListShrunk,
+		Severity: notify.SeverityWarning,
+		Key:      string(provider.Code),
+		Message:  "provider price list shrank suspiciously; missing SKUs were not disabled",
+		Fields: map[string]any{
+			"provider":           string(provider.Code),
+			"previous_size":      prev,
+			"size":               size,
+			"max_shrink_percent": g.MaxShrinkPercent,
+		},
+	})
+}
+
+// SyncProviderPrices refreshes price, admin and availability of every SKU of
+// provider from client's live price list. It is shared by ProviderSyncWorker
+// and the admin on-demand sync; only repository failures are returned as err.
+// guard decides whether SKUs missing from the list may be disabled.
+func SyncProviderPrices(ctx context.Context, providerRepo *repository.PPOBProviderRepository, provider models.PPOBProvider, client PPOBProviderClient, guard PriceListGuard) (*ProviderSyncResult, error) {
+	log.Info().
+		Str("provider", string(provider.Code)).
+		Msg("Syncing prices from provider")
+
+	start := time.Now()
+	result := &ProviderSyncResult{ProviderCode: string(provider.Code)}
+
+	// Map products the provider derives SKU codes for but has no explicit
+	// mapping of; the rest of the sync prices them like any other SKU.
+	if rule := provider.DerivedSKU(); rule != nil {
+		created, collisions, err := providerRepo.CreateDerivedProviderSKUs(provider.ID, *rule)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("provider", string(provider.Code)).
+				Msg("Failed to create derived provider SKUs")
+		}
+		for _, c := range collisions {
+			log.Warn().
+				Str("provider", string(provider.Code)).
+				Str("sku_code", c.SkuCode).
+				Str("provider_sku_code", c.ProviderSKUCode).
+				Msg("Derived provider SKU code already used by another product; not mapped")
+		}
+		result.Derived = created
+		result.DerivedCollisions = collisions
+	}
+
+	// Get all SKUs for this provider
+	skus, err := providerRepo.GetProviderSKUsByProvider(provider.ID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("provider", string(provider.Code)).
+			Msg("Failed to get provider SKUs")
+		return nil, err
+	}
+	result.Total = len(skus)
+
+	if len(skus) == 0 {
+		log.Debug().
+			Str("provider", string(provider.Code)).
+			Msg("No SKUs configured for provider")
+		return result, nil
+	}
+
+	// Get price list from provider
+	priceList, err := client.GetPriceList(ctx, "")
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("provider", string(provider.Code)).
+			Msg("Failed to get price list from provider")
+
+		// Mark all SKUs with sync error
+		for _, sku := range skus {
+			_ = providerRepo.UpdateProviderSKUSyncError(sku.ID, err.Error())
+		}
+		result.Errors = len(skus)
+		result.Error = err.Error()
+		result.DurationMs = time.Since(start).Milliseconds()
+		return result, nil
+	}
+
+	// A list that shrank past the guard is still used for prices, but the
+	// SKUs missing from it keep their availability and the size it is
+	// compared against stays that of the last applied list.
+	keepMissing := guard.shrunk(provider.LastPriceListSize, len(priceList))
+	if keepMissing {
+		result.DisableSkipped = true
+		guard.alert(provider, *provider.LastPriceListSize, len(priceList))
+	} else if err := providerRepo.UpdateProviderPriceListSize(provider.ID, len(priceList)); err != nil {
+		log.Error().
+			Err(err).
+			Str("provider", string(provider.Code)).
+			Msg("Failed to record price list size")
+	}
+
+	// Create a map for quick lookup
+	priceMap := make(map[string]ProviderProduct)
+	for _, p := range priceList {
+		priceMap[p.SKUCode] = p
+	}
+
+	// Update each SKU
+	for _, sku := range skus {
+		product, found := priceMap[sku.ProviderSKUCode]
+		if !found {
+			if shouldPreserveProviderSKUAvailability(sku) {
+				result.Preserved++
+				_ = providerRepo.UpdateProviderSKUSyncError(sku.ID, "provider SKU not present in live price list; preserved for UAT alias")
+				continue
+			}
+			if keepMissing {
+				result.Kept++
+				_ = providerRepo.UpdateProviderSKUSyncError(sku.ID, "provider SKU not present in a shrunk price list; availability kept")
+				continue
+			}
+			// Product not found in provider's list - mark unavailable
+			if err := providerRepo.UpdateProviderSKUPrice(sku.ID, sku.Price, syncedAdmin(sku.Admin, nil), false); err != nil {
+				result.Errors++
+				log.Error().
+					Err(err).
+					Int("sku_id", sku.ID).
+					Msg("Failed to update SKU availability")
+			} else {
+				result.Unavailable++
+			}
+			continue
+		}
+
+		// Update price and availability
+		isAvailable := product.IsActive
+		if err := providerRepo.UpdateProviderSKUPrice(sku.ID, product.Price, syncedAdmin(sku.Admin, product.Admin), isAvailable); err != nil {
+			result.Errors++
+			log.Error().
+				Err(err).
+				Int("sku_id", sku.ID).
+				Msg("Failed to update SKU price")
+		} else {
+			result.Updated++
+		}
+	}
+
+	result.DurationMs = time.Since(start).Milliseconds()
+	log.Info().
+		Str("provider", string(provider.Code)).
+		Int("updated", result.Updated).
+		Int("unavailable", result.Unavailable).
+		Int("preserved", result.Preserved).
+		Int("kept", result.Kept).
+		Int("derived", result.Derived).
+		Int("errors", result.Errors).
+		Int64("duration_ms", result.DurationMs).
+		Msg("Provider sync completed")
+	return result, nil
+}
+
+func syncedAdmin(existing int, updated *int) *int {
+	if updated != nil {
+		return updated
+	}
+	admin := existing
+	return &admin
+}
+
+func shouldPreserveProviderSKUAvailability(sku models.PPOBProviderSKU) bool {
+	return strings.HasPrefix(strings.TrimSpace(sku.SkuCode), "99")
+}
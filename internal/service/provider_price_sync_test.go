@@ -0,0 +1,91 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+func TestSyncedAdminPreservesExistingValue(t *testing.T) {
+	t.Parallel()
+
+	admin := syncedAdmin(2500, nil)
+	if admin == nil || *admin != 2500 {
+		t.Fatalf("syncedAdmin(nil) = %#v, want 2500", admin)
+	}
+
+	updated := 3000
+	admin = syncedAdmin(2500, &updated)
+	if admin == nil || *admin != 3000 {
+		t.Fatalf("syncedAdmin(updated) = %#v, want 3000", admin)
+	}
+}
+
+func TestShouldPreserveProviderSKUAvailabilityForUATAlias(t *testing.T) {
+	t.Parallel()
+
+	if !shouldPreserveProviderSKUAvailability(models.PPOBProviderSKU{SkuCode: "9900446"}) {
+		t.Fatalf("expected 99-prefixed SKU to be preserved")
+	}
+	if shouldPreserveProviderSKUAvailability(models.PPOBProviderSKU{SkuCode: "2201001"}) {
+		t.Fatalf("expected normal SKU to follow sync availability")
+	}
+}
+
+func TestDerivedSKURule(t *testing.T) {
+	none := models.PPOBProvider{Config: json.RawMessage(`{"minBalance": 100}`)}
+	if rule := none.DerivedSKU(); rule != nil {
+		t.Fatalf("expected no rule without config.derivedSku, got %+v", rule)
+	}
+	blank := models.PPOBProvider{Config: json.RawMessage(`{"derivedSku": {"template": " "}}`)}
+	if rule := blank.DerivedSKU(); rule != nil {
+		t.Fatalf("expected no rule for blank template, got %+v", rule)
+	}
+
+	provider := models.PPOBProvider{Config: json.RawMessage(`{"derivedSku": {"template": "{skuSuffix}", "categories": ["Edukasi"]}}`)}
+	rule := provider.DerivedSKU()
+	if rule == nil || len(rule.Categories) != 1 || rule.Categories[0] != "Edukasi" {
+		t.Fatalf("unexpected rule: %+v", rule)
+	}
+
+	tests := []struct {
+		template, skuCode, want string
+	}{
+		{"{skuSuffix}", "ALT-EDU-9022", "9022"},
+		{"{skuSuffix}", "PLN20", "PLN20"},
+		{"K-{skuCode}", "PLN20", "K-PLN20"},
+		{"{skuCodeLower}", "TSEL10", "tsel10"},
+	}
+	for _, tt := range tests {
+		if got := (models.DerivedSKUConfig{Template: tt.template}).Code(tt.skuCode); got != tt.want {
+			t.Errorf("Code(%q, %q) = %q, want %q", tt.template, tt.skuCode, got, tt.want)
+		}
+	}
+}
+
+func TestPriceListGuardShrunk(t *testing.T) {
+	t.Parallel()
+
+	prev := 1000
+	cases := []struct {
+		name  string
+		guard PriceListGuard
+		prev  *int
+		size  int
+		want  bool
+	}{
+		{"within threshold", PriceListGuard{MaxShrinkPercent: 50}, &prev, 500, false},
+		{"past threshold", PriceListGuard{MaxShrinkPercent: 50}, &prev, 499, true},
+		{"empty list", PriceListGuard{MaxShrinkPercent: 50}, &prev, 0, true},
+		{"grown", PriceListGuard{MaxShrinkPercent: 10}, &prev, 1200, false},
+		{"first sync", PriceListGuard{MaxShrinkPercent: 50}, nil, 10, false},
+		{"guard off", PriceListGuard{}, &prev, 0, false},
+		{"accepted", PriceListGuard{MaxShrinkPercent: 50, Accept: true}, &prev, 0, false},
+	}
+	for _, tc := range cases {
+		if got := tc.guard.shrunk(tc.prev, tc.size); got != tc.want {
+			t.Errorf("%s: shrunk = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -136,7 +136,10 @@ func ApplyCanonicalFailureToTransaction(trx *models.Transaction, providerCode st
 	message := failure.Message
 	trx.FailedCode = &code
 	trx.FailedReason = &message
+	// Always replaced, so no earlier attempt's error outlives this failure.
+	trx.ProviderError = nil
 	if resp != nil {
+		trx.ProviderError = resp.Error
 		if desc := SanitizePublicProviderDescription(resp.Description); len(desc) > 0 {
 			trx.Description = models.NullableRawMessage(desc)
 		}
@@ -144,6 +147,18 @@ func ApplyCanonicalFailureToTransaction(trx *models.Transaction, providerCode st
 	return failure
 }
 
+// newProviderError is the structured error of a provider answer, nil unless
+// failed. An empty subcode or one equal to code is dropped.
+func newProviderError(failed bool, code, subcode, message string) *models.ProviderError {
+	if !failed {
+		return nil
+	}
+	if subcode == code {
+		subcode = ""
+	}
+	return &models.ProviderError{Code: code, Subcode: subcode, Message: message}
+}
+
 func SanitizePublicProviderDescription(raw json.RawMessage) json.RawMessage {
 	if len(raw) == 0 {
 		return nil
@@ -171,6 +186,90 @@ func SanitizePublicProviderDescription(raw json.RawMessage) json.RawMessage {
 	return out
 }
 
+// providerCostKeys are description keys carrying our side of the deal (buy
+// price, commission, remaining deposit). Compared lower-cased without "_", "-"
+// or spaces; they never reach clients. Generic keys such as price, harga or
+// balance are left alone: billers use them for the customer's own bill.
+var providerCostKeys = map[string]bool{
+	"hargabeli":  true,
+	"buyprice":   true,
+	"modal":      true,
+	"commission": true,
+	"komisi":     true,
+	"margin":     true,
+	"profit":     true,
+	"cashback":   true,
+	"feemitra":   true,
+	"sisasaldo":  true,
+}
+
+// PublicTransactionDetails returns the stored provider description with the
+// bill details clients need (period, usage, penalties, ...): the raw
+// structure is kept, internal cost fields are dropped. Nil when nothing is left.
+func PublicTransactionDetails(desc json.RawMessage) json.RawMessage {
+	if len(desc) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(strings.NewReader(string(desc)))
+	dec.UseNumber()
+	var payload any
+	if err := dec.Decode(&payload); err != nil {
+		return nil
+	}
+	stripped := stripProviderCostFields(payload)
+	if stripped == nil {
+		return nil
+	}
+	out, err := json.Marshal(stripped)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// PublicTransactionDescription is the stored description as clients see it
+// in responses and callbacks: provider names scrubbed and internal cost
+// fields dropped. Stored descriptions keep them for admin support.
+func PublicTransactionDescription(desc json.RawMessage) json.RawMessage {
+	return PublicTransactionDetails(SanitizePublicProviderDescription(desc))
+}
+
+func stripProviderCostFields(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(typed))
+		for key, val := range typed {
+			if providerCostKeys[normalizeDetailKey(key)] {
+				continue
+			}
+			if stripped := stripProviderCostFields(val); stripped != nil {
+				out[key] = stripped
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	case []any:
+		out := make([]any, 0, len(typed))
+		for _, item := range typed {
+			if stripped := stripProviderCostFields(item); stripped != nil {
+				out = append(out, stripped)
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+func normalizeDetailKey(key string) string {
+	return strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(key))
+}
+
 func BuildFinalFailureResponseFromAttempts(attempts []ProviderAttempt, phase ProviderFailurePhase) *ProviderResponse {
 	if len(attempts) == 0 {
 		resp := &ProviderResponse{
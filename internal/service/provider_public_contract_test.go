@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/utils"
 )
 
 func TestCanonicalFailureForResponseAlterraProductClosed(t *testing.T) {
@@ -102,3 +103,31 @@ func TestSanitizePublicProviderDescriptionRemovesTransportLeak(t *testing.T) {
 		t.Fatalf("Sanitized = %s, want %s", sanitized, `{"phase":"inquiry"}`)
 	}
 }
+
+func TestPublicTransactionDetailsDropsCostFields(t *testing.T) {
+	t.Parallel()
+
+	raw := json.RawMessage(`{"periode":"202605","tagihan":"125000","denda":2500,"harga":"125000","hargaBeli":"122500","detail":[{"meter_awal":100,"Buy_Price":9000,"admin":2500}],"sisa_saldo":1000000,"balance":50000}`)
+	got := PublicTransactionDetails(raw)
+	want := `{"balance":50000,"denda":2500,"detail":[{"admin":2500,"meter_awal":100}],"harga":"125000","periode":"202605","tagihan":"125000"}`
+	if string(got) != want {
+		t.Fatalf("PublicTransactionDetails = %s, want %s", got, want)
+	}
+
+	if got := PublicTransactionDetails(json.RawMessage(`{"komisi":1000}`)); got != nil {
+		t.Fatalf("only cost fields should yield nil, got %s", got)
+	}
+	if got := PublicTransactionDetails(nil); got != nil {
+		t.Fatalf("empty description should yield nil, got %s", got)
+	}
+}
+
+func TestCanonicalProviderFailuresHaveIndonesianMessages(t *testing.T) {
+	t.Parallel()
+
+	for code, failure := range canonicalProviderFailures {
+		if got := utils.LocalizeMessage(utils.LocaleID, code, failure.Message); got == failure.Message {
+			t.Errorf("%s has no Indonesian message", code)
+		}
+	}
+}
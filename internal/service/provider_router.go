@@ -3,12 +3,16 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/notify"
 	"github.com/GTDGit/gtd_api/internal/repository"
 )
 
@@ -61,6 +65,20 @@ type ProviderResponse struct {
 	PublicCode     string          `json:"-"`
 	PublicMessage  string          `json:"-"`
 	PublicHTTPCode int             `json:"-"`
+
+	// Ambiguous marks a response without a recognizable success, pending or
+	// failure signal (e.g. HTTP 200 with no status code). Adapters may set it;
+	// the router also infers it, see ProviderRouter.resolveAmbiguous.
+	Ambiguous bool `json:"ambiguous,omitempty"`
+
+	// RetryAfter is the provider's own back-off hint on a rate-limited
+	// response (Retry-After). The router leaves the provider alone for that
+	// long instead of guessing.
+	RetryAfter time.Duration `json:"retryAfter,omitempty"`
+
+	// Error is the provider's own error structure as the adapter parsed it,
+	// for support; RC and Message stay the flat form routing works with.
+	Error *models.ProviderError `json:"error,omitempty"`
 }
 
 // PPOBProvider interface that all providers must implement
@@ -87,6 +105,96 @@ type PPOBProviderClient interface {
 	IsHealthy() bool
 }
 
+// ProviderBalanceChecker is implemented by provider clients whose API exposes
+// the deposit (float) balance.
+type ProviderBalanceChecker interface {
+	Balance(ctx context.Context) (int64, error)
+}
+
+// ProviderSelfTester is implemented by provider clients that have a cheaper
+// or more telling credential check than Balance or GetPriceList (sign-on,
+// token fetch). check names the call made.
+type ProviderSelfTester interface {
+	SelfTest(ctx context.Context) (check string, err error)
+}
+
+// ProviderCapabilities describes which PPOB operations a provider client
+// actually performs; every client implements the full PPOBProviderClient
+// interface, but some methods only return an error.
+type ProviderCapabilities struct {
+	SupportsPrepaid     bool `json:"supportsPrepaid"`
+	SupportsPostpaid    bool `json:"supportsPostpaid"` // inquiry + payment
+	SupportsBalance     bool `json:"supportsBalance"`
+	SupportsStatusCheck bool `json:"supportsStatusCheck"`
+}
+
+// ProviderCapabilityReporter is implemented by provider clients that do not
+// support every PPOBProviderClient operation.
+type ProviderCapabilityReporter interface {
+	Capabilities() ProviderCapabilities
+}
+
+// ProviderSandboxReporter is implemented by provider clients that can serve
+// sandbox requests from a separate sandbox account. Clients without it, or
+// reporting false, are never used for sandbox requests, so sandbox traffic
+// cannot reach a production provider account.
+type ProviderSandboxReporter interface {
+	HasSandbox() bool
+}
+
+// clientHasSandbox reports whether client may serve sandbox requests.
+func clientHasSandbox(client PPOBProviderClient) bool {
+	r, ok := client.(ProviderSandboxReporter)
+	return ok && r.HasSandbox()
+}
+
+// ProviderRefLookup is implemented by provider clients whose CheckStatus
+// also finds a transaction by the RefID we sent, so one the provider never
+// gave its own ref can still be reconciled.
+type ProviderRefLookup interface {
+	ChecksStatusByRefID() bool
+}
+
+// clientChecksStatusByRefID reports whether client's CheckStatus accepts our
+// RefID.
+func clientChecksStatusByRefID(client PPOBProviderClient) bool {
+	l, ok := client.(ProviderRefLookup)
+	return ok && l.ChecksStatusByRefID()
+}
+
+type sandboxKey struct{}
+
+// WithSandbox marks ctx as belonging to a sandbox transaction, so that
+// PPOBProviderClient.CheckStatus, which only gets the provider ref, asks the
+// provider's sandbox account.
+func WithSandbox(ctx context.Context, sandbox bool) context.Context {
+	if !sandbox {
+		return ctx
+	}
+	return context.WithValue(ctx, sandboxKey{}, true)
+}
+
+func isSandboxContext(ctx context.Context) bool {
+	sandbox, _ := ctx.Value(sandboxKey{}).(bool)
+	return sandbox
+}
+
+// ClientCapabilities returns what client supports: its own report when it
+// implements ProviderCapabilityReporter, otherwise everything, with balance
+// following ProviderBalanceChecker.
+func ClientCapabilities(client PPOBProviderClient) ProviderCapabilities {
+	if r, ok := client.(ProviderCapabilityReporter); ok {
+		return r.Capabilities()
+	}
+	_, hasBalance := client.(ProviderBalanceChecker)
+	return ProviderCapabilities{
+		SupportsPrepaid:     true,
+		SupportsPostpaid:    true,
+		SupportsBalance:     hasBalance,
+		SupportsStatusCheck: true,
+	}
+}
+
 // ProviderProduct represents a product from provider's price list
 type ProviderProduct struct {
 	SKUCode     string `json:"skuCode"`
@@ -103,8 +211,32 @@ type ProviderProduct struct {
 type ProviderRouter struct {
 	providerRepo *repository.PPOBProviderRepository
 	providers    map[models.ProviderCode]PPOBProviderClient
+	tieBalancer  *TieBalancer // optional weighted round-robin among tied providers
+	alerts       notify.Notifier
+	// ambiguousPending keeps prepaid/payment transactions Processing on an
+	// ambiguous provider response instead of failing over to another provider.
+	ambiguousPending bool
+	// healthBuffer batches health writes; nil writes each call directly.
+	healthBuffer *ProviderHealthBuffer
+	// pendingPoll re-checks pending results in the request; nil returns them
+	// as pending.
+	pendingPoll *pendingPoll
+
+	// throttledUntil holds providers that answered with a Retry-After hint;
+	// Execute skips them until that time passes.
+	throttleMu     sync.Mutex
+	throttledUntil map[models.ProviderCode]time.Time
 }
 
+// maxProviderRetryAfter caps a provider's Retry-After hint so a bogus header
+// cannot take a provider out of routing for hours.
+const maxProviderRetryAfter = 10 * time.Minute
+
+// errProvidersThrottled signals that a prepaid run ended with providers left
+// untried because they asked to be retried later; ExecuteResult.RetryAfter
+// says when the earliest of them is available again.
+var errProvidersThrottled = errors.New("remaining providers are rate limited")
+
 // NewProviderRouter creates a new ProviderRouter
 func NewProviderRouter(providerRepo *repository.PPOBProviderRepository) *ProviderRouter {
 	return &ProviderRouter{
@@ -118,6 +250,114 @@ func (r *ProviderRouter) RegisterProvider(code models.ProviderCode, client PPOBP
 	r.providers[code] = client
 }
 
+// SetTieBalancer enables weighted round-robin among exactly tied providers.
+func (r *ProviderRouter) SetTieBalancer(b *TieBalancer) {
+	r.tieBalancer = b
+}
+
+// SetAlertNotifier raises a provider_failover ops event whenever routing
+// falls through to a backup provider.
+func (r *ProviderRouter) SetAlertNotifier(n notify.Notifier) {
+	r.alerts = n
+}
+
+// SetHealthBuffer batches provider health writes through b instead of one
+// upsert per provider call.
+func (r *ProviderRouter) SetHealthBuffer(b *ProviderHealthBuffer) {
+	r.healthBuffer = b
+}
+
+// recordHealth records one provider call for health tracking.
+func (r *ProviderRouter) recordHealth(providerID int, success bool, responseTimeMs int, failureReason string) {
+	if r.healthBuffer != nil {
+		r.healthBuffer.Record(providerID, success, responseTimeMs, failureReason)
+		return
+	}
+	_ = r.providerRepo.RecordProviderRequest(providerID, success, responseTimeMs, failureReason)
+}
+
+// throttle keeps code out of routing for d, as asked by its Retry-After hint.
+func (r *ProviderRouter) throttle(code models.ProviderCode, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	if d > maxProviderRetryAfter {
+		d = maxProviderRetryAfter
+	}
+	until := time.Now().Add(d)
+	r.throttleMu.Lock()
+	defer r.throttleMu.Unlock()
+	if r.throttledUntil == nil {
+		r.throttledUntil = make(map[models.ProviderCode]time.Time)
+	}
+	if until.After(r.throttledUntil[code]) {
+		r.throttledUntil[code] = until
+	}
+}
+
+// throttledFor returns how long code is still throttled, 0 when it is not.
+func (r *ProviderRouter) throttledFor(code models.ProviderCode) time.Duration {
+	r.throttleMu.Lock()
+	defer r.throttleMu.Unlock()
+	until, ok := r.throttledUntil[code]
+	if !ok {
+		return 0
+	}
+	wait := time.Until(until)
+	if wait <= 0 {
+		delete(r.throttledUntil, code)
+		return 0
+	}
+	return wait
+}
+
+// SetAmbiguousAsPending makes ambiguous prepaid and payment responses leave
+// the transaction Processing for the status check worker to reconcile, rather
+// than failing it and possibly charging again through the next provider.
+func (r *ProviderRouter) SetAmbiguousAsPending(enabled bool) {
+	r.ambiguousPending = enabled
+}
+
+// resolveAmbiguous turns an ambiguous prepaid/payment response into a pending
+// one when enabled, reporting whether it did. Inquiries are never charged, so
+// they keep failing over. The status check worker only picks up transactions
+// with a provider ref: a response without one gets req.RefID when client can
+// look it up, and otherwise keeps failing over, since nothing could ever
+// reconcile it.
+func (r *ProviderRouter) resolveAmbiguous(client PPOBProviderClient, req *ProviderRequest, resp *ProviderResponse) bool {
+	if !r.ambiguousPending || req.Type == ProviderTrxInquiry || !isAmbiguousResponse(resp) {
+		return false
+	}
+	if resp.ProviderRefID == "" {
+		if !clientChecksStatusByRefID(client) {
+			return false
+		}
+		resp.ProviderRefID = req.RefID
+	}
+	resp.Ambiguous = true
+	resp.Pending = true
+	return true
+}
+
+// AmbiguousAsPending reports whether a status check response should be kept
+// pending because it is ambiguous (see SetAmbiguousAsPending).
+func (r *ProviderRouter) AmbiguousAsPending(resp *ProviderResponse) bool {
+	return r.ambiguousPending && isAmbiguousResponse(resp)
+}
+
+// isAmbiguousResponse reports whether resp carries no usable outcome: flagged
+// by the adapter, or a 2xx answer that is neither success nor pending and has
+// no response code to classify the failure.
+func isAmbiguousResponse(resp *ProviderResponse) bool {
+	if resp == nil || resp.Success || resp.Pending {
+		return false
+	}
+	if resp.Ambiguous {
+		return true
+	}
+	return resp.HTTPStatus >= 200 && resp.HTTPStatus < 300 && strings.TrimSpace(resp.RC) == ""
+}
+
 // GetClients returns a copy of the provider clients map
 func (r *ProviderRouter) GetClients() map[models.ProviderCode]PPOBProviderClient {
 	result := make(map[models.ProviderCode]PPOBProviderClient)
@@ -127,6 +367,13 @@ func (r *ProviderRouter) GetClients() map[models.ProviderCode]PPOBProviderClient
 	return result
 }
 
+// IsReachable reports whether the provider is registered and currently healthy,
+// i.e. whether Execute would actually try it.
+func (r *ProviderRouter) IsReachable(code models.ProviderCode) bool {
+	client, ok := r.providers[code]
+	return ok && client.IsHealthy()
+}
+
 // GetAdapter returns the provider client for a given code, or nil if not found
 func (r *ProviderRouter) GetAdapter(code string) PPOBProviderClient {
 	return r.providers[models.ProviderCode(code)]
@@ -140,6 +387,9 @@ type ExecuteResult struct {
 	ProvidersTried []models.ProviderOption `json:"providersTried"`
 	Attempts       []ProviderAttempt       `json:"attempts,omitempty"`
 	Error          error                   `json:"error,omitempty"`
+	// RetryAfter is set with errProvidersThrottled: the wait until the first
+	// skipped provider can be tried again.
+	RetryAfter time.Duration `json:"retryAfter,omitempty"`
 }
 
 // ProviderAttempt captures one concrete provider attempt, including the request shape used.
@@ -188,7 +438,11 @@ func (r *ProviderRouter) Execute(ctx context.Context, productID int, req *Provid
 	if len(options) == 0 {
 		return nil, fmt.Errorf("no providers available for product %d", productID)
 	}
+	return r.executeOptions(ctx, productID, req, options, result)
+}
 
+// executeOptions tries options in order, as Execute describes.
+func (r *ProviderRouter) executeOptions(ctx context.Context, productID int, req *ProviderRequest, options []models.ProviderOption, result *ExecuteResult) (*ExecuteResult, error) {
 	if len(req.ExcludedProviderSKUIDs) > 0 {
 		filtered := make([]models.ProviderOption, 0, len(options))
 		for _, opt := range options {
@@ -204,6 +458,10 @@ func (r *ProviderRouter) Execute(ctx context.Context, productID int, req *Provid
 		return result, fmt.Errorf("no remaining providers available for product %d", productID)
 	}
 
+	if r.tieBalancer != nil {
+		r.tieBalancer.Apply(ctx, productID, req.Type, options)
+	}
+
 	log.Debug().
 		Int("product_id", productID).
 		Int("provider_count", len(options)).
@@ -212,8 +470,19 @@ func (r *ProviderRouter) Execute(ctx context.Context, productID int, req *Provid
 
 	refIDSuffix := 0
 	baseRefID := req.RefID
+	var throttledWait time.Duration
+	var err error
 
 	for _, opt := range options {
+		// Request deadline passed: stop before starting another provider.
+		if len(result.Attempts) > 0 && attemptDeadlineExceeded(ctx) {
+			return result, errAttemptDeadline
+		}
+		// Synchronous limit used up: leave the rest to async continuation.
+		if attemptLimitReached(ctx, len(result.Attempts)) {
+			return result, errAttemptLimit
+		}
+
 		// Get provider client
 		client, ok := r.providers[opt.ProviderCode]
 		if !ok {
@@ -223,6 +492,13 @@ func (r *ProviderRouter) Execute(ctx context.Context, productID int, req *Provid
 			continue
 		}
 
+		if req.IsSandbox && !clientHasSandbox(client) {
+			log.Debug().
+				Str("provider", string(opt.ProviderCode)).
+				Msg("Provider has no sandbox account, skipping sandbox request")
+			continue
+		}
+
 		// Check if provider is healthy
 		if !client.IsHealthy() {
 			log.Warn().
@@ -231,6 +507,18 @@ func (r *ProviderRouter) Execute(ctx context.Context, productID int, req *Provid
 			continue
 		}
 
+		// Provider asked us to back off: skip it for now, remembering the
+		// earliest time one of the skipped providers frees up. Sandbox
+		// accounts have their own limits, so sandbox requests ignore this.
+		if wait := r.throttledFor(opt.ProviderCode); wait > 0 && !req.IsSandbox {
+			log.Warn().
+				Str("provider", string(opt.ProviderCode)).
+				Dur("retry_after", wait).
+				Msg("Provider rate limited, skipping")
+			throttledWait = earliestWait(throttledWait, wait)
+			continue
+		}
+
 		// Update ref ID for each attempt (to avoid duplicate issues)
 		if refIDSuffix > 0 {
 			req.RefID = fmt.Sprintf("%s-%d", baseRefID, refIDSuffix)
@@ -239,13 +527,7 @@ func (r *ProviderRouter) Execute(ctx context.Context, productID int, req *Provid
 		}
 
 		// Update SKU code and amounts for this provider
-		req.SKUCode = opt.ProviderSKUCode
-		req.Amount = opt.Price
-		if req.Extra == nil {
-			req.Extra = make(map[string]any)
-		}
-		req.Extra["admin"] = opt.Admin
-		req.Extra["commission"] = opt.Commission
+		applyProviderOption(req, opt)
 
 		result.ProvidersTried = append(result.ProvidersTried, opt)
 
@@ -257,6 +539,9 @@ func (r *ProviderRouter) Execute(ctx context.Context, productID int, req *Provid
 			Bool("is_backup", opt.IsBackup).
 			Str("ref_id", req.RefID).
 			Msg("Trying provider")
+		if opt.IsBackup && len(result.Attempts) > 0 && !req.IsSandbox {
+			r.alertFailover(ctx, productID, req, opt, result.Attempts)
+		}
 
 		reqSnapshot := cloneProviderRequest(req)
 		startTime := time.Now()
@@ -285,12 +570,14 @@ func (r *ProviderRouter) Execute(ctx context.Context, productID int, req *Provid
 			failureReason = resp.Message
 		}
 
-		_ = r.providerRepo.RecordProviderRequest(
-			opt.ProviderID,
-			success,
-			int(responseTime.Milliseconds()),
-			failureReason,
-		)
+		if !req.IsSandbox {
+			r.recordHealth(
+				opt.ProviderID,
+				success,
+				int(responseTime.Milliseconds()),
+				failureReason,
+			)
+		}
 
 		// Handle network error - retry same provider with same ref_id is safe
 		if err != nil {
@@ -309,6 +596,15 @@ func (r *ProviderRouter) Execute(ctx context.Context, productID int, req *Provid
 			continue
 		}
 
+		if r.resolveAmbiguous(client, req, resp) {
+			log.Warn().
+				Str("provider", string(opt.ProviderCode)).
+				Str("ref_id", req.RefID).
+				Int("http_status", resp.HTTPStatus).
+				Msg("Ambiguous provider response, leaving transaction processing for status check")
+		}
+		resp = r.settlePending(ctx, client, req, resp)
+
 		// Handle response
 		if resp.Success {
 			result.Attempts = append(result.Attempts, ProviderAttempt{
@@ -346,7 +642,12 @@ func (r *ProviderRouter) Execute(ctx context.Context, productID int, req *Provid
 			return result, nil // Return pending - don't try other providers
 		}
 
-		// Transaction failed - try next provider
+		// Transaction failed - try next provider. One that asked to be retried
+		// later counts like those skipped as throttled.
+		if !req.IsSandbox && resp.RetryAfter > 0 {
+			r.throttle(opt.ProviderCode, resp.RetryAfter)
+			throttledWait = earliestWait(throttledWait, r.throttledFor(opt.ProviderCode))
+		}
 		result.Attempts = append(result.Attempts, ProviderAttempt{
 			Provider: providerOptionPtr(opt),
 			Request:  reqSnapshot,
@@ -360,6 +661,7 @@ func (r *ProviderRouter) Execute(ctx context.Context, productID int, req *Provid
 			Str("rc", resp.RC).
 			Str("message", resp.Message).
 			Bool("needs_new_ref_id", resp.NeedsRetry).
+			Dur("retry_after", resp.RetryAfter).
 			Msg("Transaction failed, trying next provider")
 
 		// For backup provider, we don't continue
@@ -367,6 +669,9 @@ func (r *ProviderRouter) Execute(ctx context.Context, productID int, req *Provid
 			result.Response = resp
 			result.ProviderUsed = &opt
 			result.Error = fmt.Errorf("all providers failed: %s", resp.Message)
+			if deferThrottled(req.Type, throttledWait, result) {
+				return result, errProvidersThrottled
+			}
 			return result, nil
 		}
 
@@ -374,10 +679,73 @@ func (r *ProviderRouter) Execute(ctx context.Context, productID int, req *Provid
 		refIDSuffix++
 	}
 
+	if deferThrottled(req.Type, throttledWait, result) {
+		return result, errProvidersThrottled
+	}
+
 	// All providers failed
 	return result, fmt.Errorf("all providers exhausted")
 }
 
+// applyProviderOption points req at the provider SKU of opt: its price as the
+// amount, plus its admin and commission.
+func applyProviderOption(req *ProviderRequest, opt models.ProviderOption) {
+	req.SKUCode = opt.ProviderSKUCode
+	req.Amount = opt.Price
+	if req.Extra == nil {
+		req.Extra = make(map[string]any)
+	}
+	req.Extra["admin"] = opt.Admin
+	req.Extra["commission"] = opt.Commission
+}
+
+// earliestWait returns the shorter of two throttle waits, 0 meaning none.
+func earliestWait(a, b time.Duration) time.Duration {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// deferThrottled reports whether a prepaid run that skipped rate-limited
+// providers should wait for them rather than fail, setting the wait on
+// result. Inquiries and payments answer the client synchronously, so they
+// cannot be deferred.
+func deferThrottled(t ProviderTransactionType, wait time.Duration, result *ExecuteResult) bool {
+	if t != ProviderTrxPrepaid || wait <= 0 {
+		return false
+	}
+	result.RetryAfter = wait
+	return true
+}
+
+// alertFailover reports that the non-backup providers failed and the backup is
+// being tried.
+func (r *ProviderRouter) alertFailover(ctx context.Context, productID int, req *ProviderRequest, backup models.ProviderOption, attempts []ProviderAttempt) {
+	if r.alerts == nil {
+		return
+	}
+	failed := make([]string, 0, len(attempts))
+	for _, a := range attempts {
+		if a.Provider != nil {
+			failed = append(failed, string(a.Provider.ProviderCode))
+		}
+	}
+	r.alerts.Notify(ctx, notify.Event{
+		Type:     notify.EventProviderFailover,
+		Severity: notify.SeverityWarning,
+		Key:      string(backup.ProviderCode),
+		Message:  "primary providers failed, falling back to backup provider",
+		Fields: map[string]any{
+			"product_id":       productID,
+			"type":             string(req.Type),
+			"ref_id":           req.RefID,
+			"backup_provider":  string(backup.ProviderCode),
+			"failed_providers": strings.Join(failed, ","),
+		},
+	})
+}
+
 // executeWithProvider executes a transaction with a specific provider (user preference or payment after inquiry)
 func (r *ProviderRouter) executeWithProvider(ctx context.Context, productID int, req *ProviderRequest, result *ExecuteResult) (*ExecuteResult, error) {
 	// Get the specific provider
@@ -385,6 +753,9 @@ func (r *ProviderRouter) executeWithProvider(ctx context.Context, productID int,
 	if !ok {
 		return nil, fmt.Errorf("forced provider %s not registered", req.ForceProvider)
 	}
+	if req.IsSandbox && !clientHasSandbox(client) {
+		return nil, fmt.Errorf("forced provider %s has no sandbox account", req.ForceProvider)
+	}
 
 	// Get provider options — include unavailable since this is a forced provider request
 	options, err := r.providerRepo.GetProvidersForProductAll(productID)
@@ -405,13 +776,7 @@ func (r *ProviderRouter) executeWithProvider(ctx context.Context, productID int,
 	}
 
 	// Update SKU code and amounts for this provider
-	req.SKUCode = opt.ProviderSKUCode
-	req.Amount = opt.Price
-	if req.Extra == nil {
-		req.Extra = make(map[string]any)
-	}
-	req.Extra["admin"] = opt.Admin
-	req.Extra["commission"] = opt.Commission
+	applyProviderOption(req, *opt)
 
 	log.Info().
 		Str("provider", string(opt.ProviderCode)).
@@ -446,12 +811,14 @@ func (r *ProviderRouter) executeWithProvider(ctx context.Context, productID int,
 		failureReason = resp.Message
 	}
 
-	_ = r.providerRepo.RecordProviderRequest(
-		opt.ProviderID,
-		success,
-		int(responseTime.Milliseconds()),
-		failureReason,
-	)
+	if !req.IsSandbox {
+		r.recordHealth(
+			opt.ProviderID,
+			success,
+			int(responseTime.Milliseconds()),
+			failureReason,
+		)
+	}
 
 	if err != nil {
 		result.Attempts = append(result.Attempts, ProviderAttempt{
@@ -462,6 +829,19 @@ func (r *ProviderRouter) executeWithProvider(ctx context.Context, productID int,
 		return nil, fmt.Errorf("%s failed with provider %s: %w", req.Type, req.ForceProvider, err)
 	}
 
+	if r.resolveAmbiguous(client, req, resp) {
+		log.Warn().
+			Str("provider", string(opt.ProviderCode)).
+			Str("ref_id", req.RefID).
+			Int("http_status", resp.HTTPStatus).
+			Msg("Ambiguous provider response, leaving transaction processing for status check")
+	}
+	resp = r.settlePending(ctx, client, req, resp)
+
+	if !resp.Success && !resp.Pending && !req.IsSandbox {
+		r.throttle(opt.ProviderCode, resp.RetryAfter)
+	}
+
 	result.Attempts = append(result.Attempts, ProviderAttempt{
 		Provider: providerOptionPtr(*opt),
 		Request:  reqSnapshot,
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+// Outcomes of a provider in a RoutingSimulation.
+const (
+	SimulatedSelected      = "selected"       // the router would settle here
+	SimulatedForcedFailure = "forced_failure" // tried, failed as requested by the simulation
+	SimulatedFallback      = "fallback"       // only tried if everything before it fails
+	SimulatedSkipped       = "skipped"        // never tried, see SkipReason
+)
+
+// Reasons a provider is skipped in a RoutingSimulation.
+const (
+	SkipNotAvailable  = "not_available"  // SKU unavailable, no price, or provider in maintenance/cut-off
+	SkipNotRegistered = "not_registered" // no provider client configured
+	SkipUnhealthy     = "unhealthy"
+	SkipThrottled     = "throttled" // provider asked to be retried later (Retry-After)
+)
+
+// SimulatedProvider is one provider SKU of a product as the router sees it.
+type SimulatedProvider struct {
+	ProviderCode     models.ProviderCode `json:"providerCode"`
+	ProviderSKUID    int                 `json:"providerSkuId"`
+	ProviderSKUCode  string              `json:"providerSkuCode"`
+	Price            int                 `json:"price"`
+	Admin            int                 `json:"admin"`
+	Commission       int                 `json:"commission"`
+	IsBackup         bool                `json:"isBackup"`
+	Available        bool                `json:"available"`
+	Registered       bool                `json:"registered"`
+	Healthy          bool                `json:"healthy"`
+	ThrottledSeconds int                 `json:"throttledSeconds,omitempty"`
+	Order            int                 `json:"order,omitempty"` // 1-based attempt position; 0 when skipped
+	Outcome          string              `json:"outcome"`
+	SkipReason       string              `json:"skipReason,omitempty"`
+}
+
+// RoutingSimulation is the attempt order the router would take for a product.
+type RoutingSimulation struct {
+	ProductID int                     `json:"productId"`
+	Type      ProviderTransactionType `json:"type"`
+	Selected  *models.ProviderCode    `json:"selected"` // nil when every candidate fails or is skipped
+	Providers []SimulatedProvider     `json:"providers"`
+	// TieRotation is set when tied providers take turns being first, so the
+	// order of those ties varies between real requests.
+	TieRotation bool `json:"tieRotation"`
+}
+
+// Simulate returns the provider attempt order Execute would take for
+// productID without calling any provider. Providers in fail are treated as
+// failing, to show where the router fails over to. A payment only goes to
+// the provider its inquiry settled on, so for ProviderTrxPayment that is the
+// one provider shown.
+func (r *ProviderRouter) Simulate(ctx context.Context, productID int, t ProviderTransactionType, fail map[models.ProviderCode]bool) (*RoutingSimulation, error) {
+	var ordered []models.ProviderOption
+	var err error
+	switch t {
+	case ProviderTrxPrepaid:
+		ordered, err = r.providerRepo.GetProvidersForProduct(productID)
+	case ProviderTrxInquiry, ProviderTrxPayment:
+		ordered, err = r.providerRepo.GetProvidersForProductPostpaid(productID)
+	default:
+		return nil, fmt.Errorf("invalid transaction type: %s", t)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get providers: %w", err)
+	}
+	all, err := r.providerRepo.GetProvidersForProductAll(productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get providers: %w", err)
+	}
+
+	var sim *RoutingSimulation
+	if t == ProviderTrxPayment {
+		sim = paymentSimulation(r.simulateOptions(ordered, all, nil), fail)
+	} else {
+		sim = r.simulateOptions(ordered, all, fail)
+	}
+	sim.ProductID, sim.Type = productID, t
+	sim.TieRotation = r.tieBalancer != nil && hasTies(t, ordered)
+	return sim, nil
+}
+
+// simulateOptions walks ordered like Execute does. all adds the provider SKUs
+// routing leaves out, reported as not available.
+func (r *ProviderRouter) simulateOptions(ordered, all []models.ProviderOption, fail map[models.ProviderCode]bool) *RoutingSimulation {
+	sim := &RoutingSimulation{Providers: make([]SimulatedProvider, 0, len(all))}
+	routed := make(map[int]bool, len(ordered))
+	order := 0
+
+	for _, opt := range ordered {
+		routed[opt.ProviderSKUID] = true
+		p := simulatedProvider(opt, true)
+		client, ok := r.providers[opt.ProviderCode]
+		p.Registered = ok
+		p.Healthy = ok && client.IsHealthy()
+		wait := r.throttledFor(opt.ProviderCode)
+		p.ThrottledSeconds = int(wait.Seconds())
+
+		switch {
+		case !p.Registered:
+			p.Outcome, p.SkipReason = SimulatedSkipped, SkipNotRegistered
+		case !p.Healthy:
+			p.Outcome, p.SkipReason = SimulatedSkipped, SkipUnhealthy
+		case wait > 0:
+			p.Outcome, p.SkipReason = SimulatedSkipped, SkipThrottled
+		default:
+			order++
+			p.Order = order
+			switch {
+			case sim.Selected != nil:
+				p.Outcome = SimulatedFallback
+			case fail[opt.ProviderCode]:
+				p.Outcome = SimulatedForcedFailure
+			default:
+				p.Outcome = SimulatedSelected
+				code := opt.ProviderCode
+				sim.Selected = &code
+			}
+		}
+		sim.Providers = append(sim.Providers, p)
+	}
+
+	for _, opt := range all {
+		if routed[opt.ProviderSKUID] {
+			continue
+		}
+		p := simulatedProvider(opt, false)
+		client, ok := r.providers[opt.ProviderCode]
+		p.Registered = ok
+		p.Healthy = ok && client.IsHealthy()
+		p.Outcome, p.SkipReason = SimulatedSkipped, SkipNotAvailable
+		sim.Providers = append(sim.Providers, p)
+	}
+	return sim
+}
+
+// paymentSimulation narrows inquiry, simulated without failures, to the
+// provider the inquiry settles on. The payment is sent there and nowhere
+// else (executePaymentWithProvider), so a failure has no fallback.
+func paymentSimulation(inquiry *RoutingSimulation, fail map[models.ProviderCode]bool) *RoutingSimulation {
+	sim := &RoutingSimulation{Providers: []SimulatedProvider{}}
+	if inquiry.Selected == nil {
+		return sim
+	}
+	for _, p := range inquiry.Providers {
+		if p.Outcome != SimulatedSelected {
+			continue
+		}
+		p.Order = 1
+		if fail[p.ProviderCode] {
+			p.Outcome = SimulatedForcedFailure
+		} else {
+			code := p.ProviderCode
+			sim.Selected = &code
+		}
+		sim.Providers = append(sim.Providers, p)
+	}
+	return sim
+}
+
+func simulatedProvider(opt models.ProviderOption, available bool) SimulatedProvider {
+	return SimulatedProvider{
+		ProviderCode:    opt.ProviderCode,
+		ProviderSKUID:   opt.ProviderSKUID,
+		ProviderSKUCode: opt.ProviderSKUCode,
+		Price:           opt.Price,
+		Admin:           opt.Admin,
+		Commission:      opt.Commission,
+		IsBackup:        opt.IsBackup,
+		Available:       available,
+	}
+}
+
+// hasTies reports whether two neighbouring options are tied the way
+// TieBalancer groups them.
+func hasTies(t ProviderTransactionType, options []models.ProviderOption) bool {
+	same := sameTiePrepaid
+	if t != ProviderTrxPrepaid {
+		same = sameTiePostpaid
+	}
+	for i := 1; i < len(options); i++ {
+		if same(options[i-1], options[i]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/pkg/alterra"
+	"github.com/GTDGit/gtd_api/pkg/digiflazz"
+)
+
+func TestClientCapabilities(t *testing.T) {
+	tests := []struct {
+		name   string
+		client PPOBProviderClient
+		want   ProviderCapabilities
+	}{
+		{
+			name:   "alterra has everything",
+			client: NewAlterraProviderClient(nil, nil),
+			want:   ProviderCapabilities{SupportsPrepaid: true, SupportsPostpaid: true, SupportsBalance: true, SupportsStatusCheck: true},
+		},
+		{
+			name:   "kiosbank has no balance API",
+			client: NewKiosbankProviderClient(nil, nil, nil, nil, nil),
+			want:   ProviderCapabilities{SupportsPrepaid: true, SupportsPostpaid: true, SupportsStatusCheck: true},
+		},
+		{
+			name:   "digiflazz has no status check",
+			client: NewDigiflazzProviderClient(nil, nil),
+			want:   ProviderCapabilities{SupportsPrepaid: true, SupportsPostpaid: true},
+		},
+		{
+			name:   "bri is BRIZZI top-up only",
+			client: NewBRIProviderClient(nil, nil),
+			want:   ProviderCapabilities{SupportsPrepaid: true, SupportsStatusCheck: true},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ClientCapabilities(tc.client); got != tc.want {
+				t.Fatalf("ClientCapabilities() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveAmbiguousKeepsChargingRequestsPending(t *testing.T) {
+	r := &ProviderRouter{}
+	r.SetAmbiguousAsPending(true)
+	kiosbank := NewKiosbankProviderClient(nil, nil, nil, nil, nil)
+	alterra := NewAlterraProviderClient(nil, nil)
+	req := func(typ ProviderTransactionType) *ProviderRequest {
+		return &ProviderRequest{RefID: "GRB-1", Type: typ}
+	}
+
+	resp := &ProviderResponse{HTTPStatus: 200, Message: "OK", ProviderRefID: "ALT-9"}
+	if !r.resolveAmbiguous(alterra, req(ProviderTrxPrepaid), resp) {
+		t.Fatal("HTTP 200 without a response code should be ambiguous")
+	}
+	if !resp.Pending || !resp.Ambiguous || resp.Success || resp.ProviderRefID != "ALT-9" {
+		t.Fatalf("resolved response = %+v, want pending and ambiguous with the provider ref", resp)
+	}
+	// Pending responses leave the transaction Processing; the status check
+	// worker keeps ambiguous status answers pending too.
+	if !r.AmbiguousAsPending(&ProviderResponse{HTTPStatus: 200}) {
+		t.Fatal("ambiguous status check response should stay pending")
+	}
+
+	cases := []struct {
+		name   string
+		r      *ProviderRouter
+		client PPOBProviderClient
+		typ    ProviderTransactionType
+		resp   *ProviderResponse
+	}{
+		{"classified failure", r, kiosbank, ProviderTrxPrepaid, &ProviderResponse{HTTPStatus: 200, RC: "14"}},
+		{"http error", r, kiosbank, ProviderTrxPayment, &ProviderResponse{HTTPStatus: 502}},
+		{"inquiry", r, kiosbank, ProviderTrxInquiry, &ProviderResponse{HTTPStatus: 200}},
+		{"success", r, kiosbank, ProviderTrxPrepaid, &ProviderResponse{HTTPStatus: 200, Success: true}},
+		{"disabled", &ProviderRouter{}, kiosbank, ProviderTrxPrepaid, &ProviderResponse{HTTPStatus: 200, Ambiguous: true}},
+		{"no ref to check by", r, alterra, ProviderTrxPrepaid, &ProviderResponse{HTTPStatus: 200}},
+	}
+	for _, tc := range cases {
+		if tc.r.resolveAmbiguous(tc.client, req(tc.typ), tc.resp) || tc.resp.Pending {
+			t.Errorf("%s: response unexpectedly resolved to pending", tc.name)
+		}
+	}
+
+	// Without a provider ref the transaction is left with ours, so the status
+	// check worker, which needs provider_ref_id, still picks it up.
+	flagged := &ProviderResponse{Ambiguous: true}
+	if !r.resolveAmbiguous(kiosbank, req(ProviderTrxPayment), flagged) || !flagged.Pending {
+		t.Fatal("adapter-flagged ambiguous payment should resolve to pending")
+	}
+	if flagged.ProviderRefID != "GRB-1" {
+		t.Fatalf("ProviderRefID = %q, want the fallback GRB-1", flagged.ProviderRefID)
+	}
+}
+
+func TestProviderThrottleHonorsRetryAfter(t *testing.T) {
+	r := &ProviderRouter{}
+	if got := r.throttledFor("alterra"); got != 0 {
+		t.Fatalf("unthrottled provider wait = %v, want 0", got)
+	}
+
+	r.throttle("alterra", 30*time.Second)
+	if got := r.throttledFor("alterra"); got <= 25*time.Second || got > 30*time.Second {
+		t.Fatalf("throttled wait = %v, want about 30s", got)
+	}
+	// A shorter hint never shortens an existing back-off.
+	r.throttle("alterra", time.Second)
+	if got := r.throttledFor("alterra"); got <= 25*time.Second {
+		t.Fatalf("wait after shorter hint = %v, want about 30s", got)
+	}
+	if got := r.throttledFor("kiosbank"); got != 0 {
+		t.Fatalf("other provider wait = %v, want 0", got)
+	}
+
+	r.throttle("kiosbank", 24*time.Hour)
+	if got := r.throttledFor("kiosbank"); got > maxProviderRetryAfter {
+		t.Fatalf("wait = %v, want capped at %v", got, maxProviderRetryAfter)
+	}
+
+	r.throttledUntil["alterra"] = time.Now().Add(-time.Second)
+	if got := r.throttledFor("alterra"); got != 0 {
+		t.Fatalf("expired throttle wait = %v, want 0", got)
+	}
+}
+
+// throttlingClient fails every top-up with a Retry-After hint.
+type throttlingClient struct {
+	PPOBProviderClient
+	retryAfter time.Duration
+	topups     int
+}
+
+func (c *throttlingClient) IsHealthy() bool { return true }
+
+func (c *throttlingClient) Topup(ctx context.Context, req *ProviderRequest) (*ProviderResponse, error) {
+	c.topups++
+	return &ProviderResponse{HTTPStatus: 429, RC: "85", Message: "rate limited", RetryAfter: c.retryAfter}, nil
+}
+
+func TestExecuteDefersWhenOnlyProviderThrottlesMidRun(t *testing.T) {
+	client := &throttlingClient{retryAfter: 20 * time.Second}
+	r := &ProviderRouter{providers: map[models.ProviderCode]PPOBProviderClient{"alterra": client}}
+	r.SetHealthBuffer(NewProviderHealthBuffer(nil, time.Minute))
+	options := []models.ProviderOption{{ProviderID: 1, ProviderCode: "alterra", ProviderSKUID: 10, ProviderSKUCode: "TSEL10", Price: 10000}}
+
+	result, err := r.executeOptions(context.Background(), 1, &ProviderRequest{RefID: "GRB-1", Type: ProviderTrxPrepaid}, options, &ExecuteResult{})
+	if !errors.Is(err, errProvidersThrottled) {
+		t.Fatalf("err = %v, want errProvidersThrottled", err)
+	}
+	if client.topups != 1 || result.RetryAfter <= 15*time.Second || result.RetryAfter > 20*time.Second {
+		t.Fatalf("topups = %d, RetryAfter = %v; want one attempt and about 20s", client.topups, result.RetryAfter)
+	}
+
+	// Payments answer synchronously: the provider is throttled, but the run fails.
+	_, err = r.executeOptions(context.Background(), 1, &ProviderRequest{RefID: "GRB-2", Type: ProviderTrxPayment}, options, &ExecuteResult{})
+	if err == nil || errors.Is(err, errProvidersThrottled) {
+		t.Fatalf("payment err = %v, want all providers exhausted", err)
+	}
+}
+
+func TestDeferThrottledOnlyForPrepaid(t *testing.T) {
+	result := &ExecuteResult{}
+	if !deferThrottled(ProviderTrxPrepaid, 20*time.Second, result) || result.RetryAfter != 20*time.Second {
+		t.Fatalf("prepaid with throttled providers should defer, RetryAfter = %v", result.RetryAfter)
+	}
+	for _, typ := range []ProviderTransactionType{ProviderTrxInquiry, ProviderTrxPayment} {
+		if deferThrottled(typ, 20*time.Second, &ExecuteResult{}) {
+			t.Errorf("%s should not defer", typ)
+		}
+	}
+	if deferThrottled(ProviderTrxPrepaid, 0, &ExecuteResult{}) {
+		t.Error("prepaid without throttled providers should not defer")
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	cases := map[time.Duration]time.Duration{
+		0:                60 * time.Second,
+		5 * time.Second:  5 * time.Second,
+		2 * time.Hour:    maxProviderRetryAfter,
+		-1 * time.Second: 60 * time.Second,
+	}
+	for in, want := range cases {
+		if got := rateLimitWait(in); got != want {
+			t.Errorf("rateLimitWait(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestClientHasSandbox(t *testing.T) {
+	prod, staging := &alterra.Client{}, &alterra.Client{}
+	digiProd, digiDev := &digiflazz.Client{}, &digiflazz.Client{}
+	tests := []struct {
+		name   string
+		client PPOBProviderClient
+		want   bool
+	}{
+		{"alterra with staging account", NewAlterraProviderClient(prod, staging), true},
+		{"alterra without staging account", NewAlterraProviderClient(prod, nil), false},
+		{"alterra sharing the production account", NewAlterraProviderClient(prod, prod), false},
+		{"digiflazz with development key", NewDigiflazzProviderClient(digiProd, digiDev), true},
+		{"digiflazz without development key", NewDigiflazzProviderClient(digiProd, nil), false},
+		{"bri does not report sandbox", NewBRIProviderClient(nil, nil), false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := clientHasSandbox(tc.client); got != tc.want {
+				t.Fatalf("clientHasSandbox() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAlterraSandboxNeverUsesProduction(t *testing.T) {
+	prod := &alterra.Client{}
+	c := NewAlterraProviderClient(prod, prod)
+	if _, err := c.getClient(true); err == nil {
+		t.Fatal("sandbox request got the production client")
+	}
+	if _, err := c.Topup(context.Background(), &ProviderRequest{SKUCode: "1", IsSandbox: true}); err == nil {
+		t.Fatal("sandbox topup without staging account should fail")
+	}
+	if got, err := c.getClient(false); err != nil || got != prod {
+		t.Fatalf("production client = %p, %v; want %p", got, err, prod)
+	}
+}
+
+func TestSandboxContext(t *testing.T) {
+	ctx := context.Background()
+	if isSandboxContext(ctx) || isSandboxContext(WithSandbox(ctx, false)) {
+		t.Fatal("plain context reported sandbox")
+	}
+	if !isSandboxContext(WithSandbox(ctx, true)) {
+		t.Fatal("WithSandbox(true) not reported")
+	}
+}
+
+func TestUseRouterInSandbox(t *testing.T) {
+	s := &TransactionService{providerRouter: &ProviderRouter{}}
+	if !s.useRouter(false) || s.useRouter(true) {
+		t.Fatal("without sandbox routing only production uses the router")
+	}
+	s.SetSandboxRouting(true)
+	if !s.useRouter(true) {
+		t.Fatal("sandbox routing enabled but sandbox skips the router")
+	}
+	if (&TransactionService{sandboxRouting: true}).useRouter(true) {
+		t.Fatal("no router configured")
+	}
+}
+
+func TestSimulateOptionsFailover(t *testing.T) {
+	r := &ProviderRouter{providers: map[models.ProviderCode]PPOBProviderClient{
+		models.ProviderKiosbank:  NewKiosbankProviderClient(nil, nil, nil, nil, nil),
+		models.ProviderAlterra:   NewAlterraProviderClient(nil, nil),
+		models.ProviderDigiflazz: NewDigiflazzProviderClient(nil, nil),
+	}}
+	r.throttle(models.ProviderAlterra, time.Minute)
+
+	ordered := []models.ProviderOption{
+		{ProviderCode: models.ProviderAlterra, ProviderSKUID: 1, Price: 9000},
+		{ProviderCode: models.ProviderKiosbank, ProviderSKUID: 2, Price: 9100},
+		{ProviderCode: models.ProviderDigiflazz, ProviderSKUID: 3, Price: 9200},
+		{ProviderCode: "unknown", ProviderSKUID: 4, Price: 9300, IsBackup: true},
+	}
+	all := append(append([]models.ProviderOption{}, ordered...), models.ProviderOption{ProviderCode: models.ProviderKiosbank, ProviderSKUID: 5})
+
+	sim := r.simulateOptions(ordered, all, map[models.ProviderCode]bool{models.ProviderKiosbank: true})
+	if sim.Selected == nil || *sim.Selected != models.ProviderDigiflazz {
+		t.Fatalf("selected = %v, want digiflazz", sim.Selected)
+	}
+	want := []struct {
+		outcome, reason string
+		order           int
+	}{
+		{SimulatedSkipped, SkipThrottled, 0},
+		{SimulatedForcedFailure, "", 1},
+		{SimulatedSelected, "", 2},
+		{SimulatedSkipped, SkipNotRegistered, 0},
+		{SimulatedSkipped, SkipNotAvailable, 0},
+	}
+	if len(sim.Providers) != len(want) {
+		t.Fatalf("providers = %d, want %d", len(sim.Providers), len(want))
+	}
+	for i, w := range want {
+		p := sim.Providers[i]
+		if p.Outcome != w.outcome || p.SkipReason != w.reason || p.Order != w.order {
+			t.Errorf("provider %d (%s) = %s/%s/%d, want %s/%s/%d", i, p.ProviderCode, p.Outcome, p.SkipReason, p.Order, w.outcome, w.reason, w.order)
+		}
+	}
+	if sim.Providers[4].Available || !sim.Providers[2].Available {
+		t.Error("availability not reported")
+	}
+
+	sim = r.simulateOptions(ordered[1:3], ordered[1:3], nil)
+	if *sim.Selected != models.ProviderKiosbank || sim.Providers[1].Outcome != SimulatedFallback {
+		t.Fatalf("without failures = %+v", sim.Providers)
+	}
+}
+
+func TestSimulatedProviderChargesLikeARequest(t *testing.T) {
+	cases := []models.ProviderOption{
+		{ProviderCode: models.ProviderKiosbank, ProviderSKUCode: "PLN20", Price: 20500, Admin: 2500, Commission: 800},
+		{ProviderCode: models.ProviderAlterra, ProviderSKUCode: "BPJS", Price: 0, Admin: 2500},
+	}
+	for _, opt := range cases {
+		req := &ProviderRequest{}
+		applyProviderOption(req, opt)
+		p := simulatedProvider(opt, true)
+		if p.ProviderSKUCode != req.SKUCode || p.Price != req.Amount || p.Admin != req.Extra["admin"] || p.Commission != req.Extra["commission"] {
+			t.Errorf("%s: simulated %s/%d/%d/%d, request %s/%d/%v/%v", opt.ProviderCode,
+				p.ProviderSKUCode, p.Price, p.Admin, p.Commission, req.SKUCode, req.Amount, req.Extra["admin"], req.Extra["commission"])
+		}
+	}
+}
+
+func TestPaymentSimulationShowsOnlyInquiryProvider(t *testing.T) {
+	r := &ProviderRouter{providers: map[models.ProviderCode]PPOBProviderClient{
+		models.ProviderKiosbank: NewKiosbankProviderClient(nil, nil, nil, nil, nil),
+		models.ProviderAlterra:  NewAlterraProviderClient(nil, nil),
+	}}
+	ordered := []models.ProviderOption{
+		{ProviderCode: models.ProviderAlterra, ProviderSKUID: 1, Admin: 2500},
+		{ProviderCode: models.ProviderKiosbank, ProviderSKUID: 2, Admin: 2700},
+	}
+	inquiry := r.simulateOptions(ordered, ordered, nil)
+
+	sim := paymentSimulation(inquiry, nil)
+	if sim.Selected == nil || *sim.Selected != models.ProviderAlterra || len(sim.Providers) != 1 || sim.Providers[0].Order != 1 {
+		t.Fatalf("payment = %+v, want only alterra", sim.Providers)
+	}
+
+	sim = paymentSimulation(inquiry, map[models.ProviderCode]bool{models.ProviderAlterra: true})
+	if sim.Selected != nil || len(sim.Providers) != 1 || sim.Providers[0].Outcome != SimulatedForcedFailure {
+		t.Fatalf("failed payment = %+v, want alterra failing with no fallback", sim.Providers)
+	}
+}
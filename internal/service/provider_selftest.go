@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+// providerSelfTestTimeout bounds one self-test call.
+const providerSelfTestTimeout = 20 * time.Second
+
+// ProviderSelfTestResult reports whether a provider adapter can reach and
+// authenticate with its provider.
+type ProviderSelfTestResult struct {
+	ProviderID   int                 `json:"providerId"`
+	ProviderCode models.ProviderCode `json:"providerCode"`
+	// Check is the call exercised: the adapter's own self test (sign_on,
+	// token), balance, or price_list. None of them moves money.
+	Check         string `json:"check"`
+	Reachable     bool   `json:"reachable"`     // the provider answered
+	Authenticated bool   `json:"authenticated"` // the call succeeded with the configured credentials
+	LatencyMs     int64  `json:"latencyMs"`
+	Balance       *int64 `json:"balance,omitempty"`
+	Products      *int   `json:"products,omitempty"` // price list size
+	Error         string `json:"error,omitempty"`
+}
+
+// RunProviderSelfTest exercises the cheapest safe call of client: its
+// ProviderSelfTester check, else Balance, else a full price list fetch.
+func RunProviderSelfTest(ctx context.Context, client PPOBProviderClient) ProviderSelfTestResult {
+	ctx, cancel := context.WithTimeout(ctx, providerSelfTestTimeout)
+	defer cancel()
+
+	result := ProviderSelfTestResult{ProviderCode: client.Code()}
+	start := time.Now()
+	var err error
+	switch c := client.(type) {
+	case ProviderSelfTester:
+		result.Check, err = c.SelfTest(ctx)
+	case ProviderBalanceChecker:
+		result.Check = "balance"
+		var balance int64
+		if balance, err = c.Balance(ctx); err == nil {
+			result.Balance = &balance
+		}
+	default:
+		result.Check = "price_list"
+		var products []ProviderProduct
+		if products, err = client.GetPriceList(ctx, ""); err == nil {
+			n := len(products)
+			result.Products = &n
+		}
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+
+	if err != nil {
+		result.Error = err.Error()
+		result.Reachable = !isConnectivityError(err)
+		return result
+	}
+	result.Reachable, result.Authenticated = true, true
+	return result
+}
+
+// isConnectivityError reports whether err means the provider never answered
+// (DNS, connect, TLS or timeout) rather than refused the request.
+func isConnectivityError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// SelfTestProvider runs RunProviderSelfTest against the registered client of
+// a provider, e.g. to validate its keys and base URL before enabling it.
+func (s *AdminPPOBService) SelfTestProvider(ctx context.Context, providerID int) (*ProviderSelfTestResult, error) {
+	provider, err := s.providerRepo.GetProviderByID(providerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAdminNotFound
+		}
+		return nil, fmt.Errorf("get provider: %w", err)
+	}
+
+	var client PPOBProviderClient
+	if s.trxSvc != nil && s.trxSvc.providerRouter != nil {
+		client = s.trxSvc.providerRouter.GetClients()[provider.Code]
+	}
+	if client == nil {
+		return nil, &AdminValidationError{Message: "provider has no registered client"}
+	}
+
+	result := RunProviderSelfTest(ctx, client)
+	result.ProviderID = provider.ID
+	return &result, nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+type selfTestPriceListClient struct {
+	PPOBProviderClient
+	products []ProviderProduct
+	err      error
+}
+
+func (c selfTestPriceListClient) Code() models.ProviderCode { return models.ProviderDigiflazz }
+func (c selfTestPriceListClient) GetPriceList(context.Context, string) ([]ProviderProduct, error) {
+	return c.products, c.err
+}
+
+type selfTestBalanceClient struct {
+	selfTestPriceListClient
+	balance int64
+}
+
+func (c selfTestBalanceClient) Balance(context.Context) (int64, error) { return c.balance, c.err }
+
+func TestRunProviderSelfTestPicksSafeCall(t *testing.T) {
+	res := RunProviderSelfTest(context.Background(), selfTestBalanceClient{balance: 250000})
+	if res.Check != "balance" || !res.Reachable || !res.Authenticated || res.Balance == nil || *res.Balance != 250000 {
+		t.Fatalf("balance client: %+v", res)
+	}
+
+	res = RunProviderSelfTest(context.Background(), selfTestPriceListClient{products: make([]ProviderProduct, 3)})
+	if res.Check != "price_list" || !res.Authenticated || res.Products == nil || *res.Products != 3 {
+		t.Fatalf("price list client: %+v", res)
+	}
+}
+
+func TestRunProviderSelfTestClassifiesFailures(t *testing.T) {
+	refused := selfTestBalanceClient{selfTestPriceListClient: selfTestPriceListClient{err: errors.New("alterra balance: invalid signature")}}
+	res := RunProviderSelfTest(context.Background(), refused)
+	if !res.Reachable || res.Authenticated || res.Error == "" {
+		t.Fatalf("refused credentials: %+v, want reachable and not authenticated", res)
+	}
+
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	down := selfTestPriceListClient{err: dialErr}
+	res = RunProviderSelfTest(context.Background(), down)
+	if res.Reachable || res.Authenticated {
+		t.Fatalf("unreachable provider: %+v, want not reachable", res)
+	}
+}
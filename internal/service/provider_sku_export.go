@@ -0,0 +1,65 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+// providerSKUExportHeader is the header row of a provider SKU CSV export.
+var providerSKUExportHeader = []string{
+	"sku_code", "provider_sku_code", "provider_product_name",
+	"price", "admin", "commission", "commission_percent",
+	"is_active", "is_available",
+}
+
+// ExportProviderSKUs writes providerID's SKU mappings as CSV, one row per
+// mapping by product sku_code, to the writer open returns. open is called
+// once the provider is known to exist, so a missing provider is still
+// reported as ErrAdminNotFound before anything is written; rows are then
+// written as they are read.
+func (s *AdminPPOBService) ExportProviderSKUs(providerID int, open func(provider *models.PPOBProvider) io.Writer) error {
+	provider, err := s.providerRepo.GetProviderByID(providerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAdminNotFound
+		}
+		return fmt.Errorf("get provider: %w", err)
+	}
+
+	w := csv.NewWriter(open(provider))
+	if err := w.Write(providerSKUExportHeader); err != nil {
+		return err
+	}
+	err = s.providerRepo.EachProviderSKU(provider.ID, func(sku models.PPOBProviderSKU) error {
+		return w.Write(providerSKUExportRow(sku))
+	})
+	if err != nil {
+		return fmt.Errorf("export provider skus: %w", err)
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func providerSKUExportRow(sku models.PPOBProviderSKU) []string {
+	percent := ""
+	if sku.CommissionPercent != nil {
+		percent = strconv.FormatFloat(*sku.CommissionPercent, 'f', -1, 64)
+	}
+	return []string{
+		sku.SkuCode,
+		sku.ProviderSKUCode,
+		sku.ProviderProductName,
+		strconv.Itoa(sku.Price),
+		strconv.Itoa(sku.Admin),
+		strconv.Itoa(sku.Commission),
+		percent,
+		strconv.FormatBool(sku.IsActive),
+		strconv.FormatBool(sku.IsAvailable),
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+func TestProviderSKUExportRow(t *testing.T) {
+	t.Parallel()
+
+	percent := 12.5
+	sku := models.PPOBProviderSKU{
+		SkuCode:             "PLN20",
+		ProviderSKUCode:     "pln,20k",
+		ProviderProductName: "PLN 20.000",
+		Price:               20150,
+		Admin:               2500,
+		Commission:          300,
+		CommissionPercent:   &percent,
+		IsActive:            true,
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write(providerSKUExportHeader)
+	_ = w.Write(providerSKUExportRow(sku))
+	w.Flush()
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	want := []string{"PLN20", "pln,20k", "PLN 20.000", "20150", "2500", "300", "12.5", "true", "false"}
+	if len(records) != 2 || !reflect.DeepEqual(records[1], want) {
+		t.Fatalf("records = %q, want header and %q", records, want)
+	}
+	if len(records[0]) != len(want) {
+		t.Fatalf("header has %d columns, row has %d", len(records[0]), len(want))
+	}
+}
+
+func TestProviderSKUExportRowWithoutPercent(t *testing.T) {
+	t.Parallel()
+
+	row := providerSKUExportRow(models.PPOBProviderSKU{SkuCode: "TSEL5", IsAvailable: true})
+	if row[6] != "" || row[8] != "true" {
+		t.Fatalf("row = %q, want empty commission_percent and available", row)
+	}
+}
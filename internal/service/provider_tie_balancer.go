@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/repository"
+)
+
+const (
+	tieBalancerKeyPrefix = "ppob:tie_wrr:"
+	tieBalancerKeyTTL    = 24 * time.Hour
+	tieWeightsRefresh    = time.Minute
+	// tieCounterTimeout bounds the Redis round trip on the routing path; a
+	// slow counter only costs the rotation, never the transaction.
+	tieCounterTimeout = 200 * time.Millisecond
+)
+
+// TieCounter is the shared counter behind TieBalancer (cache.RedisClient).
+type TieCounter interface {
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
+
+// TieBalancer spreads volume across exactly tied providers by smooth weighted
+// round-robin. The position in the rotation is a Redis counter per product and
+// tie set, so all API instances share one sequence.
+type TieBalancer struct {
+	counter      TieCounter
+	providerRepo *repository.PPOBProviderRepository
+
+	mu       sync.Mutex
+	weights  map[int]int // provider ID -> routing weight
+	loadedAt time.Time
+}
+
+// NewTieBalancer constructs a TieBalancer.
+func NewTieBalancer(counter TieCounter, providerRepo *repository.PPOBProviderRepository) *TieBalancer {
+	return &TieBalancer{counter: counter, providerRepo: providerRepo}
+}
+
+// Apply moves the weighted round-robin pick of every tie group to the front of
+// that group. Other providers keep their order so fallback is unchanged. Any
+// Redis or DB error leaves options as they are.
+func (b *TieBalancer) Apply(ctx context.Context, productID int, trxType ProviderTransactionType, options []models.ProviderOption) {
+	same := sameTiePrepaid
+	if trxType != ProviderTrxPrepaid {
+		same = sameTiePostpaid
+	}
+
+	var weights map[int]int
+	for start := 0; start < len(options); {
+		end := start + 1
+		for end < len(options) && same(options[start], options[end]) {
+			end++
+		}
+		if end-start > 1 {
+			if weights == nil {
+				var err error
+				if weights, err = b.providerWeights(); err != nil {
+					log.Debug().Err(err).Msg("Tie balancer: provider weights unavailable, keeping order")
+					return
+				}
+			}
+			group := options[start:end]
+			n, err := b.incr(ctx, tieGroupKey(productID, group))
+			if err != nil {
+				log.Debug().Err(err).Int("product_id", productID).Msg("Tie balancer: counter unavailable, keeping order")
+				return
+			}
+			promote(group, weightedPick(group, weights, n-1))
+		}
+		start = end
+	}
+}
+
+// incr advances the counter of a tie group within tieCounterTimeout.
+func (b *TieBalancer) incr(ctx context.Context, key string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, tieCounterTimeout)
+	defer cancel()
+	return b.counter.Incr(ctx, key, tieBalancerKeyTTL)
+}
+
+// providerWeights returns routing weights, reloading them at most once a minute.
+func (b *TieBalancer) providerWeights() (map[int]int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.weights != nil && time.Since(b.loadedAt) < tieWeightsRefresh {
+		return b.weights, nil
+	}
+	providers, err := b.providerRepo.GetAllProviders(false)
+	if err != nil {
+		return nil, fmt.Errorf("load providers: %w", err)
+	}
+	weights := make(map[int]int, len(providers))
+	for _, p := range providers {
+		weights[p.ID] = p.RoutingWeight()
+	}
+	b.weights, b.loadedAt = weights, time.Now()
+	return weights, nil
+}
+
+func sameTiePrepaid(a, b models.ProviderOption) bool {
+	return a.IsBackup == b.IsBackup && a.Price == b.Price && a.Priority == b.Priority
+}
+
+func sameTiePostpaid(a, b models.ProviderOption) bool {
+	return a.IsBackup == b.IsBackup && a.EffectiveAdmin() == b.EffectiveAdmin() && a.Priority == b.Priority
+}
+
+// tieGroupKey identifies a product's tie set; a changed set starts a new rotation.
+func tieGroupKey(productID int, group []models.ProviderOption) string {
+	ids := make([]string, len(group))
+	for i, o := range group {
+		ids[i] = strconv.Itoa(o.ProviderSKUID)
+	}
+	return tieBalancerKeyPrefix + strconv.Itoa(productID) + ":" + strings.Join(ids, ",")
+}
+
+// weightedPick returns the index in group chosen at step n of a smooth
+// weighted round-robin cycle (unknown providers weigh 1).
+func weightedPick(group []models.ProviderOption, weights map[int]int, n int64) int {
+	w := make([]int, len(group))
+	for i, o := range group {
+		if w[i] = weights[o.ProviderID]; w[i] < 1 {
+			w[i] = 1
+		}
+	}
+	seq := smoothWeightedSequence(w)
+	return seq[int(n%int64(len(seq)))]
+}
+
+// smoothWeightedSequence is one full cycle of nginx-style smooth weighted
+// round-robin: each index appears weights[i] times, interleaved.
+func smoothWeightedSequence(weights []int) []int {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	current := make([]int, len(weights))
+	seq := make([]int, 0, total)
+	for range total {
+		best := 0
+		for i, w := range weights {
+			current[i] += w
+			if current[i] > current[best] {
+				best = i
+			}
+		}
+		current[best] -= total
+		seq = append(seq, best)
+	}
+	return seq
+}
+
+// promote moves group[i] to the front, shifting the ones before it back.
+func promote(group []models.ProviderOption, i int) {
+	if i <= 0 {
+		return
+	}
+	picked := group[i]
+	copy(group[1:i+1], group[:i])
+	group[0] = picked
+}
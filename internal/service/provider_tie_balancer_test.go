@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+type fakeTieCounter struct {
+	n   map[string]int64
+	err error
+}
+
+func (f *fakeTieCounter) Incr(_ context.Context, key string, _ time.Duration) (int64, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.n[key]++
+	return f.n[key], nil
+}
+
+func TestSmoothWeightedSequence(t *testing.T) {
+	t.Parallel()
+
+	seq := smoothWeightedSequence([]int{5, 1, 1})
+	want := []int{0, 0, 1, 0, 2, 0, 0}
+	if len(seq) != len(want) {
+		t.Fatalf("len = %d, want %d", len(seq), len(want))
+	}
+	for i := range want {
+		if seq[i] != want[i] {
+			t.Fatalf("seq = %v, want %v", seq, want)
+		}
+	}
+}
+
+func TestTieBalancerApply(t *testing.T) {
+	t.Parallel()
+
+	b := &TieBalancer{
+		counter:  &fakeTieCounter{n: map[string]int64{}},
+		weights:  map[int]int{1: 2, 2: 1},
+		loadedAt: time.Now(),
+	}
+	base := []models.ProviderOption{
+		{ProviderID: 1, ProviderSKUID: 11, Price: 1000},
+		{ProviderID: 2, ProviderSKUID: 21, Price: 1000},
+		{ProviderID: 3, ProviderSKUID: 31, Price: 1200},
+	}
+
+	firsts := make(map[int]int)
+	for range 3 {
+		options := append([]models.ProviderOption(nil), base...)
+		b.Apply(context.Background(), 7, ProviderTrxPrepaid, options)
+		firsts[options[0].ProviderID]++
+		if options[2].ProviderID != 3 {
+			t.Fatalf("untied provider must keep its position, got %+v", options)
+		}
+	}
+	if firsts[1] != 2 || firsts[2] != 1 {
+		t.Fatalf("want provider 1 first twice and provider 2 once per cycle, got %v", firsts)
+	}
+
+	b.counter = &fakeTieCounter{err: errors.New("redis down")}
+	options := append([]models.ProviderOption(nil), base...)
+	b.Apply(context.Background(), 7, ProviderTrxPrepaid, options)
+	if options[0].ProviderID != 1 || options[1].ProviderID != 2 {
+		t.Fatalf("counter error must keep SQL order, got %+v", options)
+	}
+}
+
+// blockingTieCounter never answers before the caller's context ends.
+type blockingTieCounter struct{}
+
+func (blockingTieCounter) Incr(ctx context.Context, _ string, _ time.Duration) (int64, error) {
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func TestTieBalancerBoundsCounterCall(t *testing.T) {
+	t.Parallel()
+
+	b := &TieBalancer{counter: blockingTieCounter{}, weights: map[int]int{1: 1, 2: 1}, loadedAt: time.Now()}
+	options := []models.ProviderOption{
+		{ProviderID: 1, ProviderSKUID: 11, Price: 1000},
+		{ProviderID: 2, ProviderSKUID: 21, Price: 1000},
+	}
+	start := time.Now()
+	b.Apply(context.Background(), 7, ProviderTrxPrepaid, options)
+	if elapsed := time.Since(start); elapsed > 5*tieCounterTimeout {
+		t.Fatalf("Apply waited %s on a stuck counter", elapsed)
+	}
+	if options[0].ProviderID != 1 {
+		t.Fatalf("timed-out counter must keep SQL order, got %+v", options)
+	}
+}
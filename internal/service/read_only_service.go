@@ -0,0 +1,119 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/repository"
+)
+
+// ReadOnlyStore persists the read-only flag (repository.SystemFlagRepository).
+type ReadOnlyStore interface {
+	Get(name string) (*repository.SystemFlag, error)
+	Set(name string, enabled bool, reason, updatedBy string) (*repository.SystemFlag, error)
+}
+
+// ReadOnlyState is the admin view of global read-only mode.
+type ReadOnlyState struct {
+	Enabled   bool       `json:"enabled"`
+	Forced    bool       `json:"forced"` // READ_ONLY_MODE is set; the admin toggle cannot lift it
+	Reason    string     `json:"reason"`
+	UpdatedBy *string    `json:"updatedBy,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// ReadOnlyRequest toggles read-only mode from admin.
+type ReadOnlyRequest struct {
+	Enabled *bool  `json:"enabled"`
+	Reason  string `json:"reason"`
+}
+
+// ReadOnlyService decides whether the API currently rejects writes. The flag
+// lives in system_flags so every instance follows one admin toggle; each
+// instance re-reads it at most once per refresh interval, so a toggle takes
+// effect everywhere within that interval.
+type ReadOnlyService struct {
+	store   ReadOnlyStore
+	forced  bool
+	refresh time.Duration
+
+	mu       sync.Mutex
+	enabled  bool
+	loadedAt time.Time
+}
+
+// NewReadOnlyService constructs a ReadOnlyService. forced keeps read-only
+// mode on regardless of the stored flag.
+func NewReadOnlyService(store ReadOnlyStore, forced bool, refresh time.Duration) *ReadOnlyService {
+	return &ReadOnlyService{store: store, forced: forced, refresh: refresh}
+}
+
+// ReadOnly reports whether writes are currently rejected. A failed reload
+// keeps the last known value.
+func (s *ReadOnlyService) ReadOnly() bool {
+	if s.forced {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loadedAt.IsZero() && time.Since(s.loadedAt) < s.refresh {
+		return s.enabled
+	}
+	f, err := s.store.Get(repository.SystemFlagReadOnly)
+	if err != nil {
+		log.Warn().Err(err).Bool("read_only", s.enabled).Msg("Read-only flag reload failed, keeping last value")
+	} else {
+		s.enabled = f.Enabled
+	}
+	s.loadedAt = time.Now()
+	return s.enabled
+}
+
+// State returns the stored flag together with whether config forces it.
+func (s *ReadOnlyService) State() (*ReadOnlyState, error) {
+	f, err := s.store.Get(repository.SystemFlagReadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("get read-only flag: %w", err)
+	}
+	return s.state(f), nil
+}
+
+// SetReadOnly stores the flag and applies it to this instance immediately.
+func (s *ReadOnlyService) SetReadOnly(req ReadOnlyRequest, actor string) (*ReadOnlyState, error) {
+	if req.Enabled == nil {
+		return nil, &AdminValidationError{Message: "enabled is required"}
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if *req.Enabled && reason == "" {
+		return nil, &AdminValidationError{Message: "reason is required when enabling read-only mode"}
+	}
+	f, err := s.store.Set(repository.SystemFlagReadOnly, *req.Enabled, reason, actor)
+	if err != nil {
+		return nil, fmt.Errorf("set read-only flag: %w", err)
+	}
+	s.mu.Lock()
+	s.enabled = f.Enabled
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	log.Warn().Bool("read_only", f.Enabled).Str("reason", reason).Str("actor", actor).Msg("Read-only mode toggled")
+	return s.state(f), nil
+}
+
+func (s *ReadOnlyService) state(f *repository.SystemFlag) *ReadOnlyState {
+	st := &ReadOnlyState{
+		Enabled:   s.forced || f.Enabled,
+		Forced:    s.forced,
+		Reason:    f.Reason,
+		UpdatedBy: f.UpdatedBy,
+	}
+	if !f.UpdatedAt.IsZero() {
+		at := f.UpdatedAt
+		st.UpdatedAt = &at
+	}
+	return st
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/repository"
+)
+
+type fakeReadOnlyStore struct {
+	flag  repository.SystemFlag
+	err   error
+	reads int
+}
+
+func (s *fakeReadOnlyStore) Get(name string) (*repository.SystemFlag, error) {
+	s.reads++
+	if s.err != nil {
+		return nil, s.err
+	}
+	f := s.flag
+	return &f, nil
+}
+
+func (s *fakeReadOnlyStore) Set(name string, enabled bool, reason, updatedBy string) (*repository.SystemFlag, error) {
+	s.flag = repository.SystemFlag{Name: name, Enabled: enabled, Reason: reason, UpdatedBy: &updatedBy, UpdatedAt: time.Now()}
+	f := s.flag
+	return &f, nil
+}
+
+func TestReadOnlyService_CachesAndKeepsLastValueOnError(t *testing.T) {
+	store := &fakeReadOnlyStore{flag: repository.SystemFlag{Enabled: true}}
+	svc := NewReadOnlyService(store, false, time.Hour)
+
+	if !svc.ReadOnly() || !svc.ReadOnly() {
+		t.Fatal("expected read-only from stored flag")
+	}
+	if store.reads != 1 {
+		t.Fatalf("expected one store read within refresh interval, got %d", store.reads)
+	}
+
+	svc.refresh = 0
+	store.err = errors.New("db down")
+	if !svc.ReadOnly() {
+		t.Fatal("expected last known value to be kept on reload error")
+	}
+}
+
+func TestReadOnlyService_SetAppliesImmediately(t *testing.T) {
+	store := &fakeReadOnlyStore{}
+	svc := NewReadOnlyService(store, false, time.Hour)
+	if svc.ReadOnly() {
+		t.Fatal("expected writable by default")
+	}
+
+	enabled := true
+	if _, err := svc.SetReadOnly(ReadOnlyRequest{Enabled: &enabled}, "ops@gtd.co.id"); err == nil {
+		t.Fatal("expected reason to be required when enabling")
+	}
+	state, err := svc.SetReadOnly(ReadOnlyRequest{Enabled: &enabled, Reason: "DB failover"}, "ops@gtd.co.id")
+	if err != nil {
+		t.Fatalf("SetReadOnly: %v", err)
+	}
+	if !state.Enabled || state.Forced || state.Reason != "DB failover" {
+		t.Fatalf("unexpected state %+v", state)
+	}
+	if !svc.ReadOnly() {
+		t.Fatal("expected toggle to apply without waiting for refresh")
+	}
+}
+
+func TestReadOnlyService_ForcedByConfig(t *testing.T) {
+	store := &fakeReadOnlyStore{}
+	svc := NewReadOnlyService(store, true, time.Hour)
+	if !svc.ReadOnly() {
+		t.Fatal("expected forced read-only")
+	}
+	state, err := svc.State()
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+	if !state.Enabled || !state.Forced {
+		t.Fatalf("unexpected state %+v", state)
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/utils"
+	"github.com/GTDGit/gtd_api/pkg/digiflazz"
+)
+
+type fakeForcedSKUs map[int]models.SKU
+
+func (f fakeForcedSKUs) GetByID(id int) (*models.SKU, error) {
+	sku, ok := f[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &sku, nil
+}
+
+type fakeTrxLogs int
+
+func (n fakeTrxLogs) GetLogsByTransactionID(int) ([]models.TransactionLog, error) {
+	return make([]models.TransactionLog, n), nil
+}
+
+func TestPrepareForcedSKURetry(t *testing.T) {
+	t.Parallel()
+
+	ref := func(s string) *string { return &s }
+	failed := func(mod func(*models.Transaction)) *models.Transaction {
+		reason := "seller down"
+		trx := &models.Transaction{ID: 1, TransactionID: "GRB-1", ProductID: 10, Type: models.TrxTypePrepaid, Status: models.StatusFailed, FailedReason: &reason}
+		if mod != nil {
+			mod(trx)
+		}
+		return trx
+	}
+	cases := []struct {
+		name    string
+		trx     *models.Transaction
+		skuID   int
+		wantErr error
+	}{
+		{"payment", failed(func(t *models.Transaction) { t.Type = models.TrxTypePayment }), 5, utils.ErrTransactionNotRetryable},
+		{"inquiry", failed(func(t *models.Transaction) { t.Type = models.TrxTypeInquiry }), 5, utils.ErrTransactionNotRetryable},
+		{"success", failed(func(t *models.Transaction) { t.Status = models.StatusSuccess }), 5, utils.ErrTransactionNotRetryable},
+		{"pending", failed(func(t *models.Transaction) { t.Status = models.StatusPending }), 5, utils.ErrTransactionNotRetryable},
+		{"processing at digiflazz", failed(func(t *models.Transaction) {
+			t.Status, t.DigiRefID = models.StatusProcessing, ref("GRB-1-1")
+		}), 5, utils.ErrTransactionNotRetryable},
+		{"processing at another provider", failed(func(t *models.Transaction) {
+			t.Status, t.ProviderRefID = models.StatusProcessing, ref("KB-77")
+		}), 5, utils.ErrTransactionNotRetryable},
+		{"unknown sku", failed(nil), 99, utils.ErrInvalidSKU},
+		{"sku of another product", failed(nil), 6, utils.ErrInvalidSKU},
+		{"failed", failed(nil), 5, nil},
+		{"processing without a ref", failed(func(t *models.Transaction) { t.Status = models.StatusProcessing }), 5, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &TransactionService{
+				forcedSKUs:    fakeForcedSKUs{5: {ID: 5, ProductID: 10, DigiSkuCode: "xld10"}, 6: {ID: 6, ProductID: 11}},
+				trxLogs:       fakeTrxLogs(2),
+				digiflazzProd: digiflazz.NewClient("user", "key"),
+			}
+			before := *tc.trx
+			sku, suffix, err := s.prepareForcedSKURetry(tc.trx, tc.skuID)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tc.wantErr)
+				}
+				if tc.trx.Status != before.Status {
+					t.Fatalf("rejected retry changed status to %s", tc.trx.Status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if sku.ID != 5 || suffix != 2 {
+				t.Fatalf("sku %d from suffix %d, want sku 5 from suffix 2", sku.ID, suffix)
+			}
+			if tc.trx.Status != models.StatusProcessing || tc.trx.FailedReason != nil {
+				t.Fatalf("trx = %s / %v, want Processing with the failure cleared", tc.trx.Status, tc.trx.FailedReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/pkg/digiflazz"
+)
+
+// SKUDisabler stores a temporary SKU disable (repository.SKURepository).
+type SKUDisabler interface {
+	DisableUntil(id int, until time.Time) error
+}
+
+// SKUAutoDisable takes a Digiflazz seller SKU out of routing for a cooldown
+// once it fails threshold times in a row within window. Counters live in
+// memory per instance; the disable itself is stored on the SKU, so it holds
+// for every instance and lifts by itself when the cooldown ends.
+type SKUAutoDisable struct {
+	store     SKUDisabler
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu   sync.Mutex
+	runs map[int]skuFailureRun
+}
+
+type skuFailureRun struct {
+	count int
+	first time.Time
+}
+
+// NewSKUAutoDisable returns nil when threshold or cooldown is not positive,
+// which leaves automatic disabling off. window <= 0 counts failures in a row
+// regardless of how far apart they are.
+func NewSKUAutoDisable(store SKUDisabler, threshold int, window, cooldown time.Duration) *SKUAutoDisable {
+	if threshold <= 0 || cooldown <= 0 {
+		return nil
+	}
+	return &SKUAutoDisable{
+		store:     store,
+		threshold: threshold,
+		window:    window,
+		cooldown:  cooldown,
+		now:       time.Now,
+		runs:      make(map[int]skuFailureRun),
+	}
+}
+
+// Record counts one attempt outcome of sku and disables it when the run of
+// failures reaches the threshold. It reports whether the SKU was disabled.
+func (d *SKUAutoDisable) Record(sku *models.SKU, failed bool) bool {
+	if d == nil || sku == nil {
+		return false
+	}
+	now := d.now()
+
+	d.mu.Lock()
+	if !failed {
+		delete(d.runs, sku.ID)
+		d.mu.Unlock()
+		return false
+	}
+	run := d.runs[sku.ID]
+	if run.count == 0 || (d.window > 0 && now.Sub(run.first) > d.window) {
+		run = skuFailureRun{first: now}
+	}
+	run.count++
+	if run.count < d.threshold {
+		d.runs[sku.ID] = run
+		d.mu.Unlock()
+		return false
+	}
+	delete(d.runs, sku.ID)
+	d.mu.Unlock()
+
+	until := now.Add(d.cooldown)
+	if err := d.store.DisableUntil(sku.ID, until); err != nil {
+		log.Error().Err(err).Int("sku_id", sku.ID).Str("digi_sku_code", sku.DigiSkuCode).Msg("SKU auto-disable failed")
+		return false
+	}
+	sku.DisabledUntil = &until
+	log.Warn().
+		Int("sku_id", sku.ID).
+		Str("digi_sku_code", sku.DigiSkuCode).
+		Str("seller", sku.SellerName).
+		Int("failures", run.count).
+		Time("disabled_until", until).
+		Msg("SKU auto-disabled after repeated failures")
+	return true
+}
+
+// customerSwitchRCs switch SKU but depend on the customer number, not the
+// seller: 52 prefix mismatch, 59 outside the seller's region.
+var customerSwitchRCs = map[string]bool{"52": true, "59": true}
+
+// skuAttemptOutcome classifies a Digiflazz attempt for SKU auto-disable.
+// Only seller-side failures count; network errors, customer errors (fatal
+// RCs), ref ID collisions and rate limits say nothing about the seller.
+func skuAttemptOutcome(resp *digiflazz.TransactionResponse, err error) (failed, counted bool) {
+	if err != nil || resp == nil {
+		return false, false
+	}
+	switch {
+	case digiflazz.IsSuccess(resp.RC), digiflazz.IsPending(resp.RC):
+		return false, true
+	case digiflazz.IsFatal(resp.RC), digiflazz.NeedsNewRefID(resp.RC), digiflazz.IsRetryableWait(resp.RC), customerSwitchRCs[resp.RC]:
+		return false, false
+	default:
+		return true, true
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/pkg/digiflazz"
+)
+
+type fakeSKUDisabler struct {
+	disabled map[int]time.Time
+}
+
+func (f *fakeSKUDisabler) DisableUntil(id int, until time.Time) error {
+	f.disabled[id] = until
+	return nil
+}
+
+func TestSKUAutoDisable(t *testing.T) {
+	store := &fakeSKUDisabler{disabled: map[int]time.Time{}}
+	d := NewSKUAutoDisable(store, 3, time.Minute, 30*time.Minute)
+	now := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	sku := &models.SKU{ID: 7, DigiSkuCode: "xld10"}
+
+	d.Record(sku, true)
+	d.Record(sku, true)
+	d.Record(sku, false) // a success breaks the run
+	d.Record(sku, true)
+	d.Record(sku, true)
+	if len(store.disabled) != 0 {
+		t.Fatalf("disabled before threshold: %v", store.disabled)
+	}
+
+	now = now.Add(2 * time.Minute) // outside the window: run starts over
+	d.Record(sku, true)
+	d.Record(sku, true)
+	if len(store.disabled) != 0 {
+		t.Fatalf("stale failures counted: %v", store.disabled)
+	}
+	if !d.Record(sku, true) {
+		t.Fatal("third failure in a row within window should disable")
+	}
+	want := now.Add(30 * time.Minute)
+	if !store.disabled[7].Equal(want) || sku.DisabledUntil == nil || !sku.DisabledUntil.Equal(want) {
+		t.Fatalf("disabled until %v / %v, want %v", store.disabled[7], sku.DisabledUntil, want)
+	}
+	if !sku.AutoDisabled(now) || sku.AutoDisabled(want) {
+		t.Fatal("AutoDisabled should hold until the cooldown ends")
+	}
+
+	if NewSKUAutoDisable(store, 0, time.Minute, time.Minute) != nil {
+		t.Fatal("threshold 0 should leave auto-disable off")
+	}
+	var off *SKUAutoDisable
+	if off.Record(sku, true) {
+		t.Fatal("nil auto-disable recorded a failure")
+	}
+}
+
+func TestSKUAttemptOutcome(t *testing.T) {
+	tests := []struct {
+		rc              string
+		err             error
+		failed, counted bool
+	}{
+		{rc: "00", counted: true},
+		{rc: "03", counted: true},
+		{rc: "53", failed: true, counted: true}, // seller product unavailable
+		{rc: "62", failed: true, counted: true}, // seller down
+		{rc: "54"},                              // wrong customer number
+		{rc: "52"},                              // prefix mismatch
+		{rc: "49"},
+		{rc: "85"},
+		{err: errors.New("timeout")},
+	}
+	for _, tc := range tests {
+		var resp *digiflazz.TransactionResponse
+		if tc.err == nil {
+			resp = &digiflazz.TransactionResponse{RC: tc.rc}
+		}
+		failed, counted := skuAttemptOutcome(resp, tc.err)
+		if failed != tc.failed || counted != tc.counted {
+			t.Errorf("rc %q err %v = %v/%v, want %v/%v", tc.rc, tc.err, failed, counted, tc.failed, tc.counted)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+// fakeRCOverrides serves overrides and counts lookups.
+type fakeRCOverrides struct {
+	rows  []models.SKURCOverride
+	err   error
+	calls int
+}
+
+func (f *fakeRCOverrides) ListRCOverridesForSKUs(skuIDs []int) ([]models.SKURCOverride, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	want := make(map[int]bool, len(skuIDs))
+	for _, id := range skuIDs {
+		want[id] = true
+	}
+	var out []models.SKURCOverride
+	for _, o := range f.rows {
+		if want[o.SkuID] {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
+
+// TestSKUAttemptAppliesRCOverride follows the per-attempt decision of
+// tryAllSKUs and tryAllSKUsWithOffset: overrides are loaded once for the
+// transaction, then each answer is classified with its SKU's override.
+func TestSKUAttemptAppliesRCOverride(t *testing.T) {
+	t.Parallel()
+
+	lookup := &fakeRCOverrides{rows: []models.SKURCOverride{
+		{SkuID: 1, RC: "02", Outcome: models.RCOutcomeFatal},
+		{SkuID: 2, RC: "54", Outcome: models.RCOutcomeSwitchSKU},
+		{SkuID: 2, RC: "03", Outcome: models.RCOutcomeFatal},
+		{SkuID: 9, RC: "55", Outcome: models.RCOutcomeFatal},
+	}}
+	s := &TransactionService{rcOverrides: lookup}
+	trx := &models.Transaction{TransactionID: "GRB-20260101-000001"}
+	skus := []models.SKU{{ID: 1, DigiSkuCode: "A"}, {ID: 2, DigiSkuCode: "B"}}
+	overrides := s.loadRCOverrides(trx, skus)
+
+	cases := []struct {
+		name string
+		sku  int
+		rc   string
+		want skuStep
+	}{
+		{"override turns a switch into fatal", 0, "02", skuStepFatal},
+		{"no override for the RC", 0, "54", skuStepFatal},
+		{"override turns fatal into a switch", 1, "54", skuStepSwitchSKU},
+		{"another SKU's override", 1, "02", skuStepSwitchSKU},
+		{"pending is never overridden", 1, "03", skuStepPending},
+		{"success", 1, "00", skuStepSuccess},
+		{"new ref id", 0, "49", skuStepNewRefID},
+		{"rate limited", 0, "85", skuStepWait},
+		{"unknown RC", 0, "xx", skuStepUnknown},
+	}
+	for _, tc := range cases {
+		sku := &skus[tc.sku]
+		if got := classifySKUResponse(tc.rc, overrides.outcome(trx, sku, tc.rc)); got != tc.want {
+			t.Errorf("%s: SKU %d RC %s step = %d, want %d", tc.name, sku.ID, tc.rc, got, tc.want)
+		}
+	}
+	if lookup.calls != 1 {
+		t.Fatalf("override lookups = %d, want 1 per transaction", lookup.calls)
+	}
+}
+
+func TestLoadRCOverridesFallsBackOnError(t *testing.T) {
+	t.Parallel()
+
+	s := &TransactionService{rcOverrides: &fakeRCOverrides{err: errors.New("db down")}}
+	trx := &models.Transaction{TransactionID: "GRB-20260101-000002"}
+	skus := []models.SKU{{ID: 1}}
+	overrides := s.loadRCOverrides(trx, skus)
+	if got := classifySKUResponse("02", overrides.outcome(trx, &skus[0], "02")); got != skuStepSwitchSKU {
+		t.Fatalf("step = %d, want the default switch", got)
+	}
+}
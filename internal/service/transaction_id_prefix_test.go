@@ -0,0 +1,27 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+func TestTransactionIDPrefix(t *testing.T) {
+	t.Parallel()
+
+	own, bad := "WLP", "bad-"
+	svc := &TransactionService{trxIDPrefix: "GTD"}
+
+	if got := svc.transactionIDPrefix(&models.Client{TransactionIDPrefix: &own}); got != "WLP" {
+		t.Fatalf("client override: got %q", got)
+	}
+	if got := svc.transactionIDPrefix(&models.Client{TransactionIDPrefix: &bad}); got != "GTD" {
+		t.Fatalf("invalid client prefix should fall back, got %q", got)
+	}
+	if got := svc.transactionIDPrefix(&models.Client{}); got != "GTD" {
+		t.Fatalf("service default: got %q", got)
+	}
+	if got := (&TransactionService{}).transactionIDPrefix(nil); got != models.DefaultTransactionIDPrefix {
+		t.Fatalf("package default: got %q", got)
+	}
+}
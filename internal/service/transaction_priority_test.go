@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+func TestTransactionPriority(t *testing.T) {
+	p := func(n int) *int { return &n }
+	cases := []struct {
+		tier      int
+		requested *int
+		want      int
+	}{
+		{0, nil, 0},  // default keeps FIFO
+		{0, p(9), 0}, // a request cannot raise its priority
+		{5, nil, 5},  // tier applies by default
+		{5, p(2), 2}, // a request may lower it
+		{5, p(7), 5}, // but not above the tier
+		{12, nil, 9}, // out of range tiers are capped
+		{-1, p(0), 0},
+	}
+	for _, tc := range cases {
+		got := transactionPriority(&models.Client{TransactionPriority: tc.tier}, &CreateTransactionRequest{Priority: tc.requested})
+		if got != tc.want {
+			t.Errorf("tier %d, requested %v: priority = %d, want %d", tc.tier, tc.requested, got, tc.want)
+		}
+	}
+}
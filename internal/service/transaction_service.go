@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -39,6 +40,9 @@ type TransactionService struct {
 	productRepo    *repository.ProductRepository
 	skuRepo        *repository.SKURepository
 	callbackRepo   *repository.CallbackRepository
+	forcedSKUs     forcedSKULookup      // skuRepo; a fake in tests
+	trxLogs        transactionLogReader // callbackRepo; a fake in tests
+	rcOverrides    rcOverrideLookup     // skuRepo; a fake in tests
 	digiflazzProd  *digiflazz.Client
 	digiflazzDev   *digiflazz.Client
 	productSvc     *ProductService
@@ -47,6 +51,26 @@ type TransactionService struct {
 	inquiryCache   *cache.InquiryCache
 	providerRouter *ProviderRouter         // Multi-provider router (optional)
 	notifier       sse.TransactionNotifier // SSE notifier (optional)
+	customerNoSalt string                  // salt for customer_no hashing (opt-in clients)
+	requestTimeout time.Duration           // synchronous attempt budget per CreateTransaction (0 = unbounded)
+	trxIDPrefix    string                  // default transaction ID prefix (GRB when empty)
+	noDigiInquiry  map[string]bool         // lower-cased categories without Digiflazz inquiry fallback
+	reinquire      map[string]bool         // lower-cased categories whose payments re-inquire off a gone provider
+	syncAttempts   int                     // providers tried synchronously per prepaid request (0 = all)
+	exactPaySKU    bool                    // payment skuCode must equal the inquiry's, not just its product
+	requirePrice   bool                    // reject prepaid/payment requests that resolve to no positive price
+	refIDMaxLen    int                     // referenceId length limit (0 = column width)
+	sandboxRouting bool                    // route sandbox through providerRouter (sandbox accounts only)
+
+	// inquiryPolicy bounds multi-provider inquiry per lower-cased category.
+	inquiryPolicy map[string]inquiryCategoryPolicy
+	// skuAutoDisable disables chronically failing Digiflazz SKUs (nil = off).
+	skuAutoDisable *SKUAutoDisable
+	// completion estimates when Processing transactions settle (nil = no ETA).
+	completion *CompletionEstimator
+	// priceRounding is the default rounding of prepaid sell prices; clients
+	// may override it (clients.price_rounding).
+	priceRounding models.PriceRounding
 }
 
 // NewTransactionService constructs a TransactionService.
@@ -66,6 +90,9 @@ func NewTransactionService(
 		productRepo:   productRepo,
 		skuRepo:       skuRepo,
 		callbackRepo:  callbackRepo,
+		forcedSKUs:    skuRepo,
+		trxLogs:       callbackRepo,
+		rcOverrides:   skuRepo,
 		digiflazzProd: digiProd,
 		digiflazzDev:  digiDev,
 		productSvc:    productSvc,
@@ -85,6 +112,214 @@ func (s *TransactionService) SetNotifier(notifier sse.TransactionNotifier) {
 	s.notifier = notifier
 }
 
+// SetCustomerNoHashSalt sets the salt used to hash customer numbers for
+// clients with hash_customer_no enabled.
+func (s *TransactionService) SetCustomerNoHashSalt(salt string) {
+	s.customerNoSalt = salt
+}
+
+// SetRequestTimeout bounds how long CreateTransaction keeps starting new
+// SKU/provider attempts before leaving the transaction Processing, with the
+// remaining ones deferred to ContinueWithRemainingProviders.
+func (s *TransactionService) SetRequestTimeout(d time.Duration) {
+	s.requestTimeout = d
+}
+
+// SetSyncProviderAttempts limits how many providers a prepaid request tries
+// before the remaining ones are handed to the retry worker.
+func (s *TransactionService) SetSyncProviderAttempts(n int) {
+	s.syncAttempts = n
+}
+
+// SetExactPaymentSKU requires a payment's skuCode to equal the skuCode quoted
+// at inquiry instead of only belonging to the same product.
+func (s *TransactionService) SetExactPaymentSKU(exact bool) {
+	s.exactPaySKU = exact
+}
+
+// SetRequirePositivePrice rejects prepaid requests without a positive sell
+// price and payments of inquiries without a positive amount
+// (PRICE_UNAVAILABLE) before any provider is called.
+func (s *TransactionService) SetRequirePositivePrice(require bool) {
+	s.requirePrice = require
+}
+
+// SetReferenceIDMaxLength limits referenceId length; 0 or anything above the
+// reference_id column width uses the column width.
+func (s *TransactionService) SetReferenceIDMaxLength(n int) {
+	s.refIDMaxLen = n
+}
+
+// SetSandboxRouting lets sandbox transactions use the provider router, which
+// then only tries providers with a sandbox account. Off, sandbox stays on the
+// legacy Digiflazz development flow.
+func (s *TransactionService) SetSandboxRouting(enabled bool) {
+	s.sandboxRouting = enabled
+}
+
+// SetSKUAutoDisable enables automatic disabling of repeatedly failing
+// Digiflazz SKUs; nil turns it off.
+func (s *TransactionService) SetSKUAutoDisable(d *SKUAutoDisable) {
+	s.skuAutoDisable = d
+}
+
+// SetCompletionEstimator enables estimatedCompletionAt on Processing
+// transactions.
+func (s *TransactionService) SetCompletionEstimator(e *CompletionEstimator) {
+	s.completion = e
+}
+
+// EstimateCompletion returns the best-effort completion estimate of trx, nil
+// when it is not Processing or estimates are off.
+func (s *TransactionService) EstimateCompletion(trx *models.Transaction) *time.Time {
+	return s.completion.Estimate(trx)
+}
+
+// recordSKUAttempt feeds a production Digiflazz attempt to SKU auto-disable.
+func (s *TransactionService) recordSKUAttempt(isSandbox bool, sku *models.SKU, resp *digiflazz.TransactionResponse, err error) {
+	if isSandbox || s.skuAutoDisable == nil {
+		return
+	}
+	if failed, counted := skuAttemptOutcome(resp, err); counted {
+		s.skuAutoDisable.Record(sku, failed)
+	}
+}
+
+// useRouter reports whether a transaction goes through the provider router.
+func (s *TransactionService) useRouter(isSandbox bool) bool {
+	return s.providerRouter != nil && (!isSandbox || s.sandboxRouting)
+}
+
+// ReferenceIDRule describes the referenceId constraints for error responses.
+func (s *TransactionService) ReferenceIDRule() string {
+	n := s.refIDMaxLen
+	if n <= 0 || n > maxReferenceIDLength {
+		n = maxReferenceIDLength
+	}
+	return fmt.Sprintf("referenceId must be at most %d characters of letters, digits, '-', '_', '.' or ':'", n)
+}
+
+// checkPrice returns ErrPriceUnavailable when the guard is on and price is not
+// positive. Sandbox requests never reach a real provider and are not checked.
+func (s *TransactionService) checkPrice(price *int, isSandbox bool) error {
+	if !s.requirePrice || isSandbox || (price != nil && *price > 0) {
+		return nil
+	}
+	return utils.ErrPriceUnavailable
+}
+
+// ensureSuccessAmount keeps a success from being recorded with a zero amount
+// when the provider reported no price, falling back to the quoted sell price.
+// Without one the amount is left unset (unknown) rather than zero.
+func (s *TransactionService) ensureSuccessAmount(trx *models.Transaction) {
+	if !s.requirePrice || (trx.Amount != nil && *trx.Amount > 0) {
+		return
+	}
+	if trx.SellPrice != nil && *trx.SellPrice > 0 {
+		log.Warn().
+			Str("transaction_id", trx.TransactionID).
+			Msg("Provider success without a positive amount; using the sell price")
+		amount := *trx.SellPrice
+		trx.Amount = &amount
+		return
+	}
+	log.Error().
+		Str("transaction_id", trx.TransactionID).
+		Msg("Provider success without a positive amount or sell price; amount left unset")
+	trx.Amount = nil
+}
+
+// cheapestSKUPrice returns the lowest positive price among active skus, nil
+// when there is none.
+func cheapestSKUPrice(skus []models.SKU) *int {
+	var best *int
+	for i := range skus {
+		if skus[i].IsActive && skus[i].Price > 0 && (best == nil || skus[i].Price < *best) {
+			best = &skus[i].Price
+		}
+	}
+	return best
+}
+
+// paymentSKUMismatch reports whether exact SKU matching rejects paying
+// inquirySKU's bill with reqSKU.
+func (s *TransactionService) paymentSKUMismatch(reqSKU, inquirySKU string) bool {
+	return s.exactPaySKU && reqSKU != inquirySKU
+}
+
+// SetNoDigiflazzInquiryCategories disables the legacy Digiflazz inquiry
+// fallback for the given product categories.
+func (s *TransactionService) SetNoDigiflazzInquiryCategories(categories []string) {
+	s.noDigiInquiry = make(map[string]bool, len(categories))
+	for _, c := range lowerCategories(categories) {
+		s.noDigiInquiry[c] = true
+	}
+}
+
+// lowerCategories trims and lower-cases product categories from config,
+// dropping empty entries.
+func lowerCategories(categories []string) []string {
+	out := make([]string, 0, len(categories))
+	for _, c := range categories {
+		if c = strings.ToLower(strings.TrimSpace(c)); c != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// noDigiflazzInquiryCategories lists the categories set via
+// SetNoDigiflazzInquiryCategories, sorted.
+func (s *TransactionService) noDigiflazzInquiryCategories() []string {
+	categories := make([]string, 0, len(s.noDigiInquiry))
+	for c := range s.noDigiInquiry {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// digiflazzInquiryBlocked reports whether product's category opted out of the
+// Digiflazz inquiry fallback.
+func (s *TransactionService) digiflazzInquiryBlocked(product *models.Product) bool {
+	return s.noDigiInquiry[strings.ToLower(product.Category)]
+}
+
+// SetTransactionIDPrefix sets the default transaction ID prefix.
+func (s *TransactionService) SetTransactionIDPrefix(prefix string) {
+	s.trxIDPrefix = prefix
+}
+
+// SetPriceRounding sets the default rounding of prepaid sell prices and of
+// the postpaid admin fees quoted to clients.
+func (s *TransactionService) SetPriceRounding(r models.PriceRounding) {
+	s.priceRounding = r
+}
+
+// quotedAdmin rounds a provider admin fee for client. Only the client's quote
+// and charge use it; the provider is paid the fee it quoted.
+func (s *TransactionService) quotedAdmin(client *models.Client, admin int) int {
+	return priceRoundingFor(client, s.priceRounding).Round(admin)
+}
+
+// transactionIDPrefix returns the client's own prefix when configured and
+// valid, otherwise the service default.
+func (s *TransactionService) transactionIDPrefix(client *models.Client) string {
+	return transactionIDPrefixFor(client, s.trxIDPrefix)
+}
+
+// transactionIDPrefixFor resolves a client's transaction ID prefix against
+// def (GRB when empty).
+func transactionIDPrefixFor(client *models.Client, def string) string {
+	if client != nil && client.TransactionIDPrefix != nil && models.ValidTransactionIDPrefix(*client.TransactionIDPrefix) {
+		return *client.TransactionIDPrefix
+	}
+	if def != "" {
+		return def
+	}
+	return models.DefaultTransactionIDPrefix
+}
+
 // getDigiflazzClient returns the appropriate Digiflazz client based on sandbox mode.
 func (s *TransactionService) getDigiflazzClient(isSandbox bool) *digiflazz.Client {
 	if isSandbox {
@@ -102,13 +337,42 @@ type CreateTransactionRequest struct {
 	TransactionID string         `json:"transactionId"` // Required for payment
 	Provider      string         `json:"provider"`      // Optional: force specific provider (kiosbank, alterra, digiflazz)
 	Data          map[string]any `json:"data,omitempty"`
+	// ForceFresh (inquiry only) skips the cached inquiry for the same
+	// customerNo/skuCode/referenceId and asks the provider again. The new
+	// inquiry gets a new transactionId and may quote a different amount; the
+	// cached one is discarded and can no longer be paid.
+	ForceFresh bool `json:"forceFresh,omitempty"`
+	// Priority (prepaid and payment) lowers the transaction's worker priority
+	// below the client's tier (clients.transaction_priority); it never raises
+	// it. Omitted uses the tier.
+	Priority *int `json:"priority,omitempty" binding:"omitempty,min=0,max=9"`
+}
+
+// transactionPriority is the worker priority of a transaction created by req:
+// the client's tier, or the lower priority the request asks for.
+func transactionPriority(client *models.Client, req *CreateTransactionRequest) int {
+	tier := client.TransactionPriority
+	if tier < 0 {
+		tier = 0
+	}
+	if tier > models.MaxTransactionPriority {
+		tier = models.MaxTransactionPriority
+	}
+	if req.Priority != nil && *req.Priority >= 0 && *req.Priority < tier {
+		return *req.Priority
+	}
+	return tier
 }
 
 // CreateTransaction routes processing based on req.Type.
 func (s *TransactionService) CreateTransaction(ctx context.Context, req *CreateTransactionRequest, client *models.Client, isSandbox bool) (*models.Transaction, error) {
+	if err := validateReferenceID(req.ReferenceID, s.refIDMaxLen); err != nil {
+		return nil, err
+	}
+	ctx = withAttemptDeadline(ctx, s.requestTimeout)
 	switch req.Type {
 	case "prepaid":
-		return s.processPrepaid(ctx, req, client, isSandbox)
+		return s.processPrepaid(withAttemptLimit(ctx, s.syncAttempts), req, client, isSandbox)
 	case "inquiry":
 		return s.processInquiry(ctx, req, client, isSandbox)
 	case "payment":
@@ -133,22 +397,38 @@ func (s *TransactionService) processPrepaid(ctx context.Context, req *CreateTran
 	if err != nil || product == nil {
 		return nil, utils.ErrInvalidSKU
 	}
-
-	// 3. Generate transaction ID
-	trxID, err := s.trxRepo.GenerateTransactionID()
-	if err != nil {
+	if err := validateCustomerNo(product, req.CustomerNo); err != nil {
 		return nil, err
 	}
 
-	// 4. Determine sell_price (cheapest provider price = what client sees)
+	// 3. Determine sell_price (cheapest provider price = what client sees)
 	var sellPrice *int
-	if s.providerRouter != nil && !isSandbox {
+	if s.useRouter(isSandbox) {
 		if bestPrice, _, err := s.providerRouter.GetBestPrice(product.ID); err == nil && bestPrice != nil {
 			sellPrice = bestPrice
 		}
 	}
-	if sellPrice == nil && product.MinPrice != nil && *product.MinPrice > 0 {
-		sellPrice = product.MinPrice
+	if sellPrice == nil && s.skuRepo != nil {
+		// Legacy Digiflazz-only products are priced by their SKUs.
+		if skus, err := s.skuRepo.GetByProductID(product.ID); err == nil {
+			sellPrice = cheapestSKUPrice(skus)
+		} else {
+			log.Error().Err(err).Int("product_id", product.ID).Msg("GetByProductID failed")
+		}
+	}
+	if sellPrice != nil {
+		rounded := priceRoundingFor(client, s.priceRounding).Round(*sellPrice)
+		sellPrice = &rounded
+	}
+	if err := s.checkPrice(sellPrice, isSandbox); err != nil {
+		log.Warn().Str("sku_code", product.SkuCode).Msg("Prepaid rejected: no positive sell price")
+		return nil, err
+	}
+
+	// 4. Generate transaction ID
+	trxID, err := s.trxRepo.GenerateTransactionID(s.transactionIDPrefix(client))
+	if err != nil {
+		return nil, err
 	}
 
 	// 5. Create transaction record
@@ -159,10 +439,15 @@ func (s *TransactionService) processPrepaid(ctx context.Context, req *CreateTran
 		ProductID:     product.ID,
 		SkuCode:       product.SkuCode,
 		CustomerNo:    req.CustomerNo,
+		CustomerHash:  s.customerNoHashFor(client, req.CustomerNo),
 		Type:          models.TrxTypePrepaid,
 		Status:        models.StatusProcessing,
 		IsSandbox:     isSandbox,
 		SellPrice:     sellPrice,
+		Priority:      transactionPriority(client, req),
+	}
+	if err := s.checkLikelyDuplicate(client, trx); err != nil {
+		return nil, err
 	}
 
 	if err := s.trxRepo.Create(trx); err != nil {
@@ -178,7 +463,7 @@ func (s *TransactionService) processPrepaid(ctx context.Context, req *CreateTran
 	}
 
 	// 6. Try multi-provider routing if available
-	if s.providerRouter != nil && !isSandbox {
+	if s.useRouter(isSandbox) {
 		var providers []models.ProviderOption
 		var provErr error
 		if req.Provider != "" {
@@ -212,10 +497,17 @@ func (s *TransactionService) tryAllSKUs(ctx context.Context, trx *models.Transac
 	refIDSuffix := refIDSuffixStart
 	networkRetryCount := 0
 	const maxNetworkRetries = 2 // Max retries per SKU on network error
+	overrides := s.loadRCOverrides(trx, skus)
 
 	for i := 0; i < len(skus); i++ {
 		sku := skus[i]
 
+		// Out of synchronous budget: the previous SKU failed, so the retry
+		// worker tries the rest.
+		if i > 0 && attemptDeadlineExceeded(ctx) {
+			return s.deferRemainingSKUs(trx, 0)
+		}
+
 		// Generate Digiflazz ref_id - UNIQUE per SKU attempt
 		digiRefID := trx.TransactionID
 		if refIDSuffix > 0 {
@@ -251,6 +543,7 @@ func (s *TransactionService) tryAllSKUs(ctx context.Context, trx *models.Transac
 			"ref_id":         digiRefID,
 			"testing":        isSandbox,
 		}, resp, err)
+		s.recordSKUAttempt(isSandbox, &sku, resp, err)
 
 		if err != nil {
 			// CRITICAL: Network error - DON'T change ref_id!
@@ -265,13 +558,14 @@ func (s *TransactionService) tryAllSKUs(ctx context.Context, trx *models.Transac
 			networkRetryCount++
 			if networkRetryCount <= maxNetworkRetries {
 				// Wait briefly then retry with SAME ref_id (safe - Digiflazz idempotent)
-				select {
-				case <-ctx.Done():
+				switch err := waitBeforeRetry(ctx, 5*time.Second); {
+				case errors.Is(err, errAttemptDeadline):
+					return s.leaveProcessing(trx)
+				case err != nil:
 					return s.handleAllSKUsFailed(trx)
-				case <-time.After(5 * time.Second):
-					i-- // Retry same SKU
-					continue
 				}
+				i-- // Retry same SKU
+				continue
 			}
 
 			// Max network retries reached for this SKU, move to next SKU with new ref_id
@@ -290,14 +584,14 @@ func (s *TransactionService) tryAllSKUs(ctx context.Context, trx *models.Transac
 		networkRetryCount = 0
 
 		// Check RC
-		switch {
-		case digiflazz.IsSuccess(resp.RC):
+		switch classifySKUResponse(resp.RC, overrides.outcome(trx, &sku, resp.RC)) {
+		case skuStepSuccess:
 			return s.handleSuccess(trx, &sku, resp)
-		case digiflazz.IsPending(resp.RC):
+		case skuStepPending:
 			return s.handlePending(trx, &sku, resp)
-		case digiflazz.IsFatal(resp.RC):
+		case skuStepFatal:
 			return s.handleFatal(trx, resp)
-		case digiflazz.NeedsNewRefID(resp.RC):
+		case skuStepNewRefID:
 			// RC 49: Ref ID sudah terpakai - HARUS ganti ref_id
 			log.Info().
 				Str("transaction_id", trx.TransactionID).
@@ -306,24 +600,26 @@ func (s *TransactionService) tryAllSKUs(ctx context.Context, trx *models.Transac
 			refIDSuffix++
 			i-- // Retry SAME SKU with new ref_id
 			continue
-		case digiflazz.IsRetryableWait(resp.RC):
+		case skuStepWait:
 			// RC 85/86: Need to wait before retrying on SAME SKU
 			log.Info().
 				Str("transaction_id", trx.TransactionID).
 				Str("rc", resp.RC).
 				Str("sku", sku.DigiSkuCode).
-				Msg("Rate limited, waiting 60s before retry on same SKU")
+				Dur("wait", rateLimitWait(resp.RetryAfter)).
+				Msg("Rate limited, waiting before retry on same SKU")
 
-			select {
-			case <-ctx.Done():
+			switch err := waitBeforeRetry(ctx, rateLimitWait(resp.RetryAfter)); {
+			case errors.Is(err, errAttemptDeadline):
+				return s.deferRemainingSKUs(trx, rateLimitWait(resp.RetryAfter))
+			case err != nil:
 				return s.handleAllSKUsFailed(trx)
-			case <-time.After(60 * time.Second):
-				// Retry same SKU - but need new ref_id because this ref_id was "used"
-				refIDSuffix++
-				i-- // Don't advance to next SKU, retry current one
-				continue
 			}
-		case digiflazz.IsRetryableSwitchSKU(resp.RC):
+			// Retry same SKU - but need new ref_id because this ref_id was "used"
+			refIDSuffix++
+			i-- // Don't advance to next SKU, retry current one
+			continue
+		case skuStepSwitchSKU:
 			// Switch to next SKU with new ref_id
 			log.Info().
 				Str("transaction_id", trx.TransactionID).
@@ -346,6 +642,93 @@ func (s *TransactionService) tryAllSKUs(ctx context.Context, trx *models.Transac
 	return s.handleAllSKUsFailed(trx)
 }
 
+// skuStep is what tryAllSKUs does after a Digiflazz answer.
+type skuStep int
+
+const (
+	skuStepUnknown   skuStep = iota // unknown RC: next SKU, new ref_id
+	skuStepSuccess                  // settle as success
+	skuStepPending                  // settle as pending, await the callback
+	skuStepFatal                    // fail, no further SKUs
+	skuStepNewRefID                 // same SKU, new ref_id (RC 49)
+	skuStepWait                     // wait, then same SKU with new ref_id (RC 85/86)
+	skuStepSwitchSKU                // next SKU, new ref_id
+)
+
+// classifySKUResponse maps a Digiflazz RC to the next step. override, a
+// per-SKU RC override outcome, wins over the global classification except
+// for success and pending RCs.
+func classifySKUResponse(rc, override string) skuStep {
+	switch {
+	case digiflazz.IsSuccess(rc):
+		return skuStepSuccess
+	case digiflazz.IsPending(rc):
+		return skuStepPending
+	case override == models.RCOutcomeFatal:
+		return skuStepFatal
+	case override == models.RCOutcomeSwitchSKU:
+		return skuStepSwitchSKU
+	case digiflazz.IsFatal(rc):
+		return skuStepFatal
+	case digiflazz.NeedsNewRefID(rc):
+		return skuStepNewRefID
+	case digiflazz.IsRetryableWait(rc):
+		return skuStepWait
+	case digiflazz.IsRetryableSwitchSKU(rc):
+		return skuStepSwitchSKU
+	default:
+		return skuStepUnknown
+	}
+}
+
+// rcOverrideLookup loads per-SKU RC overrides (repository.SKURepository).
+type rcOverrideLookup interface {
+	ListRCOverridesForSKUs(skuIDs []int) ([]models.SKURCOverride, error)
+}
+
+// skuRCOverrides holds override outcomes by SKU ID and RC.
+type skuRCOverrides map[int]map[string]string
+
+// loadRCOverrides loads the RC overrides of skus in one lookup, for all
+// attempts of trx. On error the default classification applies.
+func (s *TransactionService) loadRCOverrides(trx *models.Transaction, skus []models.SKU) skuRCOverrides {
+	if s.rcOverrides == nil || len(skus) == 0 {
+		return nil
+	}
+	ids := make([]int, len(skus))
+	for i, sku := range skus {
+		ids[i] = sku.ID
+	}
+	rows, err := s.rcOverrides.ListRCOverridesForSKUs(ids)
+	if err != nil {
+		log.Warn().Err(err).Str("transaction_id", trx.TransactionID).Msg("Failed to load SKU RC overrides, using default classification")
+		return nil
+	}
+	overrides := make(skuRCOverrides)
+	for _, o := range rows {
+		if overrides[o.SkuID] == nil {
+			overrides[o.SkuID] = make(map[string]string)
+		}
+		overrides[o.SkuID][o.RC] = o.Outcome
+	}
+	return overrides
+}
+
+// outcome returns sku's override outcome for rc, or "" to use the default
+// classification.
+func (o skuRCOverrides) outcome(trx *models.Transaction, sku *models.SKU, rc string) string {
+	outcome := o[sku.ID][rc]
+	if outcome != "" && !digiflazz.IsSuccess(rc) && !digiflazz.IsPending(rc) {
+		log.Info().
+			Str("transaction_id", trx.TransactionID).
+			Str("rc", rc).
+			Str("sku", sku.DigiSkuCode).
+			Str("outcome", outcome).
+			Msg("Applying per-SKU RC override")
+	}
+	return outcome
+}
+
 // handleSuccess updates trx to success and dispatches callback.
 func (s *TransactionService) handleSuccess(trx *models.Transaction, sku *models.SKU, resp *digiflazz.TransactionResponse) (*models.Transaction, error) {
 	now := time.Now()
@@ -354,21 +737,30 @@ func (s *TransactionService) handleSuccess(trx *models.Transaction, sku *models.
 	if resp.SN != "" {
 		trx.SerialNumber = &resp.SN
 	}
-	trx.Amount = &resp.Price
-	trx.BuyPrice = &resp.Price
+	if resp.Price > 0 {
+		trx.Amount = &resp.Price
+		trx.BuyPrice = &resp.Price
+	}
+	s.ensureSuccessAmount(trx)
 	trx.ProcessedAt = &now
 	if resp.RefID != "" {
 		trx.DigiRefID = &resp.RefID
 	}
+	// A duplicate serial number held for review is saved and answers Pending,
+	// not Success.
+	held := s.callbackSvc.checkDuplicateSerialNumber(trx)
 	if err := s.persistTransactionUpdate(trx); err != nil {
 		return nil, err
 	}
 	if s.notifier != nil {
 		s.notifier.NotifyTransactionStatusChanged(trx)
 	}
+	if held {
+		return trx, nil
+	}
 
 	// Send callback to client asynchronously
-	go s.callbackSvc.SendCallback(trx, "transaction.success")
+	s.callbackSvc.QueueCallback(trx, "transaction.success")
 	return trx, nil
 }
 
@@ -409,10 +801,23 @@ func (s *TransactionService) handleFatal(trx *models.Transaction, resp *digiflaz
 		s.notifier.NotifyTransactionStatusChanged(trx)
 	}
 
-	go s.callbackSvc.SendCallback(trx, "transaction.failed")
+	s.callbackSvc.QueueCallback(trx, "transaction.failed")
 	return trx, nil
 }
 
+// rateLimitWait is how long to wait after a Digiflazz rate-limit RC (85/86):
+// the Retry-After hint when the response carried one, otherwise the minute
+// those limits last, capped at maxProviderRetryAfter.
+func rateLimitWait(retryAfter time.Duration) time.Duration {
+	switch {
+	case retryAfter <= 0:
+		return 60 * time.Second
+	case retryAfter > maxProviderRetryAfter:
+		return maxProviderRetryAfter
+	}
+	return retryAfter
+}
+
 // handleAllSKUsFailed marks transaction as failed when all SKUs have been exhausted.
 // This happens when all available SKUs return retryable errors - since we've already
 // tried all sellers, there's no point in waiting. Mark as failed immediately.
@@ -433,7 +838,51 @@ func (s *TransactionService) handleAllSKUsFailed(trx *models.Transaction) (*mode
 		s.notifier.NotifyTransactionStatusChanged(trx)
 	}
 
-	go s.callbackSvc.SendCallback(trx, "transaction.failed")
+	s.callbackSvc.QueueCallback(trx, "transaction.failed")
+	return trx, nil
+}
+
+// leaveProcessing stops synchronous attempts once the request deadline passes.
+// The transaction keeps its last ref so the status check worker can resolve or
+// retry it asynchronously; the client gets a Processing response.
+func (s *TransactionService) leaveProcessing(trx *models.Transaction) (*models.Transaction, error) {
+	log.Warn().
+		Str("transaction_id", trx.TransactionID).
+		Dur("timeout", s.requestTimeout).
+		Msg("Request deadline reached, leaving transaction Processing for async completion")
+	trx.Status = models.StatusProcessing
+	if err := s.persistTransactionUpdate(trx); err != nil {
+		return nil, err
+	}
+	return trx, nil
+}
+
+// deferRemainingSKUs is deferRemainingProviders for the Digiflazz-only path:
+// it clears the digi_ref_id of the last, failed attempt, which the status
+// check worker would otherwise settle the transaction from, and leaves the
+// untried SKUs to ContinueWithRemainingProviders.
+func (s *TransactionService) deferRemainingSKUs(trx *models.Transaction, delay time.Duration) (*models.Transaction, error) {
+	trx.DigiRefID = nil
+	return s.deferRemainingProviders(trx, delay)
+}
+
+// deferRemainingProviders keeps the transaction Processing once the
+// synchronous provider attempt limit is used up, or the remaining providers
+// are rate limited. next_retry_at, delay from now, marks it due for the retry
+// worker, which tries the remaining providers; the client gets its callback
+// when that run settles the transaction.
+func (s *TransactionService) deferRemainingProviders(trx *models.Transaction, delay time.Duration) (*models.Transaction, error) {
+	log.Info().
+		Str("transaction_id", trx.TransactionID).
+		Int("sync_attempts", s.syncAttempts).
+		Dur("delay", delay).
+		Msg("Remaining providers continue asynchronously")
+	next := time.Now().Add(delay)
+	trx.Status = models.StatusProcessing
+	trx.NextRetryAt = &next
+	if err := s.persistTransactionUpdate(trx); err != nil {
+		return nil, err
+	}
 	return trx, nil
 }
 
@@ -545,10 +994,19 @@ func (s *TransactionService) processInquiry(ctx context.Context, req *CreateTran
 	if err != nil || product == nil {
 		return nil, utils.ErrInvalidSKU
 	}
+	if err := validateCustomerNo(product, req.CustomerNo); err != nil {
+		return nil, err
+	}
 
 	// Check if inquiry already cached (same client, customer, sku, refId)
 	cached, err := s.inquiryCache.GetByCacheKey(ctx, client.ID, req.CustomerNo, req.SkuCode, req.ReferenceID)
-	if err == nil && cached != nil {
+	if err == nil && cached != nil && req.ForceFresh {
+		// Drop the old quote so only the fresh inquiry can be paid.
+		log.Debug().Str("transactionId", cached.TransactionID).Msg("inquiry cache bypassed (forceFresh)")
+		if err := s.inquiryCache.Delete(ctx, cached); err != nil {
+			log.Warn().Err(err).Str("transactionId", cached.TransactionID).Msg("failed to delete inquiry cache")
+		}
+	} else if err == nil && cached != nil {
 		log.Debug().Str("transactionId", cached.TransactionID).Msg("inquiry cache hit")
 		// Return cached inquiry as transaction model
 		return s.cachedInquiryToTransaction(cached, client.ID, product.ID), nil
@@ -557,7 +1015,7 @@ func (s *TransactionService) processInquiry(ctx context.Context, req *CreateTran
 	}
 
 	// Cache miss - generate new transaction ID
-	trxID, err := s.trxRepo.GenerateTransactionID()
+	trxID, err := s.trxRepo.GenerateTransactionID(s.transactionIDPrefix(client))
 	if err != nil {
 		return nil, err
 	}
@@ -567,8 +1025,8 @@ func (s *TransactionService) processInquiry(ctx context.Context, req *CreateTran
 	nowWIB := time.Now().In(wib)
 	eod := time.Date(nowWIB.Year(), nowWIB.Month(), nowWIB.Day(), 23, 59, 59, 0, wib)
 
-	// Try multi-provider inquiry if available and not sandbox
-	if s.providerRouter != nil && !isSandbox {
+	// Try multi-provider inquiry if available
+	if s.useRouter(isSandbox) {
 		var providers []models.ProviderOption
 		var provErr error
 		if req.Provider != "" {
@@ -578,7 +1036,12 @@ func (s *TransactionService) processInquiry(ctx context.Context, req *CreateTran
 			providers, provErr = s.providerRouter.GetProviderOptionsPostpaid(product.ID)
 		}
 		if provErr == nil && len(providers) > 0 {
-			return s.executeInquiryWithProviders(ctx, req, client, product, trxID, providers, eod)
+			return s.executeInquiryWithProviders(ctx, req, client, product, trxID, providers, eod, isSandbox)
+		}
+		if s.digiflazzInquiryBlocked(product) {
+			log.Warn().Int("product_id", product.ID).Str("category", product.Category).
+				Msg("No multi-provider SKUs for inquiry and Digiflazz fallback disabled for category")
+			return nil, utils.ErrInquiryUnavailable
 		}
 		log.Debug().Int("product_id", product.ID).Msg("No multi-provider SKUs for inquiry, using legacy Digiflazz flow")
 	}
@@ -611,7 +1074,10 @@ func (s *TransactionService) processPayment(ctx context.Context, req *CreateTran
 	if inquiryData.Status != "" && inquiryData.Status != string(models.StatusSuccess) {
 		return nil, utils.ErrInvalidTransactionType
 	}
-	// Validate SKU code belongs to same product
+	// Validate SKU code belongs to same product (or is the quoted SKU itself)
+	if s.paymentSKUMismatch(req.SkuCode, inquiryData.SKUCode) {
+		return nil, utils.ErrSkuMismatch
+	}
 	product, err := s.productRepo.GetBySKUCode(req.SkuCode)
 	if err != nil || product == nil || product.ID != inquiryData.ProductID {
 		return nil, utils.ErrSkuMismatch
@@ -619,9 +1085,18 @@ func (s *TransactionService) processPayment(ctx context.Context, req *CreateTran
 	if inquiryData.ExpiredAt.Before(time.Now()) {
 		return nil, utils.ErrInquiryExpired
 	}
+	if err := s.checkPrice(&inquiryData.Amount, isSandbox); err != nil {
+		log.Warn().Str("inquiry_trx_id", inquiryData.TransactionID).Msg("Payment rejected: inquiry has no positive amount")
+		return nil, err
+	}
+	if inquiryData.ProviderCode != "" && s.useRouter(isSandbox) && !s.inquiryProviderUsable(inquiryData) {
+		if inquiryData, err = s.replaceStaleInquiry(ctx, req, client, product, inquiryData, isSandbox); err != nil {
+			return nil, err
+		}
+	}
 
 	// 3. Create payment transaction in database (this one we store!)
-	payTrxID, err := s.trxRepo.GenerateTransactionID()
+	payTrxID, err := s.trxRepo.GenerateTransactionID(s.transactionIDPrefix(client))
 	if err != nil {
 		return nil, err
 	}
@@ -638,10 +1113,16 @@ func (s *TransactionService) processPayment(ctx context.Context, req *CreateTran
 		ProductID:     inquiryData.ProductID,
 		SkuCode:       inquiryData.SKUCode,
 		CustomerNo:    inquiryData.CustomerNo,
+		CustomerHash:  s.customerNoHashFor(client, inquiryData.CustomerNo),
 		Type:          models.TrxTypePayment,
 		Status:        models.StatusProcessing,
 		IsSandbox:     isSandbox,
 		SellPrice:     sellPrice,
+		Priority:      transactionPriority(client, req),
+	}
+	applyInquiryReceipt(payment, inquiryData)
+	if err := s.checkLikelyDuplicate(client, payment); err != nil {
+		return nil, err
 	}
 	if err := s.trxRepo.Create(payment); err != nil {
 		return nil, err
@@ -654,7 +1135,7 @@ func (s *TransactionService) processPayment(ctx context.Context, req *CreateTran
 	// 4. Route payment to the correct provider
 	// If inquiry was handled by a multi-provider (ProviderCode is set), use that same provider.
 	// Otherwise, fall back to legacy Digiflazz flow.
-	if inquiryData.ProviderCode != "" && s.providerRouter != nil && !isSandbox {
+	if inquiryData.ProviderCode != "" && s.useRouter(isSandbox) {
 		log.Info().
 			Str("provider", inquiryData.ProviderCode).
 			Str("inquiry_trx_id", inquiryData.TransactionID).
@@ -709,7 +1190,7 @@ func (s *TransactionService) processPayment(ctx context.Context, req *CreateTran
 		if s.notifier != nil {
 			s.notifier.NotifyTransactionStatusChanged(payment)
 		}
-		go s.callbackSvc.SendCallback(payment, "transaction.success")
+		s.callbackSvc.QueueCallback(payment, "transaction.success")
 		return payment, nil
 	}
 
@@ -739,7 +1220,7 @@ func (s *TransactionService) processPayment(ctx context.Context, req *CreateTran
 	if s.notifier != nil {
 		s.notifier.NotifyTransactionStatusChanged(payment)
 	}
-	go s.callbackSvc.SendCallback(payment, "transaction.failed")
+	s.callbackSvc.QueueCallback(payment, "transaction.failed")
 	return payment, nil
 }
 
@@ -773,6 +1254,12 @@ func (s *TransactionService) GetTransaction(transactionID string, clientID int)
 	return nil, utils.ErrTransactionNotFound
 }
 
+// ListClientSKUStats returns a client's transaction counts and amounts per
+// product for self-service reporting.
+func (s *TransactionService) ListClientSKUStats(filter repository.ClientSKUStatsFilter) ([]repository.ClientSKUStat, int, error) {
+	return s.trxRepo.ListClientSKUStats(filter)
+}
+
 // RetryTransaction retries a pending/processing transaction.
 // CRITICAL: Must check if there's a pending transaction at any provider first to avoid duplicates.
 func (s *TransactionService) RetryTransaction(ctx context.Context, trx *models.Transaction) (*models.Transaction, error) {
@@ -794,8 +1281,8 @@ func (s *TransactionService) RetryTransaction(ctx context.Context, trx *models.T
 		}
 	}
 
-	// Use provider router for multi-provider transactions (non-sandbox, prepaid)
-	if s.providerRouter != nil && !trx.IsSandbox && trx.Type == "prepaid" {
+	// Use provider router for multi-provider prepaid transactions
+	if s.useRouter(trx.IsSandbox) && trx.Type == "prepaid" {
 		return s.executeWithProviderRouter(ctx, trx, ProviderTrxPrepaid, "", nil)
 	}
 
@@ -840,6 +1327,8 @@ func (s *TransactionService) tryAllSKUsWithOffset(ctx context.Context, trx *mode
 	networkRetryCount := 0
 	const maxNetworkRetries = 2
 
+	overrides := s.loadRCOverrides(trx, skus)
+
 	log.Info().
 		Str("transaction_id", trx.TransactionID).
 		Int("start_suffix", startSuffix).
@@ -848,6 +1337,10 @@ func (s *TransactionService) tryAllSKUsWithOffset(ctx context.Context, trx *mode
 	for i := 0; i < len(skus); i++ {
 		sku := skus[i]
 
+		if i > 0 && attemptDeadlineExceeded(ctx) {
+			return s.deferRemainingSKUs(trx, 0)
+		}
+
 		digiRefID := trx.TransactionID
 		if refIDSuffix > 0 {
 			digiRefID = fmt.Sprintf("%s-%d", trx.TransactionID, refIDSuffix)
@@ -878,18 +1371,20 @@ func (s *TransactionService) tryAllSKUsWithOffset(ctx context.Context, trx *mode
 			"testing":        isSandbox,
 			"is_retry":       true,
 		}, resp, err)
+		s.recordSKUAttempt(isSandbox, &sku, resp, err)
 
 		if err != nil {
 			log.Warn().Err(err).Str("transaction_id", trx.TransactionID).Str("digi_ref_id", digiRefID).Msg("Network error on retry")
 			networkRetryCount++
 			if networkRetryCount <= maxNetworkRetries {
-				select {
-				case <-ctx.Done():
+				switch err := waitBeforeRetry(ctx, 5*time.Second); {
+				case errors.Is(err, errAttemptDeadline):
+					return s.leaveProcessing(trx)
+				case err != nil:
 					return s.handleAllSKUsFailed(trx)
-				case <-time.After(5 * time.Second):
-					i--
-					continue
 				}
+				i--
+				continue
 			}
 			refIDSuffix++
 			networkRetryCount = 0
@@ -898,30 +1393,28 @@ func (s *TransactionService) tryAllSKUsWithOffset(ctx context.Context, trx *mode
 
 		networkRetryCount = 0
 
-		switch {
-		case digiflazz.IsSuccess(resp.RC):
+		switch classifySKUResponse(resp.RC, overrides.outcome(trx, &sku, resp.RC)) {
+		case skuStepSuccess:
 			return s.handleSuccess(trx, &sku, resp)
-		case digiflazz.IsPending(resp.RC):
+		case skuStepPending:
 			return s.handlePending(trx, &sku, resp)
-		case digiflazz.IsFatal(resp.RC):
+		case skuStepFatal:
 			return s.handleFatal(trx, resp)
-		case digiflazz.NeedsNewRefID(resp.RC):
+		case skuStepNewRefID:
 			refIDSuffix++
 			i--
 			continue
-		case digiflazz.IsRetryableWait(resp.RC):
-			select {
-			case <-ctx.Done():
+		case skuStepWait:
+			switch err := waitBeforeRetry(ctx, rateLimitWait(resp.RetryAfter)); {
+			case errors.Is(err, errAttemptDeadline):
+				return s.deferRemainingSKUs(trx, rateLimitWait(resp.RetryAfter))
+			case err != nil:
 				return s.handleAllSKUsFailed(trx)
-			case <-time.After(60 * time.Second):
-				refIDSuffix++
-				i--
-				continue
 			}
-		case digiflazz.IsRetryableSwitchSKU(resp.RC):
 			refIDSuffix++
+			i--
 			continue
-		default:
+		default: // skuStepSwitchSKU, skuStepUnknown
 			refIDSuffix++
 			continue
 		}
@@ -1280,7 +1773,8 @@ func (s *TransactionService) cachedInquiryToTransaction(data *cache.InquiryData,
 		Type:          models.TrxTypeInquiry,
 		Status:        status,
 		Amount:        &amount,
-		Admin:         data.Admin,
+		Admin:         data.ClientAdmin(),
+		Period:        descriptionPeriod(data.Description),
 		CustomerName:  customerName,
 		Description:   models.NullableRawMessage(SanitizePublicProviderDescription(data.Description)),
 		FailedCode:    failedCode,
@@ -1302,6 +1796,47 @@ func (s *TransactionService) cachedInquiryToTransaction(data *cache.InquiryData,
 	}
 }
 
+// applyInquiryReceipt copies the bill details quoted by the inquiry onto its
+// payment transaction. The inquiry cache entry is deleted once the payment
+// goes through, so the payment row has to carry what a receipt needs.
+func applyInquiryReceipt(payment *models.Transaction, inquiry *cache.InquiryData) {
+	if name := strings.TrimSpace(inquiry.CustomerName); name != "" {
+		payment.CustomerName = &name
+	}
+	payment.Admin = inquiry.ClientAdmin()
+	payment.Period = descriptionPeriod(inquiry.Description)
+	if desc := SanitizePublicProviderDescription(inquiry.Description); len(desc) > 0 {
+		payment.Description = models.NullableRawMessage(desc)
+	}
+}
+
+// descriptionPeriod returns the billing period in a provider description:
+// a top-level period/periode (Alterra, Kiosbank) or the periode of each
+// Digiflazz detail line, comma separated.
+func descriptionPeriod(desc json.RawMessage) *string {
+	var payload map[string]any
+	if len(desc) == 0 || json.Unmarshal(desc, &payload) != nil {
+		return nil
+	}
+	period := stringFromKeys(payload, "period", "periode")
+	if period == "" {
+		details, _ := payload["detail"].([]any)
+		periods := make([]string, 0, len(details))
+		for _, d := range details {
+			if line, ok := d.(map[string]any); ok {
+				if p := stringFromKeys(line, "periode", "period"); p != "" {
+					periods = append(periods, p)
+				}
+			}
+		}
+		period = strings.Join(periods, ",")
+	}
+	if period == "" {
+		return nil
+	}
+	return &period
+}
+
 // safeMarshalRaw returns json.RawMessage from a RawMessage or nil if empty.
 func safeMarshalRaw(v json.RawMessage) json.RawMessage {
 	if len(v) == 0 || bytes.Equal(v, []byte("null")) {
@@ -1420,11 +1955,84 @@ func (s *TransactionService) RetryWithNextSKU(ctx context.Context, trx *models.T
 	return result, false, nil // Success or Pending
 }
 
+// RetryWithSKU re-attempts a legacy Digiflazz transaction on one specific SKU,
+// bypassing the tried-SKU exclusion used by RetryWithNextSKU. It is an admin
+// recovery action for when a seller that failed transiently is back.
+func (s *TransactionService) RetryWithSKU(ctx context.Context, trx *models.Transaction, skuID int) (*models.Transaction, error) {
+	sku, refIDSuffixStart, err := s.prepareForcedSKURetry(trx, skuID)
+	if err != nil {
+		return trx, err
+	}
+	return s.tryAllSKUs(ctx, trx, []models.SKU{*sku}, trx.IsSandbox, refIDSuffixStart)
+}
+
+// forcedSKULookup loads SKUs for RetryWithSKU (repository.SKURepository).
+type forcedSKULookup interface {
+	GetByID(id int) (*models.SKU, error)
+}
+
+// transactionLogReader lists a transaction's provider attempts
+// (repository.CallbackRepository).
+type transactionLogReader interface {
+	GetLogsByTransactionID(transactionID int) ([]models.TransactionLog, error)
+}
+
+// prepareForcedSKURetry checks that trx may be re-sent on skuID and, when it
+// may, resets it to Processing and returns the SKU and the ref_id suffix to
+// start from. Only prepaid transactions qualify: a payment re-sent through
+// Topup would be a second purchase. A Processing transaction with a ref at
+// Digiflazz or another provider is left to its callback.
+func (s *TransactionService) prepareForcedSKURetry(trx *models.Transaction, skuID int) (*models.SKU, int, error) {
+	if trx.Type != models.TrxTypePrepaid || trx.Status == models.StatusSuccess || trx.Status == models.StatusPending {
+		return nil, 0, utils.ErrTransactionNotRetryable
+	}
+	if trx.Status == models.StatusProcessing &&
+		((trx.DigiRefID != nil && *trx.DigiRefID != "") || (trx.ProviderRefID != nil && *trx.ProviderRefID != "")) {
+		return nil, 0, utils.ErrTransactionNotRetryable
+	}
+
+	sku, err := s.forcedSKUs.GetByID(skuID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, 0, utils.ErrInvalidSKU
+		}
+		return nil, 0, err
+	}
+	if sku.ProductID != trx.ProductID {
+		return nil, 0, utils.ErrInvalidSKU
+	}
+	if s.getDigiflazzClient(trx.IsSandbox) == nil {
+		return nil, 0, fmt.Errorf("digiflazz client not configured")
+	}
+
+	logs, err := s.trxLogs.GetLogsByTransactionID(trx.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	refIDSuffixStart := len(logs)
+	if refIDSuffixStart == 0 {
+		refIDSuffixStart = 1
+	}
+
+	log.Warn().
+		Str("transaction_id", trx.TransactionID).
+		Int("sku_id", sku.ID).
+		Str("sku_code", sku.DigiSkuCode).
+		Str("previous_status", string(trx.Status)).
+		Msg("Admin override: retrying transaction with forced SKU")
+
+	trx.Status = models.StatusProcessing
+	trx.FailedCode = nil
+	trx.FailedReason = nil
+	trx.ProcessedAt = nil
+	return sku, refIDSuffixStart, nil
+}
+
 // RetryWithNextProvider retries a prepaid multi-provider transaction with the next untried provider.
 // It returns handled=true when this method has fully handled the failure path, either by retrying
 // another provider or finalizing the transaction as failed.
 func (s *TransactionService) RetryWithNextProvider(ctx context.Context, trx *models.Transaction, failedRC string, failedMessage string) (*models.Transaction, bool, error) {
-	if trx == nil || trx.Type != models.TrxTypePrepaid || trx.IsSandbox || s.providerRouter == nil {
+	if trx == nil || trx.Type != models.TrxTypePrepaid || !s.useRouter(trx.IsSandbox) {
 		return trx, false, nil
 	}
 
@@ -1461,6 +2069,65 @@ func (s *TransactionService) RetryWithNextProvider(ctx context.Context, trx *mod
 	return result, true, err
 }
 
+// ContinueWithRemainingProviders tries, without an attempt limit, the
+// providers a prepaid transaction did not reach synchronously. The outcome
+// follows the normal router handling: Success or Failed send the client
+// callback, a provider Pending leaves it to the status check worker. No new
+// attempt starts after budget (0 = unbounded); the rest are deferred again.
+func (s *TransactionService) ContinueWithRemainingProviders(ctx context.Context, trx *models.Transaction, budget time.Duration) (*models.Transaction, error) {
+	ctx = withAttemptDeadline(ctx, budget)
+	if !s.useRouter(trx.IsSandbox) {
+		return s.continueRemainingSKUs(ctx, trx)
+	}
+	excluded, err := s.getTriedProviderSKUs(trx.ID)
+	if err != nil {
+		return trx, err
+	}
+	trx.NextRetryAt = nil
+	return s.executeWithProviderRouter(ctx, trx, ProviderTrxPrepaid, "", excluded)
+}
+
+// continueRemainingSKUs tries the SKUs a Digiflazz-only transaction has not
+// used up yet, with ref_ids after the ones already sent.
+func (s *TransactionService) continueRemainingSKUs(ctx context.Context, trx *models.Transaction) (*models.Transaction, error) {
+	logs, err := s.callbackRepo.GetLogsByTransactionID(trx.ID)
+	if err != nil {
+		return trx, err
+	}
+	skus, err := s.productSvc.GetAvailableSKUs(trx.ProductID)
+	if err != nil {
+		return trx, err
+	}
+	trx.NextRetryAt = nil
+	remaining, suffix := s.remainingSKUs(skus, logs)
+	if len(remaining) == 0 {
+		return s.handleAllSKUsFailed(trx)
+	}
+	return s.tryAllSKUsWithOffset(ctx, trx, remaining, trx.IsSandbox, suffix)
+}
+
+// remainingSKUs returns the skus no attempt in logs used up, and the ref_id
+// suffix to continue with. A SKU that was only rate limited is tried again.
+func (s *TransactionService) remainingSKUs(skus []models.SKU, logs []models.TransactionLog) ([]models.SKU, int) {
+	used := make(map[int]bool)
+	suffix := 0
+	for _, l := range logs {
+		if l.SkuID != nil && (l.RC == nil || !digiflazz.IsRetryableWait(*l.RC)) {
+			used[*l.SkuID] = true
+		}
+		if l.DigiRefID != "" {
+			suffix = max(suffix, s.extractRefIDSuffix(&l.DigiRefID)+1)
+		}
+	}
+	var remaining []models.SKU
+	for _, sku := range skus {
+		if !used[sku.ID] {
+			remaining = append(remaining, sku)
+		}
+	}
+	return remaining, suffix
+}
+
 // executeWithProviderRouter executes a transaction using the multi-provider router.
 func (s *TransactionService) executeWithProviderRouter(ctx context.Context, trx *models.Transaction, trxType ProviderTransactionType, forceProvider string, excludedProviderSKUs map[int]bool) (*models.Transaction, error) {
 	if s.providerRouter == nil {
@@ -1484,6 +2151,22 @@ func (s *TransactionService) executeWithProviderRouter(ctx context.Context, trx
 	if trxType == ProviderTrxInquiry {
 		phase = ProviderFailurePhaseInquiry
 	}
+	// The attempts so far failed, so their provider refs must not reach the
+	// status check worker: the retry worker tries the remaining providers.
+	if errors.Is(err, errAttemptDeadline) || errors.Is(err, errAttemptLimit) {
+		applyAttemptProvider(trx, latestAttemptOption(result))
+		if result != nil && result.Response != nil {
+			applyProviderTrace(trx, result.Response)
+		}
+		return s.deferRemainingProviders(trx, 0)
+	}
+	if errors.Is(err, errProvidersThrottled) {
+		applyAttemptProvider(trx, latestAttemptOption(result))
+		if result.Response != nil {
+			applyProviderTrace(trx, result.Response)
+		}
+		return s.deferRemainingProviders(trx, result.RetryAfter)
+	}
 	if err != nil {
 		log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("Provider router execution failed")
 		var attempts []ProviderAttempt
@@ -1536,6 +2219,7 @@ func (s *TransactionService) handleProviderSuccess(trx *models.Transaction, resp
 	trx.Status = models.StatusSuccess
 	trx.FailedCode = nil
 	trx.FailedReason = nil
+	trx.ProviderError = nil
 	if resp.SerialNumber != "" {
 		trx.SerialNumber = &resp.SerialNumber
 	}
@@ -1543,6 +2227,7 @@ func (s *TransactionService) handleProviderSuccess(trx *models.Transaction, resp
 		trx.Amount = &resp.Amount
 		trx.BuyPrice = &resp.Amount
 	}
+	s.ensureSuccessAmount(trx)
 	if resp.CustomerName != "" {
 		trx.CustomerName = &resp.CustomerName
 	}
@@ -1550,14 +2235,18 @@ func (s *TransactionService) handleProviderSuccess(trx *models.Transaction, resp
 		trx.Description = models.NullableRawMessage(desc)
 	}
 	trx.ProcessedAt = &now
+	held := s.callbackSvc.checkDuplicateSerialNumber(trx)
 	if err := s.persistTransactionUpdate(trx); err != nil {
 		return nil, err
 	}
 	if s.notifier != nil {
 		s.notifier.NotifyTransactionStatusChanged(trx)
 	}
+	if held {
+		return trx, nil
+	}
 
-	go s.callbackSvc.SendCallback(trx, "transaction.success")
+	s.callbackSvc.QueueCallback(trx, "transaction.success")
 	return trx, nil
 }
 
@@ -1604,7 +2293,7 @@ func (s *TransactionService) handleProviderFailedForPhase(trx *models.Transactio
 		s.notifier.NotifyTransactionStatusChanged(trx)
 	}
 
-	go s.callbackSvc.SendCallback(trx, "transaction.failed")
+	s.callbackSvc.QueueCallback(trx, "transaction.failed")
 	return trx, nil
 }
 
@@ -1676,8 +2365,8 @@ func applyAttemptProvider(trx *models.Transaction, opt *models.ProviderOption) {
 	trx.ProviderCode = &providerCode
 	trx.Admin = opt.Admin
 	if opt.Price > 0 && trx.Amount == nil {
-		amount := opt.Price
-		trx.Amount = &amount
+		price := opt.Price
+		trx.Amount = &price
 	}
 }
 
@@ -1699,6 +2388,7 @@ func (s *TransactionService) executeInquiryWithProviders(
 	trxID string,
 	providers []models.ProviderOption,
 	eod time.Time,
+	isSandbox bool,
 ) (*models.Transaction, error) {
 	// Filter providers if user specifies a preferred provider
 	if req.Provider != "" {
@@ -1718,13 +2408,26 @@ func (s *TransactionService) executeInquiryWithProviders(
 	}
 
 	attempts := make([]ProviderAttempt, 0, len(providers))
+	policy := s.inquiryPolicyForProduct(product)
 
 	for _, opt := range providers {
+		if policy.attempts > 0 && len(attempts) >= policy.attempts {
+			log.Warn().
+				Str("category", product.Category).
+				Int("max_attempts", policy.attempts).
+				Str("transaction_id", trxID).
+				Msg("Inquiry provider attempt cap reached, not trying remaining providers")
+			break
+		}
 		adapter := s.providerRouter.GetAdapter(string(opt.ProviderCode))
 		if adapter == nil {
 			log.Warn().Str("provider", string(opt.ProviderCode)).Msg("Provider adapter not found for inquiry")
 			continue
 		}
+		if isSandbox && !clientHasSandbox(adapter) {
+			log.Debug().Str("provider", string(opt.ProviderCode)).Msg("Provider has no sandbox account, skipping sandbox inquiry")
+			continue
+		}
 		if !adapter.IsHealthy() && req.Provider == "" {
 			log.Warn().Str("provider", string(opt.ProviderCode)).Msg("Provider not healthy, skipping inquiry")
 			continue
@@ -1735,7 +2438,7 @@ func (s *TransactionService) executeInquiryWithProviders(
 			SKUCode:    opt.ProviderSKUCode,
 			CustomerNo: req.CustomerNo,
 			Type:       ProviderTrxInquiry,
-			IsSandbox:  false,
+			IsSandbox:  isSandbox,
 			Extra:      cloneAnyMap(req.Data),
 		}
 		if opt.ProviderCode == models.ProviderKiosbank {
@@ -1746,10 +2449,11 @@ func (s *TransactionService) executeInquiryWithProviders(
 			Str("provider", string(opt.ProviderCode)).
 			Str("sku_code", opt.ProviderSKUCode).
 			Str("ref_id", trxID).
+			Dur("timeout", policy.timeout).
 			Msg("Trying inquiry with provider")
 
 		reqSnapshot := cloneProviderRequest(provReq)
-		resp, err := adapter.Inquiry(ctx, provReq)
+		resp, err := s.inquireProvider(ctx, adapter, provReq, policy.timeout)
 		if err != nil {
 			attempts = append(attempts, ProviderAttempt{
 				Provider: providerOptionPtr(opt),
@@ -1797,6 +2501,7 @@ func (s *TransactionService) executeInquiryWithProviders(
 				SKUCode:               req.SkuCode,
 				Amount:                resp.Amount,
 				Admin:                 resp.Admin,
+				QuotedAdmin:           s.quotedAdmin(client, resp.Admin),
 				CustomerName:          resp.CustomerName,
 				Description:           SanitizePublicProviderDescription(resp.Description),
 				ExpiredAt:             expiredAt,
@@ -1902,6 +2607,20 @@ func (s *TransactionService) executeInquiryWithProviders(
 	return s.cachedInquiryToTransaction(inquiryData, client.ID, product.ID), nil
 }
 
+// inquireProvider runs one provider inquiry, bounded by timeout when set.
+func (s *TransactionService) inquireProvider(ctx context.Context, adapter PPOBProviderClient, req *ProviderRequest, timeout time.Duration) (*ProviderResponse, error) {
+	if timeout <= 0 {
+		return adapter.Inquiry(ctx, req)
+	}
+	inqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp, err := adapter.Inquiry(inqCtx, req)
+	if err != nil && errors.Is(inqCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil, fmt.Errorf("inquiry timed out after %s: %w", timeout, err)
+	}
+	return resp, err
+}
+
 // executeInquiryWithDigiflazz runs the legacy Digiflazz inquiry flow.
 func (s *TransactionService) executeInquiryWithDigiflazz(
 	ctx context.Context,
@@ -1953,6 +2672,7 @@ func (s *TransactionService) executeInquiryWithDigiflazz(
 		SKUCode:       req.SkuCode,
 		Amount:        resp.Price,
 		Admin:         resp.Admin,
+		QuotedAdmin:   s.quotedAdmin(client, resp.Admin),
 		CustomerName:  resp.CustomerName,
 		Description:   resp.Desc,
 		ExpiredAt:     eod,
@@ -2013,7 +2733,7 @@ func (s *TransactionService) executePaymentWithProvider(
 	payment.ProviderSKUID = &providerSKUID
 	providerCode := inquiryData.ProviderCode
 	payment.ProviderCode = &providerCode
-	payment.Admin = inquiryData.Admin
+	payment.Admin = inquiryData.ClientAdmin()
 	if inquiryData.Amount > 0 {
 		payment.Amount = &inquiryData.Amount
 	}
@@ -2091,7 +2811,7 @@ func (s *TransactionService) executePaymentWithProvider(
 		if s.notifier != nil {
 			s.notifier.NotifyTransactionStatusChanged(payment)
 		}
-		go s.callbackSvc.SendCallback(payment, "transaction.success")
+		s.callbackSvc.QueueCallback(payment, "transaction.success")
 		return payment, nil
 	}
 
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -63,3 +64,54 @@ func TestCachedInquiryToTransactionIncludesReadModelFields(t *testing.T) {
 		t.Fatalf("CustomerName = %v", trx.CustomerName)
 	}
 }
+
+func TestApplyInquiryReceiptCopiesBillDetails(t *testing.T) {
+	t.Parallel()
+
+	inquiry := &cache.InquiryData{
+		Admin:        2500,
+		CustomerName: " BUDI ",
+		Description:  json.RawMessage(`{"tarif":"R1","detail":[{"periode":"202603","nilai_tagihan":"50000"},{"periode":"202604","nilai_tagihan":"52000"}]}`),
+	}
+	payment := &models.Transaction{Type: models.TrxTypePayment}
+
+	applyInquiryReceipt(payment, inquiry)
+
+	if payment.CustomerName == nil || *payment.CustomerName != "BUDI" {
+		t.Fatalf("CustomerName = %v, want BUDI", payment.CustomerName)
+	}
+	if payment.Admin != 2500 {
+		t.Fatalf("Admin = %d, want 2500", payment.Admin)
+	}
+	if payment.Period == nil || *payment.Period != "202603,202604" {
+		t.Fatalf("Period = %v, want 202603,202604", payment.Period)
+	}
+	if len(payment.Description) == 0 {
+		t.Fatal("Description not copied from inquiry")
+	}
+}
+
+func TestDescriptionPeriod(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		`{"period":"APR 2026","admin":2500}`: "APR 2026",
+		`{"periode":202604}`:                 "202604",
+		`{"detail":[{"periode":"202604"}]}`:  "202604",
+		`{"tarif":"R1"}`:                     "",
+		`not json`:                           "",
+		``:                                   "",
+	}
+	for in, want := range cases {
+		got := descriptionPeriod(json.RawMessage(in))
+		if want == "" {
+			if got != nil {
+				t.Errorf("descriptionPeriod(%s) = %q, want nil", in, *got)
+			}
+			continue
+		}
+		if got == nil || *got != want {
+			t.Errorf("descriptionPeriod(%s) = %v, want %q", in, got, want)
+		}
+	}
+}
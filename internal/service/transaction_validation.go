@@ -0,0 +1,80 @@
+package service
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+// maxReferenceIDLength is the width of transactions.reference_id.
+const maxReferenceIDLength = 50
+
+// referenceIDPattern is the referenceId charset: letters, digits and - _ . :
+// which every provider accepts in the ref ids derived from it and which
+// never needs escaping in logs or URLs.
+var referenceIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]+$`)
+
+// validateReferenceID rejects referenceIds longer than maxLen (capped at the
+// column width) or outside referenceIDPattern.
+func validateReferenceID(referenceID string, maxLen int) error {
+	if maxLen <= 0 || maxLen > maxReferenceIDLength {
+		maxLen = maxReferenceIDLength
+	}
+	if len(referenceID) > maxLen || !referenceIDPattern.MatchString(referenceID) {
+		return utils.ErrInvalidReferenceID
+	}
+	return nil
+}
+
+// customerNoPatterns caches compiled products.customer_no_pattern values by
+// source, so each admin-managed pattern is compiled once, not per request.
+// The set is small (one per product at most), so entries are never evicted.
+var customerNoPatterns sync.Map // string -> compiledPattern
+
+type compiledPattern struct {
+	re  *regexp.Regexp
+	err error
+}
+
+// customerNoPattern returns the compiled pattern, or the compile error of a
+// broken one.
+func customerNoPattern(pattern string) (*regexp.Regexp, error) {
+	if v, ok := customerNoPatterns.Load(pattern); ok {
+		c := v.(compiledPattern)
+		return c.re, c.err
+	}
+	re, err := regexp.Compile(pattern)
+	customerNoPatterns.Store(pattern, compiledPattern{re: re, err: err})
+	return re, err
+}
+
+// validateCustomerNo checks customerNo against the product's admin-managed
+// length and pattern rules before any provider is called.
+func validateCustomerNo(product *models.Product, customerNo string) error {
+	if product == nil {
+		return nil
+	}
+	n := len(customerNo)
+	if product.CustomerNoMinLength != nil && n < *product.CustomerNoMinLength {
+		return utils.ErrInvalidCustomerNo
+	}
+	if product.CustomerNoMaxLength != nil && *product.CustomerNoMaxLength > 0 && n > *product.CustomerNoMaxLength {
+		return utils.ErrInvalidCustomerNo
+	}
+	if product.CustomerNoPattern != nil && *product.CustomerNoPattern != "" {
+		re, err := customerNoPattern(*product.CustomerNoPattern)
+		if err != nil {
+			// A broken rule must not block sales; admin input is validated on save.
+			log.Warn().Err(err).Str("sku_code", product.SkuCode).Msg("invalid customer_no_pattern on product, skipping")
+			return nil
+		}
+		if !re.MatchString(customerNo) {
+			return utils.ErrInvalidCustomerNo
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/utils"
+)
+
+func TestValidateCustomerNo(t *testing.T) {
+	t.Parallel()
+
+	intPtr := func(v int) *int { return &v }
+	strPtr := func(v string) *string { return &v }
+
+	product := &models.Product{
+		SkuCode:             "PLN",
+		CustomerNoMinLength: intPtr(11),
+		CustomerNoMaxLength: intPtr(12),
+		CustomerNoPattern:   strPtr(`^[0-9]+$`),
+	}
+
+	cases := []struct {
+		name       string
+		product    *models.Product
+		customerNo string
+		wantErr    bool
+	}{
+		{"no rules", &models.Product{}, "anything", false},
+		{"nil product", nil, "x", false},
+		{"valid", product, "551600530024", false},
+		{"too short", product, "5516005", true},
+		{"too long", product, "5516005300241", true},
+		{"pattern mismatch", product, "55160053002A", true},
+		{"broken pattern ignored", &models.Product{CustomerNoPattern: strPtr(`([`)}, "123", false},
+	}
+	for _, tc := range cases {
+		err := validateCustomerNo(tc.product, tc.customerNo)
+		if tc.wantErr != errors.Is(err, utils.ErrInvalidCustomerNo) {
+			t.Fatalf("%s: validateCustomerNo() err = %v, wantErr %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidateReferenceID(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("a", 51)
+	cases := []struct {
+		name    string
+		ref     string
+		maxLen  int
+		wantErr bool
+	}{
+		{"plain", "INV-2026.04:001_a", 0, false},
+		{"column width", long[:50], 0, false},
+		{"over column width", long, 0, true},
+		{"max above column width is capped", long, 100, true},
+		{"configured max", "REF-0001", 6, true},
+		{"empty", "", 0, true},
+		{"space", "REF 001", 0, true},
+		{"slash", "REF/001", 0, true},
+		{"unicode", "REF-ü", 0, true},
+		{"newline", "REF-1\nforged", 0, true},
+	}
+	for _, tc := range cases {
+		err := validateReferenceID(tc.ref, tc.maxLen)
+		if tc.wantErr != errors.Is(err, utils.ErrInvalidReferenceID) {
+			t.Fatalf("%s: validateReferenceID() err = %v, wantErr %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestCheckPrice(t *testing.T) {
+	t.Parallel()
+
+	intPtr := func(v int) *int { return &v }
+	guarded := &TransactionService{}
+	guarded.SetRequirePositivePrice(true)
+
+	cases := []struct {
+		name    string
+		svc     *TransactionService
+		price   *int
+		sandbox bool
+		wantErr bool
+	}{
+		{"nil price", guarded, nil, false, true},
+		{"zero price", guarded, intPtr(0), false, true},
+		{"negative price", guarded, intPtr(-100), false, true},
+		{"positive price", guarded, intPtr(5500), false, false},
+		{"sandbox nil price", guarded, nil, true, false},
+		{"guard disabled", &TransactionService{}, nil, false, false},
+	}
+	for _, tc := range cases {
+		err := tc.svc.checkPrice(tc.price, tc.sandbox)
+		if tc.wantErr != errors.Is(err, utils.ErrPriceUnavailable) {
+			t.Errorf("%s: checkPrice err = %v, wantErr %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestEnsureSuccessAmountFallsBackToSellPrice(t *testing.T) {
+	t.Parallel()
+
+	svc := &TransactionService{}
+	svc.SetRequirePositivePrice(true)
+
+	sell := 10500
+	trx := &models.Transaction{TransactionID: "GRB-20260101-000001", SellPrice: &sell}
+	svc.ensureSuccessAmount(trx)
+	if trx.Amount == nil || *trx.Amount != sell {
+		t.Fatalf("amount = %v, want sell price %d", trx.Amount, sell)
+	}
+
+	zero := 0
+	trx = &models.Transaction{TransactionID: "GRB-20260101-000002", Amount: &zero}
+	svc.ensureSuccessAmount(trx)
+	if trx.Amount != nil {
+		t.Fatalf("zero amount without sell price kept as %d, want unset", *trx.Amount)
+	}
+}
+
+func TestCheapestSKUPrice(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		skus []models.SKU
+		want int // 0 = nil
+	}{
+		{"no skus", nil, 0},
+		{"cheapest active", []models.SKU{{Price: 10200, IsActive: true}, {Price: 9900, IsActive: true}, {Price: 9000}}, 9900},
+		{"zero prices skipped", []models.SKU{{Price: 0, IsActive: true}, {Price: 10100, IsActive: true}}, 10100},
+		{"only inactive", []models.SKU{{Price: 9000}}, 0},
+	}
+	for _, tc := range cases {
+		got := cheapestSKUPrice(tc.skus)
+		if (got == nil) != (tc.want == 0) || (got != nil && *got != tc.want) {
+			t.Errorf("%s: cheapestSKUPrice = %v, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCustomerNoPatternCompilesOnce(t *testing.T) {
+	t.Parallel()
+
+	a, err := customerNoPattern(`^62[0-9]{9,12}$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := customerNoPattern(`^62[0-9]{9,12}$`); a != b {
+		t.Fatal("pattern compiled again instead of cached")
+	}
+	if _, err := customerNoPattern(`([`); err == nil {
+		t.Fatal("broken pattern compiled")
+	}
+}
@@ -26,7 +26,8 @@ const (
 // admin SSE hub moves to the Gateway: the API emits events, the Gateway
 // subscribes and forwards them to connected admin clients.
 type RedisPublishNotifier struct {
-	rdb *redis.Client
+	rdb       *redis.Client
+	available func() bool
 }
 
 // NewRedisPublishNotifier creates a notifier that publishes domain events to
@@ -35,6 +36,13 @@ func NewRedisPublishNotifier(rdb *redis.Client) *RedisPublishNotifier {
 	return &RedisPublishNotifier{rdb: rdb}
 }
 
+// SetAvailability skips publishing while available reports false (see
+// cache.RedisClient.Available), so a Redis outage never holds up the
+// operation raising the event.
+func (n *RedisPublishNotifier) SetAvailability(available func() bool) {
+	n.available = available
+}
+
 // NotifyTransactionCreated publishes a transaction.created event.
 func (n *RedisPublishNotifier) NotifyTransactionCreated(trx *models.Transaction) {
 	n.publish(ChannelTransaction, transactionToEvent(EventTransactionCreated, trx))
@@ -59,6 +67,10 @@ func (n *RedisPublishNotifier) NotifyPaymentStatusChanged(p *models.Payment) {
 // Marshal and publish errors are logged and swallowed so event delivery never
 // disrupts the originating business operation.
 func (n *RedisPublishNotifier) publish(channel string, evt any) {
+	if n.available != nil && !n.available() {
+		log.Debug().Str("channel", channel).Msg("Redis unavailable, domain event dropped")
+		return
+	}
 	data, err := json.Marshal(evt)
 	if err != nil {
 		log.Error().Err(err).Str("channel", channel).Msg("failed to marshal domain event for Redis publish")
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locales supported for client-facing messages. English is the default and
+// the language of every message passed to Error.
+const (
+	LocaleEN = "en"
+	LocaleID = "id"
+)
+
+// localeKey is the gin context key holding the request's message locale. It is
+// only set for client (API key) requests, so admin responses stay English.
+const localeKey = "locale"
+
+// messagesID translates client-facing error messages to Indonesian, keyed by
+// error code. Codes whose message carries request-specific detail (e.g.
+// MISSING_FIELD, INVALID_PARAM) are deliberately left out.
+var messagesID = map[string]string{
+	"UNAUTHORIZED":             "API key tidak ada atau tidak valid",
+	"INVALID_TOKEN":            "Tidak terautentikasi",
+	"IP_NOT_ALLOWED":           "Permintaan dari alamat IP yang tidak diizinkan",
+	"RATE_LIMITED":             "Terlalu banyak permintaan, silakan coba lagi nanti",
+	"INTERNAL_ERROR":           "Terjadi kesalahan pada server",
+	"SERVICE_UNAVAILABLE":      "Layanan sedang tidak tersedia",
+	"REQUEST_TIMEOUT":          "Permintaan melebihi batas waktu",
+	"READ_ONLY_MODE":           "Layanan sedang dalam pemeliharaan; perubahan data sementara dinonaktifkan",
+	"INVALID_TYPE":             "Type harus 'prepaid', 'inquiry', atau 'payment'",
+	"DUPLICATE_REFERENCE_ID":   "Reference ID sudah digunakan",
+	"INVALID_SKU":              "Kode SKU tidak ditemukan",
+	"NO_AVAILABLE_SKU":         "Tidak ada SKU yang tersedia untuk produk ini",
+	"TRANSACTION_NOT_FOUND":    "Transaksi tidak ditemukan",
+	"INVALID_TRANSACTION_TYPE": "Transaksi bukan inquiry",
+	"REFERENCE_MISMATCH":       "Reference ID tidak sesuai",
+	"SKU_MISMATCH":             "Kode SKU tidak sesuai",
+	"CUSTOMER_MISMATCH":        "Nomor pelanggan tidak sesuai",
+	"INVALID_CUSTOMER_NO":      "Nomor pelanggan tidak valid untuk produk ini",
+	"INVALID_REFERENCE_ID":     "Reference ID tidak valid",
+	"INQUIRY_EXPIRED":          "Inquiry sudah kedaluwarsa",
+	"INQUIRY_ALREADY_PAID":     "Inquiry sudah dibayar",
+	"INQUIRY_UNAVAILABLE":      "Inquiry untuk produk ini sedang tidak tersedia",
+	"CALLBACK_NOT_FOUND":       "Callback tidak ditemukan",
+	"PRICE_UNAVAILABLE":        "Harga produk ini sedang tidak tersedia",
+	"REINQUIRY_REQUIRED":       "Provider inquiry tidak lagi tersedia, silakan lakukan inquiry ulang",
+	"POSSIBLE_DUPLICATE":       "Transaksi serupa baru saja dibuat dengan referenceId lain",
+
+	// Canonical provider failures (failed transactions).
+	"DUPLICATE_TRANSACTION":         "Transaksi duplikat",
+	"ALREADY_PAID":                  "Tagihan atau transaksi sudah dibayar",
+	"INQUIRY_REQUIRED":              "Inquiry harus dilakukan sebelum pembayaran",
+	"REQUEST_EXPIRED":               "Permintaan transaksi sudah kedaluwarsa",
+	"COOLDOWN_ACTIVE":               "Transaksi serupa sedang dibatasi sementara",
+	"ORDER_CANCELED":                "Transaksi dibatalkan oleh operasional upstream",
+	"INVALID_CUSTOMER":              "Nomor pelanggan atau akun tidak valid",
+	"CUSTOMER_RESTRICTED":           "Pelanggan tidak diizinkan bertransaksi",
+	"INVALID_AMOUNT":                "Nominal transaksi tidak valid",
+	"INQUIRY_NOT_FOUND":             "Inquiry tidak ditemukan atau nominal telah berubah",
+	"BILL_UNAVAILABLE":              "Tagihan tidak tersedia",
+	"LIMIT_EXCEEDED":                "Transaksi melebihi batas yang diizinkan",
+	"PRODUCT_UNAVAILABLE":           "Produk sedang tidak tersedia",
+	"PROVIDER_BALANCE_INSUFFICIENT": "Transaksi tidak dapat diproses saat ini",
+	"PROVIDER_UNAVAILABLE":          "Layanan provider sedang tidak tersedia",
+	"NO_PROVIDER_AVAILABLE":         "Tidak ada provider yang dapat menyelesaikan transaksi",
+	"PROVIDER_TIMEOUT":              "Provider tidak merespons tepat waktu",
+	"UPSTREAM_REQUEST_INVALID":      "Permintaan ke upstream tidak dapat diproses",
+	"UPSTREAM_AUTH_ERROR":           "Autentikasi ke upstream gagal",
+	"GENERAL_PROVIDER_ERROR":        "Transaksi tidak dapat diselesaikan",
+}
+
+// SetLocale stores the message locale for the rest of the request.
+func SetLocale(c *gin.Context, locale string) {
+	c.Set(localeKey, locale)
+}
+
+// Locale returns the message locale SetLocale stored, or "" (English).
+func Locale(c *gin.Context) string {
+	return c.GetString(localeKey)
+}
+
+// NormalizeLocale maps a language tag such as "id-ID" or "EN" to a supported
+// locale, or "" when it is not supported.
+func NormalizeLocale(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	switch tag {
+	case LocaleEN, LocaleID:
+		return tag
+	}
+	return ""
+}
+
+// ResolveLocale picks the message locale of a request: the supported language
+// Accept-Language prefers most, then the client's setting, then English.
+func ResolveLocale(acceptLanguage, clientLocale string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		locale := NormalizeLocale(tag)
+		if locale == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > bestQ {
+			best, bestQ = locale, q
+		}
+	}
+	if best != "" {
+		return best
+	}
+	if locale := NormalizeLocale(clientLocale); locale != "" {
+		return locale
+	}
+	return LocaleEN
+}
+
+// LocalizeMessage returns the message for code in locale, or fallback (the
+// English message) when there is no translation.
+func LocalizeMessage(locale, code, fallback string) string {
+	if locale == LocaleID {
+		if msg, ok := messagesID[code]; ok {
+			return msg
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,39 @@
+package utils
+
+import "testing"
+
+func TestResolveLocale(t *testing.T) {
+	tests := []struct {
+		accept, client, want string
+	}{
+		{"", "", LocaleEN},
+		{"", "id", LocaleID},
+		{"", "fr", LocaleEN},
+		{"id-ID,id;q=0.9,en;q=0.8", "", LocaleID},
+		{"en-US,en;q=0.9,id;q=0.8", "id", LocaleEN},
+		{"fr-FR, id;q=0.5", "en", LocaleID},
+		{"en;q=0.2, ID;q=0.7", "", LocaleID},
+		{"fr, de", "id", LocaleID},
+		{"id;q=0", "", LocaleEN},
+	}
+	for _, tc := range tests {
+		if got := ResolveLocale(tc.accept, tc.client); got != tc.want {
+			t.Errorf("ResolveLocale(%q, %q) = %q, want %q", tc.accept, tc.client, got, tc.want)
+		}
+	}
+}
+
+func TestLocalizeMessage(t *testing.T) {
+	if got := LocalizeMessage(LocaleID, "TRANSACTION_NOT_FOUND", "Transaction not found"); got != "Transaksi tidak ditemukan" {
+		t.Errorf("id message = %q", got)
+	}
+	if got := LocalizeMessage(LocaleEN, "TRANSACTION_NOT_FOUND", "Transaction not found"); got != "Transaction not found" {
+		t.Errorf("en keeps the given message, got %q", got)
+	}
+	if got := LocalizeMessage(LocaleID, "MISSING_FIELD", "transactionId is required for payment"); got != "transactionId is required for payment" {
+		t.Errorf("untranslated code keeps the given message, got %q", got)
+	}
+	if got := LocalizeMessage("", "TRANSACTION_NOT_FOUND", "Transaction not found"); got != "Transaction not found" {
+		t.Errorf("no locale (admin request) keeps the given message, got %q", got)
+	}
+}
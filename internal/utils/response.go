@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -84,6 +85,7 @@ func SuccessWithPagination(c *gin.Context, code int, message string, data interf
 }
 
 // Error writes an error response with provided API error code and message.
+// On client requests the message is localized by errCode (see SetLocale).
 func Error(c *gin.Context, code int, errCode, message string) {
 	c.JSON(code, Response{
 		Success: false,
@@ -91,7 +93,7 @@ func Error(c *gin.Context, code int, errCode, message string) {
 		Message: "Failed",
 		Error: &ErrorInfo{
 			Code:    errCode,
-			Message: message,
+			Message: LocalizeMessage(c.GetString(localeKey), errCode, message),
 		},
 		Meta: Meta{
 			RequestID: getRequestID(c),
@@ -109,7 +111,7 @@ func ErrorWithData(c *gin.Context, code int, message, errCode, errMessage string
 		Data:    data,
 		Error: &ErrorInfo{
 			Code:    errCode,
-			Message: errMessage,
+			Message: LocalizeMessage(c.GetString(localeKey), errCode, errMessage),
 		},
 		Meta: Meta{
 			RequestID: getRequestID(c),
@@ -118,6 +120,66 @@ func ErrorWithData(c *gin.Context, code int, message, errCode, errMessage string
 	})
 }
 
+// SuccessFlat writes the response Success would, with the fields of data
+// beside success/code/message/meta instead of under "data".
+func SuccessFlat(c *gin.Context, code int, message string, data interface{}) {
+	writeFlat(c, code, Response{
+		Success: true,
+		Code:    code,
+		Message: message,
+		Data:    data,
+		Meta: Meta{
+			RequestID: getRequestID(c),
+			Timestamp: NowISO(),
+		},
+	})
+}
+
+// ErrorWithDataFlat writes the response ErrorWithData would, with the fields
+// of data at the top level.
+func ErrorWithDataFlat(c *gin.Context, code int, message, errCode, errMessage string, data interface{}) {
+	writeFlat(c, code, Response{
+		Success: false,
+		Code:    code,
+		Message: "Failed",
+		Data:    data,
+		Error: &ErrorInfo{
+			Code:    errCode,
+			Message: LocalizeMessage(c.GetString(localeKey), errCode, errMessage),
+		},
+		Meta: Meta{
+			RequestID: getRequestID(c),
+			Timestamp: NowISO(),
+		},
+	})
+}
+
+// writeFlat writes resp with the fields of resp.Data lifted to the top level;
+// envelope fields win on a name clash. Data that is not a JSON object stays
+// under "data".
+func writeFlat(c *gin.Context, code int, resp Response) {
+	var fields map[string]json.RawMessage
+	if raw, err := json.Marshal(resp.Data); err != nil || json.Unmarshal(raw, &fields) != nil || fields == nil {
+		c.JSON(code, resp)
+		return
+	}
+	resp.Data = nil
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		c.JSON(code, resp)
+		return
+	}
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		c.JSON(code, resp)
+		return
+	}
+	for name, value := range envelope {
+		fields[name] = value
+	}
+	c.JSON(code, fields)
+}
+
 func getRequestID(c *gin.Context) string {
 	if id := c.GetString("request_id"); id != "" {
 		return id
@@ -11,6 +11,7 @@ import (
 
 // CallbackWorker retries failed callbacks on a fixed interval.
 type CallbackWorker struct {
+    pausable
     callbackService *service.CallbackService
     interval        time.Duration
 }
@@ -33,6 +34,9 @@ func (w *CallbackWorker) Start(ctx context.Context) {
     for {
         select {
         case <-ticker.C:
+            if w.paused() {
+                continue
+            }
             w.run(ctx)
         case <-ctx.Done():
             log.Info().Msg("Callback worker stopped")
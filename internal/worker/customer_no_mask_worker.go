@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/repository"
+)
+
+// CustomerNoMaskWorker masks raw customer numbers of opted-in clients once the
+// retention window has passed. The salted hash stays for dedupe lookups.
+type CustomerNoMaskWorker struct {
+	pausable
+	trxRepo   *repository.TransactionRepository
+	retention time.Duration
+	interval  time.Duration
+	batchSize int
+}
+
+// NewCustomerNoMaskWorker constructs a CustomerNoMaskWorker.
+func NewCustomerNoMaskWorker(trxRepo *repository.TransactionRepository, retention, interval time.Duration, batchSize int) *CustomerNoMaskWorker {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	return &CustomerNoMaskWorker{
+		trxRepo:   trxRepo,
+		retention: retention,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Start begins the periodic masking loop until context is canceled.
+func (w *CustomerNoMaskWorker) Start(ctx context.Context) {
+	log.Info().Dur("interval", w.interval).Dur("retention", w.retention).Msg("Starting customer number mask worker")
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if w.paused() {
+				continue
+			}
+			w.run(ctx)
+		case <-ctx.Done():
+			log.Info().Msg("Customer number mask worker stopped")
+			return
+		}
+	}
+}
+
+func (w *CustomerNoMaskWorker) run(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		n, err := w.trxRepo.MaskHashedCustomerNos(w.retention, w.batchSize)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to mask customer numbers")
+			return
+		}
+		if n > 0 {
+			log.Info().Int64("count", n).Msg("Masked raw customer numbers past retention")
+		}
+		if n < int64(w.batchSize) {
+			return
+		}
+	}
+}
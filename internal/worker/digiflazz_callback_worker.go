@@ -14,6 +14,7 @@ import (
 
 // DigiflazzCallbackWorker processes unprocessed Digiflazz callbacks and reconciles transactions.
 type DigiflazzCallbackWorker struct {
+	pausable
 	callbackRepo *repository.CallbackRepository
 	trxRepo      *repository.TransactionRepository
 	trxSvc       *service.TransactionService
@@ -48,6 +49,9 @@ func (w *DigiflazzCallbackWorker) Start(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
+			if w.paused() {
+				continue
+			}
 			w.run(ctx)
 		case <-ctx.Done():
 			log.Info().Msg("Digiflazz callback worker stopped")
@@ -115,8 +119,8 @@ func (w *DigiflazzCallbackWorker) processCallback(ctx context.Context, cb *model
 		return
 	}
 
-	// Skip if transaction is already in final state
-	if trx.Status == models.StatusSuccess || trx.Status == models.StatusFailed {
+	// Skip if transaction is already in final state (or held for review)
+	if trx.Status == models.StatusSuccess || trx.Status == models.StatusFailed || trx.HeldForReview() {
 		log.Debug().
 			Str("transaction_id", trx.TransactionID).
 			Str("status", string(trx.Status)).
@@ -151,7 +155,7 @@ func (w *DigiflazzCallbackWorker) processCallback(ctx context.Context, cb *model
 		}
 
 		// Send callback to client
-		go w.callbackSvc.SendCallback(trx, "transaction.success")
+		w.callbackSvc.QueueCallback(trx, "transaction.success")
 		log.Info().Str("transaction_id", trx.TransactionID).Msg("Transaction updated to Success from Digiflazz callback")
 
 	case digiflazz.IsFatal(rc):
@@ -168,7 +172,7 @@ func (w *DigiflazzCallbackWorker) processCallback(ctx context.Context, cb *model
 			return
 		}
 
-		go w.callbackSvc.SendCallback(trx, "transaction.failed")
+		w.callbackSvc.QueueCallback(trx, "transaction.failed")
 		log.Info().Str("transaction_id", trx.TransactionID).Str("rc", rc).Msg("Transaction updated to Failed from Digiflazz callback (fatal RC)")
 
 	case digiflazz.IsRetryable(rc):
@@ -224,7 +228,7 @@ func (w *DigiflazzCallbackWorker) processCallback(ctx context.Context, cb *model
 			return
 		}
 
-		go w.callbackSvc.SendCallback(trx, "transaction.failed")
+		w.callbackSvc.QueueCallback(trx, "transaction.failed")
 	}
 
 	// Mark callback as processed
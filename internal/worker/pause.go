@@ -0,0 +1,25 @@
+package worker
+
+// Pauser reports whether global read-only mode is on
+// (service.ReadOnlyService).
+type Pauser interface {
+	ReadOnly() bool
+}
+
+// pausable is embedded by workers that stop writing in read-only mode.
+type pausable struct {
+	pauser Pauser
+}
+
+// SetPauser makes the worker skip its scheduled runs while p reports
+// read-only mode, so no background writes happen during maintenance. Skipped
+// work is picked up by the first run after the mode is lifted. Call it before
+// starting the worker.
+func (w *pausable) SetPauser(p Pauser) {
+	w.pauser = p
+}
+
+// paused reports whether the current run should be skipped.
+func (w *pausable) paused() bool {
+	return w.pauser != nil && w.pauser.ReadOnly()
+}
@@ -11,6 +11,7 @@ import (
 
 // PaymentCallbackWorker retries pending outbound client webhook deliveries.
 type PaymentCallbackWorker struct {
+	pausable
 	callbackSvc *service.PaymentCallbackService
 	interval    time.Duration
 	batchSize   int
@@ -38,6 +39,9 @@ func (w *PaymentCallbackWorker) Start(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
+			if w.paused() {
+				continue
+			}
 			if err := w.callbackSvc.RetryPendingCallbacks(ctx, w.batchSize); err != nil && err != context.Canceled {
 				log.Error().Err(err).Msg("payment callback worker tick failed")
 			}
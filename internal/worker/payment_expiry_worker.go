@@ -12,6 +12,7 @@ import (
 // PaymentExpiryWorker marks Pending payments as Expired once their
 // expired_at is in the past.
 type PaymentExpiryWorker struct {
+	pausable
 	paymentSvc *service.PaymentService
 	interval   time.Duration
 	batchSize  int
@@ -39,6 +40,9 @@ func (w *PaymentExpiryWorker) Start(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
+			if w.paused() {
+				continue
+			}
 			if err := w.paymentSvc.ExpirePendingPayments(ctx, w.batchSize); err != nil && err != context.Canceled {
 				log.Error().Err(err).Msg("payment expiry worker tick failed")
 			}
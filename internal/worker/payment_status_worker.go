@@ -12,6 +12,7 @@ import (
 // PaymentStatusWorker periodically re-inquiries provider state for Pending
 // payments whose updated_at has drifted beyond staleAfter.
 type PaymentStatusWorker struct {
+	pausable
 	paymentSvc *service.PaymentService
 	interval   time.Duration
 	staleAfter time.Duration
@@ -42,6 +43,9 @@ func (w *PaymentStatusWorker) Start(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
+			if w.paused() {
+				continue
+			}
 			if err := w.paymentSvc.ProcessPendingPayments(ctx, w.staleAfter, w.batchSize); err != nil && err != context.Canceled {
 				log.Error().Err(err).Msg("payment status worker tick failed")
 			}
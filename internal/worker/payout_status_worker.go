@@ -12,6 +12,7 @@ import (
 // PayoutStatusWorker periodically reconciles pending payouts with their
 // provider and retries undelivered client callbacks.
 type PayoutStatusWorker struct {
+	pausable
 	payoutService *service.PayoutService
 	interval      time.Duration
 	staleAfter    time.Duration
@@ -53,6 +54,9 @@ func (w *PayoutStatusWorker) Start(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
+			if w.paused() {
+				continue
+			}
 			w.run(ctx)
 		case <-ctx.Done():
 			log.Info().Msg("payout status worker stopped")
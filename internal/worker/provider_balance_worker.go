@@ -0,0 +1,243 @@
+package worker
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+	"github.com/GTDGit/gtd_api/internal/notify"
+	"github.com/GTDGit/gtd_api/internal/repository"
+	"github.com/GTDGit/gtd_api/internal/service"
+)
+
+const balanceHistoryLockPrefix = "ppob:balance_history:provider:"
+
+// balanceHistoryPurgeInterval is how often samples past the retention are
+// deleted; the worker itself ticks more often.
+const balanceHistoryPurgeInterval = time.Hour
+
+// BalanceHistoryLock lets one API instance take each provider's balance
+// history sample (cache.RedisClient).
+type BalanceHistoryLock interface {
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+}
+
+// ProviderBalanceWorker periodically records each provider's deposit balance
+// and alerts when it drops below the provider's configured minBalance.
+type ProviderBalanceWorker struct {
+	pausable
+	providerRepo    *repository.PPOBProviderRepository
+	providerClients map[models.ProviderCode]service.PPOBProviderClient
+	interval        time.Duration
+	low             map[int]bool // provider ID -> currently below threshold
+
+	// historyEvery is the minimum gap between balance history samples of a
+	// provider; 0 disables the history.
+	historyEvery time.Duration
+	lastSample   map[int]time.Time // provider ID -> last history sample
+
+	historyLock      BalanceHistoryLock // nil = this instance samples on its own
+	historyRetention time.Duration      // 0 keeps every sample
+	lastPurge        time.Time
+
+	alerts notify.Notifier // ops channel for low balance alerts; nil only logs
+}
+
+// NewProviderBalanceWorker constructs a ProviderBalanceWorker.
+func NewProviderBalanceWorker(
+	providerRepo *repository.PPOBProviderRepository,
+	providerClients map[models.ProviderCode]service.PPOBProviderClient,
+	interval time.Duration,
+) *ProviderBalanceWorker {
+	return &ProviderBalanceWorker{
+		providerRepo:    providerRepo,
+		providerClients: providerClients,
+		interval:        interval,
+		low:             make(map[int]bool),
+		lastSample:      make(map[int]time.Time),
+	}
+}
+
+// SetHistoryInterval enables balance history sampling, at most one sample per
+// provider every d (rounded up to the check interval); 0 disables it.
+func (w *ProviderBalanceWorker) SetHistoryInterval(d time.Duration) {
+	w.historyEvery = d
+}
+
+// SetHistoryLock shares the sampling schedule across instances: a sample is
+// only taken by the instance that claims the provider's window in lock.
+func (w *ProviderBalanceWorker) SetHistoryLock(lock BalanceHistoryLock) {
+	w.historyLock = lock
+}
+
+// SetHistoryRetention deletes balance history samples older than d, checked
+// every balanceHistoryPurgeInterval; 0 keeps them.
+func (w *ProviderBalanceWorker) SetHistoryRetention(d time.Duration) {
+	w.historyRetention = d
+}
+
+// SetAlertNotifier routes low balance alerts and recoveries to notifier.
+func (w *ProviderBalanceWorker) SetAlertNotifier(n notify.Notifier) {
+	w.alerts = n
+}
+
+// Start begins the periodic balance check loop and listens for context cancellation.
+func (w *ProviderBalanceWorker) Start(ctx context.Context) {
+	log.Info().Dur("interval", w.interval).Msg("Starting provider balance worker")
+
+	if !w.paused() {
+		w.run(ctx)
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if w.paused() {
+				continue
+			}
+			w.run(ctx)
+		case <-ctx.Done():
+			log.Info().Msg("Provider balance worker stopped")
+			return
+		}
+	}
+}
+
+func (w *ProviderBalanceWorker) run(ctx context.Context) {
+	providers, err := w.providerRepo.GetAllProviders(true)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get providers for balance check")
+		return
+	}
+
+	for _, provider := range providers {
+		checker, ok := w.providerClients[provider.Code].(service.ProviderBalanceChecker)
+		if !ok {
+			continue
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		balance, err := checker.Balance(checkCtx)
+		cancel()
+		if err != nil {
+			log.Warn().Err(err).Str("provider", string(provider.Code)).Msg("Failed to fetch provider balance")
+			continue
+		}
+
+		if err := w.providerRepo.RecordProviderBalance(provider.ID, balance); err != nil {
+			log.Error().Err(err).Str("provider", string(provider.Code)).Msg("Failed to record provider balance")
+		}
+		if w.sampleDue(provider.ID, time.Now()) {
+			if !w.claimSample(ctx, provider.ID) {
+				// Another instance took this window's sample.
+				w.lastSample[provider.ID] = time.Now()
+			} else if err := w.providerRepo.RecordProviderBalanceSample(provider.ID, balance); err != nil {
+				log.Error().Err(err).Str("provider", string(provider.Code)).Msg("Failed to record provider balance history")
+			} else {
+				w.lastSample[provider.ID] = time.Now()
+			}
+		}
+		w.evaluate(provider, balance)
+	}
+	w.purgeHistory(time.Now())
+}
+
+// claimSample reports whether this instance takes the provider's history
+// sample for the current window. Without a lock, or when it is unreachable,
+// every instance samples: a duplicate does not change the trend's min, max
+// or last balance, a gap would.
+func (w *ProviderBalanceWorker) claimSample(ctx context.Context, providerID int) bool {
+	if w.historyLock == nil {
+		return true
+	}
+	ttl := w.historyEvery - time.Second // same slack as sampleDue
+	if ttl <= 0 {
+		ttl = w.historyEvery
+	}
+	ok, err := w.historyLock.SetNX(ctx, balanceHistoryLockPrefix+strconv.Itoa(providerID), "1", ttl)
+	if err != nil {
+		log.Warn().Err(err).Int("provider_id", providerID).Msg("Balance history lock unavailable, sampling anyway")
+		return true
+	}
+	return ok
+}
+
+// purgeHistory deletes samples past the retention when the last purge is at
+// least balanceHistoryPurgeInterval old.
+func (w *ProviderBalanceWorker) purgeHistory(now time.Time) {
+	if w.historyRetention <= 0 || now.Sub(w.lastPurge) < balanceHistoryPurgeInterval {
+		return
+	}
+	w.lastPurge = now
+	n, err := w.providerRepo.DeleteProviderBalanceSamplesBefore(now.Add(-w.historyRetention))
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to delete old provider balance history")
+		return
+	}
+	if n > 0 {
+		log.Info().Int64("deleted", n).Msg("Deleted old provider balance history")
+	}
+}
+
+// sampleDue reports whether the provider's balance should go into the history
+// now. A small slack keeps samples on schedule despite ticker jitter.
+func (w *ProviderBalanceWorker) sampleDue(providerID int, now time.Time) bool {
+	if w.historyEvery <= 0 {
+		return false
+	}
+	last, ok := w.lastSample[providerID]
+	return !ok || now.Sub(last) >= w.historyEvery-time.Second
+}
+
+// evaluate alerts once when a provider crosses below its threshold and logs
+// the recovery once it is back above. It reports whether an alert fired.
+func (w *ProviderBalanceWorker) evaluate(provider models.PPOBProvider, balance int64) bool {
+	threshold := provider.MinBalance()
+	below := threshold > 0 && balance < threshold
+	wasLow := w.low[provider.ID]
+	w.low[provider.ID] = below
+
+	switch {
+	case below && !wasLow:
+		log.Error().
+			Str("alert", "provider_low_balance").
+			Str("provider", string(provider.Code)).
+			Int64("balance", balance).
+			Int64("min_balance", threshold).
+			Msg("ALERT: provider deposit balance is below the configured minimum")
+		w.alert(notify.EventProviderLowBalance, notify.SeverityCritical, provider, balance, threshold,
+			"provider deposit balance is below the configured minimum")
+		return true
+	case !below && wasLow:
+		log.Info().
+			Str("provider", string(provider.Code)).
+			Int64("balance", balance).
+			Msg("Provider deposit balance back above minimum")
+		w.alert(notify.EventProviderBalanceRecovered, notify.SeverityInfo, provider, balance, threshold,
+			"provider deposit balance back above minimum")
+	}
+	return false
+}
+
+func (w *ProviderBalanceWorker) alert(t notify.EventType, sev notify.Severity, provider models.PPOBProvider, balance, threshold int64, msg string) {
+	if w.alerts == nil {
+		return
+	}
+	w.alerts.Notify(context.Background(), notify.Event{
+		Type:     t,
+		Severity: sev,
+		Key:      string(provider.Code),
+		Message:  msg,
+		Fields: map[string]any{
+			"provider":    string(provider.Code),
+			"balance":     balance,
+			"min_balance": threshold,
+		},
+	})
+}
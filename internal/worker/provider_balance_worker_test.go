@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GTDGit/gtd_api/internal/models"
+)
+
+func TestProviderBalanceWorkerAlertsOncePerDip(t *testing.T) {
+	t.Parallel()
+
+	w := NewProviderBalanceWorker(nil, nil, 0)
+	provider := models.PPOBProvider{ID: 2, Code: models.ProviderAlterra, Config: json.RawMessage(`{"minBalance":1000000}`)}
+
+	if w.evaluate(provider, 2_000_000) {
+		t.Fatal("balance above threshold should not alert")
+	}
+	if !w.evaluate(provider, 900_000) {
+		t.Fatal("first reading below threshold should alert")
+	}
+	if w.evaluate(provider, 800_000) {
+		t.Fatal("still below threshold should not alert again")
+	}
+	w.evaluate(provider, 1_500_000)
+	if !w.evaluate(provider, 500_000) {
+		t.Fatal("new dip after recovery should alert")
+	}
+
+	if w.evaluate(models.PPOBProvider{ID: 3, Code: models.ProviderKiosbank}, 0) {
+		t.Fatal("provider without minBalance should never alert")
+	}
+}
+
+func TestProviderBalanceWorkerSampleDue(t *testing.T) {
+	t.Parallel()
+
+	w := NewProviderBalanceWorker(nil, nil, 5*time.Minute)
+	now := time.Now()
+	if w.sampleDue(1, now) {
+		t.Fatal("history is disabled until an interval is set")
+	}
+
+	w.SetHistoryInterval(time.Hour)
+	if !w.sampleDue(1, now) {
+		t.Fatal("first reading of a provider should be sampled")
+	}
+	w.lastSample[1] = now
+	if w.sampleDue(1, now.Add(55*time.Minute)) {
+		t.Fatal("reading within the interval should not be sampled")
+	}
+	if !w.sampleDue(1, now.Add(time.Hour-100*time.Millisecond)) {
+		t.Fatal("ticker jitter just short of the interval should still sample")
+	}
+}
+
+// fakeHistoryLock grants each key once until it is cleared.
+type fakeHistoryLock struct {
+	held map[string]bool
+	err  error
+}
+
+func (f *fakeHistoryLock) SetNX(_ context.Context, key, _ string, _ time.Duration) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	if f.held[key] {
+		return false, nil
+	}
+	f.held[key] = true
+	return true, nil
+}
+
+func TestProviderBalanceWorkerClaimSample(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	w := NewProviderBalanceWorker(nil, nil, 5*time.Minute)
+	w.SetHistoryInterval(time.Hour)
+	if !w.claimSample(ctx, 1) {
+		t.Fatal("without a lock every instance samples")
+	}
+
+	lock := &fakeHistoryLock{held: map[string]bool{}}
+	w.SetHistoryLock(lock)
+	if !w.claimSample(ctx, 1) || w.claimSample(ctx, 1) {
+		t.Fatal("only the first instance may take a provider's window")
+	}
+	if !w.claimSample(ctx, 2) {
+		t.Fatal("providers are claimed independently")
+	}
+
+	lock.err = errors.New("redis down")
+	if !w.claimSample(ctx, 1) {
+		t.Fatal("an unreachable lock must not stop the history")
+	}
+}
+
+func TestProviderBalanceWorkerPurgeSchedule(t *testing.T) {
+	t.Parallel()
+
+	// providerRepo is nil: reaching the delete would panic.
+	w := NewProviderBalanceWorker(nil, nil, 5*time.Minute)
+	w.purgeHistory(time.Now())
+
+	w.SetHistoryRetention(90 * 24 * time.Hour)
+	w.lastPurge = time.Now().Add(-balanceHistoryPurgeInterval / 2)
+	w.purgeHistory(time.Now())
+}
@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/service"
+)
+
+// ProviderCallbackRetryWorker re-processes stored provider callbacks whose
+// processing failed, following the service's retry backoff.
+type ProviderCallbackRetryWorker struct {
+	pausable
+	callbackSvc *service.ProviderCallbackService
+	interval    time.Duration
+	batchSize   int
+}
+
+// NewProviderCallbackRetryWorker constructs a ProviderCallbackRetryWorker.
+func NewProviderCallbackRetryWorker(callbackSvc *service.ProviderCallbackService, interval time.Duration, batchSize int) *ProviderCallbackRetryWorker {
+	return &ProviderCallbackRetryWorker{
+		callbackSvc: callbackSvc,
+		interval:    interval,
+		batchSize:   batchSize,
+	}
+}
+
+// Start begins the retry loop and listens for context cancellation.
+func (w *ProviderCallbackRetryWorker) Start(ctx context.Context) {
+	log.Info().
+		Dur("interval", w.interval).
+		Int("batch_size", w.batchSize).
+		Msg("Starting provider callback retry worker")
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if w.paused() {
+				continue
+			}
+			n, err := w.callbackSvc.RetryFailedCallbacks(ctx, w.batchSize)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to retry provider callbacks")
+			} else if n > 0 {
+				log.Info().Int("recovered", n).Msg("Provider callbacks recovered on retry")
+			}
+		case <-ctx.Done():
+			log.Info().Msg("Provider callback retry worker stopped")
+			return
+		}
+	}
+}
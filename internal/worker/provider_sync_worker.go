@@ -3,7 +3,6 @@ package worker
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -15,9 +14,11 @@ import (
 
 // ProviderSyncWorker periodically syncs prices from all PPOB providers.
 type ProviderSyncWorker struct {
+	pausable
 	providerRepo    *repository.PPOBProviderRepository
 	providerClients map[models.ProviderCode]service.PPOBProviderClient
 	interval        time.Duration
+	guard           service.PriceListGuard
 }
 
 // NewProviderSyncWorker constructs a ProviderSyncWorker.
@@ -33,12 +34,20 @@ func NewProviderSyncWorker(
 	}
 }
 
+// SetPriceListGuard keeps syncs from disabling the SKUs missing from a
+// suspiciously shrunk price list.
+func (w *ProviderSyncWorker) SetPriceListGuard(guard service.PriceListGuard) {
+	w.guard = guard
+}
+
 // Start begins the periodic sync loop and listens for context cancellation.
 func (w *ProviderSyncWorker) Start(ctx context.Context) {
 	log.Info().Dur("interval", w.interval).Msg("Starting provider price sync worker")
 
 	// Run immediately on start
-	w.run(ctx)
+	if !w.paused() {
+		w.run(ctx)
+	}
 
 	ticker := time.NewTicker(w.interval)
 	defer ticker.Stop()
@@ -46,6 +55,9 @@ func (w *ProviderSyncWorker) Start(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
+			if w.paused() {
+				continue
+			}
 			w.run(ctx)
 		case <-ctx.Done():
 			log.Info().Msg("Provider sync worker stopped")
@@ -78,98 +90,7 @@ func (w *ProviderSyncWorker) run(ctx context.Context) {
 }
 
 func (w *ProviderSyncWorker) syncProvider(ctx context.Context, provider models.PPOBProvider, client service.PPOBProviderClient) {
-	log.Info().
-		Str("provider", string(provider.Code)).
-		Msg("Syncing prices from provider")
-
-	start := time.Now()
-
-	// Get all SKUs for this provider
-	skus, err := w.providerRepo.GetProviderSKUsByProvider(provider.ID)
-	if err != nil {
-		log.Error().
-			Err(err).
-			Str("provider", string(provider.Code)).
-			Msg("Failed to get provider SKUs")
-		return
-	}
-
-	if len(skus) == 0 {
-		log.Debug().
-			Str("provider", string(provider.Code)).
-			Msg("No SKUs configured for provider")
-		return
-	}
-
-	// Get price list from provider
-	priceList, err := client.GetPriceList(ctx, "")
-	if err != nil {
-		log.Error().
-			Err(err).
-			Str("provider", string(provider.Code)).
-			Msg("Failed to get price list from provider")
-
-		// Mark all SKUs with sync error
-		for _, sku := range skus {
-			_ = w.providerRepo.UpdateProviderSKUSyncError(sku.ID, err.Error())
-		}
-		return
-	}
-
-	// Create a map for quick lookup
-	priceMap := make(map[string]service.ProviderProduct)
-	for _, p := range priceList {
-		priceMap[p.SKUCode] = p
-	}
-
-	// Update each SKU
-	updated := 0
-	unavailable := 0
-	errors := 0
-	preserved := 0
-
-	for _, sku := range skus {
-		product, found := priceMap[sku.ProviderSKUCode]
-		if !found {
-			if shouldPreserveProviderSKUAvailability(sku) {
-				preserved++
-				_ = w.providerRepo.UpdateProviderSKUSyncError(sku.ID, "provider SKU not present in live price list; preserved for UAT alias")
-				continue
-			}
-			// Product not found in provider's list - mark unavailable
-			if err := w.providerRepo.UpdateProviderSKUPrice(sku.ID, sku.Price, syncedAdmin(sku.Admin, nil), false); err != nil {
-				errors++
-				log.Error().
-					Err(err).
-					Int("sku_id", sku.ID).
-					Msg("Failed to update SKU availability")
-			} else {
-				unavailable++
-			}
-			continue
-		}
-
-		// Update price and availability
-		isAvailable := product.IsActive
-		if err := w.providerRepo.UpdateProviderSKUPrice(sku.ID, product.Price, syncedAdmin(sku.Admin, product.Admin), isAvailable); err != nil {
-			errors++
-			log.Error().
-				Err(err).
-				Int("sku_id", sku.ID).
-				Msg("Failed to update SKU price")
-		} else {
-			updated++
-		}
-	}
-
-	log.Info().
-		Str("provider", string(provider.Code)).
-		Int("updated", updated).
-		Int("unavailable", unavailable).
-		Int("preserved", preserved).
-		Int("errors", errors).
-		Dur("duration", time.Since(start)).
-		Msg("Provider sync completed")
+	_, _ = service.SyncProviderPrices(ctx, w.providerRepo, provider, client, w.guard)
 }
 
 // SyncSingleProvider syncs prices for a single provider (can be called on-demand)
@@ -187,15 +108,3 @@ func (w *ProviderSyncWorker) SyncSingleProvider(ctx context.Context, providerCod
 	w.syncProvider(ctx, *provider, client)
 	return nil
 }
-
-func syncedAdmin(existing int, updated *int) *int {
-	if updated != nil {
-		return updated
-	}
-	admin := existing
-	return &admin
-}
-
-func shouldPreserveProviderSKUAvailability(sku models.PPOBProviderSKU) bool {
-	return strings.HasPrefix(strings.TrimSpace(sku.SkuCode), "99")
-}
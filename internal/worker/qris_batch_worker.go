@@ -28,6 +28,7 @@ type qrisSlot struct {
 // idempotent per (date, seq) via a unique DB constraint, so re-attempts after a
 // restart or a missed tick are safe no-ops.
 type QRISBatchWorker struct {
+	pausable
 	batchSvc *service.QRISBatchService
 	loc      *time.Location
 	slots    []qrisSlot
@@ -69,10 +70,15 @@ func (w *QRISBatchWorker) Start(ctx context.Context) {
 	defer ticker.Stop()
 
 	// Run once on startup to catch up any slot already passed today.
-	w.runDue(ctx)
+	if !w.paused() {
+		w.runDue(ctx)
+	}
 	for {
 		select {
 		case <-ticker.C:
+			if w.paused() {
+				continue
+			}
 			w.runDue(ctx)
 		case <-ctx.Done():
 			log.Info().Msg("qris batch worker stopped")
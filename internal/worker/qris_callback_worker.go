@@ -12,6 +12,7 @@ import (
 // QRISCallbackWorker retries pending outbound QRIS client webhook deliveries
 // (merchant.activated, payment.success).
 type QRISCallbackWorker struct {
+	pausable
 	callbackSvc *service.QRISCallbackService
 	interval    time.Duration
 	batchSize   int
@@ -45,6 +46,9 @@ func (w *QRISCallbackWorker) Start(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
+			if w.paused() {
+				continue
+			}
 			if err := w.callbackSvc.RetryDue(ctx, w.batchSize); err != nil && err != context.Canceled {
 				log.Error().Err(err).Msg("qris callback worker tick failed")
 			}
@@ -0,0 +1,123 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/GTDGit/gtd_api/internal/cache"
+	"github.com/GTDGit/gtd_api/internal/notify"
+)
+
+// redisPingTimeout bounds one availability check, so a hung Redis reads as
+// down instead of stalling the monitor.
+const redisPingTimeout = 2 * time.Second
+
+// inquiryFallbackPurgeInterval is how often expired inquiry fallback rows are
+// deleted; the monitor itself ticks much more often.
+const inquiryFallbackPurgeInterval = time.Minute
+
+// InquiryFallbackPurger deletes expired inquiries saved while Redis was down
+// (repository.InquiryFallbackRepository).
+type InquiryFallbackPurger interface {
+	DeleteExpiredInquiries() (int64, error)
+}
+
+// RedisMonitorWorker checks Redis every interval. While a check fails,
+// commands through the client fail fast with cache.ErrRedisUnavailable
+// rather than waiting out connection timeouts; the outage and the recovery
+// are each logged and alerted once.
+type RedisMonitorWorker struct {
+	pausable
+	redis     *cache.RedisClient
+	interval  time.Duration
+	alerts    notify.Notifier // nil only logs
+	degraded  bool            // inquiries fall back to the database meanwhile
+	fallback  InquiryFallbackPurger
+	lastPurge time.Time
+}
+
+// NewRedisMonitorWorker constructs a RedisMonitorWorker; degraded only words
+// the outage log and alert.
+func NewRedisMonitorWorker(redis *cache.RedisClient, interval time.Duration, degraded bool) *RedisMonitorWorker {
+	return &RedisMonitorWorker{redis: redis, interval: interval, degraded: degraded}
+}
+
+// SetAlertNotifier routes Redis outage and recovery alerts to notifier.
+func (w *RedisMonitorWorker) SetAlertNotifier(n notify.Notifier) {
+	w.alerts = n
+}
+
+// SetInquiryFallback makes the monitor delete expired inquiry fallback rows
+// every inquiryFallbackPurgeInterval, instead of each fallback write doing it.
+func (w *RedisMonitorWorker) SetInquiryFallback(p InquiryFallbackPurger) {
+	w.fallback = p
+}
+
+// Start checks Redis until ctx is cancelled. It keeps running in read-only
+// mode: Redis availability matters to reads too. Only the fallback purge,
+// a write, is paused.
+func (w *RedisMonitorWorker) Start(ctx context.Context) {
+	log.Info().Dur("interval", w.interval).Bool("degraded", w.degraded).Msg("Starting Redis monitor")
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.check(ctx)
+			w.purgeFallback(time.Now())
+		case <-ctx.Done():
+			log.Info().Msg("Redis monitor stopped")
+			return
+		}
+	}
+}
+
+func (w *RedisMonitorWorker) check(ctx context.Context) {
+	changed, err := w.redis.CheckAvailability(ctx, redisPingTimeout)
+	if !changed || ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		msg := "Redis is unavailable; postpaid inquiry and payment fail until it is back"
+		if w.degraded {
+			msg = "Redis is unavailable; inquiries fall back to the database until it is back"
+		}
+		log.Error().Err(err).Str("alert", string(notify.EventRedisUnavailable)).Msg("ALERT: " + msg)
+		w.alert(notify.EventRedisUnavailable, notify.SeverityCritical, msg, err)
+		return
+	}
+	log.Info().Msg("Redis is available again")
+	w.alert(notify.EventRedisRecovered, notify.SeverityInfo, "Redis is available again", nil)
+}
+
+// purgeFallback deletes expired inquiry fallback rows when the last purge is
+// at least inquiryFallbackPurgeInterval old.
+func (w *RedisMonitorWorker) purgeFallback(now time.Time) {
+	if w.fallback == nil || w.paused() || now.Sub(w.lastPurge) < inquiryFallbackPurgeInterval {
+		return
+	}
+	w.lastPurge = now
+	n, err := w.fallback.DeleteExpiredInquiries()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to delete expired inquiry fallbacks")
+		return
+	}
+	if n > 0 {
+		log.Info().Int64("deleted", n).Msg("Deleted expired inquiry fallbacks")
+	}
+}
+
+func (w *RedisMonitorWorker) alert(t notify.EventType, sev notify.Severity, msg string, err error) {
+	if w.alerts == nil {
+		return
+	}
+	e := notify.Event{Type: t, Severity: sev, Message: msg, Fields: map[string]any{"degraded": w.degraded}}
+	if err != nil {
+		e.Fields["error"] = err.Error()
+	}
+	w.alerts.Notify(context.Background(), e)
+}
@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+type countingPurger struct{ calls int }
+
+func (p *countingPurger) DeleteExpiredInquiries() (int64, error) {
+	p.calls++
+	return 0, nil
+}
+
+type fixedPauser bool
+
+func (p fixedPauser) ReadOnly() bool { return bool(p) }
+
+func TestRedisMonitorPurgesFallbackOncePerInterval(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		readOnly bool
+		ticks    []time.Duration // since start
+		want     int
+	}{
+		{"first tick purges", false, []time.Duration{0}, 1},
+		{"ticks within the interval skip", false, []time.Duration{0, 5 * time.Second, 30 * time.Second}, 1},
+		{"next interval purges again", false, []time.Duration{0, 30 * time.Second, inquiryFallbackPurgeInterval}, 2},
+		{"paused in read-only mode", true, []time.Duration{0, inquiryFallbackPurgeInterval}, 0},
+	}
+	for _, tc := range cases {
+		purger := &countingPurger{}
+		w := NewRedisMonitorWorker(nil, 5*time.Second, true)
+		w.SetInquiryFallback(purger)
+		w.SetPauser(fixedPauser(tc.readOnly))
+		for _, d := range tc.ticks {
+			w.purgeFallback(start.Add(d))
+		}
+		if purger.calls != tc.want {
+			t.Errorf("%s: purged %d times, want %d", tc.name, purger.calls, tc.want)
+		}
+	}
+}
@@ -11,13 +11,40 @@ import (
 	"github.com/GTDGit/gtd_api/internal/service"
 )
 
+// continuationLease is how long a claimed async provider continuation is held
+// before another run may pick it up again (e.g. after a crash mid-attempt).
+const continuationLease = 5 * time.Minute
+
+// continuationBudget is how long one continuation may start new provider
+// attempts. It leaves an attempt in flight the rest of continuationLease to
+// finish, so the lease does not run out under it.
+const continuationBudget = 3 * time.Minute
+
+// ProviderContinuer finishes prepaid transactions left Processing by the
+// synchronous provider attempt limit, starting no attempt after budget.
+type ProviderContinuer interface {
+	ContinueWithRemainingProviders(ctx context.Context, trx *models.Transaction, budget time.Duration) (*models.Transaction, error)
+}
+
 // RetryWorker cleans up any lingering pending transactions.
 // With the new logic, transactions fail immediately when all SKUs are exhausted,
 // so this worker mainly serves as a cleanup mechanism for edge cases.
+//
+// When a ProviderContinuer is set it also runs the async half of the
+// synchronous provider attempt limit:
+//
+//	Processing (next_retry_at set) -> claimed -> remaining providers tried
+//	  -> Success / Failed: client callback sent
+//	  -> provider Pending: Processing, finished by the status check worker
 type RetryWorker struct {
+	pausable
 	trxRepo     *repository.TransactionRepository
 	callbackSvc *service.CallbackService
 	interval    time.Duration
+
+	// Optional async continuation of the synchronous provider attempt limit.
+	continuer        ProviderContinuer
+	continueInterval time.Duration
 }
 
 // NewRetryWorker constructs a RetryWorker.
@@ -33,6 +60,14 @@ func NewRetryWorker(
 	}
 }
 
+// SetProviderContinuer enables async continuation of transactions deferred by
+// the synchronous provider attempt limit, the request timeout or a provider's
+// Retry-After hint, polled every interval.
+func (w *RetryWorker) SetProviderContinuer(c ProviderContinuer, interval time.Duration) {
+	w.continuer = c
+	w.continueInterval = interval
+}
+
 // Start begins the periodic retry loop until context is canceled.
 func (w *RetryWorker) Start(ctx context.Context) {
 	log.Info().Dur("interval", w.interval).Msg("Starting retry worker")
@@ -40,10 +75,25 @@ func (w *RetryWorker) Start(ctx context.Context) {
 	ticker := time.NewTicker(w.interval)
 	defer ticker.Stop()
 
+	var continueC <-chan time.Time
+	if w.continuer != nil && w.continueInterval > 0 {
+		continueTicker := time.NewTicker(w.continueInterval)
+		defer continueTicker.Stop()
+		continueC = continueTicker.C
+	}
+
 	for {
 		select {
 		case <-ticker.C:
+			if w.paused() {
+				continue
+			}
 			w.run(ctx)
+		case <-continueC:
+			if w.paused() {
+				continue
+			}
+			w.continueRemainingProviders(ctx)
 		case <-ctx.Done():
 			log.Info().Msg("Retry worker stopped")
 			return
@@ -97,7 +147,7 @@ func (w *RetryWorker) markExpiredTransactionsFailed(ctx context.Context) {
 			}
 
 			// Send callback to client
-			go w.callbackSvc.SendCallback(trx, "transaction.failed")
+			w.callbackSvc.QueueCallback(trx, "transaction.failed")
 
 			log.Info().
 				Str("transaction_id", trx.TransactionID).
@@ -105,3 +155,42 @@ func (w *RetryWorker) markExpiredTransactionsFailed(ctx context.Context) {
 		}
 	}
 }
+
+// continueRemainingProviders tries the untried providers of transactions the
+// synchronous attempt limit left Processing.
+func (w *RetryWorker) continueRemainingProviders(ctx context.Context) {
+	due, err := w.trxRepo.GetDueProviderContinuations(50)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get due provider continuations")
+		return
+	}
+
+	for i := range due {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		trx := &due[i]
+		claimed, err := w.trxRepo.ClaimProviderContinuation(trx.ID, continuationLease)
+		if err != nil {
+			log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("Failed to claim provider continuation")
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		result, err := w.continuer.ContinueWithRemainingProviders(ctx, trx, continuationBudget)
+		if err != nil {
+			log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("Async provider continuation failed")
+			continue
+		}
+		log.Info().
+			Str("transaction_id", result.TransactionID).
+			Str("status", string(result.Status)).
+			Str("provider_code", providerCode(result)).
+			Msg("Async provider continuation finished")
+	}
+}
@@ -0,0 +1,17 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+// A continuation stops starting attempts well before its lease expires, so
+// the final in-flight provider call still finishes under the lease.
+func TestContinuationBudgetFitsLease(t *testing.T) {
+	t.Parallel()
+
+	const providerCall = time.Minute
+	if continuationBudget+providerCall > continuationLease {
+		t.Fatalf("budget %s + provider call %s exceeds lease %s", continuationBudget, providerCall, continuationLease)
+	}
+}
@@ -16,6 +16,7 @@ import (
 // For multi-provider transactions, it uses ProviderRouter to check the correct provider.
 // For legacy Digiflazz transactions, it continues using direct Digiflazz calls.
 type StatusCheckWorker struct {
+	pausable
 	trxRepo         *repository.TransactionRepository
 	skuRepo         *repository.SKURepository
 	callbackSvc     *service.CallbackService
@@ -77,6 +78,9 @@ func (w *StatusCheckWorker) Start(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
+			if w.paused() {
+				continue
+			}
 			w.run(ctx)
 		case <-ctx.Done():
 			log.Info().Msg("Status check worker stopped")
@@ -85,6 +89,70 @@ func (w *StatusCheckWorker) Start(ctx context.Context) {
 	}
 }
 
+// reconcileBatchSize is how many transactions Reconcile reads at a time.
+const reconcileBatchSize = 50
+
+// Reconcile re-checks every stale Processing transaction once, paging by
+// id, until a page comes back empty or timeout passes, and returns how many
+// were checked. It resyncs what changed at providers while no worker ran
+// (a deploy or crash), so it is meant to run at boot before traffic and
+// before Start. Unlike a scheduled run it asks the provider even past the
+// max age, failing a transaction on age only when the provider gives no
+// definite answer.
+func (w *StatusCheckWorker) Reconcile(ctx context.Context, timeout time.Duration) int {
+	if w.paused() {
+		log.Info().Msg("Startup reconciliation skipped: read-only mode")
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	fetch := func(afterID int) ([]models.Transaction, error) {
+		return w.trxRepo.GetStaleProcessingTransactionsAfter(w.staleAfter, afterID, reconcileBatchSize)
+	}
+	checked := reconcile(ctx, fetch, func(ctx context.Context, trx *models.Transaction) {
+		w.check(ctx, trx, true)
+	})
+
+	event := log.Info()
+	if ctx.Err() != nil {
+		event = log.Warn().Bool("timed_out", true)
+	}
+	event.Int("checked", checked).Dur("took", time.Since(start)).Msg("Startup reconciliation finished")
+	return checked
+}
+
+// reconcile feeds the pages fetch returns to check until a page is empty,
+// brings no transaction not checked already, or ctx is done.
+func reconcile(ctx context.Context, fetch func(afterID int) ([]models.Transaction, error), check func(context.Context, *models.Transaction)) int {
+	seen := make(map[int]bool)
+	afterID := 0
+	for ctx.Err() == nil {
+		page, err := fetch(afterID)
+		if err != nil {
+			log.Error().Err(err).Msg("Startup reconciliation: failed to get stale processing transactions")
+			break
+		}
+		fresh := 0
+		for i := range page {
+			if page[i].ID > afterID {
+				afterID = page[i].ID
+			}
+			if ctx.Err() != nil || seen[page[i].ID] {
+				continue
+			}
+			seen[page[i].ID] = true
+			check(ctx, &page[i])
+			fresh++
+		}
+		if fresh == 0 {
+			break
+		}
+	}
+	return len(seen)
+}
+
 func (w *StatusCheckWorker) run(ctx context.Context) {
 	// Get Processing transactions that haven't received callback
 	stale, err := w.trxRepo.GetStaleProcessingTransactions(w.staleAfter)
@@ -109,6 +177,13 @@ func (w *StatusCheckWorker) run(ctx context.Context) {
 }
 
 func (w *StatusCheckWorker) checkTransaction(ctx context.Context, trx *models.Transaction) {
+	w.check(ctx, trx, false)
+}
+
+// check re-checks trx with its provider. Past the max age a scheduled check
+// fails trx without asking; askPastMaxAge asks first and fails it on age
+// only when the provider has no final status for it.
+func (w *StatusCheckWorker) check(ctx context.Context, trx *models.Transaction, askPastMaxAge bool) {
 	age := time.Since(trx.CreatedAt)
 	if minAge := w.minAgeFor(trx); minAge > 0 && age < minAge {
 		log.Debug().
@@ -122,6 +197,9 @@ func (w *StatusCheckWorker) checkTransaction(ctx context.Context, trx *models.Tr
 
 	// Check if too old - mark as failed
 	if maxAge := w.maxAgeFor(trx); maxAge > 0 && age > maxAge {
+		if askPastMaxAge && w.checkWithProvider(ctx, trx) {
+			return
+		}
 		log.Warn().
 			Str("transaction_id", trx.TransactionID).
 			Str("provider_code", providerCode(trx)).
@@ -131,14 +209,19 @@ func (w *StatusCheckWorker) checkTransaction(ctx context.Context, trx *models.Tr
 		return
 	}
 
+	w.checkWithProvider(ctx, trx)
+}
+
+// checkWithProvider asks trx's provider for its status and applies it,
+// reporting whether the provider gave a final one (success or failure).
+func (w *StatusCheckWorker) checkWithProvider(ctx context.Context, trx *models.Transaction) bool {
 	// Check if this is a multi-provider transaction
 	if trx.ProviderCode != nil && trx.ProviderRefID != nil && *trx.ProviderCode != "" {
-		w.checkMultiProviderTransaction(ctx, trx)
-		return
+		return w.checkMultiProviderTransaction(ctx, trx)
 	}
 
 	// Legacy Digiflazz transaction
-	w.checkDigiflazzTransaction(ctx, trx)
+	return w.checkDigiflazzTransaction(ctx, trx)
 }
 
 func providerCode(trx *models.Transaction) string {
@@ -164,7 +247,9 @@ func (w *StatusCheckWorker) maxAgeFor(trx *models.Transaction) time.Duration {
 	return w.maxAge
 }
 
-func (w *StatusCheckWorker) checkMultiProviderTransaction(ctx context.Context, trx *models.Transaction) {
+// checkMultiProviderTransaction applies the router provider's status of trx,
+// reporting whether it was final.
+func (w *StatusCheckWorker) checkMultiProviderTransaction(ctx context.Context, trx *models.Transaction) bool {
 	log.Info().
 		Str("transaction_id", trx.TransactionID).
 		Str("provider_code", *trx.ProviderCode).
@@ -176,7 +261,7 @@ func (w *StatusCheckWorker) checkMultiProviderTransaction(ctx context.Context, t
 		log.Error().
 			Str("transaction_id", trx.TransactionID).
 			Msg("ProviderRouter not configured, cannot check multi-provider transaction")
-		return
+		return false
 	}
 
 	// Get the provider adapter
@@ -186,18 +271,18 @@ func (w *StatusCheckWorker) checkMultiProviderTransaction(ctx context.Context, t
 			Str("transaction_id", trx.TransactionID).
 			Str("provider_code", *trx.ProviderCode).
 			Msg("No adapter found for provider")
-		return
+		return false
 	}
 
 	// Check status with the provider
-	result, err := adapter.CheckStatus(ctx, *trx.ProviderRefID)
+	result, err := adapter.CheckStatus(service.WithSandbox(ctx, trx.IsSandbox), *trx.ProviderRefID)
 	if err != nil {
 		log.Warn().
 			Err(err).
 			Str("transaction_id", trx.TransactionID).
 			Str("provider_code", *trx.ProviderCode).
 			Msg("Error checking transaction status with provider, will retry later")
-		return
+		return false
 	}
 
 	now := time.Now()
@@ -214,35 +299,41 @@ func (w *StatusCheckWorker) checkMultiProviderTransaction(ctx context.Context, t
 		trx.Status = models.StatusSuccess
 		trx.FailedCode = nil
 		trx.FailedReason = nil
+		trx.ProviderError = nil
 		if result.SerialNumber != "" {
 			trx.SerialNumber = &result.SerialNumber
 		}
 		if result.Amount > 0 {
 			trx.Amount = &result.Amount
 		}
+		if desc := service.SanitizePublicProviderDescription(result.Description); len(desc) > 0 {
+			trx.Description = models.NullableRawMessage(desc)
+		}
 		trx.ProcessedAt = &now
 
 		if err := w.trxRepo.Update(trx); err != nil {
 			log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("Failed to update transaction to success")
-			return
+			return true
 		}
 
-		go w.callbackSvc.SendCallback(trx, "transaction.success")
+		w.callbackSvc.QueueCallback(trx, "transaction.success")
 		log.Info().
 			Str("transaction_id", trx.TransactionID).
 			Str("provider_code", *trx.ProviderCode).
 			Msg("Transaction updated to Success from multi-provider status check")
 
-	case result.Pending:
+	case result.Pending || w.providerRouter.AmbiguousAsPending(result):
+		// An ambiguous answer is no proof of failure; keep checking until maxAge.
 		if err := w.trxRepo.Update(trx); err != nil {
 			log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("Failed to refresh pending transaction trace")
-			return
+			return false
 		}
 		// Still pending, will check again on next run
 		log.Debug().
 			Str("transaction_id", trx.TransactionID).
 			Str("provider_code", *trx.ProviderCode).
 			Msg("Transaction still pending from multi-provider status check")
+		return false
 
 	default:
 		// Failed
@@ -252,7 +343,7 @@ func (w *StatusCheckWorker) checkMultiProviderTransaction(ctx context.Context, t
 			retried, handled, err := w.providerRetrier.RetryWithNextProvider(ctx, trx, rc, msg)
 			if err != nil {
 				log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("Failed to retry transaction with next provider")
-				return
+				return true
 			}
 			if handled {
 				if retried != nil && retried.Status == models.StatusFailed {
@@ -262,7 +353,7 @@ func (w *StatusCheckWorker) checkMultiProviderTransaction(ctx context.Context, t
 						Str("rc", valueOrEmpty(retried.FailedCode)).
 						Msg("Transaction finalized after prepaid provider fallback")
 				}
-				return
+				return true
 			}
 		}
 		trx.Status = models.StatusFailed
@@ -273,21 +364,23 @@ func (w *StatusCheckWorker) checkMultiProviderTransaction(ctx context.Context, t
 			HTTPStatus:  valueOrZero(trx.ProviderHTTPStatus),
 			RawResponse: result.RawResponse,
 			Description: result.Description,
+			Error:       result.Error,
 		})
 		trx.ProcessedAt = &now
 
 		if err := w.trxRepo.Update(trx); err != nil {
 			log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("Failed to update transaction to failed")
-			return
+			return true
 		}
 
-		go w.callbackSvc.SendCallback(trx, "transaction.failed")
+		w.callbackSvc.QueueCallback(trx, "transaction.failed")
 		log.Info().
 			Str("transaction_id", trx.TransactionID).
 			Str("provider_code", *trx.ProviderCode).
 			Str("rc", rc).
 			Msg("Transaction updated to Failed from multi-provider status check")
 	}
+	return true
 }
 
 func valueOrEmpty(v *string) string {
@@ -304,19 +397,21 @@ func valueOrZero(v *int) int {
 	return *v
 }
 
-func (w *StatusCheckWorker) checkDigiflazzTransaction(ctx context.Context, trx *models.Transaction) {
+// checkDigiflazzTransaction applies Digiflazz's status of trx, reporting
+// whether it was final.
+func (w *StatusCheckWorker) checkDigiflazzTransaction(ctx context.Context, trx *models.Transaction) bool {
 	if w.digiProd == nil && w.digiDev == nil {
 		log.Warn().
 			Str("transaction_id", trx.TransactionID).
 			Msg("Digiflazz clients not configured, skipping legacy status check")
-		return
+		return false
 	}
 
 	if trx.DigiRefID == nil || *trx.DigiRefID == "" {
 		log.Error().
 			Str("transaction_id", trx.TransactionID).
 			Msg("Transaction has no DigiRefID, cannot check status")
-		return
+		return false
 	}
 
 	log.Info().
@@ -328,13 +423,13 @@ func (w *StatusCheckWorker) checkDigiflazzTransaction(ctx context.Context, trx *
 	// Get SKU to get the digi_sku_code
 	if trx.SkuID == nil {
 		log.Error().Str("transaction_id", trx.TransactionID).Msg("Transaction has no SKU ID")
-		return
+		return false
 	}
 
 	sku, err := w.skuRepo.GetByID(*trx.SkuID)
 	if err != nil {
 		log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("Failed to get SKU")
-		return
+		return false
 	}
 
 	// Call Digiflazz with same ref_id - this will return current status
@@ -350,7 +445,7 @@ func (w *StatusCheckWorker) checkDigiflazzTransaction(ctx context.Context, trx *
 			Err(err).
 			Str("transaction_id", trx.TransactionID).
 			Msg("Network error checking transaction status, will retry later")
-		return // Don't fail, will retry on next run
+		return false // Don't fail, will retry on next run
 	}
 
 	log.Info().
@@ -374,10 +469,10 @@ func (w *StatusCheckWorker) checkDigiflazzTransaction(ctx context.Context, trx *
 
 		if err := w.trxRepo.Update(trx); err != nil {
 			log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("Failed to update transaction to success")
-			return
+			return true
 		}
 
-		go w.callbackSvc.SendCallback(trx, "transaction.success")
+		w.callbackSvc.QueueCallback(trx, "transaction.success")
 		log.Info().Str("transaction_id", trx.TransactionID).Msg("Transaction updated to Success from status check")
 
 	case digiflazz.IsFatal(resp.RC):
@@ -390,10 +485,10 @@ func (w *StatusCheckWorker) checkDigiflazzTransaction(ctx context.Context, trx *
 
 		if err := w.trxRepo.Update(trx); err != nil {
 			log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("Failed to update transaction to failed")
-			return
+			return true
 		}
 
-		go w.callbackSvc.SendCallback(trx, "transaction.failed")
+		w.callbackSvc.QueueCallback(trx, "transaction.failed")
 		log.Info().
 			Str("transaction_id", trx.TransactionID).
 			Str("rc", resp.RC).
@@ -412,10 +507,10 @@ func (w *StatusCheckWorker) checkDigiflazzTransaction(ctx context.Context, trx *
 
 		if err := w.trxRepo.Update(trx); err != nil {
 			log.Error().Err(err).Str("transaction_id", trx.TransactionID).Msg("Failed to update transaction to failed")
-			return
+			return true
 		}
 
-		go w.callbackSvc.SendCallback(trx, "transaction.failed")
+		w.callbackSvc.QueueCallback(trx, "transaction.failed")
 		log.Info().
 			Str("transaction_id", trx.TransactionID).
 			Str("rc", resp.RC).
@@ -426,6 +521,7 @@ func (w *StatusCheckWorker) checkDigiflazzTransaction(ctx context.Context, trx *
 		log.Debug().
 			Str("transaction_id", trx.TransactionID).
 			Msg("Transaction still pending from status check")
+		return false
 
 	default:
 		// Unknown RC, log but don't change status
@@ -433,7 +529,9 @@ func (w *StatusCheckWorker) checkDigiflazzTransaction(ctx context.Context, trx *
 			Str("transaction_id", trx.TransactionID).
 			Str("rc", resp.RC).
 			Msg("Unknown RC from status check, keeping as Processing")
+		return false
 	}
+	return true
 }
 
 func (w *StatusCheckWorker) markFailed(trx *models.Transaction, reason string) {
@@ -450,7 +548,7 @@ func (w *StatusCheckWorker) markFailed(trx *models.Transaction, reason string) {
 		return
 	}
 
-	go w.callbackSvc.SendCallback(trx, "transaction.failed")
+	w.callbackSvc.QueueCallback(trx, "transaction.failed")
 	log.Info().
 		Str("transaction_id", trx.TransactionID).
 		Str("reason", reason).
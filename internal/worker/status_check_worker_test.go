@@ -64,3 +64,53 @@ func TestStatusCheckWorkerSkipsYoungKiosbankTransactions(t *testing.T) {
 		t.Fatalf("ProcessedAt = %v, want nil", trx.ProcessedAt)
 	}
 }
+
+func TestReconcileStopConditions(t *testing.T) {
+	t.Parallel()
+
+	page := func(ids ...int) []models.Transaction {
+		out := make([]models.Transaction, len(ids))
+		for i, id := range ids {
+			out[i] = models.Transaction{ID: id}
+		}
+		return out
+	}
+
+	t.Run("pages until empty", func(t *testing.T) {
+		pages := map[int][]models.Transaction{0: page(1, 2), 2: page(5), 5: nil}
+		var checked []int
+		n := reconcile(context.Background(), func(afterID int) ([]models.Transaction, error) {
+			return pages[afterID], nil
+		}, func(_ context.Context, trx *models.Transaction) {
+			checked = append(checked, trx.ID)
+		})
+		if n != 3 || len(checked) != 3 || checked[2] != 5 {
+			t.Fatalf("checked %v (n=%d), want 1, 2, 5", checked, n)
+		}
+	})
+
+	t.Run("stops when a page brings nothing new", func(t *testing.T) {
+		fetches := 0
+		n := reconcile(context.Background(), func(int) ([]models.Transaction, error) {
+			fetches++
+			return page(1, 2), nil // ignores the cursor
+		}, func(context.Context, *models.Transaction) {})
+		if n != 2 || fetches != 2 {
+			t.Fatalf("n = %d after %d fetches, want 2 after 2", n, fetches)
+		}
+	})
+
+	t.Run("stops at the timeout", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		n := reconcile(ctx, func(afterID int) ([]models.Transaction, error) {
+			return page(afterID+1, afterID+2), nil // never runs dry
+		}, func(_ context.Context, trx *models.Transaction) {
+			if trx.ID == 3 {
+				cancel()
+			}
+		})
+		if n != 3 {
+			t.Fatalf("n = %d, want 3 (checks stop once ctx is done)", n)
+		}
+	})
+}
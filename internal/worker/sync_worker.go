@@ -11,6 +11,7 @@ import (
 
 // SyncWorker periodically syncs pricelists from Digiflazz.
 type SyncWorker struct {
+    pausable
     syncService *service.SyncService
     interval    time.Duration
 }
@@ -28,7 +29,9 @@ func (w *SyncWorker) Start(ctx context.Context) {
     log.Info().Dur("interval", w.interval).Msg("Starting sync worker")
 
     // Run immediately on start
-    w.run(ctx)
+    if !w.paused() {
+        w.run(ctx)
+    }
 
     ticker := time.NewTicker(w.interval)
     defer ticker.Stop()
@@ -36,6 +39,9 @@ func (w *SyncWorker) Start(ctx context.Context) {
     for {
         select {
         case <-ticker.C:
+            if w.paused() {
+                continue
+            }
             w.run(ctx)
         case <-ctx.Done():
             log.Info().Msg("Sync worker stopped")
@@ -0,0 +1,5 @@
+-- Reverse 000071: drop per-product customer number rules.
+
+ALTER TABLE products DROP COLUMN IF EXISTS customer_no_pattern;
+ALTER TABLE products DROP COLUMN IF EXISTS customer_no_max_length;
+ALTER TABLE products DROP COLUMN IF EXISTS customer_no_min_length;
@@ -0,0 +1,6 @@
+-- Per-product customer number validation rules, managed by product owners.
+-- NULL means "no constraint" for each column.
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS customer_no_min_length INT;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS customer_no_max_length INT;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS customer_no_pattern VARCHAR(255);
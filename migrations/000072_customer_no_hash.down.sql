@@ -0,0 +1,5 @@
+-- Reverse 000072: drop customer number hashing.
+
+DROP INDEX IF EXISTS idx_transactions_client_customer_no_hash;
+ALTER TABLE transactions DROP COLUMN IF EXISTS customer_no_hash;
+ALTER TABLE clients DROP COLUMN IF EXISTS hash_customer_no;
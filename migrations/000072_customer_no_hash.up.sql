@@ -0,0 +1,13 @@
+-- Per-client opt-in for customer number data minimization.
+--
+-- When clients.hash_customer_no is on, every transaction stores a salted
+-- SHA-256 of customer_no for dedupe/velocity lookups, and the raw value is
+-- masked once the transaction is final and older than the retention window.
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS hash_customer_no BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS customer_no_hash VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_client_customer_no_hash
+    ON transactions (client_id, customer_no_hash, created_at)
+    WHERE customer_no_hash IS NOT NULL;
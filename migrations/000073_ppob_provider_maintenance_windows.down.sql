@@ -0,0 +1,3 @@
+-- Reverse 000073: drop provider maintenance windows.
+
+DROP TABLE IF EXISTS ppob_provider_maintenance_windows;
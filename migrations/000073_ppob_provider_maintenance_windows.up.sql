@@ -0,0 +1,17 @@
+-- Scheduled provider maintenance windows. While NOW() is inside a window the
+-- provider is excluded from routing and best-price quotes; it is restored
+-- automatically once the window ends.
+
+CREATE TABLE IF NOT EXISTS ppob_provider_maintenance_windows (
+    id SERIAL PRIMARY KEY,
+    provider_id INT NOT NULL REFERENCES ppob_providers(id) ON DELETE CASCADE,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    reason VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_ppob_maintenance_window_range CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ppob_maintenance_windows_provider_range
+    ON ppob_provider_maintenance_windows (provider_id, starts_at, ends_at);
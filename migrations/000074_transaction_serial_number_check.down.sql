@@ -0,0 +1,4 @@
+-- Reverse 000074: drop serial-number uniqueness check.
+
+DROP INDEX IF EXISTS idx_transactions_serial_number;
+ALTER TABLE transactions DROP COLUMN IF EXISTS serial_number_duplicate_of;
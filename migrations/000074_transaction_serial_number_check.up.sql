@@ -0,0 +1,12 @@
+-- Serial-number uniqueness check for successful prepaid transactions.
+--
+-- A token/serial returned by a provider that is already recorded on another
+-- successful transaction points to a provider bug or replay. The new
+-- transaction is not failed; it is flagged with the transaction_id it
+-- collides with for ops follow-up.
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS serial_number_duplicate_of VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_serial_number
+    ON transactions (serial_number)
+    WHERE serial_number IS NOT NULL AND status = 'Success';
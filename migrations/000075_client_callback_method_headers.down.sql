@@ -0,0 +1,5 @@
+-- Reverse 000075: drop per-client callback method and headers.
+
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_callback_method_check;
+ALTER TABLE clients DROP COLUMN IF EXISTS callback_headers;
+ALTER TABLE clients DROP COLUMN IF EXISTS callback_method;
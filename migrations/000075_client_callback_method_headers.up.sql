@@ -0,0 +1,9 @@
+-- Per-client outgoing callback HTTP method and static headers, for clients
+-- whose webhook gateway needs e.g. PUT or an Authorization bearer.
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS callback_method VARCHAR(10) NOT NULL DEFAULT 'POST';
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS callback_headers JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_callback_method_check;
+ALTER TABLE clients ADD CONSTRAINT clients_callback_method_check
+    CHECK (callback_method IN ('POST', 'PUT', 'PATCH'));
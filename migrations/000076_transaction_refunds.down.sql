@@ -0,0 +1,3 @@
+-- Reverse 000076: drop transaction refunds.
+
+DROP TABLE IF EXISTS transaction_refunds;
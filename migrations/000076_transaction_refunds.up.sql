@@ -0,0 +1,15 @@
+-- Partial refunds recorded against a transaction when a dispute is resolved
+-- with a credit. The sum of refunds per transaction never exceeds its amount
+-- (enforced by the API under a row lock on the parent transaction).
+
+CREATE TABLE IF NOT EXISTS transaction_refunds (
+    id             SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    amount         INTEGER NOT NULL CHECK (amount > 0),
+    reason         TEXT NOT NULL,
+    created_by     VARCHAR(255),
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_refunds_transaction_id
+    ON transaction_refunds (transaction_id);
@@ -0,0 +1,4 @@
+-- Reverse 000077: drop per-client transaction ID prefix.
+
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_transaction_id_prefix_check;
+ALTER TABLE clients DROP COLUMN IF EXISTS transaction_id_prefix;
@@ -0,0 +1,8 @@
+-- Optional per-client transaction ID prefix for white-label partners.
+-- NULL keeps the global TRANSACTION_ID_PREFIX.
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS transaction_id_prefix VARCHAR(6);
+
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_transaction_id_prefix_check;
+ALTER TABLE clients ADD CONSTRAINT clients_transaction_id_prefix_check
+    CHECK (transaction_id_prefix IS NULL OR transaction_id_prefix ~ '^[A-Z0-9]{2,6}$');
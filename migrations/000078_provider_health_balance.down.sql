@@ -0,0 +1,4 @@
+-- Reverse 000078: drop last known provider balance.
+
+ALTER TABLE ppob_provider_health DROP COLUMN IF EXISTS last_balance_at;
+ALTER TABLE ppob_provider_health DROP COLUMN IF EXISTS last_balance;
@@ -0,0 +1,5 @@
+-- Last known provider deposit balance, recorded by the balance check worker
+-- and shown alongside today's health stats.
+
+ALTER TABLE ppob_provider_health ADD COLUMN IF NOT EXISTS last_balance BIGINT;
+ALTER TABLE ppob_provider_health ADD COLUMN IF NOT EXISTS last_balance_at TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+-- Reverse 000079: drop per-SKU RC overrides.
+
+DROP TABLE IF EXISTS sku_rc_overrides;
@@ -0,0 +1,15 @@
+-- Per-SKU Digiflazz RC overrides. For a seller whose RC means something other
+-- than the global classification (e.g. a "switch SKU" code that is really a
+-- permanent failure for the number), ops can pin the outcome here.
+
+CREATE TABLE IF NOT EXISTS sku_rc_overrides (
+    id SERIAL PRIMARY KEY,
+    sku_id INT NOT NULL REFERENCES skus(id) ON DELETE CASCADE,
+    rc VARCHAR(10) NOT NULL,
+    outcome VARCHAR(20) NOT NULL,
+    note VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_sku_rc_overrides UNIQUE (sku_id, rc),
+    CONSTRAINT chk_sku_rc_overrides_outcome CHECK (outcome IN ('fatal', 'switch_sku'))
+);
@@ -0,0 +1,5 @@
+-- Reverse 000080: drop provider callback retry state.
+
+DROP INDEX IF EXISTS idx_ppob_provider_callbacks_next_retry;
+ALTER TABLE ppob_provider_callbacks DROP COLUMN IF EXISTS next_retry_at;
+ALTER TABLE ppob_provider_callbacks DROP COLUMN IF EXISTS retry_count;
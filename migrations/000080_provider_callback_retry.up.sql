@@ -0,0 +1,10 @@
+-- Retry state for provider callbacks whose processing failed (process_error
+-- set, is_processed still false). next_retry_at NULL with an error means the
+-- retry budget is exhausted and the callback needs manual attention.
+
+ALTER TABLE ppob_provider_callbacks ADD COLUMN IF NOT EXISTS retry_count INT NOT NULL DEFAULT 0;
+ALTER TABLE ppob_provider_callbacks ADD COLUMN IF NOT EXISTS next_retry_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_ppob_provider_callbacks_next_retry
+    ON ppob_provider_callbacks (next_retry_at)
+    WHERE is_processed = false AND process_error IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Reverse 000081: drop the async provider continuation index.
+
+DROP INDEX IF EXISTS idx_transactions_provider_continuation;
@@ -0,0 +1,6 @@
+-- Processing prepaid transactions whose remaining providers are tried
+-- asynchronously carry next_retry_at; index them for the retry worker.
+
+CREATE INDEX IF NOT EXISTS idx_transactions_provider_continuation
+    ON transactions(next_retry_at)
+    WHERE status = 'Processing' AND next_retry_at IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Reverse 000082: drop the denormalized transaction provider code.
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS provider_code;
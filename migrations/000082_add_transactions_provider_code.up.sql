@@ -0,0 +1,5 @@
+-- Denormalized provider code on transactions so reads that do not join
+-- ppob_providers still show the provider. Written from provider_id on every
+-- insert/update; historical rows are filled by cmd/backfill-provider-code.
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS provider_code VARCHAR(20);
@@ -0,0 +1,3 @@
+-- Reverse 000083: drop the callback attempt info opt-in.
+
+ALTER TABLE clients DROP COLUMN IF EXISTS callback_attempt_info;
@@ -0,0 +1,4 @@
+-- Opt-in per client: success callbacks carry attemptInfo (provider attempt
+-- count, whether fallback or the backup provider was used).
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS callback_attempt_info BOOLEAN NOT NULL DEFAULT false;
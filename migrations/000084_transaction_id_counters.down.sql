@@ -0,0 +1,3 @@
+-- Reverse 000084: drop the transaction ID counters.
+
+DROP TABLE IF EXISTS transaction_id_counters;
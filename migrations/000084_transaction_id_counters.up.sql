@@ -0,0 +1,12 @@
+-- Atomic per-prefix, per-day counter behind transaction IDs
+-- (PREFIX-YYYYMMDD-NNNNNN). Each ID takes the next value with a single
+-- INSERT ... ON CONFLICT DO UPDATE ... RETURNING, so concurrent requests never
+-- share one.
+
+CREATE TABLE IF NOT EXISTS transaction_id_counters (
+    prefix     VARCHAR(6) NOT NULL,
+    day        CHAR(8) NOT NULL, -- YYYYMMDD, Asia/Jakarta
+    last_value INT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (prefix, day)
+);
@@ -0,0 +1,3 @@
+-- Reverse 000085: drop callback acknowledgment timestamp.
+
+ALTER TABLE callback_logs DROP COLUMN IF EXISTS acknowledged_at;
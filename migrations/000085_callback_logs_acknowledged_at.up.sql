@@ -0,0 +1,4 @@
+-- Client-side acknowledgment of a PPOB callback (POST /v1/ppob/callbacks/:id/ack).
+-- An acknowledged callback counts as delivered and is never retried again.
+
+ALTER TABLE callback_logs ADD COLUMN IF NOT EXISTS acknowledged_at TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+-- Reverse 000086: drop provider balance history.
+
+DROP TABLE IF EXISTS ppob_provider_balance_history;
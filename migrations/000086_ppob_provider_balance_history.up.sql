@@ -0,0 +1,13 @@
+-- Sampled provider deposit balances for trend reporting. The balance worker
+-- inserts one row per provider at most every PROVIDER_BALANCE_HISTORY_INTERVAL;
+-- ppob_provider_health keeps only the latest value.
+
+CREATE TABLE IF NOT EXISTS ppob_provider_balance_history (
+    id BIGSERIAL PRIMARY KEY,
+    provider_id INT NOT NULL REFERENCES ppob_providers(id) ON DELETE CASCADE,
+    balance BIGINT NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ppob_provider_balance_history_provider_time
+    ON ppob_provider_balance_history (provider_id, recorded_at);
@@ -0,0 +1,3 @@
+-- Reverse 000087: drop client locale.
+
+ALTER TABLE clients DROP COLUMN IF EXISTS locale;
@@ -0,0 +1,4 @@
+-- Per-client language for client-facing error messages ('en' or 'id').
+-- An Accept-Language header on the request takes precedence.
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS locale VARCHAR(5) NOT NULL DEFAULT 'en';
@@ -0,0 +1,3 @@
+-- Reverse 000088: drop transaction notes.
+
+DROP TABLE IF EXISTS transaction_notes;
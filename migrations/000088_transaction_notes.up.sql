@@ -0,0 +1,13 @@
+-- Internal notes left by admins/support agents on a transaction. Never exposed
+-- to clients (API responses or callbacks).
+
+CREATE TABLE IF NOT EXISTS transaction_notes (
+    id             SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    note           TEXT NOT NULL,
+    created_by     VARCHAR(255),
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_notes_transaction_id
+    ON transaction_notes (transaction_id);
@@ -0,0 +1,3 @@
+-- Reverse 000089: drop per-product provider ordering override.
+
+ALTER TABLE products DROP COLUMN IF EXISTS selection_strategy;
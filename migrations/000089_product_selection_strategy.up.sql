@@ -0,0 +1,5 @@
+-- Per-product override of provider ordering. NULL keeps the type default
+-- (prepaid: price, postpaid: admin - commission); see
+-- repository.SelectionStrategy* for the accepted values.
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS selection_strategy VARCHAR(20);
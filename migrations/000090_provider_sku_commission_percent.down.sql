@@ -0,0 +1,3 @@
+-- Reverse 000090: drop percentage commission for provider SKUs.
+
+ALTER TABLE ppob_provider_skus DROP COLUMN IF EXISTS commission_percent;
@@ -0,0 +1,6 @@
+-- Percentage commission for provider SKUs. When set, the commission is
+-- computed from the current admin at selection time instead of using the
+-- pre-computed integer commission column.
+
+ALTER TABLE ppob_provider_skus ADD COLUMN IF NOT EXISTS commission_percent NUMERIC(7,4)
+    CHECK (commission_percent IS NULL OR (commission_percent >= 0 AND commission_percent <= 100));
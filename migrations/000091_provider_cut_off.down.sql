@@ -0,0 +1,4 @@
+-- Reverse 000091: drop provider-level cut-off window.
+
+ALTER TABLE ppob_providers DROP COLUMN IF EXISTS cut_off_end;
+ALTER TABLE ppob_providers DROP COLUMN IF EXISTS cut_off_start;
@@ -0,0 +1,6 @@
+-- Daily provider-level cut-off window (WIB) during which the provider is
+-- skipped for routing, mirroring skus.cut_off_start/cut_off_end.
+-- 00:00:00-00:00:00 means no cut-off; end < start crosses midnight.
+
+ALTER TABLE ppob_providers ADD COLUMN IF NOT EXISTS cut_off_start TIME NOT NULL DEFAULT '00:00:00';
+ALTER TABLE ppob_providers ADD COLUMN IF NOT EXISTS cut_off_end TIME NOT NULL DEFAULT '00:00:00';
@@ -0,0 +1,4 @@
+-- Reverse 000092: drop the admin audit log.
+
+DROP TABLE IF EXISTS admin_audit_log;
+DROP FUNCTION IF EXISTS admin_audit_log_immutable();
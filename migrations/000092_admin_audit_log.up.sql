@@ -0,0 +1,31 @@
+-- Append-only trail of admin actions. The admin audit middleware writes one
+-- row per mutating /v1/admin request with the JWT actor, the route, its path
+-- parameters and the request body with secret-looking values redacted. The
+-- trigger rejects UPDATE and DELETE so rows cannot be rewritten.
+
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INT,
+    actor_email VARCHAR(255) NOT NULL DEFAULT '',
+    action VARCHAR(255) NOT NULL,
+    target VARCHAR(255) NOT NULL DEFAULT '',
+    changes JSONB,
+    status_code INT NOT NULL,
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_created_at ON admin_audit_log (created_at);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_actor ON admin_audit_log (actor_email, created_at);
+
+CREATE OR REPLACE FUNCTION admin_audit_log_immutable()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'admin_audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS admin_audit_log_no_change ON admin_audit_log;
+CREATE TRIGGER admin_audit_log_no_change
+    BEFORE UPDATE OR DELETE ON admin_audit_log
+    FOR EACH ROW EXECUTE FUNCTION admin_audit_log_immutable();
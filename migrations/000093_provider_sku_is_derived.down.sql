@@ -0,0 +1,3 @@
+-- Reverse 000093: drop the derived provider SKU flag.
+
+ALTER TABLE ppob_provider_skus DROP COLUMN IF EXISTS is_derived;
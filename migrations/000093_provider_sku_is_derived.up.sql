@@ -0,0 +1,5 @@
+-- Flags provider SKU mappings created from a provider's config.derivedSku
+-- rule instead of explicit admin mapping. The provider price sync creates
+-- them unavailable and prices them from the live price list like any other.
+
+ALTER TABLE ppob_provider_skus ADD COLUMN IF NOT EXISTS is_derived BOOLEAN NOT NULL DEFAULT false;
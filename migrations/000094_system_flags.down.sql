@@ -0,0 +1,3 @@
+-- Reverse 000094: drop system flags.
+
+DROP TABLE IF EXISTS system_flags;
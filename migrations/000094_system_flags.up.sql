@@ -0,0 +1,14 @@
+-- Global operational switches toggled from admin and shared by every API
+-- instance. read_only rejects client and admin writes during risky
+-- maintenance while reads keep working.
+
+CREATE TABLE IF NOT EXISTS system_flags (
+    name VARCHAR(50) PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    reason TEXT NOT NULL DEFAULT '',
+    updated_by VARCHAR(255),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO system_flags (name, enabled) VALUES ('read_only', false)
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,3 @@
+-- Reverse 000095: drop skus.disabled_until.
+
+ALTER TABLE skus DROP COLUMN IF EXISTS disabled_until;
@@ -0,0 +1,4 @@
+-- Digiflazz seller SKUs that fail repeatedly are taken out of routing until
+-- disabled_until passes (automatic SKU disabling); NULL means never disabled.
+
+ALTER TABLE skus ADD COLUMN IF NOT EXISTS disabled_until TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+-- Reverse 000096: drop the ordered callback delivery opt-in.
+
+ALTER TABLE clients DROP COLUMN IF EXISTS callback_ordered;
@@ -0,0 +1,5 @@
+-- Opt-in per client: callbacks of one transaction are delivered one at a
+-- time and in the order they were raised (a retry of an older event goes
+-- out before a newer event).
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS callback_ordered BOOLEAN NOT NULL DEFAULT false;
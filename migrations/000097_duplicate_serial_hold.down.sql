@@ -0,0 +1,5 @@
+-- Reverse 000097: drop the duplicate serial number review hold.
+
+DROP INDEX IF EXISTS idx_transactions_review_hold;
+ALTER TABLE transactions DROP COLUMN IF EXISTS review_hold_at;
+ALTER TABLE products DROP COLUMN IF EXISTS hold_duplicate_serial;
@@ -0,0 +1,11 @@
+-- Opt-in per product (token products such as PLN or vouchers): a success
+-- whose serial number a successful transaction of the same product and
+-- provider already holds goes back to Pending and waits for admin review
+-- instead of completing (requires PPOB_SERIAL_NUMBER_CHECK).
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS hold_duplicate_serial BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS review_hold_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_review_hold
+    ON transactions (review_hold_at) WHERE review_hold_at IS NOT NULL;
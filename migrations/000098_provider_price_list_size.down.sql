@@ -0,0 +1,3 @@
+-- Reverse 000098: drop the last price list size.
+
+ALTER TABLE ppob_providers DROP COLUMN IF EXISTS last_price_list_size;
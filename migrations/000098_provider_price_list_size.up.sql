@@ -0,0 +1,4 @@
+-- Item count of the provider price list the last sync applied, so a sync can
+-- tell a truncated price list from a real one before disabling missing SKUs.
+
+ALTER TABLE ppob_providers ADD COLUMN IF NOT EXISTS last_price_list_size INTEGER;
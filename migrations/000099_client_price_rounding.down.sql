@@ -0,0 +1,3 @@
+-- Reverse 000099: drop the per-client price rounding rule.
+
+ALTER TABLE clients DROP COLUMN IF EXISTS price_rounding;
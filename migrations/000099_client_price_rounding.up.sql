@@ -0,0 +1,4 @@
+-- Per-client rounding of prepaid sell prices and listed prices, e.g. 'up:100'
+-- or 'nearest:1000'. NULL uses the global PRICE_ROUNDING default.
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS price_rounding VARCHAR(20);
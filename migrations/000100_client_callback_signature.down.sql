@@ -0,0 +1,6 @@
+-- Reverse 000100: drop the per-client callback signature format.
+
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_callback_signature_encoding_check;
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_callback_signature_algorithm_check;
+ALTER TABLE clients DROP COLUMN IF EXISTS callback_signature_encoding;
+ALTER TABLE clients DROP COLUMN IF EXISTS callback_signature_algorithm;
@@ -0,0 +1,13 @@
+-- Per-client HMAC hash and encoding of the outgoing PPOB callback signature,
+-- for webhook frameworks that expect SHA512 or base64. The defaults keep
+-- X-GTD-Signature: sha256=<hex>.
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS callback_signature_algorithm VARCHAR(10) NOT NULL DEFAULT 'sha256';
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS callback_signature_encoding VARCHAR(10) NOT NULL DEFAULT 'hex';
+
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_callback_signature_algorithm_check;
+ALTER TABLE clients ADD CONSTRAINT clients_callback_signature_algorithm_check
+    CHECK (callback_signature_algorithm IN ('sha256', 'sha512'));
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_callback_signature_encoding_check;
+ALTER TABLE clients ADD CONSTRAINT clients_callback_signature_encoding_check
+    CHECK (callback_signature_encoding IN ('hex', 'base64'));
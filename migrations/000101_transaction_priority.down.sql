@@ -0,0 +1,6 @@
+-- Reverse 000101: drop transaction and client worker priorities.
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_priority_check;
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_transaction_priority_check;
+ALTER TABLE transactions DROP COLUMN IF EXISTS priority;
+ALTER TABLE clients DROP COLUMN IF EXISTS transaction_priority;
@@ -0,0 +1,15 @@
+-- Worker priority of transactions (0-9, higher first). The retry, status
+-- check and callback workers order their queues by priority, then age, so
+-- important transactions are not stuck behind a backlog. The client's
+-- transaction_priority is its tier and the default of its transactions;
+-- 0 everywhere keeps the FIFO-by-age order.
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS transaction_priority SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0;
+
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_transaction_priority_check;
+ALTER TABLE clients ADD CONSTRAINT clients_transaction_priority_check
+    CHECK (transaction_priority BETWEEN 0 AND 9);
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_priority_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_priority_check
+    CHECK (priority BETWEEN 0 AND 9);
@@ -0,0 +1,5 @@
+-- Reverse 000102: drop per-client callback batching.
+
+DROP INDEX IF EXISTS idx_callback_logs_client_undelivered;
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_callback_batch_size_check;
+ALTER TABLE clients DROP COLUMN IF EXISTS callback_batch_size;
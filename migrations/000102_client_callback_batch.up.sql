@@ -0,0 +1,13 @@
+-- Per-client batched PPOB callbacks: up to callback_batch_size events are
+-- delivered as one JSON array under one signature. 0 (default) delivers each
+-- event on its own.
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS callback_batch_size INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_callback_batch_size_check;
+ALTER TABLE clients ADD CONSTRAINT clients_callback_batch_size_check
+    CHECK (callback_batch_size BETWEEN 0 AND 100);
+
+CREATE INDEX IF NOT EXISTS idx_callback_logs_client_undelivered
+    ON callback_logs (client_id, id)
+    WHERE is_delivered = false;
@@ -0,0 +1,4 @@
+-- Reverse 000103: drop the per-client transaction response format.
+
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_response_format_check;
+ALTER TABLE clients DROP COLUMN IF EXISTS response_format;
@@ -0,0 +1,8 @@
+-- Per-client PPOB transaction response shape: envelope (default) nests the
+-- transaction under "data", flat puts its fields beside success/code/message.
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS response_format VARCHAR(10) NOT NULL DEFAULT 'envelope';
+
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_response_format_check;
+ALTER TABLE clients ADD CONSTRAINT clients_response_format_check
+    CHECK (response_format IN ('envelope', 'flat'));
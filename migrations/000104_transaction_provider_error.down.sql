@@ -0,0 +1,3 @@
+-- Reverse 000104: drop the structured provider error.
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS provider_error;
@@ -0,0 +1,5 @@
+-- Structured provider error (code, subcode, message) of a transaction's last
+-- failed provider response, as the provider adapter parsed it. Admin only;
+-- clients keep the canonical failed_code / failed_reason.
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS provider_error JSONB;
@@ -0,0 +1,7 @@
+-- Reverse 000105: drop per-client duplicate transaction detection.
+
+DROP INDEX IF EXISTS idx_transactions_client_customer_created;
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_duplicate_action_check;
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_duplicate_window_seconds_check;
+ALTER TABLE clients DROP COLUMN IF EXISTS duplicate_action;
+ALTER TABLE clients DROP COLUMN IF EXISTS duplicate_window_seconds;
@@ -0,0 +1,18 @@
+-- Per-client detection of likely-duplicate PPOB transactions: same customer,
+-- product and price within duplicate_window_seconds under a new reference_id.
+-- 0 turns it off; duplicate_action warn (default) flags the response, block
+-- rejects the request.
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS duplicate_window_seconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS duplicate_action VARCHAR(10) NOT NULL DEFAULT 'warn';
+
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_duplicate_window_seconds_check;
+ALTER TABLE clients ADD CONSTRAINT clients_duplicate_window_seconds_check
+    CHECK (duplicate_window_seconds BETWEEN 0 AND 86400);
+
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_duplicate_action_check;
+ALTER TABLE clients ADD CONSTRAINT clients_duplicate_action_check
+    CHECK (duplicate_action IN ('warn', 'block'));
+
+CREATE INDEX IF NOT EXISTS idx_transactions_client_customer_created
+    ON transactions (client_id, customer_no, created_at DESC);
@@ -0,0 +1,3 @@
+-- Reverse 000106: drop the inquiry fallback store.
+
+DROP TABLE IF EXISTS inquiry_fallbacks;
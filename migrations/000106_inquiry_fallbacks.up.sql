@@ -0,0 +1,16 @@
+-- Inquiries saved while Redis was unavailable, so postpaid keeps working in
+-- degraded mode (REDIS_OUTAGE_MODE=degraded). data is the inquiry cache JSON;
+-- cache_key is the duplicate-inquiry key, NULL for failed inquiries.
+
+CREATE TABLE IF NOT EXISTS inquiry_fallbacks (
+    transaction_id VARCHAR(50) PRIMARY KEY,
+    cache_key TEXT,
+    data JSONB NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_inquiry_fallbacks_cache_key
+    ON inquiry_fallbacks (cache_key) WHERE cache_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_inquiry_fallbacks_expires_at
+    ON inquiry_fallbacks (expires_at);
@@ -15,6 +15,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -119,17 +120,40 @@ func cloneRawJSON(body []byte) json.RawMessage {
 	return json.RawMessage(raw)
 }
 
-func attachRawResponse(result any, status int, body []byte) {
+func attachRawResponse(result any, status int, header http.Header, body []byte) {
+	retryAfter := parseRetryAfter(header.Get("Retry-After"), time.Now())
 	switch v := result.(type) {
 	case *TransactionResponse:
 		v.RawResponse = cloneRawJSON(body)
 		v.HTTPStatus = status
+		v.RetryAfter = retryAfter
 	case *TransactionDetailResponse:
 		v.RawResponse = cloneRawJSON(body)
 		v.HTTPStatus = status
+		v.RetryAfter = retryAfter
 	}
 }
 
+// parseRetryAfter reads a Retry-After header, either delay-seconds or an
+// HTTP-date, as a delay from now. Missing, malformed or past values give 0.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	at, err := http.ParseTime(v)
+	if err != nil || !at.After(now) {
+		return 0
+	}
+	return at.Sub(now)
+}
+
 // doRequest performs a request with RSA-SHA256 authentication
 func (c *Client) doRequest(ctx context.Context, method, path string, body any, result any) error {
 	url := buildRequestURL(c.config.BaseURL, path)
@@ -204,7 +228,7 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body any, r
 		// (Alterra dummy biller uses HTTP status codes for business errors)
 		if resp.StatusCode < 500 {
 			if err := json.Unmarshal(respBody, result); err == nil {
-				attachRawResponse(result, resp.StatusCode, respBody)
+				attachRawResponse(result, resp.StatusCode, resp.Header, respBody)
 				return nil // Parsed successfully as business response
 			}
 		}
@@ -213,18 +237,27 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body any, r
 			switch v := result.(type) {
 			case *TransactionResponse:
 				v.Error = &errResp.Error
-				attachRawResponse(v, resp.StatusCode, respBody)
+				attachRawResponse(v, resp.StatusCode, resp.Header, respBody)
 				return nil
 			}
 			return fmt.Errorf("api error: %s (code: %s)", errResp.Error.Message, errResp.Error.Code)
 		}
+		// A rate-limited call without a readable body still tells the
+		// router how long to leave this provider alone.
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if v, ok := result.(*TransactionResponse); ok {
+				v.Error = &ErrorDetail{Code: "429", Message: "Too many requests"}
+				attachRawResponse(v, resp.StatusCode, resp.Header, nil)
+				return nil
+			}
+		}
 		return fmt.Errorf("http error: %d", resp.StatusCode)
 	}
 
 	if err := json.Unmarshal(respBody, result); err != nil {
 		return fmt.Errorf("failed to decode response: %w", err)
 	}
-	attachRawResponse(result, resp.StatusCode, respBody)
+	attachRawResponse(result, resp.StatusCode, resp.Header, respBody)
 
 	return nil
 }
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func testPrivateKeyPEM(t *testing.T) string {
@@ -149,3 +150,50 @@ func TestPurchasePreservesErrorEnvelopeOnHTTP403(t *testing.T) {
 		t.Fatalf("RawResponse = %q, want %q", got, rawBody)
 	}
 }
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 4, 10, 8, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"30":                            30 * time.Second,
+		" 5 ":                           5 * time.Second,
+		"0":                             0,
+		"-3":                            0,
+		"soon":                          0,
+		"Fri, 10 Apr 2026 08:01:30 GMT": 90 * time.Second,
+		"Fri, 10 Apr 2026 07:59:00 GMT": 0,
+	}
+	for in, want := range tests {
+		if got := parseRetryAfter(in, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestPurchaseRateLimitedCarriesRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "12")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, "slow down")
+	}))
+	defer server.Close()
+
+	client := testClient(t, server.URL)
+	resp, err := client.Purchase(context.Background(), "08123456789", 27, "ORD-429", nil)
+	if err != nil {
+		t.Fatalf("Purchase() error = %v", err)
+	}
+	if resp.HTTPStatus != http.StatusTooManyRequests {
+		t.Fatalf("HTTPStatus = %d, want %d", resp.HTTPStatus, http.StatusTooManyRequests)
+	}
+	if resp.RetryAfter != 12*time.Second {
+		t.Fatalf("RetryAfter = %v, want 12s", resp.RetryAfter)
+	}
+	if resp.Error == nil || resp.Error.Code != "429" {
+		t.Fatalf("Error = %#v, want code 429", resp.Error)
+	}
+}
@@ -3,6 +3,7 @@ package alterra
 import (
 	"encoding/json"
 	"strings"
+	"time"
 )
 
 // FlexBool handles JSON booleans that may come as strings ("true"/"false")
@@ -141,6 +142,7 @@ type TransactionResponse struct {
 	Error         *ErrorDetail       `json:"error"`
 	RawResponse   json.RawMessage    `json:"-"`
 	HTTPStatus    int                `json:"-"`
+	RetryAfter    time.Duration      `json:"-"` // Retry-After header on a rate-limited (429/503) response
 }
 
 // TransactionDetailResponse represents transaction detail response
@@ -163,6 +165,7 @@ type TransactionDetailResponse struct {
 	Data          *TransactionData   `json:"data"`
 	RawResponse   json.RawMessage    `json:"-"`
 	HTTPStatus    int                `json:"-"`
+	RetryAfter    time.Duration      `json:"-"` // Retry-After header on a rate-limited (429/503) response
 }
 
 // CallbackPayload represents callback from Alterra
@@ -172,6 +172,16 @@ func (c *Client) SNAPAvailable() bool {
 		strings.TrimSpace(c.cfg.ChannelID) != ""
 }
 
+// VerifyBRIZZICredentials requests a fresh BRIZZI OAuth token, checking the
+// base URL, client ID and client secret without any card or money movement.
+func (c *Client) VerifyBRIZZICredentials(ctx context.Context) error {
+	c.mu.Lock()
+	c.oauthAccessToken = ""
+	c.mu.Unlock()
+	_, err := c.getOAuthToken(ctx)
+	return err
+}
+
 func (c *Client) InternalAccountInquiry(ctx context.Context, accountNo string) (*bnc.AccountInquiryResponse, error) {
 	body := map[string]string{
 		"beneficiaryAccountNo": strings.TrimSpace(accountNo),
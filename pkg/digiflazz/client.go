@@ -10,6 +10,8 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -174,5 +176,28 @@ func (c *Client) doRequest(ctx context.Context, endpoint string, body any, resul
 	if err := json.Unmarshal(respBody, result); err != nil {
 		return fmt.Errorf("failed to decode response: %w", err)
 	}
+	if w, ok := result.(*TransactionResponseWrapper); ok {
+		w.Data.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
 	return nil
 }
+
+// parseRetryAfter reads a Retry-After header, either delay-seconds or an
+// HTTP-date, as a delay from now. Missing, malformed or past values give 0.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	at, err := http.ParseTime(v)
+	if err != nil || !at.After(now) {
+		return 0
+	}
+	return at.Sub(now)
+}
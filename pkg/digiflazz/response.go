@@ -1,6 +1,9 @@
 package digiflazz
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // TransactionResponseWrapper wraps the transaction response from Digiflazz.
 // Digiflazz always wraps the response in a "data" field.
@@ -27,6 +30,9 @@ type TransactionResponse struct {
 	Admin        int             `json:"admin,omitempty"`
 	Selling      int             `json:"selling_price,omitempty"`
 	Desc         json.RawMessage `json:"desc,omitempty"`
+
+	// RetryAfter is the Retry-After header of the HTTP response, if any.
+	RetryAfter time.Duration `json:"-"`
 }
 
 // PricelistResponse represents the pricelist payload.
@@ -298,6 +298,17 @@ func (c *Client) clearSession() {
 	c.sessionExp = time.Time{}
 }
 
+// RefreshSession signs on again and replaces the cached session, so bad
+// credentials or an unreachable endpoint show up now rather than on the next
+// transaction.
+func (c *Client) RefreshSession(ctx context.Context) error {
+	c.sessionMu.Lock()
+	c.clearSession()
+	c.sessionMu.Unlock()
+	_, err := c.ensureSession(ctx)
+	return err
+}
+
 // ensureSession ensures we have a valid session
 func (c *Client) ensureSession(ctx context.Context) (string, error) {
 	c.sessionMu.RLock()